@@ -0,0 +1,10 @@
+// Package repository benchmarks how the user repository reaches PostgreSQL:
+// the lib/pq and pgx drivers, prepared and unprepared statements, offset and
+// keyset pagination, and single and batched inserts.
+//
+// The benchmarks run against the database at BENCH_DATABASE_URL and are
+// skipped without it. They migrate it, replace the users whose emails start
+// with bench- and leave other rows alone. scripts/bench.sh starts a
+// throwaway Postgres container, runs them and summarizes the results with
+// `go run ./cmd/bench report`.
+package repository
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// insertBatchSize is the number of users each benchmark operation inserts
+const insertBatchSize = 100
+
+// insertUser is the statement inserting one user
+const insertUser = `INSERT INTO users (name, email, age) VALUES ($1, $2, $3)`
+
+// BenchmarkInsert compares inserting a batch of users one statement at a
+// time, one statement at a time within a transaction, and as one multi-row
+// statement. Each operation inserts insertBatchSize users.
+func BenchmarkInsert(b *testing.B) {
+	strategies := []struct {
+		name   string
+		insert func(ctx context.Context, db *sql.DB, emails []string) error
+	}{
+		{"single", insertSingle},
+		{"transaction", insertInTransaction},
+		{"multirow", insertMultiRow},
+	}
+
+	group := fmt.Sprintf("rows=%d", insertBatchSize)
+	for _, strategy := range strategies {
+		// Counts batches across the runs b.Run makes, keeping emails unique
+		batch := 0
+		b.Run(group+"/"+strategy.name, func(b *testing.B) {
+			db := open(b, driverPGX)
+			ctx := context.Background()
+			b.Cleanup(func() {
+				db.Exec(`DELETE FROM users WHERE email LIKE 'bench-insert-%'`)
+			})
+
+			emails := make([]string, insertBatchSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				batch++
+				for j := range emails {
+					emails[j] = fmt.Sprintf("bench-insert-%s-%d-%d@example.com", strategy.name, batch, j)
+				}
+				if err := strategy.insert(ctx, db, emails); err != nil {
+					b.Fatalf("failed to insert users: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// insertSingle inserts each user in a statement and transaction of its own
+func insertSingle(ctx context.Context, db *sql.DB, emails []string) error {
+	for _, email := range emails {
+		if _, err := db.ExecContext(ctx, insertUser, "Bench User", email, 30); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertInTransaction inserts each user in a statement of its own, all in
+// one transaction
+func insertInTransaction(ctx context.Context, db *sql.DB, emails []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, email := range emails {
+		if _, err := tx.ExecContext(ctx, insertUser, "Bench User", email, 30); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// insertMultiRow inserts every user in one statement
+func insertMultiRow(ctx context.Context, db *sql.DB, emails []string) error {
+	values := make([]string, len(emails))
+	args := make([]interface{}, 0, 3*len(emails))
+	for i, email := range emails {
+		values[i] = fmt.Sprintf("($%d, $%d, $%d)", 3*i+1, 3*i+2, 3*i+3)
+		args = append(args, "Bench User", email, 30)
+	}
+	_, err := db.ExecContext(ctx, `INSERT INTO users (name, email, age) VALUES `+strings.Join(values, ", "), args...)
+	return err
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/models"
+	userrepo "github.com/pratham15541/go-crud/internal/repository"
+)
+
+// pageSize is the number of users each page holds
+const pageSize = 50
+
+// BenchmarkPagination compares reading a page of users at increasing depths
+// with OFFSET, which scans past every earlier row, and with a keyset
+// cursor, which seeks to the page through the (created_at, id) index
+func BenchmarkPagination(b *testing.B) {
+	for _, depth := range []int{0, 1000, 10000} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			db := open(b, driverPGX)
+			repo := userrepo.NewUserRepository(db, userrepo.UserSchema{})
+			ctx := context.Background()
+
+			// The cursor of the last user before the page
+			var cursor *models.UserCursor
+			if depth > 0 {
+				before, err := repo.GetAll(ctx, nil, 1, depth-1)
+				if err != nil || len(before) == 0 {
+					b.Fatalf("failed to find the user before depth %d: %v", depth, err)
+				}
+				cursor = &models.UserCursor{CreatedAt: before[0].CreatedAt, ID: before[0].ID}
+			}
+
+			b.Run("offset", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.GetAll(ctx, nil, pageSize, depth); err != nil {
+						b.Fatalf("failed to get page: %v", err)
+					}
+				}
+			})
+			b.Run("keyset", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.GetAfter(ctx, nil, cursor, pageSize); err != nil {
+						b.Fatalf("failed to get page: %v", err)
+					}
+				}
+			})
+		})
+	}
+}
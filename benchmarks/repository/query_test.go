@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// getUserQuery is the lookup GetByID runs, with the age column of the old
+// schema phase
+const getUserQuery = `
+	SELECT id, name, email, age, role, created_at, updated_at, legal_hold, version
+	FROM users
+	WHERE id = $1 AND deleted_at IS NULL
+`
+
+// BenchmarkGetByID compares the drivers looking users up by ID, with the
+// statement prepared once or sent with every query
+func BenchmarkGetByID(b *testing.B) {
+	for _, driver := range []string{driverPQ, driverPGX} {
+		for _, prepared := range []bool{false, true} {
+			name := driver + "_unprepared"
+			if prepared {
+				name = driver + "_prepared"
+			}
+			b.Run(name, func(b *testing.B) {
+				db := open(b, driver)
+				ids := seededIDs(b, db)
+				ctx := context.Background()
+
+				query := db.QueryRowContext
+				if prepared {
+					stmt, err := db.PrepareContext(ctx, getUserQuery)
+					if err != nil {
+						b.Fatalf("failed to prepare: %v", err)
+					}
+					defer stmt.Close()
+					query = func(ctx context.Context, _ string, args ...interface{}) *sql.Row {
+						return stmt.QueryRowContext(ctx, args...)
+					}
+				}
+
+				random := rand.New(rand.NewSource(1))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					var (
+						id, age, version  int
+						name, email, role string
+						created, updated  time.Time
+						legalHold         bool
+					)
+					err := query(ctx, getUserQuery, ids[random.Intn(len(ids))]).Scan(
+						&id, &name, &email, &age, &role, &created, &updated, &legalHold, &version)
+					if err != nil {
+						b.Fatalf("failed to get user: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/lib/pq"
+	"github.com/pratham15541/go-crud/internal/database"
+)
+
+// seedUsers is how many users the benchmarks read from
+const seedUsers = 20000
+
+// seedBatchSize is how many users one seeding statement inserts
+const seedBatchSize = 1000
+
+// Drivers compared. pgx connections use the exec query mode, which like
+// lib/pq sends each unprepared query as an unnamed statement instead of
+// caching a prepared one, so unprepared means the same for both.
+const (
+	driverPQ  = "pq"
+	driverPGX = "pgx"
+)
+
+var (
+	seedOnce sync.Once
+	seedErr  error
+)
+
+// open connects to BENCH_DATABASE_URL with driver, migrating and seeding
+// the database on first use, and skips b when the variable is not set
+func open(b *testing.B, driver string) *sql.DB {
+	b.Helper()
+	url := os.Getenv("BENCH_DATABASE_URL")
+	if url == "" {
+		b.Skip("BENCH_DATABASE_URL is not set")
+	}
+
+	var db *sql.DB
+	var err error
+	switch driver {
+	case driverPQ:
+		db, err = sql.Open("postgres", url)
+	case driverPGX:
+		separator := "?"
+		if strings.Contains(url, "?") {
+			separator = "&"
+		}
+		db, err = sql.Open("pgx", url+separator+"default_query_exec_mode=exec")
+	default:
+		b.Fatalf("unknown driver %q", driver)
+	}
+	if err != nil {
+		b.Fatalf("failed to open %s connection: %v", driver, err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	seedOnce.Do(func() { seedErr = seed(db) })
+	if seedErr != nil {
+		b.Fatalf("failed to prepare benchmark database: %v", seedErr)
+	}
+	return db
+}
+
+// seed migrates db and replaces the benchmark users with seedUsers new
+// ones, one second apart so pagination order is unambiguous
+func seed(db *sql.DB) error {
+	migrator, err := database.NewMigrator(database.DriverPostgres)
+	if err != nil {
+		return err
+	}
+	if err := migrator.RunMigrations(db); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`DELETE FROM users WHERE email LIKE 'bench-%'`); err != nil {
+		return err
+	}
+
+	for start := 0; start < seedUsers; start += seedBatchSize {
+		_, err := db.Exec(`
+			INSERT INTO users (name, email, age, created_at)
+			SELECT 'Bench User ' || i, 'bench-seed-' || i || '@example.com', 18 + i % 60,
+				NOW() - i * INTERVAL '1 second'
+			FROM generate_series($1::int, $2::int) AS i
+		`, start, start+seedBatchSize-1)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = db.Exec(`ANALYZE users`)
+	return err
+}
+
+// seededIDs returns the IDs of the seeded users
+func seededIDs(b *testing.B, db *sql.DB) []int {
+	b.Helper()
+	rows, err := db.QueryContext(context.Background(), `SELECT id FROM users WHERE email LIKE 'bench-seed-%' ORDER BY id`)
+	if err != nil {
+		b.Fatalf("failed to list seeded users: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			b.Fatalf("failed to scan seeded user: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		b.Fatalf("failed to list seeded users: %v", err)
+	}
+	if len(ids) == 0 {
+		b.Fatal("no seeded users")
+	}
+	return ids
+}
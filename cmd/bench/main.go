@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pratham15541/go-crud/internal/benchreport"
+)
+
+const usage = `Usage: bench <command>
+
+Commands:
+  report [FILE]   Summarize the output of go test -bench read from FILE, or
+                  from standard input, comparing the variants of each
+                  benchmark with the first
+
+scripts/bench.sh runs the repository benchmarks against a Postgres container
+and reports on them.
+`
+
+// bench works with the results of the benchmarks under benchmarks/
+func main() {
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "bench %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+// run runs command with args
+func run(command string, args []string) error {
+	switch command {
+	case "report":
+		var in io.Reader = os.Stdin
+		if len(args) > 0 {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			in = file
+		}
+
+		results, err := benchreport.Parse(in)
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return fmt.Errorf("no benchmark results found")
+		}
+		return benchreport.Write(os.Stdout, results)
+	default:
+		return fmt.Errorf("unknown command, want report")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/database"
+)
+
+const usage = `Usage: migrate [flags] <command>
+
+Commands:
+  up              Apply every pending migration
+  down [N]        Roll back the newest N migrations (default 1)
+  status          List migrations and when each was applied
+  force VERSION   Record VERSION as the schema version without running any
+                  migration, after repairing the schema by hand
+
+The database is configured by the same config file, environment variables
+and -set flags as the server.
+
+Flags:
+`
+
+// migrate changes the database schema separately from server startup
+func main() {
+	sources := config.BindFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load environment variables; a missing .env file is fine
+	_ = godotenv.Load()
+	cfg, err := config.LoadFrom(*sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+	migrator, err := database.NewMigrator(cfg.Database.Driver)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid DB_DRIVER: %v\n", err)
+		os.Exit(2)
+	}
+
+	db, err := database.NewConnection(cfg.Database, cfg.Database.Migrations, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	err = migrator.RunCommand(db, os.Stdout, flag.Arg(0), flag.Args()[1:])
+	db.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
@@ -2,22 +2,50 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
 	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
+	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/joho/godotenv"
+	"github.com/pratham15541/go-crud/internal/cache"
 	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/consistency"
 	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/pratham15541/go-crud/internal/events"
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/grpcapi"
 	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/mailer"
+	"github.com/pratham15541/go-crud/internal/metrics"
 	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/priority"
+	"github.com/pratham15541/go-crud/internal/ratelimit"
+	"github.com/pratham15541/go-crud/internal/realtime"
 	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/pratham15541/go-crud/internal/router"
 	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/pratham15541/go-crud/internal/startup"
+	"github.com/pratham15541/go-crud/internal/support"
+	"github.com/pratham15541/go-crud/internal/tracing"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 // @title Go CRUD API
@@ -29,80 +57,759 @@ import (
 // @license.url https://opensource.org/licenses/MIT
 // @host localhost:8080
 // @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description A JWT or personal access token as "Bearer <token>"
 func main() {
+	sources := config.BindFlags(flag.CommandLine)
+	migrateCommand := flag.String("migrate", "", "run a migrate command (up, down [N], status or force VERSION) and exit instead of serving")
+	flag.Parse()
+
 	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
+	envErr := godotenv.Load()
+
+	// Load configuration: -set flags override the environment, which
+	// overrides the config file
+	cfg, err := config.LoadFrom(*sources)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Initialize logging; the standard logger is redirected so stray log
+	// calls still end up in the structured output
+	appLogger, err := logger.New(cfg.Logging)
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	defer appLogger.Sync()
+	if cfg.Server.Region != "" {
+		appLogger = appLogger.With(zap.String("region", cfg.Server.Region))
+	}
+	// Keep the latest entries for support bundles
+	var recentLogs *logger.Recent
+	if cfg.Logging.RecentEntries > 0 {
+		recentLogs = logger.NewRecent(cfg.Logging.RecentEntries)
+		appLogger = appLogger.WithOptions(recentLogs.Tee(appLogger.Level()))
+	}
+	zap.ReplaceGlobals(appLogger)
+	zap.RedirectStdLog(appLogger)
+	if envErr != nil {
+		appLogger.Warn(".env file not found, using system environment variables")
+	}
+
+	// -migrate changes the schema without starting the server, as the
+	// migrate command does
+	if *migrateCommand != "" {
+		if err := runMigrateCommand(cfg, *migrateCommand, flag.Args()); err != nil {
+			appLogger.Fatal("migration failed", zap.String("command", *migrateCommand), zap.Error(err))
+		}
+		return
+	}
+
+	// Report every problem with the settings before acting on any of them
+	var invalid *config.ValidationError
+	if err := cfg.Validate(); errors.As(err, &invalid) {
+		appLogger.Fatal("invalid configuration", zap.Strings("problems", invalid.Problems))
+	}
+	logSampler, err := logger.NewSampler(cfg.Logging.Sampling)
+	if err != nil {
+		appLogger.Fatal("invalid LOG_SAMPLING", zap.Error(err))
+	}
+
+	// Select how user IDs appear in the API before routes are registered
+	codec, err := idcodec.New(cfg.IDs.Codec, cfg.IDs.Alphabet, cfg.IDs.MinLength)
+	if err != nil {
+		appLogger.Fatal("invalid ID codec configuration", zap.Error(err))
+	}
+
+	// Select which of the age columns user queries write and read
+	agePhase, err := models.ParseTransitionPhase(cfg.Schema.AgePhase)
+	if err != nil {
+		appLogger.Fatal("invalid AGE_TRANSITION_PHASE", zap.Error(err))
+	}
+
+	// Select the database users are stored in. Only Postgres has the tables
+	// of the other features, so with MySQL or SQLite they are refused or
+	// left off.
+	migrator, err := database.NewMigrator(cfg.Database.Driver)
+	if err != nil {
+		appLogger.Fatal("invalid DB_DRIVER", zap.Error(err))
+	}
+	userSchema := repository.UserSchema{Dialect: repository.Dialect(cfg.Database.Driver), AgePhase: agePhase}
+	postgres := cfg.Database.Driver == database.DriverPostgres
+
+	// Trace requests and SQL statements
+	var tracer trace.Tracer
+	if cfg.Tracing.Enabled {
+		provider, err := tracing.New(context.Background(), cfg.Tracing, cfg.Server.Region)
+		if err != nil {
+			appLogger.Fatal("failed to initialize tracing", zap.Error(err))
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := provider.Shutdown(ctx); err != nil {
+				appLogger.Warn("failed to flush traces", zap.Error(err))
+			}
+		}()
+		tracer = provider.Tracer("github.com/pratham15541/go-crud")
+	}
+
+	// Collect request, connection pool, per-statement and cache metrics. The
+	// observers stay nil interfaces when metrics are off.
+	var appMetrics *metrics.Metrics
+	var queryObserver database.QueryObserver
+	var cacheObserver repository.CacheObserver
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.NewForRegion(cfg.Server.Region)
+		queryObserver = appMetrics
+		cacheObserver = appMetrics
 	}
 
-	// Load configuration
-	cfg := config.Load()
+	// /readyz fails until the schema is at the version this binary needs
+	readiness := health.NewReadiness()
+	if cfg.Database.ReadyRequiresMigrations {
+		readiness.SetPending("migrations", fmt.Sprintf("waiting for schema version %d", migrator.SchemaVersion()))
+	}
+
+	switch cfg.Database.MigrationMode {
+	case database.MigrationModeRun:
+		// Run migrations on a pool of their own, closed once they are done
+		migrationsDB, err := database.NewConnection(cfg.Database, cfg.Database.Migrations, queryObserver)
+		if err != nil {
+			appLogger.Fatal("failed to connect to database", zap.Error(err))
+		}
+		if err := migrator.RunMigrations(migrationsDB); err != nil {
+			appLogger.Fatal("failed to run migrations", zap.Error(err))
+		}
+		migrationsDB.Close()
+		readiness.SetReady("migrations")
+		appLogger.Info("database migrations completed")
+	case database.MigrationModeWait:
+		// Checked once the API pool is open
+	}
 
-	// Initialize database
-	db, err := database.NewConnection(cfg.Database)
+	// Requests and background jobs use separate pools, so a busy job cannot
+	// take the connections requests need
+	db, err := database.NewConnection(cfg.Database, cfg.Database.API, queryObserver)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		appLogger.Fatal("failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
+	jobsDB, err := database.NewConnection(cfg.Database, cfg.Database.Jobs, queryObserver)
+	if err != nil {
+		appLogger.Fatal("failed to connect to database for background jobs", zap.Error(err))
+	}
+	defer jobsDB.Close()
+	appLogger.Info("database connection established")
 
-	// Run migrations
-	if err := database.RunMigrations(db); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	// Connect to the read replica and start monitoring database health
+	var replica *sql.DB
+	var replicaPinger health.Pinger
+	if cfg.Database.ReplicaHost != "" {
+		// Sized like the API pool, whose reads it takes over
+		replicaPool := cfg.Database.API
+		replicaPool.Name = "replica"
+		replica, err = database.NewConnection(cfg.Database.Replica(), replicaPool, queryObserver)
+		if err != nil {
+			appLogger.Warn("read replica unavailable, read-only mode disabled", zap.Error(err))
+			replica = nil
+		} else {
+			defer replica.Close()
+			replicaPinger = replica
+		}
 	}
+	monitor := health.NewMonitor(db, replicaPinger, cfg.Database.HealthCheckInterval)
+	// Background jobs log through the logger carried by their context
+	backgroundCtx, stopBackground := context.WithCancel(logger.WithContext(context.Background(), appLogger))
+	defer stopBackground()
+	go monitor.Run(backgroundCtx)
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
+	// Act on memory pressure before the OOM killer does. MEMORY_LIMIT_BYTES
+	// also becomes the garbage collector's soft limit unless GOMEMLIMIT is set.
+	var memoryWatchdog *health.MemoryWatchdog
+	if limit := health.MemoryLimit(cfg.Memory.Limit); limit > 0 {
+		if os.Getenv("GOMEMLIMIT") == "" {
+			debug.SetMemoryLimit(int64(limit))
+		}
+		memoryWatchdog = health.NewMemoryWatchdog(limit, cfg.Memory.Threshold, readiness)
+		memoryWatchdog.ProfileDir = cfg.Memory.ProfileDir
+		go memoryWatchdog.Run(backgroundCtx, cfg.Memory.CheckInterval)
+	}
+
+	// Another instance applies the migrations; serve once it is done
+	if cfg.Database.MigrationMode == database.MigrationModeWait {
+		go func() {
+			if err := migrator.WaitForMigrations(backgroundCtx, db, cfg.Database.MigrationPollInterval); err != nil {
+				return
+			}
+			readiness.SetReady("migrations")
+			appLogger.Info("database migrations applied by another instance")
+		}()
+	}
+
+	// Export connection pool statistics
+	if appMetrics != nil {
+		if err := appMetrics.RegisterDB("api", db); err != nil {
+			appLogger.Fatal("failed to register database metrics", zap.Error(err))
+		}
+		if err := appMetrics.RegisterDB("jobs", jobsDB); err != nil {
+			appLogger.Fatal("failed to register job database metrics", zap.Error(err))
+		}
+		if replica != nil {
+			if err := appMetrics.RegisterDB("replica", replica); err != nil {
+				appLogger.Fatal("failed to register replica metrics", zap.Error(err))
+			}
+		}
+	}
+
+	// Page sizes of list endpoints, also reported by /_meta
+	pagination, err := services.NewPagination(cfg.Paging)
+	if err != nil {
+		appLogger.Fatal("invalid pagination configuration", zap.Error(err))
+	}
+
+	// Connect to Redis; an unreachable server is tolerated so the API keeps
+	// working with per-instance state
+	var redisClient *redis.Client
+	if cfg.Redis.URL != "" {
+		opts, err := redis.ParseURL(cfg.Redis.URL)
+		if err != nil {
+			appLogger.Fatal("invalid REDIS_URL", zap.Error(err))
+		}
+		redisClient = redis.NewClient(opts)
+		defer redisClient.Close()
+		if err := redisClient.Ping(backgroundCtx).Err(); err != nil {
+			appLogger.Warn("redis unavailable at startup", zap.Error(err))
+		}
+	}
+
+	// Initialize the cache selected by CACHE_DRIVER
+	var appCache cache.Cache
+	switch cfg.Cache.Driver {
+	case cache.DriverMemory:
+		memoryCache, err := cache.NewMemory(cfg.Cache.MemoryMaxBytes)
+		if err != nil {
+			appLogger.Fatal("failed to initialize cache", zap.Error(err))
+		}
+		defer memoryCache.Close()
+		appCache = memoryCache
+	case cache.DriverRedis:
+		appCache = cache.NewRedis(redisClient)
+	case cache.DriverMemcached:
+		appCache = cache.NewMemcached(memcache.New(cfg.Cache.MemcachedServers...))
+	}
+
+	// Initialize repositories. Reads go to the replica while the primary is
+	// down, and with follower reads whenever the replica is up.
+	useReplica := monitor.ReadOnly
+	if cfg.Database.FollowerReads {
+		if replica == nil {
+			appLogger.Warn("DB_FOLLOWER_READS needs a reachable DB_REPLICA_HOST, reading from the primary")
+		}
+		useReplica = monitor.ReplicaHealthy
+	}
+	// With CACHE_USERS, users found by ID or email are cached in Redis, and
+	// with CACHE_MEMORY_USERS in memory in front of it. Background jobs drop
+	// the entries of users they change too.
+	userRepo := repository.NewUserRepositoryWithReplica(db, replica, useReplica, userSchema)
+	jobUserRepo := repository.NewUserRepository(jobsDB, userSchema)
+	var userCache cache.Cache
+	var userLRU *repository.UserLRU
+	if cfg.Cache.Users {
+		userCache = cache.NewRedis(redisClient)
+		userRepo = repository.NewCachedUserRepository(userRepo, userCache, cfg.Cache.UserTTL, cacheObserver)
+		jobUserRepo = repository.NewCachedUserRepository(jobUserRepo, userCache, cfg.Cache.UserTTL, cacheObserver)
+	}
+	if cfg.Cache.MemoryUsers {
+		userLRU = repository.NewUserLRU(cfg.Cache.MemoryUserEntries, cfg.Cache.UserTTL)
+		userRepo = repository.NewLRUUserRepository(userRepo, userLRU, cacheObserver)
+		jobUserRepo = repository.NewLRUUserRepository(jobUserRepo, userLRU, cacheObserver)
+	}
+	if cfg.Cache.MissingUserTTL > 0 {
+		// Users that jobs create drop the marks requests left, as they
+		// share appCache
+		userRepo = repository.NewNegativeCacheUserRepository(userRepo, appCache, cfg.Cache.MissingUserTTL)
+		jobUserRepo = repository.NewNegativeCacheUserRepository(jobUserRepo, appCache, cfg.Cache.MissingUserTTL)
+	}
 
 	// Initialize services
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, pagination, codec)
+	jobUserService := services.NewUserService(jobUserRepo, pagination, codec)
+	authService := services.NewAuthService(userRepo, cfg.JWT, codec)
+	claimsLoader := services.NewUserClaimsLoader(userRepo, appCache, cfg.JWT.ClaimsCacheTTL, cfg.JWT.ClaimsCacheStale)
+
+	// Personal access tokens, settings and background operations keep
+	// tables of their own
+	var tokenService *services.PersonalTokenService
+	var tokenHandler *handlers.TokenHandler
+	var settingsHandler *handlers.SettingsHandler
+	var operationService *services.OperationService
+	var operationHandler *handlers.OperationHandler
+	if postgres {
+		tokenService = services.NewPersonalTokenService(repository.NewPersonalTokenRepository(db), cfg.Tokens, appLogger)
+		tokenHandler = handlers.NewTokenHandler(tokenService)
+		settingsService := services.NewSettingsService(repository.NewSettingsRepository(db), userRepo, services.UserSettingsSchema)
+		settingsHandler = handlers.NewSettingsHandler(settingsService, codec)
+
+		// Operations cannot resume after a restart
+		operationRepo := repository.NewOperationRepository(db)
+		operationService = services.NewOperationService(operationRepo, appLogger)
+		operationHandler = handlers.NewOperationHandler(operationService, userService, jobUserService, codec, router.APIPrefix(cfg))
+		if n, err := operationRepo.FailInterrupted(backgroundCtx); err != nil {
+			appLogger.Warn("failed to clean up interrupted operations", zap.Error(err))
+		} else if n > 0 {
+			appLogger.Info("marked interrupted operations as failed", zap.Int64("count", n))
+		}
+	}
+
+	// With Postgres, JWT keys can be rotated; the keys are shared by every
+	// instance and picked up by the others on their next refresh
+	var jwtKeys *services.JWTKeyring
+	jwtKeyfunc := middleware.HMACKey(cfg.JWT.Secret)
+	if postgres {
+		jwtKeyRepo := repository.NewJWTKeyRepository(db)
+		if cfg.JWT.KeyEncryptionKey != "" {
+			if jwtKeyRepo, err = repository.NewEncryptedJWTKeyRepository(jwtKeyRepo, cfg.JWT.KeyEncryptionKey); err != nil {
+				appLogger.Fatal("failed to set up jwt key encryption", zap.Error(err))
+			}
+		} else if cfg.Server.Mode != "debug" {
+			appLogger.Warn("JWT_KEY_ENCRYPTION_KEY is not set, rotated jwt keys are stored in plaintext")
+		}
+		jwtKeys = services.NewJWTKeyring(jwtKeyRepo, cfg.JWT)
+		if err := jwtKeys.Refresh(backgroundCtx); err != nil {
+			appLogger.Warn("failed to load jwt keys", zap.Error(err))
+		}
+		go jwtKeys.Run(backgroundCtx, cfg.JWT.KeyRefreshInterval)
+		authService.SetSigner(jwtKeys)
+		jwtKeyfunc = jwtKeys.Keyfunc
+	}
+	introspectionService := services.NewIntrospectionService(jwtKeyfunc, tokenService, claimsLoader)
+
+	// Initialize the audit log. Background jobs record their changes in it
+	// within the transaction that makes them.
+	var auditService *services.AuditService
+	var auditHandler *handlers.AuditHandler
+	if cfg.Audit.Enabled {
+		auditService = services.NewAuditService(repository.NewAuditRepository(db), pagination)
+		auditHandler = handlers.NewAuditHandler(auditService)
+		jobUserService.SetAuditLog(repository.NewTxManager(jobsDB), auditService)
+	}
+
+	// Permanently remove users once they can no longer be restored
+	if cfg.Database.SoftDeleteRetention > 0 {
+		go jobUserService.RunPurge(backgroundCtx, cfg.Database.SoftDeleteRetention, cfg.Database.PurgeInterval)
+	}
+
+	// Fill new columns of schema transitions for rows written before
+	// dual-writes began. The MySQL schema starts with both age columns.
+	var transitionHandler *handlers.TransitionHandler
+	if postgres {
+		transitionService := services.NewTransitionService(repository.NewAgeTransition(jobsDB, userSchema))
+		go transitionService.RunBackfill(backgroundCtx, cfg.Schema.BackfillBatchSize, cfg.Schema.BackfillPause)
+		transitionHandler = handlers.NewTransitionHandler(transitionService, codec)
+	}
 
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userService)
-	healthHandler := handlers.NewHealthHandler(db)
+	userHandler := handlers.NewUserHandler(userService, codec)
+	authHandler := handlers.NewAuthHandler(authService)
+	healthHandler := handlers.NewHealthHandler(db, monitor)
+	healthHandler.SetRegion(cfg.Server.Region)
+	readinessHandler := handlers.NewReadinessHandler(readiness)
+	introspectionHandler := handlers.NewIntrospectionHandler(introspectionService)
+	changelogHandler := handlers.NewChangelogHandler()
+	metaHandler := handlers.NewMetaHandler(pagination)
 
-	// Setup router
-	router := mux.NewRouter()
+	// Preload the cache for the users likely to return first after a deploy
+	cacheWarmer := services.NewCacheWarmer(userRepo, claimsLoader)
+	cacheHandler := handlers.NewCacheHandler(cacheWarmer, cfg.Cache.WarmUsers)
+
+	// Diagnostics for support tickets
+	supportBundler := support.NewBundler(cfg, migrator, db, map[string]*sql.DB{
+		"api":     db,
+		"jobs":    jobsDB,
+		"replica": replica,
+	}, monitor, recentLogs)
+	supportHandler := handlers.NewSupportHandler(supportBundler)
+	if cfg.Cache.WarmOnStart {
+		go func() {
+			result, err := cacheWarmer.Warm(backgroundCtx, cfg.Cache.WarmUsers)
+			if err != nil {
+				appLogger.Warn("failed to warm cache", zap.Error(err))
+				return
+			}
+			appLogger.Info("cache warmed", zap.Int("users", result.Users), zap.Float64("duration_ms", result.DurationMs))
+		}()
+	}
+
+	// Summarize recent traffic for admins from the metrics registry
+	var dashboardHandler *handlers.DashboardHandler
+	if appMetrics != nil {
+		dashboard := metrics.NewDashboard(appMetrics, cfg.Metrics.DashboardWindow)
+		go dashboard.Run(backgroundCtx)
+		dashboardHandler = handlers.NewDashboardHandler(dashboard)
+	}
+
+	// Deliver queued emails from the outbox. Registration queues a welcome
+	// email in the transaction that creates the user, and admins see whether
+	// a user's address bounced.
+	var emailService *services.EmailService
+	var emailHandler *handlers.EmailHandler
+	if cfg.Email.Enabled {
+		var sender mailer.Sender = mailer.NewLog(appLogger)
+		if cfg.Email.SMTPHost != "" {
+			sender = mailer.NewSMTP(cfg.Email.SMTPHost, cfg.Email.SMTPPort, cfg.Email.SMTPUsername, cfg.Email.SMTPPassword)
+		}
+		emailService = services.NewEmailService(repository.NewEmailRepository(db), sender, cfg.Email, pagination)
+		jobEmailService := services.NewEmailService(repository.NewEmailRepository(jobsDB), sender, cfg.Email, pagination)
+		go jobEmailService.RunDelivery(backgroundCtx, cfg.Email.PollInterval)
+		authService.SetWelcomeEmail(repository.NewTxManager(db), emailService)
+		emailHandler = handlers.NewEmailHandler(emailService)
+		userHandler.SetDeliverability(emailService)
+	}
+
+	// Hold self-registrations for an admin's decision, emailing the user when
+	// it is made. Accounts queued while approval was required stay unable to
+	// sign in until decided, even after the mode changes, so the queue is
+	// checked whenever its table exists.
+	var approvalHandler *handlers.ApprovalHandler
+	if postgres {
+		approvalRepo := repository.NewApprovalRepository(db)
+		approvalService := services.NewApprovalService(approvalRepo, pagination)
+		if emailService != nil {
+			approvalService.SetNotifications(repository.NewTxManager(db), emailService)
+		}
+		authService.SetRegistration(cfg.Auth.Registration, repository.NewTxManager(db), approvalRepo)
+		approvalHandler = handlers.NewApprovalHandler(approvalService, codec)
+	} else {
+		authService.SetRegistration(cfg.Auth.Registration, nil, nil)
+	}
+
+	// Notify webhook subscribers of user lifecycle events. Background jobs
+	// publish to the same workers as requests.
+	var webhookService *services.WebhookService
+	var webhookHandler *handlers.WebhookHandler
+	if cfg.Webhooks.Enabled {
+		webhookService = services.NewWebhookService(repository.NewWebhookRepository(db), cfg.Webhooks)
+		go webhookService.Run(backgroundCtx)
+		userService.SetWebhooks(webhookService)
+		jobUserService.SetWebhooks(webhookService)
+		authService.SetWebhooks(webhookService)
+		webhookHandler = handlers.NewWebhookHandler(webhookService)
+	}
+
+	// Push user changes to WebSocket clients. Background jobs publish to the
+	// same hub as requests.
+	var liveHub *realtime.Hub
+	var liveHandler *handlers.LiveHandler
+	if cfg.Live.Enabled {
+		liveHub = realtime.NewHub(cfg.Live, codec, appLogger)
+		userService.SetLiveUpdates(liveHub)
+		jobUserService.SetLiveUpdates(liveHub)
+		authService.SetLiveUpdates(liveHub)
+		liveHandler = handlers.NewLiveHandler(liveHub, cfg.Live.AllowedOrigins)
+	}
+
+	// Publish domain events after every user change, to subscribers in this
+	// process or to Kafka or NATS. Background jobs publish too.
+	eventPublisher, err := events.NewPublisher(cfg.Events, appLogger)
+	if err != nil {
+		appLogger.Fatal("failed to create event publisher", zap.Error(err))
+	}
+	// Subscribers in this process see every event, also when they go to a
+	// broker
+	bus, ok := eventPublisher.(*events.Bus)
+	if !ok {
+		bus = events.NewBus()
+		eventPublisher = events.Tee(eventPublisher, bus)
+	}
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		logger.FromContext(ctx).Debug("domain event published",
+			zap.String("event", event.Type), zap.String("subject", event.Subject), zap.String("event_id", event.ID))
+	})
+	userService.SetEvents(eventPublisher)
+	jobUserService.SetEvents(eventPublisher)
+	authService.SetEvents(eventPublisher)
+
+	// With the outbox, user changes write their events in their own
+	// transaction and a relay publishes them, so a crash loses none
+	if cfg.Events.Outbox {
+		outboxService := services.NewOutboxService(repository.NewOutboxRepository(db), eventPublisher, cfg.Events)
+		userService.SetOutbox(repository.NewTxManager(db), outboxService)
+		authService.SetOutbox(repository.NewTxManager(db), outboxService)
+		jobOutboxService := services.NewOutboxService(repository.NewOutboxRepository(jobsDB), eventPublisher, cfg.Events)
+		jobUserService.SetOutbox(repository.NewTxManager(jobsDB), jobOutboxService)
+		go jobOutboxService.RunRelay(backgroundCtx, cfg.Events.OutboxPollInterval)
+	}
+
+	// Notify watchers when fields of the users they watch change. An event
+	// is evaluated by the instance publishing it, so event streams on other
+	// instances do not see it; watches with a URL get every change.
+	var watchService *services.WatchService
+	var watchHandler *handlers.WatchHandler
+	if cfg.Watches.Enabled {
+		watchService = services.NewWatchService(repository.NewWatchRepository(db), userRepo, codec, cfg.Watches)
+		if webhookService != nil {
+			watchService.SetWebhooks(webhookService)
+		}
+		bus.Subscribe(watchService.HandleEvent, models.WebhookUserUpdated, models.WebhookUserDeleted)
+		go watchService.Run(backgroundCtx)
+		watchHandler = handlers.NewWatchHandler(watchService, codec)
+	}
 
-	// Add middleware
-	router.Use(middleware.LoggingMiddleware)
-	router.Use(middleware.CORSMiddleware)
+	// Initialize the write-ahead queue for creates during outages
+	var writeAheadHandler *handlers.WriteAheadHandler
+	if cfg.Queue.Enabled {
+		queueRepo, err := repository.NewFileWriteAheadRepository(cfg.Queue.Dir)
+		if err != nil {
+			appLogger.Fatal("failed to initialize write-ahead queue", zap.Error(err))
+		}
+		writeAheadService := services.NewWriteAheadService(queueRepo, userService, jobUserService, monitor, cfg.Queue.RetryInterval)
+		go writeAheadService.Run(backgroundCtx)
+		writeAheadHandler = handlers.NewWriteAheadHandler(writeAheadService, codec, router.APIPrefix(cfg))
+	}
 
-	// API routes
-	api := router.PathPrefix("/api/v1").Subrouter()
+	// Initialize the OpenID Connect provider
+	var oidcHandler *handlers.OIDCHandler
+	if cfg.OIDC.Enabled {
+		oauthRepo := repository.NewOAuthRepository(db)
+		oidcService, err := services.NewOIDCService(oauthRepo, userRepo, cfg, appLogger)
+		if err != nil {
+			appLogger.Fatal("failed to initialize OIDC provider", zap.Error(err))
+		}
+		if jwtKeys != nil {
+			oidcService.SetSigner(jwtKeys)
+		}
+		oidcHandler = handlers.NewOIDCHandler(oidcService, router.APIBasePath)
+	}
 
-	// Health check
-	api.HandleFunc("/health", healthHandler.HealthCheck).Methods("GET")
+	// Operational actions for admins, each recorded in the audit log. The
+	// in-memory user cache is only flushed on the instance serving the
+	// request.
+	var runbookHandler *handlers.RunbookHandler
+	if cfg.Runbook.Enabled {
+		// Migrations run on a pool of their own, as they do at startup
+		runbook := services.NewRunbookService(migrator, func() (*sql.DB, error) {
+			return database.NewConnection(cfg.Database, cfg.Database.Migrations, queryObserver)
+		})
+		runbook.AddCache("app", appCache.Flush)
+		if userCache != nil {
+			runbook.AddCache("users", userCache.Flush)
+		}
+		if userLRU != nil {
+			runbook.AddCache("users_memory", func(context.Context) error {
+				userLRU.Clear()
+				return nil
+			})
+		}
+		if jwtKeys != nil {
+			runbook.SetJWTKeys(jwtKeys)
+		}
+		if cfg.Email.Enabled {
+			runbook.SetEmails(repository.NewEmailRepository(db))
+		}
+		if webhookService != nil {
+			runbook.SetWebhooks(webhookService)
+		}
+		runbookHandler = handlers.NewRunbookHandler(runbook)
+	}
 
-	// User routes
-	userRoutes := api.PathPrefix("/users").Subrouter()
-	userRoutes.HandleFunc("", userHandler.GetUsers).Methods("GET")
-	userRoutes.HandleFunc("", userHandler.CreateUser).Methods("POST")
-	userRoutes.HandleFunc("/{id:[0-9]+}", userHandler.GetUser).Methods("GET")
-	userRoutes.HandleFunc("/{id:[0-9]+}", userHandler.UpdateUser).Methods("PUT")
-	userRoutes.HandleFunc("/{id:[0-9]+}", userHandler.DeleteUser).Methods("DELETE")
+	// Initialize locale negotiation
+	locales, err := i18n.NewNegotiator(cfg.I18n.SupportedLocales)
+	if err != nil {
+		appLogger.Fatal("invalid locale configuration", zap.Error(err))
+	}
+	for _, locale := range locales.Untranslated() {
+		appLogger.Warn("no message translations for locale, messages are shown in English",
+			zap.String("locale", locale.String()))
+	}
+	tenantLocales, err := i18n.ParseTenantLocales(cfg.I18n.TenantLocales)
+	if err != nil {
+		appLogger.Fatal("invalid TENANT_LOCALES", zap.Error(err))
+	}
+	trustedProxies, err := forwarded.ParseProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		appLogger.Fatal("invalid TRUSTED_PROXIES", zap.Error(err))
+	}
+
+	// The gRPC API shares the services, and the token checks, of the REST
+	// API; the gateway serves its JSON form, generated from the proto, over HTTP
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled || cfg.GRPC.Gateway {
+		grpcDeps := grpcapi.Dependencies{
+			Config:      cfg,
+			UserService: userService,
+			IDs:         codec,
+			Claims:      claimsLoader,
+			JWTKeys:     jwtKeyfunc,
+			Logger:      appLogger,
+		}
+		if tokenService != nil {
+			grpcDeps.Tokens = tokenService
+		}
+		if auditService != nil {
+			grpcDeps.Audit = auditService
+		}
+		grpcServer = grpcapi.NewServer(grpcDeps)
+	}
+	if cfg.GRPC.Enabled {
+		grpcAddr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.GRPC.Port)
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			appLogger.Fatal("failed to listen for gRPC", zap.String("addr", grpcAddr), zap.Error(err))
+		}
+		go func() {
+			appLogger.Info("gRPC server starting", zap.String("addr", grpcAddr))
+			if err := grpcServer.Serve(listener); err != nil {
+				appLogger.Fatal("gRPC server failed", zap.Error(err))
+			}
+		}()
+	}
+	var gateway *grpcapi.Gateway
+	if cfg.GRPC.Gateway {
+		gateway, err = grpcapi.NewGateway(grpcServer)
+		if err != nil {
+			appLogger.Fatal("failed to start the gRPC gateway", zap.Error(err))
+		}
+		defer gateway.Close()
+	}
+
+	// Setup router
+	deps := router.Dependencies{
+		Config:         cfg,
+		UserHandler:    userHandler,
+		AuthHandler:    authHandler,
+		HealthHandler:  healthHandler,
+		Readiness:      readinessHandler,
+		OIDCHandler:    oidcHandler,
+		TokenHandler:   tokenHandler,
+		Introspection:  introspectionHandler,
+		Changelog:      changelogHandler,
+		Meta:           metaHandler,
+		WriteAhead:     writeAheadHandler,
+		Operations:     operationHandler,
+		Transitions:    transitionHandler,
+		Settings:       settingsHandler,
+		Emails:         emailHandler,
+		Webhooks:       webhookHandler,
+		Watches:        watchHandler,
+		Approvals:      approvalHandler,
+		Live:           liveHandler,
+		Dashboard:      dashboardHandler,
+		Cache:          cacheHandler,
+		Support:        supportHandler,
+		Runbook:        runbookHandler,
+		IDs:            codec,
+		Locales:        locales,
+		TenantLocales:  tenantLocales,
+		TrustedProxies: trustedProxies,
+		JWTKeys:        jwtKeyfunc,
+		Claims:         claimsLoader,
+		ReadOnly:       monitor,
+		AuditHandler:   auditHandler,
+		Metrics:        appMetrics,
+		Tracer:         tracer,
+		Logger:         appLogger,
+		LogSampler:     logSampler,
+	}
+	if auditService != nil {
+		deps.Audit = auditService
+	}
+	if tokenService != nil {
+		deps.Tokens = tokenService
+	}
+	if gateway != nil {
+		deps.Gateway = gateway
+	}
+	if memoryWatchdog != nil {
+		deps.MemoryPressure = memoryWatchdog
+	}
+	if replica != nil && postgres {
+		// Tokens are WAL positions, which only Postgres replication has
+		deps.Consistency = consistency.NewTracker(db)
+	}
+	if cfg.Priority.Enabled {
+		deps.Priority = priority.NewScheduler(cfg.Priority.MaxConcurrent, map[priority.Class]int{
+			priority.Critical: cfg.Priority.CriticalQueue,
+			priority.Read:     cfg.Priority.ReadQueue,
+			priority.Write:    cfg.Priority.WriteQueue,
+			priority.Bulk:     cfg.Priority.BulkQueue,
+		}, cfg.Priority.QueueTimeout)
+	}
+	if cfg.Limits.Enabled && cfg.Limits.RequestsPerSecond > 0 {
+		memoryLimiter := ratelimit.NewMemory(cfg.Limits.RequestsPerSecond, cfg.Limits.Burst)
+		deps.RateLimiter = memoryLimiter
+		switch {
+		case cfg.Cache.Driver == cache.DriverMemcached:
+			// Shared through memcached; per instance while it is down
+			deps.RateLimiter = ratelimit.NewCached(appCache, cfg.Limits.RequestsPerSecond, cfg.Limits.Burst, memoryLimiter)
+		case redisClient != nil:
+			// Shared across instances; per instance while Redis is down
+			deps.RateLimiter = ratelimit.NewRedis(redisClient, cfg.Limits.RequestsPerSecond, cfg.Limits.Burst, memoryLimiter)
+		}
+	}
+	r := router.New(deps)
+	r.LogRoutes()
 
 	// Create server
 	srv := &http.Server{
-		Addr: fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port),
-		Handler: router,
-		ReadTimeout: 15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout: 60 * time.Second,
+		Addr:         fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port),
+		Handler:      r.Handler(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  60 * time.Second,
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server starting on %s:%s", cfg.Server.Host, cfg.Server.Port)
+		appLogger.Info("server starting", zap.String("addr", srv.Addr))
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+			appLogger.Fatal("server failed to start", zap.Error(err))
 		}
 	}()
 
+	// Report the configuration the instance runs with, for operators and
+	// deployment checks
+	report := startup.New(cfg, migrator, time.Now())
+	report.Database.AppliedSchemaVersion, err = database.AppliedSchemaVersion(backgroundCtx, db)
+	if err != nil {
+		appLogger.Warn("failed to read schema version for the startup report", zap.Error(err))
+	}
+	appLogger.Info("startup report", zap.Any("report", report))
+	supportBundler.SetStartupReport(report)
+	if cfg.Logging.Format == "console" {
+		fmt.Fprint(os.Stderr, report.Banner())
+	}
+	if cfg.Server.StartupReportFile != "" {
+		if err := report.WriteFile(cfg.Server.StartupReportFile); err != nil {
+			appLogger.Fatal("failed to write STARTUP_REPORT_FILE", zap.Error(err))
+		}
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	appLogger.Info("shutting down server")
+
+	// Tell WebSocket clients to reconnect elsewhere. The server does not
+	// track upgraded connections, so the hub closes them itself.
+	if liveHub != nil {
+		graceCtx, cancelGrace := context.WithTimeout(context.Background(), cfg.Live.ShutdownGrace)
+		notified, forced := liveHub.Shutdown(graceCtx)
+		cancelGrace()
+		appLogger.Info("websocket streams closed", zap.Int("notified", notified), zap.Int("force_closed", forced))
+	}
+
+	// Tell watch event streams to reconnect elsewhere. Shutdown does not end
+	// requests in flight, so streams would otherwise hold it up.
+	if watchService != nil {
+		graceCtx, cancelGrace := context.WithTimeout(context.Background(), cfg.Watches.ShutdownGrace)
+		notified, forced := watchService.Shutdown(graceCtx)
+		cancelGrace()
+		appLogger.Info("watch streams closed", zap.Int("notified", notified), zap.Int("force_closed", forced))
+	}
 
 	// Give outstanding requests a deadline for completion
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -110,8 +817,35 @@ func main() {
 
 	// Attempt graceful shutdown
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		appLogger.Fatal("server forced to shutdown", zap.Error(err))
+	}
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
+
+	// Let background operations record their outcome
+	if operationService != nil {
+		operationService.Shutdown()
+	}
+
+	// Send the domain events still buffered for the broker
+	if err := eventPublisher.Close(); err != nil {
+		appLogger.Error("failed to flush domain events", zap.Error(err))
 	}
 
-	log.Println("Server exited")
-}
\ No newline at end of file
+	appLogger.Info("server exited")
+}
+
+// stopGRPC lets calls in flight finish until ctx is done, then cuts off the rest
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/database"
+)
+
+// runMigrateCommand runs the -migrate command with args on the migrations
+// pool, as cmd/migrate does
+func runMigrateCommand(cfg *config.Config, command string, args []string) error {
+	migrator, err := database.NewMigrator(cfg.Database.Driver)
+	if err != nil {
+		return err
+	}
+	db, err := database.NewConnection(cfg.Database, cfg.Database.Migrations, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	return migrator.RunCommand(db, os.Stdout, command, args)
+}
@@ -0,0 +1,109 @@
+package actor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// Actor describes who a request acts for. Middleware builds it once per
+// request from the caller's connection and token; authorization, auditing
+// and anything else that attributes work read it rather than the token, so
+// they all agree on who the caller is.
+type Actor struct {
+	// UserID is the token subject; empty for anonymous requests
+	UserID string
+	Role   string
+	// Scoped is set for tokens restricted to Scopes. Session tokens are not
+	// scoped and have full access.
+	Scoped bool
+	Scopes []string
+	// ImpersonatorID is the user acting as UserID, from the token's act
+	// claim (RFC 8693). Permissions are always those of UserID.
+	ImpersonatorID string
+	TenantID       string
+	// APIKeyID is the personal access token the request was authenticated with
+	APIKeyID string
+	// SigningKey is the public key registered for APIKeyID, PEM encoded;
+	// when set, mutating requests must be signed with its private key
+	SigningKey string
+	IP         string
+}
+
+// FromClaims builds the actor described by token claims
+func FromClaims(claims map[string]interface{}) Actor {
+	a := Actor{}
+	a.UserID, _ = claims["sub"].(string)
+	a.Role, _ = claims["role"].(string)
+	a.TenantID, _ = claims["tenant"].(string)
+
+	if scope, ok := claims["scope"].(string); ok {
+		a.Scoped = true
+		a.Scopes = strings.Fields(scope)
+	}
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		a.ImpersonatorID, _ = act["sub"].(string)
+	}
+	if tokenType, _ := claims["token_type"].(string); tokenType == "pat" {
+		if id, ok := claims["token_id"]; ok {
+			// JWT numbers decode as float64, so format without a fraction
+			a.APIKeyID = fmt.Sprint(id)
+		}
+		a.SigningKey, _ = claims["signing_key"].(string)
+	}
+	return a
+}
+
+// Authenticated reports whether the request carried a valid token
+func (a Actor) Authenticated() bool {
+	return a.UserID != ""
+}
+
+// IsAdmin reports whether the actor has the admin role
+func (a Actor) IsAdmin() bool {
+	return a.Role == models.RoleAdmin
+}
+
+// Impersonated reports whether someone else is acting as the user
+func (a Actor) Impersonated() bool {
+	return a.ImpersonatorID != ""
+}
+
+// HasScope reports whether the actor may use scope
+func (a Actor) HasScope(scope string) bool {
+	if !a.Scoped {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// UserIDInt returns UserID as a user ID, or false if it is not one
+func (a Actor) UserIDInt() (int, bool) {
+	id, err := strconv.Atoi(a.UserID)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// contextKey is the type for values stored in the request context
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying a
+func NewContext(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, a)
+}
+
+// FromContext returns the actor stored in ctx, or an anonymous actor if none was set
+func FromContext(ctx context.Context) Actor {
+	a, _ := ctx.Value(contextKey{}).(Actor)
+	return a
+}
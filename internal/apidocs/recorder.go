@@ -0,0 +1,224 @@
+package apidocs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxExampleBytes caps the size of a recorded request or response body
+const maxExampleBytes = 16 << 10
+
+// redactedValue replaces sensitive values in recorded examples
+const redactedValue = "<redacted>"
+
+// sensitiveFields are JSON keys whose values are never written to examples
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"id_token":      true,
+	"refresh_token": true,
+	"client_secret": true,
+	"device_code":   true,
+}
+
+// pathVariablePattern matches mux variables with a pattern, e.g. {id:[0-9]+}
+var pathVariablePattern = regexp.MustCompile(`\{([^}:]+):[^}]+\}`)
+
+// MediaType is an OpenAPI media type object holding a single example
+type MediaType struct {
+	Example interface{} `json:"example"`
+}
+
+// RequestBody is an OpenAPI request body object
+type RequestBody struct {
+	Content map[string]MediaType `json:"content"`
+}
+
+// Response is an OpenAPI response object
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Operation is the examples section of an OpenAPI operation object
+type Operation struct {
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Document is an OpenAPI paths fragment holding recorded examples, ready to
+// be merged into the generated specification
+type Document struct {
+	Paths map[string]map[string]*Operation `json:"paths"`
+}
+
+// Recorder captures real request and response bodies per route so the API
+// documentation can show examples that match what the server returns.
+// The most recent exchange for each route and status code wins.
+type Recorder struct {
+	mu    sync.RWMutex
+	paths map[string]map[string]*Operation
+}
+
+// NewRecorder creates an empty example recorder
+func NewRecorder() *Recorder {
+	return &Recorder{paths: make(map[string]map[string]*Operation)}
+}
+
+// Wrap records the exchanges handled by next under the route template path
+func (rec *Recorder) Wrap(path string, next http.Handler) http.Handler {
+	path = OpenAPIPath(path)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestBody []byte
+		if r.Body != nil && isJSON(r.Header.Get("Content-Type")) {
+			requestBody, _ = io.ReadAll(io.LimitReader(r.Body, maxExampleBytes+1))
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(requestBody), r.Body))
+		}
+
+		cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(cw, r)
+
+		rec.record(path, r.Method, requestBody, cw)
+	})
+}
+
+// Document returns the recorded examples as an OpenAPI paths fragment
+func (rec *Recorder) Document() *Document {
+	rec.mu.RLock()
+	defer rec.mu.RUnlock()
+
+	doc := &Document{Paths: make(map[string]map[string]*Operation, len(rec.paths))}
+	for path, operations := range rec.paths {
+		doc.Paths[path] = make(map[string]*Operation, len(operations))
+		for method, op := range operations {
+			copied := &Operation{
+				RequestBody: op.RequestBody,
+				Responses:   make(map[string]Response, len(op.Responses)),
+			}
+			for status, resp := range op.Responses {
+				copied.Responses[status] = resp
+			}
+			doc.Paths[path][method] = copied
+		}
+	}
+	return doc
+}
+
+// record stores one exchange, skipping bodies that are not JSON or too large
+func (rec *Recorder) record(path, method string, requestBody []byte, cw *captureWriter) {
+	requestExample, requestOK := decodeExample(requestBody)
+
+	response := Response{Description: http.StatusText(cw.status)}
+	if isJSON(cw.Header().Get("Content-Type")) && !cw.truncated {
+		if example, ok := decodeExample(cw.body.Bytes()); ok {
+			response.Content = map[string]MediaType{"application/json": {Example: example}}
+		}
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	operations, ok := rec.paths[path]
+	if !ok {
+		operations = make(map[string]*Operation)
+		rec.paths[path] = operations
+	}
+	method = strings.ToLower(method)
+	op, ok := operations[method]
+	if !ok {
+		op = &Operation{Responses: make(map[string]Response)}
+		operations[method] = op
+	}
+
+	if requestOK && cw.status < http.StatusBadRequest {
+		op.RequestBody = &RequestBody{
+			Content: map[string]MediaType{"application/json": {Example: requestExample}},
+		}
+	}
+	op.Responses[strconv.Itoa(cw.status)] = response
+}
+
+// decodeExample parses a JSON body and redacts sensitive fields
+func decodeExample(body []byte) (interface{}, bool) {
+	if len(body) == 0 || len(body) > maxExampleBytes {
+		return nil, false
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, false
+	}
+	return redact(value), true
+}
+
+// redact replaces sensitive values anywhere in a decoded JSON value
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFields[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redact(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+// OpenAPIPath converts a mux path template to OpenAPI form, e.g.
+// /users/{id:[0-9]+} becomes /users/{id}
+func OpenAPIPath(path string) string {
+	return pathVariablePattern.ReplaceAllString(path, "{$1}")
+}
+
+// isJSON reports whether a Content-Type header denotes JSON
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(strings.TrimSpace(contentType), "application/json")
+}
+
+// captureWriter copies the status code and body written by a handler
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	truncated   bool
+}
+
+// WriteHeader captures the status code
+func (cw *captureWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.status = status
+		cw.wroteHeader = true
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+// Write captures the body up to maxExampleBytes
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	cw.wroteHeader = true
+	if cw.body.Len()+len(b) > maxExampleBytes {
+		cw.truncated = true
+	} else {
+		cw.body.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
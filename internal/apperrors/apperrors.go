@@ -0,0 +1,82 @@
+// Package apperrors defines the kinds of error services return and the HTTP
+// status each kind is reported with, so handlers need not match messages.
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kinds of error, matched with errors.Is
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrForbidden  = errors.New("forbidden")
+	ErrInternal   = errors.New("internal error")
+)
+
+// Error is an error of one kind with a message clients may see
+type Error struct {
+	kind    error
+	message string
+	err     error
+}
+
+// Error returns the message
+func (e *Error) Error() string {
+	return e.message
+}
+
+// Is reports whether target is the kind of e
+func (e *Error) Is(target error) bool {
+	return target == e.kind
+}
+
+// Unwrap returns the error that caused e, if any
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// NotFound returns an error for a resource that does not exist
+func NotFound(message string) error {
+	return &Error{kind: ErrNotFound, message: message}
+}
+
+// Conflict returns an error for a request the current state rejects
+func Conflict(message string) error {
+	return &Error{kind: ErrConflict, message: message}
+}
+
+// Validation returns an error for a request with invalid input
+func Validation(message string) error {
+	return &Error{kind: ErrValidation, message: message}
+}
+
+// Forbidden returns an error for a request the caller may not make
+func Forbidden(message string) error {
+	return &Error{kind: ErrForbidden, message: message}
+}
+
+// Internal wraps err, which the server could not handle, under message
+func Internal(message string, err error) error {
+	return &Error{kind: ErrInternal, message: message + ": " + err.Error(), err: err}
+}
+
+// Status returns the HTTP status err is reported with, or fallback when err
+// is of no known kind
+func Status(err error, fallback int) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrInternal):
+		return http.StatusInternalServerError
+	}
+	return fallback
+}
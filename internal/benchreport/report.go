@@ -0,0 +1,143 @@
+// Package benchreport summarizes the output of `go test -bench`, comparing
+// the variants of each benchmark, such as two drivers running one query
+package benchreport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// procsSuffix matches the -GOMAXPROCS suffix go test appends to names
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+// Result is the mean of one benchmark's runs. A benchmark named
+// BenchmarkPagination/depth=1000/keyset is the keyset variant of the
+// BenchmarkPagination/depth=1000 group.
+type Result struct {
+	Name    string
+	Group   string
+	Variant string
+	Runs    int
+
+	NsPerOp     float64
+	BytesPerOp  float64
+	AllocsPerOp float64
+}
+
+// Parse reads benchmark results from go test output, ignoring other lines,
+// and averages the runs of each benchmark. Results are in the order their
+// first run appears.
+func Parse(r io.Reader) ([]Result, error) {
+	var results []*Result
+	byName := make(map[string]*Result)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+
+		name := procsSuffix.ReplaceAllString(fields[0], "")
+		result, ok := byName[name]
+		if !ok {
+			result = &Result{Name: name, Group: name}
+			if i := strings.LastIndex(name, "/"); i >= 0 {
+				result.Group, result.Variant = name[:i], name[i+1:]
+			}
+			byName[name] = result
+			results = append(results, result)
+		}
+		result.Runs++
+
+		// The rest are value and unit pairs
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s of %s: %q", fields[i+1], name, fields[i])
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				result.NsPerOp += value
+			case "B/op":
+				result.BytesPerOp += value
+			case "allocs/op":
+				result.AllocsPerOp += value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	summary := make([]Result, len(results))
+	for i, result := range results {
+		runs := float64(result.Runs)
+		result.NsPerOp /= runs
+		result.BytesPerOp /= runs
+		result.AllocsPerOp /= runs
+		summary[i] = *result
+	}
+	return summary, nil
+}
+
+// Write prints results as one table per group. Each variant's time is also
+// given relative to the group's first variant, so 0.25x is four times faster.
+func Write(w io.Writer, results []Result) error {
+	var groups []string
+	byGroup := make(map[string][]Result)
+	for _, result := range results {
+		if _, ok := byGroup[result.Group]; !ok {
+			groups = append(groups, result.Group)
+		}
+		byGroup[result.Group] = append(byGroup[result.Group], result)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, group)
+		fmt.Fprintln(tw, "VARIANT\tRUNS\tTIME/OP\tVS FIRST\tB/OP\tALLOCS/OP")
+
+		variants := byGroup[group]
+		baseline := variants[0].NsPerOp
+		for _, result := range variants {
+			variant := result.Variant
+			if variant == "" {
+				variant = "-"
+			}
+			relative := "-"
+			if baseline > 0 {
+				relative = fmt.Sprintf("%.2fx", result.NsPerOp/baseline)
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.0f\t%.0f\n",
+				variant, result.Runs, formatDuration(result.NsPerOp), relative, result.BytesPerOp, result.AllocsPerOp)
+		}
+	}
+	return tw.Flush()
+}
+
+// formatDuration prints ns nanoseconds in the largest unit under which it
+// stays at least one
+func formatDuration(ns float64) string {
+	switch {
+	case ns >= 1e9:
+		return fmt.Sprintf("%.2fs", ns/1e9)
+	case ns >= 1e6:
+		return fmt.Sprintf("%.2fms", ns/1e6)
+	case ns >= 1e3:
+		return fmt.Sprintf("%.2fµs", ns/1e3)
+	default:
+		return fmt.Sprintf("%.0fns", ns)
+	}
+}
@@ -0,0 +1,63 @@
+// Package buildinfo reports the version of the running binary. Release
+// builds set it with -ldflags, for example:
+//
+//	go build -ldflags "-X github.com/pratham15541/go-crud/internal/buildinfo.Version=1.4.0 \
+//	  -X github.com/pratham15541/go-crud/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/pratham15541/go-crud/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without them fall back to the VCS details the go command stamps
+// into binaries built from a checkout.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X at build time
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	// Modified reports a build from a checkout with uncommitted changes
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	Compiler  string `json:"compiler"`
+}
+
+// Get returns the build info of the running binary
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Compiler:  runtime.Compiler,
+	}
+	// Without a commit set at build time, report the checkout's, and its
+	// commit time for the build date
+	if build, ok := debug.ReadBuildInfo(); ok && Commit == "" {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
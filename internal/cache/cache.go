@@ -0,0 +1,46 @@
+// Package cache stores short-lived values in process memory, Redis or
+// memcached behind one interface, so caching layers need not know which
+// backend CACHE_DRIVER selected.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Drivers
+const (
+	DriverMemory    = "memory"
+	DriverRedis     = "redis"
+	DriverMemcached = "memcached"
+)
+
+// Cache stores values by key until their TTL passes. A missing or expired
+// key is reported by ok rather than an error; errors mean the backend
+// failed. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value for ttl; a ttl of zero or less keeps it until evicted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// TTL returns how long key has left, zero if it has no expiry
+	TTL(ctx context.Context, key string) (ttl time.Duration, ok bool, err error)
+
+	// GetMulti returns the values of the keys that are present
+	GetMulti(ctx context.Context, keys []string) (map[string][]byte, error)
+	// SetMulti stores every item for ttl
+	SetMulti(ctx context.Context, items map[string][]byte, ttl time.Duration) error
+
+	// Flush removes every entry
+	Flush(ctx context.Context) error
+}
+
+// ValidateDriver returns an error unless driver is a known cache driver
+func ValidateDriver(driver string) error {
+	switch driver {
+	case DriverMemory, DriverRedis, DriverMemcached:
+		return nil
+	}
+	return fmt.Errorf("unknown cache driver %q, want memory, redis or memcached", driver)
+}
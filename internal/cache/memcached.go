@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// maxRelativeExpiration is the longest expiry memcached reads as relative;
+// longer ones must be given as a Unix time
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// Memcached is a cache shared by every instance using the same memcached
+// servers. Memcached cannot report a key's expiry, so each item carries its
+// expiry as a Unix time in its flags. TTLs are rounded up to whole seconds.
+type Memcached struct {
+	client *memcache.Client
+}
+
+// NewMemcached creates a cache stored in client
+func NewMemcached(client *memcache.Client) *Memcached {
+	return &Memcached{client: client}
+}
+
+// Get implements Cache
+func (c *Memcached) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	item, err := c.client.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return item.Value, true, nil
+}
+
+// Set implements Cache
+func (c *Memcached) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(memcachedItem(key, value, ttl, time.Now()))
+}
+
+// Delete implements Cache
+func (c *Memcached) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := c.client.Delete(key); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+			return err
+		}
+	}
+	return nil
+}
+
+// TTL implements Cache
+func (c *Memcached) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	item, err := c.client.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if item.Flags == 0 {
+		return 0, true, nil
+	}
+	ttl := time.Until(time.Unix(int64(item.Flags), 0))
+	if ttl <= 0 {
+		return 0, false, nil
+	}
+	return ttl, true, nil
+}
+
+// GetMulti implements Cache in one round trip per server
+func (c *Memcached) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	items, err := c.client.GetMulti(keys)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte, len(items))
+	for key, item := range items {
+		values[key] = item.Value
+	}
+	return values, nil
+}
+
+// SetMulti implements Cache. Memcached has no batch set, so items are
+// stored one at a time.
+func (c *Memcached) SetMulti(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	now := time.Now()
+	for key, value := range items {
+		if err := c.client.Set(memcachedItem(key, value, ttl, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush implements Cache. Memcached has no namespaces, so this empties
+// the servers of anything else stored in them too.
+func (c *Memcached) Flush(ctx context.Context) error {
+	return c.client.FlushAll()
+}
+
+// memcachedItem builds the item storing value under key for ttl from now
+func memcachedItem(key string, value []byte, ttl time.Duration, now time.Time) *memcache.Item {
+	item := &memcache.Item{Key: key, Value: value}
+	if ttl <= 0 {
+		return item
+	}
+
+	expiresAt := now.Add(ttl).Truncate(time.Second)
+	if expiresAt.Before(now.Add(ttl)) {
+		expiresAt = expiresAt.Add(time.Second)
+	}
+	item.Flags = uint32(expiresAt.Unix())
+	item.Expiration = int32((ttl + time.Second - 1) / time.Second)
+	if ttl > maxRelativeExpiration {
+		item.Expiration = int32(expiresAt.Unix())
+	}
+	return item
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+)
+
+// Memory is a cache in process memory bounded by the total size of its
+// values. Entries are per instance, so replicas do not share them.
+type Memory struct {
+	cache *ristretto.Cache
+}
+
+// NewMemory creates a memory cache holding up to maxBytes of values
+func NewMemory(maxBytes int64) (*Memory, error) {
+	c, err := ristretto.NewCache(&ristretto.Config{
+		// Ristretto recommends tracking ten times the expected entry count;
+		// assume entries of about 100 bytes
+		NumCounters: maxBytes / 10,
+		MaxCost:     maxBytes,
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create memory cache: %w", err)
+	}
+	return &Memory{cache: c}, nil
+}
+
+// Get implements Cache
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok := m.cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return value.([]byte), true, nil
+}
+
+// Set implements Cache. The value is visible to Get once Set returns, though
+// the cache may still decline to keep it when full.
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.set(key, value, ttl)
+	m.cache.Wait()
+	return nil
+}
+
+// set stores value without waiting for ristretto to apply it
+func (m *Memory) set(key string, value []byte, ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	m.cache.SetWithTTL(key, value, int64(len(value)), ttl)
+}
+
+// Delete implements Cache
+func (m *Memory) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		m.cache.Del(key)
+	}
+	return nil
+}
+
+// TTL implements Cache
+func (m *Memory) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	ttl, ok := m.cache.GetTTL(key)
+	return ttl, ok, nil
+}
+
+// GetMulti implements Cache
+func (m *Memory) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if value, ok := m.cache.Get(key); ok {
+			values[key] = value.([]byte)
+		}
+	}
+	return values, nil
+}
+
+// SetMulti implements Cache
+func (m *Memory) SetMulti(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	for key, value := range items {
+		m.set(key, value, ttl)
+	}
+	m.cache.Wait()
+	return nil
+}
+
+// Flush implements Cache
+func (m *Memory) Flush(ctx context.Context) error {
+	m.cache.Clear()
+	return nil
+}
+
+// Close stops the cache's background goroutines
+func (m *Memory) Close() {
+	m.cache.Close()
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix namespaces cache keys in a shared Redis
+const redisKeyPrefix = "cache:"
+
+// Redis is a cache shared by every instance using the same Redis
+type Redis struct {
+	client redis.UniversalClient
+}
+
+// NewRedis creates a cache stored in client
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{client: client}
+}
+
+// Get implements Cache
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements Cache
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, redisKeyPrefix+key, value, redisTTL(ttl)).Err()
+}
+
+// Delete implements Cache
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = redisKeyPrefix + key
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+// TTL implements Cache
+func (c *Redis) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	ttl, err := c.client.PTTL(ctx, redisKeyPrefix+key).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	// go-redis passes through -2 for a missing key and -1 for one without
+	// an expiry as raw durations
+	switch ttl {
+	case -2:
+		return 0, false, nil
+	case -1:
+		return 0, true, nil
+	}
+	return ttl, true, nil
+}
+
+// GetMulti implements Cache
+func (c *Redis) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = redisKeyPrefix + key
+	}
+	results, err := c.client.MGet(ctx, prefixed...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range results {
+		if value, ok := result.(string); ok {
+			values[keys[i]] = []byte(value)
+		}
+	}
+	return values, nil
+}
+
+// SetMulti implements Cache in a single round trip
+func (c *Redis) SetMulti(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, value := range items {
+			pipe.Set(ctx, redisKeyPrefix+key, value, redisTTL(ttl))
+		}
+		return nil
+	})
+	return err
+}
+
+// redisFlushBatch is how many keys Flush scans for and deletes at a time
+const redisFlushBatch = 500
+
+// Flush implements Cache, deleting the cache's keys a batch at a time so
+// Redis is never blocked for long. Other data in the same Redis is left
+// alone. On a cluster every primary is flushed.
+func (c *Redis) Flush(ctx context.Context) error {
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return flushRedisNode(ctx, node)
+		})
+	}
+	return flushRedisNode(ctx, c.client)
+}
+
+// flushRedisNode deletes the cache's keys from one Redis server. Keys are
+// deleted one per command, as a cluster node refuses commands spanning
+// hash slots.
+func flushRedisNode(ctx context.Context, client redis.Cmdable) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, redisKeyPrefix+"*", redisFlushBatch).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, key := range keys {
+					pipe.Del(ctx, key)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// redisTTL converts a Cache TTL to a Redis expiry, where zero means none
+func redisTTL(ttl time.Duration) time.Duration {
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// swrVersion marks values written by StaleWhileRevalidate, so values in
+// another format under the same key are treated as missing
+const swrVersion = 1
+
+// refreshTimeout bounds a background refresh, which outlives its request
+const refreshTimeout = 10 * time.Second
+
+// Loader reads the current value of a key from its source
+type Loader func(ctx context.Context) ([]byte, error)
+
+// StaleWhileRevalidate serves values for ttl and then, for a further stale
+// window, keeps serving the old value while one background load per key and
+// instance refreshes it. Only a key that is missing or past both waits for
+// its load, so popular keys don't stall requests each time their TTL passes.
+type StaleWhileRevalidate struct {
+	cache Cache
+	ttl   time.Duration
+	stale time.Duration
+
+	refreshing sync.Map
+	// OnError, if set, is called when the cache fails or a background
+	// refresh fails; neither fails the fetch
+	OnError func(ctx context.Context, key string, err error)
+}
+
+// NewStaleWhileRevalidate caches values in c fresh for ttl and served stale
+// for a further stale window. A ttl of zero or less disables caching.
+func NewStaleWhileRevalidate(c Cache, ttl, stale time.Duration) *StaleWhileRevalidate {
+	if stale < 0 {
+		stale = 0
+	}
+	return &StaleWhileRevalidate{cache: c, ttl: ttl, stale: stale}
+}
+
+// Fetch returns the value of key from the cache, or from load when it is
+// missing or too stale to serve. A failing cache is bypassed.
+func (s *StaleWhileRevalidate) Fetch(ctx context.Context, key string, load Loader) ([]byte, error) {
+	if s.ttl <= 0 {
+		return load(ctx)
+	}
+
+	cached, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.reportError(ctx, key, err)
+	}
+	if ok {
+		if freshUntil, value, ok := decodeEntry(cached); ok {
+			if time.Now().After(freshUntil) {
+				s.refresh(ctx, key, load)
+			}
+			return value, nil
+		}
+	}
+
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Store(ctx, key, value); err != nil {
+		s.reportError(ctx, key, err)
+	}
+	return value, nil
+}
+
+// refresh reloads key in the background unless this instance is already
+// doing so. A failed load drops the stale value, so the next request loads
+// it itself and sees the error.
+func (s *StaleWhileRevalidate) refresh(ctx context.Context, key string, load Loader) {
+	if _, running := s.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+	go func() {
+		defer cancel()
+		defer s.refreshing.Delete(key)
+
+		value, err := load(ctx)
+		if err == nil {
+			err = s.Store(ctx, key, value)
+		} else {
+			s.cache.Delete(ctx, key)
+		}
+		if err != nil {
+			s.reportError(ctx, key, err)
+		}
+	}()
+}
+
+// reportError passes err to OnError if it is set
+func (s *StaleWhileRevalidate) reportError(ctx context.Context, key string, err error) {
+	if s.OnError != nil {
+		s.OnError(ctx, key, err)
+	}
+}
+
+// Store caches value fresh for the TTL and kept for the stale window after,
+// as a load would. It does nothing while caching is disabled.
+func (s *StaleWhileRevalidate) Store(ctx context.Context, key string, value []byte) error {
+	if s.ttl <= 0 {
+		return nil
+	}
+	return s.cache.Set(ctx, key, encodeEntry(time.Now().Add(s.ttl), value), s.ttl+s.stale)
+}
+
+// encodeEntry prefixes value with the version and the time it is fresh until
+func encodeEntry(freshUntil time.Time, value []byte) []byte {
+	entry := make([]byte, 9, 9+len(value))
+	entry[0] = swrVersion
+	binary.BigEndian.PutUint64(entry[1:], uint64(freshUntil.UnixNano()))
+	return append(entry, value...)
+}
+
+// decodeEntry splits an entry written by encodeEntry
+func decodeEntry(entry []byte) (freshUntil time.Time, value []byte, ok bool) {
+	if len(entry) < 9 || entry[0] != swrVersion {
+		return time.Time{}, nil, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(entry[1:9]))), entry[9:], true
+}
+
+// Invalidate drops the cached value of key
+func (s *StaleWhileRevalidate) Invalidate(ctx context.Context, key string) error {
+	return s.cache.Delete(ctx, key)
+}
@@ -1,103 +1,883 @@
 package config
 
 import (
-	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	Server   ServerConfig
+	GRPC     GRPCConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
+	Auth     AuthConfig
 	Logging  LoggingConfig
+	I18n     I18nConfig
+	OIDC     OIDCConfig
+	Tokens   PersonalTokenConfig
+	Queue    WriteAheadConfig
+	Audit    AuditConfig
+	Runbook  RunbookConfig
+	IDs      IDConfig
+	Metrics  MetricsConfig
+	Limits   RateLimitConfig
+	Priority PriorityConfig
+	Redis    RedisConfig
+	Cache    CacheConfig
+	Schema   SchemaTransitionConfig
+	Tracing  TracingConfig
+	Email    EmailConfig
+	Webhooks WebhookConfig
+	Watches  WatchConfig
+	Live     LiveConfig
+	Events   EventsConfig
+	Paging   PaginationConfig
+	Memory   MemoryConfig
+	Compress CompressionConfig
+	CORS     CORSConfig
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Host string
-	Port string
-	Mode string
+	Host           string
+	Port           string
+	Mode           string
+	MethodOverride bool
+	TrailingSlash  string
+	LowercasePaths bool
+	RecordExamples bool
+	// Swagger serves the Swagger UI and OpenAPI document under /swagger/,
+	// OpenAPI 3 documents at /openapi.json and /openapi.yaml and JSON Schemas
+	// under /schemas/
+	Swagger bool
+	// OpenAPIVersion is the OpenAPI version served when a request does not
+	// pick one: 3.0 or 3.1
+	OpenAPIVersion string
+
+	// ReadTimeout and WriteTimeout bound the connection; RequestTimeout bounds
+	// the request context handed to services and must be below WriteTimeout
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	RequestTimeout time.Duration
+	// TransformTimeout caps ?transform= evaluation; zero disables transforms
+	TransformTimeout time.Duration
+	// MaxBodyBytes caps request bodies, except CSV imports, which have their
+	// own limit; zero disables the cap
+	MaxBodyBytes int64
+
+	// Region names the region the instance runs in, such as eu-west-1, for
+	// metrics, traces, health checks and the X-Region header; empty for
+	// single-region deployments
+	Region string
+
+	// BasePath is the path every route is mounted under, such as /crud when
+	// a gateway forwards that prefix unchanged; empty to serve from the root.
+	// Normalized to a leading slash and no trailing one.
+	BasePath string
+	// TrustedProxies are the addresses and CIDR ranges of load balancers
+	// whose X-Forwarded-Proto and X-Forwarded-Host headers are believed
+	TrustedProxies []string
+
+	// StartupReportFile, if set, is where the startup report is written as
+	// JSON on startup
+	StartupReportFile string
+}
+
+// GRPCConfig holds configuration of the gRPC user API
+type GRPCConfig struct {
+	// Enabled serves the gRPC API on Port, next to the REST API on HOST
+	Enabled bool
+	Port    string
+	// Gateway serves the JSON API generated from the proto under /api/v2
+	Gateway bool
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Host         string
-	Port         string
-	User         string
-	Password     string
+	// Driver is "postgres", "mysql" or "sqlite". Only users are stored in
+	// MySQL and SQLite; the features with tables of their own need Postgres.
+	Driver   string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+	// SQLitePath is the database file used with the sqlite driver, or
+	// ":memory:" for a database that lasts as long as the process
+	SQLitePath string
+
+	// ApplicationName prefixes the application_name each pool reports to
+	// Postgres, e.g. go-crud-api, so pg_stat_activity shows who holds a connection
+	ApplicationName string
+	// API, Jobs and Migrations size the separate pools used by requests,
+	// background jobs and schema migrations, so jobs cannot starve requests
+	API        PoolConfig
+	Jobs       PoolConfig
+	Migrations PoolConfig
+	// PoolHealthCheckPeriod is how often Postgres pools check their idle
+	// connections and top them up to MinConns
+	PoolHealthCheckPeriod time.Duration
+
+	// MigrationMode is "run" to apply pending migrations at startup or
+	// "wait" to leave them to another instance and wait until they are done
+	MigrationMode string
+	// MigrationPollInterval is how often a waiting instance checks the schema
+	MigrationPollInterval time.Duration
+	// ReadyRequiresMigrations keeps /readyz failing until the schema is at
+	// the version the binary needs
+	ReadyRequiresMigrations bool
+
+	// ReplicaHost is a read replica used while the primary is down; empty disables it
+	ReplicaHost string
+	ReplicaPort string
+	// FollowerReads serves reads from the replica whenever it is healthy,
+	// such as a replica in the instance's own region, rather than only
+	// while the primary is down
+	FollowerReads bool
+	// HealthCheckInterval is how often the primary and replica are probed
+	HealthCheckInterval time.Duration
+
+	// SoftDeleteRetention is how long deleted users can be restored before
+	// they are purged every PurgeInterval; zero disables purging
+	SoftDeleteRetention time.Duration
+	PurgeInterval       time.Duration
+}
+
+// PoolConfig sizes one database connection pool
+type PoolConfig struct {
+	// Name is appended to the application name of the pool's connections
 	Name         string
-	SSLMode      string
 	MaxOpenConns int
+	// MaxIdleConns only applies to MySQL and SQLite. Postgres pools keep
+	// idle connections for MaxIdleTime instead, down to MinConns.
 	MaxIdleConns int
+	MinConns     int
+	MaxIdleTime  time.Duration
 	MaxLifetime  time.Duration
+	// MultiStatements lets one query hold several statements, as migration
+	// files do; only MySQL connections need it enabled
+	MultiStatements bool
+	// User and Password, when set, replace DB_USER and DB_PASSWORD for the
+	// pool's connections
+	User     string
+	Password string
+}
+
+// Replica returns the connection settings for the read replica
+func (c DatabaseConfig) Replica() DatabaseConfig {
+	replica := c
+	replica.Host = c.ReplicaHost
+	replica.Port = c.ReplicaPort
+	return replica
 }
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	Secret     string
-	Expiration time.Duration
+	Secret         string
+	Expiration     time.Duration
+	ClaimsCacheTTL time.Duration
+	// ClaimsCacheStale is how long past ClaimsCacheTTL cached claims are
+	// still served while they are reloaded in the background
+	ClaimsCacheStale time.Duration
+	// KeyRefreshInterval is how often keys rotated on other instances are
+	// picked up
+	KeyRefreshInterval time.Duration
+	// KeyEncryptionKey, if set, encrypts the secrets of rotated keys in the
+	// database
+	KeyEncryptionKey string
+}
+
+// PersonalTokenConfig holds personal access token configuration
+type PersonalTokenConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+	// RequireSignatures makes every token sign its mutating requests, not
+	// only those with a signing key registered
+	RequireSignatures bool
+	// SignatureMaxSkew is how far a signature's timestamp may be from the
+	// server's clock, bounding how long a signed request can be replayed
+	SignatureMaxSkew time.Duration
+}
+
+// OIDCConfig holds OpenID Connect provider configuration
+type OIDCConfig struct {
+	Enabled        bool
+	Issuer         string
+	SigningKeyFile string
+	CodeTTL        time.Duration
+	IDTokenTTL     time.Duration
+
+	DeviceCodeTTL      time.Duration
+	DevicePollInterval time.Duration
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level  string
 	Format string
+	// Sampling lists category=N entries; one in every N request logs of the
+	// category is written. Categories are health, not_found and rate_limited.
+	Sampling []string
+	// ErrorBurst is how many entries with the same error message are
+	// written per ErrorWindow; the rest are summarized. A zero ErrorWindow
+	// writes every entry.
+	ErrorWindow time.Duration
+	ErrorBurst  int
+	// RecentEntries is how many of the latest log entries are kept in
+	// memory for support bundles; zero keeps none
+	RecentEntries int
+}
+
+// WriteAheadConfig holds configuration for queuing creates during outages
+type WriteAheadConfig struct {
+	Enabled bool
+	// Dir is where queued requests are stored until the database recovers
+	Dir string
+	// RetryInterval is how often pending requests are retried
+	RetryInterval time.Duration
+}
+
+// AuditConfig holds audit log configuration
+type AuditConfig struct {
+	// Enabled records mutating requests in the hash-chained audit log
+	Enabled bool
+}
+
+// RunbookConfig holds operational runbook configuration
+type RunbookConfig struct {
+	// Enabled serves the admin endpoints that flush caches, rotate JWT keys,
+	// re-run migrations and replay failed jobs and webhook deliveries
+	Enabled bool
+}
+
+// CORSConfig holds Cross-Origin Resource Sharing configuration
+type CORSConfig struct {
+	// AllowedOrigins lists the origins browsers may call the API from, such
+	// as https://app.example.com. "*" allows every origin, and a leading "*."
+	// in the host, as in https://*.example.com, every subdomain.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and authorization headers;
+	// it cannot be combined with the "*" origin
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// EmailConfig holds outbound email configuration
+type EmailConfig struct {
+	// Enabled queues emails, such as the welcome email, and delivers them
+	Enabled bool
+	From    string
+	// SMTPHost is the relay emails are sent through; when empty, emails are
+	// logged instead of sent
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	// WebhookSecret verifies provider delivery notifications; empty
+	// disables the webhook
+	WebhookSecret string
+	// PollInterval is how often queued emails are delivered
+	PollInterval time.Duration
+	// MaxAttempts is how many times an email is tried before it fails
+	MaxAttempts int
+	// RetryBackoff is the wait after the first failed attempt; it doubles
+	// after each one that follows
+	RetryBackoff time.Duration
+}
+
+// LiveConfig holds configuration of live user updates over WebSocket
+type LiveConfig struct {
+	// Enabled serves /ws, pushing user changes to connected clients
+	Enabled bool
+	// AllowedOrigins lists the origins browsers may connect from; empty
+	// allows only the server's own host
+	AllowedOrigins []string
+	// SendBuffer is how many messages may wait for a client; clients that
+	// fall further behind are disconnected
+	SendBuffer int
+	// PingInterval is how often clients are pinged; those that do not answer
+	// within two intervals are disconnected
+	PingInterval time.Duration
+	// ShutdownGrace is how long clients have at shutdown to acknowledge the
+	// close frame before their connections are cut
+	ShutdownGrace time.Duration
+}
+
+// EventsConfig holds the domain event publisher configuration
+type EventsConfig struct {
+	// Backend is where domain events go: inproc, to subscribers in this
+	// process, kafka or nats
+	Backend string
+	// KafkaBrokers lists the Kafka bootstrap brokers as host:port
+	KafkaBrokers []string
+	// KafkaTopic is the topic every event is written to, keyed by subject
+	KafkaTopic string
+	// NATSURL is the NATS server to connect to
+	NATSURL string
+	// NATSSubjectPrefix is prepended to event types to form NATS subjects,
+	// e.g. go-crud.user.created
+	NATSSubjectPrefix string
+	// Outbox writes events to the outbox table in the transaction of the
+	// change that raises them, and a background relay publishes them, so no
+	// event is lost to a crash. The backend then confirms every event.
+	Outbox bool
+	// OutboxPollInterval is how often the relay looks for unpublished events
+	OutboxPollInterval time.Duration
+	// OutboxRetryBackoff is the wait before the first retry of an event the
+	// backend rejected; it doubles with each attempt
+	OutboxRetryBackoff time.Duration
+	// OutboxRetention is how long published events are kept in the outbox
+	OutboxRetention time.Duration
+}
+
+// WebhookConfig holds webhook delivery configuration
+type WebhookConfig struct {
+	// Enabled serves the webhook endpoints and delivers user lifecycle
+	// events to subscribers
+	Enabled bool
+	// Workers is how many deliveries are made at once
+	Workers int
+	// QueueSize bounds the events and deliveries waiting; events published
+	// while it is full are dropped
+	QueueSize int
+	// Timeout bounds one delivery attempt
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is tried before it is dropped
+	MaxAttempts int
+	// RetryBackoff is the wait after the first failed attempt; it doubles
+	// after each one that follows
+	RetryBackoff time.Duration
+}
+
+// WatchConfig holds the configuration of watches on users
+type WatchConfig struct {
+	// Enabled serves the watch endpoints and notifies watchers of changes
+	Enabled bool
+	// DefaultTTL is how long a watch lasts when its request names no TTL;
+	// MaxTTL caps the TTL a request may ask for
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+	// QueueSize bounds the events waiting to be evaluated; events published
+	// while it is full are dropped
+	QueueSize int
+	// SweepInterval is how often expired watches are deleted
+	SweepInterval time.Duration
+	// StreamHeartbeat is how often an idle event stream is sent a comment,
+	// so proxies keep it open
+	StreamHeartbeat time.Duration
+	// ShutdownGrace is how long event streams have at shutdown to send
+	// their final event before their connections are cut
+	ShutdownGrace time.Duration
+}
+
+// MemoryConfig holds the memory pressure watchdog configuration
+type MemoryConfig struct {
+	// Limit is the memory the process may use in bytes, such as the
+	// container's limit; zero takes GOMEMLIMIT, and with neither the
+	// watchdog is off
+	Limit int64
+	// Threshold is the fraction of Limit past which memory is short
+	Threshold     float64
+	CheckInterval time.Duration
+	// ProfileDir, if set, receives a heap profile each time memory runs short
+	ProfileDir string
+}
+
+// CompressionConfig holds response compression configuration
+type CompressionConfig struct {
+	// Enabled compresses responses for clients that accept gzip, or brotli
+	// with Brotli
+	Enabled bool
+	// MinSize is the smallest body, in bytes, worth compressing
+	MinSize int
+	Brotli  bool
+}
+
+// PaginationConfig holds the page sizes of list endpoints
+type PaginationConfig struct {
+	// DefaultLimit is the page size when a request sets none or one out of range
+	DefaultLimit int
+	// MaxLimit is the largest page size a request may ask for
+	MaxLimit int
+	// Routes lists per-route overrides as route=default:max entries
+	Routes []string
+	// MaxOffset is the deepest row, page times limit, GET /users pages may
+	// reach; zero allows any depth
+	MaxOffset int
+	// DeepPages is "reject" to refuse pages past MaxOffset or "cursor" to
+	// answer them with cursor pagination from MaxOffset
+	DeepPages string
+}
+
+// MetricsConfig holds Prometheus metrics configuration
+type MetricsConfig struct {
+	// Enabled instruments requests and serves /metrics to internal networks
+	Enabled bool
+	// DashboardWindow is the span the admin dashboard's rates cover
+	DashboardWindow time.Duration
+}
+
+// RateLimitConfig holds per-client rate limiting configuration for the API
+type RateLimitConfig struct {
+	Enabled bool
+	// RequestsPerSecond is the sustained rate each client IP may make requests at
+	RequestsPerSecond float64
+	// Burst is how many requests a client can make at once after being idle
+	Burst int
+}
+
+// PriorityConfig holds the request prioritization configuration
+type PriorityConfig struct {
+	Enabled bool
+	// MaxConcurrent is how many requests are served at once; more wait
+	MaxConcurrent int
+	// Queue sizes bound how many requests of each class may wait
+	CriticalQueue int
+	ReadQueue     int
+	WriteQueue    int
+	BulkQueue     int
+	// QueueTimeout is how long a request may wait before it is rejected
+	QueueTimeout time.Duration
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	// Enabled exports spans for requests and SQL statements over OTLP/HTTP
+	Enabled bool
+	// Endpoint is the collector's base URL; an http:// URL disables TLS
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1
+	SampleRatio float64
+}
+
+// RedisConfig holds the connection to the Redis shared by all instances
+type RedisConfig struct {
+	// URL is a redis:// or rediss:// URL; empty keeps state per instance
+	URL string
+}
+
+// CacheConfig selects the cache used by caching layers and the rate limiter
+type CacheConfig struct {
+	// Driver is "memory", "redis" (which needs REDIS_URL) or "memcached"
+	Driver string
+	// MemoryMaxBytes bounds the size of the values the memory cache holds
+	MemoryMaxBytes int64
+	// MemcachedServers are the host:port addresses of the memcached servers
+	MemcachedServers []string
+	// MissingUserTTL is how long lookups of a user ID or email that found
+	// no user are remembered; zero disables it
+	MissingUserTTL time.Duration
+	// Users caches users by ID and email in Redis, which needs REDIS_URL,
+	// for UserTTL; writes drop a user's entry
+	Users   bool
+	UserTTL time.Duration
+	// MemoryUsers keeps up to MemoryUserEntries users in process memory
+	// for UserTTL, with concurrent misses for a user sharing one query.
+	// Entries are per instance, so it suits single-instance deployments.
+	MemoryUsers       bool
+	MemoryUserEntries int
+	// WarmOnStart caches the claims of the most recently active users in
+	// the background when the server starts
+	WarmOnStart bool
+	// WarmUsers is how many users a warm-up caches unless
+	// POST /admin/cache/warm asks for another number
+	WarmUsers int
+}
+
+// SchemaTransitionConfig holds configuration for expand/contract schema changes
+type SchemaTransitionConfig struct {
+	// AgePhase is the phase of the move from users.age to
+	// users.date_of_birth: "old", "dual-write", "read-new" or "new"
+	AgePhase string
+	// BackfillBatchSize rows are backfilled at a time, BackfillPause apart
+	BackfillBatchSize int
+	BackfillPause     time.Duration
+}
+
+// IDConfig holds configuration for the IDs exposed by the API
+type IDConfig struct {
+	// Codec is "plain" for database IDs or "sqids" for opaque strings
+	Codec string
+	// Alphabet seeds the sqids encoding; keep it private and never change it
+	// once IDs have been handed out
+	Alphabet  string
+	MinLength int
+}
+
+// AuthConfig holds route authentication configuration
+type AuthConfig struct {
+	// PublicPaths lists routes reachable without a token, relative to the
+	// API base path. Entries are "/path" for every method or "METHOD /path".
+	PublicPaths []string
+	// Registration is who may self-register: "open" for anyone, "approval"
+	// for anyone pending an admin's approval, or "closed" for nobody
+	Registration string
+}
+
+// I18nConfig holds localization configuration
+type I18nConfig struct {
+	// SupportedLocales lists BCP 47 tags; the first one is the default
+	SupportedLocales []string
+	// TenantLocales lists tenant=locale entries. Numbers and amounts for
+	// a listed tenant's requests are formatted for its locale rather than
+	// the negotiated one.
+	TenantLocales []string
 }
 
 // Load loads configuration from environment variables
 func Load() *Config {
+	return (&loader{}).load()
+}
+
+// load builds the configuration from the settings l looks up
+func (l *loader) load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Host: getEnv("HOST", "localhost"),
-			Port: getEnv("PORT", "8080"),
-			Mode: getEnv("GIN_MODE", "debug"),
+			Host:           l.getEnv("HOST", "localhost"),
+			Port:           l.getEnv("PORT", "8080"),
+			Mode:           l.getEnv("GIN_MODE", "debug"),
+			MethodOverride: l.getEnvAsBool("HTTP_METHOD_OVERRIDE", false),
+			TrailingSlash:  l.getEnv("PATH_TRAILING_SLASH", "redirect"),
+			LowercasePaths: l.getEnvAsBool("PATH_LOWERCASE", false),
+			RecordExamples: l.getEnvAsBool("RECORD_EXAMPLES", false),
+			Swagger:        l.getEnvAsBool("SWAGGER_ENABLED", true),
+			OpenAPIVersion: l.getEnv("OPENAPI_VERSION", "3.0"),
+			ReadTimeout:    l.getEnvAsDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:   l.getEnvAsDuration("SERVER_WRITE_TIMEOUT", 15*time.Second),
+			RequestTimeout: l.getEnvAsDuration("REQUEST_TIMEOUT", 10*time.Second),
+
+			TransformTimeout: l.getEnvAsDuration("TRANSFORM_TIMEOUT", 50*time.Millisecond),
+			MaxBodyBytes:     int64(l.getEnvAsInt("MAX_BODY_BYTES", 1<<20)),
+
+			Region:   l.getEnv("REGION", ""),
+			BasePath: l.getEnvAsPath("BASE_PATH", ""),
+
+			TrustedProxies: l.getEnvAsSlice("TRUSTED_PROXIES", nil),
+
+			StartupReportFile: l.getEnv("STARTUP_REPORT_FILE", ""),
+		},
+		GRPC: GRPCConfig{
+			Enabled: l.getEnvAsBool("GRPC_ENABLED", false),
+			Port:    l.getEnv("GRPC_PORT", "9090"),
+			Gateway: l.getEnvAsBool("GRPC_GATEWAY_ENABLED", false),
 		},
 		Database: DatabaseConfig{
-			Host:         getEnv("DB_HOST", "localhost"),
-			Port:         getEnv("DB_PORT", "5432"),
-			User:         getEnv("DB_USER", "postgres"),
-			Password:     getEnv("DB_PASSWORD", "password"),
-			Name:         getEnv("DB_NAME", "crud_demo"),
-			SSLMode:      getEnv("DB_SSLMODE", "disable"),
-			MaxOpenConns: getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-			MaxIdleConns: getEnvAsInt("DB_MAX_IDLE_CONNS", 25),
-			MaxLifetime:  getEnvAsDuration("DB_MAX_LIFETIME", 5*time.Minute),
+			Driver:   l.getEnv("DB_DRIVER", "postgres"),
+			Host:     l.getEnv("DB_HOST", "localhost"),
+			Port:     l.getEnv("DB_PORT", "5432"),
+			User:     l.getEnv("DB_USER", "postgres"),
+			Password: l.getEnv("DB_PASSWORD", "password"),
+			Name:     l.getEnv("DB_NAME", "crud_demo"),
+			SSLMode:  l.getEnv("DB_SSLMODE", "disable"),
+
+			SQLitePath: l.getEnv("DB_SQLITE_PATH", "go-crud.db"),
+
+			ApplicationName: l.getEnv("DB_APPLICATION_NAME", "go-crud"),
+			API: PoolConfig{
+				Name:         "api",
+				MaxOpenConns: l.getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
+				MaxIdleConns: l.getEnvAsInt("DB_MAX_IDLE_CONNS", 25),
+				MinConns:     l.getEnvAsInt("DB_MIN_CONNS", 0),
+				MaxIdleTime:  l.getEnvAsDuration("DB_MAX_IDLE_TIME", 30*time.Minute),
+				MaxLifetime:  l.getEnvAsDuration("DB_MAX_LIFETIME", 5*time.Minute),
+			},
+			Jobs: PoolConfig{
+				Name:         "jobs",
+				MaxOpenConns: l.getEnvAsInt("DB_JOBS_MAX_OPEN_CONNS", 5),
+				MaxIdleConns: l.getEnvAsInt("DB_JOBS_MAX_IDLE_CONNS", 2),
+				MinConns:     l.getEnvAsInt("DB_JOBS_MIN_CONNS", 0),
+				MaxIdleTime:  l.getEnvAsDuration("DB_JOBS_MAX_IDLE_TIME", 30*time.Minute),
+				MaxLifetime:  l.getEnvAsDuration("DB_JOBS_MAX_LIFETIME", 5*time.Minute),
+			},
+			Migrations: PoolConfig{
+				Name:            "migrations",
+				MaxOpenConns:    1,
+				MaxIdleConns:    1,
+				MultiStatements: true,
+				User:            l.getEnv("DB_MIGRATIONS_USER", ""),
+				Password:        l.getEnv("DB_MIGRATIONS_PASSWORD", ""),
+			},
+			PoolHealthCheckPeriod:   l.getEnvAsDuration("DB_POOL_HEALTH_CHECK_PERIOD", time.Minute),
+			MigrationMode:           l.getEnv("MIGRATION_MODE", "run"),
+			MigrationPollInterval:   l.getEnvAsDuration("MIGRATION_POLL_INTERVAL", 2*time.Second),
+			ReadyRequiresMigrations: l.getEnvAsBool("READY_REQUIRES_MIGRATIONS", true),
+
+			ReplicaHost: l.getEnv("DB_REPLICA_HOST", ""),
+			ReplicaPort: l.getEnv("DB_REPLICA_PORT", "5432"),
+
+			FollowerReads: l.getEnvAsBool("DB_FOLLOWER_READS", false),
+
+			HealthCheckInterval: l.getEnvAsDuration("HEALTH_CHECK_INTERVAL", 10*time.Second),
+			SoftDeleteRetention: l.getEnvAsDuration("SOFT_DELETE_RETENTION", 30*24*time.Hour),
+			PurgeInterval:       l.getEnvAsDuration("PURGE_INTERVAL", time.Hour),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-secret-key"),
-			Expiration: getEnvAsDuration("JWT_EXPIRATION", 24*time.Hour),
+			Secret:           l.getEnv("JWT_SECRET", "your-secret-key"),
+			Expiration:       l.getEnvAsDuration("JWT_EXPIRATION", 24*time.Hour),
+			ClaimsCacheTTL:   l.getEnvAsDuration("CLAIMS_CACHE_TTL", 30*time.Second),
+			ClaimsCacheStale: l.getEnvAsDuration("CLAIMS_CACHE_STALE", 15*time.Second),
+
+			KeyRefreshInterval: l.getEnvAsDuration("JWT_KEY_REFRESH_INTERVAL", time.Minute),
+			KeyEncryptionKey:   l.getEnv("JWT_KEY_ENCRYPTION_KEY", ""),
 		},
 		Logging: LoggingConfig{
-			Level:  getEnv("LOG_LEVEL", "info"),
-			Format: getEnv("LOG_FORMAT", "json"),
+			Level:       l.getEnv("LOG_LEVEL", "info"),
+			Format:      l.getEnv("LOG_FORMAT", "json"),
+			Sampling:    l.getEnvAsSlice("LOG_SAMPLING", nil),
+			ErrorWindow: l.getEnvAsDuration("LOG_ERROR_WINDOW", time.Minute),
+			ErrorBurst:  l.getEnvAsInt("LOG_ERROR_BURST", 5),
+
+			RecentEntries: l.getEnvAsInt("LOG_RECENT_ENTRIES", 1000),
+		},
+		Tokens: PersonalTokenConfig{
+			DefaultTTL: l.getEnvAsDuration("PAT_DEFAULT_TTL", 30*24*time.Hour),
+			MaxTTL:     l.getEnvAsDuration("PAT_MAX_TTL", 365*24*time.Hour),
+
+			RequireSignatures: l.getEnvAsBool("PAT_REQUIRE_SIGNATURES", false),
+			SignatureMaxSkew:  l.getEnvAsDuration("PAT_SIGNATURE_MAX_SKEW", 5*time.Minute),
+		},
+		OIDC: OIDCConfig{
+			Enabled:        l.getEnvAsBool("OIDC_ENABLED", false),
+			Issuer:         l.getEnv("OIDC_ISSUER", "http://localhost:8080"),
+			SigningKeyFile: l.getEnv("OIDC_SIGNING_KEY_FILE", ""),
+			CodeTTL:        l.getEnvAsDuration("OIDC_CODE_TTL", time.Minute),
+			IDTokenTTL:     l.getEnvAsDuration("OIDC_ID_TOKEN_TTL", time.Hour),
+
+			DeviceCodeTTL:      l.getEnvAsDuration("OIDC_DEVICE_CODE_TTL", 10*time.Minute),
+			DevicePollInterval: l.getEnvAsDuration("OIDC_DEVICE_POLL_INTERVAL", 5*time.Second),
+		},
+		Queue: WriteAheadConfig{
+			Enabled:       l.getEnvAsBool("WRITE_AHEAD_ENABLED", false),
+			Dir:           l.getEnv("WRITE_AHEAD_DIR", "data/write-ahead"),
+			RetryInterval: l.getEnvAsDuration("WRITE_AHEAD_RETRY_INTERVAL", 5*time.Second),
+		},
+		IDs: IDConfig{
+			Codec:     l.getEnv("ID_CODEC", "plain"),
+			Alphabet:  l.getEnv("ID_ALPHABET", ""),
+			MinLength: l.getEnvAsInt("ID_MIN_LENGTH", 8),
+		},
+		Audit: AuditConfig{
+			Enabled: l.getEnvAsBool("AUDIT_ENABLED", false),
+		},
+		Runbook: RunbookConfig{
+			Enabled: l.getEnvAsBool("RUNBOOK_ENABLED", false),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: l.getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
+			AllowedMethods: l.getEnvAsSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowedHeaders: l.getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-Request-ID", "X-Consistency-Token"}),
+			ExposedHeaders: l.getEnvAsSlice("CORS_EXPOSED_HEADERS", []string{
+				"X-Request-ID", "X-Region", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Consistency-Token",
+			}),
+			AllowCredentials: l.getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           l.getEnvAsDuration("CORS_MAX_AGE", 24*time.Hour),
+		},
+		Metrics: MetricsConfig{
+			Enabled:         l.getEnvAsBool("METRICS_ENABLED", true),
+			DashboardWindow: l.getEnvAsDuration("METRICS_DASHBOARD_WINDOW", time.Minute),
+		},
+		Limits: RateLimitConfig{
+			Enabled:           l.getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			RequestsPerSecond: l.getEnvAsFloat("RATE_LIMIT_RPS", 10),
+			Burst:             l.getEnvAsInt("RATE_LIMIT_BURST", 20),
+		},
+		Priority: PriorityConfig{
+			Enabled:       l.getEnvAsBool("PRIORITY_ENABLED", false),
+			MaxConcurrent: l.getEnvAsInt("PRIORITY_MAX_CONCURRENT", 100),
+			CriticalQueue: l.getEnvAsInt("PRIORITY_QUEUE_CRITICAL", 100),
+			ReadQueue:     l.getEnvAsInt("PRIORITY_QUEUE_READ", 200),
+			WriteQueue:    l.getEnvAsInt("PRIORITY_QUEUE_WRITE", 100),
+			BulkQueue:     l.getEnvAsInt("PRIORITY_QUEUE_BULK", 10),
+			QueueTimeout:  l.getEnvAsDuration("PRIORITY_QUEUE_TIMEOUT", 2*time.Second),
+		},
+		Tracing: TracingConfig{
+			Enabled:     l.getEnvAsBool("TRACING_ENABLED", false),
+			Endpoint:    l.getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			ServiceName: l.getEnv("OTEL_SERVICE_NAME", "go-crud"),
+			SampleRatio: l.getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
+		},
+		Redis: RedisConfig{
+			URL: l.getEnv("REDIS_URL", ""),
+		},
+		Cache: CacheConfig{
+			Driver:            l.getEnv("CACHE_DRIVER", "memory"),
+			MemoryMaxBytes:    int64(l.getEnvAsInt("CACHE_MEMORY_MAX_BYTES", 64<<20)),
+			MemcachedServers:  l.getEnvAsSlice("MEMCACHED_SERVERS", []string{"localhost:11211"}),
+			MissingUserTTL:    l.getEnvAsDuration("CACHE_MISSING_USER_TTL", 10*time.Second),
+			Users:             l.getEnvAsBool("CACHE_USERS", false),
+			UserTTL:           l.getEnvAsDuration("CACHE_USER_TTL", 5*time.Minute),
+			MemoryUsers:       l.getEnvAsBool("CACHE_MEMORY_USERS", false),
+			MemoryUserEntries: l.getEnvAsInt("CACHE_MEMORY_USER_ENTRIES", 10000),
+			WarmOnStart:       l.getEnvAsBool("CACHE_WARM_ON_START", false),
+			WarmUsers:         l.getEnvAsInt("CACHE_WARM_USERS", 1000),
+		},
+		Email: EmailConfig{
+			Enabled:       l.getEnvAsBool("EMAIL_ENABLED", false),
+			From:          l.getEnv("EMAIL_FROM", "no-reply@localhost"),
+			SMTPHost:      l.getEnv("SMTP_HOST", ""),
+			SMTPPort:      l.getEnv("SMTP_PORT", "587"),
+			SMTPUsername:  l.getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:  l.getEnv("SMTP_PASSWORD", ""),
+			WebhookSecret: l.getEnv("EMAIL_WEBHOOK_SECRET", ""),
+			PollInterval:  l.getEnvAsDuration("EMAIL_POLL_INTERVAL", 5*time.Second),
+			MaxAttempts:   l.getEnvAsInt("EMAIL_MAX_ATTEMPTS", 5),
+			RetryBackoff:  l.getEnvAsDuration("EMAIL_RETRY_BACKOFF", 30*time.Second),
+		},
+		Webhooks: WebhookConfig{
+			Enabled:      l.getEnvAsBool("WEBHOOKS_ENABLED", false),
+			Workers:      l.getEnvAsInt("WEBHOOK_WORKERS", 4),
+			QueueSize:    l.getEnvAsInt("WEBHOOK_QUEUE_SIZE", 1000),
+			Timeout:      l.getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:  l.getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 5),
+			RetryBackoff: l.getEnvAsDuration("WEBHOOK_RETRY_BACKOFF", time.Second),
+		},
+		Watches: WatchConfig{
+			Enabled:         l.getEnvAsBool("WATCHES_ENABLED", false),
+			DefaultTTL:      l.getEnvAsDuration("WATCH_DEFAULT_TTL", 24*time.Hour),
+			MaxTTL:          l.getEnvAsDuration("WATCH_MAX_TTL", 30*24*time.Hour),
+			QueueSize:       l.getEnvAsInt("WATCH_QUEUE_SIZE", 1000),
+			SweepInterval:   l.getEnvAsDuration("WATCH_SWEEP_INTERVAL", 10*time.Minute),
+			StreamHeartbeat: l.getEnvAsDuration("WATCH_STREAM_HEARTBEAT", 15*time.Second),
+			ShutdownGrace:   l.getEnvAsDuration("WATCH_SHUTDOWN_GRACE", 2*time.Second),
+		},
+		Live: LiveConfig{
+			Enabled:        l.getEnvAsBool("WEBSOCKET_ENABLED", false),
+			AllowedOrigins: l.getEnvAsSlice("WEBSOCKET_ALLOWED_ORIGINS", nil),
+			SendBuffer:     l.getEnvAsInt("WEBSOCKET_SEND_BUFFER", 64),
+			PingInterval:   l.getEnvAsDuration("WEBSOCKET_PING_INTERVAL", 30*time.Second),
+			ShutdownGrace:  l.getEnvAsDuration("WEBSOCKET_SHUTDOWN_GRACE", 2*time.Second),
+		},
+		Events: EventsConfig{
+			Backend:            l.getEnv("EVENTS_BACKEND", "inproc"),
+			KafkaBrokers:       l.getEnvAsSlice("EVENTS_KAFKA_BROKERS", nil),
+			KafkaTopic:         l.getEnv("EVENTS_KAFKA_TOPIC", "go-crud.users"),
+			NATSURL:            l.getEnv("EVENTS_NATS_URL", "nats://127.0.0.1:4222"),
+			NATSSubjectPrefix:  l.getEnv("EVENTS_NATS_SUBJECT_PREFIX", "go-crud"),
+			Outbox:             l.getEnvAsBool("EVENTS_OUTBOX", false),
+			OutboxPollInterval: l.getEnvAsDuration("EVENTS_OUTBOX_POLL_INTERVAL", time.Second),
+			OutboxRetryBackoff: l.getEnvAsDuration("EVENTS_OUTBOX_RETRY_BACKOFF", time.Second),
+			OutboxRetention:    l.getEnvAsDuration("EVENTS_OUTBOX_RETENTION", 24*time.Hour),
+		},
+		Memory: MemoryConfig{
+			Limit:         int64(l.getEnvAsInt("MEMORY_LIMIT_BYTES", 0)),
+			Threshold:     l.getEnvAsFloat("MEMORY_PRESSURE_THRESHOLD", 0.85),
+			CheckInterval: l.getEnvAsDuration("MEMORY_CHECK_INTERVAL", time.Second),
+			ProfileDir:    l.getEnv("MEMORY_PROFILE_DIR", ""),
+		},
+		Compress: CompressionConfig{
+			Enabled: l.getEnvAsBool("COMPRESSION_ENABLED", true),
+			MinSize: l.getEnvAsInt("COMPRESSION_MIN_SIZE", 1024),
+			Brotli:  l.getEnvAsBool("COMPRESSION_BROTLI", false),
+		},
+		Paging: PaginationConfig{
+			DefaultLimit: l.getEnvAsInt("PAGE_DEFAULT_LIMIT", 10),
+			MaxLimit:     l.getEnvAsInt("PAGE_MAX_LIMIT", 100),
+			Routes:       l.getEnvAsSlice("PAGE_LIMITS", nil),
+			MaxOffset:    l.getEnvAsInt("PAGE_MAX_OFFSET", 10000),
+			DeepPages:    l.getEnv("PAGE_DEEP_PAGES", "reject"),
+		},
+		Schema: SchemaTransitionConfig{
+			AgePhase:          l.getEnv("AGE_TRANSITION_PHASE", "old"),
+			BackfillBatchSize: l.getEnvAsInt("BACKFILL_BATCH_SIZE", 1000),
+			BackfillPause:     l.getEnvAsDuration("BACKFILL_PAUSE", time.Second),
+		},
+		Auth: AuthConfig{
+			PublicPaths: l.getEnvAsSlice("AUTH_PUBLIC_PATHS", []string{
+				"/health",
+				"/version",
+				"/changelog",
+				"/_meta",
+				"/auth/login",
+				"/auth/register",
+				"GET /users",
+				"GET /users/{id}",
+			}),
+			Registration: l.getEnv("REGISTRATION_MODE", "open"),
+		},
+		I18n: I18nConfig{
+			SupportedLocales: l.getEnvAsSlice("SUPPORTED_LOCALES", []string{"en"}),
+			TenantLocales:    l.getEnvAsSlice("TENANT_LOCALES", nil),
 		},
 	}
 }
 
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultVal string) string {
-	if value, exists := os.LookupEnv(key); exists {
+// getEnv gets a setting, from the overrides, the environment or the config
+// file in that order, or returns a default value
+func (l *loader) getEnv(key, defaultVal string) string {
+	if value, exists := l.lookup(key); exists {
 		return value
 	}
 	return defaultVal
 }
 
 // getEnvAsInt gets an environment variable as integer or returns a default value
-func getEnvAsInt(name string, defaultVal int) int {
-	valueStr := getEnv(name, "")
+func (l *loader) getEnvAsInt(name string, defaultVal int) int {
+	valueStr := l.getEnv(name, "")
 	if value, err := strconv.Atoi(valueStr); err == nil {
 		return value
 	}
 	return defaultVal
 }
 
+// getEnvAsFloat gets an environment variable as float or returns a default value
+func (l *loader) getEnvAsFloat(name string, defaultVal float64) float64 {
+	valueStr := l.getEnv(name, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultVal
+}
+
 // getEnvAsDuration gets an environment variable as duration or returns a default value
-func getEnvAsDuration(name string, defaultVal time.Duration) time.Duration {
-	valueStr := getEnv(name, "")
+func (l *loader) getEnvAsDuration(name string, defaultVal time.Duration) time.Duration {
+	valueStr := l.getEnv(name, "")
 	if value, err := time.ParseDuration(valueStr); err == nil {
 		return value
 	}
 	return defaultVal
-}
\ No newline at end of file
+}
+
+// getEnvAsBool gets an environment variable as boolean or returns a default value
+func (l *loader) getEnvAsBool(name string, defaultVal bool) bool {
+	valueStr := l.getEnv(name, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultVal
+}
+
+// getEnvAsSlice gets a comma-separated environment variable as a slice or returns a default value
+func (l *loader) getEnvAsSlice(name string, defaultVal []string) []string {
+	valueStr := l.getEnv(name, "")
+	if valueStr == "" {
+		return defaultVal
+	}
+
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsPath gets an environment variable as a URL path prefix with a
+// leading slash and no trailing one, so "crud/" becomes "/crud" and "/"
+// becomes ""
+func (l *loader) getEnvAsPath(name string, defaultVal string) string {
+	path := strings.Trim(strings.TrimSpace(l.getEnv(name, defaultVal)), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
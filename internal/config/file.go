@@ -0,0 +1,204 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the config file loaded when none is named, if it exists
+const DefaultFile = "config.yaml"
+
+// Sources are the layers settings are read from besides the environment.
+// Overrides take precedence over the environment, which takes precedence
+// over the file, which takes precedence over the defaults.
+type Sources struct {
+	// File is a YAML file of settings; "" loads DefaultFile if it exists
+	File string
+	// Overrides map setting names, such as PORT or cors.max_age, to values
+	Overrides map[string]string
+}
+
+// BindFlags defines the -config and repeatable -set NAME=VALUE flags on fs,
+// which fill the returned sources once fs is parsed
+func BindFlags(fs *flag.FlagSet) *Sources {
+	src := &Sources{Overrides: make(map[string]string)}
+	fs.StringVar(&src.File, "config", "", "YAML config file (default "+DefaultFile+" if it exists)")
+	fs.Func("set", "override a setting, as `NAME=VALUE`; may be repeated", func(value string) error {
+		name, setting, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("want NAME=VALUE")
+		}
+		src.Overrides[name] = setting
+		return nil
+	})
+	return src
+}
+
+// LoadFrom loads configuration from src layered with environment variables.
+// Settings the configuration has no use for are rejected, so a misspelled
+// name is not silently ignored.
+func LoadFrom(src Sources) (*Config, error) {
+	l := &loader{used: make(map[string]bool)}
+
+	path, required := src.File, true
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	file, err := readFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && !required:
+	case err != nil:
+		return nil, err
+	default:
+		l.file = file
+	}
+
+	l.overrides = make(map[string]string, len(src.Overrides))
+	for name, value := range src.Overrides {
+		l.overrides[settingName(name)] = value
+	}
+
+	cfg := l.load()
+	if unknown := l.unused(); len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown settings: %s", strings.Join(unknown, ", "))
+	}
+	return cfg, nil
+}
+
+// loader looks up settings in its layers
+type loader struct {
+	file      map[string]string
+	overrides map[string]string
+	// used records the names looked up, to find unknown settings
+	used map[string]bool
+}
+
+// lookup returns the value of the setting name from the highest layer
+// setting it
+func (l *loader) lookup(name string) (string, bool) {
+	if l.used != nil {
+		l.used[name] = true
+	}
+	if value, ok := l.overrides[name]; ok {
+		return value, true
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	value, ok := l.file[name]
+	return value, ok
+}
+
+// unused returns the settings in the file and overrides that were never
+// looked up
+func (l *loader) unused() []string {
+	var names []string
+	for _, layer := range []map[string]string{l.file, l.overrides} {
+		for name := range layer {
+			if !l.used[name] {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readFile reads the settings of a YAML config file, naming each by its
+// environment variable
+func readFile(path string) (map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format, want .yaml or .yml", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	settings := make(map[string]string)
+	if err := flatten(settings, "", doc); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// flatten adds the settings of section to settings. Nested keys join into
+// one name, so cors: {max_age: 1h} sets CORS_MAX_AGE, and lists join into a
+// comma-separated value.
+func flatten(settings map[string]string, prefix string, section map[string]interface{}) error {
+	for key, value := range section {
+		name := settingName(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		var setting string
+		switch v := value.(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			if err := flatten(settings, name, v); err != nil {
+				return err
+			}
+			continue
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				s, ok := scalar(item)
+				if !ok {
+					return fmt.Errorf("%s: list items must be plain values", name)
+				}
+				items[i] = s
+			}
+			setting = strings.Join(items, ",")
+		default:
+			s, ok := scalar(v)
+			if !ok {
+				return fmt.Errorf("%s: unsupported value", name)
+			}
+			setting = s
+		}
+
+		if _, ok := settings[name]; ok {
+			return fmt.Errorf("%s is set more than once", name)
+		}
+		settings[name] = setting
+	}
+	return nil
+}
+
+// scalar formats a plain YAML value as it would be written in the environment
+func scalar(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// settingName returns the environment variable a config file key or
+// override stands for, so cors.max-age becomes CORS_MAX_AGE
+func settingName(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(strings.TrimSpace(key)))
+}
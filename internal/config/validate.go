@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/cache"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/openapi"
+)
+
+// exampleJWTSecret is the JWT_SECRET default and .env.example value
+const exampleJWTSecret = "your-secret-key"
+
+// ValidationError lists every problem Validate found
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks settings that would otherwise fail, or be unsafe, only
+// once the server runs. It reports every problem at once as a
+// *ValidationError, each naming the setting to change.
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	checkPort := func(name, port string) {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			addf("%s %q is not a port number between 1 and 65535", name, port)
+		}
+	}
+	debug := c.Server.Mode == "debug"
+
+	checkPort("PORT", c.Server.Port)
+	if c.GRPC.Enabled {
+		checkPort("GRPC_PORT", c.GRPC.Port)
+	}
+	if c.Server.RequestTimeout > 0 && c.Server.WriteTimeout > 0 && c.Server.RequestTimeout >= c.Server.WriteTimeout {
+		addf("REQUEST_TIMEOUT (%s) must be below SERVER_WRITE_TIMEOUT (%s), so timed out requests can still be answered",
+			c.Server.RequestTimeout, c.Server.WriteTimeout)
+	}
+
+	if !debug && (c.JWT.Secret == "" || c.JWT.Secret == exampleJWTSecret) {
+		addf("JWT_SECRET must be set to a secret of your own outside GIN_MODE=debug")
+	}
+	if c.JWT.Expiration <= 0 {
+		addf("JWT_EXPIRATION must be positive, got %s", c.JWT.Expiration)
+	}
+
+	switch c.Database.Driver {
+	case "postgres", "mysql", "sqlite":
+	default:
+		addf("DB_DRIVER %q is not postgres, mysql or sqlite", c.Database.Driver)
+	}
+	if c.Database.Driver != "sqlite" {
+		checkPort("DB_PORT", c.Database.Port)
+		if c.Database.ReplicaHost != "" {
+			checkPort("DB_REPLICA_PORT", c.Database.ReplicaPort)
+		}
+		if !debug && c.Database.Password == "" {
+			addf("DB_PASSWORD must be set outside GIN_MODE=debug")
+		}
+	}
+	checkPool := func(prefix string, pool PoolConfig) {
+		if pool.MaxOpenConns < 1 {
+			addf("%s_MAX_OPEN_CONNS must be at least 1, got %d", prefix, pool.MaxOpenConns)
+		}
+		if pool.MaxIdleConns < 0 || pool.MaxIdleConns > pool.MaxOpenConns {
+			addf("%s_MAX_IDLE_CONNS must be between 0 and %s_MAX_OPEN_CONNS (%d), got %d",
+				prefix, prefix, pool.MaxOpenConns, pool.MaxIdleConns)
+		}
+		if pool.MinConns < 0 || pool.MinConns > pool.MaxOpenConns {
+			addf("%s_MIN_CONNS must be between 0 and %s_MAX_OPEN_CONNS (%d), got %d",
+				prefix, prefix, pool.MaxOpenConns, pool.MinConns)
+		}
+		if pool.MaxIdleTime < 0 || pool.MaxLifetime < 0 {
+			addf("%s_MAX_IDLE_TIME and %s_MAX_LIFETIME cannot be negative", prefix, prefix)
+		}
+	}
+	checkPool("DB", c.Database.API)
+	checkPool("DB_JOBS", c.Database.Jobs)
+	switch c.Database.MigrationMode {
+	case "run", "wait":
+	default:
+		addf("MIGRATION_MODE %q is not run or wait", c.Database.MigrationMode)
+	}
+
+	// Only Postgres has the tables of these features
+	if c.Database.Driver != "postgres" {
+		var features []string
+		for name, enabled := range map[string]bool{
+			"AUDIT_ENABLED":              c.Audit.Enabled,
+			"EMAIL_ENABLED":              c.Email.Enabled,
+			"OIDC_ENABLED":               c.OIDC.Enabled,
+			"WEBHOOKS_ENABLED":           c.Webhooks.Enabled,
+			"WATCHES_ENABLED":            c.Watches.Enabled,
+			"EVENTS_OUTBOX":              c.Events.Outbox,
+			"REGISTRATION_MODE=approval": c.Auth.Registration == models.RegistrationApproval,
+		} {
+			if enabled {
+				features = append(features, name)
+			}
+		}
+		if len(features) > 0 {
+			sort.Strings(features)
+			addf("%s need DB_DRIVER=postgres", strings.Join(features, ", "))
+		}
+	}
+	if c.Runbook.Enabled && !c.Audit.Enabled {
+		addf("RUNBOOK_ENABLED needs AUDIT_ENABLED, so every runbook action is audited")
+	}
+
+	if _, err := idcodec.New(c.IDs.Codec, c.IDs.Alphabet, c.IDs.MinLength); err != nil {
+		addf("ID_CODEC: %v", err)
+	}
+	if _, err := models.ParseTransitionPhase(c.Schema.AgePhase); err != nil {
+		addf("AGE_TRANSITION_PHASE: %v", err)
+	}
+	switch c.Auth.Registration {
+	case models.RegistrationOpen, models.RegistrationApproval, models.RegistrationClosed:
+	default:
+		addf("REGISTRATION_MODE %q is not %s, %s or %s", c.Auth.Registration,
+			models.RegistrationOpen, models.RegistrationApproval, models.RegistrationClosed)
+	}
+	if !openapi.Supported(c.Server.OpenAPIVersion) {
+		addf("OPENAPI_VERSION %q is not supported", c.Server.OpenAPIVersion)
+	}
+	if err := c.CORS.Validate(); err != nil {
+		addf("CORS: %v", err)
+	}
+	if _, err := ParseLogSampling(c.Logging.Sampling); err != nil {
+		addf("LOG_SAMPLING: %v", err)
+	}
+	if _, err := c.Paging.RouteLimits(); err != nil {
+		addf("pagination: %v", err)
+	}
+
+	if err := cache.ValidateDriver(c.Cache.Driver); err != nil {
+		addf("CACHE_DRIVER: %v", err)
+	}
+	if c.Redis.URL == "" {
+		if c.Cache.Driver == cache.DriverRedis {
+			addf("CACHE_DRIVER=redis needs REDIS_URL")
+		}
+		if c.Cache.Users {
+			addf("CACHE_USERS needs REDIS_URL")
+		}
+	}
+
+	if c.Memory.Threshold <= 0 || c.Memory.Threshold > 1 {
+		addf("MEMORY_PRESSURE_THRESHOLD must be above 0 and at most 1, got %g", c.Memory.Threshold)
+	}
+	if c.Memory.CheckInterval <= 0 {
+		addf("MEMORY_CHECK_INTERVAL must be positive, got %s", c.Memory.CheckInterval)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Validate returns an error for an allowed origin that is not "*" or an
+// http or https origin, and for credentials allowed from every origin
+func (c CORSConfig) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("credentials cannot be allowed from every origin")
+			}
+			continue
+		}
+		// Only a leading label may be a wildcard
+		concrete := strings.Replace(origin, "://*.", "://x.", 1)
+		u, err := url.Parse(concrete)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.User != nil || strings.Contains(concrete, "*") {
+			return fmt.Errorf("invalid CORS origin %q, want an origin such as https://app.example.com or https://*.example.com", origin)
+		}
+	}
+	return nil
+}
+
+// ParseLogSampling parses LOG_SAMPLING entries of the form category=N into
+// the N of each category
+func ParseLogSampling(entries []string) (map[string]uint64, error) {
+	rates := make(map[string]uint64, len(entries))
+	for _, entry := range entries {
+		category, rate, ok := strings.Cut(entry, "=")
+		n, err := strconv.ParseUint(rate, 10, 64)
+		if !ok || category == "" || err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid log sampling %q, want category=N with N at least 1", entry)
+		}
+		rates[category] = n
+	}
+	return rates, nil
+}
+
+// RouteLimits returns the page sizes of every paginated route: the defaults,
+// or those of the route's PAGE_LIMITS entry. It also checks the deep page
+// settings.
+func (p PaginationConfig) RouteLimits() (map[string]models.PageLimits, error) {
+	defaults := models.PageLimits{DefaultLimit: p.DefaultLimit, MaxLimit: p.MaxLimit}
+	if err := checkPageLimits(defaults); err != nil {
+		return nil, err
+	}
+	if p.MaxOffset < 0 {
+		return nil, fmt.Errorf("maximum page offset must not be negative, got %d", p.MaxOffset)
+	}
+	switch p.DeepPages {
+	case "", "reject", "cursor":
+	default:
+		return nil, fmt.Errorf("invalid deep page handling %q, want reject or cursor", p.DeepPages)
+	}
+
+	routes := make(map[string]models.PageLimits, len(models.PageRoutes))
+	for _, route := range models.PageRoutes {
+		routes[route] = defaults
+	}
+	for _, entry := range p.Routes {
+		route, sizes, _ := strings.Cut(entry, "=")
+		if _, ok := routes[route]; !ok {
+			return nil, fmt.Errorf("unknown paginated route %q in %q", route, entry)
+		}
+		defaultLimit, maxLimit, ok := strings.Cut(sizes, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid page limits %q, want route=default:max", entry)
+		}
+
+		var limits models.PageLimits
+		var err error
+		if limits.DefaultLimit, err = strconv.Atoi(defaultLimit); err != nil {
+			return nil, fmt.Errorf("invalid default page size in %q", entry)
+		}
+		if limits.MaxLimit, err = strconv.Atoi(maxLimit); err != nil {
+			return nil, fmt.Errorf("invalid maximum page size in %q", entry)
+		}
+		if err := checkPageLimits(limits); err != nil {
+			return nil, fmt.Errorf("%s: %w", route, err)
+		}
+		routes[route] = limits
+	}
+	return routes, nil
+}
+
+// checkPageLimits checks that a default page size fits under its maximum
+func checkPageLimits(limits models.PageLimits) error {
+	if limits.DefaultLimit < 1 || limits.MaxLimit < limits.DefaultLimit {
+		return fmt.Errorf("page sizes need 1 <= default <= max, got default %d and max %d", limits.DefaultLimit, limits.MaxLimit)
+	}
+	return nil
+}
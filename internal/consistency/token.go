@@ -0,0 +1,87 @@
+// Package consistency gives clients read-your-writes consistency across the
+// primary database and its read replica. Responses to writes carry a token
+// naming the primary's WAL position (LSN); reads that present it are served
+// by the replica only once it has replayed that far.
+package consistency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Header carries consistency tokens in responses to writes and in the
+// requests that follow them
+const Header = "X-Consistency-Token"
+
+// Token is a position in the primary's write-ahead log
+type Token uint64
+
+// ParseToken parses a token in the pg_lsn text form, such as 16/B374D848
+func ParseToken(s string) (Token, error) {
+	high, low, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid consistency token %q", s)
+	}
+	h, err := strconv.ParseUint(high, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid consistency token %q", s)
+	}
+	l, err := strconv.ParseUint(low, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid consistency token %q", s)
+	}
+	return Token(h<<32 | l), nil
+}
+
+// String returns the token in the pg_lsn text form
+func (t Token) String() string {
+	return fmt.Sprintf("%X/%X", uint64(t)>>32, uint64(t)&0xFFFFFFFF)
+}
+
+type contextKey struct{}
+
+// WithToken returns a copy of ctx carrying the client's consistency token
+func WithToken(ctx context.Context, token Token) context.Context {
+	return context.WithValue(ctx, contextKey{}, token)
+}
+
+// FromContext returns the consistency token the client presented, if any
+func FromContext(ctx context.Context) (Token, bool) {
+	token, ok := ctx.Value(contextKey{}).(Token)
+	return token, ok
+}
+
+// Tracker issues consistency tokens from the primary database
+type Tracker struct {
+	primary *sql.DB
+}
+
+// NewTracker creates a tracker of the primary's WAL position
+func NewTracker(primary *sql.DB) *Tracker {
+	return &Tracker{primary: primary}
+}
+
+// Current returns a token covering every write the primary has committed
+func (t *Tracker) Current(ctx context.Context) (Token, error) {
+	var lsn string
+	if err := t.primary.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&lsn); err != nil {
+		return 0, fmt.Errorf("failed to read WAL position: %w", err)
+	}
+	return ParseToken(lsn)
+}
+
+// Reached reports whether db has replayed the primary's WAL up to token. A
+// database that is not a standby is the primary, and has.
+func Reached(ctx context.Context, db *sql.DB, token Token) (bool, error) {
+	var reached bool
+	err := db.QueryRowContext(ctx,
+		"SELECT COALESCE(pg_last_wal_replay_lsn(), pg_current_wal_lsn()) >= $1::pg_lsn", token.String(),
+	).Scan(&reached)
+	if err != nil {
+		return false, fmt.Errorf("failed to read replay position: %w", err)
+	}
+	return reached, nil
+}
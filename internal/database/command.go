@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// RunCommand runs a migrate command with args against db, writing its
+// output to w. The commands are up, down [N], status and force VERSION.
+func (m *Migrator) RunCommand(db *sql.DB, w io.Writer, command string, args []string) error {
+	switch command {
+	case "up":
+		if err := m.RunMigrations(db); err != nil {
+			return err
+		}
+		version, err := AppliedSchemaVersion(context.Background(), db)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "schema is at version %d\n", version)
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[0])
+			}
+			steps = n
+		}
+		// Each migration is rolled back in its own transaction, so an error
+		// keeps the ones already rolled back
+		for i := 0; i < steps; i++ {
+			version, err := m.RollbackMigration(db)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "rolled back migration %d\n", version)
+		}
+		return nil
+	case "status":
+		return m.printStatus(db, w)
+	case "force":
+		if len(args) != 1 {
+			return fmt.Errorf("force needs a version")
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		if err := m.ForceVersion(db, version); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "schema version forced to %d\n", version)
+		return nil
+	default:
+		return fmt.Errorf("unknown command, want up, down, status or force")
+	}
+}
+
+// printStatus writes a table of migrations and the number still pending to w
+func (m *Migrator) printStatus(db *sql.DB, w io.Writer) error {
+	states, err := m.MigrationStatus(context.Background(), db)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "VERSION\tNAME\tAPPLIED AT")
+	pending := 0
+	for _, state := range states {
+		appliedAt := "pending"
+		switch {
+		case state.AppliedAt != nil && state.Version > m.SchemaVersion():
+			appliedAt = state.AppliedAt.Format(time.RFC3339) + " (newer than this binary)"
+		case state.AppliedAt != nil:
+			appliedAt = state.AppliedAt.Format(time.RFC3339)
+		default:
+			pending++
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\n", state.Version, state.Name, appliedAt)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d pending\n", pending)
+	return nil
+}
@@ -1,94 +1,224 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"log"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pratham15541/go-crud/internal/config"
-	_ "github.com/lib/pq"
+	"github.com/qustavo/sqlhooks/v2"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"modernc.org/sqlite"
 )
 
-// NewConnection creates a new database connection
-func NewConnection(cfg config.DatabaseConfig) (*sql.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode,
-	)
+// Database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+)
 
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+// sqliteMemory is the SQLitePath of an in-memory database
+const sqliteMemory = ":memory:"
+
+// memoryKeepers hold a connection to each in-memory SQLite database open,
+// keyed by DSN. The database is dropped when its last connection closes,
+// which would otherwise happen when the migrations pool is closed.
+var memoryKeepers sync.Map
+
+// NewConnection creates a connection pool sized by pool. Its connections
+// report <ApplicationName>-<pool name> as their application name. Every
+// statement the pool runs is reported to observer by fingerprint; observer
+// may be nil.
+func NewConnection(cfg config.DatabaseConfig, pool config.PoolConfig, observer QueryObserver) (*sql.DB, error) {
+	hooks := &queryHooks{pool: pool.Name, observer: observer}
+	if pool.User != "" {
+		cfg.User = pool.User
+	}
+	if pool.Password != "" {
+		cfg.Password = pool.Password
+	}
+
+	var dsn string
+	var base driver.Driver
+	switch cfg.Driver {
+	case DriverPostgres:
+		hooks.system = semconv.DBSystemPostgreSQL
+		return newPostgresConnection(cfg, pool, hooks)
+	case DriverMySQL:
+		dsn, base, hooks.system = mysqlDSN(cfg, pool), &mysql.MySQLDriver{}, semconv.DBSystemMySQL
+	case DriverSQLite:
+		dsn, base, hooks.system = sqliteDSN(cfg), &sqlite.Driver{}, semconv.DBSystemSqlite
+		if err := keepMemoryDatabase(cfg, dsn); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown database driver %q, want postgres, mysql or sqlite", cfg.Driver)
 	}
 
+	// Hooks on the driver instrument every statement, so repositories need
+	// no instrumentation of their own
+	db := sql.OpenDB(&dsnConnector{dsn: dsn, driver: sqlhooks.Wrap(base, hooks)})
+
 	// Configure connection pool
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetConnMaxLifetime(cfg.MaxLifetime)
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.MaxLifetime)
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("Database connection established")
 	return db, nil
 }
 
-// RunMigrations runs the database migrations
-func RunMigrations(db *sql.DB) error {
-	log.Println("Running database migrations...")
-
-	// Create users table
-	createUsersTable := `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		email VARCHAR(255) UNIQUE NOT NULL,
-		age INTEGER CHECK (age > 0 AND age < 150),
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	if _, err := db.Exec(createUsersTable); err != nil {
-		return fmt.Errorf("failed to create users table: %w", err)
-	}
-
-	// Create updated_at trigger function
-	updatedAtTrigger := `
-	CREATE OR REPLACE FUNCTION update_updated_at_column()
-	RETURNS TRIGGER AS $$
-	BEGIN
-		NEW.updated_at = CURRENT_TIMESTAMP;
-		RETURN NEW;
-	END;
-	$$ language 'plpgsql';
-	`
-
-	if _, err := db.Exec(updatedAtTrigger); err != nil {
-		return fmt.Errorf("failed to create updated_at trigger function: %w", err)
-	}
-
-	// Create trigger for users table
-	usersTrigger := `
-	DROP TRIGGER IF EXISTS update_users_updated_at ON users;
-	CREATE TRIGGER update_users_updated_at
-		BEFORE UPDATE ON users
-		FOR EACH ROW
-		EXECUTE FUNCTION update_updated_at_column();
-	`
-
-	if _, err := db.Exec(usersTrigger); err != nil {
-		return fmt.Errorf("failed to create users trigger: %w", err)
-	}
-
-	// Create index on email for faster lookups
-	emailIndex := `CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);`
-	if _, err := db.Exec(emailIndex); err != nil {
-		return fmt.Errorf("failed to create email index: %w", err)
-	}
-
-	log.Println("Database migrations completed successfully")
+// newPostgresConnection creates a pgx connection pool, served through
+// database/sql so repositories stay driver-agnostic. pgxpool owns the
+// connections: database/sql keeps none idle and hands each one back to the
+// pool when a statement or transaction is done.
+func newPostgresConnection(cfg config.DatabaseConfig, pool config.PoolConfig, hooks *queryHooks) (*sql.DB, error) {
+	poolCfg, err := pgxpool.ParseConfig(postgresDSN(cfg, pool))
+	if err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+	poolCfg.MaxConns = int32(pool.MaxOpenConns)
+	poolCfg.MinConns = int32(pool.MinConns)
+	if pool.MaxLifetime > 0 {
+		poolCfg.MaxConnLifetime = pool.MaxLifetime
+	}
+	if pool.MaxIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = pool.MaxIdleTime
+	}
+	if cfg.PoolHealthCheckPeriod > 0 {
+		poolCfg.HealthCheckPeriod = cfg.PoolHealthCheckPeriod
+	}
+	// The tracer instruments every statement, as the hooks do for the
+	// other drivers
+	poolCfg.ConnConfig.Tracer = hooks
+
+	pgPool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+	db := sql.OpenDB(&pgxConnector{Connector: stdlib.GetPoolConnector(pgPool), pool: pgPool})
+	db.SetMaxIdleConns(0)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
+
+// pgxConnector closes its pgx pool when the database/sql pool is closed
+type pgxConnector struct {
+	driver.Connector
+	pool *pgxpool.Pool
+}
+
+// Close implements io.Closer, which sql.DB.Close calls on its connector
+func (c *pgxConnector) Close() error {
+	c.pool.Close()
 	return nil
-}
\ No newline at end of file
+}
+
+// postgresDSN returns the connection string for a Postgres pool
+func postgresDSN(cfg config.DatabaseConfig, pool config.PoolConfig) string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s application_name=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode,
+		applicationName(cfg.ApplicationName, pool.Name),
+	)
+}
+
+// mysqlDSN returns the data source name for a MySQL pool. Times are read
+// and written in UTC, and updates report the rows they matched rather than
+// the rows they changed, as Postgres does.
+func mysqlDSN(cfg config.DatabaseConfig, pool config.PoolConfig) string {
+	mysqlCfg := mysql.NewConfig()
+	mysqlCfg.User = cfg.User
+	mysqlCfg.Passwd = cfg.Password
+	mysqlCfg.Net = "tcp"
+	mysqlCfg.Addr = cfg.Host + ":" + cfg.Port
+	mysqlCfg.DBName = cfg.Name
+	mysqlCfg.ParseTime = true
+	mysqlCfg.Loc = time.UTC
+	mysqlCfg.ClientFoundRows = true
+	mysqlCfg.MultiStatements = pool.MultiStatements
+	mysqlCfg.TLSConfig = mysqlTLS(cfg.SSLMode)
+	mysqlCfg.ConnectionAttributes = "program_name:" + applicationName(cfg.ApplicationName, pool.Name)
+	return mysqlCfg.FormatDSN()
+}
+
+// mysqlTLS maps a Postgres sslmode to the MySQL driver's tls setting
+func mysqlTLS(sslMode string) string {
+	switch sslMode {
+	case "", "disable":
+		return "false"
+	case "allow", "prefer":
+		return "preferred"
+	case "require":
+		return "skip-verify"
+	default:
+		return "true"
+	}
+}
+
+// sqliteDSN returns the data source name of the SQLite database. Times are
+// stored as text that sorts in time order, and a writer waits up to five
+// seconds for another to finish.
+func sqliteDSN(cfg config.DatabaseConfig) string {
+	pragmas := "_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_time_format=sqlite"
+	if cfg.SQLitePath == sqliteMemory {
+		// The memdb VFS shares one database among every pool of the
+		// process, with the same locking as a file
+		return "file:/go-crud?vfs=memdb&" + pragmas
+	}
+	return "file:" + cfg.SQLitePath + "?_pragma=journal_mode(WAL)&" + pragmas
+}
+
+// keepMemoryDatabase opens the connection that keeps an in-memory SQLite
+// database alive for the life of the process
+func keepMemoryDatabase(cfg config.DatabaseConfig, dsn string) error {
+	if cfg.SQLitePath != sqliteMemory {
+		return nil
+	}
+	if _, ok := memoryKeepers.Load(dsn); ok {
+		return nil
+	}
+
+	keeper := sql.OpenDB(&dsnConnector{dsn: dsn, driver: &sqlite.Driver{}})
+	keeper.SetMaxOpenConns(1)
+	keeper.SetConnMaxLifetime(0)
+	if err := keeper.Ping(); err != nil {
+		keeper.Close()
+		return fmt.Errorf("failed to open in-memory database: %w", err)
+	}
+	if _, loaded := memoryKeepers.LoadOrStore(dsn, keeper); loaded {
+		keeper.Close()
+	}
+	return nil
+}
+
+// applicationName joins the application and pool names. Postgres truncates
+// application_name to 63 bytes, and the DSN needs it free of spaces.
+func applicationName(app, pool string) string {
+	name := pool
+	if app != "" {
+		name = app + "-" + pool
+	}
+	name = strings.ReplaceAll(name, " ", "_")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// tracer creates a client span for every statement. It comes from the
+// global provider, which discards spans unless tracing is enabled.
+var tracer = otel.Tracer("github.com/pratham15541/go-crud/internal/database")
+
+// QueryObserver records the outcome of every SQL statement a pool runs
+type QueryObserver interface {
+	ObserveQuery(pool, fingerprint string, duration time.Duration, err error)
+}
+
+var (
+	// fingerprintValues matches string and numeric literals and bind parameters
+	fingerprintValues = regexp.MustCompile(`'(?:[^']|'')*'|\$\d+|\b\d+(?:\.\d+)?\b`)
+	// fingerprintLists matches a parenthesized list of placeholders
+	fingerprintLists = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fingerprintSpace = regexp.MustCompile(`\s+`)
+	// statementTable matches the table a statement reads or writes first
+	statementTable = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE)\s+([a-z_][a-z0-9_]*)`)
+
+	// fingerprints caches the fingerprint of each query text; the
+	// repositories only ever run a fixed set of queries
+	fingerprints sync.Map
+)
+
+// Fingerprint normalizes query so statements that differ only in values
+// share one fingerprint: literals and bind parameters become ?, lists of
+// them become (?), and whitespace is collapsed.
+func Fingerprint(query string) string {
+	if fingerprint, ok := fingerprints.Load(query); ok {
+		return fingerprint.(string)
+	}
+
+	fingerprint := fingerprintValues.ReplaceAllString(query, "?")
+	fingerprint = fingerprintLists.ReplaceAllString(fingerprint, "(?)")
+	fingerprint = fingerprintSpace.ReplaceAllString(strings.TrimSpace(fingerprint), " ")
+
+	fingerprints.Store(query, fingerprint)
+	return fingerprint
+}
+
+// StatementName names a statement by its operation and first table, e.g.
+// "SELECT users", or by its operation alone when no table is named
+func StatementName(query string) string {
+	operation := statementOperation(query)
+	if match := statementTable.FindStringSubmatch(query); match != nil {
+		return operation + " " + match[1]
+	}
+	return operation
+}
+
+// statementOperation returns the statement's leading keyword, e.g. SELECT
+func statementOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// queryStartKey is the context key holding the time a statement started
+type queryStartKey struct{}
+
+// queryTextKey is the context key holding the text of a pgx statement,
+// which pgx only passes to the tracer when the statement starts
+type queryTextKey struct{}
+
+// queryHooks times every statement sent through a pool's driver, reports it
+// by fingerprint and traces it as a child of the caller's span. For queries
+// the duration runs until the database starts returning rows, or on
+// Postgres until the rows are closed.
+type queryHooks struct {
+	pool string
+	// system is the db.system span attribute of the pool's database
+	system   attribute.KeyValue
+	observer QueryObserver
+}
+
+// Before records when the statement started and starts its span
+func (h *queryHooks) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	ctx, _ = tracer.Start(ctx, StatementName(query),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			h.system,
+			semconv.DBStatement(Fingerprint(query)),
+			semconv.DBOperation(statementOperation(query)),
+			attribute.String("db.pool", h.pool),
+		),
+	)
+	return context.WithValue(ctx, queryStartKey{}, time.Now()), nil
+}
+
+// After reports a successful statement
+func (h *queryHooks) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	h.observe(ctx, query, nil)
+	return ctx, nil
+}
+
+// OnError reports a failed statement and passes its error on unchanged
+func (h *queryHooks) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	// database/sql retries ErrSkip another way; it is not a failure. The
+	// span is left unended so it is never exported, and the retry gets its own.
+	if !errors.Is(err, driver.ErrSkip) {
+		h.observe(ctx, query, err)
+	}
+	return err
+}
+
+// TraceQueryStart implements pgx.QueryTracer like Before
+func (h *queryHooks) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = h.Before(ctx, data.SQL)
+	return context.WithValue(ctx, queryTextKey{}, data.SQL)
+}
+
+// TraceQueryEnd implements pgx.QueryTracer like After and OnError
+func (h *queryHooks) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	query, _ := ctx.Value(queryTextKey{}).(string)
+	h.observe(ctx, query, data.Err)
+}
+
+// observe ends the statement's span, reports the statement to the observer
+// and logs it at debug level with the request's fields
+func (h *queryHooks) observe(ctx context.Context, query string, err error) {
+	start, ok := ctx.Value(queryStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	duration := time.Since(start)
+	fingerprint := Fingerprint(query)
+
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+
+	if h.observer != nil {
+		h.observer.ObserveQuery(h.pool, fingerprint, duration, err)
+	}
+	logger.FromContext(ctx).Debug("sql statement",
+		zap.String("pool", h.pool),
+		zap.String("query", fingerprint),
+		zap.Duration("duration", duration),
+		zap.Error(err),
+	)
+}
+
+// dsnConnector opens connections to dsn through driver
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+// Connect opens a new connection
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver returns the driver connections are opened with
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
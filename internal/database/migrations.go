@@ -0,0 +1,135 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// migrationFiles holds the schema history as numbered pairs of files,
+// NNNN_name.up.sql and NNNN_name.down.sql. Add a change as a new pair with
+// the next number; never edit a migration once it has been released. The
+// mysql and sqlite directories hold the schemas of those databases, which
+// only have users.
+//
+//go:embed migrations/*.sql migrations/mysql/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// migrationDirs maps each driver to the directory of its migrations
+var migrationDirs = map[string]string{
+	DriverPostgres: "migrations",
+	DriverMySQL:    "migrations/mysql",
+	DriverSQLite:   "migrations/sqlite",
+}
+
+// migrationFileName matches migration file names
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies the embedded migrations of one database driver
+type Migrator struct {
+	driver     string
+	migrations []Migration
+}
+
+// NewMigrator creates a Migrator for the migrations of driver, one of
+// postgres, mysql and sqlite
+func NewMigrator(driver string) (*Migrator, error) {
+	dir, ok := migrationDirs[driver]
+	if !ok {
+		return nil, fmt.Errorf("unknown database driver %q, want postgres, mysql or sqlite", driver)
+	}
+	return &Migrator{driver: driver, migrations: mustLoadMigrations(migrationFiles, dir)}, nil
+}
+
+// SchemaVersion is the schema version this binary needs: the version of its
+// newest migration. Instances of a new version wait for it in wait mode.
+func (m *Migrator) SchemaVersion() int {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Migrations returns the migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+// LoadMigrations reads migrations from directory dir of fsys, ignoring
+// subdirectories. Versions must start at 1 and leave no gaps, and every
+// migration needs both an up and a down file.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named %q and %q", version, m.Name, match[2])
+		}
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	for i, m := range result {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and a down file", m.Version)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no migrations found")
+	}
+	return result, nil
+}
+
+// mustLoadMigrations loads migrations that are compiled in, so any error is
+// a bug in this release
+func mustLoadMigrations(fsys fs.FS, dir string) []Migration {
+	result, err := LoadMigrations(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
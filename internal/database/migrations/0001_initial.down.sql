@@ -0,0 +1,11 @@
+-- Removes every table, so all data is lost
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS reject_audit_log_change();
+DROP TABLE IF EXISTS operations;
+DROP TABLE IF EXISTS personal_access_tokens;
+DROP TABLE IF EXISTS oauth_device_codes;
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_clients;
+DROP TABLE IF EXISTS users;
+DROP FUNCTION IF EXISTS update_updated_at_column();
+DROP FUNCTION IF EXISTS reject_legal_hold_delete();
@@ -0,0 +1,154 @@
+-- The schema as of the first versioned release. Every statement is
+-- idempotent so this also applies cleanly to databases created before
+-- migrations were versioned.
+
+-- Users
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	age INTEGER CHECK (age > 0 AND age < 150),
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Role for authorization and password hash for login
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Legal hold flag; held users cannot be deleted even by direct SQL
+ALTER TABLE users ADD COLUMN IF NOT EXISTS legal_hold BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE OR REPLACE FUNCTION reject_legal_hold_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+	RAISE EXCEPTION 'user % is under legal hold', OLD.id;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS users_legal_hold ON users;
+CREATE TRIGGER users_legal_hold
+	BEFORE DELETE ON users
+	FOR EACH ROW
+	WHEN (OLD.legal_hold)
+	EXECUTE FUNCTION reject_legal_hold_delete();
+
+-- Soft delete; emails only need to be unique among live users so a deleted
+-- user's address can be registered again
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_live ON users(email) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Keep updated_at current
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+	NEW.updated_at = CURRENT_TIMESTAMP;
+	RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+CREATE TRIGGER update_users_updated_at
+	BEFORE UPDATE ON users
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
+
+-- Email lookups and the keyset pagination order of GET /users
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users(created_at DESC, id DESC);
+
+-- OpenID Connect provider
+CREATE TABLE IF NOT EXISTS oauth_clients (
+	id VARCHAR(64) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	secret_hash VARCHAR(255) NOT NULL DEFAULT '',
+	redirect_uris TEXT[] NOT NULL,
+	public BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+	code_hash VARCHAR(64) PRIMARY KEY,
+	client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	redirect_uri TEXT NOT NULL,
+	scope TEXT NOT NULL,
+	nonce TEXT NOT NULL DEFAULT '',
+	code_challenge VARCHAR(128) NOT NULL,
+	code_challenge_method VARCHAR(10) NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS oauth_device_codes (
+	device_code_hash VARCHAR(64) PRIMARY KEY,
+	user_code VARCHAR(16) UNIQUE NOT NULL,
+	client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+	scope TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending',
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	poll_interval INTEGER NOT NULL,
+	last_polled_at TIMESTAMP WITH TIME ZONE,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Personal access tokens
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	token_prefix VARCHAR(16) NOT NULL,
+	scopes TEXT[] NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE,
+	last_used_at TIMESTAMP WITH TIME ZONE,
+	revoked_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+
+-- Asynchronous operations
+CREATE TABLE IF NOT EXISTS operations (
+	id VARCHAR(32) PRIMARY KEY,
+	type VARCHAR(50) NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	progress INTEGER NOT NULL DEFAULT 0,
+	result_url TEXT NOT NULL DEFAULT '',
+	error TEXT NOT NULL DEFAULT '',
+	created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Append-only audit log; triggers reject edits and deletes
+CREATE TABLE IF NOT EXISTS audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	action VARCHAR(255) NOT NULL,
+	actor_id VARCHAR(255) NOT NULL DEFAULT '',
+	target_id VARCHAR(255) NOT NULL DEFAULT '',
+	status INTEGER NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	prev_hash CHAR(64) NOT NULL DEFAULT '',
+	hash CHAR(64) NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION reject_audit_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+	BEFORE UPDATE OR DELETE ON audit_log
+	FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate
+	BEFORE TRUNCATE ON audit_log
+	FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
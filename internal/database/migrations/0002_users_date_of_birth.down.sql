@@ -0,0 +1,2 @@
+-- Set AGE_TRANSITION_PHASE=old on every instance before rolling back
+ALTER TABLE users DROP COLUMN IF EXISTS date_of_birth;
@@ -0,0 +1,3 @@
+-- date_of_birth replaces age; filled by dual-writes and the backfill while
+-- AGE_TRANSITION_PHASE moves from old to new
+ALTER TABLE users ADD COLUMN IF NOT EXISTS date_of_birth DATE;
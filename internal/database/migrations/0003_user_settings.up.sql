@@ -0,0 +1,8 @@
+-- Free-form user settings; schema_version records the shape of each document
+-- so old ones are upgraded on read rather than all at once
+CREATE TABLE IF NOT EXISTS user_settings (
+	user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	schema_version INTEGER NOT NULL,
+	settings JSONB NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+-- Entries that recorded any of these fields no longer verify afterwards,
+-- since their hashes cover them
+ALTER TABLE audit_log DROP COLUMN IF EXISTS impersonator_id;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS ip;
@@ -0,0 +1,5 @@
+-- Record who else was behind each audited request
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS impersonator_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS api_key_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS ip VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,25 @@
+-- Outbound emails, written in the same transaction as the change that
+-- causes them and delivered by a background job
+CREATE TABLE IF NOT EXISTS email_outbox (
+	id BIGSERIAL PRIMARY KEY,
+	message_id VARCHAR(255) UNIQUE NOT NULL,
+	recipient VARCHAR(255) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	body TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'queued',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	sent_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Finds emails due for delivery
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status = 'queued';
+
+DROP TRIGGER IF EXISTS update_email_outbox_updated_at ON email_outbox;
+CREATE TRIGGER update_email_outbox_updated_at
+	BEFORE UPDATE ON email_outbox
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,14 @@
+-- Addresses that bounced or complained; nothing more is sent to them
+CREATE TABLE IF NOT EXISTS email_suppressions (
+	email VARCHAR(255) PRIMARY KEY,
+	reason VARCHAR(20) NOT NULL,
+	detail TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_email_suppressions_updated_at ON email_suppressions;
+CREATE TRIGGER update_email_suppressions_updated_at
+	BEFORE UPDATE ON email_suppressions
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- Bumped by every change to a user, so updates can check they start from
+-- the version the client read
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,19 @@
+-- Consumers notified of user lifecycle events. Deliveries are signed with
+-- the subscription's secret.
+CREATE TABLE IF NOT EXISTS webhooks (
+	id BIGSERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT[] NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Finds the subscriptions of an event
+CREATE INDEX IF NOT EXISTS idx_webhooks_events ON webhooks USING GIN (events);
+
+DROP TRIGGER IF EXISTS update_webhooks_updated_at ON webhooks;
+CREATE TRIGGER update_webhooks_updated_at
+	BEFORE UPDATE ON webhooks
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
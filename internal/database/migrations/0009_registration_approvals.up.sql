@@ -0,0 +1,21 @@
+-- Self-registrations awaiting an admin's decision when REGISTRATION_MODE is
+-- approval. Users without a row here were never subject to approval.
+CREATE TABLE IF NOT EXISTS registration_approvals (
+	user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	reason TEXT NOT NULL DEFAULT '',
+	decided_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	decided_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Finds the queue of pending registrations
+CREATE INDEX IF NOT EXISTS idx_registration_approvals_pending
+	ON registration_approvals (user_id) WHERE status = 'pending';
+
+DROP TRIGGER IF EXISTS update_registration_approvals_updated_at ON registration_approvals;
+CREATE TRIGGER update_registration_approvals_updated_at
+	BEFORE UPDATE ON registration_approvals
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
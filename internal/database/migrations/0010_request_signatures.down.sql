@@ -0,0 +1,7 @@
+-- Signed entries no longer verify afterwards, since their hashes cover the
+-- signatures
+ALTER TABLE audit_log DROP COLUMN IF EXISTS signing_key;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS signed_payload;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS signature;
+
+ALTER TABLE personal_access_tokens DROP COLUMN IF EXISTS signing_key;
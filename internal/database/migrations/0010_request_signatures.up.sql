@@ -0,0 +1,8 @@
+-- Public keys personal access tokens sign their mutating requests with
+ALTER TABLE personal_access_tokens ADD COLUMN IF NOT EXISTS signing_key TEXT NOT NULL DEFAULT '';
+
+-- Signatures of audited requests, with what they sign and the key that
+-- verifies them
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS signature TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS signed_payload TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS signing_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,26 @@
+-- Domain events, written in the same transaction as the user change that
+-- raises them and published to the event backend by a background relay
+CREATE TABLE IF NOT EXISTS outbox (
+	id BIGSERIAL PRIMARY KEY,
+	event_id VARCHAR(64) UNIQUE NOT NULL,
+	type VARCHAR(100) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	data JSONB NOT NULL,
+	occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	published_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Finds events due for publishing, and published events to clean up
+CREATE INDEX IF NOT EXISTS idx_outbox_due ON outbox(next_attempt_at) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_published ON outbox(published_at) WHERE published_at IS NOT NULL;
+
+DROP TRIGGER IF EXISTS update_outbox_updated_at ON outbox;
+CREATE TRIGGER update_outbox_updated_at
+	BEFORE UPDATE ON outbox
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
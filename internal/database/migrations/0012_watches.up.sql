@@ -0,0 +1,26 @@
+-- Watches notify their owner when fields of a user change. snapshot holds
+-- the last values seen, to tell which fields an update changed. Expired
+-- watches are ignored and swept by a background job.
+CREATE TABLE IF NOT EXISTS watches (
+	id BIGSERIAL PRIMARY KEY,
+	owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	fields TEXT[] NOT NULL DEFAULT '{}',
+	url TEXT NOT NULL DEFAULT '',
+	secret TEXT NOT NULL DEFAULT '',
+	snapshot JSONB NOT NULL DEFAULT '{}',
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Finds the watches of a changed user, those of an owner, and expired ones
+CREATE INDEX IF NOT EXISTS idx_watches_user ON watches(user_id);
+CREATE INDEX IF NOT EXISTS idx_watches_owner ON watches(owner_id);
+CREATE INDEX IF NOT EXISTS idx_watches_expires ON watches(expires_at);
+
+DROP TRIGGER IF EXISTS update_watches_updated_at ON watches;
+CREATE TRIGGER update_watches_updated_at
+	BEFORE UPDATE ON watches
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS jwt_keys;
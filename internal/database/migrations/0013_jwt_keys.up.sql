@@ -0,0 +1,10 @@
+-- Keys JWTs are signed with once JWT_SECRET has been rotated, shared by
+-- every instance. Tokens name the key that signed them in their kid header.
+CREATE TABLE IF NOT EXISTS jwt_keys (
+	id VARCHAR(64) PRIMARY KEY,
+	secret TEXT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Finds the newest key
+CREATE INDEX IF NOT EXISTS idx_jwt_keys_created_at ON jwt_keys(created_at);
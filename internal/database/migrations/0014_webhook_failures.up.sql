@@ -0,0 +1,15 @@
+-- Webhook deliveries that ran out of attempts, kept so they can be
+-- re-delivered once the consumer recovers
+CREATE TABLE IF NOT EXISTS webhook_failures (
+	id BIGSERIAL PRIMARY KEY,
+	webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+	event_id VARCHAR(64) NOT NULL,
+	event VARCHAR(100) NOT NULL,
+	-- The body as first sent, byte for byte
+	payload BYTEA NOT NULL,
+	attempts INTEGER NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_failures_webhook_id ON webhook_failures(webhook_id);
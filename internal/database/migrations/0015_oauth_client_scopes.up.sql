@@ -0,0 +1,2 @@
+-- API scopes an OAuth client may be granted besides the OpenID Connect ones
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+-- Removes the users table, so all data is lost
+DROP TRIGGER IF EXISTS users_legal_hold;
+DROP TABLE IF EXISTS users;
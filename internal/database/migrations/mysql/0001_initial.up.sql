@@ -0,0 +1,35 @@
+-- Users, the only table kept in MySQL. Matches the Postgres users table as
+-- of its migration 0002.
+CREATE TABLE IF NOT EXISTS users (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	age INT,
+	date_of_birth DATE,
+	role VARCHAR(20) NOT NULL DEFAULT 'user',
+	password_hash VARCHAR(255) NOT NULL DEFAULT '',
+	legal_hold BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+	updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+	deleted_at TIMESTAMP(6) NULL,
+	-- Emails only need to be unique among live users. MySQL has no partial
+	-- indexes, so the unique index is on a column that is NULL for deleted
+	-- users, and NULLs never collide.
+	live_email VARCHAR(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+	CONSTRAINT users_age_check CHECK (age > 0 AND age < 150),
+	UNIQUE KEY idx_users_email_live (live_email),
+	KEY idx_users_email (email),
+	KEY idx_users_created_at_id (created_at, id),
+	KEY idx_users_deleted_at (deleted_at)
+);
+
+-- Held users cannot be deleted even by direct SQL
+DROP TRIGGER IF EXISTS users_legal_hold;
+CREATE TRIGGER users_legal_hold
+	BEFORE DELETE ON users
+	FOR EACH ROW
+BEGIN
+	IF OLD.legal_hold THEN
+		SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'user is under legal hold';
+	END IF;
+END;
@@ -0,0 +1,4 @@
+-- Removes the users table, so all data is lost
+DROP TRIGGER IF EXISTS update_users_updated_at;
+DROP TRIGGER IF EXISTS users_legal_hold;
+DROP TABLE IF EXISTS users;
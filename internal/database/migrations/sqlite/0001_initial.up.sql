@@ -0,0 +1,40 @@
+-- Users, the only table kept in SQLite. Matches the Postgres users table as
+-- of its migration 0002. Times are text in the driver's format, which sorts
+-- in time order.
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	age INTEGER CHECK (age > 0 AND age < 150),
+	date_of_birth DATE,
+	role VARCHAR(20) NOT NULL DEFAULT 'user',
+	password_hash VARCHAR(255) NOT NULL DEFAULT '',
+	legal_hold BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	deleted_at TIMESTAMP
+);
+
+-- Emails only need to be unique among live users
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_live ON users(email) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users(created_at DESC, id DESC);
+
+-- Held users cannot be deleted even by direct SQL
+CREATE TRIGGER IF NOT EXISTS users_legal_hold
+	BEFORE DELETE ON users
+	FOR EACH ROW
+	WHEN OLD.legal_hold
+BEGIN
+	SELECT RAISE(ABORT, 'user is under legal hold');
+END;
+
+-- Keep updated_at current for updates that do not set it
+CREATE TRIGGER IF NOT EXISTS update_users_updated_at
+	AFTER UPDATE ON users
+	FOR EACH ROW
+	WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+	UPDATE users SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
@@ -0,0 +1,2 @@
+-- Bumped by every change to a user; matches Postgres migration 0007
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
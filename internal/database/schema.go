@@ -0,0 +1,340 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"go.uber.org/zap"
+	"modernc.org/sqlite"
+)
+
+// migrationLockID is the Postgres advisory lock key held while migrating
+const migrationLockID = 4_738_201_615
+
+// migrationLockName is the MySQL named lock held while migrating
+const migrationLockName = "go-crud-migrations"
+
+// Migration modes
+const (
+	// MigrationModeRun applies pending migrations at startup
+	MigrationModeRun = "run"
+	// MigrationModeWait leaves migrating to another instance and waits for it
+	MigrationModeWait = "wait"
+)
+
+// placeholder matches a Postgres bind parameter
+var placeholder = regexp.MustCompile(`\$\d+`)
+
+// Error codes for a missing table
+const (
+	undefinedTable      = "42P01"
+	mysqlUndefinedTable = 1146
+)
+
+// RunMigrations applies every pending migration. Migrations run in one
+// transaction under an advisory lock, so instances starting together queue
+// behind each other instead of racing, and a failure leaves nothing applied.
+// MySQL commits schema changes as it makes them, so there a failure keeps
+// the migrations before the one that failed.
+func (m *Migrator) RunMigrations(db *sql.DB) error {
+	return m.withMigrationLock(db, func(tx *sql.Tx) error {
+		applied, err := appliedVersions(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if applied[migration.Version] {
+				continue
+			}
+			if _, err := tx.Exec(migration.Up); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := tx.Exec(m.bind(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`), migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// RollbackMigration reverts the newest applied migration and returns its
+// version
+func (m *Migrator) RollbackMigration(db *sql.DB) (int, error) {
+	var version int
+	err := m.withMigrationLock(db, func(tx *sql.Tx) error {
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+		if version == 0 {
+			return fmt.Errorf("no migrations to roll back")
+		}
+		if version > len(m.migrations) {
+			return fmt.Errorf("migration %d is newer than this binary", version)
+		}
+
+		migration := m.migrations[version-1]
+		if _, err := tx.Exec(migration.Down); err != nil {
+			return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.Exec(m.bind(`DELETE FROM schema_migrations WHERE version = $1`), migration.Version); err != nil {
+			return fmt.Errorf("failed to record rollback of migration %d: %w", migration.Version, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// ForceVersion records version as the schema version without running any
+// migration: migrations up to version are marked applied and newer ones are
+// not. Use it once a failed or hand-made change has been repaired by hand.
+func (m *Migrator) ForceVersion(db *sql.DB, version int) error {
+	if version < 0 || version > len(m.migrations) {
+		return fmt.Errorf("unknown migration version %d, want 0 to %d", version, len(m.migrations))
+	}
+
+	return m.withMigrationLock(db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(m.bind(`DELETE FROM schema_migrations WHERE version > $1`), version); err != nil {
+			return fmt.Errorf("failed to force schema version: %w", err)
+		}
+		query := `INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING`
+		if m.driver == DriverMySQL {
+			query = `INSERT IGNORE INTO schema_migrations (version, name) VALUES (?, ?)`
+		}
+		for _, migration := range m.migrations[:version] {
+			if _, err := tx.Exec(query, migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("failed to force schema version: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// MigrationState is a migration and when it was applied, if it was
+type MigrationState struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// MigrationStatus lists every migration this binary has, followed by any
+// newer ones applied to the database by a newer binary. It changes nothing,
+// so a database still on the legacy schema_version table shows every
+// migration as pending.
+func (m *Migrator) MigrationStatus(ctx context.Context, db *sql.DB) ([]MigrationState, error) {
+	states := make([]MigrationState, len(m.migrations))
+	for i, migration := range m.migrations {
+		states[i] = MigrationState{Version: migration.Version, Name: migration.Name}
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+	if isUndefinedTable(err) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var state MigrationState
+		var appliedAt time.Time
+		if err := rows.Scan(&state.Version, &state.Name, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		state.AppliedAt = &appliedAt
+		if state.Version >= 1 && state.Version <= len(m.migrations) {
+			states[state.Version-1].AppliedAt = &appliedAt
+		} else {
+			states = append(states, state)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	return states, nil
+}
+
+// withMigrationLock runs fn in a transaction holding the migration lock,
+// after making sure the schema_migrations table exists
+func (m *Migrator) withMigrationLock(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration: %w", err)
+	}
+	defer conn.Close()
+
+	// A MySQL named lock belongs to the connection rather than the
+	// transaction, so it is taken first and released after the commit
+	if m.driver == DriverMySQL {
+		if _, err := conn.ExecContext(ctx, `DO GET_LOCK(?, -1)`, migrationLockName); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(ctx, `DO RELEASE_LOCK(?)`, migrationLockName)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	createMigrationsTable := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	// SQLite takes no lock: it allows one writer at a time, so a second
+	// instance migrating at once fails instead of waiting
+	switch m.driver {
+	case DriverMySQL, DriverSQLite:
+		createMigrationsTable = strings.Replace(createMigrationsTable, "WITH TIME ZONE ", "", 1)
+	default:
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	if err := m.importLegacyVersion(tx); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// importLegacyVersion marks the migrations of a database migrated before
+// migrations were versioned files as applied. Such databases record only a
+// version number, in schema_version, which the file numbering continues.
+func (m *Migrator) importLegacyVersion(tx *sql.Tx) error {
+	// MySQL and SQLite were only supported once migrations were versioned
+	if m.driver != DriverPostgres {
+		return nil
+	}
+
+	var tracked bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations)`).Scan(&tracked); err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	var legacy bool
+	if err := tx.QueryRow(`SELECT to_regclass('schema_version') IS NOT NULL`).Scan(&legacy); err != nil {
+		return fmt.Errorf("failed to look for schema_version table: %w", err)
+	}
+	if tracked || !legacy {
+		return nil
+	}
+
+	var version int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read legacy schema version: %w", err)
+	}
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name); err != nil {
+			return fmt.Errorf("failed to import legacy schema version: %w", err)
+		}
+	}
+	return nil
+}
+
+// appliedVersions returns the set of applied migration versions
+func appliedVersions(tx *sql.Tx) (map[int]bool, error) {
+	rows, err := tx.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// AppliedSchemaVersion returns the newest migration applied to the
+// database, or 0 if it has never been migrated
+func AppliedSchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if isUndefinedTable(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// isUndefinedTable reports whether err is caused by a missing table
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	var mysqlErr *mysql.MySQLError
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &pgErr) && pgErr.Code == undefinedTable ||
+		errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlUndefinedTable ||
+		// SQLite reports a missing table with its generic error code
+		errors.As(err, &sqliteErr) && strings.Contains(sqliteErr.Error(), "no such table")
+}
+
+// bind rewrites the $1, $2, ... placeholders of query, which must be used
+// in order, into the ? placeholders of MySQL
+func (m *Migrator) bind(query string) string {
+	if m.driver != DriverMySQL {
+		return query
+	}
+	return placeholder.ReplaceAllString(query, "?")
+}
+
+// WaitForMigrations polls every interval until the database is at
+// SchemaVersion or newer. It returns early only if ctx is canceled.
+func (m *Migrator) WaitForMigrations(ctx context.Context, db *sql.DB, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		version, err := AppliedSchemaVersion(ctx, db)
+		switch {
+		case err != nil:
+			logger.FromContext(ctx).Warn("failed to check schema version", zap.Error(err))
+		case version >= m.SchemaVersion():
+			return nil
+		default:
+			logger.FromContext(ctx).Info("waiting for database migrations",
+				zap.Int("applied_version", version),
+				zap.Int("required_version", m.SchemaVersion()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package docschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNewerVersion is returned for documents written by a newer release,
+// which this one cannot read back without losing fields
+var ErrNewerVersion = errors.New("document schema version is newer than supported")
+
+// Upgrade rewrites a document in place from one schema version to the next
+type Upgrade func(doc map[string]interface{}) error
+
+// Pipeline upgrades stored JSON documents of one kind to the current schema
+// version when they are read, so changing their shape never requires
+// rewriting every stored document at once. Version 1 is the first shape
+// and upgrades[i] moves a document from version i+1 to i+2, so adding a
+// version means appending an upgrade. Never edit or remove an upgrade once
+// released; documents may still be waiting for it.
+type Pipeline struct {
+	kind     string
+	upgrades []Upgrade
+}
+
+// NewPipeline creates a pipeline for documents of kind, e.g. "user settings"
+func NewPipeline(kind string, upgrades ...Upgrade) *Pipeline {
+	return &Pipeline{kind: kind, upgrades: upgrades}
+}
+
+// Current returns the schema version documents are written at
+func (p *Pipeline) Current() int {
+	return len(p.upgrades) + 1
+}
+
+// Upgrade brings data, a JSON object stored at version, to the current
+// version. Documents already current are returned unchanged, and documents
+// stored before versioning began are treated as version 1.
+func (p *Pipeline) Upgrade(version int, data json.RawMessage) (json.RawMessage, error) {
+	if version < 1 {
+		version = 1
+	}
+	if version > p.Current() {
+		return nil, fmt.Errorf("%s version %d: %w", p.kind, version, ErrNewerVersion)
+	}
+	if version == p.Current() {
+		return data, nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", p.kind, err)
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+
+	for v := version; v < p.Current(); v++ {
+		if err := p.upgrades[v-1](doc); err != nil {
+			return nil, fmt.Errorf("failed to upgrade %s from version %d: %w", p.kind, v, err)
+		}
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", p.kind, err)
+	}
+	return upgraded, nil
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Handler consumes an event. It runs on the publisher's goroutine, so it
+// must not block.
+type Handler func(ctx context.Context, event Event)
+
+// Bus is an in-process Publisher handing each event to its subscribers
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus creates a bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe calls handler for events of the given types, or of every type
+// when none are given
+func (b *Bus) Subscribe(handler Handler, types ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(types) == 0 {
+		types = []string{""}
+	}
+	for _, typ := range types {
+		b.handlers[typ] = append(b.handlers[typ], handler)
+	}
+}
+
+// Publish calls the handlers subscribed to event before returning
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[""]...), b.handlers[event.Type]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+	return nil
+}
+
+// Close does nothing; events are delivered as they are published
+func (b *Bus) Close() error {
+	return nil
+}
+
+// tee publishes to a backend and then to a bus in this process
+type tee struct {
+	publisher Publisher
+	bus       *Bus
+}
+
+// Tee returns a Publisher sending events to publisher and, once it accepts
+// them, handing them to the subscribers of bus, so this process can react
+// to events it sends to a broker
+func Tee(publisher Publisher, bus *Bus) Publisher {
+	return &tee{publisher: publisher, bus: bus}
+}
+
+// Publish publishes to the backend, then to the bus. Events the backend
+// rejects are not handed to the bus, so a retry does not repeat them there.
+func (t *tee) Publish(ctx context.Context, event Event) error {
+	if err := t.publisher.Publish(ctx, event); err != nil {
+		return err
+	}
+	return t.bus.Publish(ctx, event)
+}
+
+// Close closes the backend
+func (t *tee) Close() error {
+	return t.publisher.Close()
+}
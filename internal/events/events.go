@@ -0,0 +1,74 @@
+// Package events publishes domain events, such as a user being created, to
+// subscribers in this process or to a message broker
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"go.uber.org/zap"
+)
+
+// Backends a Publisher can be created for
+const (
+	BackendInProc = "inproc"
+	BackendKafka  = "kafka"
+	BackendNATS   = "nats"
+)
+
+// Event is something that happened to a domain object
+type Event struct {
+	// ID identifies the event so consumers can drop duplicates
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Subject identifies what changed, such as a user's public ID; brokers
+	// key messages by it so the events of one subject stay in order
+	Subject string      `json:"subject"`
+	Time    time.Time   `json:"time"`
+	Data    interface{} `json:"data"`
+}
+
+// NewEvent creates an event of typ on subject happening now
+func NewEvent(typ, subject string, data interface{}) Event {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return Event{
+		ID:      "evt_" + hex.EncodeToString(id),
+		Type:    typ,
+		Subject: subject,
+		Time:    time.Now().UTC(),
+		Data:    data,
+	}
+}
+
+// Publisher delivers events to their consumers
+type Publisher interface {
+	// Publish hands event over for delivery. Publishers may deliver in the
+	// background, so a nil error does not mean consumers have it, unless
+	// they were created to confirm events for the outbox.
+	Publish(ctx context.Context, event Event) error
+	// Close delivers the events still buffered and releases the connection
+	Close() error
+}
+
+// NewPublisher creates the publisher cfg selects. With the outbox, Kafka and
+// NATS publishers confirm each event before Publish returns.
+func NewPublisher(cfg config.EventsConfig, logger *zap.Logger) (Publisher, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	switch cfg.Backend {
+	case BackendInProc, "":
+		return NewBus(), nil
+	case BackendKafka:
+		return NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic, cfg.Outbox, logger)
+	case BackendNATS:
+		return NewNATSPublisher(cfg.NATSURL, cfg.NATSSubjectPrefix, cfg.Outbox)
+	default:
+		return nil, fmt.Errorf("unknown events backend %q", cfg.Backend)
+	}
+}
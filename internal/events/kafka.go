@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// KafkaPublisher writes events to a Kafka topic, keyed by subject so the
+// events of one subject land on one partition in order
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a publisher writing to topic on brokers. Writes
+// are batched in the background and failures logged, unless confirm is set:
+// then Publish waits for every in-sync replica to store the event.
+func NewKafkaPublisher(brokers []string, topic string, confirm bool, logger *zap.Logger) (*KafkaPublisher, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("the kafka events backend needs EVENTS_KAFKA_BROKERS")
+	}
+	if topic == "" {
+		return nil, errors.New("the kafka events backend needs EVENTS_KAFKA_TOPIC")
+	}
+	return &KafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+		Async:        !confirm,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				logger.Error("failed to write events to kafka",
+					zap.String("topic", topic), zap.Int("events", len(messages)), zap.Error(err))
+			}
+		},
+	}}, nil
+}
+
+// Publish queues event for the next batch, or writes it when confirming
+func (p *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(event.Subject),
+		Value:   value,
+		Headers: []kafka.Header{{Key: "type", Value: []byte(event.Type)}},
+		Time:    event.Time,
+	})
+}
+
+// Close writes the pending batch and closes the connections
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes each event on the subject of its type under a
+// prefix, e.g. go-crud.user.created
+type NATSPublisher struct {
+	conn    *nats.Conn
+	prefix  string
+	confirm bool
+}
+
+// natsConfirmTimeout bounds the wait for the server to confirm an event
+const natsConfirmTimeout = 5 * time.Second
+
+// NewNATSPublisher connects to url. While the server is unreachable, at
+// startup or later, events are buffered and the connection retried, unless
+// confirm is set: then Publish waits for the server to receive the event.
+func NewNATSPublisher(url, subjectPrefix string, confirm bool) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("go-crud"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return &NATSPublisher{conn: conn, prefix: subjectPrefix, confirm: confirm}, nil
+}
+
+// Publish sends event, with its ID as Nats-Msg-Id so JetStream streams drop
+// duplicates
+func (p *NATSPublisher) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	subject := event.Type
+	if p.prefix != "" {
+		subject = p.prefix + "." + subject
+	}
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, event.ID)
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+	if p.confirm {
+		return p.conn.FlushTimeout(natsConfirmTimeout)
+	}
+	return nil
+}
+
+// Close sends the buffered events and closes the connection
+func (p *NATSPublisher) Close() error {
+	defer p.conn.Close()
+	if !p.conn.IsConnected() {
+		return nil
+	}
+	return p.conn.Flush()
+}
@@ -0,0 +1,161 @@
+// Package forwarded works out the origin clients reach the server at, which
+// differs from the request's own behind a TLS-terminating load balancer, so
+// absolute URLs in responses point where clients can follow them, and the
+// address of the client behind such a load balancer.
+package forwarded
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Proxies are the networks whose X-Forwarded-* headers are believed
+type Proxies []*net.IPNet
+
+// ParseProxies parses IP addresses and CIDR ranges such as 10.0.0.0/8
+func ParseProxies(entries []string) (Proxies, error) {
+	proxies := make(Proxies, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Contains reports whether ip is one of the proxies
+func (p Proxies) Contains(ip net.IP) bool {
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the client's IP address
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the client IP address stored in ctx, if any
+func ClientIP(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey{}).(string)
+	return ip, ok
+}
+
+// ClientIPFromHeaders returns the address of the client that sent a request
+// from the trusted proxy peer: the rightmost X-Forwarded-For hop that is not
+// one of the proxies. Hops further left were added by the client and other
+// untrusted parties, so they cannot be believed. peer is returned when there
+// are no hops, and the last trusted hop when the next one is not an address.
+func (p Proxies) ClientIPFromHeaders(r *http.Request, peer string) string {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHop(hops[i])
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !p.Contains(ip) {
+			break
+		}
+	}
+	return client
+}
+
+// WithOrigin returns a copy of ctx carrying origin, such as
+// https://api.example.com
+func WithOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, contextKey{}, origin)
+}
+
+// Origin returns the scheme and host clients reached the server at: the
+// origin stored in r's context, or else r's own
+func Origin(r *http.Request) string {
+	if origin, ok := r.Context().Value(contextKey{}).(string); ok {
+		return origin
+	}
+	return requestScheme(r) + "://" + r.Host
+}
+
+// URL returns the absolute URL of path as clients reach it
+func URL(r *http.Request, path string) string {
+	return Origin(r) + path
+}
+
+// FromHeaders returns the origin described by r's X-Forwarded-Proto and
+// X-Forwarded-Host headers, taking r's own scheme or host for a header that
+// is missing or invalid. Only call it for requests from trusted proxies.
+func FromHeaders(r *http.Request) string {
+	scheme := requestScheme(r)
+	switch proto := strings.ToLower(firstValue(r.Header.Get("X-Forwarded-Proto"))); proto {
+	case "http", "https":
+		scheme = proto
+	}
+	host := r.Host
+	if forwardedHost := firstValue(r.Header.Get("X-Forwarded-Host")); validHost(forwardedHost) {
+		host = forwardedHost
+	}
+	return scheme + "://" + host
+}
+
+// parseHop parses an X-Forwarded-For hop, which some proxies write with a
+// port, as 203.0.113.9:4000 or [2001:db8::9]:4000
+func parseHop(hop string) net.IP {
+	hop = strings.TrimSpace(hop)
+	if ip := net.ParseIP(hop); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		return net.ParseIP(host)
+	}
+	return nil
+}
+
+// requestScheme returns the scheme r arrived over
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// firstValue returns the first of a comma-separated header's values, the one
+// set by the proxy nearest the client
+func firstValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(value)
+}
+
+// validHost reports whether host is a host name or address with an optional
+// port, and nothing that would change the meaning of a URL built from it
+func validHost(host string) bool {
+	return host != "" && !strings.ContainsAny(host, "/\\?#@ \t")
+}
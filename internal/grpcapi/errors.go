@@ -0,0 +1,54 @@
+package grpcapi
+
+import (
+	"errors"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusFromError returns the gRPC status of a service error, as
+// writeServiceError does for REST, or fallback when err is of no known kind.
+// Field errors are attached as a BadRequest detail.
+func statusFromError(err error, fallback codes.Code) error {
+	code := fallback
+	switch {
+	case errors.Is(err, models.ErrLegalHold):
+		code = codes.FailedPrecondition
+	case errors.Is(err, models.ErrDuplicateEmail):
+		code = codes.AlreadyExists
+	case errors.Is(err, apperrors.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, apperrors.ErrConflict):
+		// Such as a stale version; the client should re-read and retry
+		code = codes.Aborted
+	case errors.Is(err, apperrors.ErrValidation):
+		code = codes.InvalidArgument
+	case errors.Is(err, apperrors.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, apperrors.ErrInternal):
+		code = codes.Internal
+	}
+
+	st := status.New(code, err.Error())
+	fieldErrors := services.ValidationErrors(err)
+	if len(fieldErrors) == 0 {
+		return st.Err()
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, fieldError := range fieldErrors {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fieldError.Field,
+			Description: fieldError.Message,
+		})
+	}
+	if detailed, err := st.WithDetails(badRequest); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
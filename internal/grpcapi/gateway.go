@@ -0,0 +1,83 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/proto/userpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Gateway serves the JSON API generated from the HTTP rules of
+// proto/user.proto. grpc-gateway translates its requests into calls to the
+// gRPC server, so they pass the same interceptors as gRPC clients' calls and
+// the two APIs cannot drift apart.
+type Gateway struct {
+	mux  *runtime.ServeMux
+	conn *grpc.ClientConn
+}
+
+// NewGateway serves server on a loopback listener of its own and returns a
+// gateway calling it there, so it works whether or not gRPC is exposed.
+// Stopping server closes the listener.
+func NewGateway(server *grpc.Server) (*Gateway, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the gateway: %w", err)
+	}
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect the gateway: %w", err)
+	}
+
+	mux := runtime.NewServeMux(
+		// Field names as in the proto and the REST API, e.g. created_at
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+		}),
+		// Calls carry the ID the HTTP middleware gave the request, which
+		// already echoes it
+		runtime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
+			return metadata.Pairs(requestIDKey, middleware.RequestIDFromContext(r.Context()))
+		}),
+		runtime.WithOutgoingHeaderMatcher(func(key string) (string, bool) {
+			if key == requestIDKey {
+				return "", false
+			}
+			return runtime.MetadataHeaderPrefix + key, true
+		}),
+	)
+	if err := userpb.RegisterUserServiceHandler(context.Background(), mux, conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to register the gateway: %w", err)
+	}
+	err = mux.HandlePath(http.MethodGet, "/api/v2/openapi.json", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(userpb.OpenAPI)
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to register the OpenAPI document: %w", err)
+	}
+
+	return &Gateway{mux: mux, conn: conn}, nil
+}
+
+// ServeHTTP serves a request to the generated API
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// Close disconnects the gateway from the gRPC server
+func (g *Gateway) Close() error {
+	return g.conn.Close()
+}
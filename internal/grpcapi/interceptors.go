@@ -0,0 +1,201 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/apidocs"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey carries the request ID in call metadata, as X-Request-ID does
+// in HTTP headers
+const requestIDKey = "x-request-id"
+
+// LoggingInterceptor tags every call with a request ID, reusing a valid
+// x-request-id from the client and echoing it in the response header, and
+// logs the call once it completes. Servers find a logger carrying the
+// request ID and method in the context through logger.FromContext.
+func LoggingInterceptor(base *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		ctx, id := middleware.WithRequestID(ctx, firstMetadata(ctx, requestIDKey))
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+		callLogger := base.With(
+			zap.String("request_id", id),
+			zap.String("method", info.FullMethod),
+		)
+		resp, err := handler(logger.WithContext(ctx, callLogger), req)
+
+		callLogger.Info("call completed",
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("user_agent", firstMetadata(ctx, "user-agent")),
+		)
+		return resp, err
+	}
+}
+
+// RecoveryInterceptor turns a panic in a server into an Internal error and
+// logs it with its stack trace. It must run inside LoggingInterceptor.
+func RecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			logger.FromContext(ctx).Error("panic while handling call",
+				zap.Any("panic", p),
+				zap.ByteString("stack", debug.Stack()),
+			)
+			err = status.Error(codes.Internal, "An unexpected error occurred")
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// AuthInterceptor authenticates calls as AuthMiddleware does requests, from
+// the bearer token in authorization metadata, and stores the caller's actor
+// in the context. Calls without a token are only let through to methods
+// whose REST route is on the publicPaths allowlist, and calls to methods
+// that write need the users:write scope. A nil loader skips refreshing
+// claims.
+func AuthInterceptor(keyfunc jwt.Keyfunc, tokens middleware.TokenAuthenticator, loader middleware.ClaimsLoader, publicPaths []string) grpc.UnaryServerInterceptor {
+	public := publicMethods(publicPaths)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		caller := actor.Actor{IP: peerIP(ctx)}
+
+		authHeader := firstMetadata(ctx, "authorization")
+		switch {
+		case authHeader != "":
+			claims, err := middleware.Authenticate(ctx, authHeader, keyfunc, tokens)
+			if err == nil && loader != nil {
+				claims, err = middleware.RefreshClaims(ctx, loader, claims)
+			}
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			caller = actor.FromClaims(claims)
+			caller.IP = peerIP(ctx)
+		case !public[info.FullMethod]:
+			return nil, status.Error(codes.Unauthenticated, middleware.ErrMissingAuthorization.Error())
+		}
+
+		if writeMethods[info.FullMethod] && !caller.HasScope(services.ScopeUsersWrite) {
+			if !caller.Authenticated() {
+				return nil, status.Error(codes.Unauthenticated, "Authentication required")
+			}
+			return nil, status.Error(codes.PermissionDenied, "Token is missing the "+services.ScopeUsersWrite+" scope")
+		}
+
+		return handler(actor.NewContext(ctx, caller), req)
+	}
+}
+
+// SigningInterceptor refuses writes by personal access tokens that must sign
+// their requests, as RequestSigningMiddleware would without a signature.
+// Signatures cover an HTTP request, so these tokens write over REST. It
+// must run inside AuthInterceptor.
+func SigningInterceptor(required bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		caller := actor.FromContext(ctx)
+		if writeMethods[info.FullMethod] && caller.APIKeyID != "" && (required || caller.SigningKey != "") {
+			return nil, status.Error(codes.Unauthenticated, "Requests made with this personal access token must be signed, which only the REST API supports")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuditInterceptor records every call to a method that writes in the audit
+// log, as AuditMiddleware does requests, under the REST route the method
+// mirrors and with the HTTP status of its code. It must run inside
+// AuthInterceptor so entries name the caller.
+func AuditInterceptor(recorder middleware.AuditRecorder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !writeMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		resp, err := handler(ctx, req)
+
+		entry := &models.AuditEntry{
+			Action: restRoutes[info.FullMethod],
+			Status: runtime.HTTPStatusFromCode(status.Code(err)),
+		}
+		if target, ok := req.(interface{ GetId() string }); ok {
+			entry.TargetID = target.GetId()
+		}
+		// The call may already be cancelled, but the entry must still be
+		// written
+		if recordErr := recorder.Record(context.WithoutCancel(ctx), entry); recordErr != nil {
+			logger.FromContext(ctx).Error("failed to record audit entry",
+				zap.String("action", entry.Action), zap.Error(recordErr))
+		}
+		return resp, err
+	}
+}
+
+// publicMethods returns the methods whose REST route is on the allowlist,
+// whose entries are "/path" for every method or "METHOD /path"
+func publicMethods(entries []string) map[string]bool {
+	allowed := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		fields := strings.Fields(entry)
+		switch len(fields) {
+		case 1:
+			allowed["* "+apidocs.OpenAPIPath(fields[0])] = true
+		case 2:
+			allowed[strings.ToUpper(fields[0])+" "+apidocs.OpenAPIPath(fields[1])] = true
+		}
+	}
+
+	public := make(map[string]bool)
+	for fullMethod, route := range restRoutes {
+		_, path, _ := strings.Cut(route, " ")
+		if allowed[route] || allowed["* "+path] {
+			public[fullMethod] = true
+		}
+	}
+	return public
+}
+
+// firstMetadata returns the first value of key in the call's metadata, or ""
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// peerIP returns the IP address of the caller, or "" if it is unknown
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
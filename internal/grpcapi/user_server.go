@@ -0,0 +1,132 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/pratham15541/go-crud/proto/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserServer serves the gRPC UserService from the same user service as the
+// REST handlers
+type UserServer struct {
+	userpb.UnimplementedUserServiceServer
+	userService *services.UserService
+	ids         idcodec.Codec
+}
+
+// NewUserServer creates a new user server
+func NewUserServer(userService *services.UserService, ids idcodec.Codec) *UserServer {
+	return &UserServer{userService: userService, ids: ids}
+}
+
+// CreateUser creates a user
+func (s *UserServer) CreateUser(ctx context.Context, req *userpb.CreateUserRequest) (*userpb.User, error) {
+	user, err := s.userService.CreateUser(ctx, &models.CreateUserRequest{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Age:   int(req.GetAge()),
+	})
+	if err != nil {
+		return nil, statusFromError(err, codes.InvalidArgument)
+	}
+	return s.userToProto(user), nil
+}
+
+// GetUser returns a live user
+func (s *UserServer) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.User, error) {
+	id, err := s.decodeUserID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUser(ctx, id)
+	if err != nil {
+		return nil, statusFromError(err, codes.Internal)
+	}
+	return s.userToProto(user), nil
+}
+
+// ListUsers returns a page of live users
+func (s *UserServer) ListUsers(ctx context.Context, req *userpb.ListUsersRequest) (*userpb.ListUsersResponse, error) {
+	users, total, err := s.userService.GetUsers(ctx, &models.UserFilter{}, int(req.GetPage()), int(req.GetLimit()))
+	if err != nil {
+		return nil, statusFromError(err, codes.Internal)
+	}
+
+	response := &userpb.ListUsersResponse{
+		Users: make([]*userpb.User, len(users)),
+		Total: total,
+	}
+	for i, user := range users {
+		response.Users[i] = s.userToProto(user)
+	}
+	return response, nil
+}
+
+// UpdateUser changes the fields of a user that are set in req
+func (s *UserServer) UpdateUser(ctx context.Context, req *userpb.UpdateUserRequest) (*userpb.User, error) {
+	id, err := s.decodeUserID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	update := &models.UpdateUserRequest{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Age:   int(req.GetAge()),
+	}
+	if req.Version != nil {
+		version := int(req.GetVersion())
+		update.Version = &version
+	}
+
+	user, err := s.userService.UpdateUser(ctx, id, update)
+	if err != nil {
+		return nil, statusFromError(err, codes.InvalidArgument)
+	}
+	return s.userToProto(user), nil
+}
+
+// DeleteUser soft-deletes a user
+func (s *UserServer) DeleteUser(ctx context.Context, req *userpb.DeleteUserRequest) (*emptypb.Empty, error) {
+	id, err := s.decodeUserID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userService.DeleteUser(ctx, id); err != nil {
+		return nil, statusFromError(err, codes.Internal)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// decodeUserID decodes a public user ID
+func (s *UserServer) decodeUserID(publicID string) (int, error) {
+	id, err := s.ids.Decode(publicID)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "Invalid user ID")
+	}
+	return id, nil
+}
+
+// userToProto converts a user to its message
+func (s *UserServer) userToProto(user *models.User) *userpb.User {
+	return &userpb.User{
+		Id:        s.ids.Encode(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		Age:       int32(user.Age),
+		Role:      user.Role,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+		LegalHold: user.LegalHold,
+		Version:   int32(user.Version),
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// ApprovalHandler handles HTTP requests for registrations awaiting approval
+type ApprovalHandler struct {
+	approvalService *services.ApprovalService
+	ids             idcodec.Codec
+}
+
+// NewApprovalHandler creates a new approval handler
+func NewApprovalHandler(approvalService *services.ApprovalService, ids idcodec.Codec) *ApprovalHandler {
+	return &ApprovalHandler{approvalService: approvalService, ids: ids}
+}
+
+// ListApprovals handles GET /admin/approvals
+func (h *ApprovalHandler) ListApprovals(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var afterID int
+	if raw := query.Get("after_id"); raw != "" {
+		id, err := h.ids.Decode(raw)
+		if err != nil {
+			writeError(w, "Invalid after_id", http.StatusBadRequest)
+			return
+		}
+		afterID = id
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	approvals, err := h.approvalService.ListPending(r.Context(), afterID, limit)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	responses := make([]*models.ApprovalResponse, len(approvals))
+	for i, approval := range approvals {
+		responses[i] = approval.ToResponse(h.ids)
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgRegistrationsRetrieved),
+		Data:    responses,
+	})
+}
+
+// Approve handles POST /admin/approvals/{id}/approve
+func (h *ApprovalHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.approvalService.Approve, i18n.MsgRegistrationApproved)
+}
+
+// Reject handles POST /admin/approvals/{id}/reject
+func (h *ApprovalHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.approvalService.Reject, i18n.MsgRegistrationRejected)
+}
+
+// decide reads an optional decision body and records the decision
+func (h *ApprovalHandler) decide(w http.ResponseWriter, r *http.Request,
+	decide func(ctx context.Context, userID int, req *models.ApprovalDecisionRequest) (*models.Approval, error),
+	message string) {
+	userID, err := userIDParam(r, h.ids)
+	if err != nil {
+		writeError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ApprovalDecisionRequest
+	if err := decodeJSONBody(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeDecodeError(w, err, "Invalid JSON payload")
+		return
+	}
+
+	approval, err := decide(r.Context(), userID, &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, message),
+		Data:    approval.ToResponse(h.ids),
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// AuditHandler exposes the audit log to administrators
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListEntries handles GET /audit
+func (h *AuditHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
+	afterID, _ := strconv.ParseInt(r.URL.Query().Get("after_id"), 10, 64)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	entries, err := h.auditService.ListEntries(r.Context(), afterID, limit)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []*models.AuditEntry{}
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgAuditEntriesRetrieved),
+		Data:    entries,
+	})
+}
+
+// VerifyChain handles GET /audit/verify
+func (h *AuditHandler) VerifyChain(w http.ResponseWriter, r *http.Request) {
+	result, err := h.auditService.Verify(r.Context())
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// A broken chain is reported as a conflict so monitoring can alert on it
+	statusCode := http.StatusOK
+	message := i18n.MsgAuditVerified
+	if !result.Valid {
+		statusCode = http.StatusConflict
+		message = i18n.MsgAuditVerificationFailed
+	}
+
+	writeJSON(w, statusCode, models.SuccessResponse{
+		Message: localize(r, message),
+		Data:    result,
+	})
+}
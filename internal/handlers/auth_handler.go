@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// AuthHandler handles HTTP requests for registration and login
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// Register handles POST /auth/register
+// @Summary Register an account
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param account body models.RegisterRequest true "Account to create"
+// @Success 201 {object} models.SuccessResponse{data=models.AuthResponse}
+// @Success 202 {object} models.SuccessResponse{data=models.UserResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.authService.Register(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	if resp.Pending {
+		writeJSON(w, http.StatusAccepted, models.SuccessResponse{
+			Message: localize(r, i18n.MsgRegistrationPending),
+			Data:    resp.User,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.SuccessResponse{
+		Message: localize(r, i18n.MsgUserRegistered),
+		Data:    resp,
+	})
+}
+
+// Login handles POST /auth/login
+// @Summary Log in with email and password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.LoginRequest true "Credentials"
+// @Success 200 {object} models.SuccessResponse{data=models.AuthResponse}
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.authService.Login(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgLoggedIn),
+		Data:    resp,
+	})
+}
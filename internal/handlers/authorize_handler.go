@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+//go:embed templates/authorize.html
+var authorizeTemplateFS embed.FS
+
+// authorizeTemplate renders the authorization page
+var authorizeTemplate = template.Must(template.ParseFS(authorizeTemplateFS, "templates/authorize.html"))
+
+// AuthorizePage handles GET /authorize, the page browsers are sent to by
+// clients using the authorization code flow. Users sign in on it and
+// approve or deny the request, which it forwards to POST /oauth/authorize.
+func (h *OIDCHandler) AuthorizePage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := make(map[string]string, len(query))
+	for key := range query {
+		params[key] = query.Get(key)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Credentials are typed into the page, so it must not be framed
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	authorizeTemplate.Execute(w, map[string]interface{}{
+		"ClientID": query.Get("client_id"),
+		"Scope":    query.Get("scope"),
+		"Params":   params,
+		// Relative to the page, so it resolves under any BASE_PATH
+		"APIBase": strings.TrimPrefix(h.apiBasePath, "/"),
+	})
+}
+
+// DecideAuthorization handles POST /oauth/authorize, the authorization
+// page's approval or denial of a request. It answers with the URL to send
+// the user agent to rather than redirecting, as the page calls it with fetch.
+func (h *OIDCHandler) DecideAuthorization(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		writeError(w, "Authenticated user required", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.AuthorizeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	redirectURL, err := h.oidcService.Authorize(r.Context(), userID, &req)
+	if err != nil {
+		h.sendOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgAuthorizationDecided),
+		Data:    models.AuthorizeResponse{RedirectTo: redirectURL},
+	})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// CacheHandler lets administrators warm the cache, such as after a deploy
+type CacheHandler struct {
+	warmer       *services.CacheWarmer
+	defaultLimit int
+}
+
+// NewCacheHandler creates a cache handler warming defaultLimit users unless
+// a request asks for another number
+func NewCacheHandler(warmer *services.CacheWarmer, defaultLimit int) *CacheHandler {
+	return &CacheHandler{warmer: warmer, defaultLimit: defaultLimit}
+}
+
+// WarmCache handles POST /admin/cache/warm
+func (h *CacheHandler) WarmCache(w http.ResponseWriter, r *http.Request) {
+	limit := h.defaultLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil {
+			writeError(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.warmer.Warm(r.Context(), limit)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgCacheWarmed),
+		Data:    result,
+	})
+}
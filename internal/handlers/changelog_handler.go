@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+//go:embed changelog/changelog.json
+var changelogFS embed.FS
+
+// changelog holds the embedded changelog entries, newest first
+var changelog = mustLoadChangelog()
+
+// mustLoadChangelog parses the embedded changelog, panicking on malformed
+// entries so a bad edit fails at startup rather than at request time
+func mustLoadChangelog() []models.ChangelogEntry {
+	data, err := changelogFS.ReadFile("changelog/changelog.json")
+	if err != nil {
+		panic(fmt.Sprintf("handlers: failed to read changelog: %v", err))
+	}
+
+	var entries []models.ChangelogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		panic(fmt.Sprintf("handlers: failed to parse changelog: %v", err))
+	}
+
+	for _, entry := range entries {
+		if _, err := parseVersion(entry.Version); err != nil {
+			panic(fmt.Sprintf("handlers: changelog: %v", err))
+		}
+	}
+	return entries
+}
+
+// ChangelogHandler serves the machine-readable API changelog
+type ChangelogHandler struct{}
+
+// NewChangelogHandler creates a new changelog handler
+func NewChangelogHandler() *ChangelogHandler {
+	return &ChangelogHandler{}
+}
+
+// GetChangelog handles GET /changelog
+func (h *ChangelogHandler) GetChangelog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var since []int
+	if s := query.Get("since"); s != "" {
+		v, err := parseVersion(s)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		since = v
+	}
+	changeType := strings.ToLower(query.Get("type"))
+
+	entries := make([]models.ChangelogEntry, 0, len(changelog))
+	for _, entry := range changelog {
+		if since != nil {
+			version, _ := parseVersion(entry.Version)
+			if compareVersions(version, since) <= 0 {
+				continue
+			}
+		}
+
+		changes := make([]models.APIChange, 0, len(entry.Changes))
+		for _, change := range entry.Changes {
+			if changeType == "" || change.Type == changeType {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		entry.Changes = changes
+		entries = append(entries, entry)
+	}
+
+	currentVersion := ""
+	if len(changelog) > 0 {
+		currentVersion = changelog[0].Version
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgChangelogRetrieved),
+		Data: models.ChangelogResponse{
+			CurrentVersion: currentVersion,
+			Entries:        entries,
+		},
+	})
+}
+
+// parseVersion parses a MAJOR.MINOR.PATCH version string
+func parseVersion(s string) ([]int, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", s)
+	}
+
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, equal to or newer than b
+func compareVersions(a, b []int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/metrics"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// DashboardHandler reports recent traffic to administrators
+type DashboardHandler struct {
+	dashboard *metrics.Dashboard
+}
+
+// NewDashboardHandler creates a dashboard handler
+func NewDashboardHandler(dashboard *metrics.Dashboard) *DashboardHandler {
+	return &DashboardHandler{dashboard: dashboard}
+}
+
+// GetDashboard handles GET /admin/dashboard
+func (h *DashboardHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgDashboardRetrieved),
+		Data:    h.dashboard.Snapshot(),
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// unknownFieldPrefix starts the errors encoding/json returns for fields the
+// target does not have; it offers no error type for them
+const unknownFieldPrefix = "json: unknown field "
+
+// errTrailingData reports a body holding more than one JSON value
+var errTrailingData = errors.New("request body must hold a single JSON value")
+
+// decodeJSON decodes the request body into dst, rejecting unknown fields and
+// trailing data. On failure it answers 400 naming the offending field, or
+// 413 when the body is over the limit, and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := decodeJSONBody(r, dst); err != nil {
+		writeDecodeError(w, err, "Invalid JSON payload")
+		return false
+	}
+	return true
+}
+
+// decodeJSONBody decodes the request body into dst like decodeJSON, without
+// answering. An empty body returns io.EOF.
+func decodeJSONBody(r *http.Request, dst interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return err
+	}
+
+	_, err := decoder.Token()
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == io.EOF:
+		return nil
+	case errors.As(err, &tooLarge):
+		return err
+	default:
+		return errTrailingData
+	}
+}
+
+// writeDecodeError answers a request whose body decodeJSONBody rejected,
+// with message when the body is not valid JSON or of the wrong shape
+func writeDecodeError(w http.ResponseWriter, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), unknownFieldPrefix), `"`)
+		writeFieldError(w, field, "unknown", fmt.Sprintf("Unknown field %q", field))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		writeFieldError(w, typeErr.Field, "type", fmt.Sprintf("Field %q cannot be a JSON %s", typeErr.Field, typeErr.Value))
+	case errors.Is(err, errTrailingData):
+		writeError(w, "Request body must hold a single JSON value", http.StatusBadRequest)
+	default:
+		writeError(w, message, http.StatusBadRequest)
+	}
+}
+
+// writeFieldError sends 400 for one offending field of a request body
+func writeFieldError(w http.ResponseWriter, field, rule, message string) {
+	writeJSON(w, http.StatusBadRequest, models.ErrorResponse{
+		Error:   http.StatusText(http.StatusBadRequest),
+		Message: message,
+		Code:    http.StatusBadRequest,
+		Errors:  []models.FieldError{{Field: field, Rule: rule, Message: message}},
+	})
+}
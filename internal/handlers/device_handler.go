@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+//go:embed templates/device.html
+var deviceTemplateFS embed.FS
+
+// deviceTemplate renders the device approval page
+var deviceTemplate = template.Must(template.ParseFS(deviceTemplateFS, "templates/device.html"))
+
+// DeviceAuthorization handles POST /oauth/device_authorization
+func (h *OIDCHandler) DeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.sendOAuthError(w, err)
+		return
+	}
+
+	clientID, clientSecret := r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	if id, secret, ok := r.BasicAuth(); ok {
+		clientID, clientSecret = id, secret
+	}
+
+	resp, err := h.oidcService.StartDeviceAuthorization(r.Context(), clientID, clientSecret, r.PostForm.Get("scope"))
+	if err != nil {
+		h.sendOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DevicePage handles GET /device, the page users visit to approve a device
+func (h *OIDCHandler) DevicePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	deviceTemplate.Execute(w, map[string]string{
+		"UserCode": r.URL.Query().Get("user_code"),
+		// Relative to the page, so it resolves under any BASE_PATH
+		"OAuthBase": strings.TrimPrefix(h.apiBasePath, "/") + "/oauth",
+	})
+}
+
+// GetPendingDevice handles GET /oauth/device/{user_code}
+func (h *OIDCHandler) GetPendingDevice(w http.ResponseWriter, r *http.Request) {
+	pending, err := h.oidcService.GetPendingDevice(r.Context(), mux.Vars(r)["user_code"])
+	if err != nil {
+		writeError(w, "Device code not found or expired", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgDeviceRetrieved),
+		Data:    pending,
+	})
+}
+
+// DecideDevice handles POST /oauth/device/approve
+func (h *OIDCHandler) DecideDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		writeError(w, "Authenticated user required", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.DeviceApprovalRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.oidcService.DecideDevice(r.Context(), userID, &req); err != nil {
+		writeError(w, "Device code not found or expired", http.StatusNotFound)
+		return
+	}
+
+	message := i18n.MsgDeviceDenied
+	if req.Approve {
+		message = i18n.MsgDeviceApproved
+	}
+	writeJSON(w, http.StatusOK, models.SuccessResponse{Message: localize(r, message)})
+}
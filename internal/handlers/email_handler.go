@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// maxEmailWebhookBody is the largest webhook payload read, in bytes
+const maxEmailWebhookBody = 1 << 20
+
+// EmailHandler exposes the email outbox and receives delivery notifications
+type EmailHandler struct {
+	emailService *services.EmailService
+}
+
+// NewEmailHandler creates a new email handler
+func NewEmailHandler(emailService *services.EmailService) *EmailHandler {
+	return &EmailHandler{emailService: emailService}
+}
+
+// ListEmails handles GET /admin/emails
+func (h *EmailHandler) ListEmails(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	beforeID, _ := strconv.ParseInt(query.Get("before_id"), 10, 64)
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	emails, err := h.emailService.ListEmails(r.Context(), query.Get("status"), beforeID, limit)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if emails == nil {
+		emails = []*models.Email{}
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgEmailsRetrieved),
+		Data:    emails,
+	})
+}
+
+// ReceiveEvents handles POST /webhooks/email. The body must be signed with
+// the webhook secret.
+func (h *EmailHandler) ReceiveEvents(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEmailWebhookBody))
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if !h.emailService.VerifyWebhookSignature(body, r.Header.Get("X-Webhook-Signature")) {
+		writeError(w, "Invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.EmailEventsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	applied, err := h.emailService.HandleEvents(r.Context(), req.Events)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgEmailEventsRecorded),
+		Data:    map[string]int{"applied": applied},
+	})
+}
@@ -1,32 +1,56 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"time"
 
+	"github.com/pratham15541/go-crud/internal/buildinfo"
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/i18n"
 	"github.com/pratham15541/go-crud/internal/models"
 )
 
 // HealthHandler handles health check requests
 type HealthHandler struct {
 	db        *sql.DB
+	monitor   *health.Monitor
 	startTime time.Time
+	region    string
 }
 
-// NewHealthHandler creates a new health handler
-func NewHealthHandler(db *sql.DB) *HealthHandler {
+// NewHealthHandler creates a new health handler. When monitor is set the
+// database check reports the primary and replica state from the monitor.
+func NewHealthHandler(db *sql.DB, monitor *health.Monitor) *HealthHandler {
 	return &HealthHandler{
 		db:        db,
+		monitor:   monitor,
 		startTime: time.Now(),
 	}
 }
 
+// SetRegion reports region in health checks
+func (h *HealthHandler) SetRegion(region string) {
+	h.region = region
+}
+
 // HealthCheck handles GET /health
+// @Summary Check service health
+// @Tags system
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Failure 503 {object} models.HealthResponse
+// @Router /health [get]
 func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if h.monitor != nil {
+		h.monitorHealthCheck(w)
+		return
+	}
+
 	// Check database connection
 	dbStatus := "healthy"
 	dbError := ""
@@ -42,8 +66,9 @@ func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	healthResp := models.HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now(),
-		Version:   "1.0.0",
+		Version:   buildinfo.Version,
 		Uptime:    uptime.String(),
+		Region:    h.region,
 		Checks: map[string]interface{}{
 			"database": map[string]interface{}{
 				"status": dbStatus,
@@ -64,4 +89,48 @@ func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 
 	json.NewEncoder(w).Encode(healthResp)
-}
\ No newline at end of file
+}
+
+// Version handles GET /version
+// @Summary Report the build of the running server
+// @Tags system
+// @Produce json
+// @Success 200 {object} models.SuccessResponse{data=buildinfo.Info}
+// @Router /version [get]
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgVersionRetrieved),
+		Data:    buildinfo.Get(),
+	})
+}
+
+// monitorHealthCheck reports health from the database monitor. Read-only
+// mode is reported as degraded with 200 so load balancers keep routing reads.
+func (h *HealthHandler) monitorHealthCheck(w http.ResponseWriter) {
+	status := h.monitor.Check(context.Background())
+
+	healthResp := models.HealthResponse{
+		Status:    "healthy",
+		Timestamp: time.Now(),
+		Version:   buildinfo.Version,
+		Uptime:    time.Since(h.startTime).String(),
+		Region:    h.region,
+		Checks: map[string]interface{}{
+			"database": status,
+			"memory": map[string]interface{}{
+				"status": "healthy",
+			},
+		},
+	}
+
+	statusCode := http.StatusOK
+	switch {
+	case status.ReadOnly:
+		healthResp.Status = "degraded"
+	case status.Primary != health.StateHealthy:
+		healthResp.Status = "unhealthy"
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, statusCode, healthResp)
+}
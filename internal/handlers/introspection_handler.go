@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// IntrospectionHandler handles token introspection requests from other services
+type IntrospectionHandler struct {
+	introspectionService *services.IntrospectionService
+}
+
+// NewIntrospectionHandler creates a new introspection handler
+func NewIntrospectionHandler(introspectionService *services.IntrospectionService) *IntrospectionHandler {
+	return &IntrospectionHandler{
+		introspectionService: introspectionService,
+	}
+}
+
+// Introspect handles POST /auth/introspect
+func (h *IntrospectionHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, "Invalid form payload", http.StatusBadRequest)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeError(w, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, h.introspectionService.Introspect(r.Context(), token))
+}
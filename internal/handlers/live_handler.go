@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/realtime"
+)
+
+// LiveHandler upgrades requests to WebSocket connections receiving live
+// user updates
+type LiveHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+// NewLiveHandler creates a live update handler accepting browsers from
+// allowedOrigins, or only from the server's own host when it is empty
+func NewLiveHandler(hub *realtime.Hub, allowedOrigins []string) *LiveHandler {
+	h := &LiveHandler{hub: hub}
+	if len(allowedOrigins) > 0 {
+		allowed := make(map[string]bool, len(allowedOrigins))
+		for _, origin := range allowedOrigins {
+			allowed[origin] = true
+		}
+		h.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || allowed["*"] || allowed[origin]
+		}
+	}
+	return h
+}
+
+// Connect handles GET /ws. The upgrade request is authenticated like any
+// other, with a bearer token.
+func (h *LiveHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	if h.hub.Closing() {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	caller := actor.FromContext(r.Context())
+	userID, ok := caller.UserIDInt()
+	if !ok {
+		writeError(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	// The upgrader writes its own error response
+	conn, err := h.upgrader.Upgrade(hijacker{w}, r, nil)
+	if err != nil {
+		return
+	}
+	h.hub.Add(conn, realtime.Subscriber{UserID: userID, Admin: caller.IsAdmin()})
+}
+
+// hijacker lets the upgrader take over connections whose writer middleware
+// has wrapped; http.ResponseController finds the server's own writer
+type hijacker struct {
+	http.ResponseWriter
+}
+
+// Hijack takes over the connection
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
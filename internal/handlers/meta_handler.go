@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// MetaHandler describes the API's capabilities to clients
+type MetaHandler struct {
+	pagination *services.Pagination
+}
+
+// NewMetaHandler creates a capabilities handler reporting pagination
+func NewMetaHandler(pagination *services.Pagination) *MetaHandler {
+	return &MetaHandler{pagination: pagination}
+}
+
+// GetCapabilities handles GET /_meta
+func (h *MetaHandler) GetCapabilities(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgCapabilitiesRetrieved),
+		Data: models.Capabilities{
+			Pagination: h.pagination.Capabilities(),
+		},
+	})
+}
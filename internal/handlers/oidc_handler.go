@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// OIDCHandler handles OpenID Connect provider requests
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+	apiBasePath string
+}
+
+// NewOIDCHandler creates a new OpenID Connect handler. apiBasePath is the
+// prefix the OAuth endpoints are mounted under relative to the issuer, which
+// includes any BASE_PATH, used in the discovery document.
+func NewOIDCHandler(oidcService *services.OIDCService, apiBasePath string) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		apiBasePath: apiBasePath,
+	}
+}
+
+// Discovery handles GET /.well-known/openid-configuration
+func (h *OIDCHandler) Discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := strings.TrimSuffix(h.oidcService.Issuer(), "/")
+	oauthBase := issuer + h.apiBasePath + "/oauth"
+
+	writeJSON(w, http.StatusOK, models.OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/authorize",
+		DeviceAuthorizationEndpoint:       oauthBase + "/device_authorization",
+		TokenEndpoint:                     oauthBase + "/token",
+		UserinfoEndpoint:                  oauthBase + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{services.GrantTypeAuthorizationCode, services.GrantTypeDeviceCode},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		ScopesSupported:                   []string{services.ScopeOpenID, services.ScopeProfile, services.ScopeEmail},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "name", "email", "updated_at"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	})
+}
+
+// JWKS handles GET /.well-known/jwks.json
+func (h *OIDCHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, h.oidcService.JWKS())
+}
+
+// RegisterClient handles POST /oauth/clients
+func (h *OIDCHandler) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateOAuthClientRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	client, err := h.oidcService.RegisterClient(r.Context(), &req)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.SuccessResponse{
+		Message: localize(r, i18n.MsgClientRegistered),
+		Data:    client,
+	})
+}
+
+// Authorize handles GET /oauth/authorize for an authenticated user
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		writeError(w, "Authenticated user required", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	redirectURL, err := h.oidcService.Authorize(r.Context(), userID, &models.AuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		Nonce:               query.Get("nonce"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	})
+	if err != nil {
+		h.sendOAuthError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+// Token handles POST /oauth/token
+func (h *OIDCHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.sendOAuthError(w, &services.OAuthError{Code: "invalid_request", Description: "malformed form body"})
+		return
+	}
+
+	req := &models.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		DeviceCode:   r.PostForm.Get("device_code"),
+	}
+	if id, secret, ok := r.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = id, secret
+	}
+
+	token, err := h.oidcService.Token(r.Context(), req)
+	if err != nil {
+		h.sendOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, token)
+}
+
+// UserInfo handles GET /oauth/userinfo
+func (h *OIDCHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		writeError(w, "Authenticated user required", http.StatusUnauthorized)
+		return
+	}
+
+	scope := strings.Join(actor.FromContext(r.Context()).Scopes, " ")
+	claims, err := h.oidcService.UserInfo(r.Context(), userID, scope)
+	if err != nil {
+		writeError(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, claims)
+}
+
+// sendOAuthError sends an RFC 6749 error response
+func (h *OIDCHandler) sendOAuthError(w http.ResponseWriter, err error) {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		writeJSON(w, http.StatusInternalServerError, models.OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	statusCode := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		statusCode = http.StatusUnauthorized
+	}
+
+	writeJSON(w, statusCode, models.OAuthErrorResponse{
+		Error:            oauthErr.Code,
+		ErrorDescription: oauthErr.Description,
+	})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/openapi"
+)
+
+// OpenAPIHandler serves the API description as OpenAPI 3 documents and its
+// models as JSON Schemas
+type OpenAPIHandler struct {
+	spec           *openapi.Spec
+	defaultVersion string
+	basePath       string
+}
+
+// NewOpenAPIHandler creates a handler serving spec in defaultVersion unless a
+// request asks for another, with schema URLs under basePath
+func NewOpenAPIHandler(spec *openapi.Spec, defaultVersion, basePath string) *OpenAPIHandler {
+	return &OpenAPIHandler{spec: spec, defaultVersion: defaultVersion, basePath: basePath}
+}
+
+// GetJSON handles GET /openapi.json
+func (h *OpenAPIHandler) GetJSON(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "application/json", h.spec.JSON)
+}
+
+// GetYAML handles GET /openapi.yaml
+func (h *OpenAPIHandler) GetYAML(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "application/yaml", h.spec.YAML)
+}
+
+// serve writes the document in the version picked by ?version=
+func (h *OpenAPIHandler) serve(w http.ResponseWriter, r *http.Request, contentType string, document func(string) ([]byte, bool)) {
+	version := r.URL.Query().Get("version")
+	if version == "" {
+		version = h.defaultVersion
+	}
+	doc, ok := document(version)
+	if !ok {
+		writeError(w, "Unsupported OpenAPI version, expected one of: "+strings.Join(openapi.Versions, ", "), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(doc)
+}
+
+// ListSchemas handles GET /schemas
+func (h *OpenAPIHandler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	names := h.spec.SchemaNames()
+	links := make([]models.SchemaLink, 0, len(names))
+	for _, name := range names {
+		links = append(links, models.SchemaLink{
+			Name: name,
+			URL:  forwarded.URL(r, h.basePath+"/schemas/"+name+".json"),
+		})
+	}
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgSchemasRetrieved),
+		Data:    links,
+	})
+}
+
+// GetSchema handles GET /schemas/{model}.json
+func (h *OpenAPIHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	schema, ok := h.spec.Schema(mux.Vars(r)["model"])
+	if !ok {
+		writeError(w, "Schema not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(schema)
+}
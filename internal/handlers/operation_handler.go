@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// operationRetryAfter is the polling interval suggested for unfinished operations
+const operationRetryAfter = "2"
+
+// OperationHandler handles long-running operations and their status resource
+type OperationHandler struct {
+	operations  *services.OperationService
+	userService *services.UserService
+	// jobs runs the work of operations through the background job pool
+	jobs        *services.UserService
+	ids         idcodec.Codec
+	apiBasePath string
+}
+
+// NewOperationHandler creates a new operation handler. userService serves
+// requests and jobs does the work of the operations they start.
+func NewOperationHandler(operations *services.OperationService, userService, jobs *services.UserService, ids idcodec.Codec, apiBasePath string) *OperationHandler {
+	return &OperationHandler{
+		operations:  operations,
+		userService: userService,
+		jobs:        jobs,
+		ids:         ids,
+		apiBasePath: apiBasePath,
+	}
+}
+
+// GetOperation handles GET /operations/{id}
+func (h *OperationHandler) GetOperation(w http.ResponseWriter, r *http.Request) {
+	userID, _ := currentUserID(r)
+	isAdmin := actor.FromContext(r.Context()).IsAdmin()
+
+	op, err := h.operations.GetOperation(r.Context(), mux.Vars(r)["id"], userID, isAdmin)
+	if err != nil {
+		writeError(w, "Operation not found", http.StatusNotFound)
+		return
+	}
+
+	if !op.Done() {
+		w.Header().Set("Retry-After", operationRetryAfter)
+	}
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgOperationRetrieved),
+		Data:    op.ToResponse(h.ids),
+	})
+}
+
+// AnonymizeUser handles POST /users/{id}/anonymize
+func (h *OperationHandler) AnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	id, err := userIDParam(r, h.ids)
+	if err != nil {
+		writeError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Reject held users up front rather than failing the operation later
+	user, err := h.userService.GetUser(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if user.LegalHold {
+		writeError(w, "User is under legal hold and cannot be anonymized", http.StatusLocked)
+		return
+	}
+
+	var createdBy *int
+	if userID, ok := currentUserID(r); ok {
+		createdBy = &userID
+	}
+
+	// The job outlives the request but still acts for its caller
+	caller := actor.FromContext(r.Context())
+	resultURL := forwarded.URL(r, fmt.Sprintf("%s/users/%s", h.apiBasePath, h.ids.Encode(id)))
+	op, err := h.operations.Start(r.Context(), models.OperationAnonymizeUser, createdBy, func(ctx context.Context, progress func(int)) (string, error) {
+		ctx = actor.NewContext(ctx, caller)
+		if _, err := h.jobs.AnonymizeUser(ctx, id); err != nil {
+			return "", err
+		}
+		return resultURL, nil
+	})
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeAccepted(w, r, op.ToResponse(h.ids), h.apiBasePath)
+}
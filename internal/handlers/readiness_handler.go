@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// ReadinessHandler reports whether the instance is ready for traffic
+type ReadinessHandler struct {
+	readiness *health.Readiness
+}
+
+// NewReadinessHandler creates a new readiness handler
+func NewReadinessHandler(readiness *health.Readiness) *ReadinessHandler {
+	return &ReadinessHandler{readiness: readiness}
+}
+
+// Ready handles GET /readyz. It responds 503 while any readiness condition
+// is pending so load balancers hold traffic back.
+func (h *ReadinessHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	pending := h.readiness.Pending()
+	if len(pending) > 0 {
+		writeJSON(w, http.StatusServiceUnavailable, models.ReadinessResponse{
+			Status:  "not_ready",
+			Pending: pending,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ReadinessResponse{Status: "ready"})
+}
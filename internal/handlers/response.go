@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// writeJSON sends v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends a JSON error response
+func writeError(w http.ResponseWriter, message string, statusCode int) {
+	writeJSON(w, statusCode, models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	})
+}
+
+// localize returns the message for key in the request's locale
+func localize(r *http.Request, key string, args ...interface{}) string {
+	return i18n.Message(r.Context(), key, args...)
+}
+
+// writeServiceError sends a JSON error response for err with the status of
+// its apperrors kind, or statusCode when it has none, listing the offending
+// fields when err is a validation failure
+func writeServiceError(w http.ResponseWriter, err error, statusCode int) {
+	statusCode = apperrors.Status(err, statusCode)
+	writeJSON(w, statusCode, models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: err.Error(),
+		Code:    statusCode,
+		Errors:  services.ValidationErrors(err),
+	})
+}
+
+// userIDParam decodes the {id} path variable of a user route with ids
+func userIDParam(r *http.Request, ids idcodec.Codec) (int, error) {
+	return ids.Decode(mux.Vars(r)["id"])
+}
+
+// writeAccepted sends 202 for an operation running in the background, with
+// a Location header pointing at its status resource
+func writeAccepted(w http.ResponseWriter, r *http.Request, op *models.OperationResponse, apiBasePath string) {
+	w.Header().Set("Location", forwarded.URL(r, apiBasePath+"/operations/"+op.ID))
+	writeJSON(w, http.StatusAccepted, models.SuccessResponse{
+		Message: localize(r, i18n.MsgOperationAccepted),
+		Data:    op,
+	})
+}
+
+// currentUserID returns the ID of the user the request acts for
+func currentUserID(r *http.Request) (int, bool) {
+	return actor.FromContext(r.Context()).UserIDInt()
+}
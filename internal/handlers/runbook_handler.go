@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// RunbookHandler serves the operational actions of the runbook to
+// administrators
+type RunbookHandler struct {
+	runbook *services.RunbookService
+}
+
+// NewRunbookHandler creates a runbook handler
+func NewRunbookHandler(runbook *services.RunbookService) *RunbookHandler {
+	return &RunbookHandler{runbook: runbook}
+}
+
+// FlushCaches handles POST /admin/runbook/caches/flush
+func (h *RunbookHandler) FlushCaches(w http.ResponseWriter, r *http.Request) {
+	result, err := h.runbook.FlushCaches(r.Context())
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgCachesFlushed),
+		Data:    result,
+	})
+}
+
+// RotateJWTKey handles POST /admin/runbook/jwt-keys/rotate
+func (h *RunbookHandler) RotateJWTKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.runbook.RotateJWTKey(r.Context())
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgJWTKeyRotated),
+		Data:    key,
+	})
+}
+
+// RunMigrations handles POST /admin/runbook/migrations/run
+func (h *RunbookHandler) RunMigrations(w http.ResponseWriter, r *http.Request) {
+	result, err := h.runbook.RunMigrations(r.Context())
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgMigrationsRun),
+		Data:    result,
+	})
+}
+
+// ReplayDeadLetters handles POST /admin/runbook/dead-letters/replay
+func (h *RunbookHandler) ReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	result, err := h.runbook.ReplayDeadLetters(r.Context())
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgDeadLettersReplayed),
+		Data:    result,
+	})
+}
+
+// RedeliverWebhooks handles POST /admin/runbook/webhooks/redeliver
+func (h *RunbookHandler) RedeliverWebhooks(w http.ResponseWriter, r *http.Request) {
+	result, err := h.runbook.RedeliverWebhooks(r.Context())
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgWebhooksRedelivered),
+		Data:    result,
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// SettingsHandler handles HTTP requests for user settings
+type SettingsHandler struct {
+	settingsService *services.SettingsService
+	ids             idcodec.Codec
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settingsService *services.SettingsService, ids idcodec.Codec) *SettingsHandler {
+	return &SettingsHandler{settingsService: settingsService, ids: ids}
+}
+
+// GetSettings handles GET /users/{id}/settings
+func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	id, err := userIDParam(r, h.ids)
+	if err != nil {
+		writeError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.settingsService.GetSettings(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgSettingsRetrieved),
+		Data:    settings,
+	})
+}
+
+// UpdateSettings handles PUT /users/{id}/settings. The body is the whole
+// settings object.
+func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	id, err := userIDParam(r, h.ids)
+	if err != nil {
+		writeError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var document json.RawMessage
+	if !decodeJSON(w, r, &document) {
+		return
+	}
+
+	settings, err := h.settingsService.UpdateSettings(r.Context(), id, document)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgSettingsUpdated),
+		Data:    settings,
+	})
+}
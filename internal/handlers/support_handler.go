@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/support"
+)
+
+// SupportHandler serves diagnostics bundles to attach to support tickets
+type SupportHandler struct {
+	bundler *support.Bundler
+}
+
+// NewSupportHandler creates a support handler
+func NewSupportHandler(bundler *support.Bundler) *SupportHandler {
+	return &SupportHandler{bundler: bundler}
+}
+
+// GetBundle handles GET /admin/support-bundle, downloading a gzipped
+// tarball of diagnostics. The bundle is built before anything is sent, so a
+// failure is still reported with a proper status.
+func (h *SupportHandler) GetBundle(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := h.bundler.Write(r.Context(), &buf); err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	name := fmt.Sprintf("support-bundle-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
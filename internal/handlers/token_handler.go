@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// TokenHandler handles HTTP requests for the current user's personal access tokens
+type TokenHandler struct {
+	tokenService *services.PersonalTokenService
+}
+
+// NewTokenHandler creates a new personal access token handler
+func NewTokenHandler(tokenService *services.PersonalTokenService) *TokenHandler {
+	return &TokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+// ListTokens handles GET /me/tokens
+func (h *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.tokenService.ListTokens(r.Context(), userID)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tokens == nil {
+		tokens = []*models.PersonalToken{}
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgTokensRetrieved),
+		Data:    tokens,
+	})
+}
+
+// CreateToken handles POST /me/tokens
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePersonalTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	token, err := h.tokenService.CreateToken(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.SuccessResponse{
+		Message: localize(r, i18n.MsgTokenCreated),
+		Data:    token,
+	})
+}
+
+// RevokeToken handles DELETE /me/tokens/{id}
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(r.Context(), userID, id); err != nil {
+		writeError(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{Message: localize(r, i18n.MsgTokenRevoked)})
+}
+
+// SetSigningKey handles PUT /me/tokens/{id}/signing-key
+func (h *TokenHandler) SetSigningKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SigningKeyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.tokenService.SetSigningKey(r.Context(), userID, id, &req); err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{Message: localize(r, i18n.MsgSigningKeyRegistered)})
+}
+
+// RemoveSigningKey handles DELETE /me/tokens/{id}/signing-key
+func (h *TokenHandler) RemoveSigningKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenService.RemoveSigningKey(r.Context(), userID, id); err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{Message: localize(r, i18n.MsgSigningKeyRemoved)})
+}
+
+// sessionUserID returns the current user, rejecting requests authenticated
+// with a scoped token, personal or OAuth, so a token cannot mint one with
+// wider scopes, and impersonated sessions so an impersonator cannot keep
+// access afterwards
+func (h *TokenHandler) sessionUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		writeError(w, "Authenticated user required", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	caller := actor.FromContext(r.Context())
+	if caller.APIKeyID != "" {
+		writeError(w, "Personal access tokens cannot manage tokens", http.StatusForbidden)
+		return 0, false
+	}
+	if caller.Scoped {
+		writeError(w, "Scoped tokens cannot manage tokens", http.StatusForbidden)
+		return 0, false
+	}
+	if caller.Impersonated() {
+		writeError(w, "Impersonated sessions cannot manage tokens", http.StatusForbidden)
+		return 0, false
+	}
+
+	return userID, true
+}
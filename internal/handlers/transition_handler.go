@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// TransitionHandler exposes schema transition consistency checks to administrators
+type TransitionHandler struct {
+	transitionService *services.TransitionService
+	ids               idcodec.Codec
+}
+
+// NewTransitionHandler creates a new transition handler
+func NewTransitionHandler(transitionService *services.TransitionService, ids idcodec.Codec) *TransitionHandler {
+	return &TransitionHandler{transitionService: transitionService, ids: ids}
+}
+
+// CheckTransition handles GET /transitions/{name}/check
+func (h *TransitionHandler) CheckTransition(w http.ResponseWriter, r *http.Request) {
+	report, err := h.transitionService.Check(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Inconsistent columns are reported as a conflict so a cutover script
+	// can stop on them
+	statusCode := http.StatusOK
+	message := i18n.MsgTransitionConsistent
+	if !report.Consistent {
+		statusCode = http.StatusConflict
+		message = i18n.MsgTransitionInconsistent
+	}
+
+	writeJSON(w, statusCode, models.SuccessResponse{
+		Message: localize(r, message),
+		Data:    report.ToResponse(h.ids),
+	})
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// userExportWriter writes exported users in one format. Begin is called
+// before the first user and End after the last, only if the export succeeds.
+type userExportWriter interface {
+	ContentType() string
+	Begin() error
+	Write(user *models.UserResponse) error
+	// Flush writes anything buffered, so a batch reaches the client whole
+	Flush() error
+	End() error
+}
+
+// newUserExportWriter returns the writer of an export format: "ndjson" (the
+// default), "json" or "csv"
+func newUserExportWriter(format string, w io.Writer) (userExportWriter, error) {
+	switch format {
+	case "", "ndjson":
+		return &ndjsonExportWriter{encoder: json.NewEncoder(w)}, nil
+	case "json":
+		return &jsonExportWriter{w: w}, nil
+	case "csv":
+		return &csvExportWriter{w: csv.NewWriter(w)}, nil
+	}
+	return nil, apperrors.Validation("format must be ndjson, json or csv")
+}
+
+// ndjsonExportWriter writes one JSON user per line
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExportWriter) ContentType() string { return "application/x-ndjson" }
+func (e *ndjsonExportWriter) Begin() error        { return nil }
+func (e *ndjsonExportWriter) Flush() error        { return nil }
+func (e *ndjsonExportWriter) End() error          { return nil }
+
+func (e *ndjsonExportWriter) Write(user *models.UserResponse) error {
+	return e.encoder.Encode(user)
+}
+
+// jsonExportWriter writes a single JSON array of users. An export that
+// fails midway leaves the array unclosed, so clients can't mistake it for a
+// complete one.
+type jsonExportWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (e *jsonExportWriter) ContentType() string { return "application/json" }
+func (e *jsonExportWriter) Flush() error        { return nil }
+
+func (e *jsonExportWriter) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonExportWriter) Write(user *models.UserResponse) error {
+	encoded, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	if e.written {
+		encoded = append([]byte(",\n"), encoded...)
+	}
+	e.written = true
+	_, err = e.w.Write(encoded)
+	return err
+}
+
+func (e *jsonExportWriter) End() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+// csvExportHeader names the columns of a CSV export
+var csvExportHeader = []string{"id", "name", "email", "age", "role", "created_at", "updated_at", "legal_hold", "deleted_at"}
+
+// csvExportWriter writes a header row and then a row per user, with times
+// in RFC 3339 and an empty deleted_at for users that are not deleted
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (e *csvExportWriter) ContentType() string { return "text/csv; charset=utf-8" }
+func (e *csvExportWriter) End() error          { return e.Flush() }
+
+func (e *csvExportWriter) Begin() error {
+	return e.w.Write(csvExportHeader)
+}
+
+func (e *csvExportWriter) Write(user *models.UserResponse) error {
+	deletedAt := ""
+	if user.DeletedAt != nil {
+		deletedAt = user.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return e.w.Write([]string{
+		user.ID.String(),
+		user.Name,
+		user.Email,
+		strconv.Itoa(user.Age),
+		user.Role,
+		user.CreatedAt.UTC().Format(time.RFC3339),
+		user.UpdatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatBool(user.LegalHold),
+		deletedAt,
+	})
+}
+
+func (e *csvExportWriter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -2,82 +2,269 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/logger"
 	"github.com/pratham15541/go-crud/internal/models"
 	"github.com/pratham15541/go-crud/internal/services"
+	"go.uber.org/zap"
 )
 
 // UserHandler handles HTTP requests for user operations
 type UserHandler struct {
 	userService *services.UserService
+	ids         idcodec.Codec
+
+	// emailService reports email deliverability to admins; nil hides it
+	emailService *services.EmailService
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userService *services.UserService) *UserHandler {
+func NewUserHandler(userService *services.UserService, ids idcodec.Codec) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		ids:         ids,
 	}
 }
 
+// SetDeliverability shows admins whether emails can be sent to a user
+func (h *UserHandler) SetDeliverability(emailService *services.EmailService) {
+	h.emailService = emailService
+}
+
 // CreateUser handles POST /users
+// @Summary Create a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body models.CreateUserRequest true "User to create"
+// @Success 201 {object} models.SuccessResponse{data=models.UserResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users [post]
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendErrorResponse(w, "Invalid JSON payload", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.userService.CreateUser(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUserCreated), user.ToResponse(h.ids), http.StatusCreated)
+}
+
+// CreateUsers handles POST /users/bulk. Items are reported one by one with
+// the status each would have had alone; the response is 201 when every user
+// was created and 207 otherwise.
+// @Summary Create users in bulk
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param users body []models.CreateUserRequest true "Users to create"
+// @Success 201 {object} models.SuccessResponse{data=models.BulkCreateUsersResponse}
+// @Success 207 {object} models.SuccessResponse{data=models.BulkCreateUsersResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/bulk [post]
+func (h *UserHandler) CreateUsers(w http.ResponseWriter, r *http.Request) {
+	var reqs []models.CreateUserRequest
+	if err := decodeJSONBody(r, &reqs); err != nil {
+		writeDecodeError(w, err, "Invalid JSON payload, expected an array of users")
+		return
+	}
+
+	items := make([]*models.CreateUserRequest, len(reqs))
+	for i := range reqs {
+		items[i] = &reqs[i]
+	}
+	results, err := h.userService.CreateUsers(r.Context(), items)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := models.BulkCreateUsersResponse{Results: make([]models.BulkUserResult, len(results))}
+	for i, result := range results {
+		item := models.BulkUserResult{Index: i, Status: http.StatusCreated}
+		if result.Err != nil {
+			item.Status = apperrors.Status(result.Err, http.StatusInternalServerError)
+			item.Error = result.Err.Error()
+			item.Errors = services.ValidationErrors(result.Err)
+			response.Failed++
+		} else {
+			item.User = result.User.ToResponse(h.ids)
+			response.Created++
+		}
+		response.Results[i] = item
+	}
+
+	if response.Failed > 0 {
+		h.sendSuccessResponse(w, localize(r, i18n.MsgUsersPartiallyCreated, response.Created, len(results)), response, http.StatusMultiStatus)
 		return
 	}
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUsersCreated), response, http.StatusCreated)
+}
 
-	user, err := h.userService.CreateUser(&req)
+// maxImportSize bounds the body of a CSV import
+const maxImportSize = 32 << 20
+
+// ImportUsers handles POST /users/import, a multipart upload whose "file"
+// part is a CSV document. The part is read as it arrives rather than saved
+// first.
+func (h *UserHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	reader, err := r.MultipartReader()
 	if err != nil {
-		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		h.sendErrorResponse(w, "Expected a multipart/form-data upload", http.StatusBadRequest)
 		return
 	}
 
-	h.sendSuccessResponse(w, "User created successfully", user.ToResponse(), http.StatusCreated)
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			h.sendErrorResponse(w, `Upload must include the CSV document in a "file" part`, http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		summary, err := h.userService.ImportUsers(r.Context(), part)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				h.sendErrorResponse(w, fmt.Sprintf("Upload exceeds %d bytes; rows before the limit were imported", maxImportSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+			writeServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		h.sendSuccessResponse(w, localize(r, i18n.MsgUsersImported), summary, http.StatusOK)
+		return
+	}
 }
 
 // GetUser handles GET /users/{id}
+// @Summary Get a user
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.SuccessResponse{data=models.UserResponse}
+// @Failure 404 {object} models.ErrorResponse
+// @Router /users/{id} [get]
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := userIDParam(r, h.ids)
 	if err != nil {
 		h.sendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	user, err := h.userService.GetUser(id)
+	user, err := h.userService.GetUser(r.Context(), id)
 	if err != nil {
-		if err.Error() == "user not found" {
-			h.sendErrorResponse(w, "User not found", http.StatusNotFound)
-		} else {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := user.ToResponse(h.ids)
+	if h.emailService != nil && actor.FromContext(r.Context()).IsAdmin() {
+		response.EmailDeliverability, err = h.emailService.Deliverability(r.Context(), user.Email)
+		if err != nil {
 			h.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		return
 	}
 
-	h.sendSuccessResponse(w, "User retrieved successfully", user.ToResponse(), http.StatusOK)
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUserRetrieved), response, http.StatusOK)
 }
 
 // GetUsers handles GET /users
+// @Summary List users
+// @Description Pages with page and limit, or by keyset when cursor is present
+// @Tags users
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Cursor from the previous page; empty for the first"
+// @Param name query string false "Name contains"
+// @Param email query string false "Email contains"
+// @Param min_age query int false "Minimum age"
+// @Param max_age query int false "Maximum age"
+// @Param created_after query string false "RFC 3339 timestamp"
+// @Param sort query string false "Fields to sort by, e.g. -created_at,name"
+// @Param include_deleted query bool false "Include deleted users; admins only"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /users [get]
 func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 
-	users, total, err := h.userService.GetUsers(page, limit)
+	filter, err := parseUserFilter(r.URL.Query())
 	if err != nil {
-		h.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	if includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted")); includeDeleted {
+		if !actor.FromContext(r.Context()).IsAdmin() {
+			h.sendErrorResponse(w, "include_deleted requires an admin token", http.StatusForbidden)
+			return
+		}
+		filter.IncludeDeleted = true
+	}
+
+	// Any cursor parameter, even an empty one, selects keyset pagination
+	if r.URL.Query().Has("cursor") {
+		h.getUsersAfter(w, r, filter, r.URL.Query().Get("cursor"), limit)
+		return
+	}
+
+	users, total, err := h.userService.GetUsers(r.Context(), filter, page, limit)
+	var deepErr *services.PageTooDeepError
+	if errors.As(err, &deepErr) {
+		if deepErr.NextCursor != "" && deepErr.Follow {
+			h.getUsersAfter(w, r, filter, deepErr.NextCursor, limit)
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, models.PageTooDeepResponse{
+			ErrorResponse: models.ErrorResponse{
+				Error:   http.StatusText(http.StatusBadRequest),
+				Message: deepErr.Error(),
+				Code:    http.StatusBadRequest,
+			},
+			ErrorCode:  models.ErrorCodePageTooDeep,
+			MaxOffset:  deepErr.MaxOffset,
+			NextCursor: deepErr.NextCursor,
+		})
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
 		return
 	}
 
 	// Convert to response format
 	userResponses := make([]*models.UserResponse, len(users))
 	for i, user := range users {
-		userResponses[i] = user.ToResponse()
+		userResponses[i] = user.ToResponse(h.ids)
 	}
 
 	// Create paginated response
@@ -90,57 +277,362 @@ func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	h.sendSuccessResponse(w, "Users retrieved successfully", response, http.StatusOK)
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUsersRetrieved), response, http.StatusOK)
+}
+
+// ExportUsers handles GET /users/export, streaming every user matching the
+// list filters as newline-delimited JSON, a JSON array or CSV, newest first
+func (h *UserHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	filter.IncludeDeleted, _ = strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+
+	export, err := newUserExportWriter(r.URL.Query().Get("format"), w)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Headers are held back until the first batch arrives, so errors raised
+	// before anything is read still get a proper status code
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		w.Header().Set("Content-Type", export.ContentType())
+		w.WriteHeader(http.StatusOK)
+		started = true
+		return export.Begin()
+	}
+
+	controller := http.NewResponseController(w)
+	err = h.userService.ExportUsers(r.Context(), filter, func(users []*models.User) error {
+		if err := start(); err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := export.Write(user.ToResponse(h.ids)); err != nil {
+				return err
+			}
+		}
+		// Send each batch right away rather than when the buffer fills
+		if err := export.Flush(); err != nil {
+			return err
+		}
+		controller.Flush()
+		return nil
+	})
+	if err == nil {
+		if err = start(); err == nil {
+			err = export.End()
+		}
+	}
+
+	switch {
+	case err == nil:
+		return
+	case started:
+		// The status line is gone; the client sees a truncated stream
+		logger.FromContext(r.Context()).Warn("user export aborted", zap.Error(err))
+	default:
+		writeServiceError(w, err, http.StatusInternalServerError)
+	}
+}
+
+// getUsersAfter serves GET /users?cursor= with keyset pagination
+func (h *UserHandler) getUsersAfter(w http.ResponseWriter, r *http.Request, filter *models.UserFilter, cursor string, limit int) {
+	users, pagination, err := h.userService.GetUsersAfter(r.Context(), filter, cursor, limit)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	userResponses := make([]*models.UserResponse, len(users))
+	for i, user := range users {
+		userResponses[i] = user.ToResponse(h.ids)
+	}
+
+	response := map[string]interface{}{
+		"users":      userResponses,
+		"pagination": pagination,
+	}
+
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUsersRetrieved), response, http.StatusOK)
+}
+
+// parseUserFilter reads the filter and sort parameters of GET /users
+func parseUserFilter(query url.Values) (*models.UserFilter, error) {
+	filter := &models.UserFilter{
+		Name:  strings.TrimSpace(query.Get("name")),
+		Email: strings.TrimSpace(query.Get("email")),
+	}
+	var fieldErrs []models.FieldError
+
+	parseAge := func(field string, target *int) {
+		value := query.Get(field)
+		if value == "" {
+			return
+		}
+		age, err := strconv.Atoi(value)
+		if err != nil || age < 1 {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   field,
+				Rule:    "min",
+				Message: field + " must be a positive integer",
+			})
+			return
+		}
+		*target = age
+	}
+	parseAge("min_age", &filter.MinAge)
+	parseAge("max_age", &filter.MaxAge)
+
+	if value := query.Get("created_after"); value != "" {
+		createdAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   "created_after",
+				Rule:    "datetime",
+				Message: "created_after must be an RFC 3339 timestamp",
+			})
+		}
+		filter.CreatedAfter = createdAfter
+	}
+
+	// sort=-created_at,name orders by created_at descending, then name
+	for _, field := range strings.Split(query.Get("sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		sort := models.UserSort{Field: strings.TrimPrefix(field, "-")}
+		sort.Desc = sort.Field != field
+		filter.Sort = append(filter.Sort, sort)
+	}
+
+	if len(fieldErrs) > 0 {
+		return nil, &services.ValidationError{Errors: fieldErrs}
+	}
+	return filter, nil
 }
 
 // UpdateUser handles PUT /users/{id}
+// @Summary Update a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body models.UpdateUserRequest true "Fields to change"
+// @Success 200 {object} models.SuccessResponse{data=models.UserResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := userIDParam(r, h.ids)
 	if err != nil {
 		h.sendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
 	var req models.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendErrorResponse(w, "Invalid JSON payload", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	user, err := h.userService.UpdateUser(id, &req)
+	user, err := h.userService.UpdateUser(r.Context(), id, &req)
 	if err != nil {
-		if err.Error() == "user not found" {
-			h.sendErrorResponse(w, "User not found", http.StatusNotFound)
-		} else {
-			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUserUpdated), user.ToResponse(h.ids), http.StatusOK)
+}
+
+// UpsertUser handles PUT /users/by-email/{email}, responding 201 when the
+// user was created and 200 when it was updated
+// @Summary Create or update a user by email
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param email path string true "Email address"
+// @Param user body models.UpsertUserRequest true "Name and age"
+// @Success 200 {object} models.SuccessResponse{data=models.UserResponse}
+// @Success 201 {object} models.SuccessResponse{data=models.UserResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/by-email/{email} [put]
+func (h *UserHandler) UpsertUser(w http.ResponseWriter, r *http.Request) {
+	var req models.UpsertUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, created, err := h.userService.UpsertUser(r.Context(), mux.Vars(r)["email"], &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if created {
+		h.sendSuccessResponse(w, localize(r, i18n.MsgUserCreated), user.ToResponse(h.ids), http.StatusCreated)
+		return
+	}
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUserUpdated), user.ToResponse(h.ids), http.StatusOK)
+}
+
+// PatchUser handles PATCH /users/{id}
+// @Summary Partially update a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body models.PatchUserRequest true "Fields to change"
+// @Success 200 {object} models.SuccessResponse{data=models.UserResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/{id} [patch]
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	id, err := userIDParam(r, h.ids)
+	if err != nil {
+		h.sendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if !decodeJSON(w, r, &fields) {
+		return
+	}
+
+	// None of the patchable fields can be cleared, so an explicit null is
+	// rejected rather than silently treated as "not provided"
+	var req models.PatchUserRequest
+	for name, value := range fields {
+		var target interface{}
+		switch name {
+		case "name":
+			target = &req.Name
+		case "email":
+			target = &req.Email
+		case "age":
+			target = &req.Age
+		case "version":
+			target = &req.Version
+		default:
+			h.sendErrorResponse(w, "Unknown field: "+name, http.StatusBadRequest)
+			return
 		}
+		if string(value) == "null" {
+			h.sendErrorResponse(w, name+" cannot be null", http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(value, target); err != nil {
+			h.sendErrorResponse(w, "Invalid value for "+name, http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, err := h.userService.PatchUser(r.Context(), id, &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	h.sendSuccessResponse(w, "User updated successfully", user.ToResponse(), http.StatusOK)
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUserUpdated), user.ToResponse(h.ids), http.StatusOK)
 }
 
 // DeleteUser handles DELETE /users/{id}
+// @Summary Delete a user
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 423 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := userIDParam(r, h.ids)
 	if err != nil {
 		h.sendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	err = h.userService.DeleteUser(id)
+	err = h.userService.DeleteUser(r.Context(), id)
 	if err != nil {
-		if err.Error() == "user not found" {
-			h.sendErrorResponse(w, "User not found", http.StatusNotFound)
+		if errors.Is(err, models.ErrLegalHold) {
+			h.sendErrorResponse(w, "User is under legal hold and cannot be deleted", http.StatusLocked)
 		} else {
-			h.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+			writeServiceError(w, err, http.StatusInternalServerError)
 		}
 		return
 	}
 
-	h.sendSuccessResponse(w, "User deleted successfully", nil, http.StatusOK)
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUserDeleted), nil, http.StatusOK)
+}
+
+// RestoreUser handles POST /users/{id}/restore
+// @Summary Restore a deleted user
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.SuccessResponse{data=models.UserResponse}
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/{id}/restore [post]
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	id, err := userIDParam(r, h.ids)
+	if err != nil {
+		h.sendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.RestoreUser(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, localize(r, i18n.MsgUserRestored), user.ToResponse(h.ids), http.StatusOK)
+}
+
+// PlaceLegalHold handles PUT /users/{id}/legal-hold
+func (h *UserHandler) PlaceLegalHold(w http.ResponseWriter, r *http.Request) {
+	h.setLegalHold(w, r, true)
+}
+
+// LiftLegalHold handles DELETE /users/{id}/legal-hold
+func (h *UserHandler) LiftLegalHold(w http.ResponseWriter, r *http.Request) {
+	h.setLegalHold(w, r, false)
+}
+
+// setLegalHold places or lifts the legal hold on the user in the path
+func (h *UserHandler) setLegalHold(w http.ResponseWriter, r *http.Request, hold bool) {
+	id, err := userIDParam(r, h.ids)
+	if err != nil {
+		h.sendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.SetLegalHold(r.Context(), id, hold)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	message := i18n.MsgLegalHoldPlaced
+	if !hold {
+		message = i18n.MsgLegalHoldLifted
+	}
+	h.sendSuccessResponse(w, localize(r, message), user.ToResponse(h.ids), http.StatusOK)
 }
 
 // sendErrorResponse sends an error response
@@ -168,4 +660,4 @@ func (h *UserHandler) sendSuccessResponse(w http.ResponseWriter, message string,
 	}
 
 	json.NewEncoder(w).Encode(successResp)
-}
\ No newline at end of file
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// WatchHandler handles HTTP requests for watches on users
+type WatchHandler struct {
+	watchService *services.WatchService
+	ids          idcodec.Codec
+}
+
+// NewWatchHandler creates a new watch handler
+func NewWatchHandler(watchService *services.WatchService, ids idcodec.Codec) *WatchHandler {
+	return &WatchHandler{watchService: watchService, ids: ids}
+}
+
+// CreateWatch handles POST /watches
+func (h *WatchHandler) CreateWatch(w http.ResponseWriter, r *http.Request) {
+	caller := actor.FromContext(r.Context())
+	ownerID, ok := caller.UserIDInt()
+	if !ok {
+		writeError(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.WatchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	watch, err := h.watchService.CreateWatch(r.Context(), ownerID, caller.IsAdmin(), &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.SuccessResponse{
+		Message: localize(r, i18n.MsgWatchCreated),
+		Data:    watch.ToResponse(h.ids),
+	})
+}
+
+// ListWatches handles GET /watches, listing the caller's watches
+func (h *WatchHandler) ListWatches(w http.ResponseWriter, r *http.Request) {
+	ownerID, ok := currentUserID(r)
+	if !ok {
+		writeError(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	watches, err := h.watchService.ListWatches(r.Context(), ownerID)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	responses := make([]*models.WatchResponse, len(watches))
+	for i, watch := range watches {
+		responses[i] = watch.ToResponse(h.ids)
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgWatchesRetrieved),
+		Data:    responses,
+	})
+}
+
+// GetWatch handles GET /watches/{id}
+func (h *WatchHandler) GetWatch(w http.ResponseWriter, r *http.Request) {
+	ownerID, id, ok := watchParams(w, r)
+	if !ok {
+		return
+	}
+
+	watch, err := h.watchService.GetWatch(r.Context(), ownerID, id)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgWatchRetrieved),
+		Data:    watch.ToResponse(h.ids),
+	})
+}
+
+// DeleteWatch handles DELETE /watches/{id}
+func (h *WatchHandler) DeleteWatch(w http.ResponseWriter, r *http.Request) {
+	ownerID, id, ok := watchParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.watchService.DeleteWatch(r.Context(), ownerID, id); err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{Message: localize(r, i18n.MsgWatchDeleted)})
+}
+
+// Stream handles GET /watches/stream, sending the notifications of the
+// caller's watches as server-sent events until the client disconnects or
+// the server shuts down
+func (h *WatchHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	ownerID, ok := currentUserID(r)
+	if !ok {
+		writeError(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	notifications, unsubscribe := h.watchService.Subscribe(ownerID, func() {
+		// A past deadline fails the write the stream is blocked in
+		controller.SetWriteDeadline(time.Now())
+	})
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, ": watching\n\n")
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := h.watchService.StreamHeartbeat()
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			io.WriteString(w, ": heartbeat\n\n")
+		case notification, ok := <-notifications:
+			if !ok {
+				// The server is shutting down
+				io.WriteString(w, "event: reconnect\ndata: server shutting down, reconnect\n\n")
+				controller.Flush()
+				return
+			}
+			data, err := json.Marshal(notification)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", notification.EventID, models.WatchTriggered, data)
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// watchParams returns the caller and the {id} path variable, answering 401
+// or 400 if either is missing
+func watchParams(w http.ResponseWriter, r *http.Request) (int, int64, bool) {
+	ownerID, ok := currentUserID(r)
+	if !ok {
+		writeError(w, "Authentication required", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeError(w, "Invalid watch ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return ownerID, id, true
+}
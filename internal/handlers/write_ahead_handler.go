@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// WriteAheadHandler handles user creation with queuing during database outages
+type WriteAheadHandler struct {
+	writeAhead  *services.WriteAheadService
+	ids         idcodec.Codec
+	apiBasePath string
+}
+
+// NewWriteAheadHandler creates a new write-ahead handler
+func NewWriteAheadHandler(writeAhead *services.WriteAheadService, ids idcodec.Codec, apiBasePath string) *WriteAheadHandler {
+	return &WriteAheadHandler{
+		writeAhead:  writeAhead,
+		ids:         ids,
+		apiBasePath: apiBasePath,
+	}
+}
+
+// CreateUser handles POST /users, responding 202 with a tracking resource
+// when the request was queued
+func (h *WriteAheadHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, queued, err := h.writeAhead.CreateUser(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if queued != nil {
+		w.Header().Set("Location", forwarded.URL(r, h.apiBasePath+"/users/queued/"+queued.ID))
+		writeJSON(w, http.StatusAccepted, models.SuccessResponse{
+			Message: localize(r, i18n.MsgUserQueued),
+			Data:    queued,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.SuccessResponse{
+		Message: localize(r, i18n.MsgUserCreated),
+		Data:    user.ToResponse(h.ids),
+	})
+}
+
+// GetQueuedWrite handles GET /users/queued/{id}
+func (h *WriteAheadHandler) GetQueuedWrite(w http.ResponseWriter, r *http.Request) {
+	queued, err := h.writeAhead.GetQueuedWrite(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, "Queued request not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: localize(r, i18n.MsgQueuedRequestRetrieved),
+		Data:    queued,
+	})
+}
@@ -0,0 +1,166 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/logger"
+	"go.uber.org/zap"
+)
+
+// memoryCondition is the readiness condition set while memory is short
+const memoryCondition = "memory"
+
+// memoryRecovery is the fraction of the pressure threshold memory must fall
+// below to end pressure, so usage hovering at the threshold does not flap
+const memoryRecovery = 0.9
+
+// MemoryLimit returns the memory the process may use: configured if it is
+// set, else GOMEMLIMIT, else 0 for no limit
+func MemoryLimit(configured int64) uint64 {
+	if configured > 0 {
+		return uint64(configured)
+	}
+	// A negative limit reads the current one without changing it
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		return uint64(limit)
+	}
+	return 0
+}
+
+// MemoryWatchdog watches the memory the Go runtime holds against a limit,
+// such as the container's, and acts before the OOM killer does. Past the
+// threshold it runs the garbage collector, and if that does not help it
+// reports pressure: heavy endpoints shed load, /readyz reports the instance
+// unready and a heap profile is written for diagnosis.
+type MemoryWatchdog struct {
+	limit     uint64
+	threshold float64
+	readiness *Readiness
+
+	underPressure atomic.Bool
+
+	// ProfileDir, if set, receives a heap profile each time pressure starts
+	ProfileDir string
+	// Usage reads the memory in use; it defaults to the runtime's own count,
+	// which GOMEMLIMIT applies to
+	Usage func() uint64
+}
+
+// NewMemoryWatchdog creates a watchdog reporting pressure above threshold,
+// a fraction of limit, in readiness
+func NewMemoryWatchdog(limit uint64, threshold float64, readiness *Readiness) *MemoryWatchdog {
+	return &MemoryWatchdog{
+		limit:     limit,
+		threshold: threshold,
+		readiness: readiness,
+		Usage:     runtimeMemoryInUse,
+	}
+}
+
+// Run checks memory every interval until ctx is cancelled
+func (w *MemoryWatchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads memory usage once and enters or leaves pressure
+func (w *MemoryWatchdog) Check(ctx context.Context) {
+	usage := w.Usage()
+	highWater := uint64(w.threshold * float64(w.limit))
+
+	if w.underPressure.Load() {
+		if usage < uint64(memoryRecovery*float64(highWater)) {
+			w.underPressure.Store(false)
+			w.readiness.SetReady(memoryCondition)
+			logger.FromContext(ctx).Info("memory pressure relieved", w.fields(usage)...)
+		}
+		return
+	}
+	if usage < highWater {
+		return
+	}
+
+	// Much of it may be garbage; only what survives a collection counts
+	runtime.GC()
+	debug.FreeOSMemory()
+	if usage = w.Usage(); usage < highWater {
+		return
+	}
+
+	w.underPressure.Store(true)
+	w.readiness.SetPending(memoryCondition, fmt.Sprintf("memory at %d%% of the %d MiB limit",
+		usage*100/w.limit, w.limit>>20))
+	fields := w.fields(usage)
+	if w.ProfileDir != "" {
+		path, err := w.writeHeapProfile()
+		if err != nil {
+			fields = append(fields, zap.NamedError("profile_error", err))
+		} else {
+			fields = append(fields, zap.String("profile", path))
+		}
+	}
+	logger.FromContext(ctx).Warn("memory pressure, shedding heavy requests", fields...)
+}
+
+// UnderPressure reports whether memory is short
+func (w *MemoryWatchdog) UnderPressure() bool {
+	return w.underPressure.Load()
+}
+
+// fields describes memory usage for logs
+func (w *MemoryWatchdog) fields(usage uint64) []zap.Field {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return []zap.Field{
+		zap.Uint64("usage_bytes", usage),
+		zap.Uint64("limit_bytes", w.limit),
+		zap.Uint64("heap_alloc_bytes", stats.HeapAlloc),
+		zap.Uint64("heap_objects", stats.HeapObjects),
+		zap.Int("goroutines", runtime.NumGoroutine()),
+	}
+}
+
+// writeHeapProfile writes a heap profile to ProfileDir and returns its path
+func (w *MemoryWatchdog) writeHeapProfile() (string, error) {
+	path := filepath.Join(w.ProfileDir, fmt.Sprintf("heap-%s.pprof", time.Now().UTC().Format("20060102T150405Z")))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := pprof.Lookup("heap").WriteTo(f, 0); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// memoryMetrics are the runtime metrics whose difference is the memory
+// GOMEMLIMIT counts: everything mapped, less what was returned to the OS
+var memoryMetrics = []string{"/memory/classes/total:bytes", "/memory/classes/heap/released:bytes"}
+
+// runtimeMemoryInUse returns the memory the Go runtime holds
+func runtimeMemoryInUse() uint64 {
+	samples := []metrics.Sample{{Name: memoryMetrics[0]}, {Name: memoryMetrics[1]}}
+	metrics.Read(samples)
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Database states reported by the monitor
+const (
+	StateHealthy     = "healthy"
+	StateUnhealthy   = "unhealthy"
+	StateUnavailable = "unavailable"
+)
+
+// pingTimeout bounds each database probe
+const pingTimeout = 2 * time.Second
+
+// Pinger is implemented by *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Status is a snapshot of the database health
+type Status struct {
+	Primary      string    `json:"primary"`
+	PrimaryError string    `json:"primary_error,omitempty"`
+	Replica      string    `json:"replica"`
+	ReplicaError string    `json:"replica_error,omitempty"`
+	ReadOnly     bool      `json:"read_only"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Monitor periodically probes the primary database and its read replica.
+// When the primary is down but the replica is reachable the service runs
+// in read-only mode: reads are served from the replica and writes are
+// rejected until the primary recovers.
+type Monitor struct {
+	primary  Pinger
+	replica  Pinger
+	interval time.Duration
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// NewMonitor creates a monitor. replica may be nil when no replica is configured.
+// The primary is assumed healthy until the first check runs.
+func NewMonitor(primary, replica Pinger, interval time.Duration) *Monitor {
+	replicaState := StateUnavailable
+	if replica != nil {
+		replicaState = StateHealthy
+	}
+
+	return &Monitor{
+		primary:  primary,
+		replica:  replica,
+		interval: interval,
+		status: Status{
+			Primary:   StateHealthy,
+			Replica:   replicaState,
+			CheckedAt: time.Now(),
+		},
+	}
+}
+
+// Run checks the databases every interval until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check probes the databases once and updates the status
+func (m *Monitor) Check(ctx context.Context) Status {
+	status := Status{Replica: StateUnavailable, CheckedAt: time.Now()}
+	status.Primary, status.PrimaryError = probe(ctx, m.primary)
+	if m.replica != nil {
+		status.Replica, status.ReplicaError = probe(ctx, m.replica)
+	}
+	status.ReadOnly = status.Primary != StateHealthy && status.Replica == StateHealthy
+
+	m.mu.Lock()
+	previous := m.status
+	m.status = status
+	m.mu.Unlock()
+
+	if status.ReadOnly != previous.ReadOnly {
+		if status.ReadOnly {
+			logger.FromContext(ctx).Warn("primary database unavailable, serving reads from replica",
+				zap.String("error", status.PrimaryError))
+		} else {
+			logger.FromContext(ctx).Info("leaving read-only mode")
+		}
+	}
+	return status
+}
+
+// Status returns the result of the most recent check
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// ReadOnly reports whether writes should be rejected and reads served from the replica
+func (m *Monitor) ReadOnly() bool {
+	return m.Status().ReadOnly
+}
+
+// ReplicaHealthy reports whether the last check reached the replica
+func (m *Monitor) ReplicaHealthy() bool {
+	return m.Status().Replica == StateHealthy
+}
+
+// PrimaryHealthy reports whether the last check reached the primary
+func (m *Monitor) PrimaryHealthy() bool {
+	return m.Status().Primary == StateHealthy
+}
+
+// probe pings db and returns its state and error message
+func probe(ctx context.Context, db Pinger) (string, string) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		return StateUnhealthy, err.Error()
+	}
+	return StateHealthy, ""
+}
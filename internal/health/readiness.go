@@ -0,0 +1,45 @@
+package health
+
+import (
+	"sync"
+)
+
+// Readiness tracks the conditions that must be met before the instance
+// should receive traffic. Each pending condition has a name and a reason
+// shown by the readiness endpoint.
+type Readiness struct {
+	mu      sync.RWMutex
+	pending map[string]string
+}
+
+// NewReadiness creates a tracker with nothing pending, so it reports ready
+func NewReadiness() *Readiness {
+	return &Readiness{pending: make(map[string]string)}
+}
+
+// SetPending marks the condition name as unmet
+func (r *Readiness) SetPending(name, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[name] = reason
+}
+
+// SetReady marks the condition name as met
+func (r *Readiness) SetReady(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, name)
+}
+
+// Pending returns the unmet conditions and their reasons; it is empty when
+// the instance is ready
+func (r *Readiness) Pending() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pending := make(map[string]string, len(r.pending))
+	for name, reason := range r.pending {
+		pending[name] = reason
+	}
+	return pending
+}
@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Formatter writes numbers and amounts the way a locale expects, for
+// serializers and exports meant to be read by people rather than programs
+type Formatter struct {
+	locale  language.Tag
+	printer *message.Printer
+}
+
+// NewFormatter creates a formatter for locale
+func NewFormatter(locale language.Tag) *Formatter {
+	return &Formatter{locale: locale, printer: message.NewPrinter(locale)}
+}
+
+// Locale returns the locale the formatter writes for
+func (f *Formatter) Locale() language.Tag {
+	return f.locale
+}
+
+// Integer formats n with the locale's digit grouping, such as 1.234.567 in German
+func (f *Formatter) Integer(n int64) string {
+	return f.printer.Sprint(number.Decimal(n))
+}
+
+// Decimal formats v rounded to scale fractional digits
+func (f *Formatter) Decimal(v float64, scale int) string {
+	return f.printer.Sprint(number.Decimal(v, number.Scale(scale)))
+}
+
+// Percent formats a ratio, so 0.25 becomes 25% or 25 % depending on the locale
+func (f *Formatter) Percent(ratio float64) string {
+	return f.printer.Sprint(number.Percent(ratio))
+}
+
+// Currency formats amount in the ISO 4217 currency code with its symbol and
+// standard number of fractional digits, such as € 1.234,50 in German
+func (f *Formatter) Currency(amount float64, code string) (string, error) {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return "", fmt.Errorf("invalid currency %q", code)
+	}
+	return f.printer.Sprint(currency.Symbol(unit.Amount(amount))), nil
+}
+
+// TenantLocales maps tenant IDs to the locale their data is formatted in
+type TenantLocales map[string]language.Tag
+
+// ParseTenantLocales parses entries of the form tenant=locale
+func ParseTenantLocales(entries []string) (TenantLocales, error) {
+	locales := make(TenantLocales, len(entries))
+	for _, entry := range entries {
+		tenant, locale, ok := strings.Cut(entry, "=")
+		if !ok || tenant == "" {
+			return nil, fmt.Errorf("invalid tenant locale %q, want tenant=locale", entry)
+		}
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale %q for tenant %q: %w", locale, tenant, err)
+		}
+		locales[tenant] = tag
+	}
+	return locales, nil
+}
+
+// formatKey is the context key for the formatting locale
+type formatKey struct{}
+
+// WithFormatLocale returns a copy of ctx whose formatter writes for locale,
+// whatever the request locale is
+func WithFormatLocale(ctx context.Context, locale language.Tag) context.Context {
+	return context.WithValue(ctx, formatKey{}, locale)
+}
+
+// FormatterFromContext returns a formatter for the locale set with
+// WithFormatLocale, or else for the request locale
+func FormatterFromContext(ctx context.Context) *Formatter {
+	if locale, ok := ctx.Value(formatKey{}).(language.Tag); ok {
+		return NewFormatter(locale)
+	}
+	return NewFormatter(LocaleFromContext(ctx))
+}
@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Negotiator picks the best supported locale for a client's Accept-Language header
+type Negotiator struct {
+	supported []language.Tag
+	matcher   language.Matcher
+}
+
+// NewNegotiator creates a negotiator for the given locales. The first locale
+// is the default used when nothing in the header matches.
+func NewNegotiator(locales []string) (*Negotiator, error) {
+	if len(locales) == 0 {
+		return nil, fmt.Errorf("at least one supported locale is required")
+	}
+
+	supported := make([]language.Tag, 0, len(locales))
+	for _, locale := range locales {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale %q: %w", locale, err)
+		}
+		supported = append(supported, tag)
+	}
+
+	return &Negotiator{
+		supported: supported,
+		matcher:   language.NewMatcher(supported),
+	}, nil
+}
+
+// Default returns the locale used when negotiation finds no match
+func (n *Negotiator) Default() language.Tag {
+	return n.supported[0]
+}
+
+// Untranslated returns the supported locales response messages are not
+// translated to; they are shown in English
+func (n *Negotiator) Untranslated() []language.Tag {
+	var untranslated []language.Tag
+	for _, locale := range n.supported {
+		if !hasMessages(locale) {
+			untranslated = append(untranslated, locale)
+		}
+	}
+	return untranslated
+}
+
+// Negotiate returns the supported locale that best matches an Accept-Language
+// header, honouring q-values
+func (n *Negotiator) Negotiate(acceptLanguage string) language.Tag {
+	if acceptLanguage == "" {
+		return n.Default()
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return n.Default()
+	}
+
+	_, index, confidence := n.matcher.Match(tags...)
+	if confidence == language.No {
+		return n.Default()
+	}
+	return n.supported[index]
+}
+
+// contextKey is the type for values stored in the request context
+type contextKey struct{}
+
+// WithLocale returns a copy of ctx carrying locale
+func WithLocale(ctx context.Context, locale language.Tag) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// LocaleFromContext returns the locale stored in ctx, or language.Und if none was set
+func LocaleFromContext(ctx context.Context) language.Tag {
+	locale, ok := ctx.Value(contextKey{}).(language.Tag)
+	if !ok {
+		return language.Und
+	}
+	return locale
+}
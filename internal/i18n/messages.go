@@ -0,0 +1,244 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Keys of the messages responses carry, named after the event they report
+const (
+	MsgUserCreated             = "user.created"
+	MsgUsersCreated            = "users.created"
+	MsgUsersPartiallyCreated   = "users.partially_created"
+	MsgUsersImported           = "users.imported"
+	MsgUserRetrieved           = "user.retrieved"
+	MsgUsersRetrieved          = "users.retrieved"
+	MsgUserUpdated             = "user.updated"
+	MsgUserDeleted             = "user.deleted"
+	MsgUserRestored            = "user.restored"
+	MsgUserQueued              = "user.queued"
+	MsgLegalHoldPlaced         = "legal_hold.placed"
+	MsgLegalHoldLifted         = "legal_hold.lifted"
+	MsgUserRegistered          = "user.registered"
+	MsgRegistrationPending     = "registration.pending"
+	MsgRegistrationsRetrieved  = "registrations.retrieved"
+	MsgRegistrationApproved    = "registration.approved"
+	MsgRegistrationRejected    = "registration.rejected"
+	MsgLoggedIn                = "auth.logged_in"
+	MsgAuditEntriesRetrieved   = "audit.entries_retrieved"
+	MsgAuditVerified           = "audit.verified"
+	MsgAuditVerificationFailed = "audit.verification_failed"
+	MsgCacheWarmed             = "cache.warmed"
+	MsgChangelogRetrieved      = "changelog.retrieved"
+	MsgCapabilitiesRetrieved   = "capabilities.retrieved"
+	MsgSchemasRetrieved        = "schemas.retrieved"
+	MsgDashboardRetrieved      = "dashboard.retrieved"
+	MsgDeviceRetrieved         = "device.retrieved"
+	MsgDeviceApproved          = "device.approved"
+	MsgDeviceDenied            = "device.denied"
+	MsgAuthorizationDecided    = "authorization.decided"
+	MsgClientRegistered        = "client.registered"
+	MsgEmailsRetrieved         = "emails.retrieved"
+	MsgEmailEventsRecorded     = "email_events.recorded"
+	MsgOperationRetrieved      = "operation.retrieved"
+	MsgOperationAccepted       = "operation.accepted"
+	MsgQueuedRequestRetrieved  = "queued_request.retrieved"
+	MsgCachesFlushed           = "runbook.caches_flushed"
+	MsgJWTKeyRotated           = "runbook.jwt_key_rotated"
+	MsgMigrationsRun           = "runbook.migrations_run"
+	MsgDeadLettersReplayed     = "runbook.dead_letters_replayed"
+	MsgWebhooksRedelivered     = "runbook.webhooks_redelivered"
+	MsgSettingsRetrieved       = "settings.retrieved"
+	MsgSettingsUpdated         = "settings.updated"
+	MsgTokensRetrieved         = "tokens.retrieved"
+	MsgTokenCreated            = "token.created"
+	MsgTokenRevoked            = "token.revoked"
+	MsgSigningKeyRegistered    = "signing_key.registered"
+	MsgSigningKeyRemoved       = "signing_key.removed"
+	MsgTransitionConsistent    = "transition.consistent"
+	MsgTransitionInconsistent  = "transition.inconsistent"
+	MsgVersionRetrieved        = "version.retrieved"
+	MsgWatchCreated            = "watch.created"
+	MsgWatchesRetrieved        = "watches.retrieved"
+	MsgWatchRetrieved          = "watch.retrieved"
+	MsgWatchDeleted            = "watch.deleted"
+	MsgWebhookCreated          = "webhook.created"
+	MsgWebhooksRetrieved       = "webhooks.retrieved"
+	MsgWebhookRetrieved        = "webhook.retrieved"
+	MsgWebhookUpdated          = "webhook.updated"
+	MsgWebhookDeleted          = "webhook.deleted"
+)
+
+// english holds the text of every message, in fmt syntax. Other locales
+// translate them in messages/<locale>.json; messages they leave out are
+// shown in English.
+var english = map[string]string{
+	MsgUserCreated:             "User created successfully",
+	MsgUsersCreated:            "Users created successfully",
+	MsgUsersPartiallyCreated:   "Created %d of %d users",
+	MsgUsersImported:           "Users imported",
+	MsgUserRetrieved:           "User retrieved successfully",
+	MsgUsersRetrieved:          "Users retrieved successfully",
+	MsgUserUpdated:             "User updated successfully",
+	MsgUserDeleted:             "User deleted successfully",
+	MsgUserRestored:            "User restored successfully",
+	MsgUserQueued:              "User creation queued until the database recovers",
+	MsgLegalHoldPlaced:         "Legal hold placed",
+	MsgLegalHoldLifted:         "Legal hold lifted",
+	MsgUserRegistered:          "User registered successfully",
+	MsgRegistrationPending:     "Registration pending approval",
+	MsgRegistrationsRetrieved:  "Pending registrations retrieved successfully",
+	MsgRegistrationApproved:    "Registration approved",
+	MsgRegistrationRejected:    "Registration rejected",
+	MsgLoggedIn:                "Login successful",
+	MsgAuditEntriesRetrieved:   "Audit entries retrieved successfully",
+	MsgAuditVerified:           "Audit log verified",
+	MsgAuditVerificationFailed: "Audit log failed verification",
+	MsgCacheWarmed:             "Cache warmed",
+	MsgChangelogRetrieved:      "Changelog retrieved successfully",
+	MsgCapabilitiesRetrieved:   "Capabilities retrieved successfully",
+	MsgSchemasRetrieved:        "Schemas retrieved successfully",
+	MsgDashboardRetrieved:      "Dashboard retrieved successfully",
+	MsgDeviceRetrieved:         "Device request retrieved successfully",
+	MsgDeviceApproved:          "Device approved",
+	MsgDeviceDenied:            "Device denied",
+	MsgAuthorizationDecided:    "Authorization request decided",
+	MsgClientRegistered:        "Client registered successfully",
+	MsgEmailsRetrieved:         "Emails retrieved successfully",
+	MsgEmailEventsRecorded:     "Email events recorded",
+	MsgOperationRetrieved:      "Operation retrieved successfully",
+	MsgOperationAccepted:       "Operation accepted",
+	MsgQueuedRequestRetrieved:  "Queued request retrieved successfully",
+	MsgCachesFlushed:           "Caches flushed",
+	MsgJWTKeyRotated:           "JWT signing key rotated",
+	MsgMigrationsRun:           "Migrations run",
+	MsgDeadLettersReplayed:     "Failed jobs queued again",
+	MsgWebhooksRedelivered:     "Failed webhook deliveries queued again",
+	MsgSettingsRetrieved:       "Settings retrieved successfully",
+	MsgSettingsUpdated:         "Settings updated successfully",
+	MsgTokensRetrieved:         "Tokens retrieved successfully",
+	MsgTokenCreated:            "Token created successfully",
+	MsgTokenRevoked:            "Token revoked successfully",
+	MsgSigningKeyRegistered:    "Signing key registered successfully",
+	MsgSigningKeyRemoved:       "Signing key removed successfully",
+	MsgTransitionConsistent:    "Transition is consistent",
+	MsgTransitionInconsistent:  "Transition is not consistent",
+	MsgVersionRetrieved:        "Version retrieved successfully",
+	MsgWatchCreated:            "Watch created successfully",
+	MsgWatchesRetrieved:        "Watches retrieved successfully",
+	MsgWatchRetrieved:          "Watch retrieved successfully",
+	MsgWatchDeleted:            "Watch deleted successfully",
+	MsgWebhookCreated:          "Webhook created successfully",
+	MsgWebhooksRetrieved:       "Webhooks retrieved successfully",
+	MsgWebhookRetrieved:        "Webhook retrieved successfully",
+	MsgWebhookUpdated:          "Webhook updated successfully",
+	MsgWebhookDeleted:          "Webhook deleted successfully",
+}
+
+// translationFiles holds a JSON object of translated messages by key for
+// each locale, named after its BCP 47 tag
+//
+//go:embed messages/*.json
+var translationFiles embed.FS
+
+// formatVerb matches the fmt verbs of a message; argIndex matches their
+// explicit argument indexes, which translations use to reorder arguments
+var (
+	formatVerb = regexp.MustCompile(`%(?:\[\d+\])?[-+# 0-9.]*[a-zA-Z%]`)
+	argIndex   = regexp.MustCompile(`\[\d+\]`)
+)
+
+// messages is the catalog of every locale's messages; messageMatcher
+// matches request locales to them
+var (
+	messages       = mustLoadMessages()
+	messageMatcher = language.NewMatcher(messages.Languages())
+)
+
+// Message returns the message for key in the request locale of ctx,
+// formatted with args. Messages are in English when the locale has no
+// translation of them, and unknown keys are returned as they are.
+func Message(ctx context.Context, key string, args ...interface{}) string {
+	locale := LocaleFromContext(ctx)
+	if !hasMessages(locale) {
+		locale = language.English
+	}
+	return message.NewPrinter(locale, message.Catalog(messages)).Sprintf(key, args...)
+}
+
+// hasMessages reports whether messages are translated to locale or a
+// locale close to it
+func hasMessages(locale language.Tag) bool {
+	_, _, confidence := messageMatcher.Match(locale)
+	return confidence != language.No
+}
+
+// mustLoadMessages builds the catalog from english and the embedded
+// translations, panicking if a translation does not match its English
+// message, as that is a build mistake
+func mustLoadMessages() *catalog.Builder {
+	builder := catalog.NewBuilder(catalog.Fallback(language.English))
+	for key, text := range english {
+		builder.SetString(language.English, key, text)
+	}
+
+	files, err := translationFiles.ReadDir("messages")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: %v", err))
+	}
+	for _, file := range files {
+		locale := strings.TrimSuffix(file.Name(), ".json")
+		tag, err := language.Parse(locale)
+		if err != nil {
+			panic(fmt.Sprintf("i18n: invalid locale file %s: %v", file.Name(), err))
+		}
+		data, err := translationFiles.ReadFile(path.Join("messages", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: %v", err))
+		}
+		var translations map[string]string
+		if err := json.Unmarshal(data, &translations); err != nil {
+			panic(fmt.Sprintf("i18n: invalid locale file %s: %v", file.Name(), err))
+		}
+		if err := checkTranslations(translations); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", file.Name(), err))
+		}
+		for key, text := range translations {
+			builder.SetString(tag, key, text)
+		}
+	}
+	return builder
+}
+
+// checkTranslations checks that each translation has an English message
+// with the same fmt verbs
+func checkTranslations(translations map[string]string) error {
+	for key, text := range translations {
+		source, ok := english[key]
+		if !ok {
+			return fmt.Errorf("unknown message %q", key)
+		}
+		want, got := verbs(source), verbs(text)
+		if want != got {
+			return fmt.Errorf("message %q has verbs %q, want %q", key, got, want)
+		}
+	}
+	return nil
+}
+
+// verbs lists the fmt verbs of text in sorted order, without argument indexes
+func verbs(text string) string {
+	found := formatVerb.FindAllString(argIndex.ReplaceAllString(text, ""), -1)
+	sort.Strings(found)
+	return strings.Join(found, " ")
+}
@@ -0,0 +1,149 @@
+package idcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/sqids/sqids-go"
+)
+
+// ErrInvalidID is returned when a public ID was not produced by the codec,
+// including IDs that were altered by hand
+var ErrInvalidID = fmt.Errorf("invalid ID")
+
+// Codec encodes database IDs for the public API
+type Codec interface {
+	Encode(id int) string
+	// Decode reverses Encode and rejects anything Encode would not produce
+	Decode(publicID string) (int, error)
+	// Pattern is a mux route pattern matching every encoded ID
+	Pattern() string
+}
+
+// New returns the codec named by kind: "plain" or "sqids"
+func New(kind, alphabet string, minLength int) (Codec, error) {
+	switch kind {
+	case "", "plain":
+		return Plain{}, nil
+	case "sqids":
+		return NewSqids(alphabet, minLength)
+	default:
+		return nil, fmt.Errorf("unknown ID codec %q", kind)
+	}
+}
+
+// Plain exposes database IDs unchanged
+type Plain struct{}
+
+// Encode returns id in decimal
+func (Plain) Encode(id int) string {
+	return strconv.Itoa(id)
+}
+
+// Decode parses a positive decimal ID
+func (Plain) Decode(publicID string) (int, error) {
+	id, err := strconv.Atoi(publicID)
+	if err != nil || id <= 0 || strconv.Itoa(id) != publicID {
+		return 0, ErrInvalidID
+	}
+	return id, nil
+}
+
+// Pattern matches decimal IDs
+func (Plain) Pattern() string {
+	return "[0-9]+"
+}
+
+// Sqids encodes IDs as short opaque strings. The alphabet order determines
+// the output, so deployments should use their own shuffled alphabet. This
+// hides row counts and creation order; it is not encryption.
+type Sqids struct {
+	sqids *sqids.Sqids
+}
+
+// NewSqids creates a Sqids codec; an empty alphabet uses the library default
+func NewSqids(alphabet string, minLength int) (*Sqids, error) {
+	if minLength < 0 || minLength > 255 {
+		return nil, fmt.Errorf("ID minimum length must be between 0 and 255")
+	}
+	s, err := sqids.New(sqids.Options{Alphabet: alphabet, MinLength: uint8(minLength)})
+	if err != nil {
+		return nil, fmt.Errorf("invalid sqids options: %w", err)
+	}
+	return &Sqids{sqids: s}, nil
+}
+
+// Encode returns the opaque form of id
+func (c *Sqids) Encode(id int) string {
+	publicID, err := c.sqids.Encode([]uint64{uint64(id)})
+	if err != nil {
+		// Only possible for blocklisted IDs, and no blocklist is configured
+		panic(fmt.Sprintf("idcodec: cannot encode %d: %v", id, err))
+	}
+	return publicID
+}
+
+// Decode returns the ID encoded in publicID. Several strings can decode to
+// the same number, so the result is re-encoded and must match exactly.
+func (c *Sqids) Decode(publicID string) (int, error) {
+	numbers := c.sqids.Decode(publicID)
+	if len(numbers) != 1 || numbers[0] == 0 || numbers[0] > uint64(maxInt) {
+		return 0, ErrInvalidID
+	}
+	id := int(numbers[0])
+	if c.Encode(id) != publicID {
+		return 0, ErrInvalidID
+	}
+	return id, nil
+}
+
+// Pattern matches any single path segment; Decode does the validation
+func (c *Sqids) Pattern() string {
+	return "[^/]+"
+}
+
+// maxInt is the largest ID that fits an int
+const maxInt = int(^uint(0) >> 1)
+
+// PublicID is a database ID as the API shows it, made with Public. Plain
+// IDs stay JSON numbers; other codecs produce strings.
+type PublicID struct {
+	encoded string
+	number  bool
+}
+
+// Public encodes id with codec
+func Public(codec Codec, id int) PublicID {
+	_, plain := codec.(Plain)
+	return PublicID{encoded: codec.Encode(id), number: plain}
+}
+
+// String returns the encoded ID, which the codec's Decode reverses
+func (id PublicID) String() string {
+	return id.encoded
+}
+
+// MarshalJSON writes the encoded ID
+func (id PublicID) MarshalJSON() ([]byte, error) {
+	if id.number {
+		return []byte(id.encoded), nil
+	}
+	return json.Marshal(id.encoded)
+}
+
+// UnmarshalJSON accepts a JSON number or an encoded string, leaving the
+// codec's Decode to check it
+func (id *PublicID) UnmarshalJSON(data []byte) error {
+	encoded := string(bytes.TrimSpace(data))
+	if len(encoded) > 0 && encoded[0] == '"' {
+		if err := json.Unmarshal(data, &encoded); err != nil {
+			return err
+		}
+		*id = PublicID{encoded: encoded}
+		return nil
+	}
+	*id = PublicID{encoded: encoded, number: true}
+	return nil
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// contextKey is the context key holding a request-scoped logger
+type contextKey struct{}
+
+// New builds a logger from LOG_LEVEL and LOG_FORMAT. Format "json" writes
+// one JSON object per line for log collectors; "console" writes readable
+// lines for development. Repeats of an error are limited as cfg sets.
+func New(cfg config.LoggingConfig) (*zap.Logger, error) {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+	}
+
+	var zapConfig zap.Config
+	switch cfg.Format {
+	case "json":
+		zapConfig = zap.NewProductionConfig()
+		zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	case "console":
+		zapConfig = zap.NewDevelopmentConfig()
+		// Keep production behaviour: no panics on DPanic, fewer stack traces
+		zapConfig.Development = false
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected json or console", cfg.Format)
+	}
+	zapConfig.Level = zap.NewAtomicLevelAt(level)
+	zapConfig.Sampling = nil
+
+	return zapConfig.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return LimitRepeatedErrors(core, cfg.ErrorWindow, cfg.ErrorBurst)
+	}))
+}
+
+// WithContext returns a copy of ctx carrying l
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, which for requests includes
+// the request ID, method and path. Without one it returns the global logger.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return zap.L()
+}
@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// repeatedError counts the occurrences of one error message in its window
+type repeatedError struct {
+	message string
+	level   zapcore.Level
+	start   time.Time
+	count   int
+}
+
+// errorLimiter is shared by a rate-limited core and the cores derived from
+// it with With, so an error repeats the same way whatever fields it carries
+type errorLimiter struct {
+	// root receives the summaries, without the fields of any request
+	root   zapcore.Core
+	window time.Duration
+	burst  int
+
+	mu        sync.Mutex
+	errors    map[string]*repeatedError
+	lastSweep time.Time
+}
+
+// rateLimitedCore writes at most burst entries at error level or above with
+// the same message and error in each window. Once a window with suppressed
+// entries is over, it writes a "suppressed N similar" summary.
+type rateLimitedCore struct {
+	zapcore.Core
+	limiter *errorLimiter
+}
+
+// LimitRepeatedErrors wraps core so that at most burst entries at error
+// level or above with the same message and error are written per window.
+// A zero window returns core unchanged.
+func LimitRepeatedErrors(core zapcore.Core, window time.Duration, burst int) zapcore.Core {
+	if window <= 0 {
+		return core
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimitedCore{Core: core, limiter: &errorLimiter{
+		root:   core,
+		window: window,
+		burst:  burst,
+		errors: make(map[string]*repeatedError),
+	}}
+}
+
+// With implements zapcore.Core
+func (c *rateLimitedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitedCore{Core: c.Core.With(fields), limiter: c.limiter}
+}
+
+// Check implements zapcore.Core, routing writes through this core rather
+// than the wrapped one
+func (c *rateLimitedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core
+func (c *rateLimitedCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.limiter.sweep(ent.Time)
+	if ent.Level >= zapcore.ErrorLevel && !c.limiter.allow(ent, fields) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// Sync implements zapcore.Core, first writing the summaries of every window
+// with suppressed entries
+func (c *rateLimitedCore) Sync() error {
+	c.limiter.flush()
+	return c.Core.Sync()
+}
+
+// allow reports whether ent may be written, counting it against its message
+func (l *errorLimiter) allow(ent zapcore.Entry, fields []zapcore.Field) bool {
+	key := ent.Message + "\x00" + errorText(fields)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	repeated, ok := l.errors[key]
+	if !ok || ent.Time.Sub(repeated.start) >= l.window {
+		if ok {
+			l.summarize(repeated)
+		}
+		repeated = &repeatedError{message: ent.Message, level: ent.Level, start: ent.Time}
+		l.errors[key] = repeated
+	}
+	repeated.count++
+	return repeated.count <= l.burst
+}
+
+// sweep summarizes and forgets the windows that ended before now, at most
+// once a window
+func (l *errorLimiter) sweep(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, repeated := range l.errors {
+		if now.Sub(repeated.start) >= l.window {
+			l.summarize(repeated)
+			delete(l.errors, key)
+		}
+	}
+}
+
+// flush summarizes and forgets every window
+func (l *errorLimiter) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, repeated := range l.errors {
+		l.summarize(repeated)
+		delete(l.errors, key)
+	}
+}
+
+// summarize writes how many entries like repeated were suppressed, if any.
+// The caller holds l.mu.
+func (l *errorLimiter) summarize(repeated *repeatedError) {
+	suppressed := repeated.count - l.burst
+	if suppressed <= 0 {
+		return
+	}
+	l.root.Write(zapcore.Entry{
+		Level:   repeated.level,
+		Time:    time.Now(),
+		Message: fmt.Sprintf("suppressed %d similar", suppressed),
+	}, []zapcore.Field{
+		zap.String("suppressed_message", repeated.message),
+		zap.Int("suppressed", suppressed),
+		zap.Time("since", repeated.start),
+	})
+}
+
+// errorText returns the text of the error field among fields, or ""
+func errorText(fields []zapcore.Field) string {
+	for _, field := range fields {
+		if field.Type == zapcore.ErrorType {
+			if err, ok := field.Interface.(error); ok {
+				return err.Error()
+			}
+		}
+	}
+	return ""
+}
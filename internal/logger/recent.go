@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"io"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Recent keeps the last entries logged in memory as JSON lines, so they can
+// be collected without access to the log pipeline, as support bundles do
+type Recent struct {
+	mu    sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+// NewRecent creates a Recent keeping up to size entries
+func NewRecent(size int) *Recent {
+	if size < 1 {
+		size = 1
+	}
+	return &Recent{lines: make([][]byte, size)}
+}
+
+// Tee returns an option copying the entries a logger writes at level or
+// above to r, whatever the logger's own format
+func (r *Recent) Tee(level zapcore.LevelEnabler) zap.Option {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	recentCore := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(r), level)
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, recentCore)
+	})
+}
+
+// Write stores one encoded entry, dropping the oldest when full
+func (r *Recent) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// WriteTo writes the kept entries to w, oldest first, one per line
+func (r *Recent) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	lines := append([][]byte(nil), r.lines[:r.next]...)
+	if r.full {
+		lines = append(append([][]byte(nil), r.lines[r.next:]...), lines...)
+	}
+	r.mu.Unlock()
+
+	var written int64
+	for _, line := range lines {
+		n, err := w.Write(line)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
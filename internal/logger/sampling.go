@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"sync"
+
+	"github.com/pratham15541/go-crud/internal/config"
+)
+
+// Sampler thins out noisy categories of log entries, such as health checks
+// or floods of 404s, by keeping one in every N entries of each category
+type Sampler struct {
+	rates map[string]uint64
+
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// NewSampler creates a sampler from entries of the form category=N, which
+// keep one in every N entries of the category. Categories not listed are
+// not sampled.
+func NewSampler(entries []string) (*Sampler, error) {
+	rates, err := config.ParseLogSampling(entries)
+	if err != nil {
+		return nil, err
+	}
+	return &Sampler{rates: rates, counts: make(map[string]uint64)}, nil
+}
+
+// Sample reports whether an entry of category should be logged, and the N
+// of its category, or 1 if it is not sampled. The first entry of each
+// category is always logged.
+func (s *Sampler) Sample(category string) (bool, uint64) {
+	if s == nil {
+		return true, 1
+	}
+	rate, ok := s.rates[category]
+	if !ok || rate == 1 {
+		return true, 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := s.counts[category]
+	s.counts[category] = count + 1
+	return count%rate == 0, rate
+}
@@ -0,0 +1,27 @@
+package mailer
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Log logs emails instead of sending them, for development
+type Log struct {
+	logger *zap.Logger
+}
+
+// NewLog creates a sender that logs every email to logger
+func NewLog(logger *zap.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+// Send logs msg without its body, which may hold secrets such as links
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	l.logger.Info("email not sent, no SMTP relay configured",
+		zap.String("message_id", msg.MessageID),
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+	)
+	return nil
+}
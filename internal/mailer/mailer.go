@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is one email to send
+type Message struct {
+	// MessageID is the Message-ID header, including angle brackets
+	MessageID string
+	From      string
+	To        string
+	Subject   string
+	Body      string
+}
+
+// Sender delivers emails. Implementations must be safe for concurrent use.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// PermanentError wraps failures that retrying cannot fix, such as a
+// rejected recipient
+type PermanentError struct {
+	Err error
+}
+
+// Error returns the wrapped error's message
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a PermanentError
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTP sends emails through an SMTP relay
+type SMTP struct {
+	addr string
+	host string
+	auth smtp.Auth
+}
+
+// NewSMTP creates a sender for the relay at host:port. PLAIN authentication
+// is used when username is set, which net/smtp only allows over TLS or to
+// localhost.
+func NewSMTP(host, port, username, password string) *SMTP {
+	s := &SMTP{addr: net.JoinHostPort(host, port), host: host}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+// Send delivers msg to the relay. Rejections with a 5xx reply are permanent.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	// net/smtp does not take a context, so cancellation is only checked
+	// before connecting
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := smtp.SendMail(s.addr, s.auth, msg.From, []string{msg.To}, buildMessage(msg))
+	if err == nil {
+		return nil
+	}
+
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return &PermanentError{Err: fmt.Errorf("smtp rejected email: %w", err)}
+	}
+	return fmt.Errorf("failed to send email: %w", err)
+}
+
+// buildMessage renders msg as a plain text RFC 5322 message
+func buildMessage(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", msg.MessageID)
+	fmt.Fprintf(&b, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", sanitizeHeader(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// sanitizeHeader drops line breaks so a value cannot add headers
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+}
@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/models"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// sample is a reading of the cumulative request metrics at one moment
+type sample struct {
+	at       time.Time
+	requests float64
+	errors   float64
+	// buckets maps latency bucket upper bounds to the requests at or under them
+	buckets map[float64]float64
+}
+
+// Dashboard summarizes recent traffic from the registry for admins without
+// a Prometheus server. It keeps readings of the cumulative counters over a
+// sliding window, so rates cover that window rather than the process
+// lifetime.
+type Dashboard struct {
+	metrics *Metrics
+	window  time.Duration
+
+	mu      sync.Mutex
+	samples []sample
+}
+
+// NewDashboard creates a dashboard whose rates cover window
+func NewDashboard(m *Metrics, window time.Duration) *Dashboard {
+	d := &Dashboard{metrics: m, window: window}
+	d.record(time.Now())
+	return d
+}
+
+// Run records a reading every window/12 until ctx is cancelled
+func (d *Dashboard) Run(ctx context.Context) {
+	interval := d.window / 12
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.record(now)
+		}
+	}
+}
+
+// record stores a reading taken at now, dropping readings older than the
+// window except the newest of them, which the window's rates start from
+func (d *Dashboard) record(now time.Time) {
+	s := readSample(now, d.metrics.gather())
+	d.metrics.forgetUsers(now.Add(-d.window))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.samples = append(d.samples, s)
+	for len(d.samples) > 2 && now.Sub(d.samples[1].at) >= d.window {
+		d.samples = d.samples[1:]
+	}
+}
+
+// Snapshot returns the traffic over the window up to now, the number of
+// users seen in it and the current state of every connection pool
+func (d *Dashboard) Snapshot() models.Dashboard {
+	now := time.Now()
+	families := d.metrics.gather()
+	latest := readSample(now, families)
+
+	d.mu.Lock()
+	oldest := d.samples[0]
+	d.mu.Unlock()
+
+	elapsed := now.Sub(oldest.at).Seconds()
+	requests := latest.requests - oldest.requests
+	dashboard := models.Dashboard{
+		WindowSeconds: math.Round(elapsed),
+		ActiveUsers:   d.metrics.activeUsers(now.Add(-d.window)),
+		InFlight:      inFlightTotal(families),
+		Pools:         poolStatus(families),
+	}
+	if elapsed > 0 {
+		dashboard.RequestsPerSecond = requests / elapsed
+	}
+	if requests > 0 {
+		dashboard.ErrorRate = (latest.errors - oldest.errors) / requests
+		dashboard.P95LatencyMs = quantile(0.95, requests, latest.buckets, oldest.buckets) * 1000
+	}
+	return dashboard
+}
+
+// readSample reads the cumulative request counters and latency buckets, summed
+// across routes and methods. Responses with a 5xx status count as errors.
+func readSample(now time.Time, families []*dto.MetricFamily) sample {
+	s := sample{at: now, buckets: make(map[float64]float64)}
+	for _, family := range families {
+		switch family.GetName() {
+		case "http_requests_total":
+			for _, metric := range family.GetMetric() {
+				count := metric.GetCounter().GetValue()
+				s.requests += count
+				if status, _ := strconv.Atoi(label(metric, "status")); status >= 500 {
+					s.errors += count
+				}
+			}
+		case "http_request_duration_seconds":
+			for _, metric := range family.GetMetric() {
+				for _, bucket := range metric.GetHistogram().GetBucket() {
+					s.buckets[bucket.GetUpperBound()] += float64(bucket.GetCumulativeCount())
+				}
+			}
+		}
+	}
+	return s
+}
+
+// inFlightTotal returns the number of requests being served
+func inFlightTotal(families []*dto.MetricFamily) int {
+	var total float64
+	for _, family := range families {
+		if family.GetName() == "http_requests_in_flight" {
+			for _, metric := range family.GetMetric() {
+				total += metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return int(total)
+}
+
+// poolStatus returns the statistics of every registered connection pool
+func poolStatus(families []*dto.MetricFamily) []models.PoolStatus {
+	pools := make(map[string]*models.PoolStatus)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name := label(metric, "db_name")
+			if name == "" {
+				continue
+			}
+			pool, ok := pools[name]
+			if !ok {
+				pool = &models.PoolStatus{Name: name}
+				pools[name] = pool
+			}
+			switch family.GetName() {
+			case "go_sql_max_open_connections":
+				pool.MaxOpen = int(metric.GetGauge().GetValue())
+			case "go_sql_open_connections":
+				pool.Open = int(metric.GetGauge().GetValue())
+			case "go_sql_in_use_connections":
+				pool.InUse = int(metric.GetGauge().GetValue())
+			case "go_sql_idle_connections":
+				pool.Idle = int(metric.GetGauge().GetValue())
+			case "go_sql_wait_count_total":
+				pool.WaitCount = int64(metric.GetCounter().GetValue())
+			}
+		}
+	}
+
+	status := make([]models.PoolStatus, 0, len(pools))
+	for _, pool := range pools {
+		status = append(status, *pool)
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Name < status[j].Name })
+	return status
+}
+
+// gather reads the registry, or returns nothing if it cannot be read
+func (m *Metrics) gather() []*dto.MetricFamily {
+	families, err := m.registry.Gather()
+	if err != nil {
+		return nil
+	}
+	return families
+}
+
+// label returns the value of metric's label name, or ""
+func label(metric *dto.Metric, name string) string {
+	for _, pair := range metric.GetLabel() {
+		if pair.GetName() == name {
+			return pair.GetValue()
+		}
+	}
+	return ""
+}
+
+// quantile estimates the q quantile of the total latencies observed between
+// two readings of the cumulative buckets, interpolating linearly within the
+// bucket it falls in as Prometheus's histogram_quantile does. Latencies
+// above the highest bound are reported as that bound.
+func quantile(q, total float64, latest, oldest map[float64]float64) float64 {
+	bounds := make([]float64, 0, len(latest))
+	for bound := range latest {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+
+	rank := q * total
+	lowerBound, lowerCount := 0.0, 0.0
+	for _, bound := range bounds {
+		count := latest[bound] - oldest[bound]
+		if count >= rank {
+			if count == lowerCount {
+				return bound
+			}
+			return lowerBound + (bound-lowerBound)*(rank-lowerCount)/(count-lowerCount)
+		}
+		lowerBound, lowerCount = bound, count
+	}
+	return lowerBound
+}
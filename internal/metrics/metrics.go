@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics owns the Prometheus registry served on /metrics and the HTTP
+// request collectors
+type Metrics struct {
+	registry *prometheus.Registry
+	// registerer adds the constant labels, such as region, to every metric
+	registerer prometheus.Registerer
+
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	inFlight     *prometheus.GaugeVec
+	responseSize *prometheus.HistogramVec
+
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+
+	cacheLookups *prometheus.CounterVec
+
+	// lastSeen holds when each authenticated user last made a request
+	usersMu  sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+// New creates a registry with Go runtime, process and HTTP metrics
+func New() *Metrics {
+	return NewForRegion("")
+}
+
+// NewForRegion creates the registry of New with a region label on every
+// metric, so dashboards can compare regions; an empty region adds none
+func NewForRegion(region string) *Metrics {
+	registry := prometheus.NewRegistry()
+	var registerer prometheus.Registerer = registry
+	if region != "" {
+		registerer = prometheus.WrapRegistererWith(prometheus.Labels{"region": region}, registry)
+	}
+
+	m := &Metrics{
+		registry:   registry,
+		registerer: registerer,
+		lastSeen:   make(map[string]time.Time),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests currently being served, by method and route.",
+		}, []string{"method", "route"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of HTTP response bodies, by method, route and status.",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Time taken by SQL statements, by pool and normalized query.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"pool", "query"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "SQL statements that failed, by pool and normalized query.",
+		}, []string{"pool", "query"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_lookups_total",
+			Help: "Cache lookups, by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+	}
+
+	m.registerer.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.inFlight,
+		m.responseSize,
+		m.queryDuration,
+		m.queryErrors,
+		m.cacheLookups,
+	)
+	return m
+}
+
+// RegisterDB exports the connection pool statistics of db, such as open
+// connections and wait count, labeled with name
+func (m *Metrics) RegisterDB(name string, db *sql.DB) error {
+	return m.registerer.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// InFlight adjusts the number of requests being served on route by delta
+func (m *Metrics) InFlight(method, route string, delta float64) {
+	m.inFlight.WithLabelValues(method, route).Add(delta)
+}
+
+// Observe records a served request
+func (m *Metrics) Observe(method, route string, status, size int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.duration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+	m.responseSize.WithLabelValues(method, route, code).Observe(float64(size))
+}
+
+// ObserveQuery records a SQL statement by its fingerprint
+func (m *Metrics) ObserveQuery(pool, fingerprint string, duration time.Duration, err error) {
+	m.queryDuration.WithLabelValues(pool, fingerprint).Observe(duration.Seconds())
+	if err != nil {
+		m.queryErrors.WithLabelValues(pool, fingerprint).Inc()
+	}
+}
+
+// ObserveCache records a lookup in the cache called name
+func (m *Metrics) ObserveCache(name, result string) {
+	m.cacheLookups.WithLabelValues(name, result).Inc()
+}
+
+// SeeUser records a request made by the user with ID userID
+func (m *Metrics) SeeUser(userID string) {
+	m.usersMu.Lock()
+	m.lastSeen[userID] = time.Now()
+	m.usersMu.Unlock()
+}
+
+// activeUsers returns the number of users seen since since
+func (m *Metrics) activeUsers(since time.Time) int {
+	m.usersMu.Lock()
+	defer m.usersMu.Unlock()
+	active := 0
+	for _, seen := range m.lastSeen {
+		if !seen.Before(since) {
+			active++
+		}
+	}
+	return active
+}
+
+// forgetUsers drops the users not seen since since
+func (m *Metrics) forgetUsers(since time.Time) {
+	m.usersMu.Lock()
+	defer m.usersMu.Unlock()
+	for userID, seen := range m.lastSeen {
+		if seen.Before(since) {
+			delete(m.lastSeen, userID)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/actor"
+)
+
+// ActivityRecorder records which users are making requests
+type ActivityRecorder interface {
+	SeeUser(userID string)
+}
+
+// ActivityMiddleware records the caller of every authenticated request, so
+// the admin dashboard can count active users. It must run after
+// authentication.
+func ActivityMiddleware(activity ActivityRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a := actor.FromContext(r.Context()); a.Authenticated() {
+				activity.SeeUser(a.UserID)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/actor"
+)
+
+// ActorMiddleware stores an anonymous actor carrying the client IP, so
+// requests that never authenticate are still attributed. AuthMiddleware
+// replaces it with the token's actor.
+func ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := actor.NewContext(r.Context(), actor.Actor{IP: clientIP(r)})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
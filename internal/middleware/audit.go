@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/apidocs"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"go.uber.org/zap"
+)
+
+// AuditRecorder stores audit entries
+type AuditRecorder interface {
+	Record(ctx context.Context, entry *models.AuditEntry) error
+}
+
+// AuditMiddleware records every POST, PUT, PATCH and DELETE request in the
+// audit log along with the caller and the response status
+func AuditMiddleware(recorder AuditRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+
+			action := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					action = apidocs.OpenAPIPath(template)
+				}
+			}
+
+			// The recorder attributes the entry to the actor in the context
+			entry := &models.AuditEntry{
+				Action:   r.Method + " " + action,
+				TargetID: mux.Vars(r)["id"],
+				Status:   wrapped.statusCode,
+			}
+
+			// The request may already be cancelled or timed out, but the
+			// entry must still be written
+			if err := recorder.Record(context.WithoutCancel(r.Context()), entry); err != nil {
+				logger.FromContext(r.Context()).Error("failed to record audit entry",
+					zap.String("action", entry.Action), zap.Error(err))
+			}
+		})
+	}
+}
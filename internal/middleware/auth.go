@@ -1,54 +1,207 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/actor"
 	"github.com/pratham15541/go-crud/internal/models"
 )
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware(secretKey string) func(http.Handler) http.Handler {
+// TokenAuthenticator validates opaque bearer tokens that are not JWTs
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (jwt.MapClaims, error)
+}
+
+// Authentication failures; their messages are sent to clients
+var (
+	ErrMissingAuthorization = errors.New("Authorization header required")
+	ErrAuthorizationFormat  = errors.New("Invalid authorization header format")
+	ErrInvalidToken         = errors.New("Invalid or expired token")
+)
+
+// HMACKey returns a jwt.Keyfunc accepting HMAC-signed tokens under secret
+func HMACKey(secret string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(secret), nil
+	}
+}
+
+// AuthMiddleware validates JWT tokens with the keys keyfunc returns. When
+// tokens is not nil, bearer tokens carrying the personal access token
+// prefix are validated by it instead.
+func AuthMiddleware(keyfunc jwt.Keyfunc, tokens TokenAuthenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get token from Authorization header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				sendAuthError(w, "Authorization header required", http.StatusUnauthorized)
+			claims, err := Authenticate(r.Context(), r.Header.Get("Authorization"), keyfunc, tokens)
+			if err != nil {
+				sendAuthError(w, err.Error(), http.StatusUnauthorized)
 				return
 			}
 
-			// Extract token from "Bearer <token>" format
-			tokenParts := strings.Split(authHeader, " ")
-			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-				sendAuthError(w, "Invalid authorization header format", http.StatusUnauthorized)
+			// Token is valid, expose its claims to downstream handlers
+			next.ServeHTTP(w, withClaims(r, claims))
+		})
+	}
+}
+
+// Authenticate validates an Authorization header value of the form
+// "Bearer <token>" and returns the token's claims. It is shared by every
+// transport the API is served over.
+func Authenticate(ctx context.Context, authHeader string, keyfunc jwt.Keyfunc, tokens TokenAuthenticator) (jwt.MapClaims, error) {
+	if authHeader == "" {
+		return nil, ErrMissingAuthorization
+	}
+
+	// Extract token from "Bearer <token>" format
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		return nil, ErrAuthorizationFormat
+	}
+
+	tokenString := tokenParts[1]
+
+	// Personal access tokens are opaque and looked up by the authenticator
+	if tokens != nil && strings.HasPrefix(tokenString, models.PersonalTokenPrefix) {
+		claims, err := tokens.AuthenticateToken(ctx, tokenString)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		return claims, nil
+	}
+
+	// Parse and validate token
+	token, err := jwt.Parse(tokenString, keyfunc)
+
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	return claims, nil
+}
+
+// ClaimsLoader loads a user's current claims by token subject
+type ClaimsLoader interface {
+	LoadClaims(ctx context.Context, subject string) (map[string]interface{}, error)
+}
+
+// RefreshClaimsMiddleware overlays the user's current claims (such as role)
+// on the token claims, so permission changes apply before the token expires.
+// Tokens of users that no longer exist are rejected. It must run after
+// AuthMiddleware.
+func RefreshClaimsMiddleware(loader ClaimsLoader) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := ClaimsFromContext(r.Context())
+			if claims == nil {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			tokenString := tokenParts[1]
+			refreshed, err := RefreshClaims(r.Context(), loader, claims)
+			if err != nil {
+				sendAuthError(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
 
-			// Parse and validate token
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				// Validate signing method
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte(secretKey), nil
-			})
+			next.ServeHTTP(w, withClaims(r, refreshed))
+		})
+	}
+}
 
-			if err != nil || !token.Valid {
-				sendAuthError(w, "Invalid or expired token", http.StatusUnauthorized)
+// RefreshClaims returns claims overlaid with the current claims of their
+// subject, or claims unchanged if they name no subject
+func RefreshClaims(ctx context.Context, loader ClaimsLoader, claims jwt.MapClaims) (jwt.MapClaims, error) {
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return claims, nil
+	}
+
+	current, err := loader.LoadClaims(ctx, sub)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	refreshed := make(jwt.MapClaims, len(claims)+len(current))
+	for key, value := range claims {
+		refreshed[key] = value
+	}
+	for key, value := range current {
+		refreshed[key] = value
+	}
+	return refreshed, nil
+}
+
+// RequireRole rejects requests whose token does not carry the given role.
+// It must run after AuthMiddleware.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller := actor.FromContext(r.Context())
+			if !caller.Authenticated() {
+				sendAuthError(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			if caller.Role != role {
+				sendAuthError(w, "Insufficient permissions", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope rejects scoped tokens that were not granted scope. Tokens
+// without a scope claim are first-party session tokens with full access.
+// It must run after AuthMiddleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller := actor.FromContext(r.Context())
+			if !caller.Authenticated() {
+				sendAuthError(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			if !caller.HasScope(scope) {
+				sendAuthError(w, "Token is missing the "+scope+" scope", http.StatusForbidden)
 				return
 			}
 
-			// Token is valid, continue to next handler
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// withClaims stores claims, and the actor they describe, in r's context
+func withClaims(r *http.Request, claims jwt.MapClaims) *http.Request {
+	caller := actor.FromClaims(claims)
+	caller.IP = clientIP(r)
+	ctx := context.WithValue(r.Context(), claimsKey, claims)
+	return r.WithContext(actor.NewContext(ctx, caller))
+}
+
+// ClaimsFromContext returns the JWT claims stored by AuthMiddleware
+func ClaimsFromContext(ctx context.Context) jwt.MapClaims {
+	claims, _ := ctx.Value(claimsKey).(jwt.MapClaims)
+	return claims
+}
+
+// contextKey is the type for values stored in the request context
+type contextKey string
+
+const claimsKey contextKey = "claims"
+
 // sendAuthError sends an authentication error response
 func sendAuthError(w http.ResponseWriter, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -61,4 +214,4 @@ func sendAuthError(w http.ResponseWriter, message string, statusCode int) {
 	}
 
 	json.NewEncoder(w).Encode(errorResp)
-}
\ No newline at end of file
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Middleware wraps an http.Handler with additional behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Chain is an ordered list of middleware. The first entry is the outermost
+// wrapper, so it sees the request first and the response last.
+type Chain []Middleware
+
+// NewChain creates a chain from the given middleware
+func NewChain(mw ...Middleware) Chain {
+	return append(Chain(nil), mw...)
+}
+
+// Append returns a new chain with mw added after the existing middleware.
+// The receiver is never modified, so chains can safely share a common base.
+func (c Chain) Append(mw ...Middleware) Chain {
+	out := make(Chain, 0, len(c)+len(mw))
+	out = append(out, c...)
+	return append(out, mw...)
+}
+
+// Then wraps h with every middleware in the chain
+func (c Chain) Then(h http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
+
+// ThenFunc wraps fn with every middleware in the chain
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	return c.Then(fn)
+}
+
+// Middleware returns the chain as a single middleware
+func (c Chain) Middleware() Middleware {
+	return c.Then
+}
+
+// Unless returns middleware that runs mw except for requests where skip
+// returns true, which go straight to the next handler
+func Unless(skip func(*http.Request) bool, mw Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Names of the built-in chains used to group routes
+const (
+	ChainGlobal   = "global"
+	ChainPublic   = "public"
+	ChainAuthed   = "authed"
+	ChainAdmin    = "admin"
+	ChainInternal = "internal"
+)
+
+// Chains is a registry of named middleware chains
+type Chains struct {
+	chains map[string]Chain
+}
+
+// NewChains creates an empty chain registry
+func NewChains() *Chains {
+	return &Chains{chains: make(map[string]Chain)}
+}
+
+// Register stores a chain under name, replacing any existing chain
+func (c *Chains) Register(name string, chain Chain) {
+	c.chains[name] = chain
+}
+
+// Extend registers name as the chain base followed by mw
+func (c *Chains) Extend(name, base string, mw ...Middleware) {
+	c.chains[name] = c.Get(base).Append(mw...)
+}
+
+// Get returns the chain registered under name. It panics on unknown names
+// so that a typo in route registration fails at startup rather than
+// silently serving a route without its middleware.
+func (c *Chains) Get(name string) Chain {
+	chain, ok := c.chains[name]
+	if !ok {
+		panic(fmt.Sprintf("middleware: unknown chain %q", name))
+	}
+	return chain
+}
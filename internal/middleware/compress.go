@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content codings CompressionMiddleware applies
+const (
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+)
+
+// compressibleTypes are the media types worth compressing. Images, archives
+// and other binary types are usually compressed already.
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/x-ndjson":   true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/javascript": true,
+	"image/svg+xml":          true,
+}
+
+var (
+	gzipWriters   = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+	brotliWriters = sync.Pool{New: func() interface{} { return brotli.NewWriter(io.Discard) }}
+)
+
+// CompressionMiddleware compresses responses of at least minSize bytes with
+// the best coding the client accepts: brotli when allowBrotli is set, then
+// gzip. Only text, JSON and other compressible types are compressed, and
+// never responses that already carry a Content-Encoding. A response flushed
+// before reaching minSize, such as a stream, is compressed from then on.
+func CompressionMiddleware(minSize int, allowBrotli bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Upgraded connections and bodiless responses are left alone
+			if r.Header.Get("Upgrade") != "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), allowBrotli)
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			next.ServeHTTP(cw, r)
+			cw.close()
+		})
+	}
+}
+
+// negotiateEncoding picks the coding to use from an Accept-Encoding header,
+// or "" to send the response as is. Brotli wins ties with gzip.
+func negotiateEncoding(header string, allowBrotli bool) string {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		weight := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				weight = q
+			}
+		}
+		weights[coding] = weight
+	}
+
+	weight := func(coding string) float64 {
+		if q, ok := weights[coding]; ok {
+			return q
+		}
+		return weights["*"]
+	}
+	gzipWeight, brotliWeight := weight(EncodingGzip), 0.0
+	if allowBrotli {
+		brotliWeight = weight(EncodingBrotli)
+	}
+	switch {
+	case brotliWeight > 0 && brotliWeight >= gzipWeight:
+		return EncodingBrotli
+	case gzipWeight > 0:
+		return EncodingGzip
+	default:
+		return ""
+	}
+}
+
+// compressResponseWriter holds back the start of the body until it reaches
+// minSize or is flushed, then compresses the rest if the response allows it
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+// WriteHeader holds back the status until the encoding is decided
+func (cw *compressResponseWriter) WriteHeader(code int) {
+	// Informational responses go out right away
+	if cw.decided || code < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if cw.status == 0 {
+		cw.status = code
+	}
+}
+
+// Write buffers the body until minSize bytes arrive
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.minSize {
+			return len(b), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends what has been written so far, compressing it when the
+// response allows it
+func (cw *compressResponseWriter) Flush() {
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			return
+		}
+	}
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide sends the headers, compressing the body from now on if large is
+// set and the response can be compressed, then writes the buffered body
+func (cw *compressResponseWriter) decide(large bool) error {
+	cw.decided = true
+	status := cw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	header := cw.Header()
+	if large && compressible(status, header) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", cw.encoding)
+		cw.encoder = newEncoder(cw.encoding, cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// close finishes the response once the handler returns
+func (cw *compressResponseWriter) close() {
+	if !cw.decided {
+		// Nothing written: net/http sends the implicit 200
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return
+		}
+		cw.decide(false)
+	}
+	if cw.encoder != nil {
+		cw.encoder.Close()
+		releaseEncoder(cw.encoder)
+		cw.encoder = nil
+	}
+}
+
+// compressible reports whether a response with status and header may be
+// compressed
+func compressible(status int, header http.Header) bool {
+	if status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || compressibleTypes[mediaType]
+}
+
+// newEncoder returns a pooled encoder of encoding writing to w
+func newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == EncodingBrotli {
+		encoder := brotliWriters.Get().(*brotli.Writer)
+		encoder.Reset(w)
+		return encoder
+	}
+	encoder := gzipWriters.Get().(*gzip.Writer)
+	encoder.Reset(w)
+	return encoder
+}
+
+// releaseEncoder returns a closed encoder to its pool
+func releaseEncoder(encoder io.WriteCloser) {
+	switch encoder := encoder.(type) {
+	case *gzip.Writer:
+		gzipWriters.Put(encoder)
+	case *brotli.Writer:
+		brotliWriters.Put(encoder)
+	}
+}
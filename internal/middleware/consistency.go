@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/consistency"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"go.uber.org/zap"
+)
+
+// ConsistencyTracker issues consistency tokens; *consistency.Tracker
+// implements it
+type ConsistencyTracker interface {
+	Current(ctx context.Context) (consistency.Token, error)
+}
+
+// ConsistencyMiddleware stores the consistency token a request presents in
+// its context, so its reads avoid a replica that is behind the token, and
+// answers successful writes with a token covering them
+func ConsistencyMiddleware(tracker ConsistencyTracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if header := r.Header.Get(consistency.Header); header != "" {
+				token, err := consistency.ParseToken(header)
+				if err != nil {
+					sendError(w, "Invalid "+consistency.Header+" header", http.StatusBadRequest)
+					return
+				}
+				r = r.WithContext(consistency.WithToken(r.Context(), token))
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+			default:
+				next.ServeHTTP(&consistencyResponseWriter{ResponseWriter: w, request: r, tracker: tracker}, r)
+			}
+		})
+	}
+}
+
+// consistencyResponseWriter adds a consistency token to successful
+// responses as their header is written, after the handler's writes have
+// committed
+type consistencyResponseWriter struct {
+	http.ResponseWriter
+	request     *http.Request
+	tracker     ConsistencyTracker
+	wroteHeader bool
+}
+
+// WriteHeader adds the token to 2xx and 3xx responses
+func (cw *consistencyResponseWriter) WriteHeader(code int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		if code < http.StatusBadRequest {
+			cw.addToken()
+		}
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the header first if the handler did not
+func (cw *consistencyResponseWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *consistencyResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// addToken sets the header to the primary's WAL position. Without it the
+// client's next reads may be stale, but the write itself succeeded.
+func (cw *consistencyResponseWriter) addToken() {
+	token, err := cw.tracker.Current(cw.request.Context())
+	if err != nil {
+		logger.FromContext(cw.request.Context()).Warn("failed to issue consistency token", zap.Error(err))
+		return
+	}
+	cw.Header().Set(consistency.Header, token.String())
+}
@@ -2,24 +2,121 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/config"
 )
 
-// CORSMiddleware handles Cross-Origin Resource Sharing
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "86400")
-
-		// Handle preflight requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// CORSMiddleware handles Cross-Origin Resource Sharing. Requests from an
+// allowed origin get the CORS headers of policy, and preflight requests are
+// answered here: with 204 from an allowed origin and 403 from any other.
+// Requests without an Origin header are not cross-origin and pass through.
+func CORSMiddleware(policy config.CORSConfig) func(http.Handler) http.Handler {
+	allowsAny := false
+	for _, origin := range policy.AllowedOrigins {
+		allowsAny = allowsAny || origin == "*"
+	}
+	methods := strings.Join(policy.AllowedMethods, ", ")
+	headers := strings.Join(policy.AllowedHeaders, ", ")
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The response depends on the origin unless every origin gets
+			// the same one
+			if !allowsAny || policy.AllowCredentials {
+				w.Header().Add("Vary", "Origin")
+			}
+			if !allowsAny && !originAllowed(policy.AllowedOrigins, origin) {
+				if preflight {
+					sendError(w, "Origin not allowed", http.StatusForbidden)
+					return
+				}
+				// Served without CORS headers, so the browser hides the response
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowsAny && !policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			if policy.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// originAllowed reports whether origin matches one of allowed, each an
+// origin or an origin with a wildcard leading label
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		scheme, host, ok := strings.Cut(pattern, "://*")
+		if !ok {
+			if origin == pattern {
+				return true
+			}
+			continue
+		}
+
+		// host is the rest of the pattern after the wildcard, such as
+		// ".example.com"; the subdomain it stands for must be a host name
+		prefix := scheme + "://"
+		if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, host) {
+			continue
 		}
+		subdomain := strings.TrimSuffix(strings.TrimPrefix(origin, prefix), host)
+		if subdomain != "" && isHostLabels(subdomain) {
+			return true
+		}
+	}
+	return false
+}
 
-		// Call the next handler
-		next.ServeHTTP(w, r)
-	})
-}
\ No newline at end of file
+// isHostLabels reports whether s is made of host name labels, such as
+// "api" or "eu.api"
+func isHostLabels(s string) bool {
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
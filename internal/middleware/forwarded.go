@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/forwarded"
+)
+
+// ForwardedMiddleware records the origin clients reached the server at, for
+// absolute URLs in responses, and the client's IP address from
+// X-Forwarded-For, for rate limiting, internal-only endpoints and audit
+// entries. The headers are only believed from proxies; anyone else could
+// point generated links at another site or pose as another client with them.
+func ForwardedMiddleware(proxies forwarded.Proxies) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer := peerIP(r)
+			if ip := net.ParseIP(peer); ip != nil && proxies.Contains(ip) {
+				ctx := forwarded.WithOrigin(r.Context(), forwarded.FromHeaders(r))
+				ctx = forwarded.WithClientIP(ctx, proxies.ClientIPFromHeaders(r, peer))
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/i18n"
+)
+
+// LocaleMiddleware negotiates the request locale from Accept-Language and
+// stores it in the request context for handlers and serializers
+func LocaleMiddleware(negotiator *i18n.Negotiator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			locale := negotiator.Negotiate(r.Header.Get("Accept-Language"))
+
+			w.Header().Set("Content-Language", locale.String())
+			w.Header().Add("Vary", "Accept-Language")
+
+			ctx := i18n.WithLocale(r.Context(), locale)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// TenantFormatMiddleware formats numbers and amounts for the caller's tenant
+// in the locale configured for it. It must run after authentication, which
+// names the tenant.
+func TenantFormatMiddleware(locales i18n.TenantLocales) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if locale, ok := locales[actor.FromContext(r.Context()).TenantID]; ok {
+				r = r.WithContext(i18n.WithFormatLocale(r.Context(), locale))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
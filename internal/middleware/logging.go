@@ -1,46 +1,112 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/pratham15541/go-crud/internal/logger"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Request log categories that can be sampled
+const (
+	LogCategoryHealth      = "health"
+	LogCategoryNotFound    = "not_found"
+	LogCategoryRateLimited = "rate_limited"
 )
 
-// LoggingMiddleware logs HTTP requests
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Create a response writer wrapper to capture status code
-		wrapped := &responseWriter{
-			ResponseWriter: w,
-			statusCode:     http.StatusOK,
-		}
-
-		// Call the next handler
-		next.ServeHTTP(wrapped, r)
-
-		// Log the request
-		duration := time.Since(start)
-		log.Printf(
-			"%s %s %d %v %s",
-			r.Method,
-			r.RequestURI,
-			wrapped.statusCode,
-			duration,
-			r.UserAgent(),
-		)
-	})
+// LoggingMiddleware logs every request once it completes, except those
+// sampler leaves out; a nil sampler logs them all. Handlers and services
+// find a logger carrying the request ID, method, path and trace ID in the
+// request context through logger.FromContext.
+func LoggingMiddleware(base *zap.Logger, sampler *logger.Sampler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestLogger := base.With(
+				zap.String("request_id", RequestIDFromContext(r.Context())),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+			// Link log lines to the request's trace when tracing is on
+			if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+				requestLogger = requestLogger.With(zap.String("trace_id", span.TraceID().String()))
+			}
+
+			// Create a response writer wrapper to capture status code
+			wrapped := &responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
+			// Call the next handler
+			next.ServeHTTP(wrapped, r.WithContext(logger.WithContext(r.Context(), requestLogger)))
+
+			// Log the request
+			sampled, rate := sampler.Sample(logCategory(r, wrapped.statusCode))
+			if !sampled {
+				return
+			}
+			fields := []zap.Field{
+				zap.Int("status", wrapped.statusCode),
+				zap.Duration("duration", time.Since(start)),
+				zap.Int("size", wrapped.size),
+				zap.String("user_agent", r.UserAgent()),
+			}
+			if rate > 1 {
+				// Stands for rate requests like it
+				fields = append(fields, zap.Uint64("sample_rate", rate))
+			}
+			requestLogger.Info("request completed", fields...)
+		})
+	}
 }
 
-// responseWriter wraps http.ResponseWriter to capture status code
+// logCategory returns the sampling category of a request answered with
+// status, or "" for requests that are always logged
+func logCategory(r *http.Request, status int) string {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return ""
+	// Suffixes, so the probes are recognized under BASE_PATH too
+	case strings.HasSuffix(r.URL.Path, "/readyz") || strings.HasSuffix(r.URL.Path, "/metrics") || strings.HasSuffix(r.URL.Path, "/health"):
+		return LogCategoryHealth
+	case status == http.StatusNotFound:
+		return LogCategoryNotFound
+	case status == http.StatusTooManyRequests:
+		return LogCategoryRateLimited
+	default:
+		return ""
+	}
+}
+
+// responseWriter wraps http.ResponseWriter to capture status code and body size
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	size        int
+	wroteHeader bool
 }
 
 // WriteHeader captures the status code
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
+	rw.wroteHeader = true
 	rw.ResponseWriter.WriteHeader(code)
-}
\ No newline at end of file
+}
+
+// Write counts the bytes written to the body
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
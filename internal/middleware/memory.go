@@ -0,0 +1,26 @@
+package middleware
+
+import "net/http"
+
+// MemoryPressureReporter reports whether the process is short of memory
+type MemoryPressureReporter interface {
+	UnderPressure() bool
+}
+
+// ShedUnderMemoryPressure rejects requests with 503 while memory is short,
+// so endpoints that hold many rows at once cannot push the process into the
+// OOM killer and take every other request down with it
+func ShedUnderMemoryPressure(pressure MemoryPressureReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !pressure.UnderPressure() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set(DegradedHeader, "memory-pressure")
+			w.Header().Set("Retry-After", "10")
+			sendError(w, "Server is short of memory, please retry later", http.StatusServiceUnavailable)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MethodOverrideHeader lets clients that can only send GET/POST tunnel other methods
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// overridableMethods are the methods a POST request may be rewritten to
+var overridableMethods = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// MethodOverrideMiddleware rewrites POST requests carrying an
+// X-HTTP-Method-Override header to the requested method. It must run before
+// routing so the override is used when matching routes.
+func MethodOverrideMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			override := strings.ToUpper(strings.TrimSpace(r.Header.Get(MethodOverrideHeader)))
+			if overridableMethods[override] {
+				r.Method = override
+				r.Header.Del(MethodOverrideHeader)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestMetrics records Prometheus metrics for served requests
+type RequestMetrics interface {
+	InFlight(method, route string, delta float64)
+	Observe(method, route string, status, size int, duration time.Duration)
+}
+
+// MetricsMiddleware records the count, latency and response size of every
+// request, and the number in flight. route labels a request with its route
+// template rather than its path, so IDs do not create new series.
+func MetricsMiddleware(metrics RequestMetrics, route func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			label := route(r)
+
+			metrics.InFlight(r.Method, label, 1)
+			defer metrics.InFlight(r.Method, label, -1)
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+
+			metrics.Observe(r.Method, label, wrapped.statusCode, wrapped.size, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/forwarded"
+)
+
+// InternalOnlyMiddleware only allows requests originating from loopback or
+// private network addresses. It is intended for operational endpoints that
+// should never be reachable from the public internet.
+func InternalOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := net.ParseIP(clientIP(r))
+		if ip == nil || !(ip.IsLoopback() || ip.IsPrivate()) {
+			sendAuthError(w, "Endpoint is only available on internal networks", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the IP address of the client that sent r: the one
+// ForwardedMiddleware found behind a trusted proxy, or else the peer's
+func clientIP(r *http.Request) string {
+	if ip, ok := forwarded.ClientIP(r.Context()); ok {
+		return ip
+	}
+	return peerIP(r)
+}
+
+// peerIP returns the IP address of the peer that sent r
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Trailing slash handling modes for PathNormalizationMiddleware
+const (
+	TrailingSlashOff      = "off"
+	TrailingSlashRedirect = "redirect"
+	TrailingSlashRewrite  = "rewrite"
+)
+
+// PathNormalizationMiddleware strips trailing slashes and optionally
+// canonicalizes the case of request paths with lowercase, so /users/ and
+// /Users reach the /users route. lowercase is nil to keep the case as sent.
+// In redirect mode clients receive a 308 to the canonical path, which
+// preserves the method and body; in rewrite mode the request is routed as if
+// the canonical path was sent. Leading slashes are collapsed too, so a
+// redirect never points at a protocol-relative URL such as //evil.com.
+func PathNormalizationMiddleware(trailingSlash string, lowercase func(path string) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			if trailingSlash != TrailingSlashOff && len(path) > 1 {
+				// Browsers read a leading backslash as a slash
+				path = "/" + strings.TrimRight(strings.TrimLeft(path, "/\\"), "/")
+			}
+			if lowercase != nil {
+				path = lowercase(path)
+			}
+
+			if path == r.URL.Path {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if trailingSlash == TrailingSlashRedirect {
+				target := *r.URL
+				target.Path = path
+				target.RawPath = ""
+				http.Redirect(w, r, target.RequestURI(), http.StatusPermanentRedirect)
+				return
+			}
+
+			r.URL.Path = path
+			r.URL.RawPath = ""
+			next.ServeHTTP(w, r)
+		})
+	}
+}
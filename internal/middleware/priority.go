@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/priority"
+)
+
+// PriorityMiddleware admits requests through scheduler in the class classify
+// assigns them. Requests that cannot get a slot, because their class's queue
+// is full or they waited too long, get a 503 with a Retry-After header.
+func PriorityMiddleware(scheduler *priority.Scheduler, classify func(*http.Request) priority.Class) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			release, err := scheduler.Acquire(r.Context(), classify(r))
+			if err != nil {
+				w.Header().Set("Retry-After", "1")
+				sendError(w, "Server is busy, please retry later", http.StatusServiceUnavailable)
+				return
+			}
+			defer release()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// RateLimitMiddleware limits each client IP with limiter. Rejected requests
+// get a 429 with a Retry-After header. If the limiter fails, the request is
+// let through rather than failing the API with its backend.
+func RateLimitMiddleware(limiter ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(r.Context(), clientIP(r))
+			if err != nil {
+				logger.FromContext(r.Context()).Warn("rate limiter unavailable", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			if !result.Allowed {
+				retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				sendError(w, "Too many requests, please retry later", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
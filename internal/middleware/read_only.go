@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// DegradedHeader marks responses served while the service is degraded
+const DegradedHeader = "X-Degraded"
+
+// ReadOnlyReporter reports whether the service is in read-only mode
+type ReadOnlyReporter interface {
+	ReadOnly() bool
+}
+
+// ReadOnlyMiddleware serves safe requests with an X-Degraded header and
+// rejects everything else with 503 while the service is in read-only mode
+func ReadOnlyMiddleware(mode ReadOnlyReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !mode.ReadOnly() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set(DegradedHeader, "read-only")
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+			default:
+				w.Header().Set("Retry-After", "30")
+				sendError(w, "Service is temporarily read-only, please retry later", http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
+
+// sendError sends a JSON error response
+func sendError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	})
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/pratham15541/go-crud/internal/logger"
+	"go.uber.org/zap"
+)
+
+// RecoveryMiddleware turns a panic in a handler into a JSON 500 response and
+// logs it with its stack trace and the request ID. If the handler had
+// already started the response, the status can no longer change, so the
+// panic is only logged and the response is cut short.
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// net/http uses this panic to abort a response on purpose
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			logger.FromContext(r.Context()).Error("panic while handling request",
+				zap.Any("panic", p),
+				zap.ByteString("stack", debug.Stack()),
+			)
+			if !wrapped.wroteHeader {
+				sendError(wrapped, "An unexpected error occurred", http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(wrapped, r)
+	})
+}
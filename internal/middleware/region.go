@@ -0,0 +1,17 @@
+package middleware
+
+import "net/http"
+
+// RegionHeader names the region that served a response, so clients and
+// global load balancers can tell where requests land
+const RegionHeader = "X-Region"
+
+// RegionMiddleware adds the RegionHeader to every response
+func RegionMiddleware(region string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(RegionHeader, region)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// TransformParam is the query parameter holding a JMESPath expression
+const TransformParam = "transform"
+
+// maxTransformLength caps the length of a transform expression
+const maxTransformLength = 512
+
+// maxTransformBody caps the size of a response that may be transformed, which
+// bounds the memory buffered and the work an expression can be given
+const maxTransformBody = 1 << 20
+
+// errTransformTooLarge is returned for responses over maxTransformBody
+var errTransformTooLarge = fmt.Errorf("response is too large to transform, the limit is %d bytes", maxTransformBody)
+
+// allowedTransformFunctions are the JMESPath functions clients may call
+var allowedTransformFunctions = map[string]bool{
+	"length":      true,
+	"keys":        true,
+	"values":      true,
+	"contains":    true,
+	"starts_with": true,
+	"ends_with":   true,
+	"join":        true,
+	"sort":        true,
+	"sort_by":     true,
+	"reverse":     true,
+	"max":         true,
+	"min":         true,
+	"max_by":      true,
+	"min_by":      true,
+	"sum":         true,
+	"avg":         true,
+	"not_null":    true,
+	"to_string":   true,
+	"to_number":   true,
+	"type":        true,
+}
+
+// ResponseTransformMiddleware reshapes successful JSON responses to GET
+// requests with the JMESPath expression in ?transform=. The expression is
+// applied to the "data" field of the response envelope, or to the whole
+// body when there is none. Evaluation is abandoned after timeout.
+//
+// Responses are buffered whole, so transforms are refused with 400 on
+// requests for which streaming returns true, and responses over
+// maxTransformBody are refused with 422. JMESPath evaluation cannot be
+// cancelled, so at most one abandoned evaluation per CPU may still be
+// running; further transforms wait for one to finish within timeout.
+func ResponseTransformMiddleware(timeout time.Duration, streaming func(*http.Request) bool) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, runtime.NumCPU())
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expression := r.URL.Query().Get(TransformParam)
+			if expression == "" || r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if streaming != nil && streaming(r) {
+				sendError(w, "transform is not supported on streaming responses", http.StatusBadRequest)
+				return
+			}
+
+			compiled, err := compileTransform(expression)
+			if err != nil {
+				sendError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			buf := &bufferedResponseWriter{header: make(http.Header), status: http.StatusOK, limit: maxTransformBody}
+			next.ServeHTTP(buf, r)
+
+			body := buf.body.Bytes()
+			if buf.status >= 200 && buf.status < 300 && strings.HasPrefix(buf.header.Get("Content-Type"), "application/json") {
+				if buf.overflow {
+					sendError(w, errTransformTooLarge.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+				transformed, err := applyTransform(compiled, body, timeout, slots)
+				if err != nil {
+					sendError(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+				body = transformed
+				buf.header.Del("Content-Length")
+			} else if buf.overflow {
+				// Responses that are not transformed were cut off, so they
+				// cannot be relayed whole either
+				sendError(w, errTransformTooLarge.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+
+			for key, values := range buf.header {
+				w.Header()[key] = values
+			}
+			if len(body) > 0 && w.Header().Get("Content-Length") == "" {
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			}
+			w.WriteHeader(buf.status)
+			w.Write(body)
+		})
+	}
+}
+
+// compileTransform validates an expression and rejects functions outside the allowlist
+func compileTransform(expression string) (*jmespath.JMESPath, error) {
+	if len(expression) > maxTransformLength {
+		return nil, fmt.Errorf("transform expression must be at most %d characters", maxTransformLength)
+	}
+
+	for _, name := range transformFunctions(expression) {
+		if !allowedTransformFunctions[name] {
+			return nil, fmt.Errorf("transform function %q is not allowed", name)
+		}
+	}
+
+	compiled, err := jmespath.Compile(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transform expression: %v", err)
+	}
+	return compiled, nil
+}
+
+// applyTransform runs compiled over the response body, giving up after
+// timeout. Each evaluation holds one of slots until it finishes, even once
+// abandoned, so runaway expressions cannot pile up.
+func applyTransform(compiled *jmespath.JMESPath, body []byte, timeout time.Duration, slots chan struct{}) ([]byte, error) {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, fmt.Errorf("response cannot be transformed")
+	}
+
+	// Transform the payload of the response envelope, keeping the message
+	target := document
+	envelope, _ := document.(map[string]interface{})
+	if data, ok := envelope["data"]; ok {
+		target = data
+	} else {
+		envelope = nil
+	}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	select {
+	case slots <- struct{}{}:
+	case <-deadline.C:
+		return nil, fmt.Errorf("transform exceeded the %s time limit", timeout)
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-slots }()
+		value, err := compiled.Search(target)
+		done <- result{value, err}
+	}()
+
+	var value interface{}
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, fmt.Errorf("transform failed: %v", res.err)
+		}
+		value = res.value
+	case <-deadline.C:
+		return nil, fmt.Errorf("transform exceeded the %s time limit", timeout)
+	}
+
+	if envelope != nil {
+		envelope["data"] = value
+		value = envelope
+	}
+
+	var out bytes.Buffer
+	if err := json.NewEncoder(&out).Encode(value); err != nil {
+		return nil, fmt.Errorf("transform failed: %v", err)
+	}
+	return out.Bytes(), nil
+}
+
+// transformFunctions returns the names of functions called in a JMESPath
+// expression: unquoted identifiers followed by "(", skipping string literals
+func transformFunctions(expression string) []string {
+	var names []string
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// Skip to the closing quote, honouring backslash escapes
+			for i++; i < len(expression) && expression[i] != c; i++ {
+				if expression[i] == '\\' {
+					i++
+				}
+			}
+		case isIdentStart(c):
+			start := i
+			for i+1 < len(expression) && isIdentPart(expression[i+1]) {
+				i++
+			}
+			j := i + 1
+			for j < len(expression) && isSpace(expression[j]) {
+				j++
+			}
+			if j < len(expression) && expression[j] == '(' {
+				names = append(names, expression[start:i+1])
+			}
+		}
+	}
+	return names
+}
+
+// isSpace reports whether the JMESPath lexer skips c between tokens
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// bufferedResponseWriter holds a response so it can be rewritten before
+// sending. Writes past limit are dropped and mark the response overflowed.
+type bufferedResponseWriter struct {
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if b.overflow || b.body.Len()+len(p) > b.limit {
+		b.overflow = true
+		return len(p), nil
+	}
+	return b.body.Write(p)
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/idcodec"
+)
+
+// Registration modes, set by REGISTRATION_MODE
+const (
+	// RegistrationOpen creates accounts that can sign in at once
+	RegistrationOpen = "open"
+	// RegistrationApproval holds new accounts pending until an admin decides
+	RegistrationApproval = "approval"
+	// RegistrationClosed turns self-registration off
+	RegistrationClosed = "closed"
+)
+
+// Statuses of a registration awaiting approval
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// Approval is a self-registered account and the admin decision
+// on it
+type Approval struct {
+	UserID    int        `json:"-"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Status    string     `json:"status"`
+	Reason    string     `json:"reason,omitempty"`
+	DecidedBy *int       `json:"-"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ApprovalResponse is an Approval with its user IDs encoded for the API
+type ApprovalResponse struct {
+	UserID idcodec.PublicID `json:"user_id"`
+	*Approval
+	DecidedBy *idcodec.PublicID `json:"decided_by,omitempty"`
+}
+
+// ToResponse encodes the IDs of a with codec
+func (a *Approval) ToResponse(codec idcodec.Codec) *ApprovalResponse {
+	resp := &ApprovalResponse{UserID: idcodec.Public(codec, a.UserID), Approval: a}
+	if a.DecidedBy != nil {
+		decidedBy := idcodec.Public(codec, *a.DecidedBy)
+		resp.DecidedBy = &decidedBy
+	}
+	return resp
+}
+
+// ApprovalDecisionRequest is the optional body of an approval or rejection.
+// Reason is included in the email sent to the user.
+type ApprovalDecisionRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
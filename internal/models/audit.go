@@ -0,0 +1,67 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditEntry records one mutating API request, or a change made by a
+// background job, whose Status is 0. Each entry carries the hash of the entry
+// before it, so editing or removing an entry breaks the chain.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Action    string    `json:"action"`
+	ActorID   string    `json:"actor_id,omitempty"`
+	TargetID  string    `json:"target_id,omitempty"`
+	Status    int       `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+
+	// ImpersonatorID is the user who acted as ActorID, if any
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+	TenantID       string `json:"tenant_id,omitempty"`
+	APIKeyID       string `json:"api_key_id,omitempty"`
+	IP             string `json:"ip,omitempty"`
+
+	// Signature is the request's signature by the key registered for
+	// APIKeyID, SignedPayload the text it signs and SigningKey the PEM
+	// public key that verifies it; all empty for unsigned requests
+	Signature     string `json:"signature,omitempty"`
+	SignedPayload string `json:"signed_payload,omitempty"`
+	SigningKey    string `json:"signing_key,omitempty"`
+}
+
+// ComputeHash returns the SHA-256 hash of the entry's contents and PrevHash
+func (e *AuditEntry) ComputeHash() string {
+	fields := []string{
+		e.PrevHash,
+		e.Action,
+		e.ActorID,
+		e.TargetID,
+		strconv.Itoa(e.Status),
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	// Hashed only when set, so entries written before these fields existed
+	// still verify
+	if e.ImpersonatorID != "" || e.TenantID != "" || e.APIKeyID != "" || e.IP != "" {
+		fields = append(fields, e.ImpersonatorID, e.TenantID, e.APIKeyID, e.IP)
+	}
+	if e.Signature != "" {
+		fields = append(fields, e.Signature, e.SignedPayload, e.SigningKey)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditVerification reports the result of checking the audit hash chain
+type AuditVerification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt is the ID of the first entry that fails verification
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// RegisterRequest represents the request payload for self-registration.
+// Passwords are capped at 72 characters because bcrypt ignores the rest.
+type RegisterRequest struct {
+	Name     string `json:"name" validate:"required,notblank,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Age      int    `json:"age" validate:"required,min=1,max=150"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// LoginRequest represents the request payload for logging in
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// AuthResponse represents the response payload for register and login
+type AuthResponse struct {
+	Token     string        `json:"token"`
+	TokenType string        `json:"token_type"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	User      *UserResponse `json:"user"`
+	// Pending reports a registration awaiting approval, which has no token
+	Pending bool `json:"-"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// CacheWarmResult reports what a cache warm-up preloaded
+type CacheWarmResult struct {
+	// Users counts the users whose claims were cached
+	Users      int     `json:"users"`
+	DurationMs float64 `json:"duration_ms"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// Paginated routes, the keys of per-route page sizes
+const (
+	PageRouteUsers     = "users"
+	PageRouteAudit     = "audit"
+	PageRouteEmails    = "emails"
+	PageRouteApprovals = "approvals"
+)
+
+// PageRoutes are the routes page sizes can be configured for
+var PageRoutes = []string{PageRouteUsers, PageRouteAudit, PageRouteEmails, PageRouteApprovals}
+
+// PageLimits are the page sizes of a list endpoint
+type PageLimits struct {
+	DefaultLimit int `json:"default_limit"`
+	MaxLimit     int `json:"max_limit"`
+}
+
+// Capabilities describes the limits clients should plan around
+type Capabilities struct {
+	// Pagination holds the page sizes in effect for each paginated route
+	Pagination map[string]PageLimits `json:"pagination"`
+}
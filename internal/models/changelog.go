@@ -0,0 +1,31 @@
+package models
+
+// Change types used in changelog entries
+const (
+	ChangeAdded      = "added"
+	ChangeChanged    = "changed"
+	ChangeDeprecated = "deprecated"
+	ChangeRemoved    = "removed"
+)
+
+// ChangelogEntry describes the API changes released in one version
+type ChangelogEntry struct {
+	Version string      `json:"version"`
+	Date    string      `json:"date"`
+	Changes []APIChange `json:"changes"`
+}
+
+// APIChange describes a single change to an endpoint or field
+type APIChange struct {
+	Type        string `json:"type"`
+	Endpoint    string `json:"endpoint,omitempty"`
+	Field       string `json:"field,omitempty"`
+	Description string `json:"description"`
+	Breaking    bool   `json:"breaking"`
+}
+
+// ChangelogResponse represents the response payload for the changelog
+type ChangelogResponse struct {
+	CurrentVersion string           `json:"current_version"`
+	Entries        []ChangelogEntry `json:"entries"`
+}
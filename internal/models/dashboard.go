@@ -0,0 +1,25 @@
+package models
+
+// Dashboard summarizes the server's recent traffic and its connection pools
+type Dashboard struct {
+	// WindowSeconds is the span the rates and latency cover
+	WindowSeconds     float64 `json:"window_seconds"`
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// ErrorRate is the share of requests answered with a 5xx status
+	ErrorRate    float64 `json:"error_rate"`
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+	InFlight     int     `json:"in_flight"`
+	// ActiveUsers counts the users who made an authenticated request in the window
+	ActiveUsers int          `json:"active_users"`
+	Pools       []PoolStatus `json:"pools"`
+}
+
+// PoolStatus is the state of one database connection pool
+type PoolStatus struct {
+	Name      string `json:"name"`
+	MaxOpen   int    `json:"max_open"`
+	Open      int    `json:"open"`
+	InUse     int    `json:"in_use"`
+	Idle      int    `json:"idle"`
+	WaitCount int64  `json:"wait_count"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+)
+
+// Email delivery statuses
+const (
+	// EmailQueued emails are waiting for their next delivery attempt
+	EmailQueued = "queued"
+	// EmailSent emails were accepted by the mail provider
+	EmailSent = "sent"
+	// EmailDelivered emails were reported delivered by the provider
+	EmailDelivered = "delivered"
+	// EmailBounced emails were accepted but reported undeliverable
+	EmailBounced = "bounced"
+	// EmailComplained emails were marked as spam by their recipient
+	EmailComplained = "complained"
+	// EmailFailed emails were rejected outright or ran out of attempts
+	EmailFailed = "failed"
+	// EmailSuppressed emails were not sent because their recipient is
+	// suppressed
+	EmailSuppressed = "suppressed"
+)
+
+// Email is an outbound email in the outbox
+type Email struct {
+	ID int64 `json:"id"`
+	// MessageID is the Message-ID header, which provider notifications refer to
+	MessageID     string     `json:"message_id"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// EmailEvent is a delivery notification from the mail provider
+type EmailEvent struct {
+	// MessageID identifies the email; it may be empty for bounces and
+	// complaints that name a Recipient
+	MessageID string `json:"message_id,omitempty"`
+	// Type is "delivered", "bounced" or "complained"
+	Type string `json:"type"`
+	// Recipient is the address that bounced or complained; it defaults to
+	// the recipient of the email
+	Recipient string `json:"recipient,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// EmailSuppression marks an address that nothing more is sent to
+type EmailSuppression struct {
+	Email string `json:"email"`
+	// Reason is "bounced" or "complained"
+	Reason    string    `json:"reason"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Email deliverability statuses
+const (
+	EmailDeliverable   = "deliverable"
+	EmailUndeliverable = "undeliverable"
+)
+
+// EmailDeliverability reports whether emails can be sent to a user's address
+type EmailDeliverability struct {
+	Status string `json:"status"`
+	// Reason, Detail and Since describe the suppression of undeliverable
+	// addresses
+	Reason string     `json:"reason,omitempty"`
+	Detail string     `json:"detail,omitempty"`
+	Since  *time.Time `json:"since,omitempty"`
+}
+
+// EmailEventsRequest is the body of the email webhook
+type EmailEventsRequest struct {
+	Events []EmailEvent `json:"events"`
+}
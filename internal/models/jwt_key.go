@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// JWTKey is a secret JWTs are signed with after JWT_SECRET is rotated.
+// Tokens carry its ID in their kid header.
+type JWTKey struct {
+	ID string `json:"id"`
+	// Secret is the HMAC key; it is never returned
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Types of LiveMessage other than user change events, whose type is the
+// event name, such as user.updated
+const (
+	LiveSubscribed   = "subscribed"
+	LiveUnsubscribed = "unsubscribed"
+	LiveError        = "error"
+)
+
+// LiveMessage is a message pushed to WebSocket clients
+type LiveMessage struct {
+	Type    string      `json:"type"`
+	Topic   string      `json:"topic,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Time    time.Time   `json:"time"`
+}
+
+// LiveCommand is a message from a WebSocket client changing its
+// subscriptions. Action is "subscribe" or "unsubscribe".
+type LiveCommand struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// SchemaLink points at the standalone JSON Schema of a model
+type SchemaLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/idcodec"
+)
+
+// Operation states
+const (
+	OperationPending   = "pending"
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+)
+
+// Operation types
+const (
+	OperationAnonymizeUser = "anonymize_user"
+)
+
+// Operation tracks a long-running request accepted with 202
+type Operation struct {
+	ID          string     `json:"id" db:"id"`
+	Type        string     `json:"type" db:"type"`
+	Status      string     `json:"status" db:"status"`
+	Progress    int        `json:"progress" db:"progress"`
+	ResultURL   string     `json:"result_url,omitempty" db:"result_url"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	CreatedBy   *int       `json:"-" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// OperationResponse is an Operation with its creator's ID encoded for the API
+type OperationResponse struct {
+	*Operation
+	CreatedBy *idcodec.PublicID `json:"created_by,omitempty"`
+}
+
+// ToResponse encodes the IDs of o with codec
+func (o *Operation) ToResponse(codec idcodec.Codec) *OperationResponse {
+	resp := &OperationResponse{Operation: o}
+	if o.CreatedBy != nil {
+		createdBy := idcodec.Public(codec, *o.CreatedBy)
+		resp.CreatedBy = &createdBy
+	}
+	return resp
+}
+
+// Done reports whether the operation has finished
+func (o *Operation) Done() bool {
+	return o.Status == OperationSucceeded || o.Status == OperationFailed
+}
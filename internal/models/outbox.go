@@ -0,0 +1,24 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent is a domain event stored in the outbox until the relay
+// publishes it
+type OutboxEvent struct {
+	ID      int64
+	EventID string
+	Type    string
+	Subject string
+	// Data is the event's data, JSON encoded
+	Data          json.RawMessage
+	OccurredAt    time.Time
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	PublishedAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
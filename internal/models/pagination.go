@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// UserCursor is the position of the last user on a keyset page
+type UserCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        int       `json:"id"`
+}
+
+// CursorPagination describes a keyset page; NextCursor is empty on the last page
+type CursorPagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// PersonalTokenPrefix marks bearer tokens that are personal access tokens
+// rather than JWTs, so they can be recognised without a database lookup
+const PersonalTokenPrefix = "pat_"
+
+// PersonalToken represents a named, scoped token a user minted for scripting
+type PersonalToken struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"-" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Prefix     string     `json:"prefix" db:"token_prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	// SigningKey is the PEM public key mutating requests made with the token
+	// must be signed with; empty when signing is not set up
+	SigningKey string `json:"signing_key,omitempty" db:"signing_key"`
+}
+
+// CreatePersonalTokenRequest represents the request payload for minting a token
+type CreatePersonalTokenRequest struct {
+	Name      string   `json:"name" validate:"required,min=2,max=100"`
+	Scopes    []string `json:"scopes" validate:"required,min=1"`
+	ExpiresIn string   `json:"expires_in,omitempty"`
+	// SigningKey optionally registers a public key at creation
+	SigningKey string `json:"signing_key,omitempty"`
+}
+
+// SigningKeyRequest registers the public key a token's requests are signed
+// with, PEM encoded
+type SigningKeyRequest struct {
+	SigningKey string `json:"signing_key" validate:"required"`
+}
+
+// PersonalTokenResponse is returned once when a token is created. The
+// plaintext token is never retrievable again.
+type PersonalTokenResponse struct {
+	*PersonalToken
+	Token string `json:"token"`
+}
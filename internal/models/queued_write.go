@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// Queued write states
+const (
+	QueuedWritePending = "pending"
+	QueuedWriteApplied = "applied"
+	QueuedWriteFailed  = "failed"
+)
+
+// QueuedWrite is a create request accepted during a database outage and
+// applied once the database recovers
+type QueuedWrite struct {
+	ID        string             `json:"id"`
+	Status    string             `json:"status"`
+	Request   *CreateUserRequest `json:"request"`
+	UserID    *int               `json:"user_id,omitempty"`
+	Error     string             `json:"error,omitempty"`
+	Attempts  int                `json:"attempts"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
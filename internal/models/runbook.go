@@ -0,0 +1,23 @@
+package models
+
+// CacheFlushResult names the caches a flush emptied
+type CacheFlushResult struct {
+	Caches []string `json:"caches"`
+}
+
+// MigrationRunResult reports a run of pending migrations
+type MigrationRunResult struct {
+	// Applied names the migrations the run applied, such as 0013_jwt_keys
+	Applied       []string `json:"applied"`
+	SchemaVersion int      `json:"schema_version"`
+}
+
+// DeadLetterReplayResult counts the failed jobs a replay queued again
+type DeadLetterReplayResult struct {
+	Emails int64 `json:"emails"`
+}
+
+// WebhookRedeliveryResult counts the failed webhook deliveries queued again
+type WebhookRedeliveryResult struct {
+	Deliveries int `json:"deliveries"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// UserSettings is a user's free-form settings document. SchemaVersion is the
+// version of the document's shape; older documents are upgraded on read.
+type UserSettings struct {
+	UserID        int             `json:"-"`
+	SchemaVersion int             `json:"schema_version"`
+	Settings      json.RawMessage `json:"settings"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/idcodec"
+)
+
+// TransitionPhase is how far an expand/contract schema change has progressed.
+// Deploy every instance at one phase before moving any of them to the next.
+type TransitionPhase string
+
+// Transition phases, in order
+const (
+	// PhaseOld reads and writes only the old column
+	PhaseOld TransitionPhase = "old"
+	// PhaseDualWrite writes both columns and reads the old one while the
+	// backfill catches up
+	PhaseDualWrite TransitionPhase = "dual-write"
+	// PhaseReadNew writes both columns and reads the new one; this is the
+	// cutover, and moving back to dual-write undoes it
+	PhaseReadNew TransitionPhase = "read-new"
+	// PhaseNew reads and writes only the new column, so the old one can be
+	// dropped
+	PhaseNew TransitionPhase = "new"
+)
+
+// ParseTransitionPhase validates a phase name from configuration
+func ParseTransitionPhase(s string) (TransitionPhase, error) {
+	switch phase := TransitionPhase(s); phase {
+	case PhaseOld, PhaseDualWrite, PhaseReadNew, PhaseNew:
+		return phase, nil
+	default:
+		return "", fmt.Errorf("unknown transition phase %q", s)
+	}
+}
+
+// WritesOld reports whether writes still go to the old column
+func (p TransitionPhase) WritesOld() bool {
+	return p != PhaseNew
+}
+
+// WritesNew reports whether writes go to the new column
+func (p TransitionPhase) WritesNew() bool {
+	return p != PhaseOld
+}
+
+// ReadsNew reports whether reads are served from the new column
+func (p TransitionPhase) ReadsNew() bool {
+	return p == PhaseReadNew || p == PhaseNew
+}
+
+// TransitionReport is the result of comparing the old and new columns of a
+// schema transition
+type TransitionReport struct {
+	Name  string          `json:"name"`
+	Phase TransitionPhase `json:"phase"`
+	// Consistent is true when every row has a new value matching the old one
+	Consistent bool  `json:"consistent"`
+	Checked    int64 `json:"checked"`
+	// Missing rows have no new value yet and are left for the backfill
+	Missing    int64 `json:"missing"`
+	Mismatched int64 `json:"mismatched"`
+	// MismatchedIDs lists some of the mismatched rows
+	MismatchedIDs []int `json:"-"`
+}
+
+// TransitionReportResponse is a TransitionReport with its IDs encoded for
+// the API
+type TransitionReportResponse struct {
+	*TransitionReport
+	MismatchedIDs []idcodec.PublicID `json:"mismatched_ids,omitempty"`
+}
+
+// ToResponse encodes the IDs of r with codec
+func (r *TransitionReport) ToResponse(codec idcodec.Codec) *TransitionReportResponse {
+	resp := &TransitionReportResponse{TransitionReport: r}
+	for _, id := range r.MismatchedIDs {
+		resp.MismatchedIDs = append(resp.MismatchedIDs, idcodec.Public(codec, id))
+	}
+	return resp
+}
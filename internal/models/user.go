@@ -1,60 +1,216 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+)
+
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
 )
 
+// ErrLegalHold is returned when deleting or anonymizing a user under legal hold
+var ErrLegalHold = errors.New("user is under legal hold")
+
+// ErrDuplicateEmail matches a DuplicateEmailError with errors.Is
+var ErrDuplicateEmail = errors.New("email already exists")
+
+// ErrInvalidAge is returned when the database rejects a user's age
+var ErrInvalidAge = apperrors.Validation("age must be between 1 and 149")
+
+// ErrVersionConflict is returned when a user changed after the version an
+// update was based on
+var ErrVersionConflict = apperrors.Conflict("user was changed by another request; fetch it and retry")
+
+// DuplicateEmailError is returned when another user already has Email
+type DuplicateEmailError struct {
+	Email string
+}
+
+// Error implements error
+func (e *DuplicateEmailError) Error() string {
+	return fmt.Sprintf("user with email %s already exists", e.Email)
+}
+
+// Is reports whether target is ErrDuplicateEmail or apperrors.ErrConflict
+func (e *DuplicateEmailError) Is(target error) bool {
+	return target == ErrDuplicateEmail || target == apperrors.ErrConflict
+}
+
 // User represents a user in the system
 type User struct {
 	ID        int       `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name" validate:"required,min=2,max=100"`
 	Email     string    `json:"email" db:"email" validate:"required,email"`
 	Age       int       `json:"age" db:"age" validate:"required,min=1,max=150"`
+	Role      string    `json:"role" db:"role"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// LegalHold blocks deletion and anonymization until an admin lifts it
+	LegalHold bool `json:"legal_hold" db:"legal_hold"`
+	// DeletedAt is set while the user is soft-deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// Version starts at 1 and is incremented by every change to the user
+	Version int `json:"version" db:"version"`
+
+	// PasswordHash is only loaded when verifying credentials
+	PasswordHash string `json:"-" db:"password_hash"`
 }
 
 // CreateUserRequest represents the request payload for creating a user
 type CreateUserRequest struct {
-	Name  string `json:"name" validate:"required,min=2,max=100"`
+	Name  string `json:"name" validate:"required,notblank,min=2,max=100"`
 	Email string `json:"email" validate:"required,email"`
 	Age   int    `json:"age" validate:"required,min=1,max=150"`
 }
 
+// UpsertUserRequest represents the request payload for creating or updating
+// the user with the email in the path
+type UpsertUserRequest struct {
+	Name string `json:"name" validate:"required,notblank,min=2,max=100"`
+	Age  int    `json:"age" validate:"required,min=1,max=150"`
+}
+
 // UpdateUserRequest represents the request payload for updating a user
 type UpdateUserRequest struct {
 	Name  string `json:"name" validate:"omitempty,min=2,max=100"`
 	Email string `json:"email" validate:"omitempty,email"`
 	Age   int    `json:"age" validate:"omitempty,min=1,max=150"`
+	// Version, if set, is the version the update was based on; the update
+	// fails with ErrVersionConflict if the user has changed since
+	Version *int `json:"version,omitempty" validate:"omitempty,min=1"`
+}
+
+// PatchUserRequest represents the request payload for partially updating a
+// user. A nil field was not sent and is left unchanged; a non-nil field is
+// applied and validated even when it holds a zero value.
+type PatchUserRequest struct {
+	Name  *string `json:"name" validate:"omitempty,notblank,min=2,max=100"`
+	Email *string `json:"email" validate:"omitempty,email"`
+	Age   *int    `json:"age" validate:"omitempty,min=1,max=150"`
+	// Version is checked as in UpdateUserRequest
+	Version *int `json:"version,omitempty" validate:"omitempty,min=1"`
+}
+
+// BulkUserResult is the outcome of one user of a bulk create, at Index in
+// the request. Status is the HTTP status the item would have had on its own.
+type BulkUserResult struct {
+	Index  int           `json:"index"`
+	Status int           `json:"status"`
+	User   *UserResponse `json:"user,omitempty"`
+	Error  string        `json:"error,omitempty"`
+	Errors []FieldError  `json:"errors,omitempty"`
+}
+
+// BulkCreateUsersResponse reports every item of a bulk create
+type BulkCreateUsersResponse struct {
+	Created int              `json:"created"`
+	Failed  int              `json:"failed"`
+	Results []BulkUserResult `json:"results"`
+}
+
+// ImportSkippedRow is a CSV row an import did not create a user from. Row is
+// the line it starts on, the header being line 1.
+type ImportSkippedRow struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+// maxImportSkippedRows bounds the skipped rows an import summary lists
+const maxImportSkippedRows = 1000
+
+// ImportUsersSummary reports the outcome of a CSV import. SkippedRows lists
+// at most the first 1000 skipped rows; Skipped counts them all.
+type ImportUsersSummary struct {
+	Processed   int                `json:"processed"`
+	Created     int                `json:"created"`
+	Skipped     int                `json:"skipped"`
+	SkippedRows []ImportSkippedRow `json:"skipped_rows"`
+}
+
+// Skip records that row was skipped for reason
+func (s *ImportUsersSummary) Skip(row int, reason string) {
+	s.Skipped++
+	if len(s.SkippedRows) < maxImportSkippedRows {
+		s.SkippedRows = append(s.SkippedRows, ImportSkippedRow{Row: row, Reason: reason})
+	}
 }
 
 // UserResponse represents the response payload for user operations
 type UserResponse struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Age       int       `json:"age"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        idcodec.PublicID `json:"id" swaggertype:"integer"`
+	Name      string           `json:"name"`
+	Email     string           `json:"email"`
+	Age       int              `json:"age"`
+	Role      string           `json:"role"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	LegalHold bool             `json:"legal_hold,omitempty"`
+	DeletedAt *time.Time       `json:"deleted_at,omitempty"`
+	Version   int              `json:"version"`
+
+	// EmailDeliverability is only shown to admins
+	EmailDeliverability *EmailDeliverability `json:"email_deliverability,omitempty"`
 }
 
-// ToResponse converts a User model to UserResponse
-func (u *User) ToResponse() *UserResponse {
+// ToResponse converts a User model to UserResponse, encoding its ID with codec
+func (u *User) ToResponse(codec idcodec.Codec) *UserResponse {
 	return &UserResponse{
-		ID:        u.ID,
+		ID:        idcodec.Public(codec, u.ID),
 		Name:      u.Name,
 		Email:     u.Email,
 		Age:       u.Age,
+		Role:      u.Role,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
+		LegalHold: u.LegalHold,
+		DeletedAt: u.DeletedAt,
+		Version:   u.Version,
 	}
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code"`
+	Error   string       `json:"error"`
+	Message string       `json:"message,omitempty"`
+	Code    int          `json:"code"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// RouteNotFoundResponse represents a 404 for a path no route matches
+type RouteNotFoundResponse struct {
+	ErrorResponse
+	Path        string   `json:"path"`
+	BasePath    string   `json:"base_path"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// PageTooDeepResponse represents a 400 for a page of users past the
+// deepest offset pages reach
+type PageTooDeepResponse struct {
+	ErrorResponse
+	ErrorCode string `json:"error_code"`
+	MaxOffset int    `json:"max_offset"`
+	// NextCursor continues in cursor mode after the last user offset pages
+	// reach
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ErrorCodePageTooDeep is the error_code of a PageTooDeepResponse
+const ErrorCodePageTooDeep = "page_too_deep"
+
+// FieldError describes one request field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
 }
 
 // SuccessResponse represents a success response
@@ -63,11 +219,19 @@ type SuccessResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// ReadinessResponse represents a readiness check response; Pending maps each
+// unmet condition to the reason it is unmet
+type ReadinessResponse struct {
+	Status  string            `json:"status"`
+	Pending map[string]string `json:"pending,omitempty"`
+}
+
 // HealthResponse represents a health check response
 type HealthResponse struct {
 	Status    string                 `json:"status"`
-	Timestamp time.Time             `json:"timestamp"`
+	Timestamp time.Time              `json:"timestamp"`
 	Version   string                 `json:"version"`
 	Uptime    string                 `json:"uptime"`
+	Region    string                 `json:"region,omitempty"`
 	Checks    map[string]interface{} `json:"checks"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// UserFilter narrows and orders the users list. Zero values are ignored.
+type UserFilter struct {
+	// Name and Email match case-insensitive substrings
+	Name         string
+	Email        string
+	MinAge       int
+	MaxAge       int
+	CreatedAfter time.Time
+	Sort         []UserSort
+	// IncludeDeleted also lists soft-deleted users
+	IncludeDeleted bool
+}
+
+// UserSort orders the users list by one field
+type UserSort struct {
+	Field string
+	Desc  bool
+}
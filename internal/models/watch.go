@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/idcodec"
+)
+
+// WatchTriggered is the event of a watch notification
+const WatchTriggered = "watch.triggered"
+
+// WatchFields lists the user fields a watch may follow
+var WatchFields = []string{"name", "email", "age", "role", "legal_hold"}
+
+// Watch notifies its owner when fields of a user change, until it expires
+type Watch struct {
+	ID      int64 `json:"id"`
+	OwnerID int   `json:"-"`
+	// UserID is the watched user
+	UserID int `json:"-"`
+	// Fields are the fields followed; empty follows all of WatchFields
+	Fields []string `json:"fields"`
+	// URL receives notifications as signed webhook deliveries; empty
+	// delivers them to event streams only
+	URL string `json:"url,omitempty"`
+	// Secret signs deliveries; it is never returned
+	Secret string `json:"-"`
+	// Snapshot holds the values of WatchFields last seen
+	Snapshot  map[string]interface{} `json:"-"`
+	ExpiresAt time.Time              `json:"expires_at"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// WatchResponse is a Watch with the watched user's ID encoded for the API
+type WatchResponse struct {
+	*Watch
+	UserID idcodec.PublicID `json:"user_id"`
+}
+
+// ToResponse encodes the IDs of w with codec
+func (w *Watch) ToResponse(codec idcodec.Codec) *WatchResponse {
+	return &WatchResponse{Watch: w, UserID: idcodec.Public(codec, w.UserID)}
+}
+
+// WatchRequest is the body that creates a watch
+type WatchRequest struct {
+	UserID idcodec.PublicID `json:"user_id" validate:"required"`
+	Fields []string         `json:"fields" validate:"omitempty,dive,oneof=name email age role legal_hold"`
+	URL    string           `json:"url" validate:"omitempty,url,max=2048"`
+	Secret string           `json:"secret" validate:"required_with=URL,omitempty,min=16,max=256"`
+	// TTLSeconds is how long the watch lasts; zero takes the default
+	TTLSeconds int `json:"ttl_seconds" validate:"min=0"`
+}
+
+// WatchChange is the old and new value of a changed field
+type WatchChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// WatchNotification tells a watch's owner what happened to the user
+type WatchNotification struct {
+	WatchID int64            `json:"watch_id"`
+	UserID  idcodec.PublicID `json:"user_id"`
+	// Event is the user event, user.updated or user.deleted
+	Event   string `json:"event"`
+	EventID string `json:"event_id"`
+	// Changes holds the followed fields an update changed
+	Changes map[string]WatchChange `json:"changes,omitempty"`
+	Time    time.Time              `json:"time"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// User lifecycle events webhooks can subscribe to
+const (
+	WebhookUserCreated = "user.created"
+	WebhookUserUpdated = "user.updated"
+	WebhookUserDeleted = "user.deleted"
+)
+
+// WebhookEvents lists every event a webhook may subscribe to
+var WebhookEvents = []string{WebhookUserCreated, WebhookUserUpdated, WebhookUserDeleted}
+
+// Webhook is a consumer's subscription to user lifecycle events
+type Webhook struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+	// Secret signs deliveries; it is never returned
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookRequest is the body that creates or replaces a webhook
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Secret string   `json:"secret" validate:"required,min=16,max=256"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=user.created user.updated user.deleted"`
+}
+
+// WebhookPayload is the body of a webhook delivery
+type WebhookPayload struct {
+	// ID identifies the event; retried deliveries of one event share it
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	// Data is the user as the API returns it; deleted users only carry
+	// their ID
+	Data interface{} `json:"data"`
+}
+
+// WebhookFailure is a delivery to a subscribed webhook that ran out of
+// attempts
+type WebhookFailure struct {
+	ID        int64  `json:"id"`
+	WebhookID int64  `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	Event     string `json:"event"`
+	// Payload is the body the delivery sent
+	Payload   []byte    `json:"-"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
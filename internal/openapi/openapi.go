@@ -0,0 +1,521 @@
+// Package openapi converts the Swagger 2.0 document generated by swag into
+// OpenAPI 3.0 and 3.1, and extracts its models as standalone JSON Schemas
+// clients can validate payloads with.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Versions of OpenAPI documents can be served in
+const (
+	Version30 = "3.0"
+	Version31 = "3.1"
+)
+
+// Versions lists every supported OpenAPI version
+var Versions = []string{Version30, Version31}
+
+// documentVersions are the exact versions written to documents
+var documentVersions = map[string]string{
+	Version30: "3.0.3",
+	Version31: "3.1.0",
+}
+
+// jsonSchemaDialect is the JSON Schema draft OpenAPI 3.1 schemas, and so
+// extracted schemas, follow
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Supported reports whether version is one of Versions
+func Supported(version string) bool {
+	_, ok := documentVersions[version]
+	return ok
+}
+
+// Spec is the API description in every supported version, encoded once
+type Spec struct {
+	json    map[string][]byte
+	yaml    map[string][]byte
+	schemas map[string][]byte
+}
+
+// New converts swagger, a Swagger 2.0 document, to every supported version
+// and extracts the JSON Schema of each of its definitions
+func New(swagger []byte) (*Spec, error) {
+	spec := &Spec{
+		json:    make(map[string][]byte, len(Versions)),
+		yaml:    make(map[string][]byte, len(Versions)),
+		schemas: make(map[string][]byte),
+	}
+
+	var components map[string]interface{}
+	for _, version := range Versions {
+		doc, err := decode(swagger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Swagger document: %w", err)
+		}
+		converted := convert(doc, version)
+		if spec.json[version], err = encodeJSON(converted); err != nil {
+			return nil, err
+		}
+		spec.yaml[version] = encodeYAML(converted)
+		if version == Version31 {
+			components, _ = converted["components"].(map[string]interface{})
+		}
+	}
+
+	schemas, _ := components["schemas"].(map[string]interface{})
+	for name := range schemas {
+		encoded, err := encodeJSON(standaloneSchema(schemas, name))
+		if err != nil {
+			return nil, err
+		}
+		spec.schemas[name] = encoded
+	}
+	return spec, nil
+}
+
+// JSON returns the document in version as JSON, or false for an
+// unsupported version
+func (s *Spec) JSON(version string) ([]byte, bool) {
+	doc, ok := s.json[version]
+	return doc, ok
+}
+
+// YAML returns the document in version as YAML, or false for an
+// unsupported version
+func (s *Spec) YAML(version string) ([]byte, bool) {
+	doc, ok := s.yaml[version]
+	return doc, ok
+}
+
+// SchemaNames returns the names of the models with a JSON Schema, sorted
+func (s *Spec) SchemaNames() []string {
+	names := make([]string, 0, len(s.schemas))
+	for name := range s.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Schema returns the standalone JSON Schema of the model name
+func (s *Spec) Schema(name string) ([]byte, bool) {
+	schema, ok := s.schemas[name]
+	return schema, ok
+}
+
+// decode decodes a JSON document keeping numbers as written
+func decode(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// encodeJSON encodes v indented, without escaping HTML characters
+func encodeJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// convert turns a Swagger 2.0 document into an OpenAPI document of version
+func convert(swagger map[string]interface{}, version string) map[string]interface{} {
+	names := schemaNames(swagger["definitions"])
+	c := &converter{version: version, names: names}
+
+	doc := map[string]interface{}{
+		"openapi": documentVersions[version],
+		"info":    swagger["info"],
+	}
+	basePath, _ := swagger["basePath"].(string)
+	if host, _ := swagger["host"].(string); host != "" {
+		basePath = "//" + host + basePath
+	}
+	if basePath != "" {
+		doc["servers"] = []interface{}{map[string]interface{}{"url": basePath}}
+	}
+	if tags, ok := swagger["tags"]; ok {
+		doc["tags"] = tags
+	}
+	if security, ok := swagger["security"]; ok {
+		doc["security"] = security
+	}
+
+	consumes := stringList(swagger["consumes"])
+	produces := stringList(swagger["produces"])
+	paths := map[string]interface{}{}
+	swaggerPaths, _ := swagger["paths"].(map[string]interface{})
+	for path, item := range swaggerPaths {
+		operations, _ := item.(map[string]interface{})
+		converted := map[string]interface{}{}
+		for method, operation := range operations {
+			op, ok := operation.(map[string]interface{})
+			if !ok {
+				// Path-level parameters
+				converted[method] = c.parameters(operation)
+				continue
+			}
+			converted[method] = c.operation(op, consumes, produces)
+		}
+		paths[path] = converted
+	}
+	doc["paths"] = paths
+
+	components := map[string]interface{}{}
+	if definitions, ok := swagger["definitions"].(map[string]interface{}); ok {
+		schemas := make(map[string]interface{}, len(definitions))
+		for name, definition := range definitions {
+			schemas[names[name]] = c.schema(definition)
+		}
+		components["schemas"] = schemas
+	}
+	if securityDefinitions, ok := swagger["securityDefinitions"].(map[string]interface{}); ok {
+		schemes := make(map[string]interface{}, len(securityDefinitions))
+		for name, definition := range securityDefinitions {
+			schemes[name] = securityScheme(definition)
+		}
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+	return doc
+}
+
+// schemaNames maps each definition to its component name: the type name
+// without its package, e.g. UserResponse for models.UserResponse, unless
+// two packages share it
+func schemaNames(definitions interface{}) map[string]string {
+	defs, _ := definitions.(map[string]interface{})
+	count := map[string]int{}
+	for name := range defs {
+		count[shortName(name)]++
+	}
+	names := make(map[string]string, len(defs))
+	for name := range defs {
+		if short := shortName(name); count[short] == 1 {
+			names[name] = short
+		} else {
+			names[name] = name
+		}
+	}
+	return names
+}
+
+// shortName returns a definition's name without its package
+func shortName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// converter rewrites the parts of a Swagger 2.0 document
+type converter struct {
+	version string
+	// names maps definition names to component names
+	names map[string]string
+}
+
+// operation converts an operation, moving body and form parameters into
+// its request body and response schemas into content
+func (c *converter) operation(op map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	if list := stringList(op["consumes"]); len(list) > 0 {
+		consumes = list
+	}
+	if list := stringList(op["produces"]); len(list) > 0 {
+		produces = list
+	}
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+
+	converted := map[string]interface{}{}
+	for key, value := range op {
+		switch key {
+		case "consumes", "produces", "parameters", "responses":
+		default:
+			converted[key] = value
+		}
+	}
+
+	var parameters []interface{}
+	form := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	var formRequired []interface{}
+	params, _ := op["parameters"].([]interface{})
+	for _, param := range params {
+		p, _ := param.(map[string]interface{})
+		switch p["in"] {
+		case "body":
+			body := map[string]interface{}{"content": content(consumes, c.schema(p["schema"]))}
+			copyKeys(body, p, "description", "required")
+			converted["requestBody"] = body
+		case "formData":
+			name, _ := p["name"].(string)
+			form["properties"].(map[string]interface{})[name] = c.parameterSchema(p)
+			if required, _ := p["required"].(bool); required {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			parameters = append(parameters, c.parameter(p))
+		}
+	}
+	if len(parameters) > 0 {
+		converted["parameters"] = parameters
+	}
+	if len(form["properties"].(map[string]interface{})) > 0 {
+		if len(formRequired) > 0 {
+			form["required"] = formRequired
+		}
+		formTypes := []string{"application/x-www-form-urlencoded"}
+		for _, contentType := range consumes {
+			if contentType == "multipart/form-data" {
+				formTypes = []string{contentType}
+			}
+		}
+		converted["requestBody"] = map[string]interface{}{"content": content(formTypes, form)}
+	}
+
+	responses := map[string]interface{}{}
+	swaggerResponses, _ := op["responses"].(map[string]interface{})
+	for code, response := range swaggerResponses {
+		responses[code] = c.response(response, produces)
+	}
+	converted["responses"] = responses
+	return converted
+}
+
+// parameters converts a list of non-body parameters
+func (c *converter) parameters(list interface{}) interface{} {
+	params, ok := list.([]interface{})
+	if !ok {
+		return list
+	}
+	converted := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		p, _ := param.(map[string]interface{})
+		converted = append(converted, c.parameter(p))
+	}
+	return converted
+}
+
+// parameterKeys are the keys a Swagger 2.0 parameter shares with OpenAPI 3
+var parameterKeys = []string{"name", "in", "description", "required", "allowEmptyValue"}
+
+// parameter converts a path, query or header parameter, moving its type
+// into a schema
+func (c *converter) parameter(p map[string]interface{}) map[string]interface{} {
+	if ref, ok := p["$ref"]; ok {
+		return map[string]interface{}{"$ref": ref}
+	}
+	converted := map[string]interface{}{"schema": c.parameterSchema(p)}
+	copyKeys(converted, p, parameterKeys...)
+	switch p["collectionFormat"] {
+	case "csv":
+		converted["style"] = "form"
+		converted["explode"] = false
+	case "multi":
+		converted["style"] = "form"
+		converted["explode"] = true
+	case "pipes":
+		converted["style"] = "pipeDelimited"
+	case "ssv":
+		converted["style"] = "spaceDelimited"
+	}
+	return converted
+}
+
+// parameterSchema returns the schema of a non-body parameter
+func (c *converter) parameterSchema(p map[string]interface{}) interface{} {
+	schema := map[string]interface{}{}
+	for key, value := range p {
+		switch key {
+		case "name", "in", "description", "required", "allowEmptyValue", "collectionFormat":
+		default:
+			schema[key] = value
+		}
+	}
+	return c.schema(schema)
+}
+
+// response converts a response, serving its schema as each content type
+func (c *converter) response(response interface{}, produces []string) interface{} {
+	r, ok := response.(map[string]interface{})
+	if !ok {
+		return response
+	}
+	if ref, ok := r["$ref"]; ok {
+		return map[string]interface{}{"$ref": ref}
+	}
+
+	converted := map[string]interface{}{"description": r["description"]}
+	if converted["description"] == nil {
+		converted["description"] = ""
+	}
+	if schema, ok := r["schema"]; ok {
+		converted["content"] = content(produces, c.schema(schema))
+	}
+	if headers, ok := r["headers"].(map[string]interface{}); ok {
+		convertedHeaders := make(map[string]interface{}, len(headers))
+		for name, header := range headers {
+			h, _ := header.(map[string]interface{})
+			convertedHeader := map[string]interface{}{"schema": c.parameterSchema(h)}
+			copyKeys(convertedHeader, h, "description")
+			convertedHeaders[name] = convertedHeader
+		}
+		converted["headers"] = convertedHeaders
+	}
+	return converted
+}
+
+// schema converts a Swagger 2.0 schema and the schemas within it
+func (c *converter) schema(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = c.schema(item)
+		}
+		return converted
+	case map[string]interface{}:
+	default:
+		return value
+	}
+
+	schema := value.(map[string]interface{})
+	converted := make(map[string]interface{}, len(schema))
+	for key, item := range schema {
+		switch key {
+		case "$ref":
+			ref, _ := item.(string)
+			converted[key] = c.ref(ref)
+		case "properties", "definitions", "patternProperties":
+			// Maps of names to schemas, rather than schemas
+			props, _ := item.(map[string]interface{})
+			convertedProps := make(map[string]interface{}, len(props))
+			for name, prop := range props {
+				convertedProps[name] = c.schema(prop)
+			}
+			converted[key] = convertedProps
+		case "x-nullable":
+			// Replaced below
+		case "example":
+			if c.version == Version31 {
+				converted["examples"] = []interface{}{item}
+			} else {
+				converted[key] = item
+			}
+		default:
+			converted[key] = c.schema(item)
+		}
+	}
+
+	if converted["type"] == "file" {
+		converted["type"] = "string"
+		converted["format"] = "binary"
+	}
+	if nullable, _ := schema["x-nullable"].(bool); nullable {
+		if c.version == Version31 {
+			if typ, ok := converted["type"].(string); ok {
+				converted["type"] = []interface{}{typ, "null"}
+			}
+		} else {
+			converted["nullable"] = true
+		}
+	}
+	if c.version == Version31 {
+		// JSON Schema 2020-12 makes exclusive bounds numbers of their own
+		for exclusive, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+			if flag, ok := converted[exclusive].(bool); ok {
+				delete(converted, exclusive)
+				if flag {
+					converted[exclusive] = converted[bound]
+					delete(converted, bound)
+				}
+			}
+		}
+	}
+	return converted
+}
+
+// ref points a definition reference at its component
+func (c *converter) ref(ref string) string {
+	name, ok := strings.CutPrefix(ref, "#/definitions/")
+	if !ok {
+		return ref
+	}
+	if component, ok := c.names[name]; ok {
+		name = component
+	}
+	return "#/components/schemas/" + name
+}
+
+// securityScheme converts a security definition; only OAuth 2.0 flows
+// change shape
+func securityScheme(definition interface{}) interface{} {
+	d, ok := definition.(map[string]interface{})
+	if !ok || d["type"] != "oauth2" {
+		return definition
+	}
+
+	flow := map[string]interface{}{}
+	copyKeys(flow, d, "authorizationUrl", "tokenUrl")
+	flow["scopes"] = d["scopes"]
+	if flow["scopes"] == nil {
+		flow["scopes"] = map[string]interface{}{}
+	}
+	flowName := map[interface{}]string{
+		"implicit":    "implicit",
+		"password":    "password",
+		"application": "clientCredentials",
+		"accessCode":  "authorizationCode",
+	}[d["flow"]]
+
+	scheme := map[string]interface{}{"type": "oauth2", "flows": map[string]interface{}{flowName: flow}}
+	copyKeys(scheme, d, "description")
+	return scheme
+}
+
+// content returns a content map serving schema as each of contentTypes
+func content(contentTypes []string, schema interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(contentTypes))
+	for _, contentType := range contentTypes {
+		converted[contentType] = map[string]interface{}{"schema": schema}
+	}
+	return converted
+}
+
+// copyKeys copies the given keys that are set in src into dst
+func copyKeys(dst, src map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		if value, ok := src[key]; ok {
+			dst[key] = value
+		}
+	}
+}
+
+// stringList returns the strings of a decoded JSON array
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
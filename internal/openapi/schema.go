@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"sort"
+	"strings"
+)
+
+// componentRefPrefix starts references to schemas in an OpenAPI document
+const componentRefPrefix = "#/components/schemas/"
+
+// standaloneSchema returns the schema of the component name as a JSON
+// Schema document, with the components it references copied into $defs
+func standaloneSchema(components map[string]interface{}, name string) map[string]interface{} {
+	defs := map[string]interface{}{}
+	pending := []string{}
+	rewrite := func(ref string) string {
+		referenced, ok := strings.CutPrefix(ref, componentRefPrefix)
+		if !ok {
+			return ref
+		}
+		if referenced == name {
+			return "#"
+		}
+		if _, seen := defs[referenced]; !seen {
+			defs[referenced] = nil
+			pending = append(pending, referenced)
+		}
+		return "#/$defs/" + referenced
+	}
+
+	root, _ := rewriteRefs(components[name], rewrite).(map[string]interface{})
+	if root == nil {
+		root = map[string]interface{}{}
+	}
+	for len(pending) > 0 {
+		referenced := pending[0]
+		pending = pending[1:]
+		defs[referenced] = rewriteRefs(components[referenced], rewrite)
+	}
+
+	root["$schema"] = jsonSchemaDialect
+	if _, ok := root["title"]; !ok {
+		root["title"] = name
+	}
+	if len(defs) > 0 {
+		root["$defs"] = defs
+	}
+	return root
+}
+
+// rewriteRefs returns a copy of value with every $ref passed through rewrite
+func rewriteRefs(value interface{}, rewrite func(string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		// Sorted so $defs are discovered in the same order every time
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ref, ok := v[key].(string); ok && key == "$ref" {
+				copied[key] = rewrite(ref)
+				continue
+			}
+			copied[key] = rewriteRefs(v[key], rewrite)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = rewriteRefs(item, rewrite)
+		}
+		return copied
+	default:
+		return value
+	}
+}
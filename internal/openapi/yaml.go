@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// plainScalar matches strings YAML reads back unchanged without quotes
+var plainScalar = regexp.MustCompile(`^[A-Za-z_/$][A-Za-z0-9 _./$()\-]*$`)
+
+// reservedScalars are plain strings YAML 1.1 readers take for other types
+var reservedScalars = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true,
+}
+
+// encodeYAML encodes a decoded JSON document as block-style YAML with
+// sorted keys
+func encodeYAML(doc map[string]interface{}) []byte {
+	var buf bytes.Buffer
+	writeYAMLMap(&buf, doc, 0)
+	return buf.Bytes()
+}
+
+// writeYAMLMap writes the entries of m at indent
+func writeYAMLMap(buf *bytes.Buffer, m map[string]interface{}, indent int) {
+	for _, key := range sortedKeys(m) {
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString(yamlScalar(key))
+		buf.WriteByte(':')
+		writeYAMLValue(buf, m[key], indent)
+	}
+}
+
+// writeYAMLList writes the items of list at indent
+func writeYAMLList(buf *bytes.Buffer, list []interface{}, indent int) {
+	for _, item := range list {
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteByte('-')
+		switch v := item.(type) {
+		case map[string]interface{}:
+			if len(v) == 0 {
+				buf.WriteString(" {}\n")
+				continue
+			}
+			// The first entry shares the dash's line
+			keys := sortedKeys(v)
+			buf.WriteByte(' ')
+			buf.WriteString(yamlScalar(keys[0]))
+			buf.WriteByte(':')
+			writeYAMLValue(buf, v[keys[0]], indent+2)
+			rest := make(map[string]interface{}, len(keys)-1)
+			for _, key := range keys[1:] {
+				rest[key] = v[key]
+			}
+			writeYAMLMap(buf, rest, indent+2)
+		case []interface{}:
+			if len(v) == 0 {
+				buf.WriteString(" []\n")
+				continue
+			}
+			buf.WriteByte('\n')
+			writeYAMLList(buf, v, indent+2)
+		default:
+			writeYAMLValue(buf, v, indent)
+		}
+	}
+}
+
+// writeYAMLValue writes v after the key or dash at indent
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+		buf.WriteByte('\n')
+		writeYAMLMap(buf, v, indent+2)
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+		buf.WriteByte('\n')
+		writeYAMLList(buf, v, indent)
+	case string:
+		buf.WriteByte(' ')
+		buf.WriteString(yamlScalar(v))
+		buf.WriteByte('\n')
+	default:
+		encoded, _ := json.Marshal(v)
+		buf.WriteByte(' ')
+		buf.Write(encoded)
+		buf.WriteByte('\n')
+	}
+}
+
+// yamlScalar returns s plain when that is unambiguous and double-quoted
+// otherwise; JSON string escapes are valid YAML
+func yamlScalar(s string) string {
+	if plainScalar.MatchString(s) && !strings.HasSuffix(s, " ") && !reservedScalars[strings.ToLower(s)] {
+		return s
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+// Package priority admits requests by class while the server is saturated,
+// so health probes and authentication keep working and bulk operations are
+// the first to wait or be turned away.
+package priority
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Class is the priority of a request; lower classes are admitted first
+type Class int
+
+const (
+	// Critical covers health probes and authentication
+	Critical Class = iota
+	Read
+	Write
+	// Bulk covers operations on many users at once, such as imports
+	Bulk
+
+	numClasses = int(Bulk) + 1
+)
+
+var classNames = [numClasses]string{"critical", "read", "write", "bulk"}
+
+// String returns the name of the class
+func (c Class) String() string {
+	return classNames[c]
+}
+
+var (
+	// ErrQueueFull is returned when the queue of a request's class is full
+	ErrQueueFull = errors.New("priority queue is full")
+	// ErrQueueTimeout is returned when a request waited too long for a slot
+	ErrQueueTimeout = errors.New("timed out waiting for a request slot")
+)
+
+// Scheduler limits the requests served at once. While every slot is taken,
+// requests wait in a bounded queue per class and freed slots go to the
+// highest class waiting. Bulk requests hold at most half the slots, so the
+// rest stay available to other traffic.
+type Scheduler struct {
+	limit     int
+	bulkLimit int
+	queueSize [numClasses]int
+	timeout   time.Duration
+
+	mu           sync.Mutex
+	inFlight     int
+	bulkInFlight int
+	queues       [numClasses][]chan struct{}
+}
+
+// NewScheduler creates a scheduler serving limit requests at once. Each
+// class queues at most queueSizes[class] requests, for at most timeout.
+func NewScheduler(limit int, queueSizes map[Class]int, timeout time.Duration) *Scheduler {
+	if limit < 1 {
+		limit = 1
+	}
+	s := &Scheduler{
+		limit:     limit,
+		bulkLimit: (limit + 1) / 2,
+		timeout:   timeout,
+	}
+	for class, size := range queueSizes {
+		s.queueSize[class] = size
+	}
+	return s
+}
+
+// Acquire waits for a slot for a request of class and returns the function
+// that frees it. It fails at once if the class's queue is full, and after
+// the queue timeout or when ctx is done.
+func (s *Scheduler) Acquire(ctx context.Context, class Class) (func(), error) {
+	release := func() { s.release(class) }
+
+	s.mu.Lock()
+	if s.canRun(class) && !s.waitingAhead(class) {
+		s.take(class)
+		s.mu.Unlock()
+		return release, nil
+	}
+	if len(s.queues[class]) >= s.queueSize[class] {
+		s.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	ready := make(chan struct{})
+	s.queues[class] = append(s.queues[class], ready)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-ready:
+		return release, nil
+	case <-timer.C:
+		err = ErrQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dequeue(class, ready) {
+		// The slot was handed over while giving up; keep it
+		return release, nil
+	}
+	return nil, err
+}
+
+// Queued returns the number of requests of class waiting for a slot
+func (s *Scheduler) Queued(class Class) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queues[class])
+}
+
+// canRun reports whether a slot is free for class
+func (s *Scheduler) canRun(class Class) bool {
+	if class == Bulk && s.bulkInFlight >= s.bulkLimit {
+		return false
+	}
+	return s.inFlight < s.limit
+}
+
+// waitingAhead reports whether requests of class or a higher one are queued
+func (s *Scheduler) waitingAhead(class Class) bool {
+	for c := Critical; c <= class; c++ {
+		if len(s.queues[c]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scheduler) take(class Class) {
+	s.inFlight++
+	if class == Bulk {
+		s.bulkInFlight++
+	}
+}
+
+// release frees the slot of a request of class and hands free slots to the
+// queued requests of the highest classes
+func (s *Scheduler) release(class Class) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+	if class == Bulk {
+		s.bulkInFlight--
+	}
+	for c := Critical; c <= Bulk; c++ {
+		for len(s.queues[c]) > 0 && s.canRun(c) {
+			ready := s.queues[c][0]
+			s.queues[c] = s.queues[c][1:]
+			s.take(c)
+			close(ready)
+		}
+	}
+}
+
+// dequeue removes ready from the queue of class, reporting whether it was
+// still queued
+func (s *Scheduler) dequeue(class Class, ready chan struct{}) bool {
+	queue := s.queues[class]
+	for i, r := range queue {
+		if r == ready {
+			s.queues[class] = append(queue[:i:i], queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/cache"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Cached is a GCRA limiter whose state lives in a cache shared by every
+// instance, for caches such as memcached that cannot run the Redis script.
+// Each key's theoretical arrival time is read and written separately, so
+// concurrent requests from one client can overshoot the limit slightly, and
+// instances must have synchronized clocks. While the cache fails, decisions
+// fall back to a per-instance limiter.
+type Cached struct {
+	cache    cache.Cache
+	emission time.Duration
+	burst    int
+	fallback Limiter
+
+	// degraded is set while the cache is failing, so the switch to and from
+	// the fallback is logged once rather than on every request
+	degraded atomic.Bool
+}
+
+// NewCached creates a limiter allowing rate requests per second with bursts
+// of up to burst requests. fallback decides while c is unavailable.
+func NewCached(c cache.Cache, rate float64, burst int, fallback Limiter) *Cached {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Cached{
+		cache:    c,
+		emission: time.Duration(float64(time.Second) / rate),
+		burst:    burst,
+		fallback: fallback,
+	}
+}
+
+// Allow applies GCRA to key's stored arrival time, or asks the fallback if
+// the cache fails
+func (l *Cached) Allow(ctx context.Context, key string) (Result, error) {
+	now := time.Now()
+	stored, ok, err := l.cache.Get(ctx, keyPrefix+key)
+	if err != nil {
+		return l.degrade(ctx, key, err)
+	}
+
+	tat := now
+	if ok && len(stored) == 8 {
+		if stored := time.Unix(0, int64(binary.BigEndian.Uint64(stored))); stored.After(now) {
+			tat = stored
+		}
+	}
+
+	newTAT := tat.Add(l.emission)
+	allowAt := newTAT.Add(-l.emission * time.Duration(l.burst))
+	if allowAt.After(now) {
+		return l.recovered(ctx, Result{Limit: l.burst, RetryAfter: allowAt.Sub(now)}), nil
+	}
+
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(newTAT.UnixNano()))
+	if err := l.cache.Set(ctx, keyPrefix+key, value, newTAT.Sub(now)); err != nil {
+		return l.degrade(ctx, key, err)
+	}
+
+	return l.recovered(ctx, Result{
+		Allowed:   true,
+		Limit:     l.burst,
+		Remaining: int(now.Sub(allowAt) / l.emission),
+	}), nil
+}
+
+// degrade logs the first cache failure and lets the fallback decide
+func (l *Cached) degrade(ctx context.Context, key string, err error) (Result, error) {
+	if !l.degraded.Swap(true) {
+		logger.FromContext(ctx).Warn("cache rate limiter unavailable, limiting per instance", zap.Error(err))
+	}
+	return l.fallback.Allow(ctx, key)
+}
+
+// recovered logs that the cache works again and returns result
+func (l *Cached) recovered(ctx context.Context, result Result) Result {
+	if l.degraded.Swap(false) {
+		logger.FromContext(ctx).Info("cache rate limiter recovered")
+	}
+	return result
+}
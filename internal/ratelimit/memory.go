@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are dropped from memory
+const sweepInterval = time.Minute
+
+// Memory is a token bucket limiter that keeps one bucket per key in process
+// memory. Limits are per instance, so they do not hold across replicas.
+type Memory struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket holds the tokens left for one key as of last
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemory creates a limiter that refills rate tokens per second into
+// buckets holding at most burst tokens
+func NewMemory(rate float64, burst int) *Memory {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Memory{
+		rate:      rate,
+		burst:     burst,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from key's bucket if one is available
+func (m *Memory) Allow(ctx context.Context, key string) (Result, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(m.burst), last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = m.refill(b, now)
+	b.last = now
+
+	result := Result{Limit: m.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+		result.Remaining = int(b.tokens)
+		return result, nil
+	}
+
+	result.RetryAfter = time.Duration((1 - b.tokens) / m.rate * float64(time.Second))
+	return result, nil
+}
+
+// refill returns the tokens in b at now, capped at the burst size
+func (m *Memory) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last).Seconds()
+	return math.Min(float64(m.burst), b.tokens+elapsed*m.rate)
+}
+
+// sweep drops buckets that have refilled completely, since a new bucket
+// would start out the same
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if m.refill(b, now) >= float64(m.burst) {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
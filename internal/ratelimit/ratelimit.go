@@ -0,0 +1,23 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limiter decides whether a client identified by key may make another
+// request. Implementations must be safe for concurrent use.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// Result is a limiter's decision for one request
+type Result struct {
+	Allowed bool
+	// Limit is the burst size, the most requests a client can make at once
+	Limit int
+	// Remaining is the number of requests the client can still make at once
+	Remaining int
+	// RetryAfter is how long a rejected client must wait for its next request
+	RetryAfter time.Duration
+}
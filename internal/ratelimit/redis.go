@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// keyPrefix namespaces limiter keys in a shared Redis
+const keyPrefix = "ratelimit:"
+
+// gcraScript applies the generic cell rate algorithm. Each key stores its
+// theoretical arrival time (TAT) in microseconds on the Redis clock, so all
+// instances agree on time. ARGV is the emission interval and burst size; the
+// result is {allowed, remaining, retry after in microseconds}.
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if tat == nil or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission
+local allow_at = new_tat - emission * burst
+if allow_at > now then
+	return {0, 0, allow_at - now}
+end
+
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / emission), 0}
+`)
+
+// Redis is a GCRA limiter whose state lives in Redis, so limits hold across
+// every instance sharing it. While Redis is unreachable, decisions fall back
+// to a per-instance limiter.
+type Redis struct {
+	client   redis.UniversalClient
+	emission time.Duration
+	burst    int
+	fallback Limiter
+
+	// degraded is set while Redis is failing, so the switch to and from the
+	// fallback is logged once rather than on every request
+	degraded atomic.Bool
+}
+
+// NewRedis creates a limiter allowing rate requests per second with bursts
+// of up to burst requests. fallback decides while Redis is unavailable.
+func NewRedis(client redis.UniversalClient, rate float64, burst int, fallback Limiter) *Redis {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Redis{
+		client:   client,
+		emission: time.Duration(float64(time.Second) / rate),
+		burst:    burst,
+		fallback: fallback,
+	}
+}
+
+// Allow runs the GCRA script for key, or asks the fallback if Redis fails
+func (l *Redis) Allow(ctx context.Context, key string) (Result, error) {
+	values, err := gcraScript.Run(ctx, l.client, []string{keyPrefix + key},
+		l.emission.Microseconds(), l.burst).Int64Slice()
+	if err != nil {
+		if !l.degraded.Swap(true) {
+			logger.FromContext(ctx).Warn("redis rate limiter unavailable, limiting per instance", zap.Error(err))
+		}
+		return l.fallback.Allow(ctx, key)
+	}
+	if l.degraded.Swap(false) {
+		logger.FromContext(ctx).Info("redis rate limiter recovered")
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      l.burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,362 @@
+// Package realtime pushes user change events to WebSocket clients
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"go.uber.org/zap"
+)
+
+// Topics clients subscribe to
+const (
+	// TopicUsers carries changes to every user; only admins may subscribe
+	TopicUsers = "users"
+	// UserTopicPrefix followed by a user's public ID carries changes to that
+	// user
+	UserTopicPrefix = "users/"
+)
+
+// Actions of a models.LiveCommand
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+)
+
+const (
+	// maxCommandSize is the largest message read from a client, in bytes
+	maxCommandSize = 4 << 10
+	// writeWait bounds writing one message or control frame to a client
+	writeWait = 10 * time.Second
+	// shutdownReason is sent with the close frame at shutdown
+	shutdownReason = "server shutting down, reconnect"
+)
+
+// errForbiddenTopic is returned for topics the subscriber may not watch
+var errForbiddenTopic = errors.New("not allowed to subscribe to this topic")
+
+// Subscriber is who a connection acts for
+type Subscriber struct {
+	UserID int
+	Admin  bool
+}
+
+// Hub tracks WebSocket clients and their subscriptions and fans user change
+// events out to them
+type Hub struct {
+	cfg    config.LiveConfig
+	ids    idcodec.Codec
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
+	closing bool
+	// shutdown is closed when Shutdown starts, stopping writes other than
+	// the close frame
+	shutdown chan struct{}
+}
+
+// NewHub creates a hub with no clients, naming user topics by the IDs
+// codec encodes
+func NewHub(cfg config.LiveConfig, ids idcodec.Codec, logger *zap.Logger) *Hub {
+	if cfg.SendBuffer < 1 {
+		cfg.SendBuffer = 1
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 30 * time.Second
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Hub{
+		cfg:      cfg,
+		ids:      ids,
+		logger:   logger,
+		clients:  make(map[*client]struct{}),
+		shutdown: make(chan struct{}),
+	}
+}
+
+// Closing reports whether Shutdown has started; no clients are added after
+func (h *Hub) Closing() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.closing
+}
+
+// Clients returns how many clients are connected
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Add serves conn for sub in the background until either end closes it.
+// Admins start subscribed to every user and other users to themselves.
+func (h *Hub) Add(conn *websocket.Conn, sub Subscriber) {
+	c := &client{
+		hub:    h,
+		conn:   conn,
+		sub:    sub,
+		send:   make(chan []byte, h.cfg.SendBuffer),
+		done:   make(chan struct{}),
+		topics: map[string]bool{h.initialTopic(sub): true},
+	}
+
+	h.mu.Lock()
+	if h.closing {
+		h.mu.Unlock()
+		c.close(websocket.CloseGoingAway, shutdownReason)
+		return
+	}
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	go c.writeLoop()
+	go c.readLoop()
+}
+
+// remove forgets a disconnected client
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, c)
+}
+
+// Publish sends event, a change to the user with userID carrying data, to
+// the clients subscribed to it. It never blocks: clients too slow to keep up
+// are disconnected.
+func (h *Hub) Publish(event string, userID int, data interface{}) {
+	topic := UserTopicPrefix + h.ids.Encode(userID)
+	msg, err := json.Marshal(models.LiveMessage{Type: event, Topic: topic, Data: data, Time: time.Now().UTC()})
+	if err != nil {
+		h.logger.Error("failed to encode live update", zap.String("event", event), zap.Error(err))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closing {
+		return
+	}
+	for c := range h.clients {
+		if c.subscribed(TopicUsers) || c.subscribed(topic) {
+			c.enqueue(msg)
+		}
+	}
+}
+
+// Shutdown sends every client a going-away close frame advising it to
+// reconnect, which may reach another instance, and waits until ctx is done
+// for them to acknowledge it by closing their end. Connections still open
+// then are cut. It returns how many clients were notified and how many of
+// them were force-closed.
+func (h *Hub) Shutdown(ctx context.Context) (notified, forced int) {
+	h.mu.Lock()
+	if h.closing {
+		h.mu.Unlock()
+		return 0, 0
+	}
+	h.closing = true
+	close(h.shutdown)
+	clients := make([]*client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+
+	frame := websocket.FormatCloseMessage(websocket.CloseGoingAway, shutdownReason)
+	deadline := time.Now().Add(writeWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	for _, c := range clients {
+		// A client that stopped reading must not hold up the others
+		go c.conn.WriteControl(websocket.CloseMessage, frame, deadline)
+	}
+
+	for _, c := range clients {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			select {
+			case <-c.done:
+			default:
+				c.conn.Close()
+				forced++
+			}
+		}
+	}
+	return len(clients), forced
+}
+
+// initialTopic returns the topic sub is subscribed to on connecting
+func (h *Hub) initialTopic(sub Subscriber) string {
+	if sub.Admin {
+		return TopicUsers
+	}
+	return UserTopicPrefix + h.ids.Encode(sub.UserID)
+}
+
+// authorizeTopic returns topic in canonical form if sub may subscribe to it
+func (h *Hub) authorizeTopic(sub Subscriber, topic string) (string, error) {
+	if topic == TopicUsers {
+		if !sub.Admin {
+			return "", errForbiddenTopic
+		}
+		return topic, nil
+	}
+
+	publicID, ok := strings.CutPrefix(topic, UserTopicPrefix)
+	if !ok {
+		return "", errors.New("unknown topic")
+	}
+	userID, err := h.ids.Decode(publicID)
+	if err != nil {
+		return "", errors.New("unknown topic")
+	}
+	if !sub.Admin && userID != sub.UserID {
+		return "", errForbiddenTopic
+	}
+	return UserTopicPrefix + h.ids.Encode(userID), nil
+}
+
+// client is one WebSocket connection
+type client struct {
+	hub  *Hub
+	conn *websocket.Conn
+	sub  Subscriber
+	// send holds encoded messages waiting to be written
+	send chan []byte
+	// done is closed once the connection is closed, by either end
+	done chan struct{}
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+// subscribed reports whether the client watches topic
+func (c *client) subscribed(topic string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.topics[topic]
+}
+
+// enqueue queues msg, disconnecting the client if its queue is full
+func (c *client) enqueue(msg []byte) {
+	select {
+	case c.send <- msg:
+	default:
+		go c.close(websocket.CloseTryAgainLater, "client too slow")
+	}
+}
+
+// reply queues a message answering a command
+func (c *client) reply(msg models.LiveMessage) {
+	msg.Time = time.Now().UTC()
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	c.enqueue(encoded)
+}
+
+// close sends a close frame and closes the connection without waiting for
+// the client to acknowledge it
+func (c *client) close(code int, reason string) {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	c.conn.Close()
+}
+
+// readLoop handles commands until the connection closes. Reading also
+// processes control frames: pongs and the client's close frame.
+func (c *client) readLoop() {
+	defer func() {
+		c.hub.remove(c)
+		c.conn.Close()
+		close(c.done)
+	}()
+
+	pongWait := 2 * c.hub.cfg.PingInterval
+	c.conn.SetReadLimit(maxCommandSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.handle(data)
+	}
+}
+
+// handle applies a subscription command
+func (c *client) handle(data []byte) {
+	var cmd models.LiveCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		c.reply(models.LiveMessage{Type: models.LiveError, Message: "invalid JSON command"})
+		return
+	}
+	if cmd.Action != ActionSubscribe && cmd.Action != ActionUnsubscribe {
+		c.reply(models.LiveMessage{Type: models.LiveError, Message: "unknown action: " + cmd.Action})
+		return
+	}
+
+	topic, err := c.hub.authorizeTopic(c.sub, cmd.Topic)
+	if err != nil {
+		c.reply(models.LiveMessage{Type: models.LiveError, Topic: cmd.Topic, Message: err.Error()})
+		return
+	}
+
+	c.mu.Lock()
+	if cmd.Action == ActionSubscribe {
+		c.topics[topic] = true
+	} else {
+		delete(c.topics, topic)
+	}
+	c.mu.Unlock()
+
+	replyType := models.LiveSubscribed
+	if cmd.Action == ActionUnsubscribe {
+		replyType = models.LiveUnsubscribed
+	}
+	c.reply(models.LiveMessage{Type: replyType, Topic: topic})
+}
+
+// writeLoop writes queued messages and pings until the connection closes or
+// the hub shuts down
+func (c *client) writeLoop() {
+	ticker := time.NewTicker(c.hub.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.hub.shutdown:
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// AgeTransitionName identifies the move from users.age to users.date_of_birth
+const AgeTransitionName = "age-to-date-of-birth"
+
+// ageSelect returns the expression users' ages are read from. Once reads
+// move to date_of_birth, ages are current rather than as of the last write;
+// rows the backfill has not reached fall back to the age column.
+func (r *userRepository) ageSelect() string {
+	if !r.agePhase.ReadsNew() {
+		return "age"
+	}
+	switch r.dialect {
+	case DialectMySQL:
+		return "COALESCE(TIMESTAMPDIFF(YEAR, date_of_birth, CURRENT_DATE), age)"
+	case DialectSQLite:
+		// Whole years between the dates, as the difference of YYYY.MMDD
+		return "COALESCE(CAST(strftime('%Y.%m%d', 'now') - strftime('%Y.%m%d', date_of_birth) AS INTEGER), age)"
+	default:
+		return "COALESCE(date_part('year', age(date_of_birth))::int, age)"
+	}
+}
+
+// dateOfBirth returns the birth date implied by the age in param as of today
+func (d Dialect) dateOfBirth(param string) string {
+	switch d {
+	case DialectMySQL:
+		return fmt.Sprintf("DATE_SUB(CURRENT_DATE, INTERVAL %s YEAR)", param)
+	case DialectSQLite:
+		return fmt.Sprintf("date('now', '-' || %s || ' years')", param)
+	}
+	return fmt.Sprintf("(CURRENT_DATE - make_interval(years => %s::int))::date", param)
+}
+
+// ageInsert returns the columns and values that store the age in param
+func (r *userRepository) ageInsert(param string) (columns, values string) {
+	switch {
+	case !r.agePhase.WritesNew():
+		return "age", param
+	case !r.agePhase.WritesOld():
+		return "date_of_birth", r.dialect.dateOfBirth(param)
+	default:
+		return "age, date_of_birth", param + ", " + r.dialect.dateOfBirth(param)
+	}
+}
+
+// ageAssign returns the SET assignments that store the age in param. With
+// keepNull a NULL param leaves the stored values unchanged.
+func (r *userRepository) ageAssign(param string, keepNull bool) string {
+	age, dob := "age = "+param, "date_of_birth = "+r.dialect.dateOfBirth(param)
+	if keepNull {
+		age = fmt.Sprintf("age = COALESCE(%s, age)", param)
+		dob = fmt.Sprintf("date_of_birth = COALESCE(%s, date_of_birth)", r.dialect.dateOfBirth(param))
+	}
+
+	switch {
+	case !r.agePhase.WritesNew():
+		return age
+	case !r.agePhase.WritesOld():
+		return dob
+	default:
+		return age + ", " + dob
+	}
+}
+
+// ageTransition backfills and checks users.date_of_birth against users.age.
+// A date of birth is derived from the age on the day it is written, so for a
+// consistent row, date_of_birth plus age years falls within the row's
+// lifetime. The extra day allows for birthdays on February 29.
+type ageTransition struct {
+	db      *sql.DB
+	dialect Dialect
+	phase   models.TransitionPhase
+}
+
+// NewAgeTransition creates the transition from users.age to
+// users.date_of_birth of the users stored in db as schema describes
+func NewAgeTransition(db *sql.DB, schema UserSchema) SchemaTransition {
+	schema = schema.withDefaults()
+	return &ageTransition{db: db, dialect: schema.Dialect, phase: schema.AgePhase}
+}
+
+// Name returns AgeTransitionName
+func (t *ageTransition) Name() string {
+	return AgeTransitionName
+}
+
+// Phase returns the phase user queries follow
+func (t *ageTransition) Phase() models.TransitionPhase {
+	return t.phase
+}
+
+// Backfill derives date_of_birth for up to batchSize users written before
+// dual-writes began. Rows locked by concurrent writes are skipped; those
+// writes fill the column themselves.
+func (t *ageTransition) Backfill(ctx context.Context, batchSize int) (int64, error) {
+	query := fmt.Sprintf(`
+		UPDATE users
+		SET date_of_birth = %s
+		WHERE id IN (
+			SELECT id FROM users
+			WHERE date_of_birth IS NULL AND age IS NOT NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+	`, t.dialect.dateOfBirth("age"))
+
+	result, err := t.db.ExecContext(ctx, query, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to backfill date of birth: %w", err)
+	}
+
+	filled, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return filled, nil
+}
+
+// Check counts users whose date_of_birth is missing or disagrees with their
+// age, listing up to sampleSize of the disagreeing ones
+func (t *ageTransition) Check(ctx context.Context, sampleSize int) (*models.TransitionReport, error) {
+	mismatch := `date_of_birth IS NOT NULL AND age IS NOT NULL
+		AND (date_of_birth + make_interval(years => age))::date
+			NOT BETWEEN created_at::date - 1 AND updated_at::date`
+
+	report := &models.TransitionReport{Name: t.Name(), Phase: t.Phase()}
+	query := fmt.Sprintf(`
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE date_of_birth IS NULL AND age IS NOT NULL),
+			COUNT(*) FILTER (WHERE %s)
+		FROM users
+	`, mismatch)
+	err := t.db.QueryRowContext(ctx, query).Scan(&report.Checked, &report.Missing, &report.Mismatched)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check date of birth: %w", err)
+	}
+
+	if report.Mismatched > 0 {
+		rows, err := t.db.QueryContext(ctx, fmt.Sprintf(`SELECT id FROM users WHERE %s ORDER BY id LIMIT $1`, mismatch), sampleSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list mismatched users: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				return nil, fmt.Errorf("failed to scan user ID: %w", err)
+			}
+			report.MismatchedIDs = append(report.MismatchedIDs, id)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("rows iteration error: %w", err)
+		}
+	}
+
+	report.Consistent = report.Missing == 0 && report.Mismatched == 0
+	return report, nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// approvalColumns are the columns scanned by scanApproval, in order, from
+// registration_approvals a joined with users u
+const approvalColumns = `a.user_id, u.name, u.email, a.status, a.reason, a.decided_by, a.decided_at, a.created_at, a.updated_at`
+
+// approvalRepository implements ApprovalRepository interface
+type approvalRepository struct {
+	db *sql.DB
+}
+
+// NewApprovalRepository creates a new registration approval repository
+func NewApprovalRepository(db *sql.DB) ApprovalRepository {
+	return &approvalRepository{db: db}
+}
+
+// Create puts a user in the approval queue
+func (r *approvalRepository) Create(ctx context.Context, userID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO registration_approvals (user_id) VALUES ($1)`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to create registration approval: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves the approval of a user
+func (r *approvalRepository) Get(ctx context.Context, userID int) (*models.Approval, error) {
+	query := `
+		SELECT ` + approvalColumns + `
+		FROM registration_approvals a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.user_id = $1`
+
+	approval, err := scanApproval(conn(ctx, r.db).QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("registration approval not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registration approval: %w", err)
+	}
+	return approval, nil
+}
+
+// ListPending retrieves up to limit pending approvals of users with an ID
+// above afterUserID, oldest first
+func (r *approvalRepository) ListPending(ctx context.Context, afterUserID, limit int) ([]*models.Approval, error) {
+	query := `
+		SELECT ` + approvalColumns + `
+		FROM registration_approvals a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.status = 'pending' AND a.user_id > $1
+		ORDER BY a.user_id
+		LIMIT $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, afterUserID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registration approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []*models.Approval
+	for rows.Next() {
+		approval, err := scanApproval(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan registration approval: %w", err)
+		}
+		approvals = append(approvals, approval)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return approvals, nil
+}
+
+// Decide approves or rejects a pending registration. A registration already
+// decided is a conflict, so two admins cannot both decide it.
+func (r *approvalRepository) Decide(ctx context.Context, userID int, status, reason string, decidedBy *int) (*models.Approval, error) {
+	query := `
+		WITH decided AS (
+			UPDATE registration_approvals
+			SET status = $2, reason = $3, decided_by = $4, decided_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND status = 'pending'
+			RETURNING *
+		)
+		SELECT ` + approvalColumns + `
+		FROM decided a
+		JOIN users u ON u.id = a.user_id`
+
+	approval, err := scanApproval(conn(ctx, r.db).QueryRowContext(ctx, query, userID, status, reason, decidedBy))
+	if err == sql.ErrNoRows {
+		if _, err := r.Get(ctx, userID); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.Conflict("registration has already been decided")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide registration approval: %w", err)
+	}
+	return approval, nil
+}
+
+// scanApproval scans a row of approvalColumns
+func scanApproval(row interface{ Scan(...interface{}) error }) (*models.Approval, error) {
+	approval := &models.Approval{}
+	var decidedBy sql.NullInt64
+	err := row.Scan(
+		&approval.UserID,
+		&approval.Name,
+		&approval.Email,
+		&approval.Status,
+		&approval.Reason,
+		&decidedBy,
+		&approval.DecidedAt,
+		&approval.CreatedAt,
+		&approval.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if decidedBy.Valid {
+		id := int(decidedBy.Int64)
+		approval.DecidedBy = &id
+	}
+	return approval, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// arrayTypes decodes Postgres arrays scanned through database/sql. A Map
+// caches scan plans and is not safe for concurrent use.
+var (
+	arrayTypesMu sync.Mutex
+	arrayTypes   = pgtype.NewMap()
+)
+
+// stringArray scans a Postgres text[] into dest. Slices are passed as
+// arguments directly; the driver encodes them.
+type stringArray struct {
+	dest *[]string
+}
+
+// Scan implements sql.Scanner
+func (a stringArray) Scan(src interface{}) error {
+	arrayTypesMu.Lock()
+	defer arrayTypesMu.Unlock()
+	return arrayTypes.SQLScanner(a.dest).Scan(src)
+}
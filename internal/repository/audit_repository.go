@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// auditRepository implements AuditRepository interface
+type auditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Append stores entry after the newest entry. The table lock serializes
+// appends so two entries can never claim the same predecessor. Within a
+// transaction from a TxManager, the entry commits or rolls back with it and
+// the lock is held until it ends.
+func (r *auditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	if tx := txFromContext(ctx); tx != nil {
+		return r.append(ctx, tx, entry)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin audit transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.append(ctx, tx, entry); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit entry: %w", err)
+	}
+	return nil
+}
+
+// append stores entry within tx
+func (r *auditRepository) append(ctx context.Context, tx *sql.Tx, entry *models.AuditEntry) error {
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE audit_log IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+
+	var prevHash string
+	err := tx.QueryRowContext(ctx, `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get latest audit entry: %w", err)
+	}
+
+	entry.PrevHash = prevHash
+	entry.Hash = entry.ComputeHash()
+
+	query := `
+		INSERT INTO audit_log (action, actor_id, target_id, status, created_at, prev_hash, hash,
+			impersonator_id, tenant_id, api_key_id, ip, signature, signed_payload, signing_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		entry.Action,
+		entry.ActorID,
+		entry.TargetID,
+		entry.Status,
+		entry.CreatedAt,
+		entry.PrevHash,
+		entry.Hash,
+		entry.ImpersonatorID,
+		entry.TenantID,
+		entry.APIKeyID,
+		entry.IP,
+		entry.Signature,
+		entry.SignedPayload,
+		entry.SigningKey,
+	).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return nil
+}
+
+// List retrieves up to limit entries with an ID above afterID, oldest first
+func (r *auditRepository) List(ctx context.Context, afterID int64, limit int) ([]*models.AuditEntry, error) {
+	query := `
+		SELECT id, action, actor_id, target_id, status, created_at, prev_hash, hash,
+			impersonator_id, tenant_id, api_key_id, ip, signature, signed_payload, signing_key
+		FROM audit_log
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		entry := &models.AuditEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Action,
+			&entry.ActorID,
+			&entry.TargetID,
+			&entry.Status,
+			&entry.CreatedAt,
+			&entry.PrevHash,
+			&entry.Hash,
+			&entry.ImpersonatorID,
+			&entry.TenantID,
+			&entry.APIKeyID,
+			&entry.IP,
+			&entry.Signature,
+			&entry.SignedPayload,
+			&entry.SigningKey,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return entries, nil
+}
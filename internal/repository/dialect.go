@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pratham15541/go-crud/internal/models"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Dialect is the SQL dialect of the database users are stored in
+type Dialect string
+
+// Dialects
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// Error codes of constraint violations
+const (
+	postgresUniqueViolation = "23505"
+	postgresCheckViolation  = "23514"
+	mysqlDuplicateKey       = 1062
+	mysqlCheckViolation     = 3819
+)
+
+// placeholder matches a Postgres bind parameter
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+// UserSchema describes the database passed to NewUserRepository
+type UserSchema struct {
+	// Dialect is the SQL dialect of the database, DialectPostgres when
+	// empty. The other repositories only support Postgres.
+	Dialect Dialect
+	// AgePhase sets which of users.age and users.date_of_birth queries
+	// write and read, PhaseOld when empty
+	AgePhase models.TransitionPhase
+}
+
+// withDefaults returns s with unset fields filled in
+func (s UserSchema) withDefaults() UserSchema {
+	if s.Dialect == "" {
+		s.Dialect = DialectPostgres
+	}
+	if s.AgePhase == "" {
+		s.AgePhase = models.PhaseOld
+	}
+	return s
+}
+
+// mysqlDB runs queries written with Postgres placeholders on MySQL
+type mysqlDB struct {
+	DBTX
+}
+
+// ExecContext implements DBTX
+func (db mysqlDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, args = rebind(query, args)
+	return db.DBTX.ExecContext(ctx, query, args...)
+}
+
+// QueryContext implements DBTX
+func (db mysqlDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args = rebind(query, args)
+	return db.DBTX.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext implements DBTX
+func (db mysqlDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args = rebind(query, args)
+	return db.DBTX.QueryRowContext(ctx, query, args...)
+}
+
+// sqliteDB runs queries on SQLite, which stores times as text. Times are
+// bound in UTC so the text sorts in time order.
+type sqliteDB struct {
+	DBTX
+}
+
+// ExecContext implements DBTX
+func (db sqliteDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DBTX.ExecContext(ctx, query, utcTimes(args)...)
+}
+
+// QueryContext implements DBTX
+func (db sqliteDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DBTX.QueryContext(ctx, query, utcTimes(args)...)
+}
+
+// QueryRowContext implements DBTX
+func (db sqliteDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.DBTX.QueryRowContext(ctx, query, utcTimes(args)...)
+}
+
+// wrap wraps db so queries written for Postgres run on d
+func (d Dialect) wrap(db DBTX) DBTX {
+	switch d {
+	case DialectMySQL:
+		return mysqlDB{db}
+	case DialectSQLite:
+		return sqliteDB{db}
+	default:
+		return db
+	}
+}
+
+// utcTimes returns args with every time.Time converted to UTC
+func utcTimes(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok {
+			arg = t.UTC()
+		}
+		converted[i] = arg
+	}
+	return converted
+}
+
+// now returns the SQL expression for the current time
+func (d Dialect) now() string {
+	if d == DialectSQLite {
+		return "strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')"
+	}
+	return "NOW()"
+}
+
+// upsertOnEmail returns the clause that turns an INSERT into users into an
+// update of the live user with the same email, up to the SET list. On MySQL
+// it also makes LAST_INSERT_ID report the updated row, so it can be read back.
+func (d Dialect) upsertOnEmail() string {
+	if d == DialectMySQL {
+		return "ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id),"
+	}
+	return "ON CONFLICT (email) WHERE deleted_at IS NULL DO UPDATE SET"
+}
+
+// rebind rewrites the $N placeholders of query into ? placeholders, which
+// MySQL binds by position, ordering and repeating args to match
+func rebind(query string, args []interface{}) (string, []interface{}) {
+	var bound []interface{}
+	query = placeholder.ReplaceAllStringFunc(query, func(param string) string {
+		n, _ := strconv.Atoi(param[1:])
+		if n >= 1 && n <= len(args) {
+			bound = append(bound, args[n-1])
+		}
+		return "?"
+	})
+	return query, bound
+}
+
+// isDuplicateKey reports whether err is a unique key violation
+func isDuplicateKey(err error) bool {
+	var pgErr *pgconn.PgError
+	var mysqlErr *mysql.MySQLError
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &pgErr) && pgErr.Code == postgresUniqueViolation ||
+		errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateKey ||
+		errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// isCheckViolation reports whether err is a CHECK constraint violation
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	var mysqlErr *mysql.MySQLError
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &pgErr) && pgErr.Code == postgresCheckViolation ||
+		errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlCheckViolation ||
+		errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_CHECK
+}
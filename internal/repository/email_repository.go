@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// emailColumns are the columns scanned by scanEmail, in order
+const emailColumns = `id, message_id, recipient, subject, body, status, attempts, last_error,
+	next_attempt_at, sent_at, created_at, updated_at`
+
+// emailRepository implements EmailRepository interface
+type emailRepository struct {
+	db *sql.DB
+}
+
+// NewEmailRepository creates a new email outbox repository
+func NewEmailRepository(db *sql.DB) EmailRepository {
+	return &emailRepository{db: db}
+}
+
+// Enqueue stores email for delivery. Within a transaction, the email is
+// only sent if the transaction commits.
+func (r *emailRepository) Enqueue(ctx context.Context, email *models.Email) error {
+	query := `
+		INSERT INTO email_outbox (message_id, recipient, subject, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + emailColumns
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, email.MessageID, email.Recipient, email.Subject, email.Body)
+	stored, err := scanEmail(row)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	*email = *stored
+	return nil
+}
+
+// ClaimDue claims due emails. SKIP LOCKED lets several workers claim at
+// once without waiting on or double-claiming each other's rows.
+func (r *emailRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.Email, error) {
+	query := `
+		UPDATE email_outbox
+		SET attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 microsecond'
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE status = 'queued' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + emailColumns
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, lease.Microseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim emails: %w", err)
+	}
+	return scanEmails(rows)
+}
+
+// MarkSent records that the provider accepted an email
+func (r *emailRepository) MarkSent(ctx context.Context, id int64) error {
+	query := `UPDATE email_outbox SET status = 'sent', sent_at = NOW(), last_error = '' WHERE id = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark email sent: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry records a failed attempt and schedules the next one
+func (r *emailRepository) MarkRetry(ctx context.Context, id int64, lastError string, next time.Time) error {
+	query := `UPDATE email_outbox SET last_error = $2, next_attempt_at = $3 WHERE id = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, lastError, next); err != nil {
+		return fmt.Errorf("failed to reschedule email: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records that an email will not be sent
+func (r *emailRepository) MarkFailed(ctx context.Context, id int64, lastError string) error {
+	query := `UPDATE email_outbox SET status = 'failed', last_error = $2 WHERE id = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, lastError); err != nil {
+		return fmt.Errorf("failed to mark email failed: %w", err)
+	}
+	return nil
+}
+
+// MarkSuppressed records that an email was not sent to a suppressed recipient
+func (r *emailRepository) MarkSuppressed(ctx context.Context, id int64, reason string) error {
+	query := `UPDATE email_outbox SET status = 'suppressed', last_error = $2 WHERE id = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("failed to mark email suppressed: %w", err)
+	}
+	return nil
+}
+
+// RequeueFailed queues failed emails for delivery now
+func (r *emailRepository) RequeueFailed(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE email_outbox
+		SET status = 'queued', attempts = 0, next_attempt_at = NOW()
+		WHERE status = 'failed'`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue failed emails: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ApplyEvent moves a sent email to the status the event reports. Bounces
+// and complaints are final, so a late delivery report does not override one.
+func (r *emailRepository) ApplyEvent(ctx context.Context, event models.EmailEvent) (*models.Email, error) {
+	query := `
+		UPDATE email_outbox
+		SET status = $2, last_error = $3
+		WHERE message_id = $1 AND status IN ('sent', 'delivered')
+		RETURNING ` + emailColumns
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, event.MessageID, event.Type, event.Reason)
+	email, err := scanEmail(row)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("email not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply email event: %w", err)
+	}
+	return email, nil
+}
+
+// List retrieves emails newest first
+func (r *emailRepository) List(ctx context.Context, status string, beforeID int64, limit int) ([]*models.Email, error) {
+	query := `
+		SELECT ` + emailColumns + `
+		FROM email_outbox
+		WHERE id < $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, beforeID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emails: %w", err)
+	}
+	return scanEmails(rows)
+}
+
+// Suppress stops sending to an address. Addresses are stored in lower case.
+func (r *emailRepository) Suppress(ctx context.Context, suppression *models.EmailSuppression) error {
+	query := `
+		INSERT INTO email_suppressions (email, reason, detail)
+		VALUES (LOWER($1), $2, $3)
+		ON CONFLICT (email) DO UPDATE SET reason = EXCLUDED.reason, detail = EXCLUDED.detail
+		RETURNING email, created_at, updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, suppression.Email, suppression.Reason, suppression.Detail).Scan(
+		&suppression.Email,
+		&suppression.CreatedAt,
+		&suppression.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to suppress email address: %w", err)
+	}
+	return nil
+}
+
+// GetSuppression retrieves the suppression of address
+func (r *emailRepository) GetSuppression(ctx context.Context, address string) (*models.EmailSuppression, error) {
+	query := `
+		SELECT email, reason, detail, created_at, updated_at
+		FROM email_suppressions
+		WHERE email = LOWER($1)
+	`
+
+	suppression := &models.EmailSuppression{}
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, address).Scan(
+		&suppression.Email,
+		&suppression.Reason,
+		&suppression.Detail,
+		&suppression.CreatedAt,
+		&suppression.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("suppression not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email suppression: %w", err)
+	}
+	return suppression, nil
+}
+
+// scanEmail scans a row of emailColumns
+func scanEmail(row interface{ Scan(...interface{}) error }) (*models.Email, error) {
+	email := &models.Email{}
+	err := row.Scan(
+		&email.ID,
+		&email.MessageID,
+		&email.Recipient,
+		&email.Subject,
+		&email.Body,
+		&email.Status,
+		&email.Attempts,
+		&email.LastError,
+		&email.NextAttemptAt,
+		&email.SentAt,
+		&email.CreatedAt,
+		&email.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return email, nil
+}
+
+// scanEmails scans and closes rows of emailColumns
+func scanEmails(rows *sql.Rows) ([]*models.Email, error) {
+	defer rows.Close()
+
+	var emails []*models.Email
+	for rows.Next() {
+		email, err := scanEmail(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return emails, nil
+}
@@ -1,21 +1,211 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/pratham15541/go-crud/internal/models"
 )
 
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
-	Create(user *models.CreateUserRequest) (*models.User, error)
-	GetByID(id int) (*models.User, error)
-	GetAll(limit, offset int) ([]*models.User, error)
-	Update(id int, user *models.UpdateUserRequest) (*models.User, error)
-	Delete(id int) error
-	GetByEmail(email string) (*models.User, error)
-	Count() (int64, error)
+	Create(ctx context.Context, user *models.CreateUserRequest) (*models.User, error)
+	// CreateBatch creates users in one transaction, returning the user or the
+	// error of each request by position; err reports the batch itself failing
+	CreateBatch(ctx context.Context, users []*models.CreateUserRequest) (created []*models.User, errs []error, err error)
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetAll(ctx context.Context, filter *models.UserFilter, limit, offset int) ([]*models.User, error)
+	GetAfter(ctx context.Context, filter *models.UserFilter, cursor *models.UserCursor, limit int) ([]*models.User, error)
+	// Export reads every matching user from one consistent snapshot
+	Export(ctx context.Context, filter *models.UserFilter, batchSize int, fn func([]*models.User) error) error
+	// Upsert creates the live user with user.Email or updates its name and
+	// age, reporting whether it was created
+	Upsert(ctx context.Context, user *models.CreateUserRequest) (*models.User, bool, error)
+	Update(ctx context.Context, id int, user *models.UpdateUserRequest) (*models.User, error)
+	Patch(ctx context.Context, id int, patch *models.PatchUserRequest) (*models.User, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (*models.User, error)
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
+	SetLegalHold(ctx context.Context, id int, hold bool) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Count(ctx context.Context, filter *models.UserFilter) (int64, error)
+	// RecentlyActive returns up to limit users, most recently active first
+	RecentlyActive(ctx context.Context, limit int) ([]*models.User, error)
+	CreateWithPassword(ctx context.Context, user *models.CreateUserRequest, passwordHash string) (*models.User, error)
+	GetCredentials(ctx context.Context, email string) (*models.User, error)
+}
+
+// SettingsRepository defines the interface for versioned user settings documents
+type SettingsRepository interface {
+	Get(ctx context.Context, userID int) (*models.UserSettings, error)
+	// Save creates or replaces settings unless the stored document has a
+	// schema version above maxVersion
+	Save(ctx context.Context, settings *models.UserSettings, maxVersion int) error
+	// Upgrade replaces settings still stored at fromVersion
+	Upgrade(ctx context.Context, settings *models.UserSettings, fromVersion int) (bool, error)
+}
+
+// SchemaTransition moves data from an old column to its replacement during
+// an expand/contract migration
+type SchemaTransition interface {
+	Name() string
+	Phase() models.TransitionPhase
+	// Backfill fills the new column for up to batchSize rows that were
+	// written before dual-writes began and returns how many it filled
+	Backfill(ctx context.Context, batchSize int) (int64, error)
+	// Check compares the old and new columns of every row, listing up to
+	// sampleSize mismatched rows
+	Check(ctx context.Context, sampleSize int) (*models.TransitionReport, error)
 }
 
 // HealthRepository defines the interface for health check operations
 type HealthRepository interface {
-	Ping() error
-}
\ No newline at end of file
+	Ping(ctx context.Context) error
+}
+
+// OAuthRepository defines the interface for OpenID Connect client and code storage
+type OAuthRepository interface {
+	CreateClient(ctx context.Context, client *models.OAuthClient) (*models.OAuthClient, error)
+	GetClient(ctx context.Context, id string) (*models.OAuthClient, error)
+	SaveAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error)
+	SaveDeviceCode(ctx context.Context, code *models.DeviceCode) error
+	GetDeviceCode(ctx context.Context, deviceCodeHash string) (*models.DeviceCode, error)
+	GetDeviceCodeByUserCode(ctx context.Context, userCode string) (*models.DeviceCode, error)
+	UpdateDeviceCodeStatus(ctx context.Context, deviceCodeHash, status string, userID *int) error
+	TouchDeviceCode(ctx context.Context, deviceCodeHash string, interval int) error
+	DeleteDeviceCode(ctx context.Context, deviceCodeHash string) error
+}
+
+// PersonalTokenRepository defines the interface for personal access token storage
+type PersonalTokenRepository interface {
+	Create(ctx context.Context, token *models.PersonalToken) (*models.PersonalToken, error)
+	ListByUser(ctx context.Context, userID int) ([]*models.PersonalToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*models.PersonalToken, error)
+	Revoke(ctx context.Context, userID, id int) error
+	SetSigningKey(ctx context.Context, userID, id int, publicKey string) error
+	TouchLastUsed(ctx context.Context, id int) error
+}
+
+// WriteAheadRepository durably stores create requests accepted while the
+// database is unavailable
+type WriteAheadRepository interface {
+	Save(ctx context.Context, write *models.QueuedWrite) error
+	Get(ctx context.Context, id string) (*models.QueuedWrite, error)
+	ListPending(ctx context.Context) ([]*models.QueuedWrite, error)
+}
+
+// OperationRepository defines the interface for long-running operation tracking
+type OperationRepository interface {
+	Create(ctx context.Context, op *models.Operation) error
+	Get(ctx context.Context, id string) (*models.Operation, error)
+	Update(ctx context.Context, op *models.Operation) error
+	FailInterrupted(ctx context.Context) (int64, error)
+}
+
+// AuditRepository defines the interface for the append-only audit log
+type AuditRepository interface {
+	// Append links entry to the newest entry and stores it
+	Append(ctx context.Context, entry *models.AuditEntry) error
+	List(ctx context.Context, afterID int64, limit int) ([]*models.AuditEntry, error)
+}
+
+// EmailRepository defines the interface for the outbound email outbox
+type EmailRepository interface {
+	// Enqueue stores email for delivery
+	Enqueue(ctx context.Context, email *models.Email) error
+	// ClaimDue returns up to limit queued emails that are due, counting an
+	// attempt for each and holding them back for lease so no other worker
+	// sends them meanwhile
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.Email, error)
+	MarkSent(ctx context.Context, id int64) error
+	// MarkRetry records a failed attempt and when to try again
+	MarkRetry(ctx context.Context, id int64, lastError string, next time.Time) error
+	MarkFailed(ctx context.Context, id int64, lastError string) error
+	// MarkSuppressed records that an email was not sent to a suppressed
+	// recipient
+	MarkSuppressed(ctx context.Context, id int64, reason string) error
+	// ApplyEvent records a provider notification about a sent email and
+	// returns the email
+	ApplyEvent(ctx context.Context, event models.EmailEvent) (*models.Email, error)
+	// List returns emails with an ID below beforeID, newest first,
+	// optionally only those with status
+	List(ctx context.Context, status string, beforeID int64, limit int) ([]*models.Email, error)
+	// Suppress stops sending to an address, replacing any earlier
+	// suppression's reason
+	Suppress(ctx context.Context, suppression *models.EmailSuppression) error
+	// GetSuppression returns the suppression of address, matched without
+	// regard to case
+	GetSuppression(ctx context.Context, address string) (*models.EmailSuppression, error)
+	// RequeueFailed queues every failed email again with its attempts reset
+	// and returns how many it queued
+	RequeueFailed(ctx context.Context) (int64, error)
+}
+
+// OutboxRepository defines the interface for the domain event outbox
+type OutboxRepository interface {
+	// Enqueue stores event for publishing
+	Enqueue(ctx context.Context, event *models.OutboxEvent) error
+	// ClaimDue returns up to limit unpublished events that are due, oldest
+	// first, counting an attempt for each and holding them back for lease so
+	// no other relay publishes them meanwhile
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int64) error
+	// MarkRetry records a failed attempt and when to try again
+	MarkRetry(ctx context.Context, id int64, lastError string, next time.Time) error
+	// DeletePublished removes events published before cutoff
+	DeletePublished(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// WebhookRepository defines the interface for webhook subscriptions
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	GetByID(ctx context.Context, id int64) (*models.Webhook, error)
+	List(ctx context.Context) ([]*models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	Delete(ctx context.Context, id int64) error
+	// ListForEvent retrieves the webhooks subscribed to event
+	ListForEvent(ctx context.Context, event string) ([]*models.Webhook, error)
+	// RecordFailure stores a delivery that ran out of attempts
+	RecordFailure(ctx context.Context, failure *models.WebhookFailure) error
+	// ListFailures retrieves up to limit failed deliveries with an ID above
+	// afterID, oldest first
+	ListFailures(ctx context.Context, afterID int64, limit int) ([]*models.WebhookFailure, error)
+	DeleteFailure(ctx context.Context, id int64) error
+}
+
+// JWTKeyRepository defines the interface for the keys JWTs are signed with
+// once rotated
+type JWTKeyRepository interface {
+	Create(ctx context.Context, key *models.JWTKey) error
+	// List retrieves every key, oldest first
+	List(ctx context.Context) ([]*models.JWTKey, error)
+	// DeleteSuperseded removes the keys a newer key replaced before cutoff
+	DeleteSuperseded(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// WatchRepository defines the interface for watches on users. Expired
+// watches are never returned.
+type WatchRepository interface {
+	Create(ctx context.Context, watch *models.Watch) error
+	GetByID(ctx context.Context, id int64) (*models.Watch, error)
+	// ListByOwner retrieves the watches an owner created
+	ListByOwner(ctx context.Context, ownerID int) ([]*models.Watch, error)
+	// ListForUser retrieves the watches on a user
+	ListForUser(ctx context.Context, userID int) ([]*models.Watch, error)
+	// UpdateSnapshot replaces the values a watch last saw
+	UpdateSnapshot(ctx context.Context, id int64, snapshot map[string]interface{}) error
+	Delete(ctx context.Context, id int64) error
+	// DeleteExpired removes watches that expired before now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ApprovalRepository defines the interface for registrations awaiting approval
+type ApprovalRepository interface {
+	Create(ctx context.Context, userID int) error
+	Get(ctx context.Context, userID int) (*models.Approval, error)
+	// ListPending retrieves a page of pending approvals, oldest first
+	ListPending(ctx context.Context, afterUserID, limit int) ([]*models.Approval, error)
+	// Decide approves or rejects a pending registration; decidedBy may be nil
+	Decide(ctx context.Context, userID int, status, reason string, decidedBy *int) (*models.Approval, error)
+}
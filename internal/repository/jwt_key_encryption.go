@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"go.uber.org/zap"
+)
+
+// encryptedSecretPrefix marks a secret stored encrypted. Secrets without it
+// were stored before an encryption key was set and are read as they are.
+const encryptedSecretPrefix = "enc:v1:"
+
+// encryptedJWTKeyRepository stores the secrets of JWT keys encrypted with
+// AES-GCM, so reading the jwt_keys table is not enough to forge tokens
+type encryptedJWTKeyRepository struct {
+	JWTKeyRepository
+	aead cipher.AEAD
+}
+
+// NewEncryptedJWTKeyRepository wraps repo so that secrets are encrypted with
+// a key derived from passphrase before they are stored
+func NewEncryptedJWTKeyRepository(repo JWTKeyRepository, passphrase string) (JWTKeyRepository, error) {
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwt key cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwt key cipher: %w", err)
+	}
+	return &encryptedJWTKeyRepository{JWTKeyRepository: repo, aead: aead}, nil
+}
+
+// Create stores key with its secret encrypted, leaving key itself in the clear
+func (r *encryptedJWTKeyRepository) Create(ctx context.Context, key *models.JWTKey) error {
+	nonce := make([]byte, r.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to encrypt jwt key: %w", err)
+	}
+	sealed := r.aead.Seal(nonce, nonce, []byte(key.Secret), []byte(key.ID))
+
+	stored := *key
+	stored.Secret = encryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed)
+	if err := r.JWTKeyRepository.Create(ctx, &stored); err != nil {
+		return err
+	}
+	key.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+// List retrieves every key with its secret decrypted. Keys that cannot be
+// decrypted, such as after the encryption key changed, are left out.
+func (r *encryptedJWTKeyRepository) List(ctx context.Context) ([]*models.JWTKey, error) {
+	keys, err := r.JWTKeyRepository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	readable := make([]*models.JWTKey, 0, len(keys))
+	for _, key := range keys {
+		secret, err := r.decrypt(key)
+		if err != nil {
+			logger.FromContext(ctx).Warn("skipping jwt key", zap.String("kid", key.ID), zap.Error(err))
+			continue
+		}
+		decrypted := *key
+		decrypted.Secret = secret
+		readable = append(readable, &decrypted)
+	}
+	return readable, nil
+}
+
+// decrypt returns the plaintext secret of key
+func (r *encryptedJWTKeyRepository) decrypt(key *models.JWTKey) (string, error) {
+	encoded, ok := strings.CutPrefix(key.Secret, encryptedSecretPrefix)
+	if !ok {
+		return key.Secret, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < r.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted secret")
+	}
+	nonce, ciphertext := sealed[:r.aead.NonceSize()], sealed[r.aead.NonceSize():]
+	secret, err := r.aead.Open(nil, nonce, ciphertext, []byte(key.ID))
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt secret, check JWT_KEY_ENCRYPTION_KEY")
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// jwtKeyRepository implements JWTKeyRepository interface
+type jwtKeyRepository struct {
+	db *sql.DB
+}
+
+// NewJWTKeyRepository creates a new JWT signing key repository
+func NewJWTKeyRepository(db *sql.DB) JWTKeyRepository {
+	return &jwtKeyRepository{db: db}
+}
+
+// Create stores a new key
+func (r *jwtKeyRepository) Create(ctx context.Context, key *models.JWTKey) error {
+	query := `INSERT INTO jwt_keys (id, secret) VALUES ($1, $2) RETURNING created_at`
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, key.ID, key.Secret).Scan(&key.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create jwt key: %w", err)
+	}
+	return nil
+}
+
+// List retrieves every key, oldest first
+func (r *jwtKeyRepository) List(ctx context.Context) ([]*models.JWTKey, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, secret, created_at FROM jwt_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jwt keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*models.JWTKey
+	for rows.Next() {
+		key := &models.JWTKey{}
+		if err := rows.Scan(&key.ID, &key.Secret, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan jwt key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return keys, nil
+}
+
+// DeleteSuperseded removes keys a newer key replaced before cutoff; no token
+// they signed can still be valid once cutoff is an expiration ago
+func (r *jwtKeyRepository) DeleteSuperseded(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `
+		DELETE FROM jwt_keys k
+		WHERE EXISTS (
+			SELECT 1 FROM jwt_keys n
+			WHERE n.created_at > k.created_at AND n.created_at < $1
+		)`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete superseded jwt keys: %w", err)
+	}
+	return result.RowsAffected()
+}
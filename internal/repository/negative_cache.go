@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/cache"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"go.uber.org/zap"
+)
+
+// missingUser is the value cached for a user that does not exist
+var missingUser = []byte{1}
+
+// negativeCacheUserRepository remembers GetByID and GetByEmail lookups that
+// found no user, so repeated lookups of the same missing user, as scrapers
+// walking IDs make, do not reach the database until ttl passes. Writes that
+// can make a missing user exist drop what was remembered for it.
+type negativeCacheUserRepository struct {
+	UserRepository
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewNegativeCacheUserRepository wraps repo so that users it did not find
+// are remembered in c for ttl
+func NewNegativeCacheUserRepository(repo UserRepository, c cache.Cache, ttl time.Duration) UserRepository {
+	return &negativeCacheUserRepository{UserRepository: repo, cache: c, ttl: ttl}
+}
+
+// missingIDKey is the cache key marking user id as missing
+func missingIDKey(id int) string {
+	return "user:missing:id:" + strconv.Itoa(id)
+}
+
+// missingEmailKey is the cache key marking email as unused. The email is
+// hashed, since cache keys may not hold every character an email can.
+func missingEmailKey(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return "user:missing:email:" + hex.EncodeToString(sum[:16])
+}
+
+// GetByID implements UserRepository
+func (r *negativeCacheUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	return r.lookup(ctx, missingIDKey(id), func() (*models.User, error) {
+		return r.UserRepository.GetByID(ctx, id)
+	})
+}
+
+// GetByEmail implements UserRepository
+func (r *negativeCacheUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.lookup(ctx, missingEmailKey(email), func() (*models.User, error) {
+		return r.UserRepository.GetByEmail(ctx, email)
+	})
+}
+
+// lookup answers from the cache when key marks the user missing, and
+// otherwise runs get, marking the user missing if it finds none. A failing
+// cache is bypassed.
+func (r *negativeCacheUserRepository) lookup(ctx context.Context, key string, get func() (*models.User, error)) (*models.User, error) {
+	if _, missing, err := r.cache.Get(ctx, key); err != nil {
+		logger.FromContext(ctx).Warn("failed to read missing user cache", zap.Error(err))
+	} else if missing {
+		return nil, apperrors.NotFound("user not found")
+	}
+
+	user, err := get()
+	if errors.Is(err, apperrors.ErrNotFound) {
+		if err := r.cache.Set(ctx, key, missingUser, r.ttl); err != nil {
+			logger.FromContext(ctx).Warn("failed to cache missing user", zap.Error(err))
+		}
+	}
+	return user, err
+}
+
+// forget drops the missing marks of users that now exist
+func (r *negativeCacheUserRepository) forget(ctx context.Context, users ...*models.User) {
+	var keys []string
+	for _, user := range users {
+		if user != nil {
+			keys = append(keys, missingIDKey(user.ID), missingEmailKey(user.Email))
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		logger.FromContext(ctx).Warn("failed to invalidate missing user cache", zap.Error(err))
+	}
+}
+
+// Create implements UserRepository
+func (r *negativeCacheUserRepository) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	user, err := r.UserRepository.Create(ctx, req)
+	r.forget(ctx, user)
+	return user, err
+}
+
+// CreateBatch implements UserRepository
+func (r *negativeCacheUserRepository) CreateBatch(ctx context.Context, reqs []*models.CreateUserRequest) ([]*models.User, []error, error) {
+	users, errs, err := r.UserRepository.CreateBatch(ctx, reqs)
+	r.forget(ctx, users...)
+	return users, errs, err
+}
+
+// CreateWithPassword implements UserRepository
+func (r *negativeCacheUserRepository) CreateWithPassword(ctx context.Context, req *models.CreateUserRequest, passwordHash string) (*models.User, error) {
+	user, err := r.UserRepository.CreateWithPassword(ctx, req, passwordHash)
+	r.forget(ctx, user)
+	return user, err
+}
+
+// Upsert implements UserRepository; a created user may have been looked up
+func (r *negativeCacheUserRepository) Upsert(ctx context.Context, req *models.CreateUserRequest) (*models.User, bool, error) {
+	user, created, err := r.UserRepository.Upsert(ctx, req)
+	if created {
+		r.forget(ctx, user)
+	}
+	return user, created, err
+}
+
+// Update implements UserRepository; the new email may have been looked up
+func (r *negativeCacheUserRepository) Update(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	user, err := r.UserRepository.Update(ctx, id, req)
+	r.forget(ctx, user)
+	return user, err
+}
+
+// Patch implements UserRepository; the new email may have been looked up
+func (r *negativeCacheUserRepository) Patch(ctx context.Context, id int, patch *models.PatchUserRequest) (*models.User, error) {
+	user, err := r.UserRepository.Patch(ctx, id, patch)
+	r.forget(ctx, user)
+	return user, err
+}
+
+// Restore implements UserRepository; deleted users are not found until then
+func (r *negativeCacheUserRepository) Restore(ctx context.Context, id int) (*models.User, error) {
+	user, err := r.UserRepository.Restore(ctx, id)
+	r.forget(ctx, user)
+	return user, err
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// oauthRepository implements OAuthRepository interface
+type oauthRepository struct {
+	db *sql.DB
+}
+
+// NewOAuthRepository creates a new OAuth repository
+func NewOAuthRepository(db *sql.DB) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+// CreateClient stores a new OAuth client
+func (r *oauthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) (*models.OAuthClient, error) {
+	query := `
+		INSERT INTO oauth_clients (id, name, secret_hash, redirect_uris, public, scopes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		client.ID,
+		client.Name,
+		client.SecretHash,
+		client.RedirectURIs,
+		client.Public,
+		client.Scopes,
+	).Scan(&client.CreatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oauth client: %w", err)
+	}
+
+	return client, nil
+}
+
+// GetClient retrieves an OAuth client by ID
+func (r *oauthRepository) GetClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	query := `
+		SELECT id, name, secret_hash, redirect_uris, public, scopes, created_at
+		FROM oauth_clients
+		WHERE id = $1
+	`
+
+	client := &models.OAuthClient{}
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&client.ID,
+		&client.Name,
+		&client.SecretHash,
+		stringArray{&client.RedirectURIs},
+		&client.Public,
+		stringArray{&client.Scopes},
+		&client.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("oauth client not found")
+		}
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+
+	return client, nil
+}
+
+// SaveAuthorizationCode stores an issued authorization code
+func (r *oauthRepository) SaveAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	query := `
+		INSERT INTO oauth_authorization_codes
+			(code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, code_challenge_method, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		code.CodeHash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		code.Scope,
+		code.Nonce,
+		code.CodeChallenge,
+		code.CodeChallengeMethod,
+		code.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save authorization code: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeAuthorizationCode deletes and returns an authorization code so that
+// it can only ever be redeemed once
+func (r *oauthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	query := `
+		DELETE FROM oauth_authorization_codes
+		WHERE code_hash = $1
+		RETURNING code_hash, client_id, user_id, redirect_uri, scope, nonce,
+			code_challenge, code_challenge_method, expires_at, created_at
+	`
+
+	code := &models.AuthorizationCode{}
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, codeHash).Scan(
+		&code.CodeHash,
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		&code.Scope,
+		&code.Nonce,
+		&code.CodeChallenge,
+		&code.CodeChallengeMethod,
+		&code.ExpiresAt,
+		&code.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("authorization code not found")
+		}
+		return nil, fmt.Errorf("failed to consume authorization code: %w", err)
+	}
+
+	return code, nil
+}
+
+// deviceCodeColumns lists the columns selected for a device code
+const deviceCodeColumns = `device_code_hash, user_code, client_id, scope, status, user_id,
+	poll_interval, last_polled_at, expires_at, created_at`
+
+// SaveDeviceCode stores a new device authorization request
+func (r *oauthRepository) SaveDeviceCode(ctx context.Context, code *models.DeviceCode) error {
+	query := `
+		INSERT INTO oauth_device_codes
+			(device_code_hash, user_code, client_id, scope, status, poll_interval, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		code.DeviceCodeHash,
+		code.UserCode,
+		code.ClientID,
+		code.Scope,
+		code.Status,
+		code.Interval,
+		code.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save device code: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeviceCode retrieves a device authorization request by device code hash
+func (r *oauthRepository) GetDeviceCode(ctx context.Context, deviceCodeHash string) (*models.DeviceCode, error) {
+	query := `SELECT ` + deviceCodeColumns + ` FROM oauth_device_codes WHERE device_code_hash = $1`
+	return r.scanDeviceCode(conn(ctx, r.db).QueryRowContext(ctx, query, deviceCodeHash))
+}
+
+// GetDeviceCodeByUserCode retrieves a device authorization request by user code
+func (r *oauthRepository) GetDeviceCodeByUserCode(ctx context.Context, userCode string) (*models.DeviceCode, error) {
+	query := `SELECT ` + deviceCodeColumns + ` FROM oauth_device_codes WHERE user_code = $1`
+	return r.scanDeviceCode(conn(ctx, r.db).QueryRowContext(ctx, query, userCode))
+}
+
+// UpdateDeviceCodeStatus records the user's decision on a pending device request
+func (r *oauthRepository) UpdateDeviceCodeStatus(ctx context.Context, deviceCodeHash, status string, userID *int) error {
+	query := `
+		UPDATE oauth_device_codes
+		SET status = $1, user_id = $2
+		WHERE device_code_hash = $3 AND status = 'pending'
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, status, userID, deviceCodeHash)
+	if err != nil {
+		return fmt.Errorf("failed to update device code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.NotFound("device code not found")
+	}
+
+	return nil
+}
+
+// TouchDeviceCode records a poll and the interval the client must now respect
+func (r *oauthRepository) TouchDeviceCode(ctx context.Context, deviceCodeHash string, interval int) error {
+	query := `
+		UPDATE oauth_device_codes
+		SET last_polled_at = CURRENT_TIMESTAMP, poll_interval = $1
+		WHERE device_code_hash = $2
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, interval, deviceCodeHash); err != nil {
+		return fmt.Errorf("failed to update device code poll time: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDeviceCode removes a device authorization request
+func (r *oauthRepository) DeleteDeviceCode(ctx context.Context, deviceCodeHash string) error {
+	query := `DELETE FROM oauth_device_codes WHERE device_code_hash = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, deviceCodeHash); err != nil {
+		return fmt.Errorf("failed to delete device code: %w", err)
+	}
+
+	return nil
+}
+
+// scanDeviceCode scans a single device code row
+func (r *oauthRepository) scanDeviceCode(row *sql.Row) (*models.DeviceCode, error) {
+	code := &models.DeviceCode{}
+	var userID sql.NullInt64
+	var lastPolledAt sql.NullTime
+
+	err := row.Scan(
+		&code.DeviceCodeHash,
+		&code.UserCode,
+		&code.ClientID,
+		&code.Scope,
+		&code.Status,
+		&userID,
+		&code.Interval,
+		&lastPolledAt,
+		&code.ExpiresAt,
+		&code.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("device code not found")
+		}
+		return nil, fmt.Errorf("failed to get device code: %w", err)
+	}
+
+	if userID.Valid {
+		id := int(userID.Int64)
+		code.UserID = &id
+	}
+	if lastPolledAt.Valid {
+		code.LastPolledAt = &lastPolledAt.Time
+	}
+
+	return code, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// operationRepository implements OperationRepository interface
+type operationRepository struct {
+	db *sql.DB
+}
+
+// NewOperationRepository creates a new operation repository
+func NewOperationRepository(db *sql.DB) OperationRepository {
+	return &operationRepository{db: db}
+}
+
+// Create stores a new operation
+func (r *operationRepository) Create(ctx context.Context, op *models.Operation) error {
+	query := `
+		INSERT INTO operations (id, type, status, progress, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, op.ID, op.Type, op.Status, op.Progress, op.CreatedBy).Scan(
+		&op.CreatedAt,
+		&op.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create operation: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves an operation by ID
+func (r *operationRepository) Get(ctx context.Context, id string) (*models.Operation, error) {
+	query := `
+		SELECT id, type, status, progress, result_url, error, created_by, created_at, updated_at, completed_at
+		FROM operations
+		WHERE id = $1
+	`
+
+	op := &models.Operation{}
+	var createdBy sql.NullInt64
+	var completedAt sql.NullTime
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&op.ID,
+		&op.Type,
+		&op.Status,
+		&op.Progress,
+		&op.ResultURL,
+		&op.Error,
+		&createdBy,
+		&op.CreatedAt,
+		&op.UpdatedAt,
+		&completedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("operation not found")
+		}
+		return nil, fmt.Errorf("failed to get operation: %w", err)
+	}
+
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		op.CreatedBy = &id
+	}
+	op.CompletedAt = nullTimePtr(completedAt)
+
+	return op, nil
+}
+
+// Update saves the state, progress and outcome of an operation
+func (r *operationRepository) Update(ctx context.Context, op *models.Operation) error {
+	query := `
+		UPDATE operations
+		SET status = $2, progress = $3, result_url = $4, error = $5, completed_at = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, op.ID, op.Status, op.Progress, op.ResultURL, op.Error, op.CompletedAt).Scan(&op.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.NotFound("operation not found")
+		}
+		return fmt.Errorf("failed to update operation: %w", err)
+	}
+
+	return nil
+}
+
+// FailInterrupted marks operations left unfinished by a previous process as failed
+func (r *operationRepository) FailInterrupted(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE operations
+		SET status = 'failed', error = 'interrupted by server restart',
+			completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE status IN ('pending', 'running')
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail interrupted operations: %w", err)
+	}
+
+	return result.RowsAffected()
+}
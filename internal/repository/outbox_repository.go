@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// outboxColumns are the columns scanned by scanOutboxEvent, in order
+const outboxColumns = `id, event_id, type, subject, data, occurred_at, attempts, last_error,
+	next_attempt_at, published_at, created_at, updated_at`
+
+// outboxRepository implements OutboxRepository interface
+type outboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository creates a new domain event outbox repository
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Enqueue stores event for publishing. Within a transaction, the event is
+// only published if the transaction commits.
+func (r *outboxRepository) Enqueue(ctx context.Context, event *models.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox (event_id, type, subject, data, occurred_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + outboxColumns
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, event.EventID, event.Type, event.Subject, []byte(event.Data), event.OccurredAt)
+	stored, err := scanOutboxEvent(row)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue event: %w", err)
+	}
+	*event = *stored
+	return nil
+}
+
+// ClaimDue claims due events. SKIP LOCKED lets several relays claim at once
+// without waiting on or double-claiming each other's rows.
+func (r *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 microsecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, lease.Microseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+	return scanOutboxEvents(rows)
+}
+
+// MarkPublished records that the event backend accepted an event
+func (r *outboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	query := `UPDATE outbox SET published_at = NOW(), last_error = '' WHERE id = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark event published: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry records a failed attempt and schedules the next one
+func (r *outboxRepository) MarkRetry(ctx context.Context, id int64, lastError string, next time.Time) error {
+	query := `UPDATE outbox SET last_error = $2, next_attempt_at = $3 WHERE id = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, lastError, next); err != nil {
+		return fmt.Errorf("failed to reschedule event: %w", err)
+	}
+	return nil
+}
+
+// DeletePublished removes events published before cutoff
+func (r *outboxRepository) DeletePublished(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM outbox WHERE published_at IS NOT NULL AND published_at < $1`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published events: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scanOutboxEvent scans one row of outboxColumns
+func scanOutboxEvent(row interface{ Scan(...interface{}) error }) (*models.OutboxEvent, error) {
+	event := &models.OutboxEvent{}
+	var data []byte
+	err := row.Scan(
+		&event.ID,
+		&event.EventID,
+		&event.Type,
+		&event.Subject,
+		&data,
+		&event.OccurredAt,
+		&event.Attempts,
+		&event.LastError,
+		&event.NextAttemptAt,
+		&event.PublishedAt,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	event.Data = data
+	return event, nil
+}
+
+// scanOutboxEvents scans and closes rows of outboxColumns
+func scanOutboxEvents(rows *sql.Rows) ([]*models.OutboxEvent, error) {
+	defer rows.Close()
+
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		event, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// personalTokenRepository implements PersonalTokenRepository interface
+type personalTokenRepository struct {
+	db *sql.DB
+}
+
+// NewPersonalTokenRepository creates a new personal access token repository
+func NewPersonalTokenRepository(db *sql.DB) PersonalTokenRepository {
+	return &personalTokenRepository{db: db}
+}
+
+// personalTokenColumns lists the columns selected for a personal token
+const personalTokenColumns = `id, user_id, name, token_hash, token_prefix, scopes,
+	expires_at, last_used_at, revoked_at, created_at, signing_key`
+
+// Create stores a new personal access token
+func (r *personalTokenRepository) Create(ctx context.Context, token *models.PersonalToken) (*models.PersonalToken, error) {
+	query := `
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at, signing_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.Prefix,
+		token.Scopes,
+		token.ExpiresAt,
+		token.SigningKey,
+	).Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create personal token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListByUser retrieves all unrevoked tokens of a user
+func (r *personalTokenRepository) ListByUser(ctx context.Context, userID int) ([]*models.PersonalToken, error) {
+	query := `SELECT ` + personalTokenColumns + `
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.PersonalToken
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// GetByHash retrieves a token by the hash of its plaintext value
+func (r *personalTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalToken, error) {
+	query := `SELECT ` + personalTokenColumns + ` FROM personal_access_tokens WHERE token_hash = $1`
+
+	token, err := scanPersonalToken(conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("personal token not found")
+		}
+		return nil, fmt.Errorf("failed to get personal token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Revoke marks a user's token as revoked
+func (r *personalTokenRepository) Revoke(ctx context.Context, userID, id int) error {
+	query := `
+		UPDATE personal_access_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke personal token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.NotFound("personal token not found")
+	}
+
+	return nil
+}
+
+// SetSigningKey replaces the signing key of a user's unrevoked token; an
+// empty key removes it
+func (r *personalTokenRepository) SetSigningKey(ctx context.Context, userID, id int, publicKey string) error {
+	query := `
+		UPDATE personal_access_tokens
+		SET signing_key = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, publicKey, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set personal token signing key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.NotFound("personal token not found")
+	}
+
+	return nil
+}
+
+// TouchLastUsed records that a token was just used
+func (r *personalTokenRepository) TouchLastUsed(ctx context.Context, id int) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to update personal token last used: %w", err)
+	}
+
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPersonalToken scans a single personal token row
+func scanPersonalToken(row rowScanner) (*models.PersonalToken, error) {
+	token := &models.PersonalToken{}
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.Prefix,
+		stringArray{&token.Scopes},
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&token.CreatedAt,
+		&token.SigningKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.ExpiresAt = nullTimePtr(expiresAt)
+	token.LastUsedAt = nullTimePtr(lastUsedAt)
+	token.RevokedAt = nullTimePtr(revokedAt)
+
+	return token, nil
+}
+
+// nullTimePtr converts a sql.NullTime to a *time.Time
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
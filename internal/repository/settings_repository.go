@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// settingsRepository implements SettingsRepository interface
+type settingsRepository struct {
+	db *sql.DB
+}
+
+// NewSettingsRepository creates a new settings repository
+func NewSettingsRepository(db *sql.DB) SettingsRepository {
+	return &settingsRepository{db: db}
+}
+
+// Get retrieves a user's settings document as stored
+func (r *settingsRepository) Get(ctx context.Context, userID int) (*models.UserSettings, error) {
+	query := `
+		SELECT user_id, schema_version, settings, updated_at
+		FROM user_settings
+		WHERE user_id = $1
+	`
+
+	settings := &models.UserSettings{}
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
+		&settings.UserID,
+		&settings.SchemaVersion,
+		&settings.Settings,
+		&settings.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("settings not found")
+		}
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Save creates or replaces a user's settings document, unless the stored
+// one has a schema version above maxVersion
+func (r *settingsRepository) Save(ctx context.Context, settings *models.UserSettings, maxVersion int) error {
+	query := `
+		INSERT INTO user_settings (user_id, schema_version, settings, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET schema_version = EXCLUDED.schema_version,
+			settings = EXCLUDED.settings,
+			updated_at = EXCLUDED.updated_at
+		WHERE user_settings.schema_version <= $4
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, settings.UserID, settings.SchemaVersion, []byte(settings.Settings), maxVersion).
+		Scan(&settings.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.Conflict("settings were saved by a newer version")
+		}
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	return nil
+}
+
+// Upgrade replaces a user's settings document with its upgrade, unless it
+// changed since it was read at fromVersion. It reports whether it did.
+func (r *settingsRepository) Upgrade(ctx context.Context, settings *models.UserSettings, fromVersion int) (bool, error) {
+	query := `
+		UPDATE user_settings
+		SET schema_version = $2, settings = $3
+		WHERE user_id = $1 AND schema_version = $4
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, settings.UserID, settings.SchemaVersion, []byte(settings.Settings), fromVersion)
+	if err != nil {
+		return false, fmt.Errorf("failed to upgrade settings: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBTX is the part of *sql.DB and *sql.Tx that repositories query through
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// TxManager runs groups of repository calls atomically
+type TxManager interface {
+	// WithinTransaction calls fn with a context carrying a transaction.
+	// Repository calls made with that context run in the transaction, which
+	// commits if fn returns nil and rolls back otherwise. Calls nested in an
+	// outer WithinTransaction join its transaction.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// sqlTxManager implements TxManager with database/sql transactions
+type sqlTxManager struct {
+	db *sql.DB
+}
+
+// NewTxManager creates a transaction manager for db. Repositories need no
+// setup to take part: those created by the New*Repository constructors in
+// this package use the transaction in the context when there is one.
+func NewTxManager(db *sql.DB) TxManager {
+	return &sqlTxManager{db: db}
+}
+
+// WithinTransaction implements TxManager
+func (m *sqlTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		// Also roll back when fn panics, then let the panic continue
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(withTx(ctx, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// txKey is the context key for the current transaction
+type txKey struct{}
+
+// withTx returns a copy of ctx carrying tx
+func withTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// txFromContext returns the transaction in ctx, or nil outside one
+func txFromContext(ctx context.Context) *sql.Tx {
+	tx, _ := ctx.Value(txKey{}).(*sql.Tx)
+	return tx
+}
+
+// conn returns the transaction in ctx, or db outside one
+func conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx
+	}
+	return db
+}
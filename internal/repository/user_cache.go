@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/cache"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"go.uber.org/zap"
+)
+
+// Results of cache lookups reported to a CacheObserver
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// userCacheName labels the user cache's lookups
+const userCacheName = "users"
+
+// CacheObserver records whether cache lookups were answered from the cache
+type CacheObserver interface {
+	ObserveCache(name, result string)
+}
+
+// cachedUserRepository keeps users found by GetByID in a cache for ttl and
+// maps emails to their IDs, so GetByEmail is answered from the same
+// entries. Writes to a user drop its entry. A write racing a read that
+// misses can leave the old user cached until ttl passes.
+type cachedUserRepository struct {
+	UserRepository
+	cache    cache.Cache
+	ttl      time.Duration
+	observer CacheObserver
+}
+
+// NewCachedUserRepository wraps repo so that users it finds are cached in
+// c for ttl. observer, which may be nil, counts hits and misses.
+func NewCachedUserRepository(repo UserRepository, c cache.Cache, ttl time.Duration, observer CacheObserver) UserRepository {
+	return &cachedUserRepository{UserRepository: repo, cache: c, ttl: ttl, observer: observer}
+}
+
+// userIDKey is the cache key of the user with id
+func userIDKey(id int) string {
+	return "user:id:" + strconv.Itoa(id)
+}
+
+// userEmailKey is the cache key of the ID of the user with email. The email
+// is hashed, since cache keys may not hold every character an email can.
+func userEmailKey(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return "user:email:" + hex.EncodeToString(sum[:16])
+}
+
+// observe reports the result of a lookup
+func (r *cachedUserRepository) observe(result string) {
+	if r.observer != nil {
+		r.observer.ObserveCache(userCacheName, result)
+	}
+}
+
+// GetByID implements UserRepository. Reads within a transaction may see
+// uncommitted changes, so they bypass the cache.
+func (r *cachedUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if txFromContext(ctx) != nil {
+		return r.UserRepository.GetByID(ctx, id)
+	}
+	if user := r.cached(ctx, id); user != nil {
+		r.observe(CacheHit)
+		return user, nil
+	}
+	r.observe(CacheMiss)
+	return r.load(ctx, id)
+}
+
+// GetByEmail implements UserRepository. The email's ID only answers from
+// the cache while the user cached under it still has that email; otherwise
+// the user is looked up by email and then cached by ID.
+func (r *cachedUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if txFromContext(ctx) != nil {
+		return r.UserRepository.GetByEmail(ctx, email)
+	}
+	key := userEmailKey(email)
+	value, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		logger.FromContext(ctx).Warn("failed to read user cache", zap.Error(err))
+	}
+	if ok {
+		if id, err := strconv.Atoi(string(value)); err == nil {
+			if user := r.cached(ctx, id); user != nil && user.Email == email {
+				r.observe(CacheHit)
+				return user, nil
+			}
+		}
+	}
+	r.observe(CacheMiss)
+
+	user, err := r.UserRepository.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.cache.Set(ctx, key, []byte(strconv.Itoa(user.ID)), r.ttl); err != nil {
+		logger.FromContext(ctx).Warn("failed to cache user", zap.Error(err))
+		return user, nil
+	}
+	// GetByEmail leaves out fields GetByID loads, so the cached user comes
+	// from GetByID
+	if cached := r.cached(ctx, user.ID); cached != nil {
+		return cached, nil
+	}
+	if full, err := r.load(ctx, user.ID); err == nil {
+		return full, nil
+	}
+	return user, nil
+}
+
+// load gets the user with id from the repository and caches it
+func (r *cachedUserRepository) load(ctx context.Context, id int) (*models.User, error) {
+	user, err := r.UserRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(user)
+	if err == nil {
+		err = r.cache.Set(ctx, userIDKey(id), value, r.ttl)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Warn("failed to cache user", zap.Error(err))
+	}
+	return user, nil
+}
+
+// cached returns the cached user with id, or nil if there is none. A
+// failing cache is bypassed.
+func (r *cachedUserRepository) cached(ctx context.Context, id int) *models.User {
+	value, ok, err := r.cache.Get(ctx, userIDKey(id))
+	if err != nil {
+		logger.FromContext(ctx).Warn("failed to read user cache", zap.Error(err))
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	user := &models.User{}
+	if err := json.Unmarshal(value, user); err != nil {
+		return nil
+	}
+	return user
+}
+
+// forget drops the cached users with ids. Email keys need no invalidation:
+// they only answer while the user cached by ID has the email.
+func (r *cachedUserRepository) forget(ctx context.Context, ids ...int) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = userIDKey(id)
+	}
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		logger.FromContext(ctx).Warn("failed to invalidate user cache", zap.Error(err))
+	}
+}
+
+// Upsert implements UserRepository
+func (r *cachedUserRepository) Upsert(ctx context.Context, req *models.CreateUserRequest) (*models.User, bool, error) {
+	user, created, err := r.UserRepository.Upsert(ctx, req)
+	if user != nil {
+		r.forget(ctx, user.ID)
+	}
+	return user, created, err
+}
+
+// Update implements UserRepository
+func (r *cachedUserRepository) Update(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	user, err := r.UserRepository.Update(ctx, id, req)
+	r.forget(ctx, id)
+	return user, err
+}
+
+// Patch implements UserRepository
+func (r *cachedUserRepository) Patch(ctx context.Context, id int, patch *models.PatchUserRequest) (*models.User, error) {
+	user, err := r.UserRepository.Patch(ctx, id, patch)
+	r.forget(ctx, id)
+	return user, err
+}
+
+// Delete implements UserRepository
+func (r *cachedUserRepository) Delete(ctx context.Context, id int) error {
+	err := r.UserRepository.Delete(ctx, id)
+	r.forget(ctx, id)
+	return err
+}
+
+// Restore implements UserRepository
+func (r *cachedUserRepository) Restore(ctx context.Context, id int) (*models.User, error) {
+	user, err := r.UserRepository.Restore(ctx, id)
+	r.forget(ctx, id)
+	return user, err
+}
+
+// SetLegalHold implements UserRepository
+func (r *cachedUserRepository) SetLegalHold(ctx context.Context, id int, hold bool) (*models.User, error) {
+	user, err := r.UserRepository.SetLegalHold(ctx, id, hold)
+	r.forget(ctx, id)
+	return user, err
+}
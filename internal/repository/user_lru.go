@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/pratham15541/go-crud/internal/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// lruUserCacheName labels the in-memory user cache's lookups
+const lruUserCacheName = "users_memory"
+
+// UserLRU holds the most recently used users in process memory for a TTL.
+// Entries are per instance: a write on another instance is not seen until
+// the TTL passes, so it suits single-instance deployments. Repositories
+// sharing one UserLRU drop each other's stale entries.
+type UserLRU struct {
+	users  *expirable.LRU[int, *models.User]
+	emails *expirable.LRU[string, int]
+	loads  singleflight.Group
+
+	// generation is incremented by every write, so a load that started
+	// before one does not cache what it read
+	generation atomic.Uint64
+}
+
+// NewUserLRU creates a UserLRU holding up to size users for ttl
+func NewUserLRU(size int, ttl time.Duration) *UserLRU {
+	return &UserLRU{
+		users:  expirable.NewLRU[int, *models.User](size, nil, ttl),
+		emails: expirable.NewLRU[string, int](size, nil, ttl),
+	}
+}
+
+// lruUserRepository answers lookups from a UserLRU. Concurrent misses for
+// one user share a single repository query, so a burst of requests for a
+// hot user reaches the database once.
+type lruUserRepository struct {
+	UserRepository
+	*UserLRU
+	observer CacheObserver
+}
+
+// NewLRUUserRepository wraps repo so that users it finds are kept in lru.
+// observer, which may be nil, counts hits and misses.
+func NewLRUUserRepository(repo UserRepository, lru *UserLRU, observer CacheObserver) UserRepository {
+	return &lruUserRepository{UserRepository: repo, UserLRU: lru, observer: observer}
+}
+
+// observe reports the result of a lookup
+func (r *lruUserRepository) observe(result string) {
+	if r.observer != nil {
+		r.observer.ObserveCache(lruUserCacheName, result)
+	}
+}
+
+// GetByID implements UserRepository. Reads within a transaction may see
+// uncommitted changes, so they bypass the cache.
+func (r *lruUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if txFromContext(ctx) != nil {
+		return r.UserRepository.GetByID(ctx, id)
+	}
+	if user, ok := r.users.Get(id); ok {
+		r.observe(CacheHit)
+		return copyUser(user), nil
+	}
+	r.observe(CacheMiss)
+	return r.load(ctx, id)
+}
+
+// GetByEmail implements UserRepository. The email's ID only answers from
+// the cache while the user cached under it still has that email.
+func (r *lruUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if txFromContext(ctx) != nil {
+		return r.UserRepository.GetByEmail(ctx, email)
+	}
+	if id, ok := r.emails.Get(email); ok {
+		if user, ok := r.users.Get(id); ok && user.Email == email {
+			r.observe(CacheHit)
+			return copyUser(user), nil
+		}
+	}
+	r.observe(CacheMiss)
+
+	value, err, _ := r.loads.Do("email:"+email, func() (interface{}, error) {
+		user, err := r.UserRepository.GetByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		r.emails.Add(email, user.ID)
+		return user.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// GetByEmail leaves out fields GetByID loads, so the cached user comes
+	// from GetByID
+	id := value.(int)
+	if user, ok := r.users.Get(id); ok {
+		return copyUser(user), nil
+	}
+	return r.load(ctx, id)
+}
+
+// load gets the user with id from the repository, sharing the query with
+// concurrent loads of the same user, and caches it unless a write happened
+// meanwhile. The query runs with the context of the first caller, so its
+// cancellation fails the callers sharing it.
+func (r *lruUserRepository) load(ctx context.Context, id int) (*models.User, error) {
+	value, err, _ := r.loads.Do("id:"+strconv.Itoa(id), func() (interface{}, error) {
+		generation := r.generation.Load()
+		user, err := r.UserRepository.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if r.generation.Load() == generation {
+			r.users.Add(id, user)
+		}
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyUser(value.(*models.User)), nil
+}
+
+// copyUser copies user, so callers changing the user they were given do
+// not change the cached one
+func copyUser(user *models.User) *models.User {
+	copied := *user
+	return &copied
+}
+
+// forget drops the cached users with ids, and stops lookups that start from
+// now on joining loads of them already running
+func (r *UserLRU) forget(ids ...int) {
+	r.generation.Add(1)
+	for _, id := range ids {
+		r.users.Remove(id)
+		r.loads.Forget("id:" + strconv.Itoa(id))
+	}
+}
+
+// Clear drops every cached user. Loads already running finish, but do not
+// cache what they read.
+func (r *UserLRU) Clear() {
+	r.generation.Add(1)
+	r.users.Purge()
+	r.emails.Purge()
+}
+
+// Upsert implements UserRepository
+func (r *lruUserRepository) Upsert(ctx context.Context, req *models.CreateUserRequest) (*models.User, bool, error) {
+	user, created, err := r.UserRepository.Upsert(ctx, req)
+	if user != nil {
+		r.forget(user.ID)
+	}
+	return user, created, err
+}
+
+// Update implements UserRepository
+func (r *lruUserRepository) Update(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	user, err := r.UserRepository.Update(ctx, id, req)
+	r.forget(id)
+	return user, err
+}
+
+// Patch implements UserRepository
+func (r *lruUserRepository) Patch(ctx context.Context, id int, patch *models.PatchUserRequest) (*models.User, error) {
+	user, err := r.UserRepository.Patch(ctx, id, patch)
+	r.forget(id)
+	return user, err
+}
+
+// Delete implements UserRepository
+func (r *lruUserRepository) Delete(ctx context.Context, id int) error {
+	err := r.UserRepository.Delete(ctx, id)
+	r.forget(id)
+	return err
+}
+
+// Restore implements UserRepository
+func (r *lruUserRepository) Restore(ctx context.Context, id int) (*models.User, error) {
+	user, err := r.UserRepository.Restore(ctx, id)
+	r.forget(id)
+	return user, err
+}
+
+// SetLegalHold implements UserRepository
+func (r *lruUserRepository) SetLegalHold(ctx context.Context, id int, hold bool) (*models.User, error) {
+	user, err := r.UserRepository.SetLegalHold(ctx, id, hold)
+	r.forget(id)
+	return user, err
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// sortableUserColumns whitelists the fields the users list can be ordered by.
+// Sort fields are never interpolated into SQL without passing through it.
+var sortableUserColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"age":        "age",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// IsSortableUserField reports whether the users list can be ordered by field
+func IsSortableUserField(field string) bool {
+	_, ok := sortableUserColumns[field]
+	return ok
+}
+
+// userConditions returns the WHERE conditions for filter, appending their
+// bind values to args
+func (r *userRepository) userConditions(filter *models.UserFilter, args []interface{}) ([]string, []interface{}) {
+	var conditions []string
+	if filter == nil || !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filter == nil {
+		return conditions, args
+	}
+
+	add := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Name != "" {
+		add(r.dialect.likeCondition("name"), "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.Email != "" {
+		add(r.dialect.likeCondition("email"), "%"+escapeLike(filter.Email)+"%")
+	}
+	if filter.MinAge > 0 {
+		add(r.ageSelect()+" >= $%d", filter.MinAge)
+	}
+	if filter.MaxAge > 0 {
+		add(r.ageSelect()+" <= $%d", filter.MaxAge)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		add("created_at > $%d", filter.CreatedAfter)
+	}
+
+	return conditions, args
+}
+
+// whereClause joins conditions into a WHERE clause, or returns "" for none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// userOrderBy returns the ORDER BY clause for filter, newest first by default.
+// Rows that tie on the sort fields are ordered by id, so pages never
+// overlap or skip rows.
+func (r *userRepository) userOrderBy(filter *models.UserFilter) (string, error) {
+	if filter == nil || len(filter.Sort) == 0 {
+		return "ORDER BY created_at DESC, id DESC", nil
+	}
+
+	terms := make([]string, 0, len(filter.Sort)+1)
+	unique := false
+	for _, sort := range filter.Sort {
+		column, ok := sortableUserColumns[sort.Field]
+		if !ok {
+			return "", fmt.Errorf("cannot sort users by %q", sort.Field)
+		}
+		if column == "id" {
+			unique = true
+		}
+		if column == "age" {
+			// Follows the age transition phase like the selected age
+			column = r.ageSelect()
+		}
+		if sort.Desc {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+	}
+	if !unique {
+		terms = append(terms, "id")
+	}
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// likeCondition returns a case-insensitive LIKE condition on column for
+// userConditions. MySQL compares case-insensitively by default and escapes
+// LIKE wildcards with a backslash already; SQLite ignores the case of ASCII
+// letters.
+func (d Dialect) likeCondition(column string) string {
+	switch d {
+	case DialectMySQL:
+		return column + " LIKE $%d"
+	case DialectSQLite:
+		return column + ` LIKE $%d ESCAPE '\'`
+	default:
+		return column + ` ILIKE $%d ESCAPE '\'`
+	}
+}
+
+// escapeLike escapes LIKE wildcards so s matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// scanUsers reads every row of a users list query
+func scanUsers(rows *sql.Rows) ([]*models.User, error) {
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		err := rows.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Email,
+			&user.Age,
+			&user.Role,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.DeletedAt,
+			&user.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return users, nil
+}
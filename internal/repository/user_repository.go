@@ -1,69 +1,235 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/consistency"
 	"github.com/pratham15541/go-crud/internal/models"
 )
 
 // userRepository implements UserRepository interface
 type userRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	dialect  Dialect
+	agePhase models.TransitionPhase
+
+	// replica serves reads while useReplica reports true; nil without a replica
+	replica    *sql.DB
+	useReplica func() bool
+}
+
+// NewUserRepository creates a new user repository for the users stored in
+// db as schema describes
+func NewUserRepository(db *sql.DB, schema UserSchema) UserRepository {
+	schema = schema.withDefaults()
+	return &userRepository{db: db, dialect: schema.Dialect, agePhase: schema.AgePhase}
+}
+
+// NewUserRepositoryWithReplica creates a user repository that reads from
+// replica instead of db while useReplica reports true
+func NewUserRepositoryWithReplica(db, replica *sql.DB, useReplica func() bool, schema UserSchema) UserRepository {
+	r := NewUserRepository(db, schema).(*userRepository)
+	r.replica, r.useReplica = replica, useReplica
+	return r
+}
+
+// readDB returns the database reads should go to: the replica while
+// useReplica reports true, unless ctx carries a consistency token the
+// replica has not replayed yet, so clients read their own writes
+func (r *userRepository) readDB(ctx context.Context) *sql.DB {
+	if r.replica == nil || !r.useReplica() {
+		return r.db
+	}
+	if token, ok := consistency.FromContext(ctx); ok {
+		if reached, err := consistency.Reached(ctx, r.replica, token); err != nil || !reached {
+			return r.db
+		}
+	}
+	return r.replica
+}
+
+// reader returns where reads should go: the transaction in ctx, so they see
+// its writes, or else readDB
+func (r *userRepository) reader(ctx context.Context) DBTX {
+	if tx := txFromContext(ctx); tx != nil {
+		return r.dialect.wrap(tx)
+	}
+	return r.dialect.wrap(r.readDB(ctx))
 }
 
-// NewUserRepository creates a new user repository
-func NewUserRepository(db *sql.DB) UserRepository {
-	return &userRepository{db: db}
+// writer returns where writes should go: the transaction in ctx or the primary
+func (r *userRepository) writer(ctx context.Context) DBTX {
+	return r.dialect.wrap(conn(ctx, r.db))
+}
+
+// queryReturning runs an INSERT or UPDATE ending in a RETURNING clause and
+// scans the returned row into dest. MySQL has no RETURNING, so there the
+// statement runs without it and, in the same transaction, the row is read
+// back by id, or by the inserted ID when id is 0. An UPDATE matching no row
+// reports sql.ErrNoRows, as it does on Postgres.
+func (r *userRepository) queryReturning(ctx context.Context, query string, id int, args []interface{}, dest ...interface{}) error {
+	if r.dialect != DialectMySQL {
+		return r.writer(ctx).QueryRowContext(ctx, query, args...).Scan(dest...)
+	}
+
+	i := strings.LastIndex(query, "RETURNING")
+	statement, columns := query[:i], query[i+len("RETURNING"):]
+	return NewTxManager(r.db).WithinTransaction(ctx, func(ctx context.Context) error {
+		result, err := r.writer(ctx).ExecContext(ctx, statement, args...)
+		if err != nil {
+			return err
+		}
+		if id == 0 {
+			inserted, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			id = int(inserted)
+		} else if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return sql.ErrNoRows
+		}
+		return r.writer(ctx).QueryRowContext(ctx, "SELECT "+columns+" FROM users WHERE id = $1", id).Scan(dest...)
+	})
 }
 
 // Create creates a new user
-func (r *userRepository) Create(req *models.CreateUserRequest) (*models.User, error) {
-	query := `
-		INSERT INTO users (name, email, age) 
-		VALUES ($1, $2, $3) 
-		RETURNING id, name, email, age, created_at, updated_at
-	`
+func (r *userRepository) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	ageColumns, ageValues := r.ageInsert("$3")
+	query := fmt.Sprintf(`
+		INSERT INTO users (name, email, %s) 
+		VALUES ($1, $2, %s) 
+		RETURNING id, name, email, %s, role, created_at, updated_at, version
+	`, ageColumns, ageValues, r.ageSelect())
 
 	user := &models.User{}
-	err := r.db.QueryRow(query, req.Name, req.Email, req.Age).Scan(
+	err := r.queryReturning(ctx, query, 0, []interface{}{req.Name, req.Email, req.Age},
 		&user.ID,
 		&user.Name,
 		&user.Email,
 		&user.Age,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
 	)
 
 	if err != nil {
+		if mapped := userConstraintError(err, req.Email); mapped != nil {
+			return nil, mapped
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
 	return user, nil
 }
 
+// Upsert creates the live user with req.Email or, if there is one, updates
+// its name and age, in a single statement. created reports which it did: a
+// new user is at version 1 and an updated one past it.
+func (r *userRepository) Upsert(ctx context.Context, req *models.CreateUserRequest) (*models.User, bool, error) {
+	ageColumns, ageValues := r.ageInsert("$3")
+	query := fmt.Sprintf(`
+		INSERT INTO users (name, email, %s)
+		VALUES ($1, $2, %s)
+		%s name = $1, %s, updated_at = %s, version = users.version + 1
+		RETURNING id, name, email, %s, role, created_at, updated_at, version
+	`, ageColumns, ageValues, r.dialect.upsertOnEmail(), r.ageAssign("$3", false), r.dialect.now(), r.ageSelect())
+
+	user := &models.User{}
+	err := r.queryReturning(ctx, query, 0, []interface{}{req.Name, req.Email, req.Age},
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Version,
+	)
+
+	if err != nil {
+		if mapped := userConstraintError(err, req.Email); mapped != nil {
+			return nil, false, mapped
+		}
+		return nil, false, fmt.Errorf("failed to upsert user: %w", err)
+	}
+
+	return user, user.Version == 1, nil
+}
+
+// CreateBatch implements UserRepository. Each insert runs under a savepoint,
+// so a failed one is rolled back alone and the rest still commit.
+func (r *userRepository) CreateBatch(ctx context.Context, reqs []*models.CreateUserRequest) ([]*models.User, []error, error) {
+	users := make([]*models.User, len(reqs))
+	errs := make([]error, len(reqs))
+	err := NewTxManager(r.db).WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, req := range reqs {
+			if _, err := r.writer(ctx).ExecContext(ctx, "SAVEPOINT bulk_user"); err != nil {
+				return err
+			}
+			users[i], errs[i] = r.Create(ctx, req)
+			if errs[i] != nil {
+				if _, err := r.writer(ctx).ExecContext(ctx, "ROLLBACK TO SAVEPOINT bulk_user"); err != nil {
+					return err
+				}
+			}
+			if _, err := r.writer(ctx).ExecContext(ctx, "RELEASE SAVEPOINT bulk_user"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create users: %w", err)
+	}
+	return users, errs, nil
+}
+
+// userConstraintError converts a constraint violation while writing a user
+// with email into the error the API reports, or returns nil for other errors.
+// A duplicate key means another request took the email after the service
+// checked it.
+func userConstraintError(err error, email string) error {
+	switch {
+	case isDuplicateKey(err):
+		return &models.DuplicateEmailError{Email: email}
+	case isCheckViolation(err):
+		return models.ErrInvalidAge
+	}
+	return nil
+}
+
 // GetByID retrieves a user by ID
-func (r *userRepository) GetByID(id int) (*models.User, error) {
-	query := `
-		SELECT id, name, email, age, created_at, updated_at 
+func (r *userRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, email, %s, role, created_at, updated_at, legal_hold, version
 		FROM users 
-		WHERE id = $1
-	`
+		WHERE id = $1 AND deleted_at IS NULL
+	`, r.ageSelect())
 
 	user := &models.User{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.reader(ctx).QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Name,
 		&user.Email,
 		&user.Age,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.LegalHold,
+		&user.Version,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -71,22 +237,124 @@ func (r *userRepository) GetByID(id int) (*models.User, error) {
 	return user, nil
 }
 
-// GetAll retrieves all users with pagination
-func (r *userRepository) GetAll(limit, offset int) ([]*models.User, error) {
-	query := `
-		SELECT id, name, email, age, created_at, updated_at 
-		FROM users 
-		ORDER BY created_at DESC 
+// GetAll retrieves users matching filter with pagination
+func (r *userRepository) GetAll(ctx context.Context, filter *models.UserFilter, limit, offset int) ([]*models.User, error) {
+	orderBy, err := r.userOrderBy(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, args := r.userConditions(filter, []interface{}{limit, offset})
+	query := fmt.Sprintf(`
+		SELECT id, name, email, %s, role, created_at, updated_at, deleted_at, version
+		FROM users
+		%s
+		%s
 		LIMIT $1 OFFSET $2
-	`
+	`, r.ageSelect(), whereClause(conditions), orderBy)
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
+
+	return scanUsers(rows)
+}
+
+// GetAfter retrieves up to limit users matching filter ordered newest first,
+// starting after cursor; a nil cursor starts from the newest user
+func (r *userRepository) GetAfter(ctx context.Context, filter *models.UserFilter, cursor *models.UserCursor, limit int) ([]*models.User, error) {
+	conditions, args := r.userConditions(filter, []interface{}{limit})
+	if cursor != nil {
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, name, email, %s, role, created_at, updated_at, deleted_at, version
+		FROM users
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1
+	`, r.ageSelect(), whereClause(conditions))
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	return scanUsers(rows)
+}
+
+// Export passes every user matching filter to fn in batches of batchSize,
+// newest first. The rows are read through a server-side cursor, so the query
+// runs once and Postgres hands out one batch per FETCH instead of the API
+// re-querying for each page. The cursor lives in a REPEATABLE READ
+// transaction, so every batch shows the same point in time even while
+// writes continue.
+func (r *userRepository) Export(ctx context.Context, filter *models.UserFilter, batchSize int, fn func([]*models.User) error) error {
+	tx, err := r.readDB(ctx).BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin export: %w", err)
+	}
+	// The transaction only reads, so rolling back is how it ends; that also
+	// closes the cursor
+	defer tx.Rollback()
+
+	conditions, args := r.userConditions(filter, nil)
+	if r.dialect != DialectPostgres {
+		return r.exportStream(ctx, r.dialect.wrap(tx), conditions, args, batchSize, fn)
+	}
+	declare := fmt.Sprintf(`
+		DECLARE user_export NO SCROLL CURSOR FOR
+		SELECT id, name, email, %s, role, created_at, updated_at, deleted_at, version
+		FROM users
+		%s
+		ORDER BY created_at DESC, id DESC
+	`, r.ageSelect(), whereClause(conditions))
+	if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+		return fmt.Errorf("failed to open export cursor: %w", err)
+	}
+
+	// FETCH does not accept bind parameters; batchSize is an int
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM user_export", batchSize)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("failed to fetch users: %w", err)
+		}
+		users, err := scanUsers(rows)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			if err := fn(users); err != nil {
+				return err
+			}
+		}
+		if len(users) < batchSize {
+			return nil
+		}
+	}
+}
+
+// exportStream is Export for MySQL and SQLite, which have no SQL cursors.
+// Their drivers stream rows as they are read, so one query is batched here
+// instead.
+func (r *userRepository) exportStream(ctx context.Context, db DBTX, conditions []string, args []interface{}, batchSize int, fn func([]*models.User) error) error {
+	query := fmt.Sprintf(`
+		SELECT id, name, email, %s, role, created_at, updated_at, deleted_at, version
+		FROM users
+		%s
+		ORDER BY created_at DESC, id DESC
+	`, r.ageSelect(), whereClause(conditions))
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch users: %w", err)
+	}
 	defer rows.Close()
 
-	var users []*models.User
+	batch := make([]*models.User, 0, batchSize)
 	for rows.Next() {
 		user := &models.User{}
 		err := rows.Scan(
@@ -94,29 +362,44 @@ func (r *userRepository) GetAll(limit, offset int) ([]*models.User, error) {
 			&user.Name,
 			&user.Email,
 			&user.Age,
+			&user.Role,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.DeletedAt,
+			&user.Version,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+			return fmt.Errorf("failed to scan user: %w", err)
+		}
+		batch = append(batch, user)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]*models.User, 0, batchSize)
 		}
-		users = append(users, user)
 	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
 	}
 
-	return users, nil
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
 }
 
-// Update updates a user
-func (r *userRepository) Update(id int, req *models.UpdateUserRequest) (*models.User, error) {
+// Update updates a user. The user must still be at the version read here,
+// or at req.Version when set, else ErrVersionConflict is returned.
+func (r *userRepository) Update(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
 	// First, get the current user
-	currentUser, err := r.GetByID(id)
+	currentUser, err := r.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if req.Version != nil && *req.Version != currentUser.Version {
+		return nil, models.ErrVersionConflict
+	}
 
 	// Update only provided fields
 	if req.Name != "" {
@@ -130,47 +413,132 @@ func (r *userRepository) Update(id int, req *models.UpdateUserRequest) (*models.
 	}
 	currentUser.UpdatedAt = time.Now()
 
-	query := `
+	query := fmt.Sprintf(`
 		UPDATE users 
-		SET name = $1, email = $2, age = $3, updated_at = $4 
-		WHERE id = $5 
-		RETURNING id, name, email, age, created_at, updated_at
-	`
+		SET name = $1, email = $2, %s, updated_at = $4, version = version + 1
+		WHERE id = $5 AND version = $6 AND deleted_at IS NULL
+		RETURNING id, name, email, %s, role, created_at, updated_at, version
+	`, r.ageAssign("$3", false), r.ageSelect())
 
 	user := &models.User{}
-	err = r.db.QueryRow(
+	err = r.queryReturning(
+		ctx,
 		query,
-		currentUser.Name,
-		currentUser.Email,
-		currentUser.Age,
-		currentUser.UpdatedAt,
 		id,
-	).Scan(
+		[]interface{}{
+			currentUser.Name,
+			currentUser.Email,
+			currentUser.Age,
+			currentUser.UpdatedAt,
+			id,
+			currentUser.Version,
+		},
 		&user.ID,
 		&user.Name,
 		&user.Email,
 		&user.Age,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
 	)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			// Changed or deleted since it was read
+			return nil, r.versionConflict(ctx, id)
+		}
+		if mapped := userConstraintError(err, currentUser.Email); mapped != nil {
+			return nil, mapped
+		}
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
 	return user, nil
 }
 
-// Delete deletes a user
-func (r *userRepository) Delete(id int) error {
+// Patch applies the non-nil fields of patch to a user in a single statement,
+// provided the user is still at patch.Version when that is set
+func (r *userRepository) Patch(ctx context.Context, id int, patch *models.PatchUserRequest) (*models.User, error) {
+	args := []interface{}{patch.Name, patch.Email, patch.Age, id}
+	versionCheck := ""
+	if patch.Version != nil {
+		args = append(args, *patch.Version)
+		versionCheck = "AND version = $5"
+	}
+	query := fmt.Sprintf(`
+		UPDATE users
+		SET name = COALESCE($1, name),
+			email = COALESCE($2, email),
+			%s,
+			updated_at = %s,
+			version = version + 1
+		WHERE id = $4 AND deleted_at IS NULL %s
+		RETURNING id, name, email, %s, role, created_at, updated_at, version
+	`, r.ageAssign("$3", true), r.dialect.now(), versionCheck, r.ageSelect())
+
+	user := &models.User{}
+	err := r.queryReturning(ctx, query, id, args,
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Version,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if patch.Version != nil {
+				return nil, r.versionConflict(ctx, id)
+			}
+			return nil, apperrors.NotFound("user not found")
+		}
+		if isDuplicateKey(err) && patch.Email != nil {
+			return nil, &models.DuplicateEmailError{Email: *patch.Email}
+		}
+		if isCheckViolation(err) {
+			return nil, models.ErrInvalidAge
+		}
+		return nil, fmt.Errorf("failed to patch user: %w", err)
+	}
+
+	return user, nil
+}
+
+// versionConflict explains why an update of user id at some version matched
+// no row: ErrVersionConflict, or the lookup's error if the user is gone
+func (r *userRepository) versionConflict(ctx context.Context, id int) error {
+	var exists bool
+	err := r.writer(ctx).QueryRowContext(ctx, `SELECT TRUE FROM users WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&exists)
+	switch {
+	case err == sql.ErrNoRows:
+		return apperrors.NotFound("user not found")
+	case err != nil:
+		return fmt.Errorf("failed to check user version: %w", err)
+	}
+	return models.ErrVersionConflict
+}
+
+// Delete soft-deletes a user; the row is kept until Purge removes it
+func (r *userRepository) Delete(ctx context.Context, id int) error {
 	// First check if user exists
-	_, err := r.GetByID(id)
+	user, err := r.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if user.LegalHold {
+		return models.ErrLegalHold
+	}
 
-	query := `DELETE FROM users WHERE id = $1`
-	result, err := r.db.Exec(query, id)
+	query := fmt.Sprintf(`
+		UPDATE users
+		SET deleted_at = %s, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND NOT legal_hold
+	`, r.dialect.now())
+	result, err := r.writer(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -181,33 +549,147 @@ func (r *userRepository) Delete(id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		// Deleted or placed under legal hold since the check
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return models.ErrLegalHold
 	}
 
 	return nil
 }
 
+// Restore undoes a soft delete, unless another user has taken the email since
+func (r *userRepository) Restore(ctx context.Context, id int) (*models.User, error) {
+	query := fmt.Sprintf(`
+		UPDATE users u
+		SET deleted_at = NULL, version = version + 1
+		WHERE u.id = $1 AND u.deleted_at IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM users o
+				WHERE o.email = u.email AND o.deleted_at IS NULL
+			)
+		RETURNING id, name, email, %s, role, created_at, updated_at, legal_hold, version
+	`, r.ageSelect())
+	if r.dialect != DialectPostgres {
+		// MySQL cannot read the table an UPDATE writes; the unique index on
+		// live emails rejects the restore instead
+		query = fmt.Sprintf(`
+			UPDATE users
+			SET deleted_at = NULL, version = version + 1
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			RETURNING id, name, email, %s, role, created_at, updated_at, legal_hold, version
+		`, r.ageSelect())
+	}
+
+	user := &models.User{}
+	err := r.queryReturning(ctx, query, id, []interface{}{id},
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.LegalHold,
+		&user.Version,
+	)
+	if err == nil {
+		return user, nil
+	}
+	if isDuplicateKey(err) {
+		return nil, apperrors.Conflict("email is in use by another user")
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	// Work out why nothing was restored
+	var deleted bool
+	err = r.writer(ctx).QueryRowContext(ctx, `SELECT deleted_at IS NOT NULL FROM users WHERE id = $1`, id).Scan(&deleted)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, apperrors.NotFound("user not found")
+	case err != nil:
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	case !deleted:
+		return nil, apperrors.Conflict("user is not deleted")
+	default:
+		return nil, apperrors.Conflict("email is in use by another user")
+	}
+}
+
+// Purge permanently removes users soft-deleted before cutoff, skipping users
+// under legal hold
+func (r *userRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deleted_at < $1 AND NOT legal_hold`
+	result, err := r.writer(ctx).ExecContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge users: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return purged, nil
+}
+
+// SetLegalHold places or lifts the legal hold on a user
+func (r *userRepository) SetLegalHold(ctx context.Context, id int, hold bool) (*models.User, error) {
+	query := fmt.Sprintf(`
+		UPDATE users
+		SET legal_hold = $1, version = version + 1
+		WHERE id = $2
+		RETURNING id, name, email, %s, role, created_at, updated_at, legal_hold, version
+	`, r.ageSelect())
+
+	user := &models.User{}
+	err := r.queryReturning(ctx, query, id, []interface{}{hold, id},
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.LegalHold,
+		&user.Version,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("user not found")
+		}
+		return nil, fmt.Errorf("failed to set legal hold: %w", err)
+	}
+
+	return user, nil
+}
+
 // GetByEmail retrieves a user by email
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
-	query := `
-		SELECT id, name, email, age, created_at, updated_at 
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, email, %s, role, created_at, updated_at, version
 		FROM users 
-		WHERE email = $1
-	`
+		WHERE email = $1 AND deleted_at IS NULL
+	`, r.ageSelect())
 
 	user := &models.User{}
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.reader(ctx).QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Name,
 		&user.Email,
 		&user.Age,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -215,15 +697,104 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	return user, nil
 }
 
-// Count returns the total number of users
-func (r *userRepository) Count() (int64, error) {
-	query := `SELECT COUNT(*) FROM users`
+// Count returns the number of users matching filter
+func (r *userRepository) Count(ctx context.Context, filter *models.UserFilter) (int64, error) {
+	conditions, args := r.userConditions(filter, nil)
+	query := `SELECT COUNT(*) FROM users ` + whereClause(conditions)
 
 	var count int64
-	err := r.db.QueryRow(query).Scan(&count)
+	err := r.reader(ctx).QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count users: %w", err)
 	}
 
 	return count, nil
-}
\ No newline at end of file
+}
+
+// RecentlyActive retrieves up to limit users ordered by when their personal
+// access tokens were last used, or else by when they last changed. Only
+// Postgres has personal access tokens; elsewhere users are ordered by their
+// last change alone.
+func (r *userRepository) RecentlyActive(ctx context.Context, limit int) ([]*models.User, error) {
+	activity := "updated_at"
+	if r.dialect == DialectPostgres {
+		activity = `COALESCE((
+			SELECT MAX(t.last_used_at) FROM personal_access_tokens t WHERE t.user_id = users.id
+		), updated_at)`
+	}
+	query := fmt.Sprintf(`
+		SELECT id, name, email, %s, role, created_at, updated_at, deleted_at, version
+		FROM users
+		WHERE deleted_at IS NULL
+		ORDER BY %s DESC, id DESC
+		LIMIT $1
+	`, r.ageSelect(), activity)
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recently active users: %w", err)
+	}
+
+	return scanUsers(rows)
+}
+
+// CreateWithPassword creates a new user that can log in with a password
+func (r *userRepository) CreateWithPassword(ctx context.Context, req *models.CreateUserRequest, passwordHash string) (*models.User, error) {
+	ageColumns, ageValues := r.ageInsert("$3")
+	query := fmt.Sprintf(`
+		INSERT INTO users (name, email, %s, password_hash)
+		VALUES ($1, $2, %s, $4)
+		RETURNING id, name, email, %s, role, created_at, updated_at, version
+	`, ageColumns, ageValues, r.ageSelect())
+
+	user := &models.User{}
+	err := r.queryReturning(ctx, query, 0, []interface{}{req.Name, req.Email, req.Age, passwordHash},
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Version,
+	)
+
+	if err != nil {
+		if mapped := userConstraintError(err, req.Email); mapped != nil {
+			return nil, mapped
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetCredentials retrieves a user by email including the password hash
+func (r *userRepository) GetCredentials(ctx context.Context, email string) (*models.User, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, email, %s, role, created_at, updated_at, password_hash
+		FROM users
+		WHERE email = $1 AND deleted_at IS NULL
+	`, r.ageSelect())
+
+	user := &models.User{}
+	err := r.reader(ctx).QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.PasswordHash,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user credentials: %w", err)
+	}
+
+	return user, nil
+}
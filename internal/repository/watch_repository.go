@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// watchColumns are the columns scanned by scanWatch, in order
+const watchColumns = `id, owner_id, user_id, fields, url, secret, snapshot, expires_at, created_at`
+
+// watchRepository implements WatchRepository interface
+type watchRepository struct {
+	db *sql.DB
+}
+
+// NewWatchRepository creates a new watch repository
+func NewWatchRepository(db *sql.DB) WatchRepository {
+	return &watchRepository{db: db}
+}
+
+// Create stores a new watch
+func (r *watchRepository) Create(ctx context.Context, watch *models.Watch) error {
+	snapshot, err := json.Marshal(watch.Snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode watch snapshot: %w", err)
+	}
+	fields := watch.Fields
+	if fields == nil {
+		fields = []string{}
+	}
+
+	query := `
+		INSERT INTO watches (owner_id, user_id, fields, url, secret, snapshot, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + watchColumns
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query,
+		watch.OwnerID, watch.UserID, fields, watch.URL, watch.Secret, snapshot, watch.ExpiresAt)
+	stored, err := scanWatch(row)
+	if err != nil {
+		return fmt.Errorf("failed to create watch: %w", err)
+	}
+	*watch = *stored
+	return nil
+}
+
+// GetByID retrieves a watch that has not expired
+func (r *watchRepository) GetByID(ctx context.Context, id int64) (*models.Watch, error) {
+	query := `SELECT ` + watchColumns + ` FROM watches WHERE id = $1 AND expires_at > CURRENT_TIMESTAMP`
+
+	watch, err := scanWatch(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("watch not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watch: %w", err)
+	}
+	return watch, nil
+}
+
+// ListByOwner retrieves the unexpired watches of an owner, oldest first
+func (r *watchRepository) ListByOwner(ctx context.Context, ownerID int) ([]*models.Watch, error) {
+	query := `SELECT ` + watchColumns + ` FROM watches
+		WHERE owner_id = $1 AND expires_at > CURRENT_TIMESTAMP ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watches: %w", err)
+	}
+	return scanWatches(rows)
+}
+
+// ListForUser retrieves the unexpired watches on a user, oldest first
+func (r *watchRepository) ListForUser(ctx context.Context, userID int) ([]*models.Watch, error) {
+	query := `SELECT ` + watchColumns + ` FROM watches
+		WHERE user_id = $1 AND expires_at > CURRENT_TIMESTAMP ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watches on user %d: %w", userID, err)
+	}
+	return scanWatches(rows)
+}
+
+// UpdateSnapshot replaces the values a watch last saw
+func (r *watchRepository) UpdateSnapshot(ctx context.Context, id int64, snapshot map[string]interface{}) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode watch snapshot: %w", err)
+	}
+	if _, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE watches SET snapshot = $2 WHERE id = $1`, id, data); err != nil {
+		return fmt.Errorf("failed to update watch snapshot: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a watch
+func (r *watchRepository) Delete(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM watches WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete watch: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete watch: %w", err)
+	}
+	if deleted == 0 {
+		return apperrors.NotFound("watch not found")
+	}
+	return nil
+}
+
+// DeleteExpired removes the watches that expired before now
+func (r *watchRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM watches WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired watches: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scanWatch scans a row of watchColumns
+func scanWatch(row interface{ Scan(...interface{}) error }) (*models.Watch, error) {
+	watch := &models.Watch{}
+	var snapshot []byte
+	err := row.Scan(
+		&watch.ID,
+		&watch.OwnerID,
+		&watch.UserID,
+		stringArray{&watch.Fields},
+		&watch.URL,
+		&watch.Secret,
+		&snapshot,
+		&watch.ExpiresAt,
+		&watch.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &watch.Snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode watch snapshot: %w", err)
+	}
+	return watch, nil
+}
+
+// scanWatches scans and closes rows of watchColumns
+func scanWatches(rows *sql.Rows) ([]*models.Watch, error) {
+	defer rows.Close()
+
+	var watches []*models.Watch
+	for rows.Next() {
+		watch, err := scanWatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan watch: %w", err)
+		}
+		watches = append(watches, watch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return watches, nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// webhookColumns are the columns scanned by scanWebhook, in order
+const webhookColumns = `id, url, secret, events, created_at, updated_at`
+
+// webhookRepository implements WebhookRepository interface
+type webhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new webhook subscription repository
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// Create stores a new webhook
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (url, secret, events)
+		VALUES ($1, $2, $3)
+		RETURNING ` + webhookColumns
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, webhook.URL, webhook.Secret, webhook.Events)
+	stored, err := scanWebhook(row)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	*webhook = *stored
+	return nil
+}
+
+// GetByID retrieves a webhook
+func (r *webhookRepository) GetByID(ctx context.Context, id int64) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+
+	webhook, err := scanWebhook(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("webhook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// List retrieves every webhook, oldest first
+func (r *webhookRepository) List(ctx context.Context) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return scanWebhooks(rows)
+}
+
+// Update replaces the URL, secret and events of a webhook
+func (r *webhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $2, secret = $3, events = $4
+		WHERE id = $1
+		RETURNING ` + webhookColumns
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, webhook.ID, webhook.URL, webhook.Secret, webhook.Events)
+	stored, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return apperrors.NotFound("webhook not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	*webhook = *stored
+	return nil
+}
+
+// Delete removes a webhook
+func (r *webhookRepository) Delete(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if deleted == 0 {
+		return apperrors.NotFound("webhook not found")
+	}
+	return nil
+}
+
+// ListForEvent retrieves the webhooks subscribed to event
+func (r *webhookRepository) ListForEvent(ctx context.Context, event string) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE events @> ARRAY[$1::text] ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks for %s: %w", event, err)
+	}
+	return scanWebhooks(rows)
+}
+
+// RecordFailure stores a failed delivery
+func (r *webhookRepository) RecordFailure(ctx context.Context, failure *models.WebhookFailure) error {
+	query := `
+		INSERT INTO webhook_failures (webhook_id, event_id, event, payload, attempts, last_error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, failed_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		failure.WebhookID, failure.EventID, failure.Event, failure.Payload, failure.Attempts, failure.LastError,
+	).Scan(&failure.ID, &failure.FailedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+	return nil
+}
+
+// ListFailures retrieves failed deliveries, oldest first
+func (r *webhookRepository) ListFailures(ctx context.Context, afterID int64, limit int) ([]*models.WebhookFailure, error) {
+	query := `
+		SELECT id, webhook_id, event_id, event, payload, attempts, last_error, failed_at
+		FROM webhook_failures
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook failures: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []*models.WebhookFailure
+	for rows.Next() {
+		failure := &models.WebhookFailure{}
+		err := rows.Scan(&failure.ID, &failure.WebhookID, &failure.EventID, &failure.Event,
+			&failure.Payload, &failure.Attempts, &failure.LastError, &failure.FailedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook failure: %w", err)
+		}
+		failures = append(failures, failure)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return failures, nil
+}
+
+// DeleteFailure removes a failed delivery
+func (r *webhookRepository) DeleteFailure(ctx context.Context, id int64) error {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM webhook_failures WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete webhook failure: %w", err)
+	}
+	return nil
+}
+
+// scanWebhook scans a row of webhookColumns
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Secret,
+		stringArray{&webhook.Events},
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// scanWebhooks scans and closes rows of webhookColumns
+func scanWebhooks(rows *sql.Rows) ([]*models.Webhook, error) {
+	defer rows.Close()
+
+	var webhooks []*models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return webhooks, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// queuedWriteIDPattern guards against path traversal through IDs
+var queuedWriteIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// fileWriteAheadRepository stores each queued write as a JSON file so the
+// queue survives restarts while the database is unavailable
+type fileWriteAheadRepository struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileWriteAheadRepository creates a write-ahead queue in dir
+func NewFileWriteAheadRepository(dir string) (WriteAheadRepository, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create write-ahead directory: %w", err)
+	}
+	return &fileWriteAheadRepository{dir: dir}, nil
+}
+
+// Save creates or replaces a queued write
+func (r *fileWriteAheadRepository) Save(ctx context.Context, write *models.QueuedWrite) error {
+	path, err := r.path(write.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(write)
+	if err != nil {
+		return fmt.Errorf("failed to encode queued write: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// The request may have given up while waiting for the lock
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename so a crash never leaves a partial entry
+	tmp, err := os.CreateTemp(r.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to save queued write: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save queued write: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save queued write: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save queued write: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save queued write: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a queued write by ID
+func (r *fileWriteAheadRepository) Get(ctx context.Context, id string) (*models.QueuedWrite, error) {
+	path, err := r.path(id)
+	if err != nil {
+		return nil, apperrors.NotFound("queued write not found")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return readQueuedWrite(path)
+}
+
+// ListPending returns pending writes, oldest first
+func (r *fileWriteAheadRepository) ListPending(ctx context.Context) ([]*models.QueuedWrite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued writes: %w", err)
+	}
+
+	var pending []*models.QueuedWrite
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		write, err := readQueuedWrite(filepath.Join(r.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if write.Status == models.QueuedWritePending {
+			pending = append(pending, write)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	return pending, nil
+}
+
+// path returns the file for id
+func (r *fileWriteAheadRepository) path(id string) (string, error) {
+	if !queuedWriteIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid queued write ID")
+	}
+	return filepath.Join(r.dir, id+".json"), nil
+}
+
+// readQueuedWrite decodes a queued write file
+func readQueuedWrite(path string) (*models.QueuedWrite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, apperrors.NotFound("queued write not found")
+		}
+		return nil, fmt.Errorf("failed to read queued write: %w", err)
+	}
+
+	write := &models.QueuedWrite{}
+	if err := json.Unmarshal(data, write); err != nil {
+		return nil, fmt.Errorf("failed to decode queued write: %w", err)
+	}
+	return write, nil
+}
@@ -0,0 +1,215 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/priority"
+	"github.com/pratham15541/go-crud/internal/services"
+)
+
+// buildChains defines the middleware chains available to route groups.
+// Chains extend each other so ordering is declared once here and every
+// route in a group gets exactly the same stack.
+func buildChains(deps Dependencies, publicRoutes *publicRouteSet, routeLabel func(*http.Request) string, lowercaseStatic func(string) string) *middleware.Chains {
+	cfg := deps.Config
+	chains := middleware.NewChains()
+
+	// Applied to every request, including unmatched routes
+	global := middleware.NewChain()
+	if cfg.Server.MethodOverride {
+		// Must run first so logging and routing see the effective method
+		global = global.Append(middleware.MethodOverrideMiddleware)
+	}
+	var lowercase func(string) string
+	if cfg.Server.LowercasePaths {
+		lowercase = lowercaseStatic
+	}
+	global = global.Append(middleware.PathNormalizationMiddleware(cfg.Server.TrailingSlash, lowercase))
+	if len(deps.TrustedProxies) > 0 {
+		global = global.Append(middleware.ForwardedMiddleware(deps.TrustedProxies))
+	}
+	if deps.Tracer != nil {
+		// Outside everything below so their work happens within the span
+		global = global.Append(middleware.TracingMiddleware(deps.Tracer, routeLabel))
+	}
+	if deps.Metrics != nil {
+		// After path normalization so requests are labeled with the route
+		// they are served by
+		global = global.Append(middleware.MetricsMiddleware(deps.Metrics, routeLabel))
+	}
+	if cfg.Server.Region != "" {
+		global = global.Append(middleware.RegionMiddleware(cfg.Server.Region))
+	}
+	global = global.Append(
+		middleware.RequestIDMiddleware,
+		// Anonymous until authentication names the caller
+		middleware.ActorMiddleware,
+		middleware.LoggingMiddleware(deps.Logger, deps.LogSampler),
+		// Inside logging so recovered panics are logged as 500s
+		middleware.RecoveryMiddleware,
+		// Outside authentication and rate limiting so preflight requests,
+		// which carry no credentials, are answered first
+		middleware.CORSMiddleware(cfg.CORS),
+	)
+	if cfg.Server.MaxBodyBytes > 0 {
+		// CSV imports are capped by their handler
+		bodyLimit := middleware.BodyLimitMiddleware(cfg.Server.MaxBodyBytes)
+		global = global.Append(middleware.Unless(isImportUsers(APIPrefix(cfg)), bodyLimit))
+	}
+	if cfg.Compress.Enabled {
+		// Outside response transforms so the transformed body is compressed
+		global = global.Append(middleware.CompressionMiddleware(cfg.Compress.MinSize, cfg.Compress.Brotli))
+	}
+	// Event streams last as long as the client listens, so they are neither
+	// timed out nor hold a priority slot
+	stream := isEventStream(APIPrefix(cfg))
+	if cfg.Server.RequestTimeout > 0 {
+		global = global.Append(middleware.Unless(stream, middleware.RequestTimeoutMiddleware(cfg.Server.RequestTimeout)))
+	}
+	if deps.Priority != nil {
+		// Inside the request timeout so time spent queued counts toward it
+		global = global.Append(middleware.Unless(stream, middleware.PriorityMiddleware(deps.Priority, requestClass(cfg))))
+	}
+	if cfg.Server.TransformTimeout > 0 {
+		global = global.Append(middleware.ResponseTransformMiddleware(cfg.Server.TransformTimeout, isStreaming(cfg)))
+	}
+	if deps.Locales != nil {
+		global = global.Append(middleware.LocaleMiddleware(deps.Locales))
+	}
+	if deps.ReadOnly != nil {
+		readOnly := middleware.ReadOnlyMiddleware(deps.ReadOnly)
+		if deps.WriteAhead != nil {
+			// User creation is queued rather than rejected during an outage
+			readOnly = middleware.Unless(isCreateUser(APIPrefix(cfg)), readOnly)
+		}
+		global = global.Append(readOnly)
+	}
+	if deps.Consistency != nil {
+		global = global.Append(middleware.ConsistencyMiddleware(deps.Consistency))
+	}
+	chains.Register(middleware.ChainGlobal, global)
+
+	// Anonymous access
+	chains.Register(middleware.ChainPublic, middleware.NewChain())
+
+	// Requires a valid JWT, except for anonymous requests to routes on the
+	// public path allowlist
+	keyfunc := deps.JWTKeys
+	if keyfunc == nil {
+		keyfunc = middleware.HMACKey(cfg.JWT.Secret)
+	}
+	authed := middleware.NewChain(middleware.AuthMiddleware(keyfunc, deps.Tokens))
+	if deps.Claims != nil {
+		authed = authed.Append(middleware.RefreshClaimsMiddleware(deps.Claims))
+	}
+	authedMiddleware := []middleware.Middleware{
+		middleware.Unless(publicRoutes.anonymous, authed.Middleware()),
+	}
+	if deps.Metrics != nil {
+		// After authentication, which names the caller
+		authedMiddleware = append(authedMiddleware, middleware.ActivityMiddleware(deps.Metrics))
+	}
+	if len(deps.TenantLocales) > 0 {
+		// After authentication, which names the tenant
+		authedMiddleware = append(authedMiddleware, middleware.TenantFormatMiddleware(deps.TenantLocales))
+	}
+	// After authentication, which finds the token's signing key, and before
+	// auditing, which records the signature
+	authedMiddleware = append(authedMiddleware,
+		middleware.RequestSigningMiddleware(cfg.Tokens.RequireSignatures, cfg.Tokens.SignatureMaxSkew))
+	if deps.Audit != nil {
+		// After authentication so entries name the caller
+		authedMiddleware = append(authedMiddleware, middleware.AuditMiddleware(deps.Audit))
+	}
+	chains.Extend(middleware.ChainAuthed, middleware.ChainPublic, authedMiddleware...)
+
+	// Requires a valid JWT carrying the admin role. The role is refreshed
+	// onto any token, so scoped tokens also need the admin scope.
+	chains.Extend(middleware.ChainAdmin, middleware.ChainAuthed,
+		middleware.RequireRole(models.RoleAdmin),
+		middleware.RequireScope(services.ScopeAdmin),
+	)
+
+	// Only reachable from loopback or private networks
+	chains.Extend(middleware.ChainInternal, middleware.ChainPublic,
+		middleware.InternalOnlyMiddleware,
+	)
+
+	return chains
+}
+
+// isCreateUser returns a predicate reporting whether r is POST /users under
+// apiPrefix. The global chain runs before routing, so the path is compared
+// directly.
+func isCreateUser(apiPrefix string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		return r.Method == http.MethodPost && r.URL.Path == apiPrefix+"/users"
+	}
+}
+
+// isImportUsers returns a predicate reporting whether r is POST
+// /users/import under apiPrefix. Like isCreateUser it runs before routing.
+func isImportUsers(apiPrefix string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		return r.Method == http.MethodPost && r.URL.Path == apiPrefix+"/users/import"
+	}
+}
+
+// isEventStream returns a predicate reporting whether r opens the watch
+// event stream under apiPrefix. Like isCreateUser it runs before routing.
+func isEventStream(apiPrefix string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		return r.Method == http.MethodGet && r.URL.Path == apiPrefix+"/watches/stream"
+	}
+}
+
+// isStreaming returns a predicate reporting whether r is for a response
+// written as it is produced: the user export, the watch event stream or the
+// WebSocket. Like isCreateUser it runs before routing.
+func isStreaming(cfg *config.Config) func(r *http.Request) bool {
+	apiPrefix := APIPrefix(cfg)
+	stream := isEventStream(apiPrefix)
+	return func(r *http.Request) bool {
+		if r.Method != http.MethodGet {
+			return false
+		}
+		return stream(r) || r.URL.Path == apiPrefix+"/users/export" || r.URL.Path == cfg.Server.BasePath+"/ws"
+	}
+}
+
+// requestClass returns a function assigning requests their priority class.
+// Like isCreateUser it runs before routing, so paths are compared directly.
+func requestClass(cfg *config.Config) func(r *http.Request) priority.Class {
+	apiPrefix := APIPrefix(cfg)
+	critical := map[string]bool{
+		cfg.Server.BasePath + "/readyz":  true,
+		cfg.Server.BasePath + "/metrics": true,
+		apiPrefix + "/health":            true,
+		apiPrefix + "/oauth/token":       true,
+	}
+	bulk := map[string]bool{
+		apiPrefix + "/users/bulk":       true,
+		apiPrefix + "/users/export":     true,
+		apiPrefix + "/users/import":     true,
+		apiPrefix + "/admin/cache/warm": true,
+	}
+
+	return func(r *http.Request) priority.Class {
+		path := r.URL.Path
+		switch {
+		case critical[path] || strings.HasPrefix(path, apiPrefix+"/auth/") ||
+			strings.HasPrefix(path, cfg.Server.BasePath+"/.well-known/"):
+			return priority.Critical
+		case bulk[path]:
+			return priority.Bulk
+		case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+			return priority.Read
+		default:
+			return priority.Write
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// candidateMethods are the methods probed when building an Allow header
+var candidateMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// methodNotAllowedHandler responds with 405 and an Allow header listing the
+// methods registered for the requested path
+func (r *Router) methodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		allowed := r.allowedMethods(req)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+		writeError(w, "Method "+req.Method+" is not allowed on "+req.URL.Path, http.StatusMethodNotAllowed)
+	})
+}
+
+// allowedMethods returns the methods that have a route matching the request path
+func (r *Router) allowedMethods(req *http.Request) []string {
+	allowed := []string{http.MethodOptions}
+	for _, method := range candidateMethods {
+		probe := req.Clone(req.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+		if r.mux.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
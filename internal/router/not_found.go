@@ -0,0 +1,178 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// maxSuggestions caps the number of routes suggested on a 404
+const maxSuggestions = 3
+
+// templateVarPattern matches mux path variables such as {id:[0-9]+}
+var templateVarPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+
+// notFoundHandler responds with a JSON 404 that suggests the closest
+// registered routes to the attempted path
+func (r *Router) notFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		resp := models.RouteNotFoundResponse{
+			ErrorResponse: models.ErrorResponse{
+				Error:   http.StatusText(http.StatusNotFound),
+				Message: "No route matches " + req.Method + " " + req.URL.Path,
+				Code:    http.StatusNotFound,
+			},
+			Path:        req.URL.Path,
+			BasePath:    r.apiPrefix,
+			Suggestions: r.suggestRoutes(req.URL.Path),
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// suggestRoutes returns the registered route templates closest to path
+func (r *Router) suggestRoutes(path string) []string {
+	type candidate struct {
+		template string
+		distance int
+	}
+
+	path = strings.ToLower(path)
+	threshold := len(path)/3 + 2
+
+	var candidates []candidate
+	for _, template := range r.pathTemplates() {
+		distance := pathDistance(path, strings.ToLower(template))
+		// Forgetting the base path is the most common mistake
+		for _, prefix := range []string{r.apiPrefix, r.basePath} {
+			if prefix != "" && !strings.HasPrefix(path, strings.ToLower(prefix)) {
+				distance = min(distance, pathDistance(strings.ToLower(prefix)+path, strings.ToLower(template)))
+			}
+		}
+		if distance <= threshold {
+			candidates = append(candidates, candidate{template: template, distance: distance})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].template)
+	}
+	return suggestions
+}
+
+// pathTemplates returns the unique path templates of all registered routes
+// with variable patterns stripped, e.g. /api/v1/users/{id}
+func (r *Router) pathTemplates() []string {
+	r.templatesOnce.Do(func() {
+		seen := make(map[string]bool)
+		r.mux.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			if route.GetHandler() == nil {
+				return nil
+			}
+			template, err := route.GetPathTemplate()
+			if err != nil {
+				return nil
+			}
+			template = templateVarPattern.ReplaceAllString(template, "{$1}")
+			if !seen[template] {
+				seen[template] = true
+				r.templates = append(r.templates, template)
+			}
+			return nil
+		})
+	})
+	return r.templates
+}
+
+// lowercaseStatic lowercases the segments of path a route template spells
+// out, so /Users/Export becomes /users/export, and keeps path variables such
+// as encoded IDs, emails and device codes as sent. Paths no template matches
+// are returned unchanged.
+func (r *Router) lowercaseStatic(path string) string {
+	segments := strings.Split(path, "/")
+	canonical, best := path, -1
+	for _, template := range r.pathTemplates() {
+		// Prefer /users/export over /users/{id}
+		if folded, static, ok := foldToTemplate(segments, template); ok && static > best {
+			canonical, best = folded, static
+		}
+	}
+	return canonical
+}
+
+// foldToTemplate returns segments with each one template spells out in the
+// template's case, and how many those are, if segments match template
+// ignoring case. Templates ending in a slash are prefixes.
+func foldToTemplate(segments []string, template string) (string, int, bool) {
+	templateSegments := strings.Split(template, "/")
+	prefix := len(templateSegments) > 2 && templateSegments[len(templateSegments)-1] == ""
+	if prefix {
+		templateSegments = templateSegments[:len(templateSegments)-1]
+	}
+	if len(segments) < len(templateSegments) || (!prefix && len(segments) != len(templateSegments)) {
+		return "", 0, false
+	}
+
+	folded := append([]string(nil), segments...)
+	static := 0
+	for i, segment := range templateSegments {
+		if strings.Contains(segment, "{") {
+			continue
+		}
+		if !strings.EqualFold(segments[i], segment) {
+			return "", 0, false
+		}
+		folded[i] = segment
+		static++
+	}
+	return strings.Join(folded, "/"), static, true
+}
+
+// pathDistance returns the edit distance between path and template, treating
+// template variables as matching whatever segment the path has in that position
+func pathDistance(path, template string) int {
+	pathSegments := strings.Split(path, "/")
+	for i, segment := range strings.Split(template, "/") {
+		if i < len(pathSegments) && strings.HasPrefix(segment, "{") && pathSegments[i] != "" {
+			pathSegments[i] = segment
+		}
+	}
+	return levenshtein(strings.Join(pathSegments, "/"), template)
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/apidocs"
+)
+
+// anyMethod matches public path entries that do not name a method
+const anyMethod = "*"
+
+// publicRouteSet is the allowlist of authed routes that skip authentication.
+// Entries are matched against the route template, so "GET /users/{id}"
+// covers every user ID.
+type publicRouteSet struct {
+	routes map[string]bool
+	// apiPrefix is trimmed from route templates; entries are relative to it
+	apiPrefix string
+}
+
+// newPublicRouteSet parses entries of the form "/path" or "METHOD /path",
+// relative to apiPrefix
+func newPublicRouteSet(entries []string, apiPrefix string) *publicRouteSet {
+	set := &publicRouteSet{routes: make(map[string]bool, len(entries)), apiPrefix: apiPrefix}
+	for _, entry := range entries {
+		fields := strings.Fields(entry)
+		switch len(fields) {
+		case 1:
+			set.routes[publicRouteKey(anyMethod, fields[0])] = true
+		case 2:
+			set.routes[publicRouteKey(strings.ToUpper(fields[0]), fields[1])] = true
+		}
+	}
+	return set
+}
+
+// matches reports whether the route that matched r is on the allowlist
+func (s *publicRouteSet) matches(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return false
+	}
+	return s.contains(r.Method, template)
+}
+
+// anonymous reports whether r may skip authentication: its route is on the
+// allowlist and it carries no credentials. Tokens sent to public routes are
+// still verified so handlers can tell who is calling.
+func (s *publicRouteSet) anonymous(r *http.Request) bool {
+	return r.Header.Get("Authorization") == "" && s.matches(r)
+}
+
+// contains reports whether method and the full route template are on the allowlist
+func (s *publicRouteSet) contains(method, template string) bool {
+	path := strings.TrimPrefix(template, s.apiPrefix)
+	return s.routes[publicRouteKey(anyMethod, path)] || s.routes[publicRouteKey(method, path)]
+}
+
+// publicRouteKey normalizes an allowlist entry for lookup
+func publicRouteKey(method, path string) string {
+	return method + " " + apidocs.OpenAPIPath(path)
+}
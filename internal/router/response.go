@@ -0,0 +1,35 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// writeError sends a JSON error response
+func writeError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	errorResp := models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	}
+
+	json.NewEncoder(w).Encode(errorResp)
+}
+
+// writeSuccess sends a JSON success response
+func writeSuccess(w http.ResponseWriter, message string, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	successResp := models.SuccessResponse{
+		Message: message,
+		Data:    data,
+	}
+
+	json.NewEncoder(w).Encode(successResp)
+}
@@ -0,0 +1,259 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/apidocs"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/metrics"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/priority"
+	"github.com/pratham15541/go-crud/internal/ratelimit"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// APIBasePath is the prefix under which all API routes are mounted, below
+// the configured BASE_PATH
+const APIBasePath = "/api/v1"
+
+// APIPrefix returns the path API routes are served under: BASE_PATH followed
+// by APIBasePath. URLs handed to clients, such as Location headers, start
+// with it.
+func APIPrefix(cfg *config.Config) string {
+	return cfg.Server.BasePath + APIBasePath
+}
+
+// Dependencies holds everything the router needs to register routes
+type Dependencies struct {
+	Config        *config.Config
+	UserHandler   *handlers.UserHandler
+	AuthHandler   *handlers.AuthHandler
+	HealthHandler *handlers.HealthHandler
+	Readiness     *handlers.ReadinessHandler
+	OIDCHandler   *handlers.OIDCHandler
+	TokenHandler  *handlers.TokenHandler
+	Introspection *handlers.IntrospectionHandler
+	Changelog     *handlers.ChangelogHandler
+	Meta          *handlers.MetaHandler
+	WriteAhead    *handlers.WriteAheadHandler
+	Operations    *handlers.OperationHandler
+	Transitions   *handlers.TransitionHandler
+	Settings      *handlers.SettingsHandler
+	Emails        *handlers.EmailHandler
+	Webhooks      *handlers.WebhookHandler
+	Watches       *handlers.WatchHandler
+	Approvals     *handlers.ApprovalHandler
+	Live          *handlers.LiveHandler
+	Dashboard     *handlers.DashboardHandler
+	Cache         *handlers.CacheHandler
+	Support       *handlers.SupportHandler
+	Runbook       *handlers.RunbookHandler
+	Locales       *i18n.Negotiator
+	// IDs encodes user IDs in paths; nil uses plain integers
+	IDs idcodec.Codec
+	// TenantLocales sets the formatting locale of listed tenants' requests
+	TenantLocales i18n.TenantLocales
+
+	// JWTKeys verifies JWTs; nil verifies them with the configured secret
+	JWTKeys jwt.Keyfunc
+	// Tokens validates personal access tokens presented as bearer tokens
+	Tokens middleware.TokenAuthenticator
+	// Claims refreshes token claims with the user's current role
+	Claims middleware.ClaimsLoader
+	// ReadOnly switches the API to read-only mode while the primary database is down
+	ReadOnly middleware.ReadOnlyReporter
+	// Consistency issues consistency tokens after writes, and routes reads
+	// presenting one away from a replica behind it; nil without a replica
+	Consistency middleware.ConsistencyTracker
+	// Audit records mutating requests in the audit log; nil disables auditing
+	Audit        middleware.AuditRecorder
+	AuditHandler *handlers.AuditHandler
+	// Metrics instruments requests and is served on /metrics; nil disables it
+	Metrics *metrics.Metrics
+	// MemoryPressure sheds heavy requests while memory is short; nil
+	// disables shedding
+	MemoryPressure middleware.MemoryPressureReporter
+	// Gateway serves the JSON API generated from proto/user.proto under
+	// /api/v2; nil disables it
+	Gateway http.Handler
+	// TrustedProxies are the load balancers whose X-Forwarded-* headers
+	// set the origin of absolute URLs in responses
+	TrustedProxies forwarded.Proxies
+	// Priority admits requests by class while the server is saturated; nil
+	// admits every request
+	Priority *priority.Scheduler
+	// RateLimiter limits requests to the API per client IP; nil disables it
+	RateLimiter ratelimit.Limiter
+	// Tracer starts a span for every request; nil disables tracing
+	Tracer trace.Tracer
+	// Logger logs requests and the route table; nil discards them
+	Logger *zap.Logger
+	// LogSampler thins out request logs of noisy categories; nil logs every request
+	LogSampler *logger.Sampler
+}
+
+// Router owns the mux router and the named middleware chains routes are served through
+type Router struct {
+	mux *mux.Router
+	// root serves routes outside the API base path, under BASE_PATH
+	root *mux.Router
+	api  *mux.Router
+	// basePath is BASE_PATH and apiPrefix the path API routes are under
+	basePath  string
+	apiPrefix string
+	chains    *middleware.Chains
+	routes    map[*mux.Route]routeMeta
+	logger    *zap.Logger
+
+	// public lists authed routes that are reachable without a token
+	public *publicRouteSet
+
+	// examples records request/response examples in debug mode, or is nil
+	examples *apidocs.Recorder
+
+	templatesOnce sync.Once
+	templates     []string
+}
+
+// New creates a router with every API route registered
+func New(deps Dependencies) *Router {
+	if deps.Logger == nil {
+		deps.Logger = zap.NewNop()
+	}
+	public := newPublicRouteSet(deps.Config.Auth.PublicPaths, APIPrefix(deps.Config))
+	r := &Router{
+		mux:       mux.NewRouter(),
+		basePath:  deps.Config.Server.BasePath,
+		apiPrefix: APIPrefix(deps.Config),
+		routes:    make(map[*mux.Route]routeMeta),
+		logger:    deps.Logger,
+		public:    public,
+	}
+	r.chains = buildChains(deps, public, r.routeLabel, r.lowercaseStatic)
+	if deps.Config.Server.RecordExamples && deps.Config.Server.Mode == "debug" {
+		r.examples = apidocs.NewRecorder()
+	}
+	r.root = r.mux
+	if r.basePath != "" {
+		r.root = r.mux.PathPrefix(r.basePath).Subrouter()
+	}
+	r.api = r.root.PathPrefix(APIBasePath).Subrouter()
+	if deps.RateLimiter != nil {
+		// Runs before the group chains, so limited clients never reach
+		// authentication. Routes outside the API base path are not limited.
+		r.api.Use(mux.MiddlewareFunc(middleware.RateLimitMiddleware(deps.RateLimiter)))
+	}
+	r.mux.MethodNotAllowedHandler = r.methodNotAllowedHandler()
+	r.mux.NotFoundHandler = r.notFoundHandler()
+
+	r.registerRoutes(deps)
+
+	return r
+}
+
+// Handler returns the root handler with the global chain applied. Global
+// middleware wraps the whole mux so it also runs for unmatched routes and
+// CORS preflight requests.
+func (r *Router) Handler() http.Handler {
+	return r.chains.Get(middleware.ChainGlobal).Then(r.mux)
+}
+
+// routeLabel returns the template of the route req matches, in OpenAPI form,
+// or "unmatched". Metrics are labeled with it before routing happens.
+func (r *Router) routeLabel(req *http.Request) string {
+	var match mux.RouteMatch
+	if r.mux.Match(req, &match) && match.Route != nil {
+		if template, err := match.Route.GetPathTemplate(); err == nil {
+			return apidocs.OpenAPIPath(template)
+		}
+	}
+	return "unmatched"
+}
+
+// Group returns a route group under the API base path whose routes run
+// through the named chain
+func (r *Router) Group(prefix, chain string) *Group {
+	return &Group{
+		router:    r,
+		mux:       r.api,
+		prefix:    prefix,
+		chainName: chain,
+		chain:     r.chains.Get(chain),
+	}
+}
+
+// RootGroup returns a route group outside the API base path, for endpoints
+// whose location is fixed by a specification such as /.well-known. It is
+// still under BASE_PATH.
+func (r *Router) RootGroup(prefix, chain string) *Group {
+	group := r.Group(prefix, chain)
+	group.mux = r.root
+	return group
+}
+
+// Group registers routes that share a path prefix and middleware chain
+type Group struct {
+	router    *Router
+	mux       *mux.Router
+	prefix    string
+	chainName string
+	chain     middleware.Chain
+}
+
+// With returns a group sharing this group's prefix whose routes also run
+// through mw, after the group's chain
+func (g *Group) With(mw ...middleware.Middleware) *Group {
+	group := *g
+	group.chain = g.chain.Append(mw...)
+	return &group
+}
+
+// Handle registers handler for path relative to the group prefix
+func (g *Group) Handle(path string, handler http.Handler) *mux.Route {
+	wrapped := g.chain.Then(handler)
+	// Tooling routes such as /_routes are not part of the public API
+	if g.router.examples != nil && !strings.HasPrefix(path, "/_") {
+		wrapped = g.router.examples.Wrap(g.basePath()+g.prefix+path, wrapped)
+	}
+
+	route := g.mux.Handle(g.prefix+path, wrapped)
+	g.router.routes[route] = routeMeta{
+		chain:   g.chainName,
+		handler: handlerName(handler),
+	}
+	return route
+}
+
+// HandlePrefix registers handler for every path under prefix, relative to
+// the group prefix. Such routes are not recorded as examples.
+func (g *Group) HandlePrefix(prefix string, handler http.Handler) *mux.Route {
+	route := g.mux.PathPrefix(g.prefix + prefix).Handler(g.chain.Then(handler))
+	g.router.routes[route] = routeMeta{
+		chain:   g.chainName,
+		handler: handlerName(handler),
+	}
+	return route
+}
+
+// HandleFunc registers fn for path relative to the group prefix
+func (g *Group) HandleFunc(path string, fn http.HandlerFunc) *mux.Route {
+	return g.Handle(path, fn)
+}
+
+// basePath returns the path the group's mux is mounted under
+func (g *Group) basePath() string {
+	if g.mux == g.router.api {
+		return g.router.apiPrefix
+	}
+	return g.router.basePath
+}
@@ -0,0 +1,296 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/pratham15541/go-crud/docs/swagger"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/openapi"
+	"github.com/pratham15541/go-crud/internal/services"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"go.uber.org/zap"
+)
+
+// registerRoutes registers every API route in its route group
+func (r *Router) registerRoutes(deps Dependencies) {
+	// User IDs in paths are in the public form produced by the ID codec
+	ids := deps.IDs
+	if ids == nil {
+		ids = idcodec.Plain{}
+	}
+	userID := "/{id:" + ids.Pattern() + "}"
+
+	// Routes that hold many users in memory at once are turned away while
+	// memory is short
+	shedHeavy := func(g *Group) *Group {
+		if deps.MemoryPressure == nil {
+			return g
+		}
+		return g.With(middleware.ShedUnderMemoryPressure(deps.MemoryPressure))
+	}
+
+	// System routes; public through the AUTH_PUBLIC_PATHS allowlist
+	system := r.Group("", middleware.ChainAuthed)
+	system.HandleFunc("/health", deps.HealthHandler.HealthCheck).Methods("GET")
+	system.HandleFunc("/version", deps.HealthHandler.Version).Methods("GET")
+	if deps.Changelog != nil {
+		system.HandleFunc("/changelog", deps.Changelog.GetChangelog).Methods("GET")
+	}
+	if deps.Meta != nil {
+		system.HandleFunc("/_meta", deps.Meta.GetCapabilities).Methods("GET")
+	}
+
+	// Readiness probe, outside the API base path like /metrics
+	if deps.Readiness != nil {
+		probes := r.RootGroup("", middleware.ChainPublic)
+		probes.HandleFunc("/readyz", deps.Readiness.Ready).Methods("GET")
+	}
+
+	// Exports stream every user and imports create users in bulk, so only
+	// admins may run them. Registered before the user routes so "export" is
+	// never taken for a user ID.
+	exports := shedHeavy(r.Group("/users", middleware.ChainAdmin))
+	exports.HandleFunc("/export", deps.UserHandler.ExportUsers).Methods("GET")
+	exports.HandleFunc("/import", deps.UserHandler.ImportUsers).Methods("POST")
+
+	// User routes; reads are public by default, mutations need users:write
+	users := r.Group("/users", middleware.ChainAuthed)
+	shedHeavy(users).HandleFunc("", deps.UserHandler.GetUsers).Methods("GET")
+	users.HandleFunc(userID, deps.UserHandler.GetUser).Methods("GET")
+
+	userWrites := users.With(middleware.RequireScope(services.ScopeUsersWrite))
+	if deps.WriteAhead != nil {
+		userWrites.HandleFunc("", deps.WriteAhead.CreateUser).Methods("POST")
+		userWrites.HandleFunc("/queued/{id}", deps.WriteAhead.GetQueuedWrite).Methods("GET")
+	} else {
+		userWrites.HandleFunc("", deps.UserHandler.CreateUser).Methods("POST")
+	}
+	shedHeavy(userWrites).HandleFunc("/bulk", deps.UserHandler.CreateUsers).Methods("POST")
+	userWrites.HandleFunc("/by-email/{email}", deps.UserHandler.UpsertUser).Methods("PUT")
+	userWrites.HandleFunc(userID, deps.UserHandler.UpdateUser).Methods("PUT")
+	userWrites.HandleFunc(userID, deps.UserHandler.PatchUser).Methods("PATCH")
+	userWrites.HandleFunc(userID, deps.UserHandler.DeleteUser).Methods("DELETE")
+	userWrites.HandleFunc(userID+"/restore", deps.UserHandler.RestoreUser).Methods("POST")
+
+	// Settings documents; unlike profiles, reading them needs a token
+	if deps.Settings != nil {
+		users.HandleFunc(userID+"/settings", deps.Settings.GetSettings).Methods("GET")
+		userWrites.HandleFunc(userID+"/settings", deps.Settings.UpdateSettings).Methods("PUT")
+	}
+
+	// Legal holds block deleting and anonymizing a user
+	holds := r.Group("/users", middleware.ChainAdmin)
+	holds.HandleFunc(userID+"/legal-hold", deps.UserHandler.PlaceLegalHold).Methods("PUT")
+	holds.HandleFunc(userID+"/legal-hold", deps.UserHandler.LiftLegalHold).Methods("DELETE")
+
+	// Long-running operations
+	if deps.Operations != nil {
+		operations := r.Group("/operations", middleware.ChainAuthed)
+		operations.HandleFunc("/{id}", deps.Operations.GetOperation).Methods("GET")
+
+		userAdmin := r.Group("/users", middleware.ChainAdmin)
+		userAdmin.HandleFunc(userID+"/anonymize", deps.Operations.AnonymizeUser).Methods("POST")
+	}
+
+	// Schema transition consistency checks
+	if deps.Transitions != nil {
+		transitions := r.Group("/transitions", middleware.ChainAdmin)
+		transitions.HandleFunc("/{name}/check", deps.Transitions.CheckTransition).Methods("GET")
+	}
+
+	// Audit log and hash chain verification
+	if deps.AuditHandler != nil {
+		audit := r.Group("/audit", middleware.ChainAdmin)
+		audit.HandleFunc("", deps.AuditHandler.ListEntries).Methods("GET")
+		audit.HandleFunc("/verify", deps.AuditHandler.VerifyChain).Methods("GET")
+	}
+
+	// Email outbox and provider delivery notifications. The webhook is
+	// only served when a secret is configured to verify it with.
+	if deps.Emails != nil {
+		adminEmails := r.Group("/admin/emails", middleware.ChainAdmin)
+		adminEmails.HandleFunc("", deps.Emails.ListEmails).Methods("GET")
+
+		if deps.Config.Email.WebhookSecret != "" {
+			webhooks := r.Group("/webhooks", middleware.ChainPublic)
+			webhooks.HandleFunc("/email", deps.Emails.ReceiveEvents).Methods("POST")
+		}
+	}
+
+	// Self-registrations awaiting an admin's decision
+	if deps.Approvals != nil {
+		approvals := r.Group("/admin/approvals", middleware.ChainAdmin)
+		approvals.HandleFunc("", deps.Approvals.ListApprovals).Methods("GET")
+		approvals.HandleFunc(userID+"/approve", deps.Approvals.Approve).Methods("POST")
+		approvals.HandleFunc(userID+"/reject", deps.Approvals.Reject).Methods("POST")
+	}
+
+	// Webhook subscriptions to user lifecycle events. IDs are numeric, so
+	// /webhooks/email is never taken for one.
+	if deps.Webhooks != nil {
+		webhookAdmin := r.Group("/webhooks", middleware.ChainAdmin)
+		webhookAdmin.HandleFunc("", deps.Webhooks.ListWebhooks).Methods("GET")
+		webhookAdmin.HandleFunc("", deps.Webhooks.CreateWebhook).Methods("POST")
+		webhookAdmin.HandleFunc("/{id:[0-9]+}", deps.Webhooks.GetWebhook).Methods("GET")
+		webhookAdmin.HandleFunc("/{id:[0-9]+}", deps.Webhooks.UpdateWebhook).Methods("PUT")
+		webhookAdmin.HandleFunc("/{id:[0-9]+}", deps.Webhooks.DeleteWebhook).Methods("DELETE")
+	}
+
+	// Watches on users of the current user, and the event stream of their
+	// notifications
+	if deps.Watches != nil {
+		watches := r.Group("/watches", middleware.ChainAuthed).With(middleware.RequireScope(services.ScopeUsersRead))
+		watches.HandleFunc("", deps.Watches.ListWatches).Methods("GET")
+		watches.HandleFunc("", deps.Watches.CreateWatch).Methods("POST")
+		watches.HandleFunc("/stream", deps.Watches.Stream).Methods("GET")
+		watches.HandleFunc("/{id:[0-9]+}", deps.Watches.GetWatch).Methods("GET")
+		watches.HandleFunc("/{id:[0-9]+}", deps.Watches.DeleteWatch).Methods("DELETE")
+	}
+
+	// Recent traffic summary for admins without Prometheus
+	if deps.Dashboard != nil {
+		dashboard := r.Group("/admin", middleware.ChainAdmin)
+		dashboard.HandleFunc("/dashboard", deps.Dashboard.GetDashboard).Methods("GET")
+	}
+
+	// Preloading the cache, such as after a deploy
+	if deps.Cache != nil {
+		adminCache := r.Group("/admin/cache", middleware.ChainAdmin)
+		adminCache.HandleFunc("/warm", deps.Cache.WarmCache).Methods("POST")
+	}
+
+	// Diagnostics to attach to support tickets
+	if deps.Support != nil {
+		support := r.Group("/admin", middleware.ChainAdmin)
+		support.HandleFunc("/support-bundle", deps.Support.GetBundle).Methods("GET")
+	}
+
+	// Operational actions replacing manual database and cache changes. Each
+	// is audited, as the runbook is only served with the audit log on.
+	// Scoped tokens need admin:runbook rather than the admin scope.
+	if deps.Runbook != nil {
+		runbook := r.Group("/admin/runbook", middleware.ChainAuthed).With(
+			middleware.RequireRole(models.RoleAdmin),
+			middleware.RequireScope(services.ScopeAdminRunbook),
+		)
+		runbook.HandleFunc("/caches/flush", deps.Runbook.FlushCaches).Methods("POST")
+		runbook.HandleFunc("/jwt-keys/rotate", deps.Runbook.RotateJWTKey).Methods("POST")
+		runbook.HandleFunc("/migrations/run", deps.Runbook.RunMigrations).Methods("POST")
+		runbook.HandleFunc("/dead-letters/replay", deps.Runbook.ReplayDeadLetters).Methods("POST")
+		runbook.HandleFunc("/webhooks/redeliver", deps.Runbook.RedeliverWebhooks).Methods("POST")
+	}
+
+	// Registration and login
+	if deps.AuthHandler != nil {
+		auth := r.Group("/auth", middleware.ChainAuthed)
+		auth.HandleFunc("/register", deps.AuthHandler.Register).Methods("POST")
+		auth.HandleFunc("/login", deps.AuthHandler.Login).Methods("POST")
+	}
+
+	// Token introspection for other services
+	if deps.Introspection != nil {
+		introspection := r.Group("/auth", middleware.ChainInternal)
+		introspection.HandleFunc("/introspect", deps.Introspection.Introspect).Methods("POST")
+	}
+
+	// Personal access tokens of the current user
+	if deps.TokenHandler != nil {
+		tokens := r.Group("/me/tokens", middleware.ChainAuthed)
+		tokens.HandleFunc("", deps.TokenHandler.ListTokens).Methods("GET")
+		tokens.HandleFunc("", deps.TokenHandler.CreateToken).Methods("POST")
+		tokens.HandleFunc("/{id:[0-9]+}", deps.TokenHandler.RevokeToken).Methods("DELETE")
+		tokens.HandleFunc("/{id:[0-9]+}/signing-key", deps.TokenHandler.SetSigningKey).Methods("PUT")
+		tokens.HandleFunc("/{id:[0-9]+}/signing-key", deps.TokenHandler.RemoveSigningKey).Methods("DELETE")
+	}
+
+	// OpenID Connect provider
+	if deps.OIDCHandler != nil {
+		wellKnown := r.RootGroup("/.well-known", middleware.ChainPublic)
+		wellKnown.HandleFunc("/openid-configuration", deps.OIDCHandler.Discovery).Methods("GET")
+		wellKnown.HandleFunc("/jwks.json", deps.OIDCHandler.JWKS).Methods("GET")
+
+		// Pages users open in a browser; they sign in on the page
+		pages := r.RootGroup("", middleware.ChainPublic)
+		pages.HandleFunc("/device", deps.OIDCHandler.DevicePage).Methods("GET")
+		pages.HandleFunc("/authorize", deps.OIDCHandler.AuthorizePage).Methods("GET")
+
+		oauth := r.Group("/oauth", middleware.ChainPublic)
+		oauth.HandleFunc("/token", deps.OIDCHandler.Token).Methods("POST")
+		oauth.HandleFunc("/device_authorization", deps.OIDCHandler.DeviceAuthorization).Methods("POST")
+
+		oauthUser := r.Group("/oauth", middleware.ChainAuthed)
+		oauthUser.HandleFunc("/authorize", deps.OIDCHandler.Authorize).Methods("GET")
+		oauthUser.HandleFunc("/authorize", deps.OIDCHandler.DecideAuthorization).Methods("POST")
+		oauthUser.HandleFunc("/userinfo", deps.OIDCHandler.UserInfo).Methods("GET")
+		oauthUser.HandleFunc("/device/approve", deps.OIDCHandler.DecideDevice).Methods("POST")
+		oauthUser.HandleFunc("/device/{user_code}", deps.OIDCHandler.GetPendingDevice).Methods("GET")
+
+		oauthAdmin := r.Group("/oauth", middleware.ChainAdmin)
+		oauthAdmin.HandleFunc("/clients", deps.OIDCHandler.RegisterClient).Methods("POST")
+	}
+
+	// Live user updates over WebSocket. The handler returns once the
+	// connection is upgraded, so the request timeout and priority slot do
+	// not last as long as the connection.
+	if deps.Live != nil {
+		live := r.RootGroup("", middleware.ChainAuthed).With(middleware.RequireScope(services.ScopeUsersRead))
+		live.HandleFunc("/ws", deps.Live.Connect).Methods("GET")
+	}
+
+	// Prometheus scrape endpoint, outside the API base path by convention
+	if deps.Metrics != nil {
+		scrape := r.RootGroup("", middleware.ChainInternal)
+		scrape.Handle("/metrics", deps.Metrics.Handler()).Methods("GET")
+	}
+
+	// JSON API generated from proto/user.proto. The gRPC interceptors behind
+	// the gateway authenticate its calls.
+	if deps.Gateway != nil {
+		gateway := r.RootGroup("/api/v2", middleware.ChainPublic)
+		gateway.HandlePrefix("/", http.StripPrefix(r.basePath, deps.Gateway))
+	}
+	// Swagger UI and the OpenAPI document it renders, generated into
+	// docs/swagger by `make swagger`
+	if deps.Config.Server.Swagger {
+		swagger.SwaggerInfo.BasePath = r.apiPrefix
+		// Requests tried from the UI go to the host that served it
+		swagger.SwaggerInfo.Host = ""
+		swaggerUI := r.RootGroup("/swagger", middleware.ChainPublic)
+		swaggerUI.HandlePrefix("/", httpSwagger.Handler(httpSwagger.URL(r.basePath+"/swagger/doc.json"))).Methods("GET")
+		// Trailing slash normalization sends /swagger/ here
+		swaggerUI.Handle("", http.RedirectHandler(r.basePath+"/swagger/index.html", http.StatusFound)).Methods("GET")
+
+		// The same document as OpenAPI 3, and its models as JSON Schemas
+		spec, err := openapi.New([]byte(swagger.SwaggerInfo.ReadDoc()))
+		if err != nil {
+			r.logger.Error("failed to convert the Swagger document to OpenAPI 3", zap.Error(err))
+		} else {
+			docs := handlers.NewOpenAPIHandler(spec, deps.Config.Server.OpenAPIVersion, r.basePath)
+			openAPI := r.RootGroup("", middleware.ChainPublic)
+			openAPI.HandleFunc("/openapi.json", docs.GetJSON).Methods("GET")
+			openAPI.HandleFunc("/openapi.yaml", docs.GetYAML).Methods("GET")
+			openAPI.HandleFunc("/schemas", docs.ListSchemas).Methods("GET")
+			openAPI.HandleFunc("/schemas/{model:[A-Za-z0-9_.]+}.json", docs.GetSchema).Methods("GET")
+		}
+	}
+
+	// Developer tooling
+	tooling := r.Group("", toolingChain(deps.Config))
+	tooling.HandleFunc("/_routes", r.listRoutes).Methods("GET")
+	if r.examples != nil {
+		tooling.HandleFunc("/_examples", r.listExamples).Methods("GET")
+	}
+}
+
+// toolingChain returns the chain for developer tooling routes: internal
+// network access in debug mode, admin tokens otherwise
+func toolingChain(cfg *config.Config) string {
+	if cfg.Server.Mode == "debug" {
+		return middleware.ChainInternal
+	}
+	return middleware.ChainAdmin
+}
@@ -0,0 +1,118 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"go.uber.org/zap"
+)
+
+// routeMeta records how a route was registered
+type routeMeta struct {
+	chain   string
+	handler string
+}
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Methods []string `json:"methods"`
+	Path    string   `json:"path"`
+	Chain   string   `json:"chain"`
+	Public  bool     `json:"public"`
+	Handler string   `json:"handler"`
+}
+
+// Routes returns every registered route sorted by path
+func (r *Router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	r.mux.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		meta, ok := r.routes[route]
+		if !ok {
+			return nil
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"*"}
+		}
+
+		routes = append(routes, RouteInfo{
+			Methods: methods,
+			Path:    path,
+			Chain:   meta.chain,
+			Public:  r.isPublic(meta.chain, methods, path),
+			Handler: meta.handler,
+		})
+		return nil
+	})
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Path < routes[j].Path
+	})
+	return routes
+}
+
+// isPublic reports whether a route can be called without a token
+func (r *Router) isPublic(chain string, methods []string, path string) bool {
+	switch chain {
+	case middleware.ChainPublic:
+		return true
+	case middleware.ChainAuthed:
+		for _, method := range methods {
+			if !r.public.contains(method, path) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// LogRoutes writes the route table to the router's logger
+func (r *Router) LogRoutes() {
+	routes := r.Routes()
+	r.logger.Info("registered routes", zap.Int("count", len(routes)))
+	for _, route := range routes {
+		r.logger.Debug("route",
+			zap.Strings("methods", route.Methods),
+			zap.String("path", route.Path),
+			zap.String("chain", route.Chain),
+			zap.String("handler", route.Handler),
+		)
+	}
+}
+
+// listRoutes handles GET /_routes
+func (r *Router) listRoutes(w http.ResponseWriter, req *http.Request) {
+	writeSuccess(w, "Routes retrieved successfully", r.Routes(), http.StatusOK)
+}
+
+// listExamples handles GET /_examples
+func (r *Router) listExamples(w http.ResponseWriter, req *http.Request) {
+	writeSuccess(w, "Examples retrieved successfully", r.examples.Document(), http.StatusOK)
+}
+
+// handlerName returns a readable name for a handler, e.g. handlers.(*UserHandler).GetUser
+func handlerName(handler http.Handler) string {
+	value := reflect.ValueOf(handler)
+	if value.Kind() != reflect.Func {
+		return fmt.Sprintf("%T", handler)
+	}
+
+	name := runtime.FuncForPC(value.Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+)
+
+// ApprovalService lets admins approve or reject self-registered accounts
+// held pending by REGISTRATION_MODE=approval
+type ApprovalService struct {
+	approvalRepo repository.ApprovalRepository
+	pagination   *Pagination
+
+	// transactions and emails are set by SetNotifications; nil sends no email
+	transactions repository.TxManager
+	emails       *EmailService
+}
+
+// NewApprovalService creates a new approval service
+func NewApprovalService(approvalRepo repository.ApprovalRepository, pagination *Pagination) *ApprovalService {
+	return &ApprovalService{approvalRepo: approvalRepo, pagination: pagination}
+}
+
+// SetNotifications emails users the decision on their registration, queued
+// in the same transaction that records it
+func (s *ApprovalService) SetNotifications(transactions repository.TxManager, emails *EmailService) {
+	s.transactions = transactions
+	s.emails = emails
+}
+
+// ListPending retrieves a page of registrations awaiting a decision, oldest
+// first, of users with an ID above afterUserID
+func (s *ApprovalService) ListPending(ctx context.Context, afterUserID, limit int) ([]*models.Approval, error) {
+	limit = s.pagination.normalizeLimit(models.PageRouteApprovals, limit)
+
+	approvals, err := s.approvalRepo.ListPending(ctx, afterUserID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registration approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+// Approve lets a pending user sign in
+func (s *ApprovalService) Approve(ctx context.Context, userID int, req *models.ApprovalDecisionRequest) (*models.Approval, error) {
+	return s.decide(ctx, userID, models.ApprovalApproved, req)
+}
+
+// Reject keeps a pending user from signing in
+func (s *ApprovalService) Reject(ctx context.Context, userID int, req *models.ApprovalDecisionRequest) (*models.Approval, error) {
+	return s.decide(ctx, userID, models.ApprovalRejected, req)
+}
+
+// decide records the decision of the admin in ctx and notifies the user
+func (s *ApprovalService) decide(ctx context.Context, userID int, status string, req *models.ApprovalDecisionRequest) (*models.Approval, error) {
+	if err := validateStruct(req); err != nil {
+		return nil, err
+	}
+	var decidedBy *int
+	if id, ok := actor.FromContext(ctx).UserIDInt(); ok {
+		decidedBy = &id
+	}
+
+	if s.emails == nil {
+		return s.approvalRepo.Decide(ctx, userID, status, req.Reason, decidedBy)
+	}
+
+	var approval *models.Approval
+	err := s.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		approval, err = s.approvalRepo.Decide(ctx, userID, status, req.Reason, decidedBy)
+		if err != nil {
+			return err
+		}
+		subject, body := decisionEmail(approval)
+		_, err = s.emails.Queue(ctx, approval.Email, subject, body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// decisionEmail returns the subject and plain text body of the email telling
+// a user the decision on their registration
+func decisionEmail(approval *models.Approval) (string, string) {
+	if approval.Status == models.ApprovalApproved {
+		body := fmt.Sprintf("Hi %s,\n\nYour registration has been approved. You can now sign in with your email address and password.\n", approval.Name)
+		return "Your Go CRUD account has been approved", withReason(body, approval.Reason)
+	}
+	body := fmt.Sprintf("Hi %s,\n\nYour registration has not been approved.\n", approval.Name)
+	return "Your Go CRUD registration", withReason(body, approval.Reason)
+}
+
+// withReason appends the reason given for a decision, if any, to body
+func withReason(body, reason string) string {
+	if reason == "" {
+		return body
+	}
+	return body + "\nReason: " + reason + "\n"
+}
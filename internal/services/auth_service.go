@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/events"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/realtime"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// errInvalidCredentials is returned for both unknown emails and wrong
+// passwords so responses do not reveal which accounts exist
+var errInvalidCredentials = fmt.Errorf("invalid email or password")
+
+// AuthService handles registration, login and JWT issuance
+type AuthService struct {
+	userRepo    repository.UserRepository
+	userService *UserService
+	ids         idcodec.Codec
+	jwtCfg      config.JWTConfig
+	signer      JWTSigner
+
+	// transactions and emails are set by SetWelcomeEmail; nil sends no email
+	transactions repository.TxManager
+	emails       *EmailService
+
+	// registration is a models.Registration* mode; approvals, set with it,
+	// holds accounts awaiting approval and is nil when none can be
+	registration string
+	approvals    repository.ApprovalRepository
+
+	// dummyHash is compared against when the email is unknown so that
+	// login takes the same time whether or not the account exists
+	dummyHash []byte
+}
+
+// NewAuthService creates a new auth service that returns users with the
+// IDs codec encodes
+func NewAuthService(userRepo repository.UserRepository, jwtCfg config.JWTConfig, ids idcodec.Codec) *AuthService {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+	return &AuthService{
+		userRepo:     userRepo,
+		userService:  NewUserService(userRepo, DefaultPagination(), ids),
+		ids:          ids,
+		jwtCfg:       jwtCfg,
+		signer:       staticSigner(jwtCfg.Secret),
+		registration: models.RegistrationOpen,
+		dummyHash:    dummyHash,
+	}
+}
+
+// SetRegistration sets who may register: anyone, with RegistrationOpen;
+// nobody, with RegistrationClosed; or anyone, pending an admin's approval,
+// with RegistrationApproval. Accounts in approvals whose registration is not
+// approved cannot sign in, whatever the mode; approvals may be nil unless
+// mode is RegistrationApproval.
+func (s *AuthService) SetRegistration(mode string, transactions repository.TxManager, approvals repository.ApprovalRepository) {
+	s.registration = mode
+	s.transactions = transactions
+	s.approvals = approvals
+}
+
+// SetSigner signs tokens with signer instead of the configured secret
+func (s *AuthService) SetSigner(signer JWTSigner) {
+	s.signer = signer
+}
+
+// SetWelcomeEmail queues a welcome email for every registered user, in the
+// same transaction that creates them
+func (s *AuthService) SetWelcomeEmail(transactions repository.TxManager, emails *EmailService) {
+	s.transactions = transactions
+	s.emails = emails
+}
+
+// SetWebhooks notifies webhooks of every registered user
+func (s *AuthService) SetWebhooks(webhooks *WebhookService) {
+	s.userService.SetWebhooks(webhooks)
+}
+
+// SetLiveUpdates pushes every registered user to the WebSocket clients of hub
+func (s *AuthService) SetLiveUpdates(hub *realtime.Hub) {
+	s.userService.SetLiveUpdates(hub)
+}
+
+// SetEvents publishes a domain event to publisher for every registered user
+func (s *AuthService) SetEvents(publisher events.Publisher) {
+	s.userService.SetEvents(publisher)
+}
+
+// SetOutbox writes the domain event of every registered user to outbox in
+// the transaction that creates them
+func (s *AuthService) SetOutbox(transactions repository.TxManager, outbox *OutboxService) {
+	s.userService.SetOutbox(transactions, outbox)
+}
+
+// Register creates a user with a password and returns a token for them, or,
+// when registrations need approval, returns them pending without a token
+func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthResponse, error) {
+	if s.registration == models.RegistrationClosed {
+		return nil, apperrors.Forbidden("registration is closed")
+	}
+	if err := validateStruct(req); err != nil {
+		return nil, err
+	}
+	createReq := &models.CreateUserRequest{
+		Name:  req.Name,
+		Email: req.Email,
+		Age:   req.Age,
+	}
+
+	// Check if email already exists
+	existingUser, _ := s.userRepo.GetByEmail(ctx, req.Email)
+	if existingUser != nil {
+		return nil, &models.DuplicateEmailError{Email: req.Email}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	pending := s.registration == models.RegistrationApproval
+	user, err := s.createAccount(ctx, createReq, string(hash), pending)
+	if err != nil {
+		if isConstraintError(err) {
+			return nil, err
+		}
+		return nil, apperrors.Internal("failed to register user", err)
+	}
+
+	s.userService.publish(ctx, models.WebhookUserCreated, user)
+	if pending {
+		return &models.AuthResponse{User: user.ToResponse(s.ids), Pending: true}, nil
+	}
+	return s.issueToken(user)
+}
+
+// createAccount creates the user and, in the same transaction, puts them in
+// the approval queue when pending, or else queues a welcome email when those
+// are enabled. Pending users hear from the approval decision instead. The
+// user's domain event joins the transaction when the outbox is enabled.
+func (s *AuthService) createAccount(ctx context.Context, req *models.CreateUserRequest, passwordHash string, pending bool) (*models.User, error) {
+	withinTransaction := s.userService.withinTransaction
+	if pending || s.emails != nil {
+		withinTransaction = s.transactions.WithinTransaction
+	}
+
+	var user *models.User
+	err := withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.CreateWithPassword(ctx, req, passwordHash)
+		if err != nil {
+			return err
+		}
+		if pending {
+			if err := s.approvals.Create(ctx, user.ID); err != nil {
+				return err
+			}
+		} else if s.emails != nil {
+			if _, err := s.emails.Queue(ctx, user.Email, "Welcome to Go CRUD", welcomeEmailBody(user.Name)); err != nil {
+				return err
+			}
+		}
+		return s.userService.stage(ctx, models.WebhookUserCreated, user)
+	})
+	return user, err
+}
+
+// welcomeEmailBody returns the plain text welcome email for name
+func welcomeEmailBody(name string) string {
+	return fmt.Sprintf("Hi %s,\n\nYour account has been created. You can now sign in with your email address and password.\n", name)
+}
+
+// Login verifies a user's credentials and returns a token
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
+	if err := validateStruct(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetCredentials(ctx, req.Email)
+	if err != nil || user.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+		return nil, errInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, errInvalidCredentials
+	}
+	if err := s.checkApproval(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.issueToken(user)
+}
+
+// checkApproval fails unless the user was never held for approval or has
+// been approved. It runs after the password check, so only the account's
+// owner learns its status.
+func (s *AuthService) checkApproval(ctx context.Context, userID int) error {
+	if s.approvals == nil {
+		return nil
+	}
+	approval, err := s.approvals.Get(ctx, userID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return apperrors.Internal("failed to check registration approval", err)
+	}
+
+	switch approval.Status {
+	case models.ApprovalApproved:
+		return nil
+	case models.ApprovalRejected:
+		return apperrors.Forbidden("registration was rejected")
+	default:
+		return apperrors.Forbidden("registration is pending approval")
+	}
+}
+
+// issueToken signs a JWT for user with the configured expiration
+func (s *AuthService) issueToken(user *models.User) (*models.AuthResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.jwtCfg.Expiration)
+
+	token, err := s.signer.SignJWT(jwt.MapClaims{
+		"sub":   strconv.Itoa(user.ID),
+		"email": user.Email,
+		"role":  user.Role,
+		"iat":   now.Unix(),
+		"exp":   expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return &models.AuthResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: expiresAt,
+		User:      user.ToResponse(s.ids),
+	}, nil
+}
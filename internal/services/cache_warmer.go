@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+)
+
+// MaxWarmUsers caps the users a single warm-up preloads
+const MaxWarmUsers = 10000
+
+// CacheWarmer preloads the cache with the records of the most recently
+// active users, so requests after a deploy don't all miss an empty cache
+type CacheWarmer struct {
+	userRepo repository.UserRepository
+	claims   *UserClaimsLoader
+}
+
+// NewCacheWarmer creates a cache warmer priming claims through claims
+func NewCacheWarmer(userRepo repository.UserRepository, claims *UserClaimsLoader) *CacheWarmer {
+	return &CacheWarmer{userRepo: userRepo, claims: claims}
+}
+
+// Warm caches the claims of up to limit of the most recently active users
+// with one query. It stops at the first cache error.
+func (w *CacheWarmer) Warm(ctx context.Context, limit int) (*models.CacheWarmResult, error) {
+	if limit < 1 || limit > MaxWarmUsers {
+		return nil, apperrors.Validation(fmt.Sprintf("limit must be between 1 and %d", MaxWarmUsers))
+	}
+
+	started := time.Now()
+	users, err := w.userRepo.RecentlyActive(ctx, limit)
+	if err != nil {
+		return nil, apperrors.Internal("failed to read recently active users", err)
+	}
+
+	result := &models.CacheWarmResult{}
+	for _, user := range users {
+		if err := w.claims.Prime(ctx, user); err != nil {
+			return nil, apperrors.Internal("failed to cache claims", err)
+		}
+		result.Users++
+	}
+	result.DurationMs = float64(time.Since(started).Microseconds()) / 1000
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/cache"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// UserClaimsLoader loads a user's current claims from the repository with a
+// short-lived cache, so role changes take effect within the TTL instead of
+// when the user's token expires
+type UserClaimsLoader struct {
+	userRepo repository.UserRepository
+	cache    *cache.StaleWhileRevalidate
+}
+
+// NewUserClaimsLoader creates a new claims loader caching claims in c for
+// ttl, then serving them for up to stale more while they are reloaded
+func NewUserClaimsLoader(userRepo repository.UserRepository, c cache.Cache, ttl, stale time.Duration) *UserClaimsLoader {
+	swr := cache.NewStaleWhileRevalidate(c, ttl, stale)
+	swr.OnError = func(ctx context.Context, key string, err error) {
+		logger.FromContext(ctx).Warn("failed to cache claims", zap.String("key", key), zap.Error(err))
+	}
+	return &UserClaimsLoader{
+		userRepo: userRepo,
+		cache:    swr,
+	}
+}
+
+// claimsKey is the cache key of a user's claims
+func claimsKey(userID int) string {
+	return "claims:" + strconv.Itoa(userID)
+}
+
+// Load returns the claims of a user, from cache when fresh enough. A failing
+// cache is bypassed rather than failing the request.
+func (l *UserClaimsLoader) Load(ctx context.Context, userID int) (*models.UserClaims, error) {
+	encoded, err := l.cache.Fetch(ctx, claimsKey(userID), func(ctx context.Context) ([]byte, error) {
+		user, err := l.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return encodeClaims(user)
+	})
+	if err != nil {
+		l.Invalidate(userID)
+		return nil, err
+	}
+
+	claims := &models.UserClaims{}
+	if err := json.Unmarshal(encoded, claims); err != nil {
+		l.Invalidate(userID)
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Prime caches the claims of a user that was just read, as Load would
+func (l *UserClaimsLoader) Prime(ctx context.Context, user *models.User) error {
+	encoded, err := encodeClaims(user)
+	if err != nil {
+		return err
+	}
+	return l.cache.Store(ctx, claimsKey(user.ID), encoded)
+}
+
+// encodeClaims encodes the claims of user for the cache
+func encodeClaims(user *models.User) ([]byte, error) {
+	return json.Marshal(&models.UserClaims{
+		UserID: user.ID,
+		Name:   user.Name,
+		Email:  user.Email,
+		Role:   user.Role,
+	})
+}
+
+// Invalidate drops the cached claims of a user
+func (l *UserClaimsLoader) Invalidate(userID int) {
+	l.cache.Invalidate(context.Background(), claimsKey(userID))
+}
+
+// LoadClaims implements middleware.ClaimsLoader for a JWT subject
+func (l *UserClaimsLoader) LoadClaims(ctx context.Context, subject string) (map[string]interface{}, error) {
+	userID, err := strconv.Atoi(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := l.Load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"name":  claims.Name,
+		"email": claims.Email,
+		"role":  claims.Role,
+	}, nil
+}
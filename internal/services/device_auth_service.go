@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"go.uber.org/zap"
+)
+
+// userCodeAlphabet avoids vowels and lookalike characters so user codes are
+// easy to read aloud and never spell words
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// slowDownIncrement is added to the polling interval when a client polls too fast
+const slowDownIncrement = 5
+
+// StartDeviceAuthorization issues a device code and user code for a CLI or
+// other input-constrained client (RFC 8628)
+func (s *OIDCService) StartDeviceAuthorization(ctx context.Context, clientID, clientSecret, scope string) (*models.DeviceAuthorizationResponse, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCode := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	userCode := generateUserCode()
+	interval := int(s.cfg.DevicePollInterval.Seconds())
+
+	err = s.oauthRepo.SaveDeviceCode(ctx, &models.DeviceCode{
+		DeviceCodeHash: hashToken(deviceCode),
+		UserCode:       userCode,
+		ClientID:       client.ID,
+		Scope:          scope,
+		Status:         models.DeviceCodePending,
+		Interval:       interval,
+		ExpiresAt:      time.Now().Add(s.cfg.DeviceCodeTTL),
+	})
+	if err != nil {
+		s.logger.Error("failed to save device code", zap.String("client_id", client.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to start device authorization: %w", err)
+	}
+
+	verificationURI := strings.TrimSuffix(s.cfg.Issuer, "/") + "/device"
+	return &models.DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(userCode),
+		ExpiresIn:               int64(s.cfg.DeviceCodeTTL.Seconds()),
+		Interval:                interval,
+	}, nil
+}
+
+// GetPendingDevice returns the details of a pending device request so the
+// user can confirm it is the device they are signing in
+func (s *OIDCService) GetPendingDevice(ctx context.Context, userCode string) (*models.DevicePendingResponse, error) {
+	code, err := s.pendingDeviceCode(ctx, userCode)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.oauthRepo.GetClient(ctx, code.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DevicePendingResponse{
+		UserCode:   code.UserCode,
+		ClientName: client.Name,
+		Scope:      code.Scope,
+		ExpiresAt:  code.ExpiresAt,
+	}, nil
+}
+
+// DecideDevice records the authenticated user's approval or denial of a device request
+func (s *OIDCService) DecideDevice(ctx context.Context, userID int, req *models.DeviceApprovalRequest) error {
+	code, err := s.pendingDeviceCode(ctx, req.UserCode)
+	if err != nil {
+		return err
+	}
+
+	status := models.DeviceCodeDenied
+	if req.Approve {
+		status = models.DeviceCodeApproved
+	}
+
+	return s.oauthRepo.UpdateDeviceCodeStatus(ctx, code.DeviceCodeHash, status, &userID)
+}
+
+// exchangeDeviceCode handles a device client polling the token endpoint
+func (s *OIDCService) exchangeDeviceCode(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCodeHash := hashToken(req.DeviceCode)
+	code, err := s.oauthRepo.GetDeviceCode(ctx, deviceCodeHash)
+	if err != nil || code.ClientID != client.ID {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "device code is invalid"}
+	}
+
+	if time.Now().After(code.ExpiresAt) {
+		s.oauthRepo.DeleteDeviceCode(ctx, deviceCodeHash)
+		return nil, &OAuthError{Code: "expired_token", Description: "device code has expired"}
+	}
+
+	switch code.Status {
+	case models.DeviceCodeDenied:
+		s.oauthRepo.DeleteDeviceCode(ctx, deviceCodeHash)
+		return nil, &OAuthError{Code: "access_denied", Description: "the user denied the request"}
+
+	case models.DeviceCodePending:
+		interval := code.Interval
+		tooFast := code.LastPolledAt != nil &&
+			time.Since(*code.LastPolledAt) < time.Duration(code.Interval)*time.Second
+		if tooFast {
+			interval += slowDownIncrement
+		}
+		if err := s.oauthRepo.TouchDeviceCode(ctx, deviceCodeHash, interval); err != nil {
+			logger.FromContext(ctx).Warn("failed to record device poll", zap.Error(err))
+		}
+		if tooFast {
+			return nil, &OAuthError{Code: "slow_down", Description: "polling too frequently"}
+		}
+		return nil, &OAuthError{Code: "authorization_pending", Description: "the user has not yet approved the request"}
+	}
+
+	// Approved: the device code is single use
+	if err := s.oauthRepo.DeleteDeviceCode(ctx, deviceCodeHash); err != nil {
+		return nil, fmt.Errorf("failed to consume device code: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, *code.UserID)
+	if err != nil {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "user no longer exists"}
+	}
+
+	return s.issueTokens(client, user, code.Scope, "")
+}
+
+// pendingDeviceCode looks up an unexpired, undecided device request by user code
+func (s *OIDCService) pendingDeviceCode(ctx context.Context, userCode string) (*models.DeviceCode, error) {
+	code, err := s.oauthRepo.GetDeviceCodeByUserCode(ctx, normalizeUserCode(userCode))
+	if err != nil {
+		return nil, err
+	}
+	if code.Status != models.DeviceCodePending || time.Now().After(code.ExpiresAt) {
+		return nil, apperrors.NotFound("device code not found")
+	}
+	return code, nil
+}
+
+// generateUserCode returns a random code formatted as XXXX-XXXX
+func generateUserCode() string {
+	var b strings.Builder
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(fmt.Sprintf("crypto/rand failed: %v", err))
+		}
+		b.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+	return b.String()
+}
+
+// normalizeUserCode accepts user codes typed in any case, with or without the dash
+func normalizeUserCode(userCode string) string {
+	code := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(userCode))
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
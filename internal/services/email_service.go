@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/mailer"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// emailDeliveryBatchSize is how many emails one delivery run claims
+	emailDeliveryBatchSize = 20
+	// emailClaimLease is how long a claimed email is held back from other
+	// workers; an email whose worker dies is retried once it expires
+	emailClaimLease = 5 * time.Minute
+)
+
+// EmailService queues emails in the outbox and delivers them in the background
+type EmailService struct {
+	emailRepo  repository.EmailRepository
+	sender     mailer.Sender
+	cfg        config.EmailConfig
+	pagination *Pagination
+}
+
+// NewEmailService creates a new email service
+func NewEmailService(emailRepo repository.EmailRepository, sender mailer.Sender, cfg config.EmailConfig, pagination *Pagination) *EmailService {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return &EmailService{
+		emailRepo:  emailRepo,
+		sender:     sender,
+		cfg:        cfg,
+		pagination: pagination,
+	}
+}
+
+// Queue stores an email for delivery. Called within a transaction, the
+// email is only sent if the transaction commits.
+func (s *EmailService) Queue(ctx context.Context, to, subject, body string) (*models.Email, error) {
+	email := &models.Email{
+		MessageID: s.newMessageID(),
+		Recipient: to,
+		Subject:   subject,
+		Body:      body,
+	}
+	if err := s.emailRepo.Enqueue(ctx, email); err != nil {
+		return nil, fmt.Errorf("failed to queue email: %w", err)
+	}
+	return email, nil
+}
+
+// newMessageID returns a unique Message-ID in the sender's domain
+func (s *EmailService) newMessageID() string {
+	domain := "localhost"
+	if at := strings.LastIndex(s.cfg.From, "@"); at >= 0 && at < len(s.cfg.From)-1 {
+		domain = strings.TrimSuffix(s.cfg.From[at+1:], ">")
+	}
+	return "<" + randomToken(16, hex.EncodeToString) + "@" + domain + ">"
+}
+
+// DeliverDue sends the emails that are due and returns how many were sent.
+// Failed emails are retried with exponential backoff until they run out of
+// attempts; permanent failures are not retried. Emails to suppressed
+// addresses are not sent.
+func (s *EmailService) DeliverDue(ctx context.Context) (int, error) {
+	emails, err := s.emailRepo.ClaimDue(ctx, emailDeliveryBatchSize, emailClaimLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim emails: %w", err)
+	}
+
+	sent := 0
+	for _, email := range emails {
+		suppression, err := s.emailRepo.GetSuppression(ctx, email.Recipient)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return sent, fmt.Errorf("failed to check suppression of email %d: %w", email.ID, err)
+		}
+		if suppression != nil {
+			if err := s.emailRepo.MarkSuppressed(ctx, email.ID, "recipient "+suppression.Reason); err != nil {
+				return sent, fmt.Errorf("failed to record suppression of email %d: %w", email.ID, err)
+			}
+			continue
+		}
+
+		sendErr := s.sender.Send(ctx, mailer.Message{
+			MessageID: email.MessageID,
+			From:      s.cfg.From,
+			To:        email.Recipient,
+			Subject:   email.Subject,
+			Body:      email.Body,
+		})
+
+		switch {
+		case sendErr == nil:
+			err = s.emailRepo.MarkSent(ctx, email.ID)
+			sent++
+		case mailer.IsPermanent(sendErr) || email.Attempts >= s.cfg.MaxAttempts:
+			err = s.emailRepo.MarkFailed(ctx, email.ID, sendErr.Error())
+		default:
+			err = s.emailRepo.MarkRetry(ctx, email.ID, sendErr.Error(), time.Now().Add(s.retryDelay(email.Attempts)))
+		}
+		if err != nil {
+			return sent, fmt.Errorf("failed to record delivery of email %d: %w", email.ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// retryDelay returns the wait after the given failed attempt, doubling from
+// RetryBackoff
+func (s *EmailService) retryDelay(attempts int) time.Duration {
+	delay := s.cfg.RetryBackoff
+	for i := 1; i < attempts && delay < 24*time.Hour; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+// RunDelivery delivers due emails every interval until ctx is cancelled
+func (s *EmailService) RunDelivery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := s.DeliverDue(ctx)
+			if err != nil {
+				logger.FromContext(ctx).Error("email delivery failed", zap.Error(err))
+			} else if sent > 0 {
+				logger.FromContext(ctx).Info("sent emails", zap.Int("count", sent))
+			}
+		}
+	}
+}
+
+// VerifyWebhookSignature checks signature, "sha256=" followed by the hex
+// HMAC-SHA256 of body under the webhook secret
+func (s *EmailService) VerifyWebhookSignature(body []byte, signature string) bool {
+	if s.cfg.WebhookSecret == "" {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(s.cfg.WebhookSecret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// HandleEvents records provider delivery notifications and returns how many
+// changed an email or suppressed an address. Bounces and complaints suppress
+// the recipient, so nothing more is sent to them. Events for unknown emails
+// only suppress the recipient they name, since the provider may report on
+// mail this API did not send.
+func (s *EmailService) HandleEvents(ctx context.Context, events []models.EmailEvent) (int, error) {
+	for _, event := range events {
+		switch event.Type {
+		case models.EmailDelivered:
+			if event.MessageID == "" {
+				return 0, apperrors.Validation("message_id is required")
+			}
+		case models.EmailBounced, models.EmailComplained:
+			if event.MessageID == "" && event.Recipient == "" {
+				return 0, apperrors.Validation("message_id or recipient is required")
+			}
+		default:
+			return 0, apperrors.Validation("invalid event type: " + event.Type)
+		}
+	}
+
+	applied := 0
+	for _, event := range events {
+		changed, err := s.applyEvent(ctx, event)
+		if err != nil {
+			return applied, err
+		}
+		if changed {
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+// applyEvent records one notification and reports whether it changed anything
+func (s *EmailService) applyEvent(ctx context.Context, event models.EmailEvent) (bool, error) {
+	var email *models.Email
+	if event.MessageID != "" {
+		var err error
+		email, err = s.emailRepo.ApplyEvent(ctx, event)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return false, fmt.Errorf("failed to record email event: %w", err)
+		}
+	}
+	if event.Type == models.EmailDelivered {
+		return email != nil, nil
+	}
+
+	recipient := event.Recipient
+	if recipient == "" && email != nil {
+		recipient = email.Recipient
+	}
+	if recipient == "" {
+		return false, nil
+	}
+	err := s.emailRepo.Suppress(ctx, &models.EmailSuppression{
+		Email:  recipient,
+		Reason: event.Type,
+		Detail: event.Reason,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to suppress email address: %w", err)
+	}
+	return true, nil
+}
+
+// Deliverability reports whether emails can be sent to address
+func (s *EmailService) Deliverability(ctx context.Context, address string) (*models.EmailDeliverability, error) {
+	suppression, err := s.emailRepo.GetSuppression(ctx, address)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return &models.EmailDeliverability{Status: models.EmailDeliverable}, nil
+		}
+		return nil, fmt.Errorf("failed to get email deliverability: %w", err)
+	}
+	return &models.EmailDeliverability{
+		Status: models.EmailUndeliverable,
+		Reason: suppression.Reason,
+		Detail: suppression.Detail,
+		Since:  &suppression.CreatedAt,
+	}, nil
+}
+
+// ListEmails retrieves a page of emails with an ID below beforeID, newest
+// first, optionally only those with status
+func (s *EmailService) ListEmails(ctx context.Context, status string, beforeID int64, limit int) ([]*models.Email, error) {
+	switch status {
+	case "", models.EmailQueued, models.EmailSent, models.EmailDelivered, models.EmailBounced,
+		models.EmailComplained, models.EmailFailed, models.EmailSuppressed:
+	default:
+		return nil, apperrors.Validation("invalid status: " + status)
+	}
+	if beforeID <= 0 {
+		beforeID = math.MaxInt64
+	}
+	limit = s.pagination.normalizeLimit(models.PageRouteEmails, limit)
+
+	emails, err := s.emailRepo.List(ctx, status, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emails: %w", err)
+	}
+	return emails, nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// IntrospectionService validates tokens on behalf of other services (RFC 7662)
+type IntrospectionService struct {
+	keyfunc      jwt.Keyfunc
+	tokenService *PersonalTokenService
+	claimsLoader *UserClaimsLoader
+}
+
+// NewIntrospectionService creates a new token introspection service that
+// verifies JWTs with the keys keyfunc returns
+func NewIntrospectionService(keyfunc jwt.Keyfunc, tokenService *PersonalTokenService, claimsLoader *UserClaimsLoader) *IntrospectionService {
+	return &IntrospectionService{
+		keyfunc:      keyfunc,
+		tokenService: tokenService,
+		claimsLoader: claimsLoader,
+	}
+}
+
+// Introspect reports whether token is active and, if so, its claims merged
+// with the user's current claims. Any failure yields an inactive response so
+// callers never learn why a token was rejected.
+func (s *IntrospectionService) Introspect(ctx context.Context, token string) *models.IntrospectionResponse {
+	inactive := &models.IntrospectionResponse{Active: false}
+
+	var claims jwt.MapClaims
+	if strings.HasPrefix(token, models.PersonalTokenPrefix) {
+		// tokenService is nil where personal tokens are unavailable
+		if s.tokenService == nil {
+			return inactive
+		}
+		var err error
+		if claims, err = s.tokenService.AuthenticateToken(ctx, token); err != nil {
+			return inactive
+		}
+	} else {
+		parsed, err := jwt.Parse(token, s.keyfunc)
+		if err != nil || !parsed.Valid {
+			return inactive
+		}
+		claims, _ = parsed.Claims.(jwt.MapClaims)
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return inactive
+	}
+	userID, err := strconv.Atoi(sub)
+	if err != nil {
+		return inactive
+	}
+
+	// Tokens of deleted users are no longer active
+	user, err := s.claimsLoader.Load(ctx, userID)
+	if err != nil {
+		return inactive
+	}
+
+	resp := &models.IntrospectionResponse{
+		Active:    true,
+		Sub:       sub,
+		TokenType: "Bearer",
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+	}
+	resp.Scope, _ = claims["scope"].(string)
+	resp.ClientID, _ = claims["client_id"].(string)
+	resp.Iss, _ = claims["iss"].(string)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		resp.Exp = exp.Unix()
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		resp.Iat = iat.Unix()
+	}
+
+	return resp
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// JWTSigner signs access tokens
+type JWTSigner interface {
+	SignJWT(claims jwt.MapClaims) (string, error)
+}
+
+// staticSigner signs with a single HMAC secret
+type staticSigner []byte
+
+// SignJWT implements JWTSigner
+func (s staticSigner) SignJWT(claims jwt.MapClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s))
+}
+
+// errUnknownKey rejects tokens naming a key the keyring does not hold
+var errUnknownKey = errors.New("unknown signing key")
+
+// keyMissRefreshInterval bounds how often a token naming an unknown key
+// makes the keyring reload its keys
+const keyMissRefreshInterval = time.Second
+
+// JWTKeyring signs tokens with the newest rotated key and verifies them
+// with any key that signed a token that may not have expired yet. Until
+// the first rotation, and for tokens without a kid header, JWT_SECRET is
+// the key. Keys are shared through the database, so a key rotated on one
+// instance is picked up by the others on their next refresh, or as soon as
+// a token signed with it arrives.
+type JWTKeyring struct {
+	keyRepo    repository.JWTKeyRepository
+	base       []byte
+	expiration time.Duration
+
+	mu sync.RWMutex
+	// keys are the rotated keys, oldest first
+	keys []*models.JWTKey
+	// missRefreshed is when a token naming an unknown key last made the
+	// keyring refresh
+	missRefreshed time.Time
+}
+
+// NewJWTKeyring creates a keyring over cfg.Secret and the keys in keyRepo;
+// call Refresh to load them
+func NewJWTKeyring(keyRepo repository.JWTKeyRepository, cfg config.JWTConfig) *JWTKeyring {
+	return &JWTKeyring{
+		keyRepo:    keyRepo,
+		base:       []byte(cfg.Secret),
+		expiration: cfg.Expiration,
+	}
+}
+
+// Refresh reloads the rotated keys
+func (k *JWTKeyring) Refresh(ctx context.Context) error {
+	keys, err := k.keyRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the keys every interval until ctx is cancelled
+func (k *JWTKeyring) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Refresh(ctx); err != nil {
+				logger.FromContext(ctx).Warn("failed to refresh jwt keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Rotate creates a key that signs every token from now on and removes the
+// keys no unexpired token can have been signed with. Tokens signed with
+// earlier keys stay valid until they expire.
+func (k *JWTKeyring) Rotate(ctx context.Context) (*models.JWTKey, error) {
+	key := &models.JWTKey{
+		ID:     randomToken(8, hex.EncodeToString),
+		Secret: randomToken(32, base64.RawURLEncoding.EncodeToString),
+	}
+	if err := k.keyRepo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to rotate jwt key: %w", err)
+	}
+
+	if _, err := k.keyRepo.DeleteSuperseded(ctx, time.Now().Add(-k.expiration)); err != nil {
+		logger.FromContext(ctx).Warn("failed to delete superseded jwt keys", zap.Error(err))
+	}
+	if err := k.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("failed to reload jwt keys: %w", err)
+	}
+	return key, nil
+}
+
+// SignJWT implements JWTSigner, signing with the newest key and naming it
+// in the kid header
+func (k *JWTKeyring) SignJWT(claims jwt.MapClaims) (string, error) {
+	k.mu.RLock()
+	var newest *models.JWTKey
+	if len(k.keys) > 0 {
+		newest = k.keys[len(k.keys)-1]
+	}
+	k.mu.RUnlock()
+
+	if newest == nil {
+		return staticSigner(k.base).SignJWT(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = newest.ID
+	return token.SignedString([]byte(newest.Secret))
+}
+
+// Keyfunc is a jwt.Keyfunc returning the key a token names. A token naming
+// a key rotated on another instance since the last refresh makes the
+// keyring refresh, at most once per keyMissRefreshInterval.
+func (k *JWTKeyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	kid, _ := token.Header["kid"].(string)
+
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	if kid == "" || !k.refreshOnMiss() {
+		return nil, errUnknownKey
+	}
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, errUnknownKey
+}
+
+// lookup returns the secret of kid. JWT_SECRET, the empty kid, is accepted
+// until the first rotated key is older than a token's expiration.
+func (k *JWTKeyring) lookup(kid string) ([]byte, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" {
+		if len(k.keys) == 0 || time.Since(k.keys[0].CreatedAt) < k.expiration {
+			return k.base, true
+		}
+		return nil, false
+	}
+	for _, key := range k.keys {
+		if key.ID == kid {
+			return []byte(key.Secret), true
+		}
+	}
+	return nil, false
+}
+
+// refreshOnMiss refreshes the keys unless that was done too recently,
+// reporting whether it refreshed
+func (k *JWTKeyring) refreshOnMiss() bool {
+	k.mu.Lock()
+	if time.Since(k.missRefreshed) < keyMissRefreshInterval {
+		k.mu.Unlock()
+		return false
+	}
+	k.missRefreshed = time.Now()
+	k.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return k.Refresh(ctx) == nil
+}
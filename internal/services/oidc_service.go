@@ -0,0 +1,442 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Supported grant types
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// Supported OpenID Connect scopes
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// OAuthError is an error reported to OAuth clients with an RFC 6749 error code
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+// Error implements the error interface
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// OIDCService implements a minimal OpenID Connect provider: client
+// registration, the authorization code flow with PKCE and ID token issuance
+type OIDCService struct {
+	oauthRepo  repository.OAuthRepository
+	userRepo   repository.UserRepository
+	cfg        config.OIDCConfig
+	jwtCfg     config.JWTConfig
+	signer     JWTSigner
+	signingKey *rsa.PrivateKey
+	keyID      string
+	logger     *zap.Logger
+}
+
+// NewOIDCService creates a new OpenID Connect service. The ID token signing
+// key is read from cfg.OIDC.SigningKeyFile; without one an ephemeral key is
+// generated, which invalidates issued ID tokens on every restart.
+func NewOIDCService(oauthRepo repository.OAuthRepository, userRepo repository.UserRepository, cfg *config.Config, logger *zap.Logger) (*OIDCService, error) {
+	key, err := loadSigningKey(cfg.OIDC.SigningKeyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	keyHash := sha256.Sum256(key.PublicKey.N.Bytes())
+
+	return &OIDCService{
+		oauthRepo:  oauthRepo,
+		userRepo:   userRepo,
+		cfg:        cfg.OIDC,
+		jwtCfg:     cfg.JWT,
+		signer:     staticSigner(cfg.JWT.Secret),
+		signingKey: key,
+		keyID:      base64.RawURLEncoding.EncodeToString(keyHash[:12]),
+		logger:     logger,
+	}, nil
+}
+
+// SetSigner signs access tokens with signer instead of the configured
+// secret; ID tokens keep the RSA key
+func (s *OIDCService) SetSigner(signer JWTSigner) {
+	s.signer = signer
+}
+
+// Issuer returns the issuer identifier
+func (s *OIDCService) Issuer() string {
+	return s.cfg.Issuer
+}
+
+// JWKS returns the public keys used to sign ID tokens
+func (s *OIDCService) JWKS() *models.JSONWebKeySet {
+	pub := s.signingKey.PublicKey
+	return &models.JSONWebKeySet{
+		Keys: []models.JSONWebKey{{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: "RS256",
+			KeyID:     s.keyID,
+			N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	}
+}
+
+// RegisterClient registers a new OAuth client. The returned secret is only
+// available in this response.
+func (s *OIDCService) RegisterClient(ctx context.Context, req *models.CreateOAuthClientRequest) (*models.OAuthClientResponse, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(req.RedirectURIs) == 0 {
+		return nil, fmt.Errorf("at least one redirect URI is required")
+	}
+	for _, uri := range req.RedirectURIs {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Fragment != "" {
+			return nil, fmt.Errorf("invalid redirect URI %q", uri)
+		}
+	}
+	for _, scope := range req.Scopes {
+		if !containsString(PersonalTokenScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	client := &models.OAuthClient{
+		ID:           randomToken(16, hex.EncodeToString),
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Public:       req.Public,
+		Scopes:       req.Scopes,
+	}
+	if client.Scopes == nil {
+		client.Scopes = []string{}
+	}
+
+	var secret string
+	if !req.Public {
+		secret = randomToken(32, base64.RawURLEncoding.EncodeToString)
+		client.SecretHash = hashToken(secret)
+	}
+
+	client, err := s.oauthRepo.CreateClient(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register client: %w", err)
+	}
+
+	return &models.OAuthClientResponse{OAuthClient: client, ClientSecret: secret}, nil
+}
+
+// Authorize handles an authorization request for an authenticated user and
+// returns the URL to redirect the user agent to. An error is only returned
+// when the client or redirect URI cannot be trusted; all other failures are
+// reported to the client through the redirect.
+func (s *OIDCService) Authorize(ctx context.Context, userID int, req *models.AuthorizeRequest) (string, error) {
+	client, err := s.oauthRepo.GetClient(ctx, req.ClientID)
+	if err != nil {
+		return "", &OAuthError{Code: "invalid_client", Description: "unknown client"}
+	}
+	if !containsString(client.RedirectURIs, req.RedirectURI) {
+		return "", &OAuthError{Code: "invalid_request", Description: "redirect_uri is not registered for this client"}
+	}
+
+	redirect := func(params url.Values) (string, error) {
+		if req.State != "" {
+			params.Set("state", req.State)
+		}
+		target, _ := url.Parse(req.RedirectURI)
+		query := target.Query()
+		for key, values := range params {
+			query[key] = values
+		}
+		target.RawQuery = query.Encode()
+		return target.String(), nil
+	}
+	fail := func(code, description string) (string, error) {
+		return redirect(url.Values{"error": {code}, "error_description": {description}})
+	}
+
+	if req.Denied {
+		return fail("access_denied", "the user denied the request")
+	}
+	if req.ResponseType != "code" {
+		return fail("unsupported_response_type", "only the code response type is supported")
+	}
+	if !containsString(strings.Fields(req.Scope), ScopeOpenID) {
+		return fail("invalid_scope", "the openid scope is required")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return fail("invalid_request", "PKCE with code_challenge_method=S256 is required")
+	}
+
+	code := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	err = s.oauthRepo.SaveAuthorizationCode(ctx, &models.AuthorizationCode{
+		CodeHash:            hashToken(code),
+		ClientID:            client.ID,
+		UserID:              userID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(s.cfg.CodeTTL),
+	})
+	if err != nil {
+		s.logger.Error("failed to save authorization code", zap.String("client_id", client.ID), zap.Error(err))
+		return fail("server_error", "failed to issue authorization code")
+	}
+
+	return redirect(url.Values{"code": {code}})
+}
+
+// Token handles a token endpoint request for any supported grant type
+func (s *OIDCService) Token(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(ctx, req)
+	case GrantTypeDeviceCode:
+		return s.exchangeDeviceCode(ctx, req)
+	default:
+		return nil, &OAuthError{Code: "unsupported_grant_type", Description: "grant type is not supported"}
+	}
+}
+
+// exchangeAuthorizationCode redeems an authorization code for an access token and ID token
+func (s *OIDCService) exchangeAuthorizationCode(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.oauthRepo.ConsumeAuthorizationCode(ctx, hashToken(req.Code))
+	if err != nil {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "authorization code is invalid or already used"}
+	}
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "authorization code was issued to another client or redirect URI"}
+	}
+	if time.Now().After(code.ExpiresAt) {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "authorization code has expired"}
+	}
+	if !verifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "code_verifier does not match code_challenge"}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, code.UserID)
+	if err != nil {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "user no longer exists"}
+	}
+
+	return s.issueTokens(client, user, code.Scope, code.Nonce)
+}
+
+// authenticateClient looks up a client and verifies its secret. Public
+// clients have no secret and rely on PKCE or the device flow instead.
+func (s *OIDCService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*models.OAuthClient, error) {
+	client, err := s.oauthRepo.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, &OAuthError{Code: "invalid_client", Description: "unknown client"}
+	}
+	if client.Public {
+		return client, nil
+	}
+
+	expected := []byte(client.SecretHash)
+	actual := []byte(hashToken(clientSecret))
+	if clientSecret == "" || subtle.ConstantTimeCompare(expected, actual) != 1 {
+		return nil, &OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+	return client, nil
+}
+
+// issueTokens signs an access token and, for openid requests, an ID token.
+// The tokens carry only the requested scopes grantedScope allows.
+func (s *OIDCService) issueTokens(client *models.OAuthClient, user *models.User, scope, nonce string) (*models.TokenResponse, error) {
+	scope = grantedScope(client, user, scope)
+	now := time.Now()
+	accessToken, err := s.signer.SignJWT(jwt.MapClaims{
+		"iss":       s.cfg.Issuer,
+		"sub":       strconv.Itoa(user.ID),
+		"client_id": client.ID,
+		"scope":     scope,
+		"iat":       now.Unix(),
+		"exp":       now.Add(s.jwtCfg.Expiration).Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	resp := &models.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.jwtCfg.Expiration.Seconds()),
+		Scope:       scope,
+	}
+	if !containsString(strings.Fields(scope), ScopeOpenID) {
+		return resp, nil
+	}
+
+	idClaims := s.userClaims(user, scope)
+	idClaims["iss"] = s.cfg.Issuer
+	idClaims["aud"] = client.ID
+	idClaims["iat"] = now.Unix()
+	idClaims["exp"] = now.Add(s.cfg.IDTokenTTL).Unix()
+	if nonce != "" {
+		idClaims["nonce"] = nonce
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, idClaims)
+	idToken.Header["kid"] = s.keyID
+	resp.IDToken, err = idToken.SignedString(s.signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign id token: %w", err)
+	}
+
+	return resp, nil
+}
+
+// grantedScope returns the scopes of requested a token for user issued to
+// client may carry: the OpenID Connect scopes, and the API scopes both the
+// client was registered with and the user's role allows. Anything else is
+// dropped, so a client cannot widen its access by asking for more.
+func grantedScope(client *models.OAuthClient, user *models.User, requested string) string {
+	var granted []string
+	for _, scope := range strings.Fields(requested) {
+		switch {
+		case containsString(granted, scope):
+		case scope == ScopeOpenID || scope == ScopeProfile || scope == ScopeEmail:
+			granted = append(granted, scope)
+		case containsString(client.Scopes, scope) && roleAllowsScope(user.Role, scope):
+			granted = append(granted, scope)
+		}
+	}
+	return strings.Join(granted, " ")
+}
+
+// roleAllowsScope reports whether a user with role may hold scope; admin
+// and runbook access are for admins only
+func roleAllowsScope(role, scope string) bool {
+	return (scope != ScopeAdminRunbook && scope != ScopeAdmin) || role == models.RoleAdmin
+}
+
+// UserInfo returns the claims of a user for the given scope
+func (s *OIDCService) UserInfo(ctx context.Context, userID int, scope string) (map[string]interface{}, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.userClaims(user, scope), nil
+}
+
+// userClaims builds the standard claims released for scope
+func (s *OIDCService) userClaims(user *models.User, scope string) jwt.MapClaims {
+	claims := jwt.MapClaims{"sub": strconv.Itoa(user.ID)}
+
+	scopes := strings.Fields(scope)
+	if containsString(scopes, ScopeProfile) {
+		claims["name"] = user.Name
+		claims["updated_at"] = user.UpdatedAt.Unix()
+	}
+	if containsString(scopes, ScopeEmail) {
+		claims["email"] = user.Email
+	}
+
+	return claims
+}
+
+// verifyPKCE checks an RFC 7636 S256 code verifier against its challenge
+func verifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// loadSigningKey reads an RSA private key from a PEM file, or generates one
+func loadSigningKey(path string, logger *zap.Logger) (*rsa.PrivateKey, error) {
+	if path == "" {
+		logger.Warn("OIDC_SIGNING_KEY_FILE not set, generating an ephemeral signing key")
+		return rsa.GenerateKey(rand.Reader, 2048)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key is not an RSA key")
+	}
+	return key, nil
+}
+
+// randomToken returns n random bytes encoded with encode
+func randomToken(n int, encode func([]byte) string) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return encode(b)
+}
+
+// hashToken returns the hex SHA-256 of a high-entropy token for storage at rest
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
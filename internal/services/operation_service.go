@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// OperationFunc performs the work of an operation. It reports progress as a
+// percentage and returns a link to the result, if there is one.
+type OperationFunc func(ctx context.Context, progress func(percent int)) (resultURL string, err error)
+
+// OperationService runs long-running work in the background and records
+// its state so clients can poll /operations/{id}
+type OperationService struct {
+	repo   repository.OperationRepository
+	logger *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewOperationService creates a new operation service
+func NewOperationService(repo repository.OperationRepository, logger *zap.Logger) *OperationService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OperationService{
+		repo:   repo,
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start records a pending operation and runs fn in the background
+func (s *OperationService) Start(ctx context.Context, opType string, createdBy *int, fn OperationFunc) (*models.Operation, error) {
+	op := &models.Operation{
+		ID:        randomToken(16, hex.EncodeToString),
+		Type:      opType,
+		Status:    models.OperationPending,
+		CreatedBy: createdBy,
+	}
+	if err := s.repo.Create(ctx, op); err != nil {
+		return nil, err
+	}
+
+	s.wg.Add(1)
+	go s.run(*op, fn)
+
+	return op, nil
+}
+
+// GetOperation retrieves an operation. Operations started by a user are
+// only visible to that user and to admins.
+func (s *OperationService) GetOperation(ctx context.Context, id string, userID int, isAdmin bool) (*models.Operation, error) {
+	op, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin && op.CreatedBy != nil && *op.CreatedBy != userID {
+		return nil, apperrors.NotFound("operation not found")
+	}
+	return op, nil
+}
+
+// Shutdown cancels running operations and waits for them to record their outcome
+func (s *OperationService) Shutdown() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// run executes fn and records progress and the outcome
+func (s *OperationService) run(op models.Operation, fn OperationFunc) {
+	defer s.wg.Done()
+
+	op.Status = models.OperationRunning
+	s.save(&op)
+
+	var mu sync.Mutex
+	progress := func(percent int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if percent < op.Progress || percent > 100 {
+			return
+		}
+		op.Progress = percent
+		s.save(&op)
+	}
+
+	resultURL, err := s.call(fn, progress)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	op.CompletedAt = &now
+	if err != nil {
+		op.Status = models.OperationFailed
+		op.Error = err.Error()
+	} else {
+		op.Status = models.OperationSucceeded
+		op.Progress = 100
+		op.ResultURL = resultURL
+	}
+	s.save(&op)
+}
+
+// call runs fn, turning a panic into an error so the operation is marked failed
+func (s *OperationService) call(fn OperationFunc, progress func(int)) (resultURL string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("operation panicked: %v", p)
+		}
+	}()
+	return fn(s.ctx, progress)
+}
+
+// save persists op, logging failures since there is no caller to return them
+// to. The outcome of an operation cancelled by Shutdown is still recorded.
+func (s *OperationService) save(op *models.Operation) {
+	if err := s.repo.Update(context.WithoutCancel(s.ctx), op); err != nil {
+		s.logger.Error("failed to update operation", zap.String("operation_id", op.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/events"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// outboxRelayBatchSize is how many events one relay run claims
+	outboxRelayBatchSize = 100
+	// outboxClaimLease is how long a claimed event is held back from other
+	// relays; an event whose relay dies is published again once it expires
+	outboxClaimLease = time.Minute
+	// outboxMaxRetryDelay caps the wait between attempts at an event
+	outboxMaxRetryDelay = time.Hour
+)
+
+// OutboxService stores domain events in the outbox and relays them to the
+// event publisher in the background. Events are published at least once:
+// consumers drop duplicates by event ID.
+type OutboxService struct {
+	outboxRepo repository.OutboxRepository
+	publisher  events.Publisher
+	cfg        config.EventsConfig
+}
+
+// NewOutboxService creates a new outbox service relaying to publisher
+func NewOutboxService(outboxRepo repository.OutboxRepository, publisher events.Publisher, cfg config.EventsConfig) *OutboxService {
+	return &OutboxService{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		cfg:        cfg,
+	}
+}
+
+// Enqueue stores event for publishing. Called within a transaction, the
+// event is only published if the transaction commits.
+func (s *OutboxService) Enqueue(ctx context.Context, event events.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	stored := &models.OutboxEvent{
+		EventID:    event.ID,
+		Type:       event.Type,
+		Subject:    event.Subject,
+		Data:       data,
+		OccurredAt: event.Time,
+	}
+	if err := s.outboxRepo.Enqueue(ctx, stored); err != nil {
+		return fmt.Errorf("failed to enqueue event: %w", err)
+	}
+	return nil
+}
+
+// RelayDue publishes the events that are due, oldest first, and returns how
+// many were published. Rejected events are retried with exponential backoff
+// until the publisher accepts them.
+func (s *OutboxService) RelayDue(ctx context.Context) (int, error) {
+	pending, err := s.outboxRepo.ClaimDue(ctx, outboxRelayBatchSize, outboxClaimLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	published := 0
+	for _, stored := range pending {
+		publishErr := s.publisher.Publish(ctx, events.Event{
+			ID:      stored.EventID,
+			Type:    stored.Type,
+			Subject: stored.Subject,
+			Time:    stored.OccurredAt.UTC(),
+			Data:    stored.Data,
+		})
+		if publishErr == nil {
+			err = s.outboxRepo.MarkPublished(ctx, stored.ID)
+			published++
+		} else {
+			err = s.outboxRepo.MarkRetry(ctx, stored.ID, publishErr.Error(), time.Now().Add(s.retryDelay(stored.Attempts)))
+		}
+		if err != nil {
+			return published, fmt.Errorf("failed to record publishing of event %s: %w", stored.EventID, err)
+		}
+	}
+	return published, nil
+}
+
+// retryDelay returns the wait after the given failed attempt, doubling from
+// OutboxRetryBackoff up to an hour
+func (s *OutboxService) retryDelay(attempts int) time.Duration {
+	delay := s.cfg.OutboxRetryBackoff
+	for i := 1; i < attempts && delay < outboxMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxRetryDelay {
+		delay = outboxMaxRetryDelay
+	}
+	return delay
+}
+
+// RunRelay publishes due events every interval until ctx is cancelled,
+// removing events published longer ago than OutboxRetention
+func (s *OutboxService) RunRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := s.RelayDue(ctx)
+			if err != nil {
+				logger.FromContext(ctx).Error("event relay failed", zap.Error(err))
+			} else if published > 0 {
+				logger.FromContext(ctx).Debug("published events", zap.Int("count", published))
+			}
+			if s.cfg.OutboxRetention > 0 {
+				if _, err := s.outboxRepo.DeletePublished(ctx, time.Now().Add(-s.cfg.OutboxRetention)); err != nil {
+					logger.FromContext(ctx).Error("failed to clean up published events", zap.Error(err))
+				}
+			}
+		}
+	}
+}
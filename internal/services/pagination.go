@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// How GetUsers answers pages past the maximum offset
+const (
+	DeepPagesReject = "reject"
+	DeepPagesCursor = "cursor"
+)
+
+// Pagination holds the page sizes of each paginated route and how deep
+// offset pages of users may go
+type Pagination struct {
+	routes    map[string]models.PageLimits
+	maxOffset int
+	deepPages string
+}
+
+// PageTooDeepError reports a page of users past the maximum offset.
+// NextCursor, when set, continues in cursor mode from the last user offset
+// pages reach, and Follow reports whether such pages are answered from it.
+type PageTooDeepError struct {
+	MaxOffset  int
+	NextCursor string
+	Follow     bool
+}
+
+// Error describes the limit
+func (e *PageTooDeepError) Error() string {
+	return fmt.Sprintf("page and limit reach past the first %d users; use cursor pagination for deeper pages", e.MaxOffset)
+}
+
+// Is reports whether target is apperrors.ErrValidation
+func (e *PageTooDeepError) Is(target error) bool {
+	return target == apperrors.ErrValidation
+}
+
+// DefaultPagination returns pages of 10 and at most 100 on every route
+func DefaultPagination() *Pagination {
+	p, _ := NewPagination(config.PaginationConfig{DefaultLimit: 10, MaxLimit: 100})
+	return p
+}
+
+// NewPagination creates the page sizes configured by cfg
+func NewPagination(cfg config.PaginationConfig) (*Pagination, error) {
+	routes, err := cfg.RouteLimits()
+	if err != nil {
+		return nil, err
+	}
+	p := &Pagination{routes: routes, maxOffset: cfg.MaxOffset, deepPages: cfg.DeepPages}
+	if p.deepPages == "" {
+		p.deepPages = DeepPagesReject
+	}
+	return p, nil
+}
+
+// Limits returns the page sizes of route
+func (p *Pagination) Limits(route string) models.PageLimits {
+	return p.routes[route]
+}
+
+// MaxOffset returns the deepest row offset pages of users may reach, or
+// zero for any depth
+func (p *Pagination) MaxOffset() int {
+	return p.maxOffset
+}
+
+// DeepPages returns how pages past MaxOffset are answered, DeepPagesReject
+// or DeepPagesCursor
+func (p *Pagination) DeepPages() string {
+	return p.deepPages
+}
+
+// Capabilities returns the page sizes of every paginated route
+func (p *Pagination) Capabilities() map[string]models.PageLimits {
+	routes := make(map[string]models.PageLimits, len(p.routes))
+	for route, limits := range p.routes {
+		routes[route] = limits
+	}
+	return routes
+}
+
+// normalizeLimit falls back to route's default page size for out of range limits
+func (p *Pagination) normalizeLimit(route string, limit int) int {
+	limits := p.Limits(route)
+	if limit < 1 || limit > limits.MaxLimit {
+		return limits.DefaultLimit
+	}
+	return limit
+}
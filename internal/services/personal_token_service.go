@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/pratham15541/go-crud/internal/signing"
+	"go.uber.org/zap"
+)
+
+// Scopes that can be granted to personal access tokens
+const (
+	ScopeUsersRead    = "users:read"
+	ScopeUsersWrite   = "users:write"
+	ScopeAdminRunbook = "admin:runbook"
+	// ScopeAdmin lets an admin's token use the admin API, other than the runbook
+	ScopeAdmin = "admin"
+)
+
+// PersonalTokenScopes lists every scope a personal access token may carry
+var PersonalTokenScopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeAdminRunbook, ScopeAdmin}
+
+// personalTokenDisplayLength is how much of a token is kept for identification
+const personalTokenDisplayLength = 12
+
+// PersonalTokenService handles business logic for personal access tokens
+type PersonalTokenService struct {
+	tokenRepo repository.PersonalTokenRepository
+	cfg       config.PersonalTokenConfig
+	logger    *zap.Logger
+}
+
+// NewPersonalTokenService creates a new personal access token service
+func NewPersonalTokenService(tokenRepo repository.PersonalTokenRepository, cfg config.PersonalTokenConfig, logger *zap.Logger) *PersonalTokenService {
+	return &PersonalTokenService{
+		tokenRepo: tokenRepo,
+		cfg:       cfg,
+		logger:    logger,
+	}
+}
+
+// CreateToken mints a new token for a user. The plaintext token is only
+// returned here; only its hash is stored.
+func (s *PersonalTokenService) CreateToken(ctx context.Context, userID int, req *models.CreatePersonalTokenRequest) (*models.PersonalTokenResponse, error) {
+	if len(strings.TrimSpace(req.Name)) < 2 || len(req.Name) > 100 {
+		return nil, fmt.Errorf("name must be between 2 and 100 characters")
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if !containsString(PersonalTokenScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	ttl := s.cfg.DefaultTTL
+	if req.ExpiresIn != "" {
+		parsed, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("expires_in must be a positive duration such as 720h")
+		}
+		ttl = parsed
+	}
+	if ttl > s.cfg.MaxTTL {
+		return nil, fmt.Errorf("expires_in must not exceed %s", s.cfg.MaxTTL)
+	}
+	if req.SigningKey != "" {
+		if _, err := signing.ParsePublicKey(req.SigningKey); err != nil {
+			return nil, err
+		}
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	plaintext := models.PersonalTokenPrefix + randomToken(32, base64.RawURLEncoding.EncodeToString)
+	token, err := s.tokenRepo.Create(ctx, &models.PersonalToken{
+		UserID:     userID,
+		Name:       req.Name,
+		TokenHash:  hashToken(plaintext),
+		Prefix:     plaintext[:personalTokenDisplayLength],
+		Scopes:     req.Scopes,
+		ExpiresAt:  &expiresAt,
+		SigningKey: req.SigningKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return &models.PersonalTokenResponse{PersonalToken: token, Token: plaintext}, nil
+}
+
+// ListTokens returns a user's active tokens
+func (s *PersonalTokenService) ListTokens(ctx context.Context, userID int) ([]*models.PersonalToken, error) {
+	tokens, err := s.tokenRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes one of a user's tokens
+func (s *PersonalTokenService) RevokeToken(ctx context.Context, userID, id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid token ID")
+	}
+	return s.tokenRepo.Revoke(ctx, userID, id)
+}
+
+// SetSigningKey registers the public key mutating requests made with one of
+// a user's tokens must be signed with, replacing any key it had
+func (s *PersonalTokenService) SetSigningKey(ctx context.Context, userID, id int, req *models.SigningKeyRequest) error {
+	if _, err := signing.ParsePublicKey(req.SigningKey); err != nil {
+		return apperrors.Validation(err.Error())
+	}
+	return s.tokenRepo.SetSigningKey(ctx, userID, id, req.SigningKey)
+}
+
+// RemoveSigningKey stops requiring one of a user's tokens to sign requests,
+// unless every token must
+func (s *PersonalTokenService) RemoveSigningKey(ctx context.Context, userID, id int) error {
+	return s.tokenRepo.SetSigningKey(ctx, userID, id, "")
+}
+
+// AuthenticateToken resolves a plaintext token into claims equivalent to a
+// JWT's, so downstream middleware and handlers treat both the same way
+func (s *PersonalTokenService) AuthenticateToken(ctx context.Context, plaintext string) (jwt.MapClaims, error) {
+	token, err := s.tokenRepo.GetByHash(ctx, hashToken(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if token.RevokedAt != nil {
+		return nil, fmt.Errorf("token has been revoked")
+	}
+	if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+		return nil, fmt.Errorf("token has expired")
+	}
+
+	if err := s.tokenRepo.TouchLastUsed(ctx, token.ID); err != nil {
+		s.logger.Warn("failed to record personal token use", zap.Int("token_id", token.ID), zap.Error(err))
+	}
+
+	claims := jwt.MapClaims{
+		"sub":        strconv.Itoa(token.UserID),
+		"scope":      strings.Join(token.Scopes, " "),
+		"token_type": "pat",
+		"token_id":   token.ID,
+	}
+	if token.SigningKey != "" {
+		claims["signing_key"] = token.SigningKey
+	}
+	return claims, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+)
+
+// RunbookService performs the operational actions that would otherwise
+// take manual changes to the database or cache servers. Actions on
+// disabled components fail with a conflict.
+type RunbookService struct {
+	migrator *database.Migrator
+	// openMigrations opens the pool migrations run on
+	openMigrations func() (*sql.DB, error)
+
+	// caches are flushed in the order they were added
+	caches   []namedCache
+	keys     *JWTKeyring
+	emails   repository.EmailRepository
+	webhooks *WebhookService
+}
+
+// namedCache is a cache FlushCaches empties
+type namedCache struct {
+	name  string
+	flush func(ctx context.Context) error
+}
+
+// NewRunbookService creates a runbook service applying the migrations of
+// migrator on pools opened by openMigrations, which use the credentials of
+// DB_MIGRATIONS_* rather than those of the API
+func NewRunbookService(migrator *database.Migrator, openMigrations func() (*sql.DB, error)) *RunbookService {
+	return &RunbookService{migrator: migrator, openMigrations: openMigrations}
+}
+
+// AddCache makes FlushCaches empty a cache with flush, reporting it as name
+func (s *RunbookService) AddCache(name string, flush func(ctx context.Context) error) {
+	s.caches = append(s.caches, namedCache{name: name, flush: flush})
+}
+
+// SetJWTKeys rotates keys in keys
+func (s *RunbookService) SetJWTKeys(keys *JWTKeyring) {
+	s.keys = keys
+}
+
+// SetEmails replays failed emails in emails
+func (s *RunbookService) SetEmails(emails repository.EmailRepository) {
+	s.emails = emails
+}
+
+// SetWebhooks redelivers failed deliveries of webhooks
+func (s *RunbookService) SetWebhooks(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
+
+// FlushCaches empties every cache, stopping at the first that fails
+func (s *RunbookService) FlushCaches(ctx context.Context) (*models.CacheFlushResult, error) {
+	result := &models.CacheFlushResult{Caches: []string{}}
+	for _, c := range s.caches {
+		if err := c.flush(ctx); err != nil {
+			return nil, apperrors.Internal(fmt.Sprintf("failed to flush the %s cache", c.name), err)
+		}
+		result.Caches = append(result.Caches, c.name)
+	}
+	return result, nil
+}
+
+// RotateJWTKey starts signing tokens with a new key
+func (s *RunbookService) RotateJWTKey(ctx context.Context) (*models.JWTKey, error) {
+	if s.keys == nil {
+		return nil, apperrors.Conflict("JWT key rotation needs DB_DRIVER=postgres")
+	}
+	key, err := s.keys.Rotate(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to rotate JWT key", err)
+	}
+	return key, nil
+}
+
+// RunMigrations applies the pending migrations, such as after one failed
+// at startup and the cause was fixed
+func (s *RunbookService) RunMigrations(ctx context.Context) (*models.MigrationRunResult, error) {
+	db, err := s.openMigrations()
+	if err != nil {
+		return nil, apperrors.Internal("failed to connect to the database for migrations", err)
+	}
+	defer db.Close()
+
+	before, err := s.migrator.MigrationStatus(ctx, db)
+	if err != nil {
+		return nil, apperrors.Internal("failed to read migration status", err)
+	}
+	if err := s.migrator.RunMigrations(db); err != nil {
+		return nil, apperrors.Internal("failed to run migrations", err)
+	}
+	after, err := s.migrator.MigrationStatus(ctx, db)
+	if err != nil {
+		return nil, apperrors.Internal("failed to read migration status", err)
+	}
+
+	result := &models.MigrationRunResult{Applied: []string{}}
+	for i, state := range after {
+		if state.AppliedAt == nil {
+			continue
+		}
+		if i >= len(before) || before[i].AppliedAt == nil {
+			result.Applied = append(result.Applied, fmt.Sprintf("%04d_%s", state.Version, state.Name))
+		}
+		result.SchemaVersion = state.Version
+	}
+	return result, nil
+}
+
+// ReplayDeadLetters queues the jobs that ran out of attempts again
+func (s *RunbookService) ReplayDeadLetters(ctx context.Context) (*models.DeadLetterReplayResult, error) {
+	if s.emails == nil {
+		return nil, apperrors.Conflict("there are no failed jobs to replay: email is disabled")
+	}
+	n, err := s.emails.RequeueFailed(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to replay failed emails", err)
+	}
+	return &models.DeadLetterReplayResult{Emails: n}, nil
+}
+
+// RedeliverWebhooks queues the webhook deliveries that ran out of attempts
+// again
+func (s *RunbookService) RedeliverWebhooks(ctx context.Context) (*models.WebhookRedeliveryResult, error) {
+	if s.webhooks == nil {
+		return nil, apperrors.Conflict("webhooks are disabled")
+	}
+	n, err := s.webhooks.Redeliver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.WebhookRedeliveryResult{Deliveries: n}, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/docschema"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxSettingsSize bounds the encoded size of a settings document
+const maxSettingsSize = 16 << 10
+
+// UserSettingsSchema upgrades stored user settings to the current shape.
+// To change the shape, append an upgrade from the current version; stored
+// documents are upgraded as they are read.
+var UserSettingsSchema = docschema.NewPipeline("user settings")
+
+// SettingsService handles business logic for user settings documents
+type SettingsService struct {
+	settingsRepo repository.SettingsRepository
+	userRepo     repository.UserRepository
+	schema       *docschema.Pipeline
+}
+
+// NewSettingsService creates a new settings service whose documents follow schema
+func NewSettingsService(settingsRepo repository.SettingsRepository, userRepo repository.UserRepository, schema *docschema.Pipeline) *SettingsService {
+	return &SettingsService{
+		settingsRepo: settingsRepo,
+		userRepo:     userRepo,
+		schema:       schema,
+	}
+}
+
+// GetSettings retrieves a user's settings at the current schema version. An
+// outdated document is upgraded and the upgrade stored, so each document is
+// rewritten at most once per version. Documents from a newer release are
+// returned as stored.
+func (s *SettingsService) GetSettings(ctx context.Context, userID int) (*models.UserSettings, error) {
+	if userID <= 0 {
+		return nil, apperrors.Validation("invalid user ID")
+	}
+
+	settings, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			return nil, err
+		}
+		// Users without settings get an empty document
+		if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+			return nil, err
+		}
+		return &models.UserSettings{
+			UserID:        userID,
+			SchemaVersion: s.schema.Current(),
+			Settings:      json.RawMessage(`{}`),
+		}, nil
+	}
+
+	if settings.SchemaVersion >= s.schema.Current() {
+		return settings, nil
+	}
+
+	fromVersion := settings.SchemaVersion
+	settings.Settings, err = s.schema.Upgrade(fromVersion, settings.Settings)
+	if err != nil {
+		return nil, err
+	}
+	settings.SchemaVersion = s.schema.Current()
+
+	// The upgrade is served either way; storing it only saves repeating it
+	if _, err := s.settingsRepo.Upgrade(ctx, settings, fromVersion); err != nil {
+		logger.FromContext(ctx).Warn("failed to store upgraded settings",
+			zap.Int("user_id", userID),
+			zap.Error(err),
+		)
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces a user's settings with document, a JSON object in
+// the current shape. Settings stored by a newer release are not overwritten.
+func (s *SettingsService) UpdateSettings(ctx context.Context, userID int, document json.RawMessage) (*models.UserSettings, error) {
+	if userID <= 0 {
+		return nil, apperrors.Validation("invalid user ID")
+	}
+	if len(document) > maxSettingsSize {
+		return nil, apperrors.Validation(fmt.Sprintf("settings must be at most %d bytes", maxSettingsSize))
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(document, &object); err != nil || object == nil {
+		return nil, apperrors.Validation("settings must be a JSON object")
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, document); err != nil {
+		return nil, apperrors.Validation("settings must be a JSON object")
+	}
+	settings := &models.UserSettings{
+		UserID:        userID,
+		SchemaVersion: s.schema.Current(),
+		Settings:      compact.Bytes(),
+	}
+	if err := s.settingsRepo.Save(ctx, settings, s.schema.Current()); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// transitionSampleSize is how many mismatched rows a consistency check lists
+const transitionSampleSize = 20
+
+// TransitionService backfills and checks the expand/contract schema
+// transitions in progress
+type TransitionService struct {
+	transitions []repository.SchemaTransition
+}
+
+// NewTransitionService creates a new transition service
+func NewTransitionService(transitions ...repository.SchemaTransition) *TransitionService {
+	return &TransitionService{transitions: transitions}
+}
+
+// RunBackfill backfills every transition that is dual-writing, one batch at
+// a time with pause in between to spare the database. It returns once no
+// rows are left or ctx is cancelled; dual-writes keep new rows filled.
+func (s *TransitionService) RunBackfill(ctx context.Context, batchSize int, pause time.Duration) {
+	for _, transition := range s.transitions {
+		phase := transition.Phase()
+		// Before dual-writes the backfill would fall behind again, and after
+		// them nothing writes the old column any more
+		if !phase.WritesOld() || !phase.WritesNew() {
+			continue
+		}
+		log := logger.FromContext(ctx).With(zap.String("transition", transition.Name()))
+
+		var total int64
+		for {
+			filled, err := transition.Backfill(ctx, batchSize)
+			if err != nil {
+				log.Error("backfill failed", zap.Error(err))
+				break
+			}
+			total += filled
+			if filled < int64(batchSize) {
+				log.Info("backfill complete", zap.Int64("rows", total))
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pause):
+			}
+		}
+	}
+}
+
+// Check compares the old and new columns of the named transition
+func (s *TransitionService) Check(ctx context.Context, name string) (*models.TransitionReport, error) {
+	for _, transition := range s.transitions {
+		if transition.Name() == name {
+			report, err := transition.Check(ctx, transitionSampleSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check transition: %w", err)
+			}
+			return report, nil
+		}
+	}
+	return nil, apperrors.NotFound("transition not found")
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// importColumns are the CSV columns an import needs, in any order
+var importColumns = []string{"name", "email", "age"}
+
+// ImportUsers creates a user from every valid row of a CSV document whose
+// header names the name, email and age columns; other columns are ignored.
+// Rows are read as they arrive and inserted MaxBulkUsers at a time, each
+// batch in its own transaction. Rows that are malformed, invalid or rejected
+// by the database are skipped and listed in the summary. An error means the
+// document could not be read to the end; the rows before it are imported.
+func (s *UserService) ImportUsers(ctx context.Context, document io.Reader) (*models.ImportUsersSummary, error) {
+	reader := csv.NewReader(document)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, apperrors.Validation("CSV document is empty")
+	}
+	if err != nil {
+		return nil, apperrors.Validation(fmt.Sprintf("invalid CSV header: %v", err))
+	}
+	columns, err := importColumnIndexes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ImportUsersSummary{SkippedRows: []models.ImportSkippedRow{}}
+	var batch []*models.CreateUserRequest
+	var batchRows []int
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		users, errs, err := s.userRepo.CreateBatch(ctx, batch)
+		if err != nil {
+			return apperrors.Internal("failed to import users", err)
+		}
+		for i, user := range users {
+			if errs[i] != nil {
+				summary.Skip(batchRows[i], errs[i].Error())
+			} else if user != nil {
+				summary.Created++
+			}
+		}
+		batch, batchRows = batch[:0], batchRows[:0]
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			// The rows read so far are whole, so they are still imported
+			if flushErr := flush(); flushErr != nil {
+				return summary, flushErr
+			}
+			return summary, fmt.Errorf("failed to read CSV after %d rows: %w", summary.Processed, err)
+		}
+
+		summary.Processed++
+		if parseErr != nil {
+			summary.Skip(parseErr.StartLine, parseErr.Err.Error())
+			continue
+		}
+		row, _ := reader.FieldPos(0)
+
+		req, err := importRequest(record, columns)
+		if err == nil {
+			err = s.validateCreateUserRequest(req)
+		}
+		if err != nil {
+			summary.Skip(row, err.Error())
+			continue
+		}
+
+		batch = append(batch, req)
+		batchRows = append(batchRows, row)
+		if len(batch) == MaxBulkUsers {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// importColumnIndexes returns where each import column is in header
+func importColumnIndexes(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(importColumns))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, seen := columns[name]; !seen {
+			columns[name] = i
+		}
+	}
+
+	var missing []string
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, apperrors.Validation("CSV header is missing columns: " + strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// importRequest builds the create request for one CSV record
+func importRequest(record []string, columns map[string]int) (*models.CreateUserRequest, error) {
+	field := func(name string) string {
+		if i := columns[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	req := &models.CreateUserRequest{Name: field("name"), Email: field("email")}
+	if age := field("age"); age != "" {
+		var err error
+		if req.Age, err = strconv.Atoi(age); err != nil {
+			return nil, apperrors.Validation("age must be a whole number")
+		}
+	}
+	return req, nil
+}
@@ -1,54 +1,260 @@
 package services
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"time"
 
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/events"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/logger"
 	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/realtime"
 	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
 )
 
+// errInvalidCursor is returned for cursors the API did not issue
+var errInvalidCursor = apperrors.Validation("invalid cursor")
+
+// exportBatchSize is the number of users an export reads at a time
+const exportBatchSize = 500
+
+// MaxBulkUsers is the most users one bulk create may contain
+const MaxBulkUsers = 100
+
+// BulkCreateResult is the outcome of creating one user of a bulk request:
+// the user, or the error that kept it from being created
+type BulkCreateResult struct {
+	User *models.User
+	Err  error
+}
+
 // UserService handles business logic for user operations
 type UserService struct {
-	userRepo repository.UserRepository
+	userRepo   repository.UserRepository
+	pagination *Pagination
+	ids        idcodec.Codec
+
+	// transactions is set by SetAuditLog and SetOutbox, audit by
+	// SetAuditLog; nil disables auditing
+	transactions repository.TxManager
+	audit        *AuditService
+
+	// webhooks is set by SetWebhooks and live by SetLiveUpdates; nil
+	// notifies no one
+	webhooks *WebhookService
+	live     *realtime.Hub
+	// publisher is set by SetEvents; nil publishes no domain events
+	publisher events.Publisher
+	// outbox is set by SetOutbox; nil publishes domain events directly
+	outbox *OutboxService
 }
 
-// NewUserService creates a new user service
-func NewUserService(userRepo repository.UserRepository) *UserService {
+// NewUserService creates a new user service that identifies users in
+// events by the IDs codec encodes
+func NewUserService(userRepo repository.UserRepository, pagination *Pagination, ids idcodec.Codec) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:   userRepo,
+		pagination: pagination,
+		ids:        ids,
 	}
 }
 
+// SetAuditLog records changes that no API request makes directly, such as
+// anonymizing a user in the background, in audit. Each entry is written in the
+// same transaction as the change, so neither is kept without the other.
+func (s *UserService) SetAuditLog(transactions repository.TxManager, audit *AuditService) {
+	s.transactions = transactions
+	s.audit = audit
+}
+
+// SetWebhooks notifies webhooks of every user created, updated or deleted
+func (s *UserService) SetWebhooks(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
+
+// SetLiveUpdates pushes every user created, updated or deleted to the
+// WebSocket clients of hub
+func (s *UserService) SetLiveUpdates(hub *realtime.Hub) {
+	s.live = hub
+}
+
+// SetEvents publishes a domain event to publisher after every user created,
+// updated or deleted
+func (s *UserService) SetEvents(publisher events.Publisher) {
+	s.publisher = publisher
+}
+
+// SetOutbox writes the domain event of every user created, updated or
+// deleted to outbox in the transaction that makes the change, rather than
+// publishing it afterwards; the outbox relay publishes it once committed
+func (s *UserService) SetOutbox(transactions repository.TxManager, outbox *OutboxService) {
+	s.transactions = transactions
+	s.outbox = outbox
+}
+
+// stage writes the domain event of event on user to the outbox when it is
+// enabled. Called within withinTransaction, it commits with the change.
+func (s *UserService) stage(ctx context.Context, event string, user *models.User) error {
+	return s.stageData(ctx, event, user.ID, user.ToResponse(s.ids))
+}
+
+// stageData writes the domain event of event on the user with userID,
+// carrying data, to the outbox when it is enabled
+func (s *UserService) stageData(ctx context.Context, event string, userID int, data interface{}) error {
+	if s.outbox == nil {
+		return nil
+	}
+	return s.outbox.Enqueue(ctx, events.NewEvent(event, s.ids.Encode(userID), data))
+}
+
+// publish notifies webhooks, WebSocket clients and the event publisher of
+// event on user when they are enabled
+func (s *UserService) publish(ctx context.Context, event string, user *models.User) {
+	s.publishData(ctx, event, user.ID, user.ToResponse(s.ids))
+}
+
+// publishData notifies webhooks, WebSocket clients and, unless the outbox
+// holds its events, the event publisher of event on the user with userID,
+// carrying data. The change is already made, so a failure to publish is
+// logged rather than returned.
+func (s *UserService) publishData(ctx context.Context, event string, userID int, data interface{}) {
+	if s.webhooks != nil {
+		s.webhooks.Publish(ctx, event, data)
+	}
+	if s.live != nil {
+		s.live.Publish(event, userID, data)
+	}
+	if s.publisher != nil && s.outbox == nil {
+		domainEvent := events.NewEvent(event, s.ids.Encode(userID), data)
+		if err := s.publisher.Publish(ctx, domainEvent); err != nil {
+			logger.FromContext(ctx).Error("failed to publish domain event",
+				zap.String("event", event), zap.String("event_id", domainEvent.ID), zap.Error(err))
+		}
+	}
+}
+
+// withinTransaction runs fn in a transaction when auditing or the outbox is
+// enabled, and directly otherwise
+func (s *UserService) withinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactions == nil {
+		return fn(ctx)
+	}
+	return s.transactions.WithinTransaction(ctx, fn)
+}
+
+// recordChange adds an audit entry for action when auditing is enabled
+func (s *UserService) recordChange(ctx context.Context, action, targetID string) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.Record(ctx, &models.AuditEntry{Action: action, TargetID: targetID})
+}
+
 // CreateUser creates a new user
-func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	// Validate business rules
 	if err := s.validateCreateUserRequest(req); err != nil {
 		return nil, err
 	}
 
-	// Check if email already exists
-	existingUser, _ := s.userRepo.GetByEmail(req.Email)
+	// Check if email already exists. Concurrent creates can all pass this
+	// check; the unique index then rejects all but one with the same error.
+	existingUser, _ := s.userRepo.GetByEmail(ctx, req.Email)
 	if existingUser != nil {
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
+		return nil, &models.DuplicateEmailError{Email: req.Email}
 	}
 
 	// Create user
-	user, err := s.userRepo.Create(req)
+	var user *models.User
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.userRepo.Create(ctx, req); err != nil {
+			return err
+		}
+		return s.stage(ctx, models.WebhookUserCreated, user)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		if isConstraintError(err) {
+			return nil, err
+		}
+		return nil, apperrors.Internal("failed to create user", err)
 	}
 
+	s.publish(ctx, models.WebhookUserCreated, user)
 	return user, nil
 }
 
+// CreateUsers creates every valid user of reqs in one transaction. Invalid
+// requests and users the database rejects, such as taken emails, fail
+// alone; the error is only for the batch as a whole.
+func (s *UserService) CreateUsers(ctx context.Context, reqs []*models.CreateUserRequest) ([]BulkCreateResult, error) {
+	if len(reqs) == 0 {
+		return nil, apperrors.Validation("at least one user is required")
+	}
+	if len(reqs) > MaxBulkUsers {
+		return nil, apperrors.Validation(fmt.Sprintf("at most %d users can be created at once", MaxBulkUsers))
+	}
+
+	results := make([]BulkCreateResult, len(reqs))
+	var valid []*models.CreateUserRequest
+	var positions []int
+	for i, req := range reqs {
+		if err := s.validateCreateUserRequest(req); err != nil {
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, req)
+		positions = append(positions, i)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	var users []*models.User
+	var errs []error
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if users, errs, err = s.userRepo.CreateBatch(ctx, valid); err != nil {
+			return err
+		}
+		for j, user := range users {
+			if errs[j] != nil {
+				continue
+			}
+			if err := s.stage(ctx, models.WebhookUserCreated, user); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, apperrors.Internal("failed to create users", err)
+	}
+	for j, i := range positions {
+		results[i] = BulkCreateResult{User: users[j], Err: errs[j]}
+		if errs[j] != nil && !isConstraintError(errs[j]) {
+			results[i].Err = apperrors.Internal("failed to create user", errs[j])
+		}
+		if errs[j] == nil {
+			s.publish(ctx, models.WebhookUserCreated, users[j])
+		}
+	}
+	return results, nil
+}
+
 // GetUser retrieves a user by ID
-func (s *UserService) GetUser(id int) (*models.User, error) {
+func (s *UserService) GetUser(ctx context.Context, id int) (*models.User, error) {
 	if id <= 0 {
-		return nil, fmt.Errorf("invalid user ID")
+		return nil, apperrors.Validation("invalid user ID")
 	}
 
-	user, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -56,26 +262,33 @@ func (s *UserService) GetUser(id int) (*models.User, error) {
 	return user, nil
 }
 
-// GetUsers retrieves all users with pagination
-func (s *UserService) GetUsers(page, limit int) ([]*models.User, int64, error) {
+// GetUsers retrieves users matching filter with pagination
+func (s *UserService) GetUsers(ctx context.Context, filter *models.UserFilter, page, limit int) ([]*models.User, int64, error) {
+	if err := validateUserFilter(filter); err != nil {
+		return nil, 0, err
+	}
+
 	// Validate pagination parameters
 	if page < 1 {
 		page = 1
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
+	limit = s.pagination.normalizeLimit(models.PageRouteUsers, limit)
 
 	offset := (page - 1) * limit
+	if maxOffset := s.pagination.MaxOffset(); maxOffset > 0 && offset+limit > maxOffset {
+		if err := s.checkPageDepth(ctx, filter, maxOffset); err != nil {
+			return nil, 0, err
+		}
+	}
 
 	// Get users
-	users, err := s.userRepo.GetAll(limit, offset)
+	users, err := s.userRepo.GetAll(ctx, filter, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get users: %w", err)
 	}
 
 	// Get total count
-	total, err := s.userRepo.Count()
+	total, err := s.userRepo.Count(ctx, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
@@ -83,10 +296,80 @@ func (s *UserService) GetUsers(page, limit int) ([]*models.User, int64, error) {
 	return users, total, nil
 }
 
+// checkPageDepth returns a PageTooDeepError for a page reaching past
+// maxOffset, so the database does not walk every row before it. When as
+// many users match, the error carries the cursor that continues after the
+// last user offset pages reach, unless filter sets a sort order cursor
+// pages cannot follow. With fewer users the page is shallow enough to read.
+func (s *UserService) checkPageDepth(ctx context.Context, filter *models.UserFilter, maxOffset int) error {
+	deepErr := &PageTooDeepError{MaxOffset: maxOffset, Follow: s.pagination.DeepPages() == DeepPagesCursor}
+	if filter != nil && len(filter.Sort) > 0 {
+		return deepErr
+	}
+
+	last, err := s.userRepo.GetAll(ctx, filter, 1, maxOffset-1)
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	if len(last) == 0 {
+		return nil
+	}
+	deepErr.NextCursor = encodeUserCursor(last[0])
+	return deepErr
+}
+
+// GetUsersAfter retrieves a keyset page of users matching filter following
+// the opaque cursor; an empty cursor starts from the newest user. Keyset
+// pages are always newest first, so filter may not set a sort order.
+func (s *UserService) GetUsersAfter(ctx context.Context, filter *models.UserFilter, cursor string, limit int) ([]*models.User, *models.CursorPagination, error) {
+	if err := validateUnsortedFilter(filter, "cursor"); err != nil {
+		return nil, nil, err
+	}
+	limit = s.pagination.normalizeLimit(models.PageRouteUsers, limit)
+
+	var position *models.UserCursor
+	if cursor != "" {
+		var err error
+		if position, err = decodeUserCursor(cursor); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	users, err := s.userRepo.GetAfter(ctx, filter, position, limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	pagination := &models.CursorPagination{Limit: limit}
+	if len(users) > limit {
+		users = users[:limit]
+		pagination.NextCursor = encodeUserCursor(users[limit-1])
+	}
+
+	return users, pagination, nil
+}
+
+// ExportUsers passes every user matching filter to fn in batches, newest
+// first. The batches come from a single snapshot, so users written during the
+// export are neither duplicated nor skipped. Exports are always newest first,
+// so filter may not set a sort order.
+func (s *UserService) ExportUsers(ctx context.Context, filter *models.UserFilter, fn func([]*models.User) error) error {
+	if err := validateUnsortedFilter(filter, "export"); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Export(ctx, filter, exportBatchSize, fn); err != nil {
+		return fmt.Errorf("failed to export users: %w", err)
+	}
+
+	return nil
+}
+
 // UpdateUser updates a user
-func (s *UserService) UpdateUser(id int, req *models.UpdateUserRequest) (*models.User, error) {
+func (s *UserService) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
 	if id <= 0 {
-		return nil, fmt.Errorf("invalid user ID")
+		return nil, apperrors.Validation("invalid user ID")
 	}
 
 	// Validate update request
@@ -96,85 +379,322 @@ func (s *UserService) UpdateUser(id int, req *models.UpdateUserRequest) (*models
 
 	// Check if email is being updated and already exists
 	if req.Email != "" {
-		existingUser, _ := s.userRepo.GetByEmail(req.Email)
+		existingUser, _ := s.userRepo.GetByEmail(ctx, req.Email)
 		if existingUser != nil && existingUser.ID != id {
-			return nil, fmt.Errorf("user with email %s already exists", req.Email)
+			return nil, &models.DuplicateEmailError{Email: req.Email}
 		}
 	}
 
 	// Update user
-	user, err := s.userRepo.Update(id, req)
+	var user *models.User
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.userRepo.Update(ctx, id, req); err != nil {
+			return err
+		}
+		return s.stage(ctx, models.WebhookUserUpdated, user)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		if isConstraintError(err) {
+			return nil, err
+		}
+		return nil, apperrors.Internal("failed to update user", err)
 	}
 
+	s.publish(ctx, models.WebhookUserUpdated, user)
 	return user, nil
 }
 
-// DeleteUser deletes a user
-func (s *UserService) DeleteUser(id int) error {
+// UpsertUser creates the user with email or, if it exists, replaces its name
+// and age, reporting whether the user was created. Concurrent upserts of one
+// email create it once and update it after.
+func (s *UserService) UpsertUser(ctx context.Context, email string, req *models.UpsertUserRequest) (*models.User, bool, error) {
+	create := &models.CreateUserRequest{Name: req.Name, Email: email, Age: req.Age}
+	if err := s.validateCreateUserRequest(create); err != nil {
+		return nil, false, err
+	}
+
+	var user *models.User
+	var created bool
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if user, created, err = s.userRepo.Upsert(ctx, create); err != nil {
+			return err
+		}
+		if created {
+			return s.stage(ctx, models.WebhookUserCreated, user)
+		}
+		return s.stage(ctx, models.WebhookUserUpdated, user)
+	})
+	if err != nil {
+		if isConstraintError(err) {
+			return nil, false, err
+		}
+		return nil, false, apperrors.Internal("failed to upsert user", err)
+	}
+
+	if created {
+		s.publish(ctx, models.WebhookUserCreated, user)
+	} else {
+		s.publish(ctx, models.WebhookUserUpdated, user)
+	}
+	return user, created, nil
+}
+
+// PatchUser applies a partial update where only the fields present in the
+// request are changed
+func (s *UserService) PatchUser(ctx context.Context, id int, req *models.PatchUserRequest) (*models.User, error) {
 	if id <= 0 {
-		return fmt.Errorf("invalid user ID")
+		return nil, apperrors.Validation("invalid user ID")
 	}
 
-	err := s.userRepo.Delete(id)
+	if err := s.validatePatchUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		existingUser, _ := s.userRepo.GetByEmail(ctx, *req.Email)
+		if existingUser != nil && existingUser.ID != id {
+			return nil, &models.DuplicateEmailError{Email: *req.Email}
+		}
+	}
+
+	var user *models.User
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.userRepo.Patch(ctx, id, req); err != nil {
+			return err
+		}
+		return s.stage(ctx, models.WebhookUserUpdated, user)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		if errors.Is(err, apperrors.ErrNotFound) || isConstraintError(err) {
+			return nil, err
+		}
+		return nil, apperrors.Internal("failed to patch user", err)
 	}
 
-	return nil
+	s.publish(ctx, models.WebhookUserUpdated, user)
+	return user, nil
 }
 
-// validateCreateUserRequest validates create user request
-func (s *UserService) validateCreateUserRequest(req *models.CreateUserRequest) error {
-	if strings.TrimSpace(req.Name) == "" {
-		return fmt.Errorf("name is required")
+// AnonymizeUser replaces a user's personal data with placeholders while
+// keeping the record so references to it stay valid
+func (s *UserService) AnonymizeUser(ctx context.Context, id int) (*models.User, error) {
+	if id <= 0 {
+		return nil, apperrors.Validation("invalid user ID")
 	}
 
-	if len(req.Name) < 2 || len(req.Name) > 100 {
-		return fmt.Errorf("name must be between 2 and 100 characters")
+	var user *models.User
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if current.LegalHold {
+			return models.ErrLegalHold
+		}
+
+		user, err = s.userRepo.Update(ctx, id, &models.UpdateUserRequest{
+			Name:  "Anonymized User",
+			Email: fmt.Sprintf("anonymized-%d@example.invalid", id),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to anonymize user: %w", err)
+		}
+		if err := s.stage(ctx, models.WebhookUserUpdated, user); err != nil {
+			return err
+		}
+
+		return s.recordChange(ctx, "anonymize user", s.ids.Encode(id))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.TrimSpace(req.Email) == "" {
-		return fmt.Errorf("email is required")
+	s.publish(ctx, models.WebhookUserUpdated, user)
+	return user, nil
+}
+
+// SetLegalHold places or lifts the legal hold that blocks deleting and
+// anonymizing a user
+func (s *UserService) SetLegalHold(ctx context.Context, id int, hold bool) (*models.User, error) {
+	if id <= 0 {
+		return nil, apperrors.Validation("invalid user ID")
 	}
 
-	if !isValidEmail(req.Email) {
-		return fmt.Errorf("invalid email format")
+	var user *models.User
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.userRepo.SetLegalHold(ctx, id, hold); err != nil {
+			return err
+		}
+		return s.stage(ctx, models.WebhookUserUpdated, user)
+	})
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to set legal hold: %w", err)
 	}
 
-	if req.Age <= 0 || req.Age > 150 {
-		return fmt.Errorf("age must be between 1 and 150")
+	s.publish(ctx, models.WebhookUserUpdated, user)
+	return user, nil
+}
+
+// RestoreUser undoes the soft delete of a user
+func (s *UserService) RestoreUser(ctx context.Context, id int) (*models.User, error) {
+	if id <= 0 {
+		return nil, apperrors.Validation("invalid user ID")
+	}
+	var user *models.User
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.userRepo.Restore(ctx, id); err != nil {
+			return err
+		}
+		return s.stage(ctx, models.WebhookUserUpdated, user)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	s.publish(ctx, models.WebhookUserUpdated, user)
+	return user, nil
 }
 
-// validateUpdateUserRequest validates update user request
-func (s *UserService) validateUpdateUserRequest(req *models.UpdateUserRequest) error {
-	if req.Name != "" {
-		if len(req.Name) < 2 || len(req.Name) > 100 {
-			return fmt.Errorf("name must be between 2 and 100 characters")
+// PurgeDeleted permanently removes users soft-deleted longer than retention
+func (s *UserService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.userRepo.Purge(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+	return purged, nil
+}
+
+// RunPurge purges users soft-deleted longer than retention every interval
+// until ctx is cancelled
+func (s *UserService) RunPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDeleted(ctx, retention)
+			if err != nil {
+				logger.FromContext(ctx).Error("purge of deleted users failed", zap.Error(err))
+			} else if purged > 0 {
+				logger.FromContext(ctx).Info("purged deleted users", zap.Int64("count", purged))
+			}
 		}
 	}
+}
 
-	if req.Email != "" {
-		if !isValidEmail(req.Email) {
-			return fmt.Errorf("invalid email format")
+// DeleteUser soft-deletes a user
+func (s *UserService) DeleteUser(ctx context.Context, id int) error {
+	if id <= 0 {
+		return apperrors.Validation("invalid user ID")
+	}
+
+	data := map[string]idcodec.PublicID{"id": idcodec.Public(s.ids, id)}
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, id); err != nil {
+			return err
 		}
+		return s.stageData(ctx, models.WebhookUserDeleted, id, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
-	if req.Age != 0 {
-		if req.Age <= 0 || req.Age > 150 {
-			return fmt.Errorf("age must be between 1 and 150")
+	s.publishData(ctx, models.WebhookUserDeleted, id, data)
+	return nil
+}
+
+// validateUserFilter checks sort fields against the repository whitelist and
+// that the age range is not inverted
+func validateUserFilter(filter *models.UserFilter) error {
+	if filter == nil {
+		return nil
+	}
+
+	var fieldErrs []models.FieldError
+	for _, sort := range filter.Sort {
+		if !repository.IsSortableUserField(sort.Field) {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   "sort",
+				Rule:    "oneof",
+				Message: fmt.Sprintf("cannot sort by %s", sort.Field),
+			})
 		}
 	}
+	if filter.MinAge > 0 && filter.MaxAge > 0 && filter.MinAge > filter.MaxAge {
+		fieldErrs = append(fieldErrs, models.FieldError{
+			Field:   "min_age",
+			Rule:    "ltefield",
+			Message: "min_age must not exceed max_age",
+		})
+	}
+
+	if len(fieldErrs) > 0 {
+		return &ValidationError{Errors: fieldErrs}
+	}
+	return nil
+}
 
+// validateUnsortedFilter validates filter for reads that are always newest
+// first, rejecting a sort order; mode names the read in the error
+func validateUnsortedFilter(filter *models.UserFilter, mode string) error {
+	if err := validateUserFilter(filter); err != nil {
+		return err
+	}
+	if filter != nil && len(filter.Sort) > 0 {
+		return &ValidationError{Errors: []models.FieldError{{
+			Field:   "sort",
+			Rule:    "excluded_with",
+			Message: fmt.Sprintf("sort cannot be combined with %s", mode),
+		}}}
+	}
 	return nil
 }
 
-// isValidEmail validates email format (basic validation)
-func isValidEmail(email string) bool {
-	// Basic email validation
-	return strings.Contains(email, "@") && strings.Contains(email, ".")
-}
\ No newline at end of file
+// encodeUserCursor returns the opaque cursor pointing just past user
+func encodeUserCursor(user *models.User) string {
+	data, _ := json.Marshal(models.UserCursor{CreatedAt: user.CreatedAt, ID: user.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor parses a cursor produced by encodeUserCursor
+func decodeUserCursor(cursor string) (*models.UserCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var position models.UserCursor
+	if err := json.Unmarshal(data, &position); err != nil || position.ID <= 0 || position.CreatedAt.IsZero() {
+		return nil, errInvalidCursor
+	}
+	return &position, nil
+}
+
+// validateCreateUserRequest validates create user request
+func (s *UserService) validateCreateUserRequest(req *models.CreateUserRequest) error {
+	return validateStruct(req)
+}
+
+// validateUpdateUserRequest validates update user request
+func (s *UserService) validateUpdateUserRequest(req *models.UpdateUserRequest) error {
+	return validateStruct(req)
+}
+
+// validatePatchUserRequest validates the fields present in a patch request
+func (s *UserService) validatePatchUserRequest(req *models.PatchUserRequest) error {
+	if req.Name == nil && req.Email == nil && req.Age == nil {
+		return apperrors.Validation("at least one of name, email or age is required")
+	}
+	return validateStruct(req)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+)
+
+// validate checks request structs against their `validate:` tags
+var validate = newValidator()
+
+// ValidationError reports every field of a request that broke a rule
+type ValidationError struct {
+	Errors []models.FieldError
+}
+
+// Error joins the per-field messages
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is reports whether target is apperrors.ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == apperrors.ErrValidation
+}
+
+// ValidationErrors returns the field errors carried by err, if any
+func ValidationErrors(err error) []models.FieldError {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Errors
+	}
+	if errors.Is(err, models.ErrInvalidAge) {
+		return []models.FieldError{{Field: "age", Rule: "range", Message: err.Error()}}
+	}
+	return nil
+}
+
+// isConstraintError reports whether err is a constraint violation or
+// version conflict the repository mapped to an API error, which services
+// return unwrapped
+func isConstraintError(err error) bool {
+	return errors.Is(err, models.ErrDuplicateEmail) || errors.Is(err, models.ErrInvalidAge) ||
+		errors.Is(err, models.ErrVersionConflict)
+}
+
+// newValidator creates a validator that reports fields by their JSON names
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// Public IDs are checked as the text the client sent
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(idcodec.PublicID).String()
+	}, idcodec.PublicID{})
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	return v
+}
+
+// validateStruct validates req and converts failures into a ValidationError
+func validateStruct(req interface{}) error {
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return fmt.Errorf("failed to validate request: %w", err)
+	}
+
+	result := &ValidationError{Errors: make([]models.FieldError, len(fieldErrs))}
+	for i, fieldErr := range fieldErrs {
+		result.Errors[i] = models.FieldError{
+			Field:   fieldErr.Field(),
+			Rule:    fieldErr.Tag(),
+			Message: validationMessage(fieldErr),
+		}
+	}
+	return result
+}
+
+// validationMessage describes a failed rule in plain words
+func validationMessage(fieldErr validator.FieldError) string {
+	field := fieldErr.Field()
+	unit := ""
+	if fieldErr.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fieldErr.Tag() {
+	case "required", "notblank":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", field, fieldErr.Param(), unit)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", field, fieldErr.Param(), unit)
+	default:
+		return fmt.Sprintf("%s failed the %s rule", field, fieldErr.Tag())
+	}
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/events"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// watchStreamBuffer is how many notifications an event stream may fall
+// behind before further ones are dropped
+const watchStreamBuffer = 16
+
+// watchFieldDefaults are the values of WatchFields a user response leaves
+// out when they are zero
+var watchFieldDefaults = map[string]interface{}{"legal_hold": false}
+
+// WatchService manages watches on users and notifies their owners when the
+// fields they follow change. It evaluates user events from the event bus on
+// its own goroutine; events live in memory only, so those not yet evaluated
+// are lost on shutdown.
+type WatchService struct {
+	watchRepo repository.WatchRepository
+	userRepo  repository.UserRepository
+	webhooks  *WebhookService
+	ids       idcodec.Codec
+	cfg       config.WatchConfig
+
+	events chan events.Event
+
+	mu      sync.Mutex
+	streams map[int]map[*watchStream]struct{}
+	closing bool
+}
+
+// watchStream is an event stream subscribed to an owner's watches
+type watchStream struct {
+	notifications chan *models.WatchNotification
+	// done is closed once the stream is no longer read
+	done chan struct{}
+	// cut interrupts the stream's connection; nil if it cannot be
+	cut func()
+}
+
+// NewWatchService creates a new watch service for users identified by the
+// IDs codec encodes
+func NewWatchService(watchRepo repository.WatchRepository, userRepo repository.UserRepository, ids idcodec.Codec, cfg config.WatchConfig) *WatchService {
+	return &WatchService{
+		watchRepo: watchRepo,
+		userRepo:  userRepo,
+		ids:       ids,
+		cfg:       cfg,
+		events:    make(chan events.Event, cfg.QueueSize),
+		streams:   make(map[int]map[*watchStream]struct{}),
+	}
+}
+
+// SetWebhooks delivers the notifications of watches with a URL through
+// webhooks. Without it, watches can only be followed over event streams.
+func (s *WatchService) SetWebhooks(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
+
+// CreateWatch creates a watch owned by ownerID. Only admins may watch users
+// other than themselves, or have notifications posted to a URL: the server
+// makes those requests, so like webhook subscriptions they are admin-only.
+func (s *WatchService) CreateWatch(ctx context.Context, ownerID int, admin bool, req *models.WatchRequest) (*models.Watch, error) {
+	if err := validateStruct(req); err != nil {
+		return nil, err
+	}
+	if req.URL != "" {
+		if s.webhooks == nil {
+			return nil, apperrors.Validation("url needs webhooks to be enabled; follow the watch's event stream instead")
+		}
+		if !admin {
+			return nil, apperrors.Forbidden("only admins may set a url; follow the watch's event stream instead")
+		}
+		parsed, err := url.Parse(req.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, apperrors.Validation("url must be an http or https URL")
+		}
+	}
+	ttl := s.cfg.DefaultTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if s.cfg.MaxTTL > 0 && ttl > s.cfg.MaxTTL {
+		return nil, apperrors.Validation(fmt.Sprintf("ttl_seconds must be at most %d", int(s.cfg.MaxTTL/time.Second)))
+	}
+	userID, err := s.ids.Decode(req.UserID.String())
+	if err != nil {
+		return nil, apperrors.Validation("user_id is not a user ID")
+	}
+	if userID != ownerID && !admin {
+		return nil, apperrors.Forbidden("only admins may watch other users")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := watchValues(user.ToResponse(s.ids))
+	if err != nil {
+		return nil, apperrors.Internal("failed to create watch", err)
+	}
+
+	watch := &models.Watch{
+		OwnerID:   ownerID,
+		UserID:    userID,
+		Fields:    req.Fields,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Snapshot:  snapshot,
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}
+	if err := s.watchRepo.Create(ctx, watch); err != nil {
+		return nil, apperrors.Internal("failed to create watch", err)
+	}
+	return watch, nil
+}
+
+// GetWatch retrieves a watch of ownerID
+func (s *WatchService) GetWatch(ctx context.Context, ownerID int, id int64) (*models.Watch, error) {
+	watch, err := s.watchRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Other owners' watches are not acknowledged to exist
+	if watch.OwnerID != ownerID {
+		return nil, apperrors.NotFound("watch not found")
+	}
+	return watch, nil
+}
+
+// ListWatches retrieves the watches of ownerID
+func (s *WatchService) ListWatches(ctx context.Context, ownerID int) ([]*models.Watch, error) {
+	watches, err := s.watchRepo.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list watches", err)
+	}
+	return watches, nil
+}
+
+// DeleteWatch removes a watch of ownerID
+func (s *WatchService) DeleteWatch(ctx context.Context, ownerID int, id int64) error {
+	if _, err := s.GetWatch(ctx, ownerID, id); err != nil {
+		return err
+	}
+	return s.watchRepo.Delete(ctx, id)
+}
+
+// HandleEvent queues a user event for evaluation; subscribe it to the event
+// bus. It never blocks: when the queue is full the event is dropped.
+func (s *WatchService) HandleEvent(ctx context.Context, event events.Event) {
+	select {
+	case s.events <- event:
+	default:
+		logger.FromContext(ctx).Warn("watch queue full, dropping event",
+			zap.String("event", event.Type), zap.String("event_id", event.ID))
+	}
+}
+
+// Run evaluates queued events and sweeps expired watches until ctx is
+// cancelled
+func (s *WatchService) Run(ctx context.Context) {
+	sweepInterval := s.cfg.SweepInterval
+	if sweepInterval <= 0 {
+		sweepInterval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.events:
+			if err := s.Evaluate(ctx, event); err != nil {
+				logger.FromContext(ctx).Error("failed to evaluate watches",
+					zap.String("event_id", event.ID), zap.Error(err))
+			}
+		case <-ticker.C:
+			swept, err := s.watchRepo.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				logger.FromContext(ctx).Error("failed to delete expired watches", zap.Error(err))
+			} else if swept > 0 {
+				logger.FromContext(ctx).Info("deleted expired watches", zap.Int64("count", swept))
+			}
+		}
+	}
+}
+
+// Evaluate notifies the watches on the subject of a user event. Updates
+// only notify watches following a field they changed, and move those
+// watches' snapshots forward; deletions notify every watch.
+func (s *WatchService) Evaluate(ctx context.Context, event events.Event) error {
+	if event.Type != models.WebhookUserUpdated && event.Type != models.WebhookUserDeleted {
+		return nil
+	}
+	userID, err := s.ids.Decode(event.Subject)
+	if err != nil {
+		return fmt.Errorf("invalid event subject %q: %w", event.Subject, err)
+	}
+	watches, err := s.watchRepo.ListForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(watches) == 0 {
+		return nil
+	}
+
+	var values map[string]interface{}
+	if event.Type == models.WebhookUserUpdated {
+		if values, err = watchValues(event.Data); err != nil {
+			return err
+		}
+	}
+	for _, watch := range watches {
+		notification := &models.WatchNotification{
+			WatchID: watch.ID,
+			UserID:  idcodec.Public(s.ids, watch.UserID),
+			Event:   event.Type,
+			EventID: event.ID,
+			Time:    event.Time,
+		}
+		if values != nil {
+			notification.Changes = watchChanges(watch, values)
+			if len(notification.Changes) == 0 {
+				continue
+			}
+			if err := s.watchRepo.UpdateSnapshot(ctx, watch.ID, values); err != nil {
+				return err
+			}
+		}
+		s.notify(ctx, watch, notification)
+	}
+	return nil
+}
+
+// notify sends a notification to the watch's URL, if it has one, and to
+// its owner's event streams
+func (s *WatchService) notify(ctx context.Context, watch *models.Watch, notification *models.WatchNotification) {
+	if watch.URL != "" && s.webhooks != nil {
+		target := &models.Webhook{ID: watch.ID, URL: watch.URL, Secret: watch.Secret}
+		s.webhooks.Send(ctx, target, models.WatchTriggered, notification)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for stream := range s.streams[watch.OwnerID] {
+		select {
+		case stream.notifications <- notification:
+		default:
+			logger.FromContext(ctx).Warn("watch stream behind, dropping notification",
+				zap.Int64("watch_id", watch.ID), zap.String("event_id", notification.EventID))
+		}
+	}
+}
+
+// Subscribe returns the notifications of ownerID's watches as they happen,
+// and a function to call once they are no longer read. The channel is
+// closed at shutdown, when the stream should tell its client to reconnect;
+// cut, if not nil, interrupts a stream that has not finished in time.
+func (s *WatchService) Subscribe(ownerID int, cut func()) (<-chan *models.WatchNotification, func()) {
+	stream := &watchStream{
+		notifications: make(chan *models.WatchNotification, watchStreamBuffer),
+		done:          make(chan struct{}),
+		cut:           cut,
+	}
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		close(stream.notifications)
+		return stream.notifications, func() {}
+	}
+	if s.streams[ownerID] == nil {
+		s.streams[ownerID] = make(map[*watchStream]struct{})
+	}
+	s.streams[ownerID][stream] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return stream.notifications, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.streams[ownerID], stream)
+			if len(s.streams[ownerID]) == 0 {
+				delete(s.streams, ownerID)
+			}
+			s.mu.Unlock()
+			close(stream.done)
+		})
+	}
+}
+
+// Shutdown closes every event stream, so each sends its client a final
+// reconnect event. Streams still open when ctx is done are cut. It returns
+// how many streams were notified and how many had to be cut.
+func (s *WatchService) Shutdown(ctx context.Context) (notified, forced int) {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return 0, 0
+	}
+	s.closing = true
+	var streams []*watchStream
+	for _, owned := range s.streams {
+		for stream := range owned {
+			close(stream.notifications)
+			streams = append(streams, stream)
+		}
+	}
+	s.streams = make(map[int]map[*watchStream]struct{})
+	s.mu.Unlock()
+
+	for _, stream := range streams {
+		select {
+		case <-stream.done:
+		case <-ctx.Done():
+			select {
+			case <-stream.done:
+			default:
+				if stream.cut != nil {
+					stream.cut()
+				}
+				forced++
+			}
+		}
+	}
+	return len(streams), forced
+}
+
+// StreamHeartbeat is how often idle event streams are sent a comment
+func (s *WatchService) StreamHeartbeat() time.Duration {
+	return s.cfg.StreamHeartbeat
+}
+
+// watchValues returns the values of WatchFields in a user as the API
+// returns it, decoded from JSON so they compare with stored snapshots
+func watchValues(user interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user: %w", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode user: %w", err)
+	}
+
+	values := make(map[string]interface{}, len(models.WatchFields))
+	for _, field := range models.WatchFields {
+		value, ok := decoded[field]
+		if !ok {
+			value = watchFieldDefaults[field]
+		}
+		values[field] = value
+	}
+	return values, nil
+}
+
+// watchChanges returns the fields followed by watch whose values differ
+// from its snapshot
+func watchChanges(watch *models.Watch, values map[string]interface{}) map[string]models.WatchChange {
+	fields := watch.Fields
+	if len(fields) == 0 {
+		fields = models.WatchFields
+	}
+
+	changes := make(map[string]models.WatchChange)
+	for _, field := range fields {
+		old, current := watch.Snapshot[field], values[field]
+		if !reflect.DeepEqual(old, current) {
+			changes[field] = models.WatchChange{Old: old, New: current}
+		}
+	}
+	return changes
+}
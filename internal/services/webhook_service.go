@@ -0,0 +1,382 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// webhookEvent is a published event waiting to be fanned out to subscribers
+type webhookEvent struct {
+	id      string
+	typ     string
+	payload []byte
+}
+
+// webhookDelivery is one event on its way to one subscriber
+type webhookDelivery struct {
+	webhook  *models.Webhook
+	event    webhookEvent
+	attempts int
+	// subscribed deliveries go to a stored webhook, so when they run out of
+	// attempts they are recorded for redelivery
+	subscribed bool
+}
+
+// webhookRedeliverBatch is how many failed deliveries Redeliver loads at a
+// time
+const webhookRedeliverBatch = 100
+
+// WebhookService manages webhook subscriptions and delivers user lifecycle
+// events to them from a pool of workers. Events live in memory only, so
+// those not yet delivered are lost on shutdown. Deliveries to subscriptions
+// that run out of attempts are stored until redelivered.
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	cfg         config.WebhookConfig
+
+	events     chan webhookEvent
+	deliveries chan *webhookDelivery
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(webhookRepo repository.WebhookRepository, cfg config.WebhookConfig) *WebhookService {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// A redirect could point deliveries anywhere; it counts as a failure
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg:        cfg,
+		events:     make(chan webhookEvent, cfg.QueueSize),
+		deliveries: make(chan *webhookDelivery, cfg.QueueSize),
+	}
+}
+
+// CreateWebhook subscribes a URL to events
+func (s *WebhookService) CreateWebhook(ctx context.Context, req *models.WebhookRequest) (*models.Webhook, error) {
+	if err := validateWebhookRequest(req); err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{URL: req.URL, Secret: req.Secret, Events: req.Events}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, apperrors.Internal("failed to create webhook", err)
+	}
+	return webhook, nil
+}
+
+// GetWebhook retrieves a webhook
+func (s *WebhookService) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	return s.webhookRepo.GetByID(ctx, id)
+}
+
+// ListWebhooks retrieves every webhook
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	webhooks, err := s.webhookRepo.List(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list webhooks", err)
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook replaces the URL, secret and events of a webhook
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id int64, req *models.WebhookRequest) (*models.Webhook, error) {
+	if err := validateWebhookRequest(req); err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{ID: id, URL: req.URL, Secret: req.Secret, Events: req.Events}
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook; deliveries already queued still go out
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+// validateWebhookRequest checks a request and that its URL is http or https
+func validateWebhookRequest(req *models.WebhookRequest) error {
+	if err := validateStruct(req); err != nil {
+		return err
+	}
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return apperrors.Validation("url must be an http or https URL")
+	}
+	return nil
+}
+
+// Publish queues event for the webhooks subscribed to it, with data as its
+// payload. It never blocks: when the queue is full the event is dropped.
+func (s *WebhookService) Publish(ctx context.Context, event string, data interface{}) {
+	queued, ok := newWebhookEvent(ctx, event, data)
+	if !ok {
+		return
+	}
+
+	select {
+	case s.events <- queued:
+	default:
+		logger.FromContext(ctx).Warn("webhook queue full, dropping event",
+			zap.String("event", event), zap.String("event_id", queued.id))
+	}
+}
+
+// Send queues one delivery of event to target alone, whatever it subscribes
+// to, such as a watch's notification. Like Publish, it drops the delivery
+// when the queue is full.
+func (s *WebhookService) Send(ctx context.Context, target *models.Webhook, event string, data interface{}) {
+	queued, ok := newWebhookEvent(ctx, event, data)
+	if !ok {
+		return
+	}
+
+	select {
+	case s.deliveries <- &webhookDelivery{webhook: target, event: queued}:
+	default:
+		logger.FromContext(ctx).Warn("webhook queue full, dropping delivery",
+			zap.String("event", event), zap.String("event_id", queued.id))
+	}
+}
+
+// newWebhookEvent encodes the payload of event, logging failures
+func newWebhookEvent(ctx context.Context, event string, data interface{}) (webhookEvent, bool) {
+	payload := models.WebhookPayload{
+		ID:        "evt_" + randomToken(16, hex.EncodeToString),
+		Type:      event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to encode webhook event", zap.String("event", event), zap.Error(err))
+		return webhookEvent{}, false
+	}
+	return webhookEvent{id: payload.ID, typ: event, payload: body}, true
+}
+
+// Run delivers published events until ctx is cancelled
+func (s *WebhookService) Run(ctx context.Context) {
+	var workers sync.WaitGroup
+	for i := 0; i < s.cfg.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.deliverQueued(ctx)
+		}()
+	}
+
+	s.fanOut(ctx)
+	workers.Wait()
+}
+
+// fanOut queues a delivery of each published event to every webhook
+// subscribed to it
+func (s *WebhookService) fanOut(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.events:
+			webhooks, err := s.webhookRepo.ListForEvent(ctx, event.typ)
+			if err != nil {
+				logger.FromContext(ctx).Error("failed to find webhooks, dropping event",
+					zap.String("event_id", event.id), zap.Error(err))
+				continue
+			}
+			for _, webhook := range webhooks {
+				s.enqueue(ctx, &webhookDelivery{webhook: webhook, event: event, subscribed: true})
+			}
+		}
+	}
+}
+
+// deliverQueued makes queued deliveries until ctx is cancelled
+func (s *WebhookService) deliverQueued(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-s.deliveries:
+			s.attempt(ctx, delivery)
+		}
+	}
+}
+
+// enqueue waits for room in the delivery queue
+func (s *WebhookService) enqueue(ctx context.Context, delivery *webhookDelivery) {
+	select {
+	case <-ctx.Done():
+	case s.deliveries <- delivery:
+	}
+}
+
+// attempt makes one delivery attempt. Failed attempts are retried with
+// exponential backoff until they run out of attempts.
+func (s *WebhookService) attempt(ctx context.Context, delivery *webhookDelivery) {
+	delivery.attempts++
+	err := s.send(ctx, delivery)
+	if err == nil {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.Int64("webhook_id", delivery.webhook.ID),
+		zap.String("event_id", delivery.event.id),
+		zap.Int("attempts", delivery.attempts),
+		zap.Error(err),
+	}
+	if delivery.attempts >= s.cfg.MaxAttempts {
+		logger.FromContext(ctx).Warn("webhook delivery failed, giving up", fields...)
+		if delivery.subscribed {
+			s.recordFailure(ctx, delivery, err)
+		}
+		return
+	}
+	logger.FromContext(ctx).Info("webhook delivery failed, retrying", fields...)
+	time.AfterFunc(s.retryDelay(delivery.attempts), func() {
+		s.enqueue(ctx, delivery)
+	})
+}
+
+// recordFailure stores a delivery that ran out of attempts, logging
+// failures
+func (s *WebhookService) recordFailure(ctx context.Context, delivery *webhookDelivery, deliveryErr error) {
+	err := s.webhookRepo.RecordFailure(ctx, &models.WebhookFailure{
+		WebhookID: delivery.webhook.ID,
+		EventID:   delivery.event.id,
+		Event:     delivery.event.typ,
+		Payload:   delivery.event.payload,
+		Attempts:  delivery.attempts,
+		LastError: deliveryErr.Error(),
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to record webhook failure",
+			zap.Int64("webhook_id", delivery.webhook.ID), zap.String("event_id", delivery.event.id), zap.Error(err))
+	}
+}
+
+// Redeliver queues every delivery that ran out of attempts again, with the
+// event ID and payload it first had, and returns how many it queued. A
+// delivery failing again is recorded again.
+func (s *WebhookService) Redeliver(ctx context.Context) (int, error) {
+	webhooks := make(map[int64]*models.Webhook)
+	queued := 0
+	var afterID int64
+	for {
+		failures, err := s.webhookRepo.ListFailures(ctx, afterID, webhookRedeliverBatch)
+		if err != nil {
+			return queued, apperrors.Internal("failed to list failed webhook deliveries", err)
+		}
+
+		for _, failure := range failures {
+			afterID = failure.ID
+			webhook, ok := webhooks[failure.WebhookID]
+			if !ok {
+				if webhook, err = s.webhookRepo.GetByID(ctx, failure.WebhookID); err != nil {
+					return queued, apperrors.Internal("failed to load webhook", err)
+				}
+				webhooks[failure.WebhookID] = webhook
+			}
+
+			select {
+			case <-ctx.Done():
+				return queued, ctx.Err()
+			case s.deliveries <- &webhookDelivery{
+				webhook:    webhook,
+				event:      webhookEvent{id: failure.EventID, typ: failure.Event, payload: failure.Payload},
+				subscribed: true,
+			}:
+			}
+			queued++
+
+			if err := s.webhookRepo.DeleteFailure(ctx, failure.ID); err != nil {
+				return queued, apperrors.Internal("failed to delete failed webhook delivery", err)
+			}
+		}
+
+		if len(failures) < webhookRedeliverBatch {
+			return queued, nil
+		}
+	}
+}
+
+// send posts the event to the webhook, failing unless it answers 2xx
+func (s *WebhookService) send(ctx context.Context, delivery *webhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.webhook.URL, bytes.NewReader(delivery.event.payload))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-crud-webhooks")
+	req.Header.Set("X-Webhook-ID", delivery.event.id)
+	req.Header.Set("X-Webhook-Event", delivery.event.typ)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhook(delivery.webhook.Secret, timestamp, delivery.event.payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// retryDelay returns the wait after the given failed attempt, doubling from
+// RetryBackoff
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.cfg.RetryBackoff
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+// SignWebhook returns the X-Webhook-Signature of a delivery: "sha256="
+// followed by the hex HMAC-SHA256, under secret, of the timestamp, a dot and
+// the body. Signing the timestamp lets consumers reject replayed deliveries.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"go.uber.org/zap"
+)
+
+// PrimaryHealthChecker reports whether the primary database accepts writes
+type PrimaryHealthChecker interface {
+	PrimaryHealthy() bool
+}
+
+// WriteAheadService accepts user creations while the primary database is
+// down and applies them in order once it recovers
+type WriteAheadService struct {
+	queue       repository.WriteAheadRepository
+	userService *UserService
+	// jobs applies queued writes; it reads and writes through the
+	// background job pool rather than the one serving requests
+	jobs     *UserService
+	primary  PrimaryHealthChecker
+	interval time.Duration
+}
+
+// NewWriteAheadService creates a new write-ahead service. userService serves
+// requests and jobs applies queued writes in the background.
+func NewWriteAheadService(queue repository.WriteAheadRepository, userService, jobs *UserService, primary PrimaryHealthChecker, interval time.Duration) *WriteAheadService {
+	return &WriteAheadService{
+		queue:       queue,
+		userService: userService,
+		jobs:        jobs,
+		primary:     primary,
+		interval:    interval,
+	}
+}
+
+// CreateUser creates the user, or queues the request and returns it when
+// the primary database is unavailable. Exactly one of the results is set.
+func (s *WriteAheadService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, *models.QueuedWrite, error) {
+	// Validate up front so clients still get immediate 400s during an outage
+	if err := s.userService.validateCreateUserRequest(req); err != nil {
+		return nil, nil, err
+	}
+
+	if s.primary.PrimaryHealthy() {
+		user, err := s.userService.CreateUser(ctx, req)
+		if err == nil || !IsConnectionError(err) {
+			return user, nil, err
+		}
+		logger.FromContext(ctx).Warn("queuing user creation after database error", zap.Error(err))
+	}
+
+	queued, err := s.enqueue(ctx, req)
+	return nil, queued, err
+}
+
+// GetQueuedWrite returns the state of a queued write
+func (s *WriteAheadService) GetQueuedWrite(ctx context.Context, id string) (*models.QueuedWrite, error) {
+	return s.queue.Get(ctx, id)
+}
+
+// Run applies pending writes every interval until ctx is cancelled
+func (s *WriteAheadService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.primary.PrimaryHealthy() {
+				if err := s.Reconcile(ctx); err != nil {
+					logger.FromContext(ctx).Error("write-ahead reconciliation stopped", zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// Reconcile applies pending writes oldest first. It stops at the first
+// connection error so the remaining writes keep their order.
+func (s *WriteAheadService) Reconcile(ctx context.Context) error {
+	pending, err := s.queue.ListPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, write := range pending {
+		write.Attempts++
+		write.UpdatedAt = time.Now()
+
+		user, err := s.jobs.CreateUser(ctx, write.Request)
+		switch {
+		case err == nil:
+			write.Status = models.QueuedWriteApplied
+			write.UserID = &user.ID
+		case IsConnectionError(err):
+			s.queue.Save(ctx, write)
+			return err
+		default:
+			write.Status = models.QueuedWriteFailed
+			write.Error = err.Error()
+		}
+
+		if err := s.queue.Save(ctx, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueue durably stores req as a pending write
+func (s *WriteAheadService) enqueue(ctx context.Context, req *models.CreateUserRequest) (*models.QueuedWrite, error) {
+	now := time.Now()
+	write := &models.QueuedWrite{
+		ID:        randomToken(16, hex.EncodeToString),
+		Status:    models.QueuedWritePending,
+		Request:   req,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.queue.Save(ctx, write); err != nil {
+		return nil, fmt.Errorf("failed to queue user creation: %w", err)
+	}
+	return write, nil
+}
+
+// IsConnectionError reports whether err was caused by the database being unreachable
+func IsConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,110 @@
+// Package signing verifies requests signed with the private key registered
+// for the personal access token that authenticates them, so audit entries can
+// prove who issued a change
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"strings"
+)
+
+// Headers carrying a request's signature
+const (
+	// HeaderSignature holds the base64 signature of the request's payload
+	HeaderSignature = "X-Signature"
+	// HeaderTimestamp holds the Unix time in seconds the request was signed
+	HeaderTimestamp = "X-Signature-Timestamp"
+)
+
+// ErrInvalidSignature is returned for signatures that do not verify
+var ErrInvalidSignature = errors.New("signature does not match the request")
+
+// Signature is a verified request signature. With the public key, anyone
+// can check that the holder of the private key signed Payload.
+type Signature struct {
+	// Payload is the exact text that was signed
+	Payload string
+	// Value is the signature, base64 encoded
+	Value string
+	// PublicKey is the PEM encoded key Value verified against
+	PublicKey string
+}
+
+// ParsePublicKey parses a PEM encoded PKIX public key. Only Ed25519 and
+// ECDSA P-256 keys are accepted.
+func ParsePublicKey(publicKeyPEM string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("signing key must be a PEM encoded PUBLIC KEY")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("signing key is not a valid public key")
+	}
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return k, nil
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return k, nil
+		}
+	}
+	return nil, errors.New("signing key must be Ed25519 or ECDSA P-256")
+}
+
+// Payload returns the text a client signs for a request: the method, the
+// request URI, the timestamp and the hex SHA-256 of the body, one per line
+func Payload(method, requestURI, timestamp string, body []byte) string {
+	digest := sha256.Sum256(body)
+	return strings.Join([]string{method, requestURI, timestamp, hex.EncodeToString(digest[:])}, "\n")
+}
+
+// Verify checks that signature, base64 encoded, is publicKeyPEM's signature
+// of payload. Ed25519 signs payload directly; ECDSA signs its SHA-256 and
+// the signature is ASN.1 DER encoded.
+func Verify(publicKeyPEM, payload, signature string) error {
+	key, err := ParsePublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.New("signature must be base64 encoded")
+	}
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		if ed25519.Verify(k, []byte(payload), sig) {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256([]byte(payload))
+		if ecdsa.VerifyASN1(k, digest[:], sig) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying a verified signature
+func NewContext(ctx context.Context, sig Signature) context.Context {
+	return context.WithValue(ctx, contextKey{}, sig)
+}
+
+// FromContext returns the verified signature of the request, if it was signed
+func FromContext(ctx context.Context) (Signature, bool) {
+	sig, ok := ctx.Value(contextKey{}).(Signature)
+	return sig, ok
+}
@@ -0,0 +1,199 @@
+// Package startup describes how an instance was started, so operators and
+// orchestration tooling can check the configuration it actually runs with.
+package startup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/buildinfo"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/pratham15541/go-crud/internal/health"
+)
+
+// Report summarizes the runtime configuration of an instance. It never
+// includes secrets such as passwords or keys.
+type Report struct {
+	StartedAt time.Time  `json:"started_at"`
+	PID       int        `json:"pid"`
+	Version   string     `json:"version"`
+	Commit    string     `json:"commit,omitempty"`
+	GoVersion string     `json:"go_version"`
+	Region    string     `json:"region,omitempty"`
+	Listeners []Listener `json:"listeners"`
+	Database  Database   `json:"database"`
+	// Modules reports which optional parts of the API are enabled
+	Modules map[string]bool `json:"modules"`
+	// Features reports settings that change how requests are handled
+	Features map[string]interface{} `json:"features"`
+}
+
+// Listener is an address the instance serves on
+type Listener struct {
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	// BasePath is the path every route is served under, if any
+	BasePath string `json:"base_path,omitempty"`
+}
+
+// Database describes the database an instance uses
+type Database struct {
+	Driver string `json:"driver"`
+	// Target is host:port/name, or the file of a SQLite database
+	Target        string `json:"target"`
+	Replica       string `json:"replica,omitempty"`
+	MigrationMode string `json:"migration_mode"`
+	// SchemaVersion is the version the binary needs and AppliedSchemaVersion
+	// the version the database was at on startup
+	SchemaVersion        int `json:"schema_version"`
+	AppliedSchemaVersion int `json:"applied_schema_version"`
+}
+
+// New builds the report of an instance started with cfg at now, needing
+// the schema of migrator. The applied schema version is left for the caller
+// to fill in.
+func New(cfg *config.Config, migrator *database.Migrator, now time.Time) *Report {
+	postgres := cfg.Database.Driver == database.DriverPostgres
+	build := buildinfo.Get()
+
+	report := &Report{
+		StartedAt: now.UTC(),
+		PID:       os.Getpid(),
+		Version:   build.Version,
+		Commit:    build.Commit,
+		GoVersion: build.GoVersion,
+		Region:    cfg.Server.Region,
+		Listeners: []Listener{{
+			Protocol: "http",
+			Address:  cfg.Server.Host + ":" + cfg.Server.Port,
+			BasePath: cfg.Server.BasePath,
+		}},
+		Database: Database{
+			Driver:        cfg.Database.Driver,
+			Target:        databaseTarget(cfg.Database),
+			MigrationMode: cfg.Database.MigrationMode,
+			SchemaVersion: migrator.SchemaVersion(),
+		},
+		Modules: map[string]bool{
+			"grpc":            cfg.GRPC.Enabled,
+			"grpc_gateway":    cfg.GRPC.Gateway,
+			"oidc":            cfg.OIDC.Enabled,
+			"write_ahead":     cfg.Queue.Enabled,
+			"audit":           cfg.Audit.Enabled,
+			"email":           cfg.Email.Enabled,
+			"webhooks":        cfg.Webhooks.Enabled,
+			"watches":         cfg.Watches.Enabled,
+			"websocket":       cfg.Live.Enabled,
+			"event_outbox":    cfg.Events.Outbox,
+			"metrics":         cfg.Metrics.Enabled,
+			"rate_limit":      cfg.Limits.Enabled && cfg.Limits.RequestsPerSecond > 0,
+			"priority":        cfg.Priority.Enabled,
+			"tracing":         cfg.Tracing.Enabled,
+			"swagger":         cfg.Server.Swagger,
+			"personal_tokens": postgres,
+			"operations":      postgres,
+			"settings":        postgres,
+			"purge":           cfg.Database.SoftDeleteRetention > 0,
+			"memory_watchdog": health.MemoryLimit(cfg.Memory.Limit) > 0,
+			"runbook":         cfg.Runbook.Enabled,
+		},
+		Features: map[string]interface{}{
+			"method_override":     cfg.Server.MethodOverride,
+			"trailing_slash":      cfg.Server.TrailingSlash,
+			"lowercase_paths":     cfg.Server.LowercasePaths,
+			"trusted_proxies":     len(cfg.Server.TrustedProxies) > 0,
+			"follower_reads":      cfg.Database.FollowerReads,
+			"consistency_tokens":  postgres && cfg.Database.ReplicaHost != "",
+			"cache_driver":        cfg.Cache.Driver,
+			"user_cache":          cfg.Cache.Users,
+			"memory_user_cache":   cfg.Cache.MemoryUsers,
+			"cache_warm_on_start": cfg.Cache.WarmOnStart,
+			"id_codec":            cfg.IDs.Codec,
+			"age_phase":           cfg.Schema.AgePhase,
+			"locales":             cfg.I18n.SupportedLocales,
+			"registration":        cfg.Auth.Registration,
+			"events_backend":      cfg.Events.Backend,
+			"compression":         cfg.Compress.Enabled,
+			"max_body_bytes":      cfg.Server.MaxBodyBytes,
+			"jwt_key_rotation":    postgres,
+		},
+	}
+	if cfg.GRPC.Enabled {
+		report.Listeners = append(report.Listeners, Listener{
+			Protocol: "grpc",
+			Address:  cfg.Server.Host + ":" + cfg.GRPC.Port,
+		})
+	}
+	if cfg.Database.ReplicaHost != "" {
+		report.Database.Replica = databaseTarget(cfg.Database.Replica())
+	}
+	return report
+}
+
+// databaseTarget names the database cfg connects to, without credentials
+func databaseTarget(cfg config.DatabaseConfig) string {
+	if cfg.Driver == database.DriverSQLite {
+		return cfg.SQLitePath
+	}
+	return cfg.Host + ":" + cfg.Port + "/" + cfg.Name
+}
+
+// EnabledModules returns the names of the enabled modules in order
+func (r *Report) EnabledModules() []string {
+	var enabled []string
+	for name, on := range r.Modules {
+		if on {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
+
+// Banner returns a short human-readable summary of the report
+func (r *Report) Banner() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "go-crud %s started (pid %d, %s)\n", r.Version, r.PID, r.GoVersion)
+	for _, l := range r.Listeners {
+		fmt.Fprintf(&b, "  %-9s %s%s\n", l.Protocol, l.Address, l.BasePath)
+	}
+	fmt.Fprintf(&b, "  %-9s %s %s, schema %d of %d\n", "database", r.Database.Driver, r.Database.Target,
+		r.Database.AppliedSchemaVersion, r.Database.SchemaVersion)
+	fmt.Fprintf(&b, "  %-9s %s\n", "modules", strings.Join(r.EnabledModules(), ", "))
+	return b.String()
+}
+
+// WriteFile writes the report to path as JSON. The file is replaced in one
+// step, so readers never see a partial report.
+func (r *Report) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode startup report: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write startup report: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write startup report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write startup report: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write startup report: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write startup report: %w", err)
+	}
+	return nil
+}
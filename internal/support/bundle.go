@@ -0,0 +1,297 @@
+// Package support gathers diagnostics of a running instance into a bundle
+// operators can attach to support tickets.
+package support
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/startup"
+)
+
+// redactedValue replaces secrets in the bundled configuration
+const redactedValue = "<redacted>"
+
+// sensitiveKeys are substrings of configuration field names whose values
+// are never bundled. The ID alphabet is private too: it decodes public IDs.
+var sensitiveKeys = []string{"password", "secret", "encryptionkey", "alphabet"}
+
+// Bundler collects diagnostics of the running instance
+type Bundler struct {
+	cfg      *config.Config
+	migrator *database.Migrator
+	primary  *sql.DB
+	pools    map[string]*sql.DB
+	monitor  *health.Monitor
+	logs     *logger.Recent
+	report   atomic.Pointer[startup.Report]
+	now      func() time.Time
+}
+
+// NewBundler creates a Bundler for an instance running with cfg. The status
+// of the migrations of migrator is read from primary, connection statistics
+// from each of pools by name, health from monitor and recent log entries
+// from logs; monitor and logs may be nil.
+func NewBundler(cfg *config.Config, migrator *database.Migrator, primary *sql.DB, pools map[string]*sql.DB, monitor *health.Monitor, logs *logger.Recent) *Bundler {
+	return &Bundler{cfg: cfg, migrator: migrator, primary: primary, pools: pools, monitor: monitor, logs: logs, now: time.Now}
+}
+
+// SetStartupReport includes report in later bundles
+func (b *Bundler) SetStartupReport(report *startup.Report) {
+	b.report.Store(report)
+}
+
+// Manifest lists the files of a bundle and the sections that could not be
+// collected
+type Manifest struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Files     []string          `json:"files"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// section is one file of a bundle
+type section struct {
+	name    string
+	collect func(ctx context.Context, w io.Writer) error
+}
+
+// Write writes a gzipped tarball of diagnostics to w. A section that fails
+// is left out and its error noted in manifest.json, so one broken
+// dependency, such as an unreachable database, does not lose the rest.
+func (b *Bundler) Write(ctx context.Context, w io.Writer) error {
+	now := b.now().UTC()
+	sections := []section{
+		{"config.json", b.writeConfig},
+		{"startup.json", b.writeStartupReport},
+		{"runtime.json", b.writeRuntime},
+		{"goroutines.txt", writeGoroutines},
+		{"db_stats.json", b.writeDBStats},
+		{"migrations.json", b.writeMigrations},
+		{"health.json", b.writeHealth},
+		{"logs.jsonl", b.writeLogs},
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest := Manifest{CreatedAt: now, Errors: make(map[string]string)}
+	for _, s := range sections {
+		var buf bytes.Buffer
+		if err := s.collect(ctx, &buf); err != nil {
+			manifest.Errors[s.name] = err.Error()
+			continue
+		}
+		if err := addFile(tw, s.name, buf.Bytes(), now); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, s.name)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle manifest: %w", err)
+	}
+	if err := addFile(tw, "manifest.json", data, now); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// addFile writes a file holding data to tw
+func addFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// writeJSON writes value as indented JSON
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writeConfig writes the configuration with secrets redacted
+func (b *Bundler) writeConfig(ctx context.Context, w io.Writer) error {
+	redacted, err := RedactConfig(b.cfg)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, redacted)
+}
+
+// RedactConfig returns cfg as a JSON object, with the values of fields
+// named like passwords, secrets and keys replaced and credentials removed from
+// URLs
+func RedactConfig(cfg *config.Config) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	redact(value)
+	return value, nil
+}
+
+// redact replaces sensitive values anywhere in a decoded JSON object
+func redact(value map[string]interface{}) {
+	for key, field := range value {
+		if isSensitive(key) {
+			if field != "" && field != nil {
+				value[key] = redactedValue
+			}
+			continue
+		}
+		value[key] = redactValue(field)
+	}
+}
+
+// redactValue redacts within objects and lists, and removes passwords from
+// URLs
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redact(v)
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	case string:
+		if u, err := url.Parse(v); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
+
+// isSensitive reports whether a configuration field holds a secret
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeStartupReport writes the report made when the instance started
+func (b *Bundler) writeStartupReport(ctx context.Context, w io.Writer) error {
+	report := b.report.Load()
+	if report == nil {
+		return fmt.Errorf("startup report not available yet")
+	}
+	return writeJSON(w, report)
+}
+
+// runtimeStats summarizes the Go runtime
+type runtimeStats struct {
+	GoVersion    string `json:"go_version"`
+	GOOS         string `json:"goos"`
+	GOARCH       string `json:"goarch"`
+	NumCPU       int    `json:"num_cpu"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	Goroutines   int    `json:"goroutines"`
+	HeapAlloc    uint64 `json:"heap_alloc_bytes"`
+	HeapInuse    uint64 `json:"heap_inuse_bytes"`
+	Sys          uint64 `json:"sys_bytes"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"gc_pause_total_ns"`
+}
+
+// writeRuntime writes runtime and memory statistics
+func (b *Bundler) writeRuntime(ctx context.Context, w io.Writer) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return writeJSON(w, runtimeStats{
+		GoVersion:    runtime.Version(),
+		GOOS:         runtime.GOOS,
+		GOARCH:       runtime.GOARCH,
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		Sys:          mem.Sys,
+		NumGC:        mem.NumGC,
+		PauseTotalNs: mem.PauseTotalNs,
+	})
+}
+
+// writeGoroutines writes the stack of every goroutine
+func writeGoroutines(ctx context.Context, w io.Writer) error {
+	return pprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// writeDBStats writes the statistics of each connection pool
+func (b *Bundler) writeDBStats(ctx context.Context, w io.Writer) error {
+	names := make([]string, 0, len(b.pools))
+	for name, db := range b.pools {
+		if db != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	stats := make(map[string]sql.DBStats, len(names))
+	for _, name := range names {
+		stats[name] = b.pools[name].Stats()
+	}
+	return writeJSON(w, stats)
+}
+
+// writeMigrations writes which migrations are applied
+func (b *Bundler) writeMigrations(ctx context.Context, w io.Writer) error {
+	states, err := b.migrator.MigrationStatus(ctx, b.primary)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, states)
+}
+
+// writeHealth writes a fresh check of the databases
+func (b *Bundler) writeHealth(ctx context.Context, w io.Writer) error {
+	if b.monitor == nil {
+		return fmt.Errorf("health monitor not configured")
+	}
+	return writeJSON(w, b.monitor.Check(ctx))
+}
+
+// writeLogs writes the recent log entries
+func (b *Bundler) writeLogs(ctx context.Context, w io.Writer) error {
+	if b.logs == nil {
+		return fmt.Errorf("recent logs are not kept; set LOG_RECENT_ENTRIES")
+	}
+	_, err := b.logs.WriteTo(w)
+	return err
+}
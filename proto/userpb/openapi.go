@@ -0,0 +1,9 @@
+package userpb
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 2.0 document of the JSON API generated from
+// user.proto's HTTP rules
+//
+//go:embed user.swagger.json
+var OpenAPI []byte
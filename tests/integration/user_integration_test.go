@@ -2,31 +2,38 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
-	"github.com/gorilla/mux"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
 	"github.com/pratham15541/go-crud/internal/config"
 	"github.com/pratham15541/go-crud/internal/database"
 	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
 	"github.com/pratham15541/go-crud/internal/models"
 	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/pratham15541/go-crud/internal/router"
 	"github.com/pratham15541/go-crud/internal/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	_ "github.com/lib/pq"
 )
 
 type IntegrationTestSuite struct {
 	suite.Suite
 	db     *sql.DB
-	router *mux.Router
+	repo   repository.UserRepository
+	router http.Handler
+	token  string
+	driver string
 }
 
 func (suite *IntegrationTestSuite) SetupSuite() {
@@ -34,52 +41,61 @@ func (suite *IntegrationTestSuite) SetupSuite() {
 	godotenv.Load("../../.env.example")
 	os.Setenv("DB_NAME", "crud_demo_test")
 
-	// Load configuration
+	// Load configuration. DB_DRIVER=sqlite runs the suite against an
+	// in-memory database, without a Postgres server.
 	cfg := config.Load()
-
-	// Connect to postgres to create test database
-	testDbConfig := cfg.Database
-	testDbConfig.Name = "postgres"
-	pgDb, err := database.NewConnection(testDbConfig)
+	migrator, err := database.NewMigrator(cfg.Database.Driver)
 	suite.Require().NoError(err)
+	suite.driver = cfg.Database.Driver
 
-	// Create test database
-	_, err = pgDb.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", cfg.Database.Name))
-	suite.Require().NoError(err)
-	_, err = pgDb.Exec(fmt.Sprintf("CREATE DATABASE %s", cfg.Database.Name))
-	suite.Require().NoError(err)
-	pgDb.Close()
+	if cfg.Database.Driver == database.DriverSQLite {
+		cfg.Database.SQLitePath = ":memory:"
+	} else {
+		// Connect to postgres to create test database
+		testDbConfig := cfg.Database
+		testDbConfig.Name = "postgres"
+		pgDb, err := database.NewConnection(testDbConfig, cfg.Database.Migrations, nil)
+		suite.Require().NoError(err)
+
+		// Create test database
+		_, err = pgDb.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", cfg.Database.Name))
+		suite.Require().NoError(err)
+		_, err = pgDb.Exec(fmt.Sprintf("CREATE DATABASE %s", cfg.Database.Name))
+		suite.Require().NoError(err)
+		pgDb.Close()
+	}
 
 	// Connect to test database
-	db, err := database.NewConnection(cfg.Database)
+	db, err := database.NewConnection(cfg.Database, cfg.Database.API, nil)
 	suite.Require().NoError(err)
 	suite.db = db
 
 	// Run migrations
-	err = database.RunMigrations(db)
+	err = migrator.RunMigrations(db)
 	suite.Require().NoError(err)
 
 	// Setup router
-	userRepo := repository.NewUserRepository(db)
-	userService := services.NewUserService(userRepo)
-	userHandler := handlers.NewUserHandler(userService)
-	healthHandler := handlers.NewHealthHandler(db)
+	userRepo := repository.NewUserRepository(db, repository.UserSchema{Dialect: repository.Dialect(cfg.Database.Driver)})
+	suite.repo = userRepo
+	userService := services.NewUserService(userRepo, services.DefaultPagination(), idcodec.Plain{})
+	userHandler := handlers.NewUserHandler(userService, idcodec.Plain{})
+	healthHandler := handlers.NewHealthHandler(db, nil)
 
-	router := mux.NewRouter()
-	api := router.PathPrefix("/api/v1").Subrouter()
+	r := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   userHandler,
+		HealthHandler: healthHandler,
+	})
 
-	// Health check
-	api.HandleFunc("/health", healthHandler.HealthCheck).Methods("GET")
+	suite.router = r.Handler()
 
-	// User routes
-	userRoutes := api.PathPrefix("/users").Subrouter()
-	userRoutes.HandleFunc("", userHandler.GetUsers).Methods("GET")
-	userRoutes.HandleFunc("", userHandler.CreateUser).Methods("POST")
-	userRoutes.HandleFunc("/{id:[0-9]+}", userHandler.GetUser).Methods("GET")
-	userRoutes.HandleFunc("/{id:[0-9]+}", userHandler.UpdateUser).Methods("PUT")
-	userRoutes.HandleFunc("/{id:[0-9]+}", userHandler.DeleteUser).Methods("DELETE")
-
-	suite.router = router
+	// User mutations require a token
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(cfg.JWT.Secret))
+	suite.Require().NoError(err)
+	suite.token = "Bearer " + token
 }
 
 func (suite *IntegrationTestSuite) TearDownSuite() {
@@ -118,6 +134,7 @@ func (suite *IntegrationTestSuite) TestCreateUser() {
 	jsonUser, _ := json.Marshal(user)
 	req, _ := http.NewRequest("POST", "/api/v1/users", bytes.NewBuffer(jsonUser))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", suite.token)
 	rr := httptest.NewRecorder()
 
 	suite.router.ServeHTTP(rr, req)
@@ -130,6 +147,38 @@ func (suite *IntegrationTestSuite) TestCreateUser() {
 	assert.Equal(suite.T(), "User created successfully", response.Message)
 }
 
+func (suite *IntegrationTestSuite) TestCreateUser_ConcurrentDuplicatesConflict() {
+	body, _ := json.Marshal(models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	// Released together so the creates race past the email check
+	const clients = 8
+	start := make(chan struct{})
+	codes := make(chan int, clients)
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("POST", "/api/v1/users", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", suite.token)
+			rr := httptest.NewRecorder()
+			<-start
+			suite.router.ServeHTTP(rr, req)
+			codes <- rr.Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(codes)
+
+	counts := make(map[int]int)
+	for code := range codes {
+		counts[code]++
+	}
+	suite.Equal(map[int]int{http.StatusCreated: 1, http.StatusConflict: clients - 1}, counts)
+}
+
 func (suite *IntegrationTestSuite) TestGetUsers() {
 	// Create a test user first
 	user := models.CreateUserRequest{
@@ -140,6 +189,7 @@ func (suite *IntegrationTestSuite) TestGetUsers() {
 	jsonUser, _ := json.Marshal(user)
 	createReq, _ := http.NewRequest("POST", "/api/v1/users", bytes.NewBuffer(jsonUser))
 	createReq.Header.Set("Content-Type", "application/json")
+	createReq.Header.Set("Authorization", suite.token)
 	createRr := httptest.NewRecorder()
 	suite.router.ServeHTTP(createRr, createReq)
 
@@ -156,6 +206,223 @@ func (suite *IntegrationTestSuite) TestGetUsers() {
 	assert.NoError(suite.T(), err)
 }
 
+func (suite *IntegrationTestSuite) TestGetUsers_PagesWithTiedSortKeys() {
+	ctx := context.Background()
+	const total = 23
+	for i := 0; i < total; i++ {
+		_, err := suite.repo.Create(ctx, &models.CreateUserRequest{
+			Name:  "Tied User",
+			Email: fmt.Sprintf("tied%d@example.com", i),
+			Age:   30,
+		})
+		suite.Require().NoError(err)
+	}
+	// Every row has the same creation time, name and age
+	_, err := suite.db.Exec("UPDATE users SET created_at = (SELECT MIN(created_at) FROM users)")
+	suite.Require().NoError(err)
+
+	sorts := [][]models.UserSort{
+		nil,
+		{{Field: "name"}},
+		{{Field: "age", Desc: true}, {Field: "created_at"}},
+	}
+	for _, sort := range sorts {
+		seen := make(map[int]bool)
+		for offset := 0; ; offset += 5 {
+			page, err := suite.repo.GetAll(ctx, &models.UserFilter{Sort: sort}, 5, offset)
+			suite.Require().NoError(err)
+			if len(page) == 0 {
+				break
+			}
+			for _, user := range page {
+				suite.False(seen[user.ID], "user %d returned on two pages sorted by %v", user.ID, sort)
+				seen[user.ID] = true
+			}
+		}
+		suite.Len(seen, total, "pages sorted by %v missed users", sort)
+	}
+}
+
+func (suite *IntegrationTestSuite) TestExport_CursorFetchesInOrderAcrossBatches() {
+	if suite.driver != database.DriverPostgres {
+		suite.T().Skip("exports only use a cursor on Postgres")
+	}
+	ctx := context.Background()
+	const total, batchSize = 10, 4
+	for i := 0; i < total; i++ {
+		_, err := suite.repo.Create(ctx, &models.CreateUserRequest{
+			Name:  "Export User",
+			Email: fmt.Sprintf("export%d@example.com", i),
+			Age:   30,
+		})
+		suite.Require().NoError(err)
+	}
+	// Tie some creation times so the id tiebreak decides order across batches
+	_, err := suite.db.Exec("UPDATE users SET created_at = (SELECT MIN(created_at) FROM users) - (id % 3) * INTERVAL '1 hour'")
+	suite.Require().NoError(err)
+
+	var sizes []int
+	var exported []*models.User
+	err = suite.repo.Export(ctx, nil, batchSize, func(users []*models.User) error {
+		sizes = append(sizes, len(users))
+		exported = append(exported, users...)
+		return nil
+	})
+	suite.Require().NoError(err)
+	suite.Equal([]int{4, 4, 2}, sizes)
+	suite.Require().Len(exported, total)
+	for i := 1; i < len(exported); i++ {
+		prev, user := exported[i-1], exported[i]
+		ordered := prev.CreatedAt.After(user.CreatedAt) ||
+			(prev.CreatedAt.Equal(user.CreatedAt) && prev.ID > user.ID)
+		suite.True(ordered, "user %d exported after user %d", user.ID, prev.ID)
+	}
+}
+
+func (suite *IntegrationTestSuite) TestExport_ClosesCursorWhenClientDisconnects() {
+	if suite.driver != database.DriverPostgres {
+		suite.T().Skip("exports only use a cursor on Postgres")
+	}
+	for i := 0; i < 6; i++ {
+		_, err := suite.repo.Create(context.Background(), &models.CreateUserRequest{
+			Name:  "Export User",
+			Email: fmt.Sprintf("export%d@example.com", i),
+			Age:   30,
+		})
+		suite.Require().NoError(err)
+	}
+
+	// The client goes away after the first batch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := 0
+	err := suite.repo.Export(ctx, nil, 2, func([]*models.User) error {
+		batches++
+		cancel()
+		return nil
+	})
+	suite.Error(err)
+	suite.Equal(1, batches)
+
+	// The transaction holding the cursor ends and frees its connection
+	suite.Eventually(func() bool {
+		var open int
+		err := suite.db.QueryRow(`
+			SELECT COUNT(*) FROM pg_stat_activity
+			WHERE datname = current_database() AND state LIKE 'idle in transaction%'
+		`).Scan(&open)
+		return err == nil && open == 0 && suite.db.Stats().InUse == 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// A later export can declare the cursor again
+	exported := 0
+	err = suite.repo.Export(context.Background(), nil, 2, func(users []*models.User) error {
+		exported += len(users)
+		return nil
+	})
+	suite.Require().NoError(err)
+	suite.Equal(6, exported)
+}
+
+func (suite *IntegrationTestSuite) TestCreateUsersBulk_CommitsAllButTheFailures() {
+	users := []models.CreateUserRequest{
+		{Name: "Ada Lovelace", Email: "ada@example.com", Age: 36},
+		{Name: "Ada Again", Email: "ada@example.com", Age: 37},
+		{Name: "Too Old", Email: "old@example.com", Age: 150},
+		{Name: "Alan Turing", Email: "alan@example.com", Age: 41},
+	}
+	body, _ := json.Marshal(users)
+	req, _ := http.NewRequest("POST", "/api/v1/users/bulk", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", suite.token)
+	rr := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rr, req)
+
+	suite.Equal(http.StatusMultiStatus, rr.Code)
+	var response struct {
+		Data models.BulkCreateUsersResponse `json:"data"`
+	}
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &response))
+	suite.Equal(2, response.Data.Created)
+	statuses := make([]int, len(response.Data.Results))
+	for i, result := range response.Data.Results {
+		statuses[i] = result.Status
+	}
+	// The database rejects the taken email and the age its CHECK forbids
+	suite.Equal([]int{http.StatusCreated, http.StatusConflict, http.StatusBadRequest, http.StatusCreated}, statuses)
+
+	count, err := suite.repo.Count(context.Background(), &models.UserFilter{})
+	suite.Require().NoError(err)
+	suite.Equal(int64(2), count)
+}
+
+func (suite *IntegrationTestSuite) TestRecentlyActive_OrdersByLastChange() {
+	ctx := context.Background()
+	ids := make([]int, 3)
+	for i := range ids {
+		user, err := suite.repo.Create(ctx, &models.CreateUserRequest{
+			Name:  "John Doe",
+			Email: fmt.Sprintf("john%d@example.com", i),
+			Age:   30,
+		})
+		suite.Require().NoError(err)
+		ids[i] = user.ID
+	}
+	_, err := suite.db.Exec(fmt.Sprintf("UPDATE users SET updated_at = '2099-01-01 00:00:00' WHERE id = %d", ids[0]))
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.repo.Delete(ctx, ids[2]))
+
+	users, err := suite.repo.RecentlyActive(ctx, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(users, 2)
+	suite.Equal(ids[0], users[0].ID)
+	suite.Equal(ids[1], users[1].ID)
+}
+
+func (suite *IntegrationTestSuite) TestUpdate_RejectsStaleVersion() {
+	ctx := context.Background()
+	user, err := suite.repo.Create(ctx, &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	suite.Require().NoError(err)
+	suite.Equal(1, user.Version)
+
+	name, version := "John", user.Version
+	updated, err := suite.repo.Patch(ctx, user.ID, &models.PatchUserRequest{Name: &name, Version: &version})
+	suite.Require().NoError(err)
+	suite.Equal(2, updated.Version)
+
+	_, err = suite.repo.Patch(ctx, user.ID, &models.PatchUserRequest{Name: &name, Version: &version})
+	suite.ErrorIs(err, models.ErrVersionConflict)
+	_, err = suite.repo.Update(ctx, user.ID, &models.UpdateUserRequest{Name: "Johnny", Version: &version})
+	suite.ErrorIs(err, models.ErrVersionConflict)
+
+	_, err = suite.repo.Patch(ctx, user.ID+100, &models.PatchUserRequest{Name: &name, Version: &version})
+	suite.EqualError(err, "user not found")
+}
+
+func (suite *IntegrationTestSuite) TestUpsert_CreatesThenUpdatesLiveUser() {
+	ctx := context.Background()
+	req := &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30}
+	user, created, err := suite.repo.Upsert(ctx, req)
+	suite.Require().NoError(err)
+	suite.True(created)
+
+	req.Name = "John Smith"
+	updated, created, err := suite.repo.Upsert(ctx, req)
+	suite.Require().NoError(err)
+	suite.False(created)
+	suite.Equal(user.ID, updated.ID)
+	suite.Equal("John Smith", updated.Name)
+	suite.Equal(2, updated.Version)
+
+	// A deleted user's email is free again, so it is not updated
+	suite.Require().NoError(suite.repo.Delete(ctx, user.ID))
+	recreated, created, err := suite.repo.Upsert(ctx, req)
+	suite.Require().NoError(err)
+	suite.True(created)
+	suite.NotEqual(user.ID, recreated.ID)
+}
+
 func TestIntegrationSuite(t *testing.T) {
 	suite.Run(t, new(IntegrationTestSuite))
-}
\ No newline at end of file
+}
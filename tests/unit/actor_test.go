@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestActor_FromClaims(t *testing.T) {
+	caller := actor.FromClaims(map[string]interface{}{
+		"sub":    "42",
+		"role":   "user",
+		"act":    map[string]interface{}{"sub": "1"},
+		"tenant": "acme",
+	})
+	assert.Equal(t, "42", caller.UserID)
+	assert.Equal(t, "1", caller.ImpersonatorID)
+	assert.Equal(t, "acme", caller.TenantID)
+	assert.True(t, caller.Impersonated())
+	assert.False(t, caller.IsAdmin())
+	assert.True(t, caller.HasScope(services.ScopeUsersWrite), "session tokens are not scoped")
+
+	// Personal access tokens; token_id is a float64 once it has been through JSON
+	caller = actor.FromClaims(map[string]interface{}{
+		"sub":        "42",
+		"scope":      services.ScopeUsersRead,
+		"token_type": "pat",
+		"token_id":   float64(3),
+	})
+	assert.Equal(t, "3", caller.APIKeyID)
+	assert.True(t, caller.HasScope(services.ScopeUsersRead))
+	assert.False(t, caller.HasScope(services.ScopeUsersWrite))
+
+	id, ok := caller.UserIDInt()
+	assert.True(t, ok)
+	assert.Equal(t, 42, id)
+
+	assert.False(t, actor.FromClaims(nil).Authenticated())
+}
+
+func TestActorMiddleware_AnonymousActorCarriesIP(t *testing.T) {
+	var caller actor.Actor
+	handler := middleware.ActorMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = actor.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.9:4000"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.False(t, caller.Authenticated())
+	assert.Equal(t, "203.0.113.9", caller.IP)
+}
+
+func TestRouter_AuditsImpersonation(t *testing.T) {
+	cfg := config.Load()
+	repo := &MockAuditRepository{}
+	auditService := services.NewAuditService(repo, services.DefaultPagination())
+	tokenService := services.NewPersonalTokenService(NewMockPersonalTokenRepository(), cfg.Tokens, zap.NewNop())
+
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		TokenHandler:  handlers.NewTokenHandler(tokenService),
+		Audit:         auditService,
+		AuditHandler:  handlers.NewAuditHandler(auditService),
+	}).Handler()
+	impersonated := bearer(t, cfg, jwt.MapClaims{
+		"sub":    "7",
+		"act":    map[string]interface{}{"sub": "1"},
+		"tenant": "acme",
+	})
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.9:4000"
+	req.Header.Set("Authorization", impersonated)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Impersonators cannot mint tokens that outlive the session
+	req = httptest.NewRequest("POST", "/api/v1/me/tokens", strings.NewReader(`{"name":"script"}`))
+	req.Header.Set("Authorization", impersonated)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	require.Len(t, repo.entries, 2)
+	entry := repo.entries[0]
+	assert.Equal(t, "7", entry.ActorID)
+	assert.Equal(t, "1", entry.ImpersonatorID)
+	assert.Equal(t, "acme", entry.TenantID)
+	assert.Equal(t, "203.0.113.9", entry.IP)
+
+	// The new fields are covered by the hash chain
+	repo.entries[0].ImpersonatorID = ""
+	result, err := auditService.Verify(req.Context())
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+}
@@ -0,0 +1,54 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/apidocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_RecordsExamplesPerRoute(t *testing.T) {
+	recorder := apidocs.NewRecorder()
+	handler := recorder.Wrap("/api/v1/users/{id:[0-9]+}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":1,"token":"secret-value"}}`))
+	}))
+
+	req := httptest.NewRequest("PUT", "/api/v1/users/1", strings.NewReader(`{"name":"John","password":"hunter22"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	doc := recorder.Document()
+	op, ok := doc.Paths["/api/v1/users/{id}"]["put"]
+	require.True(t, ok)
+
+	request := op.RequestBody.Content["application/json"].Example.(map[string]interface{})
+	assert.Equal(t, "John", request["name"])
+	assert.Equal(t, "<redacted>", request["password"])
+
+	response, ok := op.Responses["201"]
+	require.True(t, ok)
+	data := response.Content["application/json"].Example.(map[string]interface{})["data"].(map[string]interface{})
+	assert.Equal(t, "<redacted>", data["token"])
+}
+
+func TestRecorder_SkipsNonJSONBodies(t *testing.T) {
+	recorder := apidocs.NewRecorder()
+	handler := recorder.Wrap("/device", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/device", nil))
+
+	op := recorder.Document().Paths["/device"]["get"]
+	require.NotNil(t, op)
+	assert.Nil(t, op.RequestBody)
+	assert.Equal(t, "OK", op.Responses["200"].Description)
+	assert.Nil(t, op.Responses["200"].Content)
+}
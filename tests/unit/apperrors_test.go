@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppErrors_Status(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "not found", err: apperrors.NotFound("user not found"), status: http.StatusNotFound},
+		{name: "conflict", err: apperrors.Conflict("user is not deleted"), status: http.StatusConflict},
+		{name: "validation", err: apperrors.Validation("invalid cursor"), status: http.StatusBadRequest},
+		{name: "forbidden", err: apperrors.Forbidden("registration is closed"), status: http.StatusForbidden},
+		{name: "internal", err: apperrors.Internal("failed to create user", errors.New("connection reset")), status: http.StatusInternalServerError},
+		{name: "wrapped", err: fmt.Errorf("lookup: %w", apperrors.NotFound("user not found")), status: http.StatusNotFound},
+		{name: "duplicate email", err: &models.DuplicateEmailError{Email: "ada@example.com"}, status: http.StatusConflict},
+		{name: "invalid age", err: models.ErrInvalidAge, status: http.StatusBadRequest},
+		{name: "unknown kind", err: errors.New("boom"), status: http.StatusTeapot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, apperrors.Status(tt.err, http.StatusTeapot))
+		})
+	}
+}
+
+func TestAppErrors_InternalKeepsCause(t *testing.T) {
+	cause := errors.New("connection reset")
+	err := apperrors.Internal("failed to create user", cause)
+
+	assert.Equal(t, "failed to create user: connection reset", err.Error())
+	assert.ErrorIs(t, err, cause)
+	assert.ErrorIs(t, err, apperrors.ErrInternal)
+	assert.NotErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestUserService_ErrorsCarryKinds(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	_, err := userService.GetUser(context.Background(), 42)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	_, err = userService.CreateUser(context.Background(), &models.CreateUserRequest{Name: "A", Email: "not-an-email"})
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+}
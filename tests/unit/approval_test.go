@@ -0,0 +1,242 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockApprovalRepository implements ApprovalRepository interface for testing
+type MockApprovalRepository struct {
+	users     *MockUserRepository
+	approvals map[int]*models.Approval
+}
+
+func NewMockApprovalRepository(users *MockUserRepository) *MockApprovalRepository {
+	return &MockApprovalRepository{users: users, approvals: make(map[int]*models.Approval)}
+}
+
+func (m *MockApprovalRepository) Create(ctx context.Context, userID int) error {
+	m.approvals[userID] = &models.Approval{
+		UserID:    userID,
+		Status:    models.ApprovalPending,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	return nil
+}
+
+func (m *MockApprovalRepository) Get(ctx context.Context, userID int) (*models.Approval, error) {
+	approval, ok := m.approvals[userID]
+	if !ok {
+		return nil, apperrors.NotFound("registration approval not found")
+	}
+	found := *approval
+	if user, err := m.users.GetByID(ctx, userID); err == nil {
+		found.Name = user.Name
+		found.Email = user.Email
+	}
+	return &found, nil
+}
+
+func (m *MockApprovalRepository) ListPending(ctx context.Context, afterUserID, limit int) ([]*models.Approval, error) {
+	var ids []int
+	for id, approval := range m.approvals {
+		if approval.Status == models.ApprovalPending && id > afterUserID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	var pending []*models.Approval
+	for _, id := range ids {
+		if len(pending) == limit {
+			break
+		}
+		approval, _ := m.Get(ctx, id)
+		pending = append(pending, approval)
+	}
+	return pending, nil
+}
+
+func (m *MockApprovalRepository) Decide(ctx context.Context, userID int, status, reason string, decidedBy *int) (*models.Approval, error) {
+	approval, ok := m.approvals[userID]
+	if !ok {
+		return nil, apperrors.NotFound("registration approval not found")
+	}
+	if approval.Status != models.ApprovalPending {
+		return nil, apperrors.Conflict("registration has already been decided")
+	}
+	now := time.Now()
+	approval.Status = status
+	approval.Reason = reason
+	approval.DecidedAt = &now
+	if decidedBy != nil {
+		id := *decidedBy
+		approval.DecidedBy = &id
+	}
+	return m.Get(ctx, userID)
+}
+
+// newApprovalAuthService returns an auth service holding registrations for
+// approval in approvals
+func newApprovalAuthService(users *MockUserRepository, approvals *MockApprovalRepository) *services.AuthService {
+	authService := services.NewAuthService(users, config.Load().JWT, idcodec.Plain{})
+	authService.SetRegistration(models.RegistrationApproval, &fakeTxManager{}, approvals)
+	return authService
+}
+
+func registerJohn(t *testing.T, authService *services.AuthService) *models.AuthResponse {
+	resp, err := authService.Register(context.Background(), &models.RegisterRequest{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Age:      30,
+		Password: "correct horse",
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestAuthService_ClosedRegistrationIsForbidden(t *testing.T) {
+	authService := services.NewAuthService(NewMockUserRepository(), config.Load().JWT, idcodec.Plain{})
+	authService.SetRegistration(models.RegistrationClosed, nil, nil)
+
+	_, err := authService.Register(context.Background(), &models.RegisterRequest{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Age:      30,
+		Password: "correct horse",
+	})
+	assert.True(t, errors.Is(err, apperrors.ErrForbidden))
+}
+
+func TestAuthService_PendingRegistrationCannotSignIn(t *testing.T) {
+	users := NewMockUserRepository()
+	approvals := NewMockApprovalRepository(users)
+	authService := newApprovalAuthService(users, approvals)
+
+	resp := registerJohn(t, authService)
+	assert.True(t, resp.Pending)
+	assert.Empty(t, resp.Token)
+	require.Contains(t, approvals.approvals, plainID(t, resp.User.ID))
+
+	login := &models.LoginRequest{Email: "john@example.com", Password: "correct horse"}
+	_, err := authService.Login(context.Background(), login)
+	assert.True(t, errors.Is(err, apperrors.ErrForbidden))
+	assert.Contains(t, err.Error(), "pending")
+
+	// A wrong password reveals nothing about the registration
+	_, err = authService.Login(context.Background(), &models.LoginRequest{Email: "john@example.com", Password: "wrong"})
+	assert.False(t, errors.Is(err, apperrors.ErrForbidden))
+
+	approvalService := services.NewApprovalService(approvals, services.DefaultPagination())
+	_, err = approvalService.Approve(context.Background(), plainID(t, resp.User.ID), &models.ApprovalDecisionRequest{})
+	require.NoError(t, err)
+
+	signedIn, err := authService.Login(context.Background(), login)
+	require.NoError(t, err)
+	assert.NotEmpty(t, signedIn.Token)
+}
+
+func TestApprovalService_DecisionsNotifyUserOnce(t *testing.T) {
+	users := NewMockUserRepository()
+	approvals := NewMockApprovalRepository(users)
+	authService := newApprovalAuthService(users, approvals)
+	resp := registerJohn(t, authService)
+
+	emailRepo := &MockEmailRepository{}
+	transactions := &fakeTxManager{}
+	approvalService := services.NewApprovalService(approvals, services.DefaultPagination())
+	approvalService.SetNotifications(transactions, services.NewEmailService(emailRepo, &fakeSender{}, testEmailConfig(), services.DefaultPagination()))
+
+	ctx := actor.NewContext(context.Background(), actor.Actor{UserID: "7", Role: "admin"})
+	approval, err := approvalService.Reject(ctx, plainID(t, resp.User.ID), &models.ApprovalDecisionRequest{Reason: "unknown domain"})
+	require.NoError(t, err)
+	assert.Equal(t, models.ApprovalRejected, approval.Status)
+	require.NotNil(t, approval.DecidedBy)
+	assert.Equal(t, 7, *approval.DecidedBy)
+
+	assert.Equal(t, 1, transactions.committed)
+	require.Len(t, emailRepo.emails, 1)
+	assert.Equal(t, "john@example.com", emailRepo.emails[0].Recipient)
+	assert.Contains(t, emailRepo.emails[0].Body, "not been approved")
+	assert.Contains(t, emailRepo.emails[0].Body, "unknown domain")
+
+	_, err = approvalService.Approve(ctx, plainID(t, resp.User.ID), &models.ApprovalDecisionRequest{})
+	assert.True(t, errors.Is(err, apperrors.ErrConflict))
+	assert.Len(t, emailRepo.emails, 1)
+
+	_, err = authService.Login(context.Background(), &models.LoginRequest{Email: "john@example.com", Password: "correct horse"})
+	assert.True(t, errors.Is(err, apperrors.ErrForbidden))
+	assert.Contains(t, err.Error(), "rejected")
+}
+
+func TestApprovalRoutes_RegisterPendingAndAdminApproves(t *testing.T) {
+	cfg := config.Load()
+	users := NewMockUserRepository()
+	approvals := NewMockApprovalRepository(users)
+	authService := newApprovalAuthService(users, approvals)
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(users, services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		AuthHandler:   handlers.NewAuthHandler(authService),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Approvals:     handlers.NewApprovalHandler(services.NewApprovalService(approvals, services.DefaultPagination()), idcodec.Plain{}),
+	}).Handler()
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30,"password":"correct horse"}`
+	req := httptest.NewRequest("POST", "/api/v1/auth/register", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Contains(t, rr.Body.String(), "pending approval")
+	assert.NotContains(t, rr.Body.String(), `"token"`)
+
+	req = httptest.NewRequest("GET", "/api/v1/admin/approvals", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7"}))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/admin/approvals", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "john@example.com")
+
+	req = httptest.NewRequest("POST", "/api/v1/admin/approvals/1/approve", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status":"approved"`)
+
+	req = httptest.NewRequest("POST", "/api/v1/admin/approvals/1/reject", strings.NewReader(`{"reason":"too late"}`))
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	req = httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"email":"john@example.com","password":"correct horse"}`))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
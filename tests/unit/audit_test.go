@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAuditRepository implements AuditRepository interface for testing
+type MockAuditRepository struct {
+	entries []*models.AuditEntry
+}
+
+func (m *MockAuditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	if len(m.entries) > 0 {
+		entry.PrevHash = m.entries[len(m.entries)-1].Hash
+	}
+	entry.Hash = entry.ComputeHash()
+	entry.ID = int64(len(m.entries) + 1)
+	stored := *entry
+	m.entries = append(m.entries, &stored)
+	return nil
+}
+
+func (m *MockAuditRepository) List(ctx context.Context, afterID int64, limit int) ([]*models.AuditEntry, error) {
+	var entries []*models.AuditEntry
+	for _, entry := range m.entries {
+		if entry.ID > afterID && len(entries) < limit {
+			copied := *entry
+			entries = append(entries, &copied)
+		}
+	}
+	return entries, nil
+}
+
+func recordAuditEntries(t *testing.T, auditService *services.AuditService, n int) {
+	for i := 0; i < n; i++ {
+		err := auditService.Record(context.Background(), &models.AuditEntry{
+			Action:   "DELETE /api/v1/users/{id}",
+			ActorID:  "1",
+			TargetID: "2",
+			Status:   http.StatusOK,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestAuditService_RecordChainsEntries(t *testing.T) {
+	repo := &MockAuditRepository{}
+	auditService := services.NewAuditService(repo, services.DefaultPagination())
+	recordAuditEntries(t, auditService, 3)
+
+	assert.Empty(t, repo.entries[0].PrevHash)
+	assert.Equal(t, repo.entries[0].Hash, repo.entries[1].PrevHash)
+	assert.Equal(t, repo.entries[1].Hash, repo.entries[2].PrevHash)
+	assert.Len(t, repo.entries[2].Hash, 64)
+
+	result, err := auditService.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(3), result.Checked)
+}
+
+func TestAuditService_VerifyDetectsTampering(t *testing.T) {
+	repo := &MockAuditRepository{}
+	auditService := services.NewAuditService(repo, services.DefaultPagination())
+	recordAuditEntries(t, auditService, 3)
+
+	repo.entries[1].ActorID = "99"
+
+	result, err := auditService.Verify(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, int64(2), result.BrokenAt)
+	assert.Equal(t, "entry contents do not match its hash", result.Reason)
+}
+
+func TestAuditService_VerifyDetectsRemovedEntry(t *testing.T) {
+	repo := &MockAuditRepository{}
+	auditService := services.NewAuditService(repo, services.DefaultPagination())
+	recordAuditEntries(t, auditService, 3)
+
+	repo.entries = append(repo.entries[:1], repo.entries[2:]...)
+
+	result, err := auditService.Verify(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, int64(3), result.BrokenAt)
+	assert.Equal(t, "entry does not link to the previous entry", result.Reason)
+}
+
+func TestAuditEntry_HashIgnoresTimeZone(t *testing.T) {
+	at := time.Date(2025, 8, 11, 5, 34, 7, 123456000, time.UTC)
+	entry := &models.AuditEntry{Action: "POST /api/v1/users", Status: http.StatusCreated, CreatedAt: at}
+	local := *entry
+	local.CreatedAt = at.In(time.FixedZone("IST", 5*3600+1800))
+
+	assert.Equal(t, entry.ComputeHash(), local.ComputeHash())
+}
+
+func TestRouter_AuditsMutatingRequests(t *testing.T) {
+	cfg := config.Load()
+	repo := &MockAuditRepository{}
+	auditService := services.NewAuditService(repo, services.DefaultPagination())
+
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Audit:         auditService,
+		AuditHandler:  handlers.NewAuditHandler(auditService),
+	}).Handler()
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7"}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("DELETE", "/api/v1/users/1", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7"}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/users", nil))
+
+	require.Len(t, repo.entries, 2)
+	assert.Equal(t, "POST /api/v1/users", repo.entries[0].Action)
+	assert.Equal(t, http.StatusCreated, repo.entries[0].Status)
+	assert.Equal(t, "DELETE /api/v1/users/{id}", repo.entries[1].Action)
+	assert.Equal(t, "7", repo.entries[1].ActorID)
+	assert.Equal(t, "1", repo.entries[1].TargetID)
+
+	req = httptest.NewRequest("GET", "/api/v1/audit/verify", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"valid":true,"checked":2`)
+}
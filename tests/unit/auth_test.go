@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_RegisterAndLogin(t *testing.T) {
+	cfg := config.Load()
+	mockRepo := NewMockUserRepository()
+	authService := services.NewAuthService(mockRepo, cfg.JWT, idcodec.Plain{})
+
+	registered, err := authService.Register(context.Background(), &models.RegisterRequest{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Age:      30,
+		Password: "correct horse",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", registered.TokenType)
+	assert.Equal(t, "john@example.com", registered.User.Email)
+
+	stored, _ := mockRepo.GetByEmail(context.Background(), "john@example.com")
+	assert.NotEqual(t, "correct horse", stored.PasswordHash)
+
+	loggedIn, err := authService.Login(context.Background(), &models.LoginRequest{Email: "john@example.com", Password: "correct horse"})
+	require.NoError(t, err)
+
+	token, err := jwt.Parse(loggedIn.Token, func(*jwt.Token) (interface{}, error) {
+		return []byte(cfg.JWT.Secret), nil
+	})
+	require.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, "1", claims["sub"])
+	assert.Equal(t, models.RoleUser, claims["role"])
+}
+
+func TestAuthService_LoginInvalidCredentials(t *testing.T) {
+	authService := services.NewAuthService(NewMockUserRepository(), config.Load().JWT, idcodec.Plain{})
+
+	_, err := authService.Register(context.Background(), &models.RegisterRequest{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Age:      30,
+		Password: "correct horse",
+	})
+	require.NoError(t, err)
+
+	_, wrongPassword := authService.Login(context.Background(), &models.LoginRequest{Email: "john@example.com", Password: "wrong password"})
+	_, unknownEmail := authService.Login(context.Background(), &models.LoginRequest{Email: "jane@example.com", Password: "correct horse"})
+
+	assert.Error(t, wrongPassword)
+	assert.Equal(t, wrongPassword, unknownEmail)
+}
+
+func TestAuthService_RegisterValidation(t *testing.T) {
+	authService := services.NewAuthService(NewMockUserRepository(), config.Load().JWT, idcodec.Plain{})
+
+	_, err := authService.Register(context.Background(), &models.RegisterRequest{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Age:      30,
+		Password: "short",
+	})
+	assert.ErrorContains(t, err, "password")
+
+	req := &models.RegisterRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Password: "long enough"}
+	_, err = authService.Register(context.Background(), req)
+	require.NoError(t, err)
+	_, err = authService.Register(context.Background(), req)
+	assert.ErrorContains(t, err, "already exists")
+}
@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/benchreport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchOutput = `goos: linux
+goarch: amd64
+pkg: github.com/pratham15541/go-crud/benchmarks/repository
+BenchmarkGetByID/pq_unprepared-8         	   10000	    100000 ns/op	     800 B/op	      20 allocs/op
+BenchmarkGetByID/pgx_prepared-8          	   20000	     40000 ns/op	     600 B/op	      12 allocs/op
+BenchmarkGetByID/pq_unprepared-8         	   10000	    120000 ns/op	     800 B/op	      20 allocs/op
+BenchmarkPagination/depth=1000/offset-8  	    1000	   2000000 ns/op	   30000 B/op	     500 allocs/op
+BenchmarkPagination/depth=1000/keyset-8  	    5000	    250000 ns/op	   30000 B/op	     500 allocs/op
+PASS
+ok  	github.com/pratham15541/go-crud/benchmarks/repository	12.345s
+`
+
+func TestBenchReport_AveragesRunsByVariant(t *testing.T) {
+	results, err := benchreport.Parse(strings.NewReader(benchOutput))
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, "BenchmarkGetByID/pq_unprepared", results[0].Name)
+	assert.Equal(t, "BenchmarkGetByID", results[0].Group)
+	assert.Equal(t, "pq_unprepared", results[0].Variant)
+	assert.Equal(t, 2, results[0].Runs)
+	assert.Equal(t, 110000.0, results[0].NsPerOp)
+	assert.Equal(t, 20.0, results[0].AllocsPerOp)
+
+	assert.Equal(t, "BenchmarkPagination/depth=1000", results[3].Group)
+	assert.Equal(t, "keyset", results[3].Variant)
+
+	var out bytes.Buffer
+	require.NoError(t, benchreport.Write(&out, results))
+	report := out.String()
+	assert.Contains(t, report, "BenchmarkPagination/depth=1000\n")
+	// Compared with the group's first variant
+	assert.Regexp(t, `pgx_prepared\s+1\s+40\.00µs\s+0\.36x`, report)
+	assert.Regexp(t, `keyset\s+1\s+250\.00µs\s+0\.12x`, report)
+}
+
+func TestBenchReport_RejectsMalformedValues(t *testing.T) {
+	_, err := benchreport.Parse(strings.NewReader("BenchmarkGetByID-8 100 fast ns/op\n"))
+	assert.ErrorContains(t, err, "invalid ns/op")
+}
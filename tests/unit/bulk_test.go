@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkResponse is the envelope of a bulk create response
+type bulkResponse struct {
+	Message string                         `json:"message"`
+	Data    models.BulkCreateUsersResponse `json:"data"`
+}
+
+func postBulk(t *testing.T, handler *handlers.UserHandler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/v1/users/bulk", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.CreateUsers(rr, req)
+	return rr
+}
+
+func TestUserHandler_CreateUsers_AllCreated(t *testing.T) {
+	handler := handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{})
+
+	rr := postBulk(t, handler, `[
+		{"name": "Ada Lovelace", "email": "ada@example.com", "age": 36},
+		{"name": "Alan Turing", "email": "alan@example.com", "age": 41}
+	]`)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var response bulkResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Data.Created)
+	assert.Zero(t, response.Data.Failed)
+	require.Len(t, response.Data.Results, 2)
+	assert.Equal(t, "alan@example.com", response.Data.Results[1].User.Email)
+}
+
+func TestUserHandler_CreateUsers_ReportsFailuresPerItem(t *testing.T) {
+	handler := handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{})
+
+	rr := postBulk(t, handler, `[
+		{"name": "Ada Lovelace", "email": "ada@example.com", "age": 36},
+		{"name": "Bad Email", "email": "not-an-email", "age": 30},
+		{"name": "Ada Again", "email": "ada@example.com", "age": 37},
+		null
+	]`)
+
+	assert.Equal(t, http.StatusMultiStatus, rr.Code)
+	var response bulkResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "Created 1 of 4 users", response.Message)
+	assert.Equal(t, 1, response.Data.Created)
+	assert.Equal(t, 3, response.Data.Failed)
+
+	results := response.Data.Results
+	require.Len(t, results, 4)
+	assert.Equal(t, http.StatusCreated, results[0].Status)
+	assert.NotNil(t, results[0].User)
+
+	assert.Equal(t, 1, results[1].Index)
+	assert.Equal(t, http.StatusBadRequest, results[1].Status)
+	require.Len(t, results[1].Errors, 1)
+	assert.Equal(t, "email", results[1].Errors[0].Field)
+
+	assert.Equal(t, http.StatusConflict, results[2].Status)
+	assert.Equal(t, "user with email ada@example.com already exists", results[2].Error)
+	assert.Nil(t, results[2].User)
+
+	assert.Equal(t, http.StatusBadRequest, results[3].Status)
+}
+
+func TestUserHandler_CreateUsers_RejectsBadBatches(t *testing.T) {
+	handler := handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{})
+
+	tooMany := "[" + strings.TrimSuffix(strings.Repeat(`{"name":"Ada","email":"ada@example.com","age":36},`, services.MaxBulkUsers+1), ",") + "]"
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{name: "not an array", body: `{"name": "Ada"}`, message: "Invalid JSON payload, expected an array of users"},
+		{name: "empty", body: `[]`, message: "at least one user is required"},
+		{name: "too many", body: tooMany, message: fmt.Sprintf("at most %d users can be created at once", services.MaxBulkUsers)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := postBulk(t, handler, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.message, response.Message)
+		})
+	}
+}
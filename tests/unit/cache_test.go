@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pratham15541/go-crud/internal/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCache creates a memory cache closed when the test ends
+func newTestCache(t *testing.T) cache.Cache {
+	c, err := cache.NewMemory(1 << 20)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+// cacheBackends returns every cache backend that runs without a server
+func cacheBackends(t *testing.T) map[string]cache.Cache {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]cache.Cache{
+		"memory": newTestCache(t),
+		"redis":  cache.NewRedis(client),
+	}
+}
+
+func TestCache_GetSetDelete(t *testing.T) {
+	for name, c := range cacheBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			_, ok, err := c.Get(ctx, "missing")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			require.NoError(t, c.Set(ctx, "greeting", []byte("hello"), time.Minute))
+			value, ok, err := c.Get(ctx, "greeting")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, "hello", string(value))
+
+			require.NoError(t, c.Delete(ctx, "greeting", "missing"))
+			_, ok, err = c.Get(ctx, "greeting")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	for name, c := range cacheBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			require.NoError(t, c.Set(ctx, "expiring", []byte("1"), time.Minute))
+			ttl, ok, err := c.TTL(ctx, "expiring")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+			require.NoError(t, c.Set(ctx, "forever", []byte("1"), 0))
+			ttl, ok, err = c.TTL(ctx, "forever")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Zero(t, ttl)
+
+			_, ok, err = c.TTL(ctx, "missing")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestCache_BatchOperations(t *testing.T) {
+	for name, c := range cacheBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			require.NoError(t, c.SetMulti(ctx, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, time.Minute))
+			values, err := c.GetMulti(ctx, []string{"a", "b", "c"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, values)
+		})
+	}
+}
+
+func TestCache_ValidateDriver(t *testing.T) {
+	for _, driver := range []string{cache.DriverMemory, cache.DriverRedis, cache.DriverMemcached} {
+		assert.NoError(t, cache.ValidateDriver(driver))
+	}
+	assert.EqualError(t, cache.ValidateDriver("disk"), `unknown cache driver "disk", want memory, redis or memcached`)
+}
+
+func TestStaleWhileRevalidate_ServesStaleWhileRefreshing(t *testing.T) {
+	ctx := context.Background()
+	swr := cache.NewStaleWhileRevalidate(newTestCache(t), 20*time.Millisecond, time.Minute)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) ([]byte, error) {
+		if loads.Add(1) > 1 {
+			<-release
+		}
+		return []byte(fmt.Sprintf("v%d", loads.Load())), nil
+	}
+
+	value, err := swr.Fetch(ctx, "popular", load)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(value))
+
+	time.Sleep(30 * time.Millisecond)
+	// Past the TTL the stale value is served at once, with one refresh
+	for i := 0; i < 3; i++ {
+		value, err = swr.Fetch(ctx, "popular", load)
+		require.NoError(t, err)
+		assert.Equal(t, "v1", string(value))
+	}
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		value, err := swr.Fetch(ctx, "popular", load)
+		return err == nil && string(value) == "v2"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(2), loads.Load())
+}
+
+func TestStaleWhileRevalidate_FailedRefreshDropsStaleValue(t *testing.T) {
+	ctx := context.Background()
+	swr := cache.NewStaleWhileRevalidate(newTestCache(t), 20*time.Millisecond, time.Minute)
+	refreshErrors := make(chan error, 1)
+	swr.OnError = func(ctx context.Context, key string, err error) { refreshErrors <- err }
+
+	_, err := swr.Fetch(ctx, "deleted", func(context.Context) ([]byte, error) { return []byte("v1"), nil })
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+
+	gone := errors.New("gone")
+	failing := func(context.Context) ([]byte, error) { return nil, gone }
+	value, err := swr.Fetch(ctx, "deleted", failing)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(value))
+	assert.ErrorIs(t, <-refreshErrors, gone)
+
+	_, err = swr.Fetch(ctx, "deleted", failing)
+	assert.ErrorIs(t, err, gone)
+}
+
+func TestStaleWhileRevalidate_ReloadsPastStaleWindow(t *testing.T) {
+	ctx := context.Background()
+	swr := cache.NewStaleWhileRevalidate(newTestCache(t), 10*time.Millisecond, 10*time.Millisecond)
+
+	_, err := swr.Fetch(ctx, "idle", func(context.Context) ([]byte, error) { return []byte("v1"), nil })
+	require.NoError(t, err)
+	time.Sleep(40 * time.Millisecond)
+
+	value, err := swr.Fetch(ctx, "idle", func(context.Context) ([]byte, error) { return []byte("v2"), nil })
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(value))
+}
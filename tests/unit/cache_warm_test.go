@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheWarmer_PrimesClaimsOfRecentlyActiveUsers(t *testing.T) {
+	repo := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := repo.Create(ctx, &models.CreateUserRequest{Name: "John Doe", Email: fmt.Sprintf("john%d@example.com", i), Age: 30})
+		require.NoError(t, err)
+	}
+	claims := services.NewUserClaimsLoader(repo, newTestCache(t), time.Minute, 0)
+	warmer := services.NewCacheWarmer(repo, claims)
+
+	result, err := warmer.Warm(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Users)
+
+	// The two newest users are cached; the oldest is read on first use
+	for _, id := range []int{3, 2, 1} {
+		loaded, err := claims.Load(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("john%d@example.com", id-1), loaded.Email)
+	}
+	assert.Equal(t, 1, repo.lookups)
+
+	_, err = warmer.Warm(ctx, 0)
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+}
+
+func TestCacheHandler_WarmCache(t *testing.T) {
+	repo := NewMockUserRepository()
+	_, err := repo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	claims := services.NewUserClaimsLoader(repo, newTestCache(t), time.Minute, 0)
+	handler := handlers.NewCacheHandler(services.NewCacheWarmer(repo, claims), 1000)
+
+	rr := httptest.NewRecorder()
+	handler.WarmCache(rr, httptest.NewRequest("POST", "/api/v1/admin/cache/warm", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"users":1`)
+
+	for _, limit := range []string{"many", "0", "10001"} {
+		rr = httptest.NewRecorder()
+		handler.WarmCache(rr, httptest.NewRequest("POST", "/api/v1/admin/cache/warm?limit="+limit, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, limit)
+	}
+}
@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getChangelog calls the changelog handler and decodes its response
+func getChangelog(t *testing.T, query string) (int, models.ChangelogResponse) {
+	req := httptest.NewRequest("GET", "/api/v1/changelog"+query, nil)
+	rr := httptest.NewRecorder()
+	handlers.NewChangelogHandler().GetChangelog(rr, req)
+
+	var response struct {
+		Data models.ChangelogResponse `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	return rr.Code, response.Data
+}
+
+func TestChangelog_ListsEntriesNewestFirst(t *testing.T) {
+	code, changelog := getChangelog(t, "")
+
+	assert.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, changelog.Entries)
+	assert.Equal(t, changelog.CurrentVersion, changelog.Entries[0].Version)
+}
+
+func TestChangelog_FiltersBySinceAndType(t *testing.T) {
+	_, all := getChangelog(t, "")
+	oldest := all.Entries[len(all.Entries)-1].Version
+
+	_, since := getChangelog(t, "?since="+oldest)
+	assert.Len(t, since.Entries, len(all.Entries)-1)
+
+	_, breaking := getChangelog(t, "?type=changed")
+	for _, entry := range breaking.Entries {
+		for _, change := range entry.Changes {
+			assert.Equal(t, models.ChangeChanged, change.Type)
+		}
+	}
+
+	code, _ := getChangelog(t, "?since=latest")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveCompressed runs a handler writing body as contentType through the
+// compression middleware
+func serveCompressed(t *testing.T, acceptEncoding, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := middleware.CompressionMiddleware(100, true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", "999")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, body)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCompressionMiddleware_Negotiates(t *testing.T) {
+	body := `{"users":[` + strings.Repeat(`{"name":"Ada"},`, 50) + `{}]}`
+
+	rr := serveCompressed(t, "gzip, deflate", "application/json", body)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	assert.Empty(t, rr.Header().Get("Content-Length"))
+	assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+	reader, err := gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+
+	rr = serveCompressed(t, "gzip;q=0.5, br", "application/json", body)
+	assert.Equal(t, "br", rr.Header().Get("Content-Encoding"))
+	decoded, err = io.ReadAll(brotli.NewReader(rr.Body))
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+
+	rr = serveCompressed(t, "br;q=0, gzip;q=0", "application/json", body)
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, rr.Body.String())
+}
+
+func TestCompressionMiddleware_SkipsSmallAndCompressedResponses(t *testing.T) {
+	large := strings.Repeat("x", 500)
+
+	tests := map[string]struct {
+		contentType, body string
+	}{
+		"small":           {"application/json", `{"ok":true}`},
+		"image":           {"image/png", large},
+		"no content type": {"", large},
+	}
+	for name, tt := range tests {
+		rr := serveCompressed(t, "gzip", tt.contentType, tt.body)
+		assert.Empty(t, rr.Header().Get("Content-Encoding"), name)
+		assert.Equal(t, http.StatusCreated, rr.Code, name)
+		assert.Equal(t, tt.body, rr.Body.String(), name)
+	}
+}
+
+func TestCompressionMiddleware_CompressesFlushedStreams(t *testing.T) {
+	handler := middleware.CompressionMiddleware(1024, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		io.WriteString(w, "{\"id\":1}\n")
+		require.NoError(t, http.NewResponseController(w).Flush())
+		io.WriteString(w, "{\"id\":2}\n")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/export", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.True(t, rr.Flushed)
+	require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	reader, err := gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", string(decoded))
+}
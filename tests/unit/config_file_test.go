@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFile writes a config file named name with content to a
+// temporary directory
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadFrom_Layers(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+port: 9000
+cors:
+  allowed_origins:
+    - https://app.example.com
+    - https://*.example.org
+  max_age: 1h
+rate_limit:
+  rps: 2.5
+  burst: 5
+LOG_LEVEL: warn
+`)
+	t.Setenv("PORT", "9100")
+	t.Setenv("RATE_LIMIT_BURST", "7")
+
+	cfg, err := config.LoadFrom(config.Sources{
+		File:      path,
+		Overrides: map[string]string{"port": "9200"},
+	})
+	require.NoError(t, err)
+
+	// Overrides win over the environment, which wins over the file
+	assert.Equal(t, "9200", cfg.Server.Port)
+	assert.Equal(t, 7, cfg.Limits.Burst)
+	assert.Equal(t, 2.5, cfg.Limits.RequestsPerSecond)
+	assert.Equal(t, []string{"https://app.example.com", "https://*.example.org"}, cfg.CORS.AllowedOrigins)
+	assert.Equal(t, time.Hour, cfg.CORS.MaxAge)
+	assert.Equal(t, "warn", cfg.Logging.Level)
+	// Unset settings keep their defaults
+	assert.Equal(t, config.Load().CORS.AllowedMethods, cfg.CORS.AllowedMethods)
+}
+
+func TestLoadFrom_RejectsBadFiles(t *testing.T) {
+	for name, content := range map[string]string{
+		"misspelled": "cors:\n  alowed_origins: [https://app.example.com]\n",
+		"twice":      "cors:\n  max_age: 1h\nCORS_MAX_AGE: 2h\n",
+		"nested":     "cors:\n  allowed_origins:\n    - {origin: https://app.example.com}\n",
+		"malformed":  "port: [9000\n",
+	} {
+		_, err := config.LoadFrom(config.Sources{File: writeConfigFile(t, "config.yaml", content)})
+		assert.Error(t, err, name)
+	}
+
+	_, err := config.LoadFrom(config.Sources{File: writeConfigFile(t, "config.toml", "port = 9000\n")})
+	assert.Error(t, err)
+	_, err = config.LoadFrom(config.Sources{File: filepath.Join(t.TempDir(), "missing.yaml")})
+	assert.Error(t, err)
+	_, err = config.LoadFrom(config.Sources{Overrides: map[string]string{"PROT": "9000"}})
+	assert.Error(t, err)
+}
+
+func TestLoadFrom_DefaultFileIsOptional(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+	// Restored once the test ends
+	t.Setenv("PORT", "")
+	os.Unsetenv("PORT")
+
+	cfg, err := config.LoadFrom(config.Sources{})
+	require.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Server.Port)
+
+	require.NoError(t, os.WriteFile(config.DefaultFile, []byte("port: 9300\n"), 0o600))
+	cfg, err = config.LoadFrom(config.Sources{})
+	require.NoError(t, err)
+	assert.Equal(t, "9300", cfg.Server.Port)
+}
@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns defaults a release deployment could start with
+func validConfig() *config.Config {
+	cfg := config.Load()
+	cfg.Server.Mode = "release"
+	cfg.Server.Port = "8080"
+	cfg.JWT.Secret = "a-secret-of-our-own"
+	cfg.Database.Driver = "postgres"
+	cfg.Database.Port = "5432"
+	cfg.Database.Password = "password"
+	return cfg
+}
+
+func TestConfigValidate_Defaults(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+}
+
+func TestConfigValidate_ReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Port = "80a"
+	cfg.JWT.Secret = ""
+	cfg.Database.Password = ""
+	cfg.Database.API.MaxIdleConns = cfg.Database.API.MaxOpenConns + 1
+	cfg.Database.Jobs.MaxOpenConns = 0
+	cfg.Server.RequestTimeout = 20 * time.Second
+	cfg.Server.WriteTimeout = 15 * time.Second
+
+	var invalid *config.ValidationError
+	require.True(t, errors.As(cfg.Validate(), &invalid))
+	assert.Equal(t, []string{
+		`PORT "80a" is not a port number between 1 and 65535`,
+		"REQUEST_TIMEOUT (20s) must be below SERVER_WRITE_TIMEOUT (15s), so timed out requests can still be answered",
+		"JWT_SECRET must be set to a secret of your own outside GIN_MODE=debug",
+		"DB_PASSWORD must be set outside GIN_MODE=debug",
+		"DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS (25), got 26",
+		"DB_JOBS_MAX_OPEN_CONNS must be at least 1, got 0",
+		"DB_JOBS_MAX_IDLE_CONNS must be between 0 and DB_JOBS_MAX_OPEN_CONNS (0), got 2",
+	}, invalid.Problems)
+}
+
+func TestConfigValidate_ReportsSettingsCheckedAtStartup(t *testing.T) {
+	cfg := validConfig()
+	cfg.IDs.Codec = "base99"
+	cfg.Cache.Driver = "disk"
+	cfg.Database.Driver = "mysql"
+	cfg.Audit.Enabled = true
+	cfg.Webhooks.Enabled = true
+	cfg.Memory.Threshold = 1.5
+	cfg.Paging.Routes = []string{"widgets=5:10"}
+
+	var invalid *config.ValidationError
+	require.True(t, errors.As(cfg.Validate(), &invalid))
+	require.Len(t, invalid.Problems, 5)
+	assert.Equal(t, "AUDIT_ENABLED, WEBHOOKS_ENABLED need DB_DRIVER=postgres", invalid.Problems[0])
+	assert.Contains(t, invalid.Problems[1], "ID_CODEC")
+	assert.Contains(t, invalid.Problems[2], `pagination: unknown paginated route "widgets"`)
+	assert.Contains(t, invalid.Problems[3], "CACHE_DRIVER")
+	assert.Equal(t, "MEMORY_PRESSURE_THRESHOLD must be above 0 and at most 1, got 1.5", invalid.Problems[4])
+}
+
+func TestConfigValidate_DebugMode(t *testing.T) {
+	// Local development can run with the example secrets
+	cfg := validConfig()
+	cfg.Server.Mode = "debug"
+	cfg.JWT.Secret = "your-secret-key"
+	cfg.Database.Password = ""
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Server.Mode = "release"
+	assert.Error(t, cfg.Validate())
+
+	// SQLite has neither a port nor a password
+	cfg.JWT.Secret = "a-secret-of-our-own"
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.Port = ""
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pratham15541/go-crud/internal/consistency"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseToken(t *testing.T) {
+	token, err := consistency.ParseToken("16/B374D848")
+	require.NoError(t, err)
+	assert.Equal(t, consistency.Token(0x16B374D848), token)
+	assert.Equal(t, "16/B374D848", token.String())
+
+	for _, invalid := range []string{"", "16", "16/", "x/1", "1/100000000"} {
+		_, err := consistency.ParseToken(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// fixedTracker issues token, or fails with err
+type fixedTracker struct {
+	token consistency.Token
+	err   error
+}
+
+func (f fixedTracker) Current(ctx context.Context) (consistency.Token, error) {
+	return f.token, f.err
+}
+
+func TestConsistencyMiddleware(t *testing.T) {
+	var presented consistency.Token
+	var status int
+	handler := middleware.ConsistencyMiddleware(fixedTracker{token: 0x2A0000FF})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented, _ = consistency.FromContext(r.Context())
+		w.WriteHeader(status)
+	}))
+
+	tests := []struct {
+		method string
+		status int
+		token  string
+	}{
+		{http.MethodPost, http.StatusCreated, "0/2A0000FF"},
+		{http.MethodDelete, http.StatusNoContent, "0/2A0000FF"},
+		{http.MethodPut, http.StatusConflict, ""},
+		{http.MethodGet, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		status = tt.status
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(tt.method, "/api/v1/users", nil))
+		assert.Equal(t, tt.status, rr.Code, tt.method)
+		assert.Equal(t, tt.token, rr.Header().Get(consistency.Header), tt.method)
+	}
+
+	// Reads carry the token they present
+	status = http.StatusOK
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+	req.Header.Set(consistency.Header, "1/0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, consistency.Token(1<<32), presented)
+
+	req.Header.Set(consistency.Header, "latest")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestConsistencyMiddleware_WriteSucceedsWithoutToken(t *testing.T) {
+	handler := middleware.ConsistencyMiddleware(fixedTracker{err: errors.New("primary gone")})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/users", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get(consistency.Header))
+}
+
+func TestUserRepository_ReadsFollowConsistencyToken(t *testing.T) {
+	const count = `SELECT COUNT\(\*\) FROM users`
+	const replayed = `SELECT COALESCE\(pg_last_wal_replay_lsn\(\), pg_current_wal_lsn\(\)\) >= \$1::pg_lsn`
+
+	tests := []struct {
+		name    string
+		token   string
+		reached bool
+		primary bool
+	}{
+		{name: "no token", primary: false},
+		{name: "replica caught up", token: "0/100", reached: true, primary: false},
+		{name: "replica behind", token: "0/200", reached: false, primary: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, primaryMock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer primary.Close()
+			replica, replicaMock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer replica.Close()
+
+			ctx := context.Background()
+			if tt.token != "" {
+				token, err := consistency.ParseToken(tt.token)
+				require.NoError(t, err)
+				ctx = consistency.WithToken(ctx, token)
+				replicaMock.ExpectQuery(replayed).WithArgs(tt.token).
+					WillReturnRows(sqlmock.NewRows([]string{"reached"}).AddRow(tt.reached))
+			}
+			served := replicaMock
+			if tt.primary {
+				served = primaryMock
+			}
+			served.ExpectQuery(count).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+			repo := repository.NewUserRepositoryWithReplica(primary, replica, func() bool { return true }, repository.UserSchema{})
+			n, err := repo.Count(ctx, &models.UserFilter{})
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), n)
+			assert.NoError(t, primaryMock.ExpectationsWereMet())
+			assert.NoError(t, replicaMock.ExpectationsWereMet())
+		})
+	}
+}
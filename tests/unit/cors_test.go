@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveCORS runs a request from origin through the CORS middleware with cfg,
+// as a preflight for POST if preflight is set
+func serveCORS(cfg config.CORSConfig, origin string, preflight bool) *httptest.ResponseRecorder {
+	handler := middleware.CORSMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	if preflight {
+		req = httptest.NewRequest(http.MethodOptions, "/api/v1/users", nil)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func testCORSConfig() config.CORSConfig {
+	return config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         time.Hour,
+	}
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	cfg := testCORSConfig()
+
+	rr := serveCORS(cfg, "https://app.example.com", true)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Authorization", rr.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "3600", rr.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, rr.Header().Values("Vary"), "Origin")
+
+	rr = serveCORS(cfg, "https://evil.example.com", true)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSMiddleware_WildcardSubdomains(t *testing.T) {
+	cfg := testCORSConfig()
+	for origin, allowed := range map[string]bool{
+		"https://eu.example.org":      true,
+		"https://api.eu.EXAMPLE.org":  true,
+		"https://example.org":         false,
+		"https://evilexample.org":     false,
+		"http://eu.example.org":       false,
+		"https://eu.example.org:8443": false,
+		"https://eu.example.org.evil": false,
+	} {
+		rr := serveCORS(cfg, origin, true)
+		if allowed {
+			assert.Equal(t, http.StatusNoContent, rr.Code, origin)
+			assert.Equal(t, origin, rr.Header().Get("Access-Control-Allow-Origin"), origin)
+		} else {
+			assert.Equal(t, http.StatusForbidden, rr.Code, origin)
+		}
+	}
+}
+
+func TestCORSMiddleware_ActualRequests(t *testing.T) {
+	cfg := testCORSConfig()
+
+	rr := serveCORS(cfg, "https://app.example.com", false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-ID", rr.Header().Get("Access-Control-Expose-Headers"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Served, but without the headers that let the browser read it
+	rr = serveCORS(cfg, "https://evil.example.com", false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	rr = serveCORS(cfg, "", false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	cfg.AllowedOrigins = []string{"*"}
+	rr = serveCORS(cfg, "https://anywhere.test", false)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Values("Vary"))
+
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.AllowCredentials = true
+	rr = serveCORS(cfg, "https://app.example.com", false)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSConfig_Validate(t *testing.T) {
+	cfg := testCORSConfig()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.AllowedOrigins = []string{"*"}
+	assert.NoError(t, cfg.Validate())
+	cfg.AllowCredentials = true
+	assert.Error(t, cfg.Validate())
+
+	for _, origin := range []string{"app.example.com", "https://app.example.com/", "https://api.*.example.com", "ftp://example.com", "https://*"} {
+		cfg.AllowedOrigins = []string{origin}
+		assert.Error(t, cfg.Validate(), origin)
+	}
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/metrics"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard_Snapshot(t *testing.T) {
+	m := metrics.New()
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(10)
+	require.NoError(t, m.RegisterDB("api", db))
+
+	dashboard := metrics.NewDashboard(m, time.Minute)
+	for i := 0; i < 19; i++ {
+		m.Observe("GET", "/api/v1/users", http.StatusOK, 100, 10*time.Millisecond)
+	}
+	m.Observe("POST", "/api/v1/users", http.StatusInternalServerError, 100, 2*time.Second)
+	m.SeeUser("1")
+	m.SeeUser("2")
+	m.SeeUser("1")
+
+	snapshot := dashboard.Snapshot()
+	assert.Greater(t, snapshot.RequestsPerSecond, 0.0)
+	assert.InDelta(t, 0.05, snapshot.ErrorRate, 1e-9)
+	// The 19th of 20 requests falls at the top of the 5-10ms bucket
+	assert.InDelta(t, 10, snapshot.P95LatencyMs, 1e-9)
+	assert.Equal(t, 2, snapshot.ActiveUsers)
+	require.Len(t, snapshot.Pools, 1)
+	assert.Equal(t, "api", snapshot.Pools[0].Name)
+	assert.Equal(t, 10, snapshot.Pools[0].MaxOpen)
+}
+
+func TestDashboard_EmptyWindow(t *testing.T) {
+	snapshot := metrics.NewDashboard(metrics.New(), time.Minute).Snapshot()
+	assert.Zero(t, snapshot.ErrorRate)
+	assert.Zero(t, snapshot.P95LatencyMs)
+	assert.Zero(t, snapshot.ActiveUsers)
+	assert.Empty(t, snapshot.Pools)
+}
+
+func TestRouter_DashboardRequiresAdmin(t *testing.T) {
+	cfg := config.Load()
+	m := metrics.New()
+	r := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Metrics:       m,
+		Dashboard:     handlers.NewDashboardHandler(metrics.NewDashboard(m, time.Minute)),
+	})
+	handler := r.Handler()
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/dashboard", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7"}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/admin/dashboard", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin"}))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data models.Dashboard `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	// Both callers made authenticated requests
+	assert.Equal(t, 2, response.Data.ActiveUsers)
+	assert.Equal(t, 1, response.Data.InFlight)
+}
@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name: "bind parameters and whitespace",
+			query: `
+				SELECT id, name
+				FROM users
+				WHERE id = $1 AND deleted_at IS NULL
+			`,
+			expected: "SELECT id, name FROM users WHERE id = ? AND deleted_at IS NULL",
+		},
+		{
+			name:     "literals",
+			query:    "SELECT * FROM users WHERE name = 'O''Brien' AND age > 30 LIMIT 10",
+			expected: "SELECT * FROM users WHERE name = ? AND age > ? LIMIT ?",
+		},
+		{
+			name:     "lists",
+			query:    "SELECT * FROM users WHERE id IN ($1, $2, $3)",
+			expected: "SELECT * FROM users WHERE id IN (?)",
+		},
+		{
+			name:     "identifiers with digits are kept",
+			query:    "FETCH FORWARD 500 FROM user_export2",
+			expected: "FETCH FORWARD ? FROM user_export2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, database.Fingerprint(tt.query))
+		})
+	}
+
+	// Differently sized batches share one fingerprint
+	assert.Equal(t,
+		database.Fingerprint("FETCH FORWARD 100 FROM user_export"),
+		database.Fingerprint("FETCH FORWARD 500 FROM user_export"))
+}
+
+func TestStatementName(t *testing.T) {
+	tests := map[string]string{
+		"SELECT id, name FROM users WHERE id = $1":         "SELECT users",
+		"\n\t\tINSERT INTO audit_log (action) VALUES ($1)": "INSERT audit_log",
+		"UPDATE users SET legal_hold = $1 WHERE id = $2":   "UPDATE users",
+		"DELETE FROM users WHERE deleted_at < $1":          "DELETE users",
+		"select count(*) from users":                       "SELECT users",
+		"FETCH FORWARD 500 FROM user_export":               "FETCH user_export",
+		"SELECT pg_advisory_xact_lock($1)":                 "SELECT",
+		"":                                                 "SQL",
+	}
+
+	for query, expected := range tests {
+		assert.Equal(t, expected, database.StatementName(query), query)
+	}
+}
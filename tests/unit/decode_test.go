@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendUserBody posts body to /users through a router capping bodies at
+// maxBody bytes; a negative length hides the body's size
+func sendUserBody(t *testing.T, maxBody int64, body string, length int64) *httptest.ResponseRecorder {
+	t.Helper()
+	cfg := config.Load()
+	cfg.Server.MaxBodyBytes = maxBody
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+	}).Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+	req.ContentLength = length
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestDecodeJSON_RejectsMalformedBodies(t *testing.T) {
+	tests := map[string]struct {
+		body, field, message string
+	}{
+		"unknown field":  {`{"name":"Ada","email":"ada@example.com","age":30,"nickname":"A"}`, "nickname", `Unknown field "nickname"`},
+		"wrong type":     {`{"name":"Ada","email":"ada@example.com","age":"thirty"}`, "age", `Field "age" cannot be a JSON string`},
+		"trailing value": {`{"name":"Ada","email":"ada@example.com","age":30} {}`, "", "Request body must hold a single JSON value"},
+		"not JSON":       {`name=Ada`, "", "Invalid JSON payload"},
+	}
+	for name, tt := range tests {
+		rr := sendUserBody(t, 1<<20, tt.body, int64(len(tt.body)))
+		require.Equal(t, http.StatusBadRequest, rr.Code, name)
+
+		var resp models.ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp), name)
+		assert.Equal(t, tt.message, resp.Message, name)
+		if tt.field != "" {
+			require.Len(t, resp.Errors, 1, name)
+			assert.Equal(t, tt.field, resp.Errors[0].Field, name)
+		}
+	}
+
+	valid := `{"name":"Ada","email":"ada@example.com","age":30}`
+	rr := sendUserBody(t, 1<<20, valid, int64(len(valid)))
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+}
+
+func TestBodyLimit_RejectsOversizedBodies(t *testing.T) {
+	body := `{"name":"` + strings.Repeat("a", 200) + `","email":"ada@example.com","age":30}`
+
+	// Content-Length is checked before the handler runs
+	rr := sendUserBody(t, 64, body, int64(len(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Request body exceeds 64 bytes")
+
+	// Bodies of unknown length fail once the decoder reads past the limit
+	rr = sendUserBody(t, 64, body, -1)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Request body exceeds 64 bytes")
+}
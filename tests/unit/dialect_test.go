@@ -0,0 +1,254 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mysqlUsers describes users stored in MySQL
+var mysqlUsers = repository.UserSchema{Dialect: repository.DialectMySQL}
+
+func userRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "email", "age", "role", "created_at", "updated_at", "version"})
+}
+
+func TestUserRepository_MySQLCreateReadsBackInsertedRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO users \(name, email, age\)\s+VALUES \(\?, \?, \?\)$`).
+		WithArgs("Ada", "ada@example.com", 36).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users WHERE id = ?")).
+		WithArgs(7).
+		WillReturnRows(userRows().AddRow(7, "Ada", "ada@example.com", 36, "user", now, now, 1))
+	mock.ExpectCommit()
+
+	repo := repository.NewUserRepository(db, mysqlUsers)
+	user, err := repo.Create(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+	assert.Equal(t, 7, user.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_MySQLUpsertReadsBackUpdatedRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), name = ?")).
+		WithArgs("Ada", "ada@example.com", 36, "Ada", 36).
+		WillReturnResult(sqlmock.NewResult(7, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users WHERE id = ?")).
+		WithArgs(7).
+		WillReturnRows(userRows().AddRow(7, "Ada", "ada@example.com", 36, "user", now, now, 4))
+	mock.ExpectCommit()
+
+	repo := repository.NewUserRepository(db, mysqlUsers)
+	user, created, err := repo.Upsert(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+	assert.Equal(t, 7, user.ID)
+	assert.False(t, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_MySQLPatchMissingUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	name := "Ada"
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id = ? AND deleted_at IS NULL")).
+		WithArgs("Ada", nil, nil, 9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	repo := repository.NewUserRepository(db, mysqlUsers)
+	_, err = repo.Patch(context.Background(), 9, &models.PatchUserRequest{Name: &name})
+	require.Error(t, err)
+	assert.Equal(t, "user not found", err.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_MySQLPatchAtStaleVersionConflicts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	name, version := "Ada", 3
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id = ? AND deleted_at IS NULL AND version = ?")).
+		WithArgs("Ada", nil, nil, 9, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT TRUE FROM users WHERE id = ?")).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"true"}).AddRow(true))
+
+	repo := repository.NewUserRepository(db, mysqlUsers)
+	_, err = repo.Patch(context.Background(), 9, &models.PatchUserRequest{Name: &name, Version: &version})
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdateChangedSinceReadConflicts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id, name, email, age, role, created_at, updated_at, legal_hold, version`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "age", "role", "created_at", "updated_at", "legal_hold", "version"}).
+			AddRow(4, "Ada", "ada@example.com", 36, "user", now, now, false, 2))
+	// Another request updates the user between the read and the write
+	mock.ExpectQuery(`UPDATE users\s+SET .*version = version \+ 1\s+WHERE id = \$5 AND version = \$6`).
+		WithArgs("Grace", "ada@example.com", 36, sqlmock.AnyArg(), 4, 2).
+		WillReturnRows(userRows())
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT TRUE FROM users WHERE id = $1")).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"true"}).AddRow(true))
+
+	repo := repository.NewUserRepository(db, repository.UserSchema{})
+	_, err = repo.Update(context.Background(), 4, &models.UpdateUserRequest{Name: "Grace"})
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_MySQLFilterRebindsPlaceholders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// $1 and $2 are the limit and offset but follow the filter in the SQL
+	mock.ExpectQuery(`WHERE deleted_at IS NULL AND name LIKE \?\s+ORDER BY created_at DESC, id DESC\s+LIMIT \? OFFSET \?`).
+		WithArgs("%a\\_b%", 10, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "age", "role", "created_at", "updated_at", "deleted_at", "version"}))
+
+	repo := repository.NewUserRepository(db, mysqlUsers)
+	_, err = repo.GetAll(context.Background(), &models.UserFilter{Name: "a_b"}, 10, 20)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_RestoreRejectsTakenEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Two restores of the same email can both pass the NOT EXISTS check;
+	// the unique index rejects the second
+	mock.ExpectQuery(`UPDATE users u\s+SET deleted_at = NULL`).
+		WithArgs(4).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	repo := repository.NewUserRepository(db, repository.UserSchema{})
+	_, err = repo.Restore(context.Background(), 4)
+	require.Error(t, err)
+	assert.Equal(t, "email is in use by another user", err.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_CreateRaceReturnsDuplicateEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`INSERT INTO users \(name, email, age\)`).
+		WithArgs("Ada", "ada@example.com", 36).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	repo := repository.NewUserRepository(db, repository.UserSchema{})
+	_, err = repo.Create(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrDuplicateEmail)
+	assert.Equal(t, "user with email ada@example.com already exists", err.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_AgeCheckViolationReturnsInvalidAge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The validator allows 150 but the column's CHECK stops at 149
+	mock.ExpectQuery(`INSERT INTO users \(name, email, age\)`).
+		WithArgs("Ada", "ada@example.com", 150).
+		WillReturnError(&pgconn.PgError{Code: "23514", ConstraintName: "users_age_check"})
+
+	repo := repository.NewUserRepository(db, repository.UserSchema{})
+	_, err = repo.Create(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 150})
+	assert.ErrorIs(t, err, models.ErrInvalidAge)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_AgePhaseSelectsColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`INSERT INTO users \(name, email, age\)\s+VALUES \(\$1, \$2, \$3\)\s+RETURNING id, name, email, age,`).
+		WithArgs("Ada", "ada@example.com", 36).
+		WillReturnRows(userRows().AddRow(1, "Ada", "ada@example.com", 36, "user", now, now, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (name, email, age, date_of_birth)")).
+		WithArgs("Bob", "bob@example.com", 40).
+		WillReturnRows(userRows().AddRow(2, "Bob", "bob@example.com", 40, "user", now, now, 1))
+
+	// Repositories in different phases share the database
+	old := repository.NewUserRepository(db, repository.UserSchema{})
+	dual := repository.NewUserRepository(db, repository.UserSchema{AgePhase: models.PhaseDualWrite})
+	_, err = old.Create(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+	_, err = dual.Create(context.Background(), &models.CreateUserRequest{Name: "Bob", Email: "bob@example.com", Age: 40})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_CreateBatchRollsBackFailedItemsAlone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT bulk_user").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO users`).
+		WithArgs("Ada", "ada@example.com", 36).
+		WillReturnRows(userRows().AddRow(1, "Ada", "ada@example.com", 36, "user", now, now, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT bulk_user").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT bulk_user").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO users`).
+		WithArgs("Ada", "ada@example.com", 37).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_user").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT bulk_user").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	repo := repository.NewUserRepository(db, repository.UserSchema{})
+	users, errs, err := repo.CreateBatch(context.Background(), []*models.CreateUserRequest{
+		{Name: "Ada", Email: "ada@example.com", Age: 36},
+		{Name: "Ada", Email: "ada@example.com", Age: 37},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, users[0].ID)
+	assert.NoError(t, errs[0])
+	assert.Nil(t, users[1])
+	assert.ErrorIs(t, errs[1], models.ErrDuplicateEmail)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
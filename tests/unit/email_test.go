@@ -0,0 +1,419 @@
+package unit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/mailer"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEmailRepository implements EmailRepository interface for testing
+type MockEmailRepository struct {
+	emails       []*models.Email
+	suppressions map[string]*models.EmailSuppression
+}
+
+func (m *MockEmailRepository) Enqueue(ctx context.Context, email *models.Email) error {
+	email.ID = int64(len(m.emails) + 1)
+	email.Status = models.EmailQueued
+	email.NextAttemptAt = time.Now()
+	m.emails = append(m.emails, email)
+	return nil
+}
+
+func (m *MockEmailRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.Email, error) {
+	var due []*models.Email
+	for _, email := range m.emails {
+		if email.Status == models.EmailQueued && !email.NextAttemptAt.After(time.Now()) && len(due) < limit {
+			email.Attempts++
+			email.NextAttemptAt = time.Now().Add(lease)
+			due = append(due, email)
+		}
+	}
+	return due, nil
+}
+
+func (m *MockEmailRepository) MarkSent(ctx context.Context, id int64) error {
+	m.emails[id-1].Status = models.EmailSent
+	return nil
+}
+
+func (m *MockEmailRepository) MarkRetry(ctx context.Context, id int64, lastError string, next time.Time) error {
+	m.emails[id-1].LastError = lastError
+	m.emails[id-1].NextAttemptAt = next
+	return nil
+}
+
+func (m *MockEmailRepository) MarkFailed(ctx context.Context, id int64, lastError string) error {
+	m.emails[id-1].Status = models.EmailFailed
+	m.emails[id-1].LastError = lastError
+	return nil
+}
+
+func (m *MockEmailRepository) MarkSuppressed(ctx context.Context, id int64, reason string) error {
+	m.emails[id-1].Status = models.EmailSuppressed
+	m.emails[id-1].LastError = reason
+	return nil
+}
+
+func (m *MockEmailRepository) ApplyEvent(ctx context.Context, event models.EmailEvent) (*models.Email, error) {
+	for _, email := range m.emails {
+		if email.MessageID == event.MessageID && (email.Status == models.EmailSent || email.Status == models.EmailDelivered) {
+			email.Status = event.Type
+			email.LastError = event.Reason
+			return email, nil
+		}
+	}
+	return nil, apperrors.NotFound("email not found")
+}
+
+func (m *MockEmailRepository) List(ctx context.Context, status string, beforeID int64, limit int) ([]*models.Email, error) {
+	var emails []*models.Email
+	for i := len(m.emails) - 1; i >= 0 && len(emails) < limit; i-- {
+		email := m.emails[i]
+		if email.ID < beforeID && (status == "" || email.Status == status) {
+			emails = append(emails, email)
+		}
+	}
+	return emails, nil
+}
+
+func (m *MockEmailRepository) Suppress(ctx context.Context, suppression *models.EmailSuppression) error {
+	if m.suppressions == nil {
+		m.suppressions = make(map[string]*models.EmailSuppression)
+	}
+	suppression.Email = strings.ToLower(suppression.Email)
+	suppression.CreatedAt = time.Now()
+	if existing, ok := m.suppressions[suppression.Email]; ok {
+		suppression.CreatedAt = existing.CreatedAt
+	}
+	stored := *suppression
+	m.suppressions[suppression.Email] = &stored
+	return nil
+}
+
+func (m *MockEmailRepository) GetSuppression(ctx context.Context, address string) (*models.EmailSuppression, error) {
+	if suppression, ok := m.suppressions[strings.ToLower(address)]; ok {
+		return suppression, nil
+	}
+	return nil, apperrors.NotFound("suppression not found")
+}
+
+func (m *MockEmailRepository) RequeueFailed(ctx context.Context) (int64, error) {
+	var n int64
+	for _, email := range m.emails {
+		if email.Status == models.EmailFailed {
+			email.Status = models.EmailQueued
+			email.Attempts = 0
+			email.NextAttemptAt = time.Now()
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakeSender records sent emails and fails with err when set
+type fakeSender struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (s *fakeSender) Send(ctx context.Context, msg mailer.Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func testEmailConfig() config.EmailConfig {
+	return config.EmailConfig{
+		Enabled:       true,
+		From:          "no-reply@example.com",
+		WebhookSecret: "webhook-secret",
+		MaxAttempts:   3,
+		RetryBackoff:  time.Minute,
+	}
+}
+
+func signWebhook(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestEmailService_DeliversQueuedEmail(t *testing.T) {
+	repo := &MockEmailRepository{}
+	sender := &fakeSender{}
+	emailService := services.NewEmailService(repo, sender, testEmailConfig(), services.DefaultPagination())
+
+	queued, err := emailService.Queue(context.Background(), "john@example.com", "Hello", "Body")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(queued.MessageID, "@example.com>"))
+
+	sent, err := emailService.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "no-reply@example.com", sender.sent[0].From)
+	assert.Equal(t, queued.MessageID, sender.sent[0].MessageID)
+	assert.Equal(t, models.EmailSent, repo.emails[0].Status)
+}
+
+func TestEmailService_RetriesTransientFailures(t *testing.T) {
+	repo := &MockEmailRepository{}
+	sender := &fakeSender{err: fmt.Errorf("connection refused")}
+	emailService := services.NewEmailService(repo, sender, testEmailConfig(), services.DefaultPagination())
+
+	_, err := emailService.Queue(context.Background(), "john@example.com", "Hello", "Body")
+	require.NoError(t, err)
+
+	before := time.Now()
+	_, err = emailService.DeliverDue(context.Background())
+	require.NoError(t, err)
+	email := repo.emails[0]
+	assert.Equal(t, models.EmailQueued, email.Status)
+	assert.Equal(t, "connection refused", email.LastError)
+	assert.WithinDuration(t, before.Add(time.Minute), email.NextAttemptAt, 5*time.Second)
+
+	// The second failure waits twice as long
+	email.NextAttemptAt = time.Now()
+	_, err = emailService.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), email.NextAttemptAt, 5*time.Second)
+
+	// The last attempt fails the email
+	email.NextAttemptAt = time.Now()
+	_, err = emailService.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, email.Attempts)
+	assert.Equal(t, models.EmailFailed, email.Status)
+}
+
+func TestEmailService_PermanentFailureIsNotRetried(t *testing.T) {
+	repo := &MockEmailRepository{}
+	sender := &fakeSender{err: &mailer.PermanentError{Err: fmt.Errorf("550 no such user")}}
+	emailService := services.NewEmailService(repo, sender, testEmailConfig(), services.DefaultPagination())
+
+	_, err := emailService.Queue(context.Background(), "nobody@example.com", "Hello", "Body")
+	require.NoError(t, err)
+	_, err = emailService.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, repo.emails[0].Attempts)
+	assert.Equal(t, models.EmailFailed, repo.emails[0].Status)
+	assert.Equal(t, "550 no such user", repo.emails[0].LastError)
+}
+
+func TestAuthService_RegisterQueuesWelcomeEmail(t *testing.T) {
+	emailRepo := &MockEmailRepository{}
+	transactions := &fakeTxManager{}
+	authService := services.NewAuthService(NewMockUserRepository(), config.Load().JWT, idcodec.Plain{})
+	authService.SetWelcomeEmail(transactions, services.NewEmailService(emailRepo, &fakeSender{}, testEmailConfig(), services.DefaultPagination()))
+
+	_, err := authService.Register(context.Background(), &models.RegisterRequest{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Age:      30,
+		Password: "correct horse",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, transactions.committed)
+	require.Len(t, emailRepo.emails, 1)
+	assert.Equal(t, "john@example.com", emailRepo.emails[0].Recipient)
+	assert.Contains(t, emailRepo.emails[0].Body, "John Doe")
+}
+
+func newTestEmailRouter(cfg *config.Config, repo *MockEmailRepository) http.Handler {
+	emailService := services.NewEmailService(repo, &fakeSender{}, cfg.Email, services.DefaultPagination())
+	return router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Emails:        handlers.NewEmailHandler(emailService),
+	}).Handler()
+}
+
+func TestEmailWebhook_RecordsSignedEvents(t *testing.T) {
+	cfg := config.Load()
+	cfg.Email = testEmailConfig()
+	repo := &MockEmailRepository{}
+	handler := newTestEmailRouter(cfg, repo)
+
+	emailService := services.NewEmailService(repo, &fakeSender{}, cfg.Email, services.DefaultPagination())
+	queued, err := emailService.Queue(context.Background(), "john@example.com", "Hello", "Body")
+	require.NoError(t, err)
+	_, err = emailService.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	body := fmt.Sprintf(`{"events":[{"message_id":%q,"type":"bounced","reason":"mailbox full"},{"message_id":"<unknown@example.com>","type":"delivered"}]}`, queued.MessageID)
+
+	req := httptest.NewRequest("POST", "/api/v1/webhooks/email", strings.NewReader(body))
+	req.Header.Set("X-Webhook-Signature", signWebhook("wrong-secret", body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, models.EmailSent, repo.emails[0].Status)
+
+	req = httptest.NewRequest("POST", "/api/v1/webhooks/email", strings.NewReader(body))
+	req.Header.Set("X-Webhook-Signature", signWebhook("webhook-secret", body))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"applied":1`)
+	assert.Equal(t, models.EmailBounced, repo.emails[0].Status)
+	assert.Equal(t, "mailbox full", repo.emails[0].LastError)
+}
+
+func TestEmailWebhook_DisabledWithoutSecret(t *testing.T) {
+	cfg := config.Load()
+	cfg.Email = testEmailConfig()
+	cfg.Email.WebhookSecret = ""
+	handler := newTestEmailRouter(cfg, &MockEmailRepository{})
+
+	body := `{"events":[]}`
+	req := httptest.NewRequest("POST", "/api/v1/webhooks/email", strings.NewReader(body))
+	req.Header.Set("X-Webhook-Signature", signWebhook("", body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAdminEmails_ListsNewestFirstForAdmins(t *testing.T) {
+	cfg := config.Load()
+	cfg.Email = testEmailConfig()
+	repo := &MockEmailRepository{}
+	handler := newTestEmailRouter(cfg, repo)
+
+	emailService := services.NewEmailService(repo, &fakeSender{}, cfg.Email, services.DefaultPagination())
+	for _, to := range []string{"first@example.com", "second@example.com"} {
+		_, err := emailService.Queue(context.Background(), to, "Hello", "Body")
+		require.NoError(t, err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/emails", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7"}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/admin/emails?status=queued", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Less(t, strings.Index(rr.Body.String(), "second@example.com"), strings.Index(rr.Body.String(), "first@example.com"))
+	assert.NotContains(t, rr.Body.String(), `"body"`)
+
+	req = httptest.NewRequest("GET", "/api/v1/admin/emails?status=lost", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestEmailService_BounceSuppressesRecipient(t *testing.T) {
+	repo := &MockEmailRepository{}
+	sender := &fakeSender{}
+	emailService := services.NewEmailService(repo, sender, testEmailConfig(), services.DefaultPagination())
+
+	first, err := emailService.Queue(context.Background(), "John@example.com", "Hello", "Body")
+	require.NoError(t, err)
+	_, err = emailService.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	applied, err := emailService.HandleEvents(context.Background(), []models.EmailEvent{
+		{MessageID: first.MessageID, Type: models.EmailBounced, Reason: "550 mailbox unavailable"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, models.EmailBounced, repo.emails[0].Status)
+
+	// Nothing more is sent to the address
+	_, err = emailService.Queue(context.Background(), "john@example.com", "Hello again", "Body")
+	require.NoError(t, err)
+	sent, err := emailService.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, sender.sent, 1)
+	assert.Equal(t, models.EmailSuppressed, repo.emails[1].Status)
+	assert.Equal(t, "recipient bounced", repo.emails[1].LastError)
+
+	deliverability, err := emailService.Deliverability(context.Background(), "JOHN@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailUndeliverable, deliverability.Status)
+	assert.Equal(t, "550 mailbox unavailable", deliverability.Detail)
+}
+
+func TestEmailService_ComplaintForUnknownEmailSuppressesRecipient(t *testing.T) {
+	repo := &MockEmailRepository{}
+	emailService := services.NewEmailService(repo, &fakeSender{}, testEmailConfig(), services.DefaultPagination())
+
+	applied, err := emailService.HandleEvents(context.Background(), []models.EmailEvent{
+		{MessageID: "<unknown@example.com>", Type: models.EmailComplained, Recipient: "jane@example.com"},
+		{MessageID: "<unknown@example.com>", Type: models.EmailDelivered},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	deliverability, err := emailService.Deliverability(context.Background(), "jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailUndeliverable, deliverability.Status)
+	assert.Equal(t, models.EmailComplained, deliverability.Reason)
+
+	_, err = emailService.HandleEvents(context.Background(), []models.EmailEvent{{Type: models.EmailBounced}})
+	assert.ErrorContains(t, err, "message_id or recipient is required")
+}
+
+func TestUserHandler_ShowsDeliverabilityToAdmins(t *testing.T) {
+	cfg := config.Load()
+	userRepo := NewMockUserRepository()
+	_, err := userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	emailRepo := &MockEmailRepository{}
+	emailService := services.NewEmailService(emailRepo, &fakeSender{}, testEmailConfig(), services.DefaultPagination())
+	_, err = emailService.HandleEvents(context.Background(), []models.EmailEvent{
+		{Type: models.EmailBounced, Recipient: "john@example.com", Reason: "550 no such user"},
+	})
+	require.NoError(t, err)
+
+	userHandler := handlers.NewUserHandler(services.NewUserService(userRepo, services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{})
+	userHandler.SetDeliverability(emailService)
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   userHandler,
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+	}).Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/users/1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "email_deliverability")
+
+	req := httptest.NewRequest("GET", "/api/v1/users/1", nil)
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"email_deliverability":{"status":"undeliverable","reason":"bounced","detail":"550 no such user"`)
+}
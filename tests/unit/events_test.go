@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/events"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPublisher rejects every event
+type failingPublisher struct {
+	attempts int
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, event events.Event) error {
+	p.attempts++
+	return errors.New("broker unavailable")
+}
+
+func (p *failingPublisher) Close() error {
+	return nil
+}
+
+func TestBus_DeliversEventsToSubscribers(t *testing.T) {
+	bus := events.NewBus()
+	var all, deleted []events.Event
+	bus.Subscribe(func(ctx context.Context, event events.Event) { all = append(all, event) })
+	bus.Subscribe(func(ctx context.Context, event events.Event) { deleted = append(deleted, event) }, models.WebhookUserDeleted)
+
+	require.NoError(t, bus.Publish(context.Background(), events.NewEvent(models.WebhookUserCreated, "1", nil)))
+	require.NoError(t, bus.Publish(context.Background(), events.NewEvent(models.WebhookUserDeleted, "1", nil)))
+
+	assert.Len(t, all, 2)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, models.WebhookUserDeleted, deleted[0].Type)
+	assert.NotEqual(t, all[0].ID, all[1].ID)
+}
+
+func TestUserService_PublishesDomainEvents(t *testing.T) {
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(ctx context.Context, event events.Event) { published = append(published, event) })
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	userService.SetEvents(bus)
+
+	ctx := context.Background()
+	user, err := userService.CreateUser(ctx, &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	name := "Jane Doe"
+	_, err = userService.PatchUser(ctx, user.ID, &models.PatchUserRequest{Name: &name})
+	require.NoError(t, err)
+	require.NoError(t, userService.DeleteUser(ctx, user.ID))
+
+	require.Len(t, published, 3)
+	subject := idcodec.Plain{}.Encode(user.ID)
+	for i, typ := range []string{models.WebhookUserCreated, models.WebhookUserUpdated, models.WebhookUserDeleted} {
+		assert.Equal(t, typ, published[i].Type)
+		assert.Equal(t, subject, published[i].Subject)
+	}
+	assert.Equal(t, "Jane Doe", published[1].Data.(*models.UserResponse).Name)
+}
+
+func TestUserService_PublishFailureDoesNotFailChange(t *testing.T) {
+	publisher := &failingPublisher{}
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	userService.SetEvents(publisher)
+
+	_, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	assert.Equal(t, 1, publisher.attempts)
+}
+
+func TestNewPublisher_SelectsBackend(t *testing.T) {
+	publisher, err := events.NewPublisher(config.EventsConfig{Backend: events.BackendInProc}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &events.Bus{}, publisher)
+
+	_, err = events.NewPublisher(config.EventsConfig{Backend: events.BackendKafka, KafkaTopic: "users"}, nil)
+	assert.ErrorContains(t, err, "EVENTS_KAFKA_BROKERS")
+
+	publisher, err = events.NewPublisher(config.EventsConfig{Backend: events.BackendKafka, KafkaBrokers: []string{"localhost:9092"}, KafkaTopic: "users"}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &events.KafkaPublisher{}, publisher)
+	assert.NoError(t, publisher.Close())
+
+	_, err = events.NewPublisher(config.EventsConfig{Backend: "rabbitmq"}, nil)
+	assert.ErrorContains(t, err, "unknown events backend")
+}
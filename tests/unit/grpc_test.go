@@ -0,0 +1,277 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/grpcapi"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/pratham15541/go-crud/proto/userpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient serves the gRPC API over an in-memory connection
+func newGRPCClient(t *testing.T, cfg *config.Config) userpb.UserServiceClient {
+	return newGRPCClientWith(t, grpcapi.Dependencies{Config: cfg})
+}
+
+// newGRPCClientWith serves the gRPC API built from deps over an in-memory
+// connection, with a user service and logger when deps has none
+func newGRPCClientWith(t *testing.T, deps grpcapi.Dependencies) userpb.UserServiceClient {
+	if deps.UserService == nil {
+		deps.UserService = services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	}
+	if deps.Logger == nil {
+		deps.Logger = zap.NewNop()
+	}
+	listener := bufconn.Listen(1 << 20)
+	server := grpcapi.NewServer(deps)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return userpb.NewUserServiceClient(conn)
+}
+
+func withToken(t *testing.T, cfg *config.Config, claims jwt.MapClaims) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", bearer(t, cfg, claims))
+}
+
+func TestGRPC_UserLifecycle(t *testing.T) {
+	cfg := config.Load()
+	client := newGRPCClient(t, cfg)
+	ctx := withToken(t, cfg, jwt.MapClaims{"sub": "1", "role": models.RoleUser})
+
+	created, err := client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", created.Name)
+	assert.EqualValues(t, 1, created.Version)
+
+	// Reads are public by default, as on REST
+	got, err := client.GetUser(context.Background(), &userpb.GetUserRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", got.Email)
+
+	list, err := client.ListUsers(context.Background(), &userpb.ListUsersRequest{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, list.Total)
+	require.Len(t, list.Users, 1)
+
+	stale := int32(0)
+	_, err = client.UpdateUser(ctx, &userpb.UpdateUserRequest{Id: created.Id, Name: "Jane Doe", Version: &stale})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	current := created.Version
+	updated, err := client.UpdateUser(ctx, &userpb.UpdateUserRequest{Id: created.Id, Name: "Jane Doe", Version: &current})
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", updated.Name)
+	assert.Equal(t, "john@example.com", updated.Email)
+
+	_, err = client.UpdateUser(ctx, &userpb.UpdateUserRequest{Id: created.Id, Name: "John Doe", Version: &current})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = client.DeleteUser(ctx, &userpb.DeleteUserRequest{Id: created.Id})
+	require.NoError(t, err)
+	_, err = client.GetUser(context.Background(), &userpb.GetUserRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPC_Errors(t *testing.T) {
+	cfg := config.Load()
+	client := newGRPCClient(t, cfg)
+	ctx := withToken(t, cfg, jwt.MapClaims{"sub": "1", "role": models.RoleUser})
+
+	_, err := client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "John Doe", Email: "not-an-email", Age: 30})
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	assert.Equal(t, "email", badRequest.FieldViolations[0].Field)
+
+	_, err = client.GetUser(context.Background(), &userpb.GetUserRequest{Id: "not-an-id"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPC_Auth(t *testing.T) {
+	cfg := config.Load()
+	client := newGRPCClient(t, cfg)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected codes.Code
+	}{
+		{"without token", context.Background(), codes.Unauthenticated},
+		{"invalid token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope"), codes.Unauthenticated},
+		{"token without users:write", withToken(t, cfg, jwt.MapClaims{"sub": "1", "scope": "users:read"}), codes.PermissionDenied},
+		{"token with users:write", withToken(t, cfg, jwt.MapClaims{"sub": "1", "scope": "users:write"}), codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.CreateUser(tt.ctx, &userpb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+			assert.Equal(t, tt.expected, status.Code(err))
+		})
+	}
+}
+
+func TestGRPC_TokensThatSignCannotWrite(t *testing.T) {
+	pat := func(signingKey string) jwt.MapClaims {
+		claims := jwt.MapClaims{"sub": "1", "scope": "users:read users:write", "token_type": "pat", "token_id": 3}
+		if signingKey != "" {
+			claims["signing_key"] = signingKey
+		}
+		return claims
+	}
+	create := func(client userpb.UserServiceClient, ctx context.Context, email string) error {
+		_, err := client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "John Doe", Email: email, Age: 30})
+		return err
+	}
+
+	cfg := config.Load()
+	client := newGRPCClient(t, cfg)
+	assert.NoError(t, create(client, withToken(t, cfg, pat("")), "john@example.com"))
+	signer := withToken(t, cfg, pat("-----BEGIN PUBLIC KEY-----"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(create(client, signer, "jane@example.com")))
+	_, err := client.ListUsers(signer, &userpb.ListUsersRequest{})
+	assert.NoError(t, err, "reads need no signature")
+
+	t.Setenv("PAT_REQUIRE_SIGNATURES", "true")
+	cfg = config.Load()
+	client = newGRPCClient(t, cfg)
+	assert.Equal(t, codes.Unauthenticated, status.Code(create(client, withToken(t, cfg, pat("")), "john@example.com")))
+	// Sessions are not signed
+	assert.NoError(t, create(client, withToken(t, cfg, jwt.MapClaims{"sub": "1"}), "john@example.com"))
+}
+
+func TestGRPC_AuditsWrites(t *testing.T) {
+	cfg := config.Load()
+	repo := &MockAuditRepository{}
+	client := newGRPCClientWith(t, grpcapi.Dependencies{
+		Config: cfg,
+		Audit:  services.NewAuditService(repo, services.DefaultPagination()),
+	})
+	ctx := withToken(t, cfg, jwt.MapClaims{"sub": "7"})
+
+	created, err := client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = client.GetUser(ctx, &userpb.GetUserRequest{Id: created.Id})
+	require.NoError(t, err)
+	_, err = client.DeleteUser(ctx, &userpb.DeleteUserRequest{Id: "99"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	require.Len(t, repo.entries, 2)
+	assert.Equal(t, "POST /users", repo.entries[0].Action)
+	assert.Equal(t, http.StatusOK, repo.entries[0].Status)
+	assert.Equal(t, "7", repo.entries[0].ActorID)
+	assert.Equal(t, "DELETE /users/{id}", repo.entries[1].Action)
+	assert.Equal(t, "99", repo.entries[1].TargetID)
+	assert.Equal(t, http.StatusNotFound, repo.entries[1].Status)
+}
+
+func TestGRPC_PublicPathsApplyToMethods(t *testing.T) {
+	t.Setenv("AUTH_PUBLIC_PATHS", "/health")
+	cfg := config.Load()
+	client := newGRPCClient(t, cfg)
+
+	_, err := client.ListUsers(context.Background(), &userpb.ListUsersRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPC_EchoesRequestID(t *testing.T) {
+	client := newGRPCClient(t, config.Load())
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "abc-123")
+	_, err := client.ListUsers(ctx, &userpb.ListUsersRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc-123"}, header.Get("x-request-id"))
+}
+
+func TestGateway_ServesGeneratedAPI(t *testing.T) {
+	t.Setenv("BASE_PATH", "/crud")
+	cfg := config.Load()
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	server := grpcapi.NewServer(grpcapi.Dependencies{Config: cfg, UserService: userService, Logger: zap.NewNop()})
+	t.Cleanup(server.Stop)
+	gateway, err := grpcapi.NewGateway(server)
+	require.NoError(t, err)
+	t.Cleanup(func() { gateway.Close() })
+
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(userService, idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Gateway:       gateway,
+	}).Handler()
+	token := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": models.RoleUser})
+
+	send := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send("POST", "/crud/api/v2/users", `{"name":"John Doe","email":"john@example.com","age":30}`, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = send("POST", "/crud/api/v2/users", `{"name":"John Doe","email":"john@example.com","age":30}`, token)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "john@example.com", created["email"])
+	assert.Contains(t, created, "created_at")
+	assert.Len(t, rr.Header().Values("X-Request-ID"), 1)
+
+	// The same user is visible through the hand-written REST API
+	rr = send("GET", "/crud/api/v1/users/"+created["id"].(string), "", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = send("PUT", "/crud/api/v2/users/"+created["id"].(string), `{"name":"Jane Doe","version":5}`, token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = send("GET", "/crud/api/v2/users/"+created["id"].(string), "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"name":"John Doe"`)
+
+	rr = send("GET", "/crud/api/v2/openapi.json", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"/api/v2/users/{id}"`)
+}
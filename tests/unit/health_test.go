@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/buildinfo"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePinger is a database whose availability can be toggled
+type fakePinger struct {
+	down bool
+}
+
+func (p *fakePinger) PingContext(ctx context.Context) error {
+	if p.down {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestMonitor_ReadOnlyWhenPrimaryDown(t *testing.T) {
+	primary, replica := &fakePinger{}, &fakePinger{}
+	monitor := health.NewMonitor(primary, replica, time.Second)
+
+	monitor.Check(context.Background())
+	assert.False(t, monitor.ReadOnly())
+
+	primary.down = true
+	status := monitor.Check(context.Background())
+	assert.True(t, monitor.ReadOnly())
+	assert.Equal(t, health.StateUnhealthy, status.Primary)
+	assert.Equal(t, "connection refused", status.PrimaryError)
+
+	replica.down = true
+	monitor.Check(context.Background())
+	assert.False(t, monitor.ReadOnly(), "no read-only mode without a healthy replica")
+
+	primary.down, replica.down = false, false
+	monitor.Check(context.Background())
+	assert.False(t, monitor.ReadOnly())
+}
+
+func TestMonitor_NoReplica(t *testing.T) {
+	monitor := health.NewMonitor(&fakePinger{down: true}, nil, time.Second)
+
+	status := monitor.Check(context.Background())
+	assert.False(t, status.ReadOnly)
+	assert.Equal(t, health.StateUnavailable, status.Replica)
+	assert.False(t, monitor.ReplicaHealthy())
+}
+
+func TestMonitor_ReplicaHealthyForFollowerReads(t *testing.T) {
+	replica := &fakePinger{}
+	monitor := health.NewMonitor(&fakePinger{}, replica, time.Second)
+
+	monitor.Check(context.Background())
+	assert.True(t, monitor.ReplicaHealthy())
+	assert.False(t, monitor.ReadOnly())
+
+	// Follower reads fall back to the primary while the replica is down
+	replica.down = true
+	monitor.Check(context.Background())
+	assert.False(t, monitor.ReplicaHealthy())
+}
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	primary := &fakePinger{down: true}
+	monitor := health.NewMonitor(primary, &fakePinger{}, time.Second)
+	monitor.Check(context.Background())
+
+	handler := middleware.ReadOnlyMiddleware(monitor)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/users", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "read-only", rr.Header().Get("X-Degraded"))
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/users", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+	primary.down = false
+	monitor.Check(context.Background())
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/users", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("X-Degraded"))
+}
+
+func TestVersionAndHealthReportBuild(t *testing.T) {
+	defer func(version, commit string) { buildinfo.Version, buildinfo.Commit = version, commit }(buildinfo.Version, buildinfo.Commit)
+	buildinfo.Version, buildinfo.Commit = "1.4.0", "0123abc"
+
+	cfg := config.Load()
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, health.NewMonitor(&fakePinger{}, nil, time.Hour)),
+	}).Handler()
+
+	// Public like /health, so deploy tooling needs no token
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var version struct {
+		Data buildinfo.Info `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &version))
+	assert.Equal(t, "1.4.0", version.Data.Version)
+	assert.Equal(t, "0123abc", version.Data.Commit)
+	assert.Equal(t, runtime.Version(), version.Data.GoVersion)
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, version.Data.Platform)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	var healthResp models.HealthResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &healthResp))
+	assert.Equal(t, "1.4.0", healthResp.Version)
+}
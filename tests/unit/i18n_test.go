@@ -0,0 +1,169 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/i18n"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestNegotiator_Negotiate(t *testing.T) {
+	negotiator, err := i18n.NewNegotiator([]string{"en", "es", "fr"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "en"},
+		{"fr", "fr"},
+		{"es-MX,es;q=0.9,en;q=0.8", "es"},
+		{"de;q=0.9,fr;q=0.5", "fr"},
+		{"en;q=0.2,fr;q=0.8", "fr"},
+		{"ja", "en"},
+		{"not a header;;", "en"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, negotiator.Negotiate(tt.header).String(), tt.header)
+	}
+}
+
+func TestNewNegotiator_InvalidLocale(t *testing.T) {
+	_, err := i18n.NewNegotiator([]string{"en", "??"})
+	assert.Error(t, err)
+
+	_, err = i18n.NewNegotiator(nil)
+	assert.Error(t, err)
+}
+
+func TestFormatter_FollowsLocale(t *testing.T) {
+	tests := []struct {
+		locale   string
+		integer  string
+		decimal  string
+		currency string
+	}{
+		{"en", "1,234,567", "1,234.57", "€ 1,234.50"},
+		{"de-DE", "1.234.567", "1.234,57", "€ 1.234,50"},
+		{"fr-FR", "1 234 567", "1 234,57", "€ 1 234,50"},
+		{"hi-IN", "12,34,567", "1,234.57", "€ 1,234.50"},
+	}
+
+	for _, tt := range tests {
+		f := i18n.NewFormatter(language.MustParse(tt.locale))
+		assert.Equal(t, tt.integer, f.Integer(1234567), tt.locale)
+		assert.Equal(t, tt.decimal, f.Decimal(1234.567, 2), tt.locale)
+		amount, err := f.Currency(1234.5, "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, tt.currency, amount, tt.locale)
+	}
+}
+
+func TestFormatter_CurrencyDigits(t *testing.T) {
+	f := i18n.NewFormatter(language.English)
+	amount, err := f.Currency(1234.5, "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, "¥ 1,235", amount)
+
+	_, err = f.Currency(1, "XYZW")
+	assert.Error(t, err)
+}
+
+func TestParseTenantLocales(t *testing.T) {
+	locales, err := i18n.ParseTenantLocales([]string{"acme=de-CH", "globex=en-IN"})
+	require.NoError(t, err)
+	assert.Equal(t, "de-CH", locales["acme"].String())
+	assert.Equal(t, "en-IN", locales["globex"].String())
+
+	for _, entry := range []string{"acme", "=de", "acme=??"} {
+		_, err := i18n.ParseTenantLocales([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestTenantFormatMiddleware(t *testing.T) {
+	locales := i18n.TenantLocales{"acme": language.German}
+	var got string
+	handler := middleware.TenantFormatMiddleware(locales)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = i18n.FormatterFromContext(r.Context()).Integer(1234)
+	}))
+
+	for tenant, expected := range map[string]string{"acme": "1.234", "globex": "1,234"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx := i18n.WithLocale(req.Context(), language.English)
+		ctx = actor.NewContext(ctx, actor.Actor{UserID: "1", TenantID: tenant})
+		handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+		assert.Equal(t, expected, got, tenant)
+	}
+}
+
+func TestMessage_FollowsLocale(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected string
+	}{
+		{"en", "Created 1,200 of 1,500 users"},
+		{"de", "1.200 von 1.500 Benutzern erstellt"},
+		// Locales without translations fall back to English
+		{"ja", "Created 1,200 of 1,500 users"},
+		{"und", "Created 1,200 of 1,500 users"},
+	}
+
+	for _, tt := range tests {
+		ctx := i18n.WithLocale(context.Background(), language.MustParse(tt.locale))
+		assert.Equal(t, tt.expected, i18n.Message(ctx, i18n.MsgUsersPartiallyCreated, 1200, 1500), tt.locale)
+	}
+	assert.Equal(t, "no.such.key", i18n.Message(context.Background(), "no.such.key"))
+}
+
+func TestNegotiator_Untranslated(t *testing.T) {
+	negotiator, err := i18n.NewNegotiator([]string{"en", "de-AT", "ja"})
+	require.NoError(t, err)
+	assert.Equal(t, []language.Tag{language.Japanese}, negotiator.Untranslated())
+}
+
+func TestRouter_LocalizesResponseMessages(t *testing.T) {
+	cfg := config.Load()
+	locales, err := i18n.NewNegotiator([]string{"en", "de"})
+	require.NoError(t, err)
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Locales:       locales,
+	}).Handler()
+
+	for header, expected := range map[string]string{
+		"de-DE,de;q=0.9": "Benutzer erfolgreich erstellt",
+		"fr":             "User created successfully",
+	} {
+		body := `{"name":"Ada","email":"ada-` + header[:2] + `@example.com","age":30}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+		req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"}))
+		req.Header.Set("Accept-Language", header)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		var resp models.SuccessResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, expected, resp.Message, header)
+	}
+}
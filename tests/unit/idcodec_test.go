@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCodecRouter creates a router whose user IDs are encoded with codec
+func newCodecRouter(cfg *config.Config, codec idcodec.Codec) http.Handler {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), codec)
+
+	r := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(userService, codec),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		IDs:           codec,
+	})
+	return r.Handler()
+}
+
+// plainID decodes an ID the plain codec encoded
+func plainID(t *testing.T, id idcodec.PublicID) int {
+	t.Helper()
+	decoded, err := idcodec.Plain{}.Decode(id.String())
+	require.NoError(t, err)
+	return decoded
+}
+
+// plainPublicID encodes id with the plain codec
+func plainPublicID(id int) idcodec.PublicID {
+	return idcodec.Public(idcodec.Plain{}, id)
+}
+
+func TestPlainCodec(t *testing.T) {
+	codec := idcodec.Plain{}
+
+	id, err := codec.Decode(codec.Encode(42))
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	for _, publicID := range []string{"", "abc", "-1", "0", "007", "+7"} {
+		_, err := codec.Decode(publicID)
+		assert.ErrorIs(t, err, idcodec.ErrInvalidID, publicID)
+	}
+}
+
+func TestSqidsCodec(t *testing.T) {
+	codec, err := idcodec.NewSqids("", 8)
+	require.NoError(t, err)
+
+	publicID := codec.Encode(42)
+	assert.GreaterOrEqual(t, len(publicID), 8)
+	assert.NotContains(t, publicID, "42")
+
+	id, err := codec.Decode(publicID)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	tampered := publicID[:len(publicID)-1] + string(rune(publicID[len(publicID)-1])^1)
+	_, err = codec.Decode(tampered)
+	assert.ErrorIs(t, err, idcodec.ErrInvalidID)
+	_, err = codec.Decode("42")
+	assert.ErrorIs(t, err, idcodec.ErrInvalidID)
+}
+
+func TestSqidsCodec_AlphabetChangesOutput(t *testing.T) {
+	a, err := idcodec.NewSqids("abcdefghijklmnopqrstuvwxyz", 0)
+	require.NoError(t, err)
+	b, err := idcodec.NewSqids("zyxwvutsrqponmlkjihgfedcba", 0)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a.Encode(42), b.Encode(42))
+
+	_, err = idcodec.NewSqids("aab", 0)
+	assert.Error(t, err)
+}
+
+func TestPublicID_JSON(t *testing.T) {
+	user := &models.User{ID: 7}
+	data, err := json.Marshal(user.ToResponse(idcodec.Plain{}))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id":7,`)
+
+	codec, err := idcodec.NewSqids("", 6)
+	require.NoError(t, err)
+
+	data, err = json.Marshal(user.ToResponse(codec))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id":"`+codec.Encode(7)+`"`)
+
+	var decoded models.UserResponse
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	id, err := codec.Decode(decoded.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 7, id)
+}
+
+func TestRouter_OpaqueUserIDs(t *testing.T) {
+	codec, err := idcodec.NewSqids("", 8)
+	require.NoError(t, err)
+
+	cfg := config.Load()
+	handler := newCodecRouter(cfg, codec)
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "1"}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, codec.Encode(1), created.Data.ID)
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{"/api/v1/users/" + created.Data.ID, http.StatusOK},
+		{"/api/v1/users/1", http.StatusBadRequest},
+		{"/api/v1/users/" + strings.ToUpper(created.Data.ID), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+		assert.Equal(t, tt.expected, rr.Code, tt.path)
+	}
+}
@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchCountingUserRepository counts the batches inserted
+type batchCountingUserRepository struct {
+	*MockUserRepository
+	batches int
+}
+
+func (r *batchCountingUserRepository) CreateBatch(ctx context.Context, reqs []*models.CreateUserRequest) ([]*models.User, []error, error) {
+	r.batches++
+	return r.MockUserRepository.CreateBatch(ctx, reqs)
+}
+
+func TestUserService_ImportUsers_SkipsBadRows(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	document := strings.Join([]string{
+		"Email,Name,Age,Department",
+		"ada@example.com,Ada Lovelace,36,Engineering",
+		"not-an-email,Bad Email,30,Sales",
+		"alan@example.com,Alan Turing,forty,Research",
+		"ada@example.com,Ada Again,37,Engineering",
+		`grace@example.com,"Grace Hopper",85`,
+	}, "\n")
+
+	summary, err := userService.ImportUsers(context.Background(), strings.NewReader(document))
+	require.NoError(t, err)
+	assert.Equal(t, 5, summary.Processed)
+	assert.Equal(t, 2, summary.Created)
+	assert.Equal(t, 3, summary.Skipped)
+	assert.Equal(t, []models.ImportSkippedRow{
+		{Row: 3, Reason: "email must be a valid email address"},
+		{Row: 4, Reason: "age must be a whole number"},
+		{Row: 5, Reason: "user with email ada@example.com already exists"},
+	}, summary.SkippedRows)
+}
+
+func TestUserService_ImportUsers_InsertsInBatches(t *testing.T) {
+	repo := &batchCountingUserRepository{MockUserRepository: NewMockUserRepository()}
+	userService := services.NewUserService(repo, services.DefaultPagination(), idcodec.Plain{})
+
+	var document strings.Builder
+	document.WriteString("name,email,age\n")
+	for i := 0; i < services.MaxBulkUsers*2+1; i++ {
+		fmt.Fprintf(&document, "User %d,user%d@example.com,30\n", i, i)
+	}
+
+	summary, err := userService.ImportUsers(context.Background(), strings.NewReader(document.String()))
+	require.NoError(t, err)
+	assert.Equal(t, services.MaxBulkUsers*2+1, summary.Created)
+	assert.Equal(t, 3, repo.batches)
+}
+
+func TestUserService_ImportUsers_RejectsBadHeaders(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	_, err := userService.ImportUsers(context.Background(), strings.NewReader(""))
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+	assert.EqualError(t, err, "CSV document is empty")
+
+	_, err = userService.ImportUsers(context.Background(), strings.NewReader("full_name,email\nAda,ada@example.com\n"))
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+	assert.EqualError(t, err, "CSV header is missing columns: name, age")
+}
+
+func TestUserHandler_ImportUsers(t *testing.T) {
+	handler := handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{})
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("note", "legacy system"))
+	file, err := form.CreateFormFile("file", "users.csv")
+	require.NoError(t, err)
+	file.Write([]byte("name,email,age\nAda Lovelace,ada@example.com,36\n"))
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest("POST", "/api/v1/users/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr := httptest.NewRecorder()
+	handler.ImportUsers(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response struct {
+		Data models.ImportUsersSummary `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Data.Created)
+	assert.Empty(t, response.Data.SkippedRows)
+}
+
+func TestUserHandler_ImportUsers_RequiresFilePart(t *testing.T) {
+	handler := handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{})
+
+	req := httptest.NewRequest("POST", "/api/v1/users/import", strings.NewReader("name,email,age\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	rr := httptest.NewRecorder()
+	handler.ImportUsers(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("note", "no file"))
+	require.NoError(t, form.Close())
+	req = httptest.NewRequest("POST", "/api/v1/users/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr = httptest.NewRecorder()
+	handler.ImportUsers(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func signTestToken(t *testing.T, secret string, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestIntrospectionService_Introspect(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	user, _ := userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	tokenService := services.NewPersonalTokenService(NewMockPersonalTokenRepository(), config.Load().Tokens, zap.NewNop())
+	claimsLoader := services.NewUserClaimsLoader(userRepo, newTestCache(t), time.Minute, 0)
+	introspection := services.NewIntrospectionService(middleware.HMACKey("secret"), tokenService, claimsLoader)
+
+	token := signTestToken(t, "secret", jwt.MapClaims{
+		"sub":  "1",
+		"role": "admin",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+
+	resp := introspection.Introspect(context.Background(), token)
+	assert.True(t, resp.Active)
+	assert.Equal(t, "1", resp.Sub)
+	assert.Equal(t, "john@example.com", resp.Email)
+	// The stored role wins over whatever the token claims
+	assert.Equal(t, models.RoleUser, resp.Role)
+
+	// Role changes are visible once the cached claims are invalidated
+	user.Role = models.RoleAdmin
+	claimsLoader.Invalidate(user.ID)
+	assert.Equal(t, models.RoleAdmin, introspection.Introspect(context.Background(), token).Role)
+
+	expired := signTestToken(t, "secret", jwt.MapClaims{"sub": "1", "exp": time.Now().Add(-time.Hour).Unix()})
+	assert.False(t, introspection.Introspect(context.Background(), expired).Active)
+
+	forged := signTestToken(t, "other-secret", jwt.MapClaims{"sub": "1"})
+	assert.False(t, introspection.Introspect(context.Background(), forged).Active)
+
+	unknownUser := signTestToken(t, "secret", jwt.MapClaims{"sub": "99"})
+	assert.False(t, introspection.Introspect(context.Background(), unknownUser).Active)
+}
@@ -0,0 +1,145 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/realtime"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLiveServer serves /ws from a hub fed by the returned user service
+func newLiveServer(t *testing.T) (*config.Config, *httptest.Server, *realtime.Hub, *services.UserService) {
+	cfg := config.Load()
+	hub := realtime.NewHub(config.LiveConfig{Enabled: true, SendBuffer: 8, PingInterval: time.Minute}, idcodec.Plain{}, nil)
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	userService.SetLiveUpdates(hub)
+
+	server := httptest.NewServer(router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(userService, idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Live:          handlers.NewLiveHandler(hub, nil),
+	}).Handler())
+	t.Cleanup(server.Close)
+	return cfg, server, hub, userService
+}
+
+// dialLive connects to /ws with claims, waiting until the hub has the client
+func dialLive(t *testing.T, cfg *config.Config, server *httptest.Server, hub *realtime.Hub, claims jwt.MapClaims) *websocket.Conn {
+	clients := hub.Clients()
+	header := http.Header{"Authorization": {bearer(t, cfg, claims)}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.Eventually(t, func() bool { return hub.Clients() > clients }, time.Second, 5*time.Millisecond)
+	return conn
+}
+
+func readLive(t *testing.T, conn *websocket.Conn) models.LiveMessage {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg models.LiveMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestLive_RequiresAuthentication(t *testing.T) {
+	_, server, _, _ := newLiveServer(t)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestLive_PushesUserChangesToSubscribers(t *testing.T) {
+	cfg, server, hub, userService := newLiveServer(t)
+	admin := dialLive(t, cfg, server, hub, jwt.MapClaims{"sub": "99", "role": "admin"})
+	// Users start subscribed to themselves only
+	owner := dialLive(t, cfg, server, hub, jwt.MapClaims{"sub": "2"})
+
+	ctx := context.Background()
+	_, err := userService.CreateUser(ctx, &models.CreateUserRequest{Name: "First", Email: "first@example.com", Age: 30})
+	require.NoError(t, err)
+	second, err := userService.CreateUser(ctx, &models.CreateUserRequest{Name: "Second", Email: "second@example.com", Age: 30})
+	require.NoError(t, err)
+	require.Equal(t, 2, second.ID)
+
+	msg := readLive(t, admin)
+	assert.Equal(t, models.WebhookUserCreated, msg.Type)
+	assert.Equal(t, "users/1", msg.Topic)
+	assert.Equal(t, "users/2", readLive(t, admin).Topic)
+
+	msg = readLive(t, owner)
+	assert.Equal(t, models.WebhookUserCreated, msg.Type)
+	assert.Equal(t, "users/2", msg.Topic)
+	data, _ := json.Marshal(msg.Data)
+	assert.Contains(t, string(data), "second@example.com")
+
+	require.NoError(t, userService.DeleteUser(ctx, second.ID))
+	msg = readLive(t, owner)
+	assert.Equal(t, models.WebhookUserDeleted, msg.Type)
+}
+
+func TestLive_SubscriptionsAreAuthorized(t *testing.T) {
+	cfg, server, hub, _ := newLiveServer(t)
+	conn := dialLive(t, cfg, server, hub, jwt.MapClaims{"sub": "2"})
+
+	require.NoError(t, conn.WriteJSON(models.LiveCommand{Action: realtime.ActionSubscribe, Topic: realtime.TopicUsers}))
+	msg := readLive(t, conn)
+	assert.Equal(t, models.LiveError, msg.Type)
+
+	require.NoError(t, conn.WriteJSON(models.LiveCommand{Action: realtime.ActionSubscribe, Topic: "users/3"}))
+	assert.Equal(t, models.LiveError, readLive(t, conn).Type)
+
+	require.NoError(t, conn.WriteJSON(models.LiveCommand{Action: realtime.ActionUnsubscribe, Topic: "users/2"}))
+	msg = readLive(t, conn)
+	assert.Equal(t, models.LiveUnsubscribed, msg.Type)
+	assert.Equal(t, "users/2", msg.Topic)
+}
+
+func TestLive_ShutdownNotifiesClientsAndCountsForceClosed(t *testing.T) {
+	cfg, server, hub, _ := newLiveServer(t)
+	listening := dialLive(t, cfg, server, hub, jwt.MapClaims{"sub": "1"})
+	// Never reads, so never acknowledges the close frame
+	dialLive(t, cfg, server, hub, jwt.MapClaims{"sub": "2"})
+
+	closed := make(chan error, 1)
+	go func() {
+		_, _, err := listening.ReadMessage()
+		closed <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	notified, forced := hub.Shutdown(ctx)
+	assert.Equal(t, 2, notified)
+	assert.Equal(t, 1, forced)
+
+	var closeErr *websocket.CloseError
+	require.True(t, errors.As(<-closed, &closeErr))
+	assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+	assert.Contains(t, closeErr.Text, "reconnect")
+
+	// New connections are refused while shutting down
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws",
+		http.Header{"Authorization": {bearer(t, cfg, jwt.MapClaims{"sub": "1"})}})
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
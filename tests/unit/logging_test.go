@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_New(t *testing.T) {
+	for _, format := range []string{"json", "console"} {
+		l, err := logger.New(config.LoggingConfig{Level: "warn", Format: format})
+		require.NoError(t, err, format)
+		assert.False(t, l.Core().Enabled(zapcore.InfoLevel), format)
+		assert.True(t, l.Core().Enabled(zapcore.WarnLevel), format)
+	}
+
+	_, err := logger.New(config.LoggingConfig{Level: "loud", Format: "json"})
+	assert.Error(t, err)
+	_, err = logger.New(config.LoggingConfig{Level: "info", Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestLoggingMiddleware_RequestFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	handler := middleware.NewChain(
+		middleware.RequestIDMiddleware,
+		middleware.LoggingMiddleware(zap.New(core), nil),
+	).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("handling")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/users?page=2", nil)
+	req.Header.Set(middleware.RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, "abc-123", rr.Header().Get(middleware.RequestIDHeader))
+	require.Equal(t, 2, logs.Len())
+
+	// The handler's logger carries the request fields too
+	handling := logs.All()[0].ContextMap()
+	assert.Equal(t, "abc-123", handling["request_id"])
+	assert.Equal(t, "GET", handling["method"])
+	assert.Equal(t, "/api/v1/users", handling["path"])
+
+	completed := logs.All()[1]
+	assert.Equal(t, "request completed", completed.Message)
+	fields := completed.ContextMap()
+	assert.Equal(t, "abc-123", fields["request_id"])
+	assert.Equal(t, int64(http.StatusTeapot), fields["status"])
+	assert.Equal(t, int64(len("short and stout")), fields["size"])
+	assert.Contains(t, fields, "duration")
+}
+
+func TestRequestIDMiddleware_GeneratesID(t *testing.T) {
+	var seen string
+	handler := middleware.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = middleware.RequestIDFromContext(r.Context())
+	}))
+
+	for _, supplied := range []string{"", "has spaces", string(make([]byte, 200))} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if supplied != "" {
+			req.Header.Set(middleware.RequestIDHeader, supplied)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Len(t, seen, 32)
+		assert.NotEqual(t, supplied, seen)
+		assert.Equal(t, seen, rr.Header().Get(middleware.RequestIDHeader))
+	}
+}
+
+func TestLoggingMiddleware_SamplesNoisyCategories(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	sampler, err := logger.NewSampler([]string{"health=3", "not_found=2"})
+	require.NoError(t, err)
+	handler := middleware.LoggingMiddleware(zap.New(core), sampler)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/nowhere" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	for i := 0; i < 6; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/health", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/nowhere", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/users", nil))
+	}
+
+	paths := map[string]int{}
+	for _, entry := range logs.All() {
+		paths[entry.ContextMap()["path"].(string)]++
+	}
+	assert.Equal(t, map[string]int{"/api/v1/health": 2, "/api/v1/nowhere": 3, "/api/v1/users": 6}, paths)
+
+	health := logs.FilterField(zap.String("path", "/api/v1/health")).All()[0]
+	assert.Equal(t, uint64(3), health.ContextMap()["sample_rate"])
+}
+
+func TestNewSampler_RejectsBadEntries(t *testing.T) {
+	for _, entry := range []string{"health", "=2", "health=0", "health=many"} {
+		_, err := logger.NewSampler([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestLimitRepeatedErrors(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(logger.LimitRepeatedErrors(core, time.Hour, 2))
+
+	for i := 0; i < 5; i++ {
+		// Request fields differ, but the message and error repeat
+		l.With(zap.Int("attempt", i)).Error("failed to send email", zap.Error(errors.New("connection refused")))
+	}
+	l.Error("failed to send email", zap.Error(errors.New("timeout")))
+	l.Warn("slow query")
+	l.Warn("slow query")
+	l.Warn("slow query")
+
+	assert.Equal(t, 2, logs.FilterField(zap.Error(errors.New("connection refused"))).Len())
+	assert.Equal(t, 1, logs.FilterField(zap.Error(errors.New("timeout"))).Len())
+	// Only errors are limited
+	assert.Equal(t, 3, logs.FilterMessage("slow query").Len())
+
+	require.NoError(t, l.Sync())
+	summaries := logs.FilterMessage("suppressed 3 similar").All()
+	require.Len(t, summaries, 1)
+	assert.Equal(t, zapcore.ErrorLevel, summaries[0].Level)
+	assert.Equal(t, "failed to send email", summaries[0].ContextMap()["suppressed_message"])
+	assert.Equal(t, int64(3), summaries[0].ContextMap()["suppressed"])
+}
+
+func TestLimitRepeatedErrors_SummarizesAfterWindow(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(logger.LimitRepeatedErrors(core, 20*time.Millisecond, 1))
+
+	l.Error("replica unreachable")
+	l.Error("replica unreachable")
+	time.Sleep(30 * time.Millisecond)
+	l.Info("next entry")
+
+	assert.Equal(t, 1, logs.FilterMessage("replica unreachable").Len())
+	assert.Equal(t, 1, logs.FilterMessage("suppressed 1 similar").Len())
+}
@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimit(t *testing.T) {
+	assert.EqualValues(t, 512<<20, health.MemoryLimit(512<<20))
+	// Tests run without GOMEMLIMIT, so nothing else sets a limit
+	assert.Zero(t, health.MemoryLimit(0))
+}
+
+func TestMemoryWatchdog_Pressure(t *testing.T) {
+	readiness := health.NewReadiness()
+	watchdog := health.NewMemoryWatchdog(100<<20, 0.8, readiness)
+	watchdog.ProfileDir = t.TempDir()
+	usage := uint64(50 << 20)
+	watchdog.Usage = func() uint64 { return usage }
+
+	watchdog.Check(context.Background())
+	assert.False(t, watchdog.UnderPressure())
+
+	usage = 90 << 20
+	watchdog.Check(context.Background())
+	assert.True(t, watchdog.UnderPressure())
+	assert.Equal(t, map[string]string{"memory": "memory at 90% of the 100 MiB limit"}, readiness.Pending())
+	profiles, err := filepath.Glob(filepath.Join(watchdog.ProfileDir, "heap-*.pprof"))
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+	info, err := os.Stat(profiles[0])
+	require.NoError(t, err)
+	assert.NotZero(t, info.Size())
+
+	// Just below the threshold is not enough to end pressure
+	usage = 78 << 20
+	watchdog.Check(context.Background())
+	assert.True(t, watchdog.UnderPressure())
+
+	usage = 60 << 20
+	watchdog.Check(context.Background())
+	assert.False(t, watchdog.UnderPressure())
+	assert.Empty(t, readiness.Pending())
+}
+
+func TestMemoryWatchdog_CollectsGarbageFirst(t *testing.T) {
+	readiness := health.NewReadiness()
+	watchdog := health.NewMemoryWatchdog(100<<20, 0.8, readiness)
+	// The second reading, after the collection, is below the threshold
+	readings := []uint64{90 << 20, 40 << 20}
+	watchdog.Usage = func() uint64 {
+		usage := readings[0]
+		if len(readings) > 1 {
+			readings = readings[1:]
+		}
+		return usage
+	}
+
+	watchdog.Check(context.Background())
+	assert.False(t, watchdog.UnderPressure())
+	assert.Empty(t, readiness.Pending())
+}
+
+func TestRouter_ShedsHeavyRoutesUnderMemoryPressure(t *testing.T) {
+	cfg := config.Load()
+	watchdog := health.NewMemoryWatchdog(100<<20, 0.8, health.NewReadiness())
+	watchdog.Usage = func() uint64 { return 99 << 20 }
+	watchdog.Check(context.Background())
+	require.True(t, watchdog.UnderPressure())
+
+	repo := NewMockUserRepository()
+	user, err := repo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	handler := router.New(router.Dependencies{
+		Config:         cfg,
+		UserHandler:    handlers.NewUserHandler(services.NewUserService(repo, services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler:  handlers.NewHealthHandler(nil, nil),
+		MemoryPressure: watchdog,
+	}).Handler()
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": models.RoleAdmin})
+
+	tests := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{"GET", "/api/v1/users", http.StatusServiceUnavailable},
+		{"GET", "/api/v1/users/export", http.StatusServiceUnavailable},
+		{"POST", "/api/v1/users/bulk", http.StatusServiceUnavailable},
+		{"GET", "/api/v1/users/" + idcodec.Plain{}.Encode(user.ID), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", admin)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.expected, rr.Code)
+			if tt.expected == http.StatusServiceUnavailable {
+				assert.Equal(t, "memory-pressure", rr.Header().Get("X-Degraded"))
+				assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/metrics"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Metrics(t *testing.T) {
+	r := router.New(router.Dependencies{
+		Config:        config.Load(),
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Metrics:       metrics.New(),
+	})
+	handler := r.Handler()
+
+	for _, path := range []string{"/api/v1/users/1", "/api/v1/users/2", "/api/v1/nowhere"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// Only internal networks may scrape
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.RemoteAddr = "203.0.113.10:12345"
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	body := rr.Body.String()
+	// Both user lookups share one series labeled with the route template
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/api/v1/users/{id}",status="404"} 2`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/api/v1/users/{id}",status="404"`)
+	assert.Contains(t, body, `http_response_size_bytes_count{method="GET",route="/api/v1/users/{id}",status="404"} 2`)
+	assert.Contains(t, body, `http_requests_in_flight{method="GET",route="/metrics"} 1`)
+	assert.False(t, strings.Contains(body, `route="/api/v1/users/1"`))
+}
+
+func TestRouter_Region(t *testing.T) {
+	cfg := config.Load()
+	cfg.Server.Region = "eu-west-1"
+	healthHandler := handlers.NewHealthHandler(nil, health.NewMonitor(&fakePinger{}, nil, time.Second))
+	healthHandler.SetRegion(cfg.Server.Region)
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: healthHandler,
+		Metrics:       metrics.NewForRegion(cfg.Server.Region),
+	}).Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/health", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "eu-west-1", rr.Header().Get("X-Region"))
+	assert.Contains(t, rr.Body.String(), `"region":"eu-west-1"`)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Contains(t, rr.Body.String(), `http_requests_total{method="GET",region="eu-west-1",route="/api/v1/health",status="200"} 1`)
+	assert.Contains(t, rr.Body.String(), `go_goroutines{region="eu-west-1"}`)
+}
+
+func TestMetrics_ObserveQuery(t *testing.T) {
+	m := metrics.New()
+	m.ObserveQuery("api", "SELECT * FROM users WHERE id = ?", 2*time.Millisecond, nil)
+	m.ObserveQuery("api", "SELECT * FROM users WHERE id = ?", 3*time.Millisecond, errors.New("timeout"))
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rr.Body.String()
+	assert.Contains(t, body, `db_query_duration_seconds_count{pool="api",query="SELECT * FROM users WHERE id = ?"} 2`)
+	assert.Contains(t, body, `db_query_errors_total{pool="api",query="SELECT * FROM users WHERE id = ?"} 1`)
+}
@@ -0,0 +1,238 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordingMiddleware(name string, calls *[]string) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	chain := middleware.NewChain(
+		recordingMiddleware("first", &calls),
+		recordingMiddleware("second", &calls),
+	)
+
+	handler := chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestChains_ExtendDoesNotModifyBase(t *testing.T) {
+	var calls []string
+	chains := middleware.NewChains()
+	chains.Register("base", middleware.NewChain(recordingMiddleware("base", &calls)))
+	chains.Extend("extended", "base", recordingMiddleware("extra", &calls))
+
+	assert.Len(t, chains.Get("base"), 1)
+	assert.Len(t, chains.Get("extended"), 2)
+}
+
+func TestChains_UnknownChainPanics(t *testing.T) {
+	chains := middleware.NewChains()
+
+	assert.Panics(t, func() { chains.Get("missing") })
+}
+
+func TestPathNormalization(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		lowercase    func(string) string
+		target       string
+		expectedCode int
+		expectedPath string
+	}{
+		{"redirect trailing slash", middleware.TrailingSlashRedirect, nil, "/users/?page=2", http.StatusPermanentRedirect, "/users?page=2"},
+		{"rewrite trailing slash", middleware.TrailingSlashRewrite, nil, "/users/", http.StatusOK, "/users"},
+		{"rewrite lowercase", middleware.TrailingSlashRewrite, strings.ToLower, "/Users", http.StatusOK, "/users"},
+		{"off keeps slash", middleware.TrailingSlashOff, nil, "/users/", http.StatusOK, "/users/"},
+		{"root untouched", middleware.TrailingSlashRedirect, nil, "/", http.StatusOK, "/"},
+		{"redirect stays on host", middleware.TrailingSlashRedirect, nil, "//evil.com/", http.StatusPermanentRedirect, "/evil.com"},
+		{"redirect ignores backslashes", middleware.TrailingSlashRedirect, nil, "/%5Cevil.com/", http.StatusPermanentRedirect, "/evil.com"},
+		{"rewrite leading slashes", middleware.TrailingSlashRewrite, nil, "//users", http.StatusOK, "/users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seenPath string
+			handler := middleware.PathNormalizationMiddleware(tt.mode, tt.lowercase)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					seenPath = r.URL.Path
+				}),
+			)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", tt.target, nil))
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			if tt.expectedCode == http.StatusPermanentRedirect {
+				assert.Equal(t, tt.expectedPath, rr.Header().Get("Location"))
+			} else {
+				assert.Equal(t, tt.expectedPath, seenPath)
+			}
+		})
+	}
+}
+
+func TestRequestTimeoutMiddleware(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	handler := middleware.RequestTimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/users", nil))
+
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+}
+
+func TestResponseTransformMiddleware(t *testing.T) {
+	handler := middleware.ResponseTransformMiddleware(time.Second, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message":"Users retrieved successfully","data":{"users":[{"id":1,"name":"John"},{"id":2,"name":"Jane"}]}}`))
+	}))
+
+	tests := []struct {
+		transform string
+		code      int
+		expected  string
+	}{
+		{"", http.StatusOK, `"users":[{"id":1,"name":"John"}`},
+		{"users[].name", http.StatusOK, `"data":["John","Jane"]`},
+		{"length(users)", http.StatusOK, `"data":2`},
+		{"users[?contains(name, 'Ja')].id", http.StatusOK, `"data":[2]`},
+		{"users[", http.StatusBadRequest, "invalid transform expression"},
+		{"merge(users[0], `{}`)", http.StatusBadRequest, `function \"merge\" is not allowed`},
+		{"merge\n(users[0], `{}`)", http.StatusBadRequest, `function \"merge\" is not allowed`},
+		{"merge\r\n (users[0], `{}`)", http.StatusBadRequest, `function \"merge\" is not allowed`},
+		{"users[?name == 'merge(x)'].id", http.StatusOK, `"data":[]`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/users", nil)
+		if tt.transform != "" {
+			q := req.URL.Query()
+			q.Set("transform", tt.transform)
+			req.URL.RawQuery = q.Encode()
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.code, rr.Code, tt.transform)
+		assert.Contains(t, rr.Body.String(), tt.expected, tt.transform)
+	}
+}
+
+func TestResponseTransformMiddleware_StreamingAndLargeResponses(t *testing.T) {
+	streaming := func(r *http.Request) bool { return r.URL.Path == "/api/v1/users/export" }
+	handler := middleware.ResponseTransformMiddleware(time.Second, streaming)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":"`))
+		w.Write([]byte(strings.Repeat("x", 2<<20)))
+		w.Write([]byte(`"}`))
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/users/export?transform=length(@)", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "not supported on streaming responses")
+
+	req = httptest.NewRequest("GET", "/api/v1/users?transform=length(@)", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), "too large to transform")
+}
+
+func TestForwardedMiddleware(t *testing.T) {
+	proxies, err := forwarded.ParseProxies([]string{"192.0.2.0/24", "2001:db8::1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		proto      string
+		host       string
+		expected   string
+	}{
+		{"trusted proxy", "192.0.2.7:4000", "https", "api.example.com", "https://api.example.com/users"},
+		{"trusted IPv6 proxy", "[2001:db8::1]:4000", "https", "api.example.com", "https://api.example.com/users"},
+		{"untrusted peer", "203.0.113.9:4000", "https", "evil.example", "http://example.com/users"},
+		{"first of several values", "192.0.2.7:4000", "https, http", "api.example.com, inner", "https://api.example.com/users"},
+		{"unknown scheme", "192.0.2.7:4000", "ftp", "", "http://example.com/users"},
+		{"host with a path", "192.0.2.7:4000", "", "evil.example/x", "http://example.com/users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var url string
+			handler := middleware.ForwardedMiddleware(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				url = forwarded.URL(r, "/users")
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-Proto", tt.proto)
+			req.Header.Set("X-Forwarded-Host", tt.host)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expected, url)
+		})
+	}
+}
+
+func TestProxies_ClientIPFromHeaders(t *testing.T) {
+	proxies, err := forwarded.ParseProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name     string
+		headers  []string
+		expected string
+	}{
+		{"no header", nil, "10.0.0.5"},
+		{"one hop", []string{"203.0.113.9"}, "203.0.113.9"},
+		{"spoofed hops are skipped", []string{"198.51.100.1, 203.0.113.9"}, "203.0.113.9"},
+		{"inner proxies are skipped", []string{"203.0.113.9, 10.0.0.7"}, "203.0.113.9"},
+		{"several headers", []string{"198.51.100.1", "203.0.113.9, 10.0.0.7"}, "203.0.113.9"},
+		{"hop with a port", []string{"[2001:db8::9]:4000"}, "2001:db8::9"},
+		{"garbage stops the walk", []string{"203.0.113.9, nonsense, 10.0.0.7"}, "10.0.0.7"},
+		{"only proxies", []string{"10.0.0.8, 10.0.0.7"}, "10.0.0.8"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			for _, header := range tt.headers {
+				req.Header.Add("X-Forwarded-For", header)
+			}
+			assert.Equal(t, tt.expected, proxies.ClientIPFromHeaders(req, "10.0.0.5"))
+		})
+	}
+}
+
+func TestParseProxies_RejectsInvalidEntries(t *testing.T) {
+	for _, entry := range []string{"proxy.internal", "10.0.0.0/33"} {
+		_, err := forwarded.ParseProxies([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
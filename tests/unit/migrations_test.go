@@ -0,0 +1,246 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMigrator returns a Migrator for driver
+func newMigrator(t *testing.T, driver string) *database.Migrator {
+	t.Helper()
+	migrator, err := database.NewMigrator(driver)
+	require.NoError(t, err)
+	return migrator
+}
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrator := newMigrator(t, database.DriverPostgres)
+	migrations := migrator.Migrations()
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, len(migrations), migrator.SchemaVersion())
+	assert.Equal(t, "initial", migrations[0].Name)
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version)
+		assert.NotEmpty(t, m.Up, m.Name)
+		assert.NotEmpty(t, m.Down, m.Name)
+	}
+}
+
+func TestNewMigrator_MySQLMigrations(t *testing.T) {
+	migrator := newMigrator(t, database.DriverMySQL)
+	migrations := migrator.Migrations()
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, len(migrations), migrator.SchemaVersion())
+	assert.Contains(t, migrations[0].Up, "AUTO_INCREMENT")
+
+	// Each Migrator keeps its own driver's migrations
+	assert.NotEqual(t, migrations[0].Up, newMigrator(t, database.DriverPostgres).Migrations()[0].Up)
+	_, err := database.NewMigrator("oracle")
+	assert.Error(t, err)
+}
+
+func TestNewMigrator_SQLiteMigrations(t *testing.T) {
+	migrator := newMigrator(t, database.DriverSQLite)
+	migrations := migrator.Migrations()
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, len(migrations), migrator.SchemaVersion())
+	assert.Contains(t, migrations[0].Up, "AUTOINCREMENT")
+}
+
+func TestLoadMigrations_RejectsBadSets(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		err   string
+	}{
+		{
+			name: "gap",
+			files: fstest.MapFS{
+				"migrations/0001_a.up.sql": file, "migrations/0001_a.down.sql": file,
+				"migrations/0003_c.up.sql": file, "migrations/0003_c.down.sql": file,
+			},
+			err: "migration 2 is missing",
+		},
+		{
+			name:  "missing down",
+			files: fstest.MapFS{"migrations/0001_a.up.sql": file},
+			err:   "migration 1 needs both an up and a down file",
+		},
+		{
+			name:  "bad name",
+			files: fstest.MapFS{"migrations/add_users.sql": file},
+			err:   `invalid migration file name "add_users.sql"`,
+		},
+		{
+			name: "mismatched names",
+			files: fstest.MapFS{
+				"migrations/0001_a.up.sql": file, "migrations/0001_b.down.sql": file,
+			},
+			err: `migration 1 has files named "a" and "b"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := database.LoadMigrations(tt.files, "migrations")
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+// expectMigrationLock expects the statements every migration run starts with
+func expectMigrationLock(mock sqlmock.Sqlmock, tracked, legacy bool) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM schema_migrations)")).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tracked))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT to_regclass('schema_version')")).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(legacy))
+}
+
+func TestRunMigrations_ImportsLegacyVersionAndAppliesPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	migrator := newMigrator(t, database.DriverPostgres)
+	migrations := migrator.Migrations()
+
+	// The database was migrated to version 2 before files were versioned
+	expectMigrationLock(mock, false, true)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(version), 0) FROM schema_version")).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	for _, m := range migrations[:2] {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations")).
+			WithArgs(m.Version, m.Name).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	applied := sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version FROM schema_migrations")).WillReturnRows(applied)
+	for _, m := range migrations[2:] {
+		mock.ExpectExec(regexp.QuoteMeta(m.Up)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations")).
+			WithArgs(m.Version, m.Name).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	require.NoError(t, migrator.RunMigrations(db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRollbackMigration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	migrator := newMigrator(t, database.DriverPostgres)
+	newest := migrator.Migrations()[migrator.SchemaVersion()-1]
+
+	expectMigrationLock(mock, true, false)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(version), 0) FROM schema_migrations")).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(newest.Version))
+	mock.ExpectExec(regexp.QuoteMeta(newest.Down)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations")).
+		WithArgs(newest.Version).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	version, err := migrator.RollbackMigration(db)
+	require.NoError(t, err)
+	assert.Equal(t, newest.Version, version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Versions from a newer binary cannot be rolled back
+	expectMigrationLock(mock, true, false)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(version), 0) FROM schema_migrations")).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(newest.Version + 1))
+	mock.ExpectRollback()
+
+	_, err = migrator.RollbackMigration(db)
+	assert.ErrorContains(t, err, "newer than this binary")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForceVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	migrator := newMigrator(t, database.DriverPostgres)
+	migrations := migrator.Migrations()
+
+	expectMigrationLock(mock, true, false)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version > $1")).
+		WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 3))
+	for _, m := range migrations[:2] {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations")).
+			WithArgs(m.Version, m.Name).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectCommit()
+
+	require.NoError(t, migrator.ForceVersion(db, 2))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.ErrorContains(t, migrator.ForceVersion(db, migrator.SchemaVersion()+1), "unknown migration version")
+}
+
+func TestMigrationStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	migrator := newMigrator(t, database.DriverPostgres)
+	appliedAt := time.Date(2025, 8, 11, 5, 34, 7, 0, time.UTC)
+
+	// Version 1 is applied, and a newer binary applied a version this one lacks
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"}).
+		AddRow(1, "initial", appliedAt).
+		AddRow(migrator.SchemaVersion()+1, "from_the_future", appliedAt)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, name, applied_at FROM schema_migrations")).WillReturnRows(rows)
+
+	states, err := migrator.MigrationStatus(context.Background(), db)
+	require.NoError(t, err)
+	require.Len(t, states, migrator.SchemaVersion()+1)
+	assert.Equal(t, appliedAt, *states[0].AppliedAt)
+	assert.Nil(t, states[1].AppliedAt)
+	assert.Equal(t, "from_the_future", states[migrator.SchemaVersion()].Name)
+
+	// A database that was never migrated has every migration pending
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, name, applied_at FROM schema_migrations")).
+		WillReturnError(&pgconn.PgError{Code: "42P01"})
+	states, err = migrator.MigrationStatus(context.Background(), db)
+	require.NoError(t, err)
+	require.Len(t, states, migrator.SchemaVersion())
+	for _, state := range states {
+		assert.Nil(t, state.AppliedAt)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunCommand(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	migrator := newMigrator(t, database.DriverPostgres)
+	appliedAt := time.Date(2025, 8, 11, 5, 34, 7, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "initial", appliedAt)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, name, applied_at FROM schema_migrations")).WillReturnRows(rows)
+	var out strings.Builder
+	require.NoError(t, migrator.RunCommand(db, &out, "status", nil))
+	assert.Contains(t, out.String(), "2025-08-11T05:34:07Z")
+	assert.Contains(t, out.String(), fmt.Sprintf("\n%d pending\n", migrator.SchemaVersion()-1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.ErrorContains(t, migrator.RunCommand(db, &out, "down", []string{"zero"}), "invalid number of migrations")
+	assert.ErrorContains(t, migrator.RunCommand(db, &out, "force", nil), "force needs a version")
+	assert.ErrorContains(t, migrator.RunCommand(db, &out, "sideways", nil), "unknown command")
+}
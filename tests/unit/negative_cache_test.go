@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingUserRepository counts the lookups that reach the repository
+type countingUserRepository struct {
+	*MockUserRepository
+	lookups int
+}
+
+func (r *countingUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.lookups++
+	return r.MockUserRepository.GetByID(ctx, id)
+}
+
+func (r *countingUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.lookups++
+	return r.MockUserRepository.GetByEmail(ctx, email)
+}
+
+func TestNegativeCache_RemembersMissingUsers(t *testing.T) {
+	inner := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	repo := repository.NewNegativeCacheUserRepository(inner, newTestCache(t), time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.GetByID(ctx, 42)
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+		_, err = repo.GetByEmail(ctx, "ada@example.com")
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	}
+	assert.Equal(t, 2, inner.lookups)
+}
+
+func TestNegativeCache_CreateForgetsMissingUser(t *testing.T) {
+	inner := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	repo := repository.NewNegativeCacheUserRepository(inner, newTestCache(t), time.Minute)
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, 1)
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+	_, err = repo.GetByEmail(ctx, "ada@example.com")
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	created, err := repo.Create(ctx, &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+	require.Equal(t, 1, created.ID)
+
+	user, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", user.Email)
+	user, err = repo.GetByEmail(ctx, "ada@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+}
+
+func TestNegativeCache_UpsertByJobsForgetsMissingUser(t *testing.T) {
+	// Requests and jobs use separate repositories over one database and
+	// one cache
+	base := NewMockUserRepository()
+	missing := newTestCache(t)
+	repo := repository.NewNegativeCacheUserRepository(base, missing, time.Minute)
+	jobRepo := repository.NewNegativeCacheUserRepository(base, missing, time.Minute)
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, 1)
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+	_, err = repo.GetByEmail(ctx, "ada@example.com")
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	created, isNew, err := jobRepo.Upsert(ctx, &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+	require.True(t, isNew)
+	require.Equal(t, 1, created.ID)
+
+	_, err = repo.GetByID(ctx, 1)
+	assert.NoError(t, err)
+	_, err = repo.GetByEmail(ctx, "ada@example.com")
+	assert.NoError(t, err)
+}
+
+func TestNegativeCache_ExpiresAfterTTL(t *testing.T) {
+	inner := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	repo := repository.NewNegativeCacheUserRepository(inner, newTestCache(t), 20*time.Millisecond)
+	ctx := context.Background()
+
+	repo.GetByID(ctx, 42)
+	repo.GetByID(ctx, 42)
+	assert.Equal(t, 1, inner.lookups)
+
+	time.Sleep(50 * time.Millisecond)
+	repo.GetByID(ctx, 42)
+	assert.Equal(t, 2, inner.lookups)
+}
+
+func TestNegativeCache_FoundUsersAreNotCached(t *testing.T) {
+	inner := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	repo := repository.NewNegativeCacheUserRepository(inner, newTestCache(t), time.Minute)
+	ctx := context.Background()
+
+	_, err := inner.Create(ctx, &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+
+	repo.GetByID(ctx, 1)
+	repo.GetByID(ctx, 1)
+	assert.Equal(t, 2, inner.lookups)
+}
@@ -0,0 +1,476 @@
+package unit
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockOAuthRepository implements OAuthRepository interface for testing
+type MockOAuthRepository struct {
+	clients     map[string]*models.OAuthClient
+	codes       map[string]*models.AuthorizationCode
+	deviceCodes map[string]*models.DeviceCode
+}
+
+func NewMockOAuthRepository() *MockOAuthRepository {
+	return &MockOAuthRepository{
+		clients:     make(map[string]*models.OAuthClient),
+		codes:       make(map[string]*models.AuthorizationCode),
+		deviceCodes: make(map[string]*models.DeviceCode),
+	}
+}
+
+func (m *MockOAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) (*models.OAuthClient, error) {
+	m.clients[client.ID] = client
+	return client, nil
+}
+
+func (m *MockOAuthRepository) GetClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	if client, exists := m.clients[id]; exists {
+		return client, nil
+	}
+	return nil, apperrors.NotFound("oauth client not found")
+}
+
+func (m *MockOAuthRepository) SaveAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	m.codes[code.CodeHash] = code
+	return nil
+}
+
+func (m *MockOAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	code, exists := m.codes[codeHash]
+	if !exists {
+		return nil, apperrors.NotFound("authorization code not found")
+	}
+	delete(m.codes, codeHash)
+	return code, nil
+}
+
+func (m *MockOAuthRepository) SaveDeviceCode(ctx context.Context, code *models.DeviceCode) error {
+	m.deviceCodes[code.DeviceCodeHash] = code
+	return nil
+}
+
+func (m *MockOAuthRepository) GetDeviceCode(ctx context.Context, deviceCodeHash string) (*models.DeviceCode, error) {
+	if code, exists := m.deviceCodes[deviceCodeHash]; exists {
+		return code, nil
+	}
+	return nil, apperrors.NotFound("device code not found")
+}
+
+func (m *MockOAuthRepository) GetDeviceCodeByUserCode(ctx context.Context, userCode string) (*models.DeviceCode, error) {
+	for _, code := range m.deviceCodes {
+		if code.UserCode == userCode {
+			return code, nil
+		}
+	}
+	return nil, apperrors.NotFound("device code not found")
+}
+
+func (m *MockOAuthRepository) UpdateDeviceCodeStatus(ctx context.Context, deviceCodeHash, status string, userID *int) error {
+	code, exists := m.deviceCodes[deviceCodeHash]
+	if !exists || code.Status != models.DeviceCodePending {
+		return apperrors.NotFound("device code not found")
+	}
+	code.Status = status
+	code.UserID = userID
+	return nil
+}
+
+func (m *MockOAuthRepository) TouchDeviceCode(ctx context.Context, deviceCodeHash string, interval int) error {
+	if code, exists := m.deviceCodes[deviceCodeHash]; exists {
+		now := time.Now()
+		code.LastPolledAt = &now
+		code.Interval = interval
+	}
+	return nil
+}
+
+func (m *MockOAuthRepository) DeleteDeviceCode(ctx context.Context, deviceCodeHash string) error {
+	delete(m.deviceCodes, deviceCodeHash)
+	return nil
+}
+
+func newTestOIDCService(t *testing.T) (*services.OIDCService, *MockUserRepository) {
+	userRepo := NewMockUserRepository()
+	oidcService, err := services.NewOIDCService(NewMockOAuthRepository(), userRepo, config.Load(), zap.NewNop())
+	require.NoError(t, err)
+	return oidcService, userRepo
+}
+
+func TestOIDCService_AuthorizationCodeFlow(t *testing.T) {
+	oidcService, userRepo := newTestOIDCService(t)
+	user, _ := userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "Internal Dashboard",
+		RedirectURIs: []string{"https://dashboard.internal/callback"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, client.ClientSecret)
+
+	verifier := strings.Repeat("v", 50)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	redirect, err := oidcService.Authorize(context.Background(), user.ID, &models.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ID,
+		RedirectURI:         "https://dashboard.internal/callback",
+		Scope:               "openid email profile",
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: "S256",
+	})
+	require.NoError(t, err)
+
+	location, err := url.Parse(redirect)
+	require.NoError(t, err)
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+	code := location.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	tokenReq := &models.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  "https://dashboard.internal/callback",
+		ClientID:     client.ID,
+		ClientSecret: client.ClientSecret,
+		CodeVerifier: verifier,
+	}
+	token, err := oidcService.Token(context.Background(), tokenReq)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", token.TokenType)
+
+	// The ID token verifies against the published key set
+	jwk := oidcService.JWKS().Keys[0]
+	idToken, err := jwt.Parse(token.IDToken, func(tok *jwt.Token) (interface{}, error) {
+		assert.Equal(t, jwk.KeyID, tok.Header["kid"])
+		return oidcServicePublicKey(t, jwk), nil
+	})
+	require.NoError(t, err)
+	claims := idToken.Claims.(jwt.MapClaims)
+	assert.Equal(t, "1", claims["sub"])
+	assert.Equal(t, client.ID, claims["aud"])
+	assert.Equal(t, "n-0S6", claims["nonce"])
+	assert.Equal(t, "john@example.com", claims["email"])
+
+	// Codes are single use
+	_, err = oidcService.Token(context.Background(), tokenReq)
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestOIDCService_RejectsWrongVerifier(t *testing.T) {
+	oidcService, userRepo := newTestOIDCService(t)
+	user, _ := userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "CLI",
+		RedirectURIs: []string{"http://127.0.0.1:9999/callback"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, client.ClientSecret)
+
+	redirect, err := oidcService.Authorize(context.Background(), user.ID, &models.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ID,
+		RedirectURI:         "http://127.0.0.1:9999/callback",
+		Scope:               "openid",
+		CodeChallenge:       "challenge-that-does-not-match",
+		CodeChallengeMethod: "S256",
+	})
+	require.NoError(t, err)
+	location, _ := url.Parse(redirect)
+
+	_, err = oidcService.Token(context.Background(), &models.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         location.Query().Get("code"),
+		RedirectURI:  "http://127.0.0.1:9999/callback",
+		ClientID:     client.ID,
+		CodeVerifier: strings.Repeat("v", 50),
+	})
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestOIDCService_AuthorizeRejectsUnregisteredRedirect(t *testing.T) {
+	oidcService, _ := newTestOIDCService(t)
+
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "Internal Dashboard",
+		RedirectURIs: []string{"https://dashboard.internal/callback"},
+	})
+	require.NoError(t, err)
+
+	_, err = oidcService.Authorize(context.Background(), 1, &models.AuthorizeRequest{
+		ResponseType: "code",
+		ClientID:     client.ID,
+		RedirectURI:  "https://evil.example/callback",
+		Scope:        "openid",
+	})
+	assert.ErrorContains(t, err, "invalid_request")
+}
+
+func TestOIDCService_AuthorizeRequiresPKCE(t *testing.T) {
+	oidcService, _ := newTestOIDCService(t)
+
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "Internal Dashboard",
+		RedirectURIs: []string{"https://dashboard.internal/callback"},
+	})
+	require.NoError(t, err)
+
+	redirect, err := oidcService.Authorize(context.Background(), 1, &models.AuthorizeRequest{
+		ResponseType: "code",
+		ClientID:     client.ID,
+		RedirectURI:  "https://dashboard.internal/callback",
+		Scope:        "openid",
+	})
+	require.NoError(t, err)
+
+	location, _ := url.Parse(redirect)
+	assert.Equal(t, "invalid_request", location.Query().Get("error"))
+	assert.Empty(t, location.Query().Get("code"))
+}
+
+// oidcServicePublicKey rebuilds an RSA public key from a JWK
+func TestOIDCService_AccessTokenScopes(t *testing.T) {
+	oidcService, userRepo := newTestOIDCService(t)
+	user, _ := userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	_, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "Widget",
+		RedirectURIs: []string{"https://widget.example.com/callback"},
+		Scopes:       []string{"users:delete"},
+	})
+	assert.ErrorContains(t, err, "unknown scope")
+
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "Reporting",
+		RedirectURIs: []string{"https://reports.example.com/callback"},
+		Public:       true,
+		Scopes:       []string{services.ScopeUsersRead, services.ScopeAdminRunbook},
+	})
+	require.NoError(t, err)
+
+	verifier := strings.Repeat("v", 50)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	issue := func(scope string) *models.TokenResponse {
+		redirect, err := oidcService.Authorize(context.Background(), user.ID, &models.AuthorizeRequest{
+			ResponseType:        "code",
+			ClientID:            client.ID,
+			RedirectURI:         "https://reports.example.com/callback",
+			Scope:               scope,
+			CodeChallenge:       challenge,
+			CodeChallengeMethod: "S256",
+		})
+		require.NoError(t, err)
+		location, _ := url.Parse(redirect)
+		token, err := oidcService.Token(context.Background(), &models.TokenRequest{
+			GrantType:    services.GrantTypeAuthorizationCode,
+			Code:         location.Query().Get("code"),
+			RedirectURI:  "https://reports.example.com/callback",
+			ClientID:     client.ID,
+			CodeVerifier: verifier,
+		})
+		require.NoError(t, err)
+		return token
+	}
+	tokenScope := func(token *models.TokenResponse) interface{} {
+		parsed, err := jwt.Parse(token.AccessToken, func(*jwt.Token) (interface{}, error) {
+			return []byte(config.Load().JWT.Secret), nil
+		})
+		require.NoError(t, err)
+		return parsed.Claims.(jwt.MapClaims)["scope"]
+	}
+
+	// Scopes the client was not registered with, or the user's role does
+	// not allow, are dropped from the token
+	requested := "openid users:read users:write admin:runbook users:read made:up"
+	token := issue(requested)
+	assert.Equal(t, "openid users:read", token.Scope)
+	assert.Equal(t, "openid users:read", tokenScope(token))
+
+	user.Role = models.RoleAdmin
+	token = issue(requested)
+	assert.Equal(t, "openid users:read admin:runbook", tokenScope(token))
+
+	// The device grant is capped the same way
+	user.Role = models.RoleUser
+	device, err := oidcService.StartDeviceAuthorization(context.Background(), client.ID, "", "openid admin:runbook users:read")
+	require.NoError(t, err)
+	require.NoError(t, oidcService.DecideDevice(context.Background(), user.ID, &models.DeviceApprovalRequest{UserCode: device.UserCode, Approve: true}))
+	token, err = oidcService.Token(context.Background(), &models.TokenRequest{
+		GrantType:  services.GrantTypeDeviceCode,
+		ClientID:   client.ID,
+		DeviceCode: device.DeviceCode,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "openid users:read", tokenScope(token))
+}
+
+func TestOIDCService_AuthorizeDenied(t *testing.T) {
+	oidcService, _ := newTestOIDCService(t)
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "Internal Dashboard",
+		RedirectURIs: []string{"https://dashboard.internal/callback"},
+	})
+	require.NoError(t, err)
+
+	redirect, err := oidcService.Authorize(context.Background(), 1, &models.AuthorizeRequest{
+		ClientID:    client.ID,
+		RedirectURI: "https://dashboard.internal/callback",
+		State:       "xyz",
+		Denied:      true,
+	})
+	require.NoError(t, err)
+	location, err := url.Parse(redirect)
+	require.NoError(t, err)
+	assert.Equal(t, "access_denied", location.Query().Get("error"))
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+	assert.Empty(t, location.Query().Get("code"))
+}
+
+func TestOIDCHandler_AuthorizationPage(t *testing.T) {
+	oidcService, userRepo := newTestOIDCService(t)
+	user, _ := userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "Internal Dashboard",
+		RedirectURIs: []string{"https://dashboard.internal/callback"},
+	})
+	require.NoError(t, err)
+	handler := handlers.NewOIDCHandler(oidcService, "/api/v1")
+
+	// The page carries the request parameters, escaped for its script
+	rr := httptest.NewRecorder()
+	handler.AuthorizePage(rr, httptest.NewRequest("GET", "/authorize?client_id="+client.ID+"&state=%3C%2Fscript%3E", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "DENY", rr.Header().Get("X-Frame-Options"))
+	assert.Contains(t, rr.Body.String(), client.ID)
+	assert.Contains(t, rr.Body.String(), `"state":"\u003c/script\u003e"`)
+
+	// Its decision is answered with where to send the browser
+	body := `{"response_type":"code","client_id":"` + client.ID + `","redirect_uri":"https://dashboard.internal/callback",` +
+		`"scope":"openid","state":"xyz","code_challenge":"` + strings.Repeat("c", 43) + `","code_challenge_method":"S256"}`
+	req := httptest.NewRequest("POST", "/api/v1/oauth/authorize", strings.NewReader(body))
+	req = req.WithContext(actor.NewContext(req.Context(), actor.Actor{UserID: strconv.Itoa(user.ID)}))
+	rr = httptest.NewRecorder()
+	handler.DecideAuthorization(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var decided struct {
+		Data models.AuthorizeResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &decided))
+	location, err := url.Parse(decided.Data.RedirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, "dashboard.internal", location.Host)
+	assert.NotEmpty(t, location.Query().Get("code"))
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+}
+
+func oidcServicePublicKey(t *testing.T, jwk models.JSONWebKey) *rsa.PublicKey {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	require.NoError(t, err)
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	require.NoError(t, err)
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+}
+
+func TestOIDCService_DeviceAuthorizationFlow(t *testing.T) {
+	oidcService, userRepo := newTestOIDCService(t)
+	user, _ := userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "crudctl",
+		RedirectURIs: []string{"http://127.0.0.1/unused"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+
+	device, err := oidcService.StartDeviceAuthorization(context.Background(), client.ID, "", "openid profile")
+	require.NoError(t, err)
+	assert.Regexp(t, `^[A-Z]{4}-[A-Z]{4}$`, device.UserCode)
+	assert.Contains(t, device.VerificationURIComplete, device.UserCode)
+
+	poll := &models.TokenRequest{
+		GrantType:  services.GrantTypeDeviceCode,
+		ClientID:   client.ID,
+		DeviceCode: device.DeviceCode,
+	}
+
+	_, err = oidcService.Token(context.Background(), poll)
+	assert.ErrorContains(t, err, "authorization_pending")
+
+	// Polling again immediately is too fast
+	_, err = oidcService.Token(context.Background(), poll)
+	assert.ErrorContains(t, err, "slow_down")
+
+	pending, err := oidcService.GetPendingDevice(context.Background(), strings.ToLower(strings.ReplaceAll(device.UserCode, "-", "")))
+	require.NoError(t, err)
+	assert.Equal(t, "crudctl", pending.ClientName)
+
+	err = oidcService.DecideDevice(context.Background(), user.ID, &models.DeviceApprovalRequest{UserCode: device.UserCode, Approve: true})
+	require.NoError(t, err)
+
+	token, err := oidcService.Token(context.Background(), poll)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.AccessToken)
+	assert.NotEmpty(t, token.IDToken)
+
+	// Device codes are single use
+	_, err = oidcService.Token(context.Background(), poll)
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestOIDCService_DeviceAuthorizationDenied(t *testing.T) {
+	oidcService, userRepo := newTestOIDCService(t)
+	user, _ := userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	client, err := oidcService.RegisterClient(context.Background(), &models.CreateOAuthClientRequest{
+		Name:         "crudctl",
+		RedirectURIs: []string{"http://127.0.0.1/unused"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+
+	device, err := oidcService.StartDeviceAuthorization(context.Background(), client.ID, "", "openid")
+	require.NoError(t, err)
+
+	err = oidcService.DecideDevice(context.Background(), user.ID, &models.DeviceApprovalRequest{UserCode: device.UserCode, Approve: false})
+	require.NoError(t, err)
+
+	_, err = oidcService.Token(context.Background(), &models.TokenRequest{
+		GrantType:  services.GrantTypeDeviceCode,
+		ClientID:   client.ID,
+		DeviceCode: device.DeviceCode,
+	})
+	assert.ErrorContains(t, err, "access_denied")
+}
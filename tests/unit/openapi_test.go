@@ -0,0 +1,199 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pratham15541/go-crud/docs/swagger"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/openapi"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const swaggerFixture = `{
+	"swagger": "2.0",
+	"info": {"title": "Test", "version": "1.0"},
+	"basePath": "/api/v1",
+	"paths": {
+		"/users": {
+			"post": {
+				"consumes": ["application/json"],
+				"produces": ["application/json"],
+				"security": [{"BearerAuth": []}],
+				"parameters": [
+					{"description": "User", "name": "user", "in": "body", "required": true,
+					 "schema": {"$ref": "#/definitions/models.CreateUserRequest"}},
+					{"type": "string", "collectionFormat": "csv", "name": "fields", "in": "query"}
+				],
+				"responses": {
+					"201": {"description": "Created", "schema": {"$ref": "#/definitions/models.Page"}},
+					"204": {"description": "No Content"}
+				}
+			}
+		}
+	},
+	"definitions": {
+		"models.CreateUserRequest": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string", "maxLength": 100},
+				"age": {"type": "integer", "minimum": 0, "exclusiveMinimum": true},
+				"nickname": {"type": "string", "x-nullable": true, "example": "jd"}
+			}
+		},
+		"models.Page": {
+			"type": "object",
+			"properties": {
+				"items": {"type": "array", "items": {"$ref": "#/definitions/models.CreateUserRequest"}},
+				"next": {"$ref": "#/definitions/models.Page"}
+			}
+		}
+	},
+	"securityDefinitions": {
+		"BearerAuth": {"type": "apiKey", "name": "Authorization", "in": "header"}
+	}
+}`
+
+func newFixtureSpec(t *testing.T) *openapi.Spec {
+	spec, err := openapi.New([]byte(swaggerFixture))
+	require.NoError(t, err)
+	return spec
+}
+
+func decodeJSON(t *testing.T, data []byte) map[string]interface{} {
+	var v map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &v))
+	return v
+}
+
+// dig follows keys through nested JSON objects
+func dig(v interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		m, _ := v.(map[string]interface{})
+		v = m[key]
+	}
+	return v
+}
+
+func TestOpenAPI_ConvertsSwaggerTo30(t *testing.T) {
+	data, ok := newFixtureSpec(t).JSON(openapi.Version30)
+	require.True(t, ok)
+	doc := decodeJSON(t, data)
+
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.Equal(t, "/api/v1", dig(doc["servers"].([]interface{})[0], "url"))
+
+	post := dig(doc, "paths", "/users", "post")
+	assert.Equal(t, "#/components/schemas/CreateUserRequest",
+		dig(post, "requestBody", "content", "application/json", "schema", "$ref"))
+	assert.Equal(t, true, dig(post, "requestBody", "required"))
+	params := dig(post, "parameters").([]interface{})
+	require.Len(t, params, 1)
+	assert.Equal(t, "string", dig(params[0], "schema", "type"))
+	assert.Equal(t, false, dig(params[0], "explode"))
+	assert.Equal(t, "#/components/schemas/Page",
+		dig(post, "responses", "201", "content", "application/json", "schema", "$ref"))
+	assert.Nil(t, dig(post, "responses", "204", "content"))
+
+	user := dig(doc, "components", "schemas", "CreateUserRequest")
+	assert.Equal(t, true, dig(user, "properties", "nickname", "nullable"))
+	assert.Equal(t, true, dig(user, "properties", "age", "exclusiveMinimum"))
+	assert.Equal(t, "apiKey", dig(doc, "components", "securitySchemes", "BearerAuth", "type"))
+}
+
+func TestOpenAPI_ConvertsSwaggerTo31(t *testing.T) {
+	data, ok := newFixtureSpec(t).JSON(openapi.Version31)
+	require.True(t, ok)
+	doc := decodeJSON(t, data)
+
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	user := dig(doc, "components", "schemas", "CreateUserRequest")
+	assert.Equal(t, []interface{}{"string", "null"}, dig(user, "properties", "nickname", "type"))
+	assert.Equal(t, []interface{}{"jd"}, dig(user, "properties", "nickname", "examples"))
+	assert.Equal(t, float64(0), dig(user, "properties", "age", "exclusiveMinimum"))
+	assert.Nil(t, dig(user, "properties", "age", "minimum"))
+
+	_, ok = newFixtureSpec(t).JSON("2.0")
+	assert.False(t, ok)
+}
+
+func TestOpenAPI_YAMLMatchesJSON(t *testing.T) {
+	data, ok := newFixtureSpec(t).YAML(openapi.Version31)
+	require.True(t, ok)
+	doc := string(data)
+
+	assert.True(t, strings.HasPrefix(doc, "components:\n"))
+	assert.Contains(t, doc, "openapi: \"3.1.0\"\n")
+	assert.Contains(t, doc, "servers:\n- url: /api/v1\n")
+	assert.Contains(t, doc, "$ref: \"#/components/schemas/CreateUserRequest\"\n")
+	assert.Contains(t, doc, "- \"null\"\n")
+	assert.Contains(t, doc, "version: \"1.0\"\n")
+}
+
+func TestOpenAPI_SchemasAreStandalone(t *testing.T) {
+	spec := newFixtureSpec(t)
+	assert.Equal(t, []string{"CreateUserRequest", "Page"}, spec.SchemaNames())
+
+	data, ok := spec.Schema("Page")
+	require.True(t, ok)
+	schema := decodeJSON(t, data)
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+	assert.Equal(t, "Page", schema["title"])
+	// References to itself point at the root; others are copied in
+	assert.Equal(t, "#", dig(schema, "properties", "next", "$ref"))
+	assert.Equal(t, "#/$defs/CreateUserRequest", dig(schema, "properties", "items", "items", "$ref"))
+	assert.Equal(t, []interface{}{"name"}, dig(schema, "$defs", "CreateUserRequest", "required"))
+
+	_, ok = spec.Schema("Missing")
+	assert.False(t, ok)
+}
+
+func TestOpenAPIRoutes_ServeGeneratedDocument(t *testing.T) {
+	cfg := config.Load()
+	cfg.Server.Swagger = true
+	cfg.Server.OpenAPIVersion = openapi.Version30
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+	}).Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/openapi.json")
+	require.Equal(t, http.StatusOK, rr.Code)
+	doc := decodeJSON(t, rr.Body.Bytes())
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.Equal(t, swagger.SwaggerInfo.BasePath, dig(doc["servers"].([]interface{})[0], "url"))
+
+	rr = get("/openapi.yaml?version=3.1")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/yaml", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "openapi: \"3.1.0\"\n")
+
+	assert.Equal(t, http.StatusBadRequest, get("/openapi.json?version=2.0").Code)
+
+	rr = get("/schemas")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "/schemas/CreateUserRequest.json")
+
+	rr = get("/schemas/CreateUserRequest.json")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/schema+json", rr.Header().Get("Content-Type"))
+	schema := decodeJSON(t, rr.Body.Bytes())
+	assert.Contains(t, schema["required"], "email")
+
+	assert.Equal(t, http.StatusNotFound, get("/schemas/Nope.json").Code)
+}
@@ -0,0 +1,231 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockOperationRepository is an in-memory OperationRepository
+type MockOperationRepository struct {
+	mu         sync.Mutex
+	operations map[string]models.Operation
+}
+
+func NewMockOperationRepository() *MockOperationRepository {
+	return &MockOperationRepository{operations: make(map[string]models.Operation)}
+}
+
+func (m *MockOperationRepository) Create(ctx context.Context, op *models.Operation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op.CreatedAt = time.Now()
+	op.UpdatedAt = op.CreatedAt
+	m.operations[op.ID] = *op
+	return nil
+}
+
+func (m *MockOperationRepository) Get(ctx context.Context, id string) (*models.Operation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op, ok := m.operations[id]
+	if !ok {
+		return nil, apperrors.NotFound("operation not found")
+	}
+	return &op, nil
+}
+
+func (m *MockOperationRepository) Update(ctx context.Context, op *models.Operation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.operations[op.ID]; !ok {
+		return apperrors.NotFound("operation not found")
+	}
+	op.UpdatedAt = time.Now()
+	m.operations[op.ID] = *op
+	return nil
+}
+
+func (m *MockOperationRepository) FailInterrupted(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestOperationService_RecordsProgressAndResult(t *testing.T) {
+	repo := NewMockOperationRepository()
+	svc := services.NewOperationService(repo, zap.NewNop())
+
+	release := make(chan struct{})
+	op, err := svc.Start(context.Background(), "test", nil, func(ctx context.Context, progress func(int)) (string, error) {
+		progress(40)
+		<-release
+		return "/api/v1/results/1", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.OperationPending, op.Status)
+
+	assert.Eventually(t, func() bool {
+		current, _ := repo.Get(context.Background(), op.ID)
+		return current.Progress == 40 && current.Status == models.OperationRunning
+	}, time.Second, 5*time.Millisecond)
+
+	close(release)
+	svc.Shutdown()
+
+	done, _ := repo.Get(context.Background(), op.ID)
+	assert.Equal(t, models.OperationSucceeded, done.Status)
+	assert.Equal(t, 100, done.Progress)
+	assert.Equal(t, "/api/v1/results/1", done.ResultURL)
+	assert.NotNil(t, done.CompletedAt)
+}
+
+func TestOperationService_RecordsFailures(t *testing.T) {
+	repo := NewMockOperationRepository()
+	svc := services.NewOperationService(repo, zap.NewNop())
+
+	failed, _ := svc.Start(context.Background(), "test", nil, func(ctx context.Context, progress func(int)) (string, error) {
+		return "", errors.New("export failed")
+	})
+	panicked, _ := svc.Start(context.Background(), "test", nil, func(ctx context.Context, progress func(int)) (string, error) {
+		panic("boom")
+	})
+	svc.Shutdown()
+
+	op, _ := repo.Get(context.Background(), failed.ID)
+	assert.Equal(t, models.OperationFailed, op.Status)
+	assert.Equal(t, "export failed", op.Error)
+
+	op, _ = repo.Get(context.Background(), panicked.ID)
+	assert.Equal(t, models.OperationFailed, op.Status)
+	assert.Contains(t, op.Error, "boom")
+}
+
+func TestOperationService_VisibleToCreatorAndAdmins(t *testing.T) {
+	svc := services.NewOperationService(NewMockOperationRepository(), zap.NewNop())
+	owner := 1
+	op, _ := svc.Start(context.Background(), "test", &owner, func(ctx context.Context, progress func(int)) (string, error) {
+		return "", nil
+	})
+	svc.Shutdown()
+
+	_, err := svc.GetOperation(context.Background(), op.ID, 1, false)
+	assert.NoError(t, err)
+	_, err = svc.GetOperation(context.Background(), op.ID, 2, false)
+	assert.Error(t, err)
+	_, err = svc.GetOperation(context.Background(), op.ID, 2, true)
+	assert.NoError(t, err)
+}
+
+func TestOperationHandler_LocationIncludesBasePath(t *testing.T) {
+	t.Setenv("BASE_PATH", "crud/")
+	cfg := config.Load()
+	userRepo := NewMockUserRepository()
+	userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	userService := services.NewUserService(userRepo, services.DefaultPagination(), idcodec.Plain{})
+	operations := services.NewOperationService(NewMockOperationRepository(), zap.NewNop())
+	defer operations.Shutdown()
+
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(userService, idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Operations:    handlers.NewOperationHandler(operations, userService, userService, idcodec.Plain{}, router.APIPrefix(cfg)),
+	}).Handler()
+	token := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": models.RoleAdmin})
+
+	req := httptest.NewRequest("POST", "/crud/api/v1/users/1/anonymize", nil)
+	req.Header.Set("Authorization", token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Regexp(t, "^http://example.com/crud/api/v1/operations/", rr.Header().Get("Location"))
+}
+
+func TestOperationHandler_AnonymizeUser(t *testing.T) {
+	cfg := config.Load()
+	userRepo := NewMockUserRepository()
+	userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	userService := services.NewUserService(userRepo, services.DefaultPagination(), idcodec.Plain{})
+	operations := services.NewOperationService(NewMockOperationRepository(), zap.NewNop())
+
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(userService, idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Operations:    handlers.NewOperationHandler(operations, userService, userService, idcodec.Plain{}, router.APIBasePath),
+	}).Handler()
+	token := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": models.RoleAdmin})
+
+	req := httptest.NewRequest("POST", "/api/v1/users/1/anonymize", nil)
+	req.Header.Set("Authorization", token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	location := rr.Header().Get("Location")
+	assert.Contains(t, location, "/api/v1/operations/")
+	operations.Shutdown()
+
+	req = httptest.NewRequest("GET", location, nil)
+	req.Header.Set("Authorization", token)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var response struct {
+		Data models.Operation `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, models.OperationSucceeded, response.Data.Status)
+	assert.Equal(t, "http://example.com/api/v1/users/1", response.Data.ResultURL)
+
+	user, _ := userRepo.GetByID(context.Background(), 1)
+	assert.Equal(t, "Anonymized User", user.Name)
+}
+
+func TestOperationHandler_LocationHonorsTrustedProxy(t *testing.T) {
+	cfg := config.Load()
+	userRepo := NewMockUserRepository()
+	userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	userService := services.NewUserService(userRepo, services.DefaultPagination(), idcodec.Plain{})
+	operations := services.NewOperationService(NewMockOperationRepository(), zap.NewNop())
+	defer operations.Shutdown()
+	proxies, err := forwarded.ParseProxies([]string{"192.0.2.0/24"})
+	require.NoError(t, err)
+
+	handler := router.New(router.Dependencies{
+		Config:         cfg,
+		UserHandler:    handlers.NewUserHandler(userService, idcodec.Plain{}),
+		HealthHandler:  handlers.NewHealthHandler(nil, nil),
+		Operations:     handlers.NewOperationHandler(operations, userService, userService, idcodec.Plain{}, router.APIBasePath),
+		TrustedProxies: proxies,
+	}).Handler()
+	token := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": models.RoleAdmin})
+
+	req := httptest.NewRequest("POST", "/api/v1/users/1/anonymize", nil)
+	req.Header.Set("Authorization", token)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Regexp(t, "^https://api.example.com/api/v1/operations/", rr.Header().Get("Location"))
+}
@@ -0,0 +1,142 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/events"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOutboxRepository is a mock implementation of OutboxRepository
+type MockOutboxRepository struct {
+	events     []*models.OutboxEvent
+	enqueueErr error
+}
+
+func (m *MockOutboxRepository) Enqueue(ctx context.Context, event *models.OutboxEvent) error {
+	if m.enqueueErr != nil {
+		return m.enqueueErr
+	}
+	event.ID = int64(len(m.events) + 1)
+	event.NextAttemptAt = time.Now()
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MockOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	var due []*models.OutboxEvent
+	for _, event := range m.events {
+		if event.PublishedAt == nil && !event.NextAttemptAt.After(time.Now()) && len(due) < limit {
+			event.Attempts++
+			event.NextAttemptAt = time.Now().Add(lease)
+			due = append(due, event)
+		}
+	}
+	return due, nil
+}
+
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	now := time.Now()
+	m.events[id-1].PublishedAt = &now
+	return nil
+}
+
+func (m *MockOutboxRepository) MarkRetry(ctx context.Context, id int64, lastError string, next time.Time) error {
+	m.events[id-1].LastError = lastError
+	m.events[id-1].NextAttemptAt = next
+	return nil
+}
+
+func (m *MockOutboxRepository) DeletePublished(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func testOutboxConfig() config.EventsConfig {
+	return config.EventsConfig{Backend: events.BackendInProc, Outbox: true, OutboxRetryBackoff: time.Minute}
+}
+
+func TestUserService_WritesEventsToOutbox(t *testing.T) {
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(ctx context.Context, event events.Event) { published = append(published, event) })
+	repo := &MockOutboxRepository{}
+	outbox := services.NewOutboxService(repo, bus, testOutboxConfig())
+	transactions := &fakeTxManager{}
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	userService.SetEvents(bus)
+	userService.SetOutbox(transactions, outbox)
+
+	ctx := context.Background()
+	user, err := userService.CreateUser(ctx, &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	name := "Jane Doe"
+	_, err = userService.PatchUser(ctx, user.ID, &models.PatchUserRequest{Name: &name})
+	require.NoError(t, err)
+	require.NoError(t, userService.DeleteUser(ctx, user.ID))
+
+	// Nothing is published until the relay runs
+	assert.Equal(t, 3, transactions.committed)
+	require.Len(t, repo.events, 3)
+	assert.Empty(t, published)
+
+	count, err := outbox.RelayDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	require.Len(t, published, 3)
+	subject := idcodec.Plain{}.Encode(user.ID)
+	for i, typ := range []string{models.WebhookUserCreated, models.WebhookUserUpdated, models.WebhookUserDeleted} {
+		assert.Equal(t, typ, published[i].Type)
+		assert.Equal(t, subject, published[i].Subject)
+		assert.Equal(t, repo.events[i].EventID, published[i].ID)
+	}
+	var data models.UserResponse
+	require.NoError(t, json.Unmarshal(published[1].Data.(json.RawMessage), &data))
+	assert.Equal(t, "Jane Doe", data.Name)
+
+	count, err = outbox.RelayDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestUserService_OutboxFailureRollsBackChange(t *testing.T) {
+	repo := &MockOutboxRepository{enqueueErr: errors.New("outbox unavailable")}
+	transactions := &fakeTxManager{}
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	userService.SetOutbox(transactions, services.NewOutboxService(repo, events.NewBus(), testOutboxConfig()))
+
+	_, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.Error(t, err)
+	assert.Equal(t, 1, transactions.rolledBack)
+	assert.Zero(t, transactions.committed)
+}
+
+func TestOutboxService_RetriesRejectedEvents(t *testing.T) {
+	repo := &MockOutboxRepository{}
+	publisher := &failingPublisher{}
+	outbox := services.NewOutboxService(repo, publisher, testOutboxConfig())
+	ctx := context.Background()
+	require.NoError(t, outbox.Enqueue(ctx, events.NewEvent(models.WebhookUserCreated, "1", nil)))
+
+	count, err := outbox.RelayDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Equal(t, 1, publisher.attempts)
+	assert.Nil(t, repo.events[0].PublishedAt)
+	assert.Equal(t, "broker unavailable", repo.events[0].LastError)
+	assert.True(t, repo.events[0].NextAttemptAt.After(time.Now().Add(50*time.Second)))
+
+	// Not due again until the backoff passes
+	count, err = outbox.RelayDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Equal(t, 1, publisher.attempts)
+}
@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPagination creates the page sizes configured by cfg
+func newPagination(t *testing.T, cfg config.PaginationConfig) *services.Pagination {
+	pagination, err := services.NewPagination(cfg)
+	require.NoError(t, err)
+	return pagination
+}
+
+func TestNewPagination_RouteOverrides(t *testing.T) {
+	pagination, err := services.NewPagination(config.PaginationConfig{
+		DefaultLimit: 20,
+		MaxLimit:     50,
+		Routes:       []string{"audit=100:1000"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.PageLimits{DefaultLimit: 20, MaxLimit: 50}, pagination.Limits(models.PageRouteUsers))
+	assert.Equal(t, models.PageLimits{DefaultLimit: 100, MaxLimit: 1000}, pagination.Limits(models.PageRouteAudit))
+	assert.Len(t, pagination.Capabilities(), 4)
+}
+
+func TestNewPagination_RejectsBadConfig(t *testing.T) {
+	configs := map[string]config.PaginationConfig{
+		"default over max": {DefaultLimit: 200, MaxLimit: 100},
+		"zero default":     {DefaultLimit: 0, MaxLimit: 100},
+		"unknown route":    {DefaultLimit: 10, MaxLimit: 100, Routes: []string{"widgets=5:10"}},
+		"missing max":      {DefaultLimit: 10, MaxLimit: 100, Routes: []string{"users=5"}},
+		"not a number":     {DefaultLimit: 10, MaxLimit: 100, Routes: []string{"users=five:10"}},
+		"inverted":         {DefaultLimit: 10, MaxLimit: 100, Routes: []string{"users=50:10"}},
+		"negative offset":  {DefaultLimit: 10, MaxLimit: 100, MaxOffset: -1},
+		"deep pages":       {DefaultLimit: 10, MaxLimit: 100, DeepPages: "skip"},
+	}
+	for name, cfg := range configs {
+		_, err := services.NewPagination(cfg)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestUserService_PageSizesFollowConfig(t *testing.T) {
+	pagination := newPagination(t, config.PaginationConfig{DefaultLimit: 10, MaxLimit: 100, Routes: []string{"users=25:500"}})
+	service := services.NewUserService(NewMockUserRepository(), pagination, idcodec.Plain{})
+
+	tests := []struct {
+		requested, effective int
+	}{
+		{0, 25},
+		{300, 300},
+		{501, 25},
+	}
+	for _, tt := range tests {
+		_, page, err := service.GetUsersAfter(context.Background(), nil, "", tt.requested)
+		require.NoError(t, err)
+		assert.Equal(t, tt.effective, page.Limit, tt.requested)
+	}
+}
+
+func TestRouter_MetaReportsPageSizes(t *testing.T) {
+	pagination := newPagination(t, config.PaginationConfig{DefaultLimit: 10, MaxLimit: 100, Routes: []string{"emails=50:200"}})
+	r := router.New(router.Dependencies{
+		Config:        config.Load(),
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), pagination, idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Meta:          handlers.NewMetaHandler(pagination),
+	})
+
+	// Public by default, like /health
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/_meta", nil)
+	rr := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"emails":{"default_limit":50,"max_limit":200}`)
+	assert.Contains(t, rr.Body.String(), `"users":{"default_limit":10,"max_limit":100}`)
+}
+
+func TestUserService_RejectsDeepPages(t *testing.T) {
+	pagination := newPagination(t, config.PaginationConfig{DefaultLimit: 10, MaxLimit: 100, MaxOffset: 20})
+	repo := NewMockUserRepository()
+	service := services.NewUserService(repo, pagination, idcodec.Plain{})
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_, err := repo.Create(ctx, &models.CreateUserRequest{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30})
+		require.NoError(t, err)
+	}
+
+	// Fewer users than the limit, so the page is cheap to read
+	users, _, err := service.GetUsers(ctx, nil, 3, 10)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	for i := 5; i < 25; i++ {
+		_, err := repo.Create(ctx, &models.CreateUserRequest{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30})
+		require.NoError(t, err)
+	}
+	_, _, err = service.GetUsers(ctx, nil, 2, 10)
+	require.NoError(t, err)
+
+	_, _, err = service.GetUsers(ctx, nil, 3, 10)
+	var deepErr *services.PageTooDeepError
+	require.ErrorAs(t, err, &deepErr)
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+	assert.Equal(t, 20, deepErr.MaxOffset)
+	assert.NotEmpty(t, deepErr.NextCursor)
+
+	// Cursor pages cannot follow a sort order, so there is no hint
+	_, _, err = service.GetUsers(ctx, &models.UserFilter{Sort: []models.UserSort{{Field: "name"}}}, 3, 10)
+	require.ErrorAs(t, err, &deepErr)
+	assert.Empty(t, deepErr.NextCursor)
+}
+
+func TestUserHandler_DeepPages(t *testing.T) {
+	repo := NewMockUserRepository()
+	for i := 0; i < 30; i++ {
+		_, err := repo.Create(context.Background(), &models.CreateUserRequest{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30})
+		require.NoError(t, err)
+	}
+	get := func(cfg config.PaginationConfig) *httptest.ResponseRecorder {
+		handler := handlers.NewUserHandler(services.NewUserService(repo, newPagination(t, cfg), idcodec.Plain{}), idcodec.Plain{})
+		rr := httptest.NewRecorder()
+		handler.GetUsers(rr, httptest.NewRequest(http.MethodGet, "/api/v1/users?page=3&limit=10", nil))
+		return rr
+	}
+
+	rr := get(config.PaginationConfig{DefaultLimit: 10, MaxLimit: 100, MaxOffset: 20})
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	var body models.PageTooDeepResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, models.ErrorCodePageTooDeep, body.ErrorCode)
+	assert.Equal(t, 20, body.MaxOffset)
+	assert.NotEmpty(t, body.NextCursor)
+
+	// In cursor mode the page after the limit is served by cursor
+	rr = get(config.PaginationConfig{DefaultLimit: 10, MaxLimit: 100, MaxOffset: 20, DeepPages: services.DeepPagesCursor})
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"next_cursor"`)
+	assert.NotContains(t, rr.Body.String(), `"total"`)
+}
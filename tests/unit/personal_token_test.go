@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockPersonalTokenRepository implements PersonalTokenRepository interface for testing
+type MockPersonalTokenRepository struct {
+	tokens map[int]*models.PersonalToken
+	nextID int
+}
+
+func NewMockPersonalTokenRepository() *MockPersonalTokenRepository {
+	return &MockPersonalTokenRepository{
+		tokens: make(map[int]*models.PersonalToken),
+		nextID: 1,
+	}
+}
+
+func (m *MockPersonalTokenRepository) Create(ctx context.Context, token *models.PersonalToken) (*models.PersonalToken, error) {
+	token.ID = m.nextID
+	token.CreatedAt = time.Now()
+	m.tokens[m.nextID] = token
+	m.nextID++
+	return token, nil
+}
+
+func (m *MockPersonalTokenRepository) ListByUser(ctx context.Context, userID int) ([]*models.PersonalToken, error) {
+	var tokens []*models.PersonalToken
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *MockPersonalTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, apperrors.NotFound("personal token not found")
+}
+
+func (m *MockPersonalTokenRepository) Revoke(ctx context.Context, userID, id int) error {
+	token, exists := m.tokens[id]
+	if !exists || token.UserID != userID || token.RevokedAt != nil {
+		return apperrors.NotFound("personal token not found")
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	return nil
+}
+
+func (m *MockPersonalTokenRepository) SetSigningKey(ctx context.Context, userID, id int, publicKey string) error {
+	token, exists := m.tokens[id]
+	if !exists || token.UserID != userID || token.RevokedAt != nil {
+		return apperrors.NotFound("personal token not found")
+	}
+	token.SigningKey = publicKey
+	return nil
+}
+
+func (m *MockPersonalTokenRepository) TouchLastUsed(ctx context.Context, id int) error {
+	if token, exists := m.tokens[id]; exists {
+		now := time.Now()
+		token.LastUsedAt = &now
+	}
+	return nil
+}
+
+func TestPersonalTokenService_CreateAndAuthenticate(t *testing.T) {
+	tokenService := services.NewPersonalTokenService(NewMockPersonalTokenRepository(), config.Load().Tokens, zap.NewNop())
+
+	created, err := tokenService.CreateToken(context.Background(), 7, &models.CreatePersonalTokenRequest{
+		Name:   "backup script",
+		Scopes: []string{services.ScopeUsersRead},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, models.PersonalTokenPrefix))
+	assert.NotContains(t, created.TokenHash, created.Token)
+	assert.True(t, strings.HasPrefix(created.Token, created.Prefix))
+
+	claims, err := tokenService.AuthenticateToken(context.Background(), created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "7", claims["sub"])
+	assert.Equal(t, "users:read", claims["scope"])
+
+	require.NoError(t, tokenService.RevokeToken(context.Background(), 7, created.ID))
+	_, err = tokenService.AuthenticateToken(context.Background(), created.Token)
+	assert.Error(t, err)
+}
+
+func TestPersonalTokenService_Validation(t *testing.T) {
+	tokenService := services.NewPersonalTokenService(NewMockPersonalTokenRepository(), config.Load().Tokens, zap.NewNop())
+
+	_, err := tokenService.CreateToken(context.Background(), 1, &models.CreatePersonalTokenRequest{Name: "x", Scopes: []string{"users:read"}})
+	assert.Error(t, err)
+
+	_, err = tokenService.CreateToken(context.Background(), 1, &models.CreatePersonalTokenRequest{Name: "script", Scopes: []string{"users:delete"}})
+	assert.ErrorContains(t, err, "unknown scope")
+
+	_, err = tokenService.CreateToken(context.Background(), 1, &models.CreatePersonalTokenRequest{
+		Name:      "script",
+		Scopes:    []string{"users:read"},
+		ExpiresIn: "100000h",
+	})
+	assert.ErrorContains(t, err, "must not exceed")
+}
+
+func TestAuthMiddleware_PersonalTokenScopes(t *testing.T) {
+	tokenService := services.NewPersonalTokenService(NewMockPersonalTokenRepository(), config.Load().Tokens, zap.NewNop())
+	created, err := tokenService.CreateToken(context.Background(), 1, &models.CreatePersonalTokenRequest{
+		Name:   "read only",
+		Scopes: []string{services.ScopeUsersRead},
+	})
+	require.NoError(t, err)
+
+	chain := middleware.NewChain(
+		middleware.AuthMiddleware(middleware.HMACKey("secret"), tokenService),
+		middleware.RequireScope(services.ScopeUsersWrite),
+	)
+	handler := chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+created.Token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer pat_unknown")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRouter_ScopedTokensCannotManageTokens(t *testing.T) {
+	cfg := config.Load()
+	tokenService := services.NewPersonalTokenService(NewMockPersonalTokenRepository(), cfg.Tokens, zap.NewNop())
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		TokenHandler:  handlers.NewTokenHandler(tokenService),
+	}).Handler()
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected int
+	}{
+		{"OAuth access token", jwt.MapClaims{"sub": "1", "role": "admin", "scope": "openid profile"}, http.StatusForbidden},
+		{"session token", jwt.MapClaims{"sub": "1", "role": "admin"}, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		body := `{"name":"script","scopes":["users:write","admin:runbook"]}`
+		req := httptest.NewRequest("POST", "/api/v1/me/tokens", strings.NewReader(body))
+		req.Header.Set("Authorization", bearer(t, cfg, tt.claims))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, tt.expected, rr.Code, tt.name)
+	}
+}
@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/priority"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queueSizes lets every class queue up to n requests
+func queueSizes(n int) map[priority.Class]int {
+	return map[priority.Class]int{priority.Critical: n, priority.Read: n, priority.Write: n, priority.Bulk: n}
+}
+
+// acquireAsync acquires a slot in the background and reports its class once
+// it is granted
+func acquireAsync(t *testing.T, s *priority.Scheduler, class priority.Class, granted chan<- priority.Class) {
+	go func() {
+		release, err := s.Acquire(context.Background(), class)
+		if !assert.NoError(t, err) {
+			return
+		}
+		granted <- class
+		release()
+	}()
+	require.Eventually(t, func() bool { return s.Queued(class) > 0 }, time.Second, time.Millisecond)
+}
+
+func TestScheduler_AdmitsHighestClassFirst(t *testing.T) {
+	s := priority.NewScheduler(1, queueSizes(1), time.Second)
+	release, err := s.Acquire(context.Background(), priority.Read)
+	require.NoError(t, err)
+
+	granted := make(chan priority.Class, 4)
+	acquireAsync(t, s, priority.Bulk, granted)
+	acquireAsync(t, s, priority.Write, granted)
+	acquireAsync(t, s, priority.Read, granted)
+	acquireAsync(t, s, priority.Critical, granted)
+
+	release()
+	for _, expected := range []priority.Class{priority.Critical, priority.Read, priority.Write, priority.Bulk} {
+		assert.Equal(t, expected, <-granted)
+	}
+}
+
+func TestScheduler_RejectsWhenQueueIsFull(t *testing.T) {
+	s := priority.NewScheduler(1, map[priority.Class]int{priority.Read: 1}, time.Second)
+	release, err := s.Acquire(context.Background(), priority.Read)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = s.Acquire(context.Background(), priority.Bulk)
+	assert.ErrorIs(t, err, priority.ErrQueueFull)
+
+	granted := make(chan priority.Class, 1)
+	acquireAsync(t, s, priority.Read, granted)
+	_, err = s.Acquire(context.Background(), priority.Read)
+	assert.ErrorIs(t, err, priority.ErrQueueFull)
+}
+
+func TestScheduler_QueueTimeout(t *testing.T) {
+	s := priority.NewScheduler(1, queueSizes(1), 10*time.Millisecond)
+	release, err := s.Acquire(context.Background(), priority.Read)
+	require.NoError(t, err)
+
+	_, err = s.Acquire(context.Background(), priority.Write)
+	assert.ErrorIs(t, err, priority.ErrQueueTimeout)
+	assert.Zero(t, s.Queued(priority.Write))
+
+	// The timed out request does not hold a slot
+	release()
+	release, err = s.Acquire(context.Background(), priority.Write)
+	require.NoError(t, err)
+	release()
+}
+
+func TestScheduler_BulkHoldsAtMostHalfTheSlots(t *testing.T) {
+	s := priority.NewScheduler(2, queueSizes(1), 10*time.Millisecond)
+	release, err := s.Acquire(context.Background(), priority.Bulk)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = s.Acquire(context.Background(), priority.Bulk)
+	assert.ErrorIs(t, err, priority.ErrQueueTimeout)
+
+	readRelease, err := s.Acquire(context.Background(), priority.Read)
+	require.NoError(t, err)
+	readRelease()
+}
+
+func TestRouter_PrioritizesRequests(t *testing.T) {
+	cfg := config.Load()
+	scheduler := priority.NewScheduler(1, map[priority.Class]int{priority.Critical: 1}, time.Second)
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Readiness:     handlers.NewReadinessHandler(health.NewReadiness()),
+		Priority:      scheduler,
+	}).Handler()
+
+	// Saturate the server
+	release, err := scheduler.Acquire(context.Background(), priority.Read)
+	require.NoError(t, err)
+
+	// Reads have no queue, so they are turned away at once
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/users", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+
+	// Probes are critical and wait for the next free slot
+	done := make(chan int)
+	go func() {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+		done <- rr.Code
+	}()
+	require.Eventually(t, func() bool { return scheduler.Queued(priority.Critical) == 1 }, time.Second, time.Millisecond)
+	release()
+	assert.Equal(t, http.StatusOK, <-done)
+}
@@ -0,0 +1,241 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pratham15541/go-crud/internal/cache"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/metrics"
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/ratelimit"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingLimiter always reports its backend as unavailable
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestMemoryLimiter_Burst(t *testing.T) {
+	limiter := ratelimit.NewMemory(1, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Allow(ctx, "198.51.100.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Limit)
+		assert.Equal(t, 1-i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Greater(t, result.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, result.RetryAfter, time.Second)
+
+	// Buckets are per key
+	result, err = limiter.Allow(ctx, "198.51.100.2")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryLimiter_Refills(t *testing.T) {
+	limiter := ratelimit.NewMemory(100, 1)
+	ctx := context.Background()
+
+	result, _ := limiter.Allow(ctx, "key")
+	require.True(t, result.Allowed)
+	result, _ = limiter.Allow(ctx, "key")
+	require.False(t, result.Allowed)
+
+	time.Sleep(20 * time.Millisecond)
+	result, _ = limiter.Allow(ctx, "key")
+	assert.True(t, result.Allowed)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	handler := middleware.RateLimitMiddleware(ratelimit.NewMemory(0.5, 1))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.10:12345"
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("X-RateLimit-Remaining"))
+
+	// Same IP from another port shares the bucket
+	req.RemoteAddr = "203.0.113.10:23456"
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	assert.Contains(t, rr.Body.String(), `"code":429`)
+}
+
+func TestRateLimitMiddleware_FailsOpen(t *testing.T) {
+	handler := middleware.RateLimitMiddleware(failingLimiter{})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRouter_RateLimitAppliesToAPIOnly(t *testing.T) {
+	r := router.New(router.Dependencies{
+		Config:      config.Load(),
+		Changelog:   handlers.NewChangelogHandler(),
+		Metrics:     metrics.New(),
+		RateLimiter: ratelimit.NewMemory(0.001, 1),
+	})
+	handler := r.Handler()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, get("/api/v1/changelog").Code)
+	assert.Equal(t, http.StatusTooManyRequests, get("/api/v1/changelog").Code)
+
+	// /metrics is outside the API base path
+	for i := 0; i < 2; i++ {
+		rr := get("/metrics")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func newTestRedisLimiter(t *testing.T, rate float64, burst int) (*ratelimit.Redis, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return ratelimit.NewRedis(client, rate, burst, ratelimit.NewMemory(rate, burst)), server
+}
+
+func TestRedisLimiter_Burst(t *testing.T) {
+	limiter, _ := newTestRedisLimiter(t, 1, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Allow(ctx, "198.51.100.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Limit)
+		assert.Equal(t, 1-i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Greater(t, result.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, result.RetryAfter, time.Second)
+
+	result, err = limiter.Allow(ctx, "198.51.100.2")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestRedisLimiter_SharedAcrossInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	newLimiter := func() *ratelimit.Redis {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return ratelimit.NewRedis(client, 1, 1, ratelimit.NewMemory(1, 1))
+	}
+	first, second := newLimiter(), newLimiter()
+	ctx := context.Background()
+
+	result, err := first.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = second.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestRedisLimiter_FallsBackWhenUnavailable(t *testing.T) {
+	limiter, server := newTestRedisLimiter(t, 1, 1)
+	server.Close()
+	ctx := context.Background()
+
+	// The in-memory fallback still limits
+	result, err := limiter.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = limiter.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestCachedLimiter_Burst(t *testing.T) {
+	limiter := ratelimit.NewCached(newTestCache(t), 1, 2, ratelimit.NewMemory(1, 2))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Allow(ctx, "198.51.100.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1-i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Greater(t, result.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, result.RetryAfter, time.Second)
+}
+
+func TestCachedLimiter_SharedAcrossInstances(t *testing.T) {
+	shared := newTestCache(t)
+	first := ratelimit.NewCached(shared, 1, 1, ratelimit.NewMemory(1, 1))
+	second := ratelimit.NewCached(shared, 1, 1, ratelimit.NewMemory(1, 1))
+	ctx := context.Background()
+
+	result, err := first.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = second.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestCachedLimiter_FallsBackWhenUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	limiter := ratelimit.NewCached(cache.NewRedis(client), 1, 1, ratelimit.NewMemory(1, 1))
+	server.Close()
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = limiter.Allow(ctx, "198.51.100.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/health"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness_Pending(t *testing.T) {
+	readiness := health.NewReadiness()
+	assert.Empty(t, readiness.Pending())
+
+	readiness.SetPending("migrations", "waiting for schema version 1")
+	assert.Equal(t, map[string]string{"migrations": "waiting for schema version 1"}, readiness.Pending())
+
+	// The returned map is a copy
+	readiness.Pending()["other"] = "reason"
+	assert.Len(t, readiness.Pending(), 1)
+
+	readiness.SetReady("migrations")
+	assert.Empty(t, readiness.Pending())
+}
+
+func TestRouter_Readyz(t *testing.T) {
+	readiness := health.NewReadiness()
+	readiness.SetPending("migrations", "waiting for schema version 1")
+
+	handler := router.New(router.Dependencies{
+		Config:        config.Load(),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Readiness:     handlers.NewReadinessHandler(readiness),
+	}).Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	var resp models.ReadinessResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "not_ready", resp.Status)
+	assert.Equal(t, "waiting for schema version 1", resp.Pending["migrations"])
+
+	readiness.SetReady("migrations")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ready"}`, rr.Body.String())
+}
@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/middleware"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newRecoveryHandler(core zapcore.Core, handler http.HandlerFunc) http.Handler {
+	return middleware.NewChain(
+		middleware.RequestIDMiddleware,
+		middleware.LoggingMiddleware(zap.New(core), nil),
+		middleware.RecoveryMiddleware,
+	).Then(handler)
+}
+
+func TestRecoveryMiddleware_ReturnsJSON500(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	handler := newRecoveryHandler(core, func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/users", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.NotContains(t, rr.Body.String(), "boom", "panic values are not leaked to clients")
+
+	panics := logs.FilterMessage("panic while handling request").All()
+	require.Len(t, panics, 1)
+	fields := panics[0].ContextMap()
+	assert.Equal(t, "req-42", fields["request_id"])
+	assert.Equal(t, "boom", fields["panic"])
+	assert.Contains(t, fields["stack"], "recovery_test.go")
+
+	completed := logs.FilterMessage("request completed").All()
+	require.Len(t, completed, 1)
+	assert.Equal(t, int64(http.StatusInternalServerError), completed[0].ContextMap()["status"])
+}
+
+func TestRecoveryMiddleware_AfterResponseStarted(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	handler := newRecoveryHandler(core, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		panic("late")
+	})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "partial", rr.Body.String())
+	assert.Equal(t, 1, logs.FilterMessage("panic while handling request").Len())
+}
+
+func TestRecoveryMiddleware_RepanicsAbortHandler(t *testing.T) {
+	handler := middleware.RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
@@ -0,0 +1,716 @@
+package unit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/forwarded"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter builds the application router backed by the mock repository
+func newTestRouter(cfg *config.Config) http.Handler {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	r := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(userService, idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Changelog:     handlers.NewChangelogHandler(),
+	})
+	return r.Handler()
+}
+
+// bearer returns an Authorization header value for a session token with claims
+func bearer(t *testing.T, cfg *config.Config, claims jwt.MapClaims) string {
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	return "Bearer " + signTestToken(t, cfg.JWT.Secret, claims)
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	handler := newTestRouter(config.Load())
+
+	req := httptest.NewRequest("DELETE", "/api/v1/users", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, "OPTIONS, GET, POST", rr.Header().Get("Allow"))
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+}
+
+func TestRouter_MethodOverride(t *testing.T) {
+	cfg := config.Load()
+	cfg.Server.MethodOverride = true
+	handler := newTestRouter(cfg)
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	token := bearer(t, cfg, jwt.MapClaims{"sub": "1"})
+	createReq := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	createReq.Header.Set("Authorization", token)
+	handler.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	req := httptest.NewRequest("POST", "/api/v1/users/1", nil)
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	req.Header.Set("Authorization", token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "User deleted successfully")
+}
+
+func TestRouter_MethodOverrideDisabled(t *testing.T) {
+	handler := newTestRouter(config.Load())
+
+	req := httptest.NewRequest("POST", "/api/v1/users/1", nil)
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestRouter_NotFoundSuggestions(t *testing.T) {
+	handler := newTestRouter(config.Load())
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/v1/user", "/api/v1/users"},
+		{"/users/5", "/api/v1/users/{id}"},
+		{"/api/v1/helth", "/api/v1/health"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		var response models.RouteNotFoundResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, tt.path, response.Path)
+		assert.Equal(t, "/api/v1", response.BasePath)
+		if assert.NotEmpty(t, response.Suggestions, tt.path) {
+			assert.Equal(t, tt.expected, response.Suggestions[0], tt.path)
+		}
+	}
+}
+
+func TestRouter_ListRoutes(t *testing.T) {
+	handler := newTestRouter(config.Load())
+
+	req := httptest.NewRequest("GET", "/api/v1/_routes", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"path":"/api/v1/users/{id:[0-9]+}"`)
+	assert.Contains(t, rr.Body.String(), `"handler":"handlers.(*UserHandler).GetUser"`)
+	assert.Contains(t, rr.Body.String(), `"chain":"authed","public":true`)
+}
+
+func TestRouter_ListRoutesRequiresInternalNetwork(t *testing.T) {
+	handler := newTestRouter(config.Load())
+
+	req := httptest.NewRequest("GET", "/api/v1/_routes", nil)
+	req.RemoteAddr = "203.0.113.10:12345"
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestRouter_InternalNetworkBehindLoadBalancer(t *testing.T) {
+	proxies, err := forwarded.ParseProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	handler := router.New(router.Dependencies{
+		Config:         config.Load(),
+		HealthHandler:  handlers.NewHealthHandler(nil, nil),
+		TrustedProxies: proxies,
+	}).Handler()
+
+	// The load balancer's own private address does not make its clients
+	// internal
+	for forwardedFor, expected := range map[string]int{
+		"203.0.113.10":              http.StatusForbidden,
+		"192.168.1.4, 203.0.113.10": http.StatusForbidden,
+		"192.168.1.4":               http.StatusOK,
+		"":                          http.StatusOK,
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/_routes", nil)
+		req.RemoteAddr = "10.0.0.5:12345"
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, expected, rr.Code, forwardedFor)
+	}
+}
+
+func TestRouter_RecordsExamplesInDebugMode(t *testing.T) {
+	cfg := config.Load()
+	cfg.Server.Mode = "debug"
+	cfg.Server.RecordExamples = true
+	handler := newTestRouter(cfg)
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "1"}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("GET", "/api/v1/_examples", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"/api/v1/users":{"post"`)
+	assert.Contains(t, rr.Body.String(), `"201":{"description":"Created"`)
+	assert.NotContains(t, rr.Body.String(), "/_routes")
+}
+
+func TestRouter_UserMutationsRequireToken(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		expected int
+	}{
+		{"create without token", "POST", "/api/v1/users", "", http.StatusUnauthorized},
+		{"update without token", "PUT", "/api/v1/users/1", "", http.StatusUnauthorized},
+		{"patch without token", "PATCH", "/api/v1/users/1", "", http.StatusUnauthorized},
+		{"delete without token", "DELETE", "/api/v1/users/1", "", http.StatusUnauthorized},
+		{"create with invalid token", "POST", "/api/v1/users", "Bearer not-a-jwt", http.StatusUnauthorized},
+		{"create with read-only scope", "POST", "/api/v1/users", bearer(t, cfg, jwt.MapClaims{"sub": "1", "scope": "users:read"}), http.StatusForbidden},
+		{"create with session token", "POST", "/api/v1/users", bearer(t, cfg, jwt.MapClaims{"sub": "1"}), http.StatusCreated},
+		// Authorized, but the email was taken by the previous create
+		{"create with write scope", "POST", "/api/v1/users", bearer(t, cfg, jwt.MapClaims{"sub": "1", "scope": "users:write"}), http.StatusConflict},
+		{"list without token", "GET", "/api/v1/users", "", http.StatusOK},
+		{"changelog without token", "GET", "/api/v1/changelog", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+		if tt.token != "" {
+			req.Header.Set("Authorization", tt.token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.expected, rr.Code, tt.name)
+	}
+}
+
+func TestRouter_PublicPathsAreConfigurable(t *testing.T) {
+	cfg := config.Load()
+	cfg.Auth.PublicPaths = []string{"/changelog"}
+	handler := newTestRouter(cfg)
+
+	req := httptest.NewRequest("GET", "/api/v1/users/1", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/changelog", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRouter_BasePath(t *testing.T) {
+	t.Setenv("BASE_PATH", "/crud/")
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"under base path", "/crud/api/v1/changelog", http.StatusOK},
+		{"public path is relative to the API", "/crud/api/v1/users/1", http.StatusNotFound},
+		{"without base path", "/api/v1/changelog", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.expected, rr.Code, tt.name)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/changelog", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var response models.RouteNotFoundResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "/crud/api/v1", response.BasePath)
+	assert.Contains(t, response.Suggestions, "/crud/api/v1/changelog")
+}
+
+func TestRouter_LowercasePathsKeepVariables(t *testing.T) {
+	cfg := config.Load()
+	cfg.Server.TrailingSlash = "redirect"
+	cfg.Server.LowercasePaths = true
+	handler := newTestRouter(cfg)
+
+	for target, canonical := range map[string]string{
+		"/API/V1/Users/Lqj8Wz2x":                  "/api/v1/users/Lqj8Wz2x",
+		"/Api/V1/Users/By-Email/Ann@Example.com/": "/api/v1/users/by-email/Ann@Example.com",
+		"/API/V1/USERS/EXPORT":                    "/api/v1/users/export",
+	} {
+		req := httptest.NewRequest("GET", target, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusPermanentRedirect, rr.Code, target)
+		assert.Equal(t, canonical, rr.Header().Get("Location"), target)
+	}
+}
+
+func TestRouter_Swagger(t *testing.T) {
+	t.Setenv("BASE_PATH", "/crud")
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+
+	req := httptest.NewRequest("GET", "/crud/swagger/doc.json", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var spec struct {
+		BasePath string                 `json:"basePath"`
+		Paths    map[string]interface{} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &spec))
+	assert.Equal(t, "/crud/api/v1", spec.BasePath)
+	assert.Contains(t, spec.Paths, "/users/{id}")
+
+	req = httptest.NewRequest("GET", "/crud/swagger/index.html", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The UI root is reached whichever way trailing slashes are handled
+	for _, mode := range []string{"redirect", "rewrite", "off"} {
+		cfg.Server.TrailingSlash = mode
+		handler = newTestRouter(cfg)
+		path := "/crud/swagger/"
+		for i := 0; i < 3; i++ {
+			req = httptest.NewRequest("GET", path, nil)
+			rr = httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code < 300 || rr.Code >= 400 {
+				break
+			}
+			path = rr.Header().Get("Location")
+		}
+		assert.Equal(t, http.StatusOK, rr.Code, mode)
+		assert.Equal(t, "/crud/swagger/index.html", path, mode)
+	}
+
+	cfg.Server.Swagger = false
+	handler = newTestRouter(cfg)
+	req = httptest.NewRequest("GET", "/crud/swagger/doc.json", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRouter_PatchUser(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+	token := bearer(t, cfg, jwt.MapClaims{"sub": "1"})
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	createReq := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	createReq.Header.Set("Authorization", token)
+	handler.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"single field", `{"age":31}`, http.StatusOK},
+		{"zero age", `{"age":0}`, http.StatusBadRequest},
+		{"explicit null", `{"name":null}`, http.StatusBadRequest},
+		{"unknown field", `{"nickname":"JD"}`, http.StatusBadRequest},
+		{"empty patch", `{}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("PATCH", "/api/v1/users/1", strings.NewReader(tt.body))
+		req.Header.Set("Authorization", token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.expected, rr.Code, tt.name)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/users/1", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Contains(t, rr.Body.String(), `"name":"John Doe"`)
+	assert.Contains(t, rr.Body.String(), `"age":31`)
+}
+
+func TestRouter_UpsertUserByEmail(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+	token := bearer(t, cfg, jwt.MapClaims{"sub": "1"})
+
+	tests := []struct {
+		name     string
+		email    string
+		body     string
+		expected int
+	}{
+		{"new email", "john@example.com", `{"name":"John Doe","age":30}`, http.StatusCreated},
+		{"existing email", "john@example.com", `{"name":"John Smith","age":31}`, http.StatusOK},
+		{"invalid email", "john", `{"name":"John Doe","age":30}`, http.StatusBadRequest},
+		{"missing age", "jane@example.com", `{"name":"Jane Doe"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("PUT", "/api/v1/users/by-email/"+tt.email, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.expected, rr.Code, tt.name)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/users/1", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Contains(t, rr.Body.String(), `"name":"John Smith"`)
+	assert.Contains(t, rr.Body.String(), `"age":31`)
+}
+
+func TestRouter_UpdateAtStaleVersionConflicts(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+	token := bearer(t, cfg, jwt.MapClaims{"sub": "1"})
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	createReq := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	createReq.Header.Set("Authorization", token)
+	handler.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		expected int
+	}{
+		{"update at current version", "PUT", `{"name":"John","version":1}`, http.StatusOK},
+		{"update at old version", "PUT", `{"name":"Johnny","version":1}`, http.StatusConflict},
+		{"patch at old version", "PATCH", `{"age":31,"version":1}`, http.StatusConflict},
+		{"patch at current version", "PATCH", `{"age":31,"version":2}`, http.StatusOK},
+		{"patch without version", "PATCH", `{"age":32}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api/v1/users/1", strings.NewReader(tt.body))
+		req.Header.Set("Authorization", token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.expected, rr.Code, tt.name)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/users/1", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Contains(t, rr.Body.String(), `"name":"John"`)
+	assert.Contains(t, rr.Body.String(), `"version":4`)
+}
+
+func TestRouter_ValidationErrorsAreStructured(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+
+	body := `{"name":"John Doe","email":"john","age":0}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "1"}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []models.FieldError{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "age", Rule: "required", Message: "age is required"},
+	}, response.Errors)
+}
+
+func TestRouter_GetUsersWithCursor(t *testing.T) {
+	handler := newTestRouter(config.Load())
+
+	req := httptest.NewRequest("GET", "/api/v1/users?cursor=&limit=5", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"pagination":{"limit":5,"next_cursor":""}`)
+
+	req = httptest.NewRequest("GET", "/api/v1/users?cursor=bogus", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRouter_GetUsersFilterParameters(t *testing.T) {
+	handler := newTestRouter(config.Load())
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"?name=john&min_age=18&max_age=65&sort=-created_at,name", http.StatusOK},
+		{"?created_after=2025-01-01T00:00:00Z", http.StatusOK},
+		{"?min_age=abc", http.StatusBadRequest},
+		{"?created_after=yesterday", http.StatusBadRequest},
+		{"?sort=password_hash", http.StatusBadRequest},
+		{"?sort=name&cursor=", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/users"+tt.query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.expected, rr.Code, tt.query)
+	}
+}
+
+func TestRouter_LegalHold(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin"})
+	user := bearer(t, cfg, jwt.MapClaims{"sub": "2"})
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Authorization", user)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	steps := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		expected int
+	}{
+		{"hold requires admin", "PUT", "/api/v1/users/1/legal-hold", user, http.StatusForbidden},
+		{"place hold", "PUT", "/api/v1/users/1/legal-hold", admin, http.StatusOK},
+		{"delete while held", "DELETE", "/api/v1/users/1", user, http.StatusLocked},
+		{"lift hold", "DELETE", "/api/v1/users/1/legal-hold", admin, http.StatusOK},
+		{"delete after lift", "DELETE", "/api/v1/users/1", user, http.StatusOK},
+		{"hold deleted user", "PUT", "/api/v1/users/1/legal-hold", admin, http.StatusOK},
+		{"hold unknown user", "PUT", "/api/v1/users/99/legal-hold", admin, http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, nil)
+		req.Header.Set("Authorization", step.token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, step.expected, rr.Code, step.name)
+	}
+}
+
+func TestRouter_AdminRoutesNeedAdminScope(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Authorization", bearer(t, cfg, jwt.MapClaims{"sub": "2"}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected int
+	}{
+		{"session token", jwt.MapClaims{"sub": "1", "role": "admin"}, http.StatusOK},
+		{"read only token", jwt.MapClaims{"sub": "1", "role": "admin", "scope": "users:read"}, http.StatusForbidden},
+		{"openid token", jwt.MapClaims{"sub": "1", "role": "admin", "scope": "openid"}, http.StatusForbidden},
+		{"admin scoped token", jwt.MapClaims{"sub": "1", "role": "admin", "scope": "users:read admin"}, http.StatusOK},
+		{"admin scope without the role", jwt.MapClaims{"sub": "2", "scope": "admin"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("PUT", "/api/v1/users/1/legal-hold", nil)
+		req.Header.Set("Authorization", bearer(t, cfg, tt.claims))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, tt.expected, rr.Code, tt.name)
+	}
+}
+
+func TestRouter_SoftDeleteAndRestore(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin"})
+	user := bearer(t, cfg, jwt.MapClaims{"sub": "2"})
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Authorization", user)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	steps := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		expected int
+	}{
+		{"delete", "DELETE", "/api/v1/users/1", user, http.StatusOK},
+		{"get deleted", "GET", "/api/v1/users/1", "", http.StatusNotFound},
+		{"include_deleted anonymously", "GET", "/api/v1/users?include_deleted=true", "", http.StatusForbidden},
+		{"include_deleted as user", "GET", "/api/v1/users?include_deleted=true", user, http.StatusForbidden},
+		{"include_deleted as admin", "GET", "/api/v1/users?include_deleted=true", admin, http.StatusOK},
+		{"restore anonymously", "POST", "/api/v1/users/1/restore", "", http.StatusUnauthorized},
+		{"restore", "POST", "/api/v1/users/1/restore", user, http.StatusOK},
+		{"restore again", "POST", "/api/v1/users/1/restore", user, http.StatusConflict},
+		{"get restored", "GET", "/api/v1/users/1", "", http.StatusOK},
+	}
+
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, nil)
+		if step.token != "" {
+			req.Header.Set("Authorization", step.token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, step.expected, rr.Code, step.name)
+		if step.name == "include_deleted as admin" {
+			assert.Contains(t, rr.Body.String(), `"deleted_at":`)
+		}
+	}
+}
+
+func TestRouter_ExportUsers(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin"})
+	user := bearer(t, cfg, jwt.MapClaims{"sub": "2"})
+
+	for i := 0; i < 2; i++ {
+		body := fmt.Sprintf(`{"name":"John Doe","email":"john%d@example.com","age":30}`, i)
+		req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+		req.Header.Set("Authorization", user)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/users/export", nil)
+	req.Header.Set("Authorization", user)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/users/export", nil)
+	req.Header.Set("Authorization", admin)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	var exported models.UserResponse
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &exported))
+	assert.Equal(t, "john1@example.com", exported.Email)
+
+	req = httptest.NewRequest("GET", "/api/v1/users/export?sort=name", nil)
+	req.Header.Set("Authorization", admin)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	req = httptest.NewRequest("GET", "/api/v1/users/export?format=xml", nil)
+	req.Header.Set("Authorization", admin)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRouter_ExportUsersFormats(t *testing.T) {
+	cfg := config.Load()
+	handler := newTestRouter(cfg)
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin"})
+
+	export := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/users/export"+query, nil)
+		req.Header.Set("Authorization", admin)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		return rr
+	}
+
+	// An empty export is still a complete document
+	rr := export("?format=json")
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, "[]", rr.Body.String())
+	rr = export("?format=csv")
+	assert.Equal(t, "id,name,email,age,role,created_at,updated_at,legal_hold,deleted_at\n", rr.Body.String())
+
+	for i, name := range []string{"John Doe", "Doe, Jane"} {
+		body := fmt.Sprintf(`{"name":%q,"email":"john%d@example.com","age":%d}`, name, i, 30+i)
+		req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+		req.Header.Set("Authorization", admin)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rr = export("?format=json&min_age=30")
+	var users []models.UserResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &users))
+	require.Len(t, users, 2)
+	assert.Equal(t, "Doe, Jane", users[0].Name)
+
+	rr = export("?format=csv&min_age=31")
+	assert.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv"))
+	rows, err := csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"Doe, Jane", "john1@example.com", "31", "user"}, rows[1][1:5])
+	assert.Equal(t, "false", rows[1][7])
+	assert.Empty(t, rows[1][8])
+}
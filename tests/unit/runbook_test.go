@@ -0,0 +1,321 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/cache"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockJWTKeyRepository implements JWTKeyRepository interface for testing
+type MockJWTKeyRepository struct {
+	mu   sync.Mutex
+	keys []*models.JWTKey
+}
+
+func (m *MockJWTKeyRepository) Create(ctx context.Context, key *models.JWTKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key.CreatedAt = time.Now()
+	stored := *key
+	m.keys = append(m.keys, &stored)
+	return nil
+}
+
+func (m *MockJWTKeyRepository) List(ctx context.Context) ([]*models.JWTKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.JWTKey(nil), m.keys...), nil
+}
+
+func (m *MockJWTKeyRepository) DeleteSuperseded(ctx context.Context, cutoff time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []*models.JWTKey
+	for i, key := range m.keys {
+		if i+1 < len(m.keys) && m.keys[i+1].CreatedAt.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, key)
+	}
+	deleted := int64(len(m.keys) - len(kept))
+	m.keys = kept
+	return deleted, nil
+}
+
+// age moves the creation of every key back by d
+func (m *MockJWTKeyRepository) age(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.keys {
+		key.CreatedAt = key.CreatedAt.Add(-d)
+	}
+}
+
+// verify parses token with the keyring's keys
+func verify(keys *services.JWTKeyring, token string) error {
+	_, err := jwt.Parse(token, keys.Keyfunc)
+	return err
+}
+
+func TestJWTKeyring_Rotate(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := &MockJWTKeyRepository{}
+	cfg := config.JWTConfig{Secret: "base-secret", Expiration: time.Hour}
+	keys := services.NewJWTKeyring(keyRepo, cfg)
+	require.NoError(t, keys.Refresh(ctx))
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Hour).Unix()}
+	}
+
+	// Before any rotation tokens are signed with JWT_SECRET, without a kid
+	baseToken, err := keys.SignJWT(claims())
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(baseToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.NotContains(t, parsed.Header, "kid")
+	require.NoError(t, verify(keys, signTestToken(t, cfg.Secret, claims())))
+
+	first, err := keys.Rotate(ctx)
+	require.NoError(t, err)
+	firstToken, err := keys.SignJWT(claims())
+	require.NoError(t, err)
+	parsed, _, err = jwt.NewParser().ParseUnverified(firstToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, parsed.Header["kid"])
+
+	// Tokens issued before the rotation stay valid
+	assert.NoError(t, verify(keys, baseToken))
+	assert.NoError(t, verify(keys, firstToken))
+
+	// Once the first rotated key is older than a token's lifetime,
+	// JWT_SECRET is no longer accepted
+	keyRepo.age(2 * time.Hour)
+	require.NoError(t, keys.Refresh(ctx))
+	assert.Error(t, verify(keys, baseToken))
+	assert.NoError(t, verify(keys, firstToken))
+
+	// The first key signed tokens until the second replaced it, so it is
+	// kept until the second is older than a token's lifetime
+	_, err = keys.Rotate(ctx)
+	require.NoError(t, err)
+	secondToken, err := keys.SignJWT(claims())
+	require.NoError(t, err)
+	assert.NoError(t, verify(keys, firstToken))
+	assert.NoError(t, verify(keys, secondToken))
+
+	keyRepo.age(2 * time.Hour)
+	_, err = keys.Rotate(ctx)
+	require.NoError(t, err)
+	assert.Error(t, verify(keys, firstToken))
+	assert.NoError(t, verify(keys, secondToken))
+	assert.Len(t, keyRepo.keys, 2)
+
+	// Forged kids are rejected
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	forged.Header["kid"] = "unknown"
+	forgedToken, err := forged.SignedString([]byte(cfg.Secret))
+	require.NoError(t, err)
+	assert.Error(t, verify(keys, forgedToken))
+}
+
+func TestJWTKeyring_PicksUpKeysRotatedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := &MockJWTKeyRepository{}
+	cfg := config.JWTConfig{Secret: "base-secret", Expiration: time.Hour}
+	signing := services.NewJWTKeyring(keyRepo, cfg)
+	verifying := services.NewJWTKeyring(keyRepo, cfg)
+	require.NoError(t, verifying.Refresh(ctx))
+
+	_, err := signing.Rotate(ctx)
+	require.NoError(t, err)
+	token, err := signing.SignJWT(jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+
+	// The unknown kid makes the other instance reload its keys
+	assert.NoError(t, verify(verifying, token))
+}
+
+func TestEncryptedJWTKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	stored := &MockJWTKeyRepository{}
+	keyRepo, err := repository.NewEncryptedJWTKeyRepository(stored, "key-encryption-key")
+	require.NoError(t, err)
+
+	key := &models.JWTKey{ID: "k1", Secret: "signing-secret"}
+	require.NoError(t, keyRepo.Create(ctx, key))
+	assert.Equal(t, "signing-secret", key.Secret)
+	assert.False(t, key.CreatedAt.IsZero())
+
+	// The table holds only the ciphertext
+	raw, _ := stored.List(ctx)
+	require.Len(t, raw, 1)
+	assert.True(t, strings.HasPrefix(raw[0].Secret, "enc:v1:"))
+	assert.NotContains(t, raw[0].Secret, "signing-secret")
+
+	// Keys stored before encryption was enabled are read as they are
+	stored.keys = append(stored.keys, &models.JWTKey{ID: "k0", Secret: "plain-secret"})
+	keys, err := keyRepo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "signing-secret", keys[0].Secret)
+	assert.Equal(t, "plain-secret", keys[1].Secret)
+
+	// Under another encryption key the encrypted key is left out
+	otherRepo, err := repository.NewEncryptedJWTKeyRepository(stored, "another-key")
+	require.NoError(t, err)
+	keys, err = otherRepo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "k0", keys[0].ID)
+}
+
+func TestUserLRU_Clear(t *testing.T) {
+	ctx := context.Background()
+	base := NewMockUserRepository()
+	created, err := base.Create(ctx, &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	lru := repository.NewUserLRU(10, time.Minute)
+	repo := repository.NewLRUUserRepository(base, lru, nil)
+	_, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+
+	// Changed behind the cache's back, as by another instance
+	changed := *created
+	changed.Name = "Jane Doe"
+	base.users[created.ID] = &changed
+	cached, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", cached.Name)
+
+	lru.Clear()
+	fresh, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", fresh.Name)
+}
+
+func TestWebhookService_RedeliversFailedDeliveries(t *testing.T) {
+	ctx := context.Background()
+	cfg := testWebhookConfig()
+	cfg.MaxAttempts = 1
+	receiver, server := newWebhookReceiver(t, 1)
+	webhookRepo := &MockWebhookRepository{}
+	webhookService := services.NewWebhookService(webhookRepo, cfg)
+	_, err := webhookService.CreateWebhook(ctx, &models.WebhookRequest{
+		URL:    server.URL,
+		Secret: "0123456789abcdef",
+		Events: []string{models.WebhookUserCreated},
+	})
+	require.NoError(t, err)
+	runWebhooks(t, webhookService)
+
+	webhookService.Publish(ctx, models.WebhookUserCreated, map[string]int{"id": 1})
+	receiver.wait(t, 1)
+	require.Eventually(t, func() bool { return len(webhookRepo.recordedFailures()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	failure := webhookRepo.recordedFailures()[0]
+	assert.Equal(t, models.WebhookUserCreated, failure.Event)
+	assert.Equal(t, "unexpected status 502", failure.LastError)
+
+	n, err := webhookService.Redeliver(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	receiver.wait(t, 1)
+	assert.Empty(t, webhookRepo.recordedFailures())
+
+	// The consumer sees the same event again
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	assert.Equal(t, failure.EventID, receiver.requests[1].Header.Get("X-Webhook-ID"))
+	assert.Equal(t, receiver.bodies[0], receiver.bodies[1])
+}
+
+// newRunbookRouter serves the runbook of runbook behind the admin chain
+func newRunbookRouter(cfg *config.Config, runbook *services.RunbookService) http.Handler {
+	return router.New(router.Dependencies{
+		Config:  cfg,
+		Runbook: handlers.NewRunbookHandler(runbook),
+	}).Handler()
+}
+
+func TestRunbookRoutes(t *testing.T) {
+	cfg := config.Load()
+	memory, err := cache.NewMemory(1 << 20)
+	require.NoError(t, err)
+	defer memory.Close()
+	require.NoError(t, memory.Set(context.Background(), "claims:1", []byte("{}"), time.Minute))
+
+	emailRepo := &MockEmailRepository{emails: []*models.Email{
+		{ID: 1, Status: models.EmailFailed, Attempts: 5},
+		{ID: 2, Status: models.EmailSent},
+	}}
+	runbook := services.NewRunbookService(newMigrator(t, database.DriverPostgres), nil)
+	runbook.AddCache("app", memory.Flush)
+	runbook.SetEmails(emailRepo)
+	handler := newRunbookRouter(cfg, runbook)
+
+	post := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/runbook"+path, nil)
+		req.Header.Set("Authorization", token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin"})
+
+	// Only admins may use the runbook
+	rr := post("/caches/flush", bearer(t, cfg, jwt.MapClaims{"sub": "2", "role": "user"}))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// Scoped tokens need the runbook scope; the admin scope is not enough
+	rr = post("/caches/flush", bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin", "scope": "admin"}))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = post("/caches/flush", bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin", "scope": "admin:runbook"}))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = post("/caches/flush", admin)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var flushed struct {
+		Data models.CacheFlushResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &flushed))
+	assert.Equal(t, []string{"app"}, flushed.Data.Caches)
+	_, found, err := memory.Get(context.Background(), "claims:1")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	rr = post("/dead-letters/replay", admin)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"emails":1}`, string(mustField(t, rr.Body.Bytes(), "data")))
+	assert.Equal(t, models.EmailQueued, emailRepo.emails[0].Status)
+	assert.Zero(t, emailRepo.emails[0].Attempts)
+
+	// Components that are off are reported as such
+	rr = post("/jwt-keys/rotate", admin)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	rr = post("/webhooks/redeliver", admin)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+// mustField returns the raw JSON of a top-level field of body
+func mustField(t *testing.T, body []byte, field string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &fields))
+	return fields[field]
+}
@@ -1,15 +1,28 @@
 package unit
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
-	"github.com/stretchr/testify/assert"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
 	"github.com/pratham15541/go-crud/internal/models"
 	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockUserRepository implements UserRepository interface for testing
 type MockUserRepository struct {
-	users map[int]*models.User
+	users  map[int]*models.User
 	nextID int
 }
 
@@ -20,35 +33,144 @@ func NewMockUserRepository() *MockUserRepository {
 	}
 }
 
-func (m *MockUserRepository) Create(req *models.CreateUserRequest) (*models.User, error) {
+func (m *MockUserRepository) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	user := &models.User{
 		ID:    m.nextID,
 		Name:  req.Name,
 		Email: req.Email,
 		Age:   req.Age,
+		Role:  models.RoleUser,
+
+		Version: 1,
+		// Whole seconds so that several users share a created_at
+		CreatedAt: time.Now().Truncate(time.Second),
 	}
 	m.users[m.nextID] = user
 	m.nextID++
 	return user, nil
 }
 
-func (m *MockUserRepository) GetByID(id int) (*models.User, error) {
-	if user, exists := m.users[id]; exists {
+// CreateBatch rejects taken emails as the unique index would
+func (m *MockUserRepository) CreateBatch(ctx context.Context, reqs []*models.CreateUserRequest) ([]*models.User, []error, error) {
+	users := make([]*models.User, len(reqs))
+	errs := make([]error, len(reqs))
+	for i, req := range reqs {
+		if existing, _ := m.GetByEmail(ctx, req.Email); existing != nil {
+			errs[i] = &models.DuplicateEmailError{Email: req.Email}
+			continue
+		}
+		users[i], errs[i] = m.Create(ctx, req)
+	}
+	return users, errs, nil
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if user, exists := m.users[id]; exists && user.DeletedAt == nil {
 		return user, nil
 	}
-	return nil, fmt.Errorf("user not found")
+	return nil, apperrors.NotFound("user not found")
 }
 
-func (m *MockUserRepository) GetAll(limit, offset int) ([]*models.User, error) {
+func (m *MockUserRepository) GetAll(ctx context.Context, filter *models.UserFilter, limit, offset int) ([]*models.User, error) {
 	var users []*models.User
 	for _, user := range m.users {
-		users = append(users, user)
+		if matchesUserFilter(user, filter) {
+			users = append(users, user)
+		}
+	}
+	if filter != nil && len(filter.Sort) > 0 {
+		sort.SliceStable(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+		for k := len(filter.Sort) - 1; k >= 0; k-- {
+			order := filter.Sort[k]
+			sort.SliceStable(users, func(i, j int) bool {
+				if order.Desc {
+					return userFieldLess(users[j], users[i], order.Field)
+				}
+				return userFieldLess(users[i], users[j], order.Field)
+			})
+		}
+	}
+	if offset >= len(users) {
+		return nil, nil
+	}
+	users = users[offset:]
+	if len(users) > limit {
+		users = users[:limit]
 	}
 	return users, nil
 }
 
-func (m *MockUserRepository) Update(id int, req *models.UpdateUserRequest) (*models.User, error) {
-	if user, exists := m.users[id]; exists {
+// matchesUserFilter mirrors the repository's filter conditions
+func matchesUserFilter(user *models.User, filter *models.UserFilter) bool {
+	if user.DeletedAt != nil && (filter == nil || !filter.IncludeDeleted) {
+		return false
+	}
+	if filter == nil {
+		return true
+	}
+	return (filter.Name == "" || strings.Contains(strings.ToLower(user.Name), strings.ToLower(filter.Name))) &&
+		(filter.Email == "" || strings.Contains(strings.ToLower(user.Email), strings.ToLower(filter.Email))) &&
+		(filter.MinAge == 0 || user.Age >= filter.MinAge) &&
+		(filter.MaxAge == 0 || user.Age <= filter.MaxAge) &&
+		(filter.CreatedAfter.IsZero() || user.CreatedAt.After(filter.CreatedAfter))
+}
+
+// userFieldLess compares two users by a sortable field
+func userFieldLess(a, b *models.User, field string) bool {
+	switch field {
+	case "name":
+		return a.Name < b.Name
+	case "email":
+		return a.Email < b.Email
+	case "age":
+		return a.Age < b.Age
+	case "created_at":
+		return a.CreatedAt.Before(b.CreatedAt)
+	default:
+		return a.ID < b.ID
+	}
+}
+
+func (m *MockUserRepository) GetAfter(ctx context.Context, filter *models.UserFilter, cursor *models.UserCursor, limit int) ([]*models.User, error) {
+	var users []*models.User
+	for _, user := range m.users {
+		if !matchesUserFilter(user, filter) {
+			continue
+		}
+		if cursor == nil || user.CreatedAt.Before(cursor.CreatedAt) ||
+			(user.CreatedAt.Equal(cursor.CreatedAt) && user.ID < cursor.ID) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (m *MockUserRepository) Upsert(ctx context.Context, req *models.CreateUserRequest) (*models.User, bool, error) {
+	if user, _ := m.GetByEmail(ctx, req.Email); user != nil {
+		user.Name = req.Name
+		user.Age = req.Age
+		user.Version++
+		return user, false, nil
+	}
+	user, err := m.Create(ctx, req)
+	return user, true, err
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	if user, exists := m.users[id]; exists && user.DeletedAt == nil {
+		if req.Version != nil && *req.Version != user.Version {
+			return nil, models.ErrVersionConflict
+		}
+		user.Version++
 		if req.Name != "" {
 			user.Name = req.Name
 		}
@@ -60,33 +182,151 @@ func (m *MockUserRepository) Update(id int, req *models.UpdateUserRequest) (*mod
 		}
 		return user, nil
 	}
-	return nil, fmt.Errorf("user not found")
+	return nil, apperrors.NotFound("user not found")
 }
 
-func (m *MockUserRepository) Delete(id int) error {
-	if _, exists := m.users[id]; exists {
-		delete(m.users, id)
+func (m *MockUserRepository) Patch(ctx context.Context, id int, patch *models.PatchUserRequest) (*models.User, error) {
+	user, exists := m.users[id]
+	if !exists || user.DeletedAt != nil {
+		return nil, apperrors.NotFound("user not found")
+	}
+	if patch.Version != nil && *patch.Version != user.Version {
+		return nil, models.ErrVersionConflict
+	}
+	user.Version++
+	if patch.Name != nil {
+		user.Name = *patch.Name
+	}
+	if patch.Email != nil {
+		user.Email = *patch.Email
+	}
+	if patch.Age != nil {
+		user.Age = *patch.Age
+	}
+	return user, nil
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id int) error {
+	if user, exists := m.users[id]; exists && user.DeletedAt == nil {
+		if user.LegalHold {
+			return models.ErrLegalHold
+		}
+		now := time.Now()
+		user.DeletedAt = &now
+		user.Version++
 		return nil
 	}
-	return fmt.Errorf("user not found")
+	return apperrors.NotFound("user not found")
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
+func (m *MockUserRepository) Export(ctx context.Context, filter *models.UserFilter, batchSize int, fn func([]*models.User) error) error {
+	var cursor *models.UserCursor
+	for {
+		users, _ := m.GetAfter(ctx, filter, cursor, batchSize)
+		if len(users) > 0 {
+			if err := fn(users); err != nil {
+				return err
+			}
+		}
+		if len(users) < batchSize {
+			return nil
+		}
+		last := users[len(users)-1]
+		cursor = &models.UserCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id int) (*models.User, error) {
+	user, exists := m.users[id]
+	switch {
+	case !exists:
+		return nil, apperrors.NotFound("user not found")
+	case user.DeletedAt == nil:
+		return nil, apperrors.Conflict("user is not deleted")
+	}
+	if other, _ := m.GetByEmail(ctx, user.Email); other != nil {
+		return nil, apperrors.Conflict("email is in use by another user")
+	}
+	user.DeletedAt = nil
+	user.Version++
+	return user, nil
+}
+
+func (m *MockUserRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	for id, user := range m.users {
+		if user.DeletedAt != nil && user.DeletedAt.Before(cutoff) && !user.LegalHold {
+			delete(m.users, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MockUserRepository) SetLegalHold(ctx context.Context, id int, hold bool) (*models.User, error) {
+	user, exists := m.users[id]
+	if !exists {
+		return nil, apperrors.NotFound("user not found")
+	}
+	user.LegalHold = hold
+	user.Version++
+	return user, nil
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	for _, user := range m.users {
-		if user.Email == email {
+		if user.Email == email && user.DeletedAt == nil {
 			return user, nil
 		}
 	}
-	return nil, fmt.Errorf("user not found")
+	return nil, apperrors.NotFound("user not found")
+}
+
+func (m *MockUserRepository) Count(ctx context.Context, filter *models.UserFilter) (int64, error) {
+	var count int64
+	for _, user := range m.users {
+		if matchesUserFilter(user, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockUserRepository) CreateWithPassword(ctx context.Context, req *models.CreateUserRequest, passwordHash string) (*models.User, error) {
+	user, err := m.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = passwordHash
+	return user, nil
+}
+
+func (m *MockUserRepository) RecentlyActive(ctx context.Context, limit int) ([]*models.User, error) {
+	var users []*models.User
+	for _, user := range m.users {
+		if user.DeletedAt == nil {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].UpdatedAt.Equal(users[j].UpdatedAt) {
+			return users[i].UpdatedAt.After(users[j].UpdatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
 }
 
-func (m *MockUserRepository) Count() (int64, error) {
-	return int64(len(m.users)), nil
+func (m *MockUserRepository) GetCredentials(ctx context.Context, email string) (*models.User, error) {
+	return m.GetByEmail(ctx, email)
 }
 
 func TestUserService_CreateUser(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	userService := services.NewUserService(mockRepo)
+	userService := services.NewUserService(mockRepo, services.DefaultPagination(), idcodec.Plain{})
 
 	req := &models.CreateUserRequest{
 		Name:  "John Doe",
@@ -94,7 +334,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		Age:   30,
 	}
 
-	user, err := userService.CreateUser(req)
+	user, err := userService.CreateUser(context.Background(), req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
@@ -105,7 +345,7 @@ func TestUserService_CreateUser(t *testing.T) {
 
 func TestUserService_CreateUser_DuplicateEmail(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	userService := services.NewUserService(mockRepo)
+	userService := services.NewUserService(mockRepo, services.DefaultPagination(), idcodec.Plain{})
 
 	req := &models.CreateUserRequest{
 		Name:  "John Doe",
@@ -114,18 +354,66 @@ func TestUserService_CreateUser_DuplicateEmail(t *testing.T) {
 	}
 
 	// Create first user
-	_, err := userService.CreateUser(req)
+	_, err := userService.CreateUser(context.Background(), req)
 	assert.NoError(t, err)
 
 	// Try to create user with same email
-	_, err = userService.CreateUser(req)
+	_, err = userService.CreateUser(context.Background(), req)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "already exists")
+	assert.ErrorIs(t, err, models.ErrDuplicateEmail)
+}
+
+// racingUserRepository loses the race between the email check and the
+// insert: the check finds nothing and the insert hits the unique index
+type racingUserRepository struct {
+	*MockUserRepository
+}
+
+func (r racingUserRepository) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	return nil, &models.DuplicateEmailError{Email: req.Email}
+}
+
+func TestUserHandler_CreateUser_RaceReturnsConflict(t *testing.T) {
+	handler := handlers.NewUserHandler(services.NewUserService(racingUserRepository{NewMockUserRepository()}, services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{})
+
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(`{"name":"John Doe","email":"john@example.com","age":30}`))
+	rr := httptest.NewRecorder()
+	handler.CreateUser(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "user with email john@example.com already exists", response.Message)
+}
+
+// ageCheckUserRepository rejects every insert as the age CHECK constraint would
+type ageCheckUserRepository struct {
+	*MockUserRepository
+}
+
+func (r ageCheckUserRepository) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	return nil, models.ErrInvalidAge
+}
+
+func TestUserHandler_CreateUser_AgeCheckViolationIsFieldError(t *testing.T) {
+	handler := handlers.NewUserHandler(services.NewUserService(ageCheckUserRepository{NewMockUserRepository()}, services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{})
+
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(`{"name":"John Doe","email":"john@example.com","age":150}`))
+	rr := httptest.NewRecorder()
+	handler.CreateUser(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "age must be between 1 and 149", response.Message)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "age", response.Errors[0].Field)
 }
 
 func TestUserService_GetUser(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	userService := services.NewUserService(mockRepo)
+	userService := services.NewUserService(mockRepo, services.DefaultPagination(), idcodec.Plain{})
 
 	// Create a user first
 	req := &models.CreateUserRequest{
@@ -133,10 +421,10 @@ func TestUserService_GetUser(t *testing.T) {
 		Email: "john@example.com",
 		Age:   30,
 	}
-	createdUser, _ := userService.CreateUser(req)
+	createdUser, _ := userService.CreateUser(context.Background(), req)
 
 	// Get the user
-	user, err := userService.GetUser(createdUser.ID)
+	user, err := userService.GetUser(context.Background(), createdUser.ID)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
@@ -145,10 +433,282 @@ func TestUserService_GetUser(t *testing.T) {
 
 func TestUserService_GetUser_NotFound(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	userService := services.NewUserService(mockRepo)
+	userService := services.NewUserService(mockRepo, services.DefaultPagination(), idcodec.Plain{})
 
-	_, err := userService.GetUser(999)
+	_, err := userService.GetUser(context.Background(), 999)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not found")
-}
\ No newline at end of file
+}
+
+func TestUserService_PatchUser(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	userService := services.NewUserService(mockRepo, services.DefaultPagination(), idcodec.Plain{})
+
+	createdUser, _ := userService.CreateUser(context.Background(), &models.CreateUserRequest{
+		Name:  "John Doe",
+		Email: "john@example.com",
+		Age:   30,
+	})
+
+	age := 31
+	user, err := userService.PatchUser(context.Background(), createdUser.ID, &models.PatchUserRequest{Age: &age})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 31, user.Age)
+	assert.Equal(t, "John Doe", user.Name)
+	assert.Equal(t, "john@example.com", user.Email)
+}
+
+func TestUserService_PatchUser_ZeroValuesAreValidated(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	userService := services.NewUserService(mockRepo, services.DefaultPagination(), idcodec.Plain{})
+
+	createdUser, _ := userService.CreateUser(context.Background(), &models.CreateUserRequest{
+		Name:  "John Doe",
+		Email: "john@example.com",
+		Age:   30,
+	})
+
+	age := 0
+	_, err := userService.PatchUser(context.Background(), createdUser.ID, &models.PatchUserRequest{Age: &age})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "age must be at least 1")
+
+	name := ""
+	_, err = userService.PatchUser(context.Background(), createdUser.ID, &models.PatchUserRequest{Name: &name})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "name is required")
+
+	_, err = userService.PatchUser(context.Background(), createdUser.ID, &models.PatchUserRequest{})
+	assert.Error(t, err)
+}
+
+func TestUserService_PatchUser_NotFound(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	age := 40
+	_, err := userService.PatchUser(context.Background(), 999, &models.PatchUserRequest{Age: &age})
+
+	assert.Error(t, err)
+	assert.Equal(t, "user not found", err.Error())
+}
+
+func TestUserService_CreateUser_ValidationErrors(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	_, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{
+		Name:  "J",
+		Email: "not-an-email@",
+		Age:   200,
+	})
+
+	assert.Equal(t, []models.FieldError{
+		{Field: "name", Rule: "min", Message: "name must be at least 2 characters"},
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "age", Rule: "max", Message: "age must be at most 150"},
+	}, services.ValidationErrors(err))
+}
+
+func TestUserService_CreateUser_BlankName(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	_, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{
+		Name:  "   ",
+		Email: "john@example.com",
+		Age:   30,
+	})
+
+	if assert.Len(t, services.ValidationErrors(err), 1) {
+		assert.Equal(t, "notblank", services.ValidationErrors(err)[0].Rule)
+	}
+}
+
+func TestUserService_GetUsersAfter(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	for i := 0; i < 5; i++ {
+		_, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{
+			Name:  "John Doe",
+			Email: fmt.Sprintf("john%d@example.com", i),
+			Age:   30,
+		})
+		assert.NoError(t, err)
+	}
+
+	var ids []int
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		users, pagination, err := userService.GetUsersAfter(context.Background(), nil, cursor, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, pagination.Limit)
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		if pagination.NextCursor == "" {
+			break
+		}
+		cursor = pagination.NextCursor
+	}
+
+	assert.Equal(t, []int{5, 4, 3, 2, 1}, ids)
+}
+
+func TestUserService_GetUsersAfter_InvalidCursor(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+		_, _, err := userService.GetUsersAfter(context.Background(), nil, cursor, 10)
+		assert.EqualError(t, err, "invalid cursor", cursor)
+	}
+}
+
+func TestUserService_GetUsers_FilterAndSort(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	for _, req := range []*models.CreateUserRequest{
+		{Name: "Carol", Email: "carol@example.com", Age: 40},
+		{Name: "alice", Email: "alice@example.com", Age: 25},
+		{Name: "Bob", Email: "bob@example.org", Age: 35},
+		{Name: "Alicia", Email: "alicia@example.org", Age: 35},
+	} {
+		_, err := userService.CreateUser(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	users, total, err := userService.GetUsers(context.Background(), &models.UserFilter{
+		MinAge: 30,
+		Sort:   []models.UserSort{{Field: "age", Desc: true}, {Field: "name"}},
+	}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Name
+	}
+	assert.Equal(t, []string{"Carol", "Alicia", "Bob"}, names)
+
+	_, total, err = userService.GetUsers(context.Background(), &models.UserFilter{Name: "ali", Email: ".org"}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestUserService_GetUsers_InvalidFilter(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+
+	_, _, err := userService.GetUsers(context.Background(), &models.UserFilter{
+		MinAge: 50,
+		MaxAge: 20,
+		Sort:   []models.UserSort{{Field: "password_hash"}},
+	}, 1, 10)
+
+	fieldErrs := services.ValidationErrors(err)
+	require.Len(t, fieldErrs, 2)
+	assert.Equal(t, "cannot sort by password_hash", fieldErrs[0].Message)
+	assert.Equal(t, "min_age", fieldErrs[1].Field)
+
+	_, _, err = userService.GetUsersAfter(context.Background(), &models.UserFilter{
+		Sort: []models.UserSort{{Field: "name"}},
+	}, "", 10)
+	assert.EqualError(t, err, "sort cannot be combined with cursor")
+}
+
+func TestUserService_LegalHoldBlocksDeleteAndAnonymize(t *testing.T) {
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	user, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{
+		Name:  "John Doe",
+		Email: "john@example.com",
+		Age:   30,
+	})
+	require.NoError(t, err)
+
+	held, err := userService.SetLegalHold(context.Background(), user.ID, true)
+	require.NoError(t, err)
+	assert.True(t, held.LegalHold)
+
+	err = userService.DeleteUser(context.Background(), user.ID)
+	assert.ErrorIs(t, err, models.ErrLegalHold)
+	_, err = userService.AnonymizeUser(context.Background(), user.ID)
+	assert.ErrorIs(t, err, models.ErrLegalHold)
+
+	_, err = userService.SetLegalHold(context.Background(), user.ID, false)
+	require.NoError(t, err)
+	assert.NoError(t, userService.DeleteUser(context.Background(), user.ID))
+}
+
+func TestUserService_SoftDeleteAndRestore(t *testing.T) {
+	repo := NewMockUserRepository()
+	userService := services.NewUserService(repo, services.DefaultPagination(), idcodec.Plain{})
+	req := &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30}
+	user, err := userService.CreateUser(context.Background(), req)
+	require.NoError(t, err)
+
+	require.NoError(t, userService.DeleteUser(context.Background(), user.ID))
+	_, err = userService.GetUser(context.Background(), user.ID)
+	assert.Error(t, err)
+	_, total, _ := userService.GetUsers(context.Background(), nil, 1, 10)
+	assert.Equal(t, int64(0), total)
+	_, total, _ = userService.GetUsers(context.Background(), &models.UserFilter{IncludeDeleted: true}, 1, 10)
+	assert.Equal(t, int64(1), total)
+
+	restored, err := userService.RestoreUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	_, err = userService.RestoreUser(context.Background(), user.ID)
+	assert.EqualError(t, err, "user is not deleted")
+
+	// The email of a deleted user can be reused, which blocks restoring it
+	require.NoError(t, userService.DeleteUser(context.Background(), user.ID))
+	_, err = userService.CreateUser(context.Background(), req)
+	require.NoError(t, err)
+	_, err = userService.RestoreUser(context.Background(), user.ID)
+	assert.EqualError(t, err, "email is in use by another user")
+}
+
+func TestUserService_PurgeDeleted(t *testing.T) {
+	repo := NewMockUserRepository()
+	userService := services.NewUserService(repo, services.DefaultPagination(), idcodec.Plain{})
+	for i := 0; i < 3; i++ {
+		user, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{
+			Name:  "John Doe",
+			Email: fmt.Sprintf("john%d@example.com", i),
+			Age:   30,
+		})
+		require.NoError(t, err)
+		if i > 0 {
+			require.NoError(t, userService.DeleteUser(context.Background(), user.ID))
+		}
+	}
+	longAgo := time.Now().Add(-48 * time.Hour)
+	repo.users[2].DeletedAt = &longAgo
+
+	purged, err := userService.PurgeDeleted(context.Background(), 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	assert.Len(t, repo.users, 2)
+	assert.NotContains(t, repo.users, 2)
+}
+
+func TestUserService_ExportUsers(t *testing.T) {
+	repo := NewMockUserRepository()
+	userService := services.NewUserService(repo, services.DefaultPagination(), idcodec.Plain{})
+	for i := 0; i < 3; i++ {
+		_, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{
+			Name:  "John Doe",
+			Email: fmt.Sprintf("john%d@example.com", i),
+			Age:   30 + i,
+		})
+		require.NoError(t, err)
+	}
+
+	var exported []*models.User
+	err := userService.ExportUsers(context.Background(), &models.UserFilter{MinAge: 31}, func(users []*models.User) error {
+		exported = append(exported, users...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, exported, 2)
+
+	err = userService.ExportUsers(context.Background(), &models.UserFilter{
+		Sort: []models.UserSort{{Field: "name"}},
+	}, func([]*models.User) error { return nil })
+	assert.EqualError(t, err, "sort cannot be combined with export")
+}
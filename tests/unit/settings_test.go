@@ -0,0 +1,202 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/docschema"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSettingsRepository keeps settings documents in memory
+type mockSettingsRepository struct {
+	settings map[int]models.UserSettings
+	upgrades int
+}
+
+func newMockSettingsRepository() *mockSettingsRepository {
+	return &mockSettingsRepository{settings: make(map[int]models.UserSettings)}
+}
+
+func (m *mockSettingsRepository) Get(ctx context.Context, userID int) (*models.UserSettings, error) {
+	settings, ok := m.settings[userID]
+	if !ok {
+		return nil, apperrors.NotFound("settings not found")
+	}
+	return &settings, nil
+}
+
+func (m *mockSettingsRepository) Save(ctx context.Context, settings *models.UserSettings, maxVersion int) error {
+	if stored, ok := m.settings[settings.UserID]; ok && stored.SchemaVersion > maxVersion {
+		return apperrors.Conflict("settings were saved by a newer version")
+	}
+	m.settings[settings.UserID] = *settings
+	return nil
+}
+
+func (m *mockSettingsRepository) Upgrade(ctx context.Context, settings *models.UserSettings, fromVersion int) (bool, error) {
+	if m.settings[settings.UserID].SchemaVersion != fromVersion {
+		return false, nil
+	}
+	m.settings[settings.UserID] = *settings
+	m.upgrades++
+	return true, nil
+}
+
+// settingsSchemaV3 renames "theme" to "appearance.theme" and then adds a
+// default "density"
+var settingsSchemaV3 = docschema.NewPipeline("test settings",
+	func(doc map[string]interface{}) error {
+		if theme, ok := doc["theme"]; ok {
+			doc["appearance"] = map[string]interface{}{"theme": theme}
+			delete(doc, "theme")
+		}
+		return nil
+	},
+	func(doc map[string]interface{}) error {
+		if _, ok := doc["density"]; !ok {
+			doc["density"] = "comfortable"
+		}
+		return nil
+	},
+)
+
+func TestDocSchema_Upgrade(t *testing.T) {
+	assert.Equal(t, 3, settingsSchemaV3.Current())
+
+	upgraded, err := settingsSchemaV3.Upgrade(1, json.RawMessage(`{"theme":"dark"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"appearance":{"theme":"dark"},"density":"comfortable"}`, string(upgraded))
+
+	// Only the upgrades after the stored version run
+	upgraded, err = settingsSchemaV3.Upgrade(2, json.RawMessage(`{"theme":"dark","density":"compact"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"theme":"dark","density":"compact"}`, string(upgraded))
+
+	// Documents from before versioning are version 1
+	upgraded, err = settingsSchemaV3.Upgrade(0, json.RawMessage(`{"theme":"light"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"appearance":{"theme":"light"},"density":"comfortable"}`, string(upgraded))
+
+	current := json.RawMessage(`{"density":"compact"}`)
+	upgraded, err = settingsSchemaV3.Upgrade(3, current)
+	require.NoError(t, err)
+	assert.Equal(t, current, upgraded)
+
+	_, err = settingsSchemaV3.Upgrade(4, current)
+	assert.True(t, errors.Is(err, docschema.ErrNewerVersion))
+}
+
+func TestSettingsService_GetSettingsUpgradesLazily(t *testing.T) {
+	users := NewMockUserRepository()
+	user, _ := users.Create(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	repo := newMockSettingsRepository()
+	repo.settings[user.ID] = models.UserSettings{UserID: user.ID, SchemaVersion: 1, Settings: json.RawMessage(`{"theme":"dark"}`)}
+	service := services.NewSettingsService(repo, users, settingsSchemaV3)
+
+	settings, err := service.GetSettings(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, settings.SchemaVersion)
+	assert.JSONEq(t, `{"appearance":{"theme":"dark"},"density":"comfortable"}`, string(settings.Settings))
+
+	// The upgrade is stored, so reading again does not repeat it
+	assert.Equal(t, 3, repo.settings[user.ID].SchemaVersion)
+	_, err = service.GetSettings(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.upgrades)
+}
+
+func TestSettingsService_GetSettingsDefaults(t *testing.T) {
+	users := NewMockUserRepository()
+	user, _ := users.Create(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	service := services.NewSettingsService(newMockSettingsRepository(), users, settingsSchemaV3)
+
+	settings, err := service.GetSettings(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, settings.SchemaVersion)
+	assert.JSONEq(t, `{}`, string(settings.Settings))
+
+	_, err = service.GetSettings(context.Background(), 99)
+	assert.EqualError(t, err, "user not found")
+}
+
+func TestSettingsService_UpdateSettings(t *testing.T) {
+	users := NewMockUserRepository()
+	user, _ := users.Create(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	repo := newMockSettingsRepository()
+	service := services.NewSettingsService(repo, users, settingsSchemaV3)
+
+	settings, err := service.UpdateSettings(context.Background(), user.ID, json.RawMessage(`{ "density": "compact" }`))
+	require.NoError(t, err)
+	assert.Equal(t, 3, settings.SchemaVersion)
+	assert.Equal(t, `{"density":"compact"}`, string(repo.settings[user.ID].Settings))
+
+	for _, document := range []string{`[]`, `"dark"`, `null`} {
+		_, err = service.UpdateSettings(context.Background(), user.ID, json.RawMessage(document))
+		assert.EqualError(t, err, "settings must be a JSON object", document)
+	}
+	large := `{"notes":"` + strings.Repeat("x", 17<<10) + `"}`
+	_, err = service.UpdateSettings(context.Background(), user.ID, json.RawMessage(large))
+	assert.Error(t, err)
+
+	// A newer release's document is left alone
+	repo.settings[user.ID] = models.UserSettings{UserID: user.ID, SchemaVersion: 4, Settings: json.RawMessage(`{}`)}
+	_, err = service.UpdateSettings(context.Background(), user.ID, json.RawMessage(`{}`))
+	assert.EqualError(t, err, "settings were saved by a newer version")
+	settings, err = service.GetSettings(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, settings.SchemaVersion)
+}
+
+func TestRouter_UserSettings(t *testing.T) {
+	cfg := config.Load()
+	users := NewMockUserRepository()
+	user, _ := users.Create(context.Background(), &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	service := services.NewSettingsService(newMockSettingsRepository(), users, services.UserSettingsSchema)
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Settings:      handlers.NewSettingsHandler(service, idcodec.Plain{}),
+	}).Handler()
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin"})
+	path := fmt.Sprintf("/api/v1/users/%d/settings", user.ID)
+
+	send := func(method, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("GET", "", "").Code)
+	assert.Equal(t, http.StatusBadRequest, send("PUT", `["dark"]`, admin).Code)
+	assert.Equal(t, http.StatusOK, send("PUT", `{"theme":"dark"}`, admin).Code)
+
+	rr := send("GET", "", admin)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data models.UserSettings `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, services.UserSettingsSchema.Current(), resp.Data.SchemaVersion)
+	assert.JSONEq(t, `{"theme":"dark"}`, string(resp.Data.Settings))
+}
@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/pratham15541/go-crud/internal/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// publicKeyPEM encodes the public half of key as a PEM PUBLIC KEY
+func publicKeyPEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// signedRequest builds a request signed with key at now
+func signedRequest(key ed25519.PrivateKey, method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	payload := signing.Payload(method, target, timestamp, []byte(body))
+	req.Header.Set(signing.HeaderTimestamp, timestamp)
+	req.Header.Set(signing.HeaderSignature, base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(payload))))
+	return req
+}
+
+func TestSigning_VerifiesSupportedKeys(t *testing.T) {
+	payload := signing.Payload("POST", "/api/v1/users?dry_run=true", "1700000000", []byte(`{"name":"John"}`))
+	assert.Equal(t, 4, len(strings.Split(payload, "\n")))
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edSignature := base64.StdEncoding.EncodeToString(ed25519.Sign(edPrivate, []byte(payload)))
+	assert.NoError(t, signing.Verify(publicKeyPEM(t, edPublic), payload, edSignature))
+	assert.ErrorIs(t, signing.Verify(publicKeyPEM(t, edPublic), payload+"x", edSignature), signing.ErrInvalidSignature)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(payload))
+	ecSignature, err := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+	require.NoError(t, err)
+	assert.NoError(t, signing.Verify(publicKeyPEM(t, &ecKey.PublicKey), payload, base64.StdEncoding.EncodeToString(ecSignature)))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = signing.ParsePublicKey(publicKeyPEM(t, &rsaKey.PublicKey))
+	assert.ErrorContains(t, err, "Ed25519 or ECDSA P-256")
+	_, err = signing.ParsePublicKey("not a key")
+	assert.Error(t, err)
+}
+
+func TestRouter_SignedRequestsAreAudited(t *testing.T) {
+	cfg := config.Load()
+	repo := &MockAuditRepository{}
+	auditService := services.NewAuditService(repo, services.DefaultPagination())
+	tokenService := services.NewPersonalTokenService(NewMockPersonalTokenRepository(), cfg.Tokens, zap.NewNop())
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Tokens:        tokenService,
+		Audit:         auditService,
+	}).Handler()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	created, err := tokenService.CreateToken(context.Background(), 1, &models.CreatePersonalTokenRequest{
+		Name:       "deploy script",
+		Scopes:     []string{services.ScopeUsersWrite},
+		SigningKey: publicKeyPEM(t, public),
+	})
+	require.NoError(t, err)
+
+	body := `{"name":"John Doe","email":"john@example.com","age":30}`
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+created.Token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Signed for another body
+	req = signedRequest(private, "POST", "/api/v1/users", body)
+	req.Body = http.NoBody
+	req.Header.Set("Authorization", "Bearer "+created.Token)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req = signedRequest(private, "POST", "/api/v1/users", body)
+	req.Header.Set("Authorization", "Bearer "+created.Token)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	require.Len(t, repo.entries, 1)
+	entry := repo.entries[0]
+	assert.Equal(t, created.PersonalToken.SigningKey, entry.SigningKey)
+	assert.True(t, strings.HasPrefix(entry.SignedPayload, "POST\n/api/v1/users\n"))
+	// The entry alone proves the key holder issued the request
+	assert.NoError(t, signing.Verify(entry.SigningKey, entry.SignedPayload, entry.Signature))
+
+	repo.entries[0].Signature = ""
+	result, err := auditService.Verify(req.Context())
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+}
+
+func TestRouter_RequiredSignaturesRejectTokensWithoutKeys(t *testing.T) {
+	cfg := config.Load()
+	cfg.Tokens.RequireSignatures = true
+	tokenService := services.NewPersonalTokenService(NewMockPersonalTokenRepository(), cfg.Tokens, zap.NewNop())
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Tokens:        tokenService,
+	}).Handler()
+
+	created, err := tokenService.CreateToken(context.Background(), 1, &models.CreatePersonalTokenRequest{
+		Name:   "deploy script",
+		Scopes: []string{services.ScopeUsersRead, services.ScopeUsersWrite},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(`{"name":"John Doe","email":"john@example.com","age":30}`))
+	req.Header.Set("Authorization", "Bearer "+created.Token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "register a signing key")
+
+	// Reads need no signature
+	req = httptest.NewRequest("GET", "/api/v1/users", nil)
+	req.Header.Set("Authorization", "Bearer "+created.Token)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
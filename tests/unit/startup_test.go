@@ -0,0 +1,61 @@
+package unit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/pratham15541/go-crud/internal/startup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupReport_DescribesConfiguration(t *testing.T) {
+	t.Setenv("HOST", "0.0.0.0")
+	t.Setenv("BASE_PATH", "/crud")
+	t.Setenv("GRPC_ENABLED", "true")
+	t.Setenv("AUDIT_ENABLED", "true")
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("DB_PASSWORD", "s3cret")
+	migrator := newMigrator(t, database.DriverPostgres)
+	report := startup.New(config.Load(), migrator, time.Now())
+
+	assert.Equal(t, []startup.Listener{
+		{Protocol: "http", Address: "0.0.0.0:8080", BasePath: "/crud"},
+		{Protocol: "grpc", Address: "0.0.0.0:9090"},
+	}, report.Listeners)
+	assert.Equal(t, "db.internal:5432/crud_demo", report.Database.Target)
+	assert.Equal(t, migrator.SchemaVersion(), report.Database.SchemaVersion)
+	assert.True(t, report.Modules["audit"])
+	assert.False(t, report.Modules["oidc"])
+	assert.Contains(t, report.Banner(), "grpc      0.0.0.0:9090")
+
+	encoded, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "s3cret")
+}
+
+func TestStartupReport_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.json")
+	report := startup.New(config.Load(), newMigrator(t, database.DriverPostgres), time.Now())
+	report.Database.AppliedSchemaVersion = 3
+
+	require.NoError(t, report.WriteFile(path))
+	// Rewriting replaces the previous report
+	require.NoError(t, report.WriteFile(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var written startup.Report
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, 3, written.Database.AppliedSchemaVersion)
+	assert.Equal(t, report.Listeners, written.Listeners)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are cleaned up")
+}
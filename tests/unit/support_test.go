@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/database"
+	"github.com/pratham15541/go-crud/internal/logger"
+	"github.com/pratham15541/go-crud/internal/startup"
+	"github.com/pratham15541/go-crud/internal/support"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// readBundle returns the files of a gzipped tarball by name
+func readBundle(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	files := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		files[header.Name], err = io.ReadAll(tr)
+		require.NoError(t, err)
+	}
+}
+
+func TestRecentLogs_KeepsLatestEntries(t *testing.T) {
+	recent := logger.NewRecent(2)
+	log := zap.NewNop().WithOptions(recent.Tee(zapcore.InfoLevel))
+	log.Info("first")
+	log.Debug("ignored")
+	log.Info("second")
+	log.Warn("third", zap.String("user", "ada"))
+
+	var buf bytes.Buffer
+	_, err := recent.WriteTo(&buf)
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.Contains(t, string(lines[0]), `"msg":"second"`)
+	assert.Contains(t, string(lines[1]), `"msg":"third"`)
+	assert.Contains(t, string(lines[1]), `"user":"ada"`)
+}
+
+func TestSupportBundle_CollectsDiagnostics(t *testing.T) {
+	cfg := config.Load()
+	cfg.JWT.Secret = "jwt-secret"
+	cfg.Database.Password = "db-password"
+	cfg.Email.SMTPPassword = "smtp-password"
+	cfg.JWT.KeyEncryptionKey = "key-encryption-key"
+	cfg.IDs.Alphabet = "private-alphabet"
+	cfg.Redis.URL = "redis://:redis-password@localhost:6379/0"
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectQuery("SELECT version, name, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "initial", time.Now()))
+
+	recent := logger.NewRecent(10)
+	zap.NewNop().WithOptions(recent.Tee(zapcore.InfoLevel)).Info("request served")
+
+	bundler := support.NewBundler(cfg, newMigrator(t, database.DriverPostgres), db, map[string]*sql.DB{"api": db, "replica": nil}, nil, recent)
+	bundler.SetStartupReport(startup.New(cfg, newMigrator(t, database.DriverPostgres), time.Now()))
+	var buf bytes.Buffer
+	require.NoError(t, bundler.Write(context.Background(), &buf))
+	files := readBundle(t, buf.Bytes())
+
+	for _, name := range []string{"config.json", "startup.json", "runtime.json", "goroutines.txt", "db_stats.json", "migrations.json", "logs.jsonl", "manifest.json"} {
+		assert.Contains(t, files, name)
+	}
+	assert.Contains(t, string(files["goroutines.txt"]), "goroutine")
+	assert.Contains(t, string(files["logs.jsonl"]), "request served")
+	assert.Contains(t, string(files["db_stats.json"]), `"api"`)
+	assert.NotContains(t, string(files["db_stats.json"]), `"replica"`)
+
+	// Secrets never reach the bundle
+	configJSON := string(files["config.json"])
+	assert.NotContains(t, configJSON, "jwt-secret")
+	assert.NotContains(t, configJSON, "db-password")
+	assert.NotContains(t, configJSON, "redis-password")
+	assert.NotContains(t, configJSON, "smtp-password")
+	assert.NotContains(t, configJSON, "key-encryption-key")
+	assert.NotContains(t, configJSON, "private-alphabet")
+	assert.NotContains(t, string(files["startup.json"]), "private-alphabet")
+	assert.Contains(t, configJSON, "localhost:6379")
+
+	// Without a monitor the health section is left out and explained
+	var manifest support.Manifest
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.NotContains(t, files, "health.json")
+	assert.Contains(t, manifest.Errors, "health.json")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransition has remaining rows to backfill and reports report on Check
+type fakeTransition struct {
+	phase     models.TransitionPhase
+	remaining int64
+	batches   []int
+	report    models.TransitionReport
+}
+
+func (t *fakeTransition) Name() string                  { return "fake" }
+func (t *fakeTransition) Phase() models.TransitionPhase { return t.phase }
+
+func (t *fakeTransition) Backfill(ctx context.Context, batchSize int) (int64, error) {
+	t.batches = append(t.batches, batchSize)
+	filled := t.remaining
+	if filled > int64(batchSize) {
+		filled = int64(batchSize)
+	}
+	t.remaining -= filled
+	return filled, nil
+}
+
+func (t *fakeTransition) Check(ctx context.Context, sampleSize int) (*models.TransitionReport, error) {
+	report := t.report
+	report.Name = t.Name()
+	report.Phase = t.phase
+	return &report, nil
+}
+
+func TestParseTransitionPhase(t *testing.T) {
+	for _, name := range []string{"old", "dual-write", "read-new", "new"} {
+		phase, err := models.ParseTransitionPhase(name)
+		require.NoError(t, err)
+		assert.Equal(t, models.TransitionPhase(name), phase)
+	}
+
+	_, err := models.ParseTransitionPhase("cutover")
+	assert.Error(t, err)
+}
+
+func TestTransitionPhase_ReadsAndWrites(t *testing.T) {
+	tests := []struct {
+		phase                         models.TransitionPhase
+		writesOld, writesNew, readNew bool
+	}{
+		{models.PhaseOld, true, false, false},
+		{models.PhaseDualWrite, true, true, false},
+		{models.PhaseReadNew, true, true, true},
+		{models.PhaseNew, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			assert.Equal(t, tt.writesOld, tt.phase.WritesOld())
+			assert.Equal(t, tt.writesNew, tt.phase.WritesNew())
+			assert.Equal(t, tt.readNew, tt.phase.ReadsNew())
+		})
+	}
+}
+
+func TestTransitionService_RunBackfill(t *testing.T) {
+	transition := &fakeTransition{phase: models.PhaseDualWrite, remaining: 25}
+	service := services.NewTransitionService(transition)
+
+	service.RunBackfill(context.Background(), 10, 0)
+
+	assert.Equal(t, []int{10, 10, 10}, transition.batches)
+	assert.Zero(t, transition.remaining)
+}
+
+func TestTransitionService_RunBackfillOnlyWhileDualWriting(t *testing.T) {
+	for _, phase := range []models.TransitionPhase{models.PhaseOld, models.PhaseNew} {
+		transition := &fakeTransition{phase: phase, remaining: 25}
+		services.NewTransitionService(transition).RunBackfill(context.Background(), 10, 0)
+		assert.Empty(t, transition.batches, phase)
+	}
+}
+
+func TestRouter_CheckTransition(t *testing.T) {
+	cfg := config.Load()
+	transition := &fakeTransition{phase: models.PhaseDualWrite}
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Transitions:   handlers.NewTransitionHandler(services.NewTransitionService(transition), idcodec.Plain{}),
+	}).Handler()
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "1", "role": "admin"})
+
+	check := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", admin)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	transition.report = models.TransitionReport{Checked: 3, Consistent: true}
+	rr := check("/api/v1/transitions/fake/check")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	transition.report = models.TransitionReport{
+		Checked:       3,
+		Mismatched:    1,
+		MismatchedIDs: []int{2},
+	}
+	rr = check("/api/v1/transitions/fake/check")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	var resp struct {
+		Data models.TransitionReportResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, models.PhaseDualWrite, resp.Data.Phase)
+	assert.Equal(t, []idcodec.PublicID{plainPublicID(2)}, resp.Data.MismatchedIDs)
+
+	assert.Equal(t, http.StatusNotFound, check("/api/v1/transitions/unknown/check").Code)
+}
@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTxManager runs fn directly and records how each transaction ended
+type fakeTxManager struct {
+	committed, rolledBack int
+}
+
+func (m *fakeTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		m.rolledBack++
+		return err
+	}
+	m.committed++
+	return nil
+}
+
+// failingAuditRepository rejects every entry
+type failingAuditRepository struct {
+	MockAuditRepository
+}
+
+func (m *failingAuditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	return fmt.Errorf("audit log unavailable")
+}
+
+func TestTxManager_RepositoriesJoinTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	settings := repository.NewSettingsRepository(db)
+	transactions := repository.NewTxManager(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO user_settings")).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE user_settings")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = transactions.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		doc := &models.UserSettings{UserID: 1, SchemaVersion: 1, Settings: json.RawMessage(`{}`)}
+		if err := settings.Save(ctx, doc, 1); err != nil {
+			return err
+		}
+		// Nested calls join the outer transaction rather than begin another
+		return transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, err := settings.Upgrade(ctx, doc, 1)
+			return err
+		})
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxManager_RollsBackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	settings := repository.NewSettingsRepository(db)
+	transactions := repository.NewTxManager(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO user_settings")).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectRollback()
+
+	err = transactions.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		doc := &models.UserSettings{UserID: 1, SchemaVersion: 1, Settings: json.RawMessage(`{}`)}
+		if err := settings.Save(ctx, doc, 1); err != nil {
+			return err
+		}
+		return fmt.Errorf("second step failed")
+	})
+	assert.EqualError(t, err, "second step failed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Panics roll back too
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	assert.Panics(t, func() {
+		transactions.WithinTransaction(context.Background(), func(ctx context.Context) error {
+			panic("boom")
+		})
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserService_AnonymizeRecordsAuditEntryInTransaction(t *testing.T) {
+	users := NewMockUserRepository()
+	user, _ := users.Create(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	auditRepo := &MockAuditRepository{}
+	transactions := &fakeTxManager{}
+	userService := services.NewUserService(users, services.DefaultPagination(), idcodec.Plain{})
+	userService.SetAuditLog(transactions, services.NewAuditService(auditRepo, services.DefaultPagination()))
+
+	ctx := actor.NewContext(context.Background(), actor.Actor{UserID: "7"})
+	_, err := userService.AnonymizeUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, transactions.committed)
+	require.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, "anonymize user", auditRepo.entries[0].Action)
+	assert.Equal(t, "7", auditRepo.entries[0].ActorID)
+	assert.Equal(t, "1", auditRepo.entries[0].TargetID)
+
+	// Without its audit entry the change is rolled back
+	userService.SetAuditLog(transactions, services.NewAuditService(&failingAuditRepository{}, services.DefaultPagination()))
+	_, err = userService.AnonymizeUser(ctx, user.ID)
+	assert.ErrorContains(t, err, "audit log unavailable")
+	assert.Equal(t, 1, transactions.rolledBack)
+}
@@ -0,0 +1,67 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCacheObserver counts cache lookups by result
+type countingCacheObserver map[string]int
+
+func (o countingCacheObserver) ObserveCache(name, result string) {
+	o[result]++
+}
+
+func TestUserCache_AnswersRepeatedLookups(t *testing.T) {
+	inner := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	observer := countingCacheObserver{}
+	repo := repository.NewCachedUserRepository(inner, newTestCache(t), time.Minute, observer)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		user, err := repo.GetByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Ada", user.Name)
+		user, err = repo.GetByEmail(ctx, "ada@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, user.ID)
+	}
+
+	// The first GetByID and the first GetByEmail reach the repository
+	assert.Equal(t, 2, inner.lookups)
+	assert.Equal(t, 4, observer[repository.CacheHit])
+	assert.Equal(t, 2, observer[repository.CacheMiss])
+}
+
+func TestUserCache_WritesDropEntries(t *testing.T) {
+	inner := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	repo := repository.NewCachedUserRepository(inner, newTestCache(t), time.Minute, nil)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+	_, err = repo.GetByEmail(ctx, "ada@example.com")
+	require.NoError(t, err)
+
+	_, err = repo.Update(ctx, created.ID, &models.UpdateUserRequest{Name: "Ada Lovelace", Email: "lovelace@example.com", Age: 36})
+	require.NoError(t, err)
+
+	user, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", user.Name)
+	// The old email no longer finds the user
+	_, err = repo.GetByEmail(ctx, "ada@example.com")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	_, err = repo.GetByID(ctx, created.ID)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
@@ -0,0 +1,105 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedUserRepository counts the GetByID calls that reach the repository
+// and holds them until release is closed
+type gatedUserRepository struct {
+	*MockUserRepository
+	lookups atomic.Int32
+	release chan struct{}
+}
+
+func (r *gatedUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.lookups.Add(1)
+	<-r.release
+	return r.MockUserRepository.GetByID(ctx, id)
+}
+
+func TestUserLRU_ConcurrentMissesShareOneQuery(t *testing.T) {
+	inner := &gatedUserRepository{MockUserRepository: NewMockUserRepository(), release: make(chan struct{})}
+	repo := repository.NewLRUUserRepository(inner, repository.NewUserLRU(10, time.Minute), nil)
+	ctx := context.Background()
+	created, err := inner.Create(ctx, &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	users := make([]*models.User, 20)
+	for i := range users {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user, err := repo.GetByID(ctx, created.ID)
+			assert.NoError(t, err)
+			users[i] = user
+		}(i)
+	}
+	require.Eventually(t, func() bool { return inner.lookups.Load() == 1 }, time.Second, time.Millisecond)
+	// Let the other lookups join the query before it finishes
+	time.Sleep(20 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), inner.lookups.Load())
+	for _, user := range users {
+		require.NotNil(t, user)
+		assert.Equal(t, "Ada", user.Name)
+	}
+
+	// Later lookups are answered from memory
+	_, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), inner.lookups.Load())
+}
+
+func TestUserLRU_WritesDropEntriesOfSharedCache(t *testing.T) {
+	inner := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	lru := repository.NewUserLRU(10, time.Minute)
+	repo := repository.NewLRUUserRepository(inner, lru, nil)
+	jobRepo := repository.NewLRUUserRepository(inner, lru, nil)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+	_, err = repo.GetByEmail(ctx, "ada@example.com")
+	require.NoError(t, err)
+	lookups := inner.lookups
+
+	// A background job changes the user through the other repository
+	_, err = jobRepo.Update(ctx, created.ID, &models.UpdateUserRequest{Name: "Ada Lovelace", Email: "lovelace@example.com", Age: 36})
+	require.NoError(t, err)
+
+	user, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", user.Name)
+	assert.Equal(t, lookups+1, inner.lookups)
+	_, err = repo.GetByEmail(ctx, "ada@example.com")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestUserLRU_CallersGetCopies(t *testing.T) {
+	inner := NewMockUserRepository()
+	repo := repository.NewLRUUserRepository(inner, repository.NewUserLRU(10, time.Minute), nil)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &models.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Age: 36})
+	require.NoError(t, err)
+
+	user, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	user.Name = "Changed"
+
+	user, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", user.Name)
+}
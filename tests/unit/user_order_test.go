@@ -0,0 +1,47 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_GetAllBreaksTiesByID(t *testing.T) {
+	tests := []struct {
+		name  string
+		sort  []models.UserSort
+		order string
+	}{
+		{name: "default", order: "ORDER BY created_at DESC, id DESC"},
+		{name: "one field", sort: []models.UserSort{{Field: "name"}}, order: "ORDER BY name, id"},
+		{
+			name:  "several fields",
+			sort:  []models.UserSort{{Field: "email", Desc: true}, {Field: "created_at"}},
+			order: "ORDER BY email DESC, created_at, id",
+		},
+		{name: "already unique", sort: []models.UserSort{{Field: "id", Desc: true}}, order: "ORDER BY id DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery(regexp.QuoteMeta(tt.order)+`\s+LIMIT \$1 OFFSET \$2`).
+				WithArgs(10, 0).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "age", "role", "created_at", "updated_at", "deleted_at", "version"}))
+
+			repo := repository.NewUserRepository(db, repository.UserSchema{})
+			_, err = repo.GetAll(context.Background(), &models.UserFilter{Sort: tt.sort}, 10, 0)
+			require.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pratham15541/go-crud/internal/actor"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/events"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWatchRepository keeps watches in memory
+type MockWatchRepository struct {
+	mu      sync.Mutex
+	watches map[int64]*models.Watch
+	nextID  int64
+}
+
+func NewMockWatchRepository() *MockWatchRepository {
+	return &MockWatchRepository{watches: make(map[int64]*models.Watch), nextID: 1}
+}
+
+func (m *MockWatchRepository) Create(ctx context.Context, watch *models.Watch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	watch.ID = m.nextID
+	watch.CreatedAt = time.Now()
+	m.nextID++
+	stored := *watch
+	m.watches[watch.ID] = &stored
+	return nil
+}
+
+func (m *MockWatchRepository) GetByID(ctx context.Context, id int64) (*models.Watch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	watch, ok := m.watches[id]
+	if !ok || !watch.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.NotFound("watch not found")
+	}
+	copied := *watch
+	return &copied, nil
+}
+
+func (m *MockWatchRepository) list(match func(*models.Watch) bool) []*models.Watch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var watches []*models.Watch
+	for id := int64(1); id < m.nextID; id++ {
+		if watch, ok := m.watches[id]; ok && watch.ExpiresAt.After(time.Now()) && match(watch) {
+			copied := *watch
+			watches = append(watches, &copied)
+		}
+	}
+	return watches
+}
+
+func (m *MockWatchRepository) ListByOwner(ctx context.Context, ownerID int) ([]*models.Watch, error) {
+	return m.list(func(w *models.Watch) bool { return w.OwnerID == ownerID }), nil
+}
+
+func (m *MockWatchRepository) ListForUser(ctx context.Context, userID int) ([]*models.Watch, error) {
+	return m.list(func(w *models.Watch) bool { return int(w.UserID) == userID }), nil
+}
+
+func (m *MockWatchRepository) UpdateSnapshot(ctx context.Context, id int64, snapshot map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if watch, ok := m.watches[id]; ok {
+		watch.Snapshot = snapshot
+	}
+	return nil
+}
+
+func (m *MockWatchRepository) Delete(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.watches[id]; !ok {
+		return apperrors.NotFound("watch not found")
+	}
+	delete(m.watches, id)
+	return nil
+}
+
+func (m *MockWatchRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted int64
+	for id, watch := range m.watches {
+		if !watch.ExpiresAt.After(now) {
+			delete(m.watches, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// newWatchFixture creates a watch service over two users, 1 and 2
+func newWatchFixture(t *testing.T) (*services.WatchService, *MockUserRepository) {
+	t.Helper()
+	users := NewMockUserRepository()
+	for _, email := range []string{"ada@example.com", "grace@example.com"} {
+		_, err := users.Create(context.Background(), &models.CreateUserRequest{Name: "User", Email: email, Age: 30})
+		require.NoError(t, err)
+	}
+	cfg := config.WatchConfig{DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour, QueueSize: 10}
+	return services.NewWatchService(NewMockWatchRepository(), users, idcodec.Plain{}, cfg), users
+}
+
+// userEvent builds the event the user service publishes for user id
+func userEvent(t *testing.T, users *MockUserRepository, typ string, id int) events.Event {
+	t.Helper()
+	if typ == models.WebhookUserDeleted {
+		return events.NewEvent(typ, idcodec.Plain{}.Encode(id), map[string]idcodec.PublicID{"id": idcodec.Public(idcodec.Plain{}, id)})
+	}
+	user, err := users.GetByID(context.Background(), id)
+	require.NoError(t, err)
+	return events.NewEvent(typ, idcodec.Plain{}.Encode(id), user.ToResponse(idcodec.Plain{}))
+}
+
+func TestWatchService_CreateWatchChecksRequest(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newWatchFixture(t)
+
+	_, err := service.CreateWatch(ctx, 1, false, &models.WatchRequest{UserID: plainPublicID(2)})
+	assert.True(t, errors.Is(err, apperrors.ErrForbidden))
+
+	_, err = service.CreateWatch(ctx, 1, false, &models.WatchRequest{UserID: plainPublicID(1), Fields: []string{"password"}})
+	assert.True(t, errors.Is(err, apperrors.ErrValidation))
+
+	_, err = service.CreateWatch(ctx, 1, false, &models.WatchRequest{UserID: plainPublicID(1), TTLSeconds: 2 * 24 * 3600})
+	assert.True(t, errors.Is(err, apperrors.ErrValidation))
+
+	// Webhook delivery needs the webhook service
+	_, err = service.CreateWatch(ctx, 1, false, &models.WatchRequest{
+		UserID: plainPublicID(1), URL: "https://example.com/hook", Secret: "0123456789abcdef",
+	})
+	assert.True(t, errors.Is(err, apperrors.ErrValidation))
+
+	// The server posts to watch URLs, so only admins may set one
+	service.SetWebhooks(services.NewWebhookService(&MockWebhookRepository{}, testWebhookConfig()))
+	hook := &models.WatchRequest{UserID: plainPublicID(1), URL: "http://169.254.169.254/latest/meta-data", Secret: "0123456789abcdef"}
+	_, err = service.CreateWatch(ctx, 1, false, hook)
+	assert.True(t, errors.Is(err, apperrors.ErrForbidden))
+	_, err = service.CreateWatch(ctx, 1, true, hook)
+	require.NoError(t, err)
+
+	watch, err := service.CreateWatch(ctx, 1, true, &models.WatchRequest{UserID: plainPublicID(2), Fields: []string{"email"}})
+	require.NoError(t, err)
+	assert.Equal(t, "grace@example.com", watch.Snapshot["email"])
+	assert.WithinDuration(t, time.Now().Add(time.Hour), watch.ExpiresAt, time.Minute)
+
+	// Watches belong to their owner
+	_, err = service.GetWatch(ctx, 2, watch.ID)
+	assert.True(t, errors.Is(err, apperrors.ErrNotFound))
+	assert.True(t, errors.Is(service.DeleteWatch(ctx, 2, watch.ID), apperrors.ErrNotFound))
+	require.NoError(t, service.DeleteWatch(ctx, 1, watch.ID))
+}
+
+func TestWatchService_NotifiesChangedFields(t *testing.T) {
+	ctx := context.Background()
+	service, users := newWatchFixture(t)
+	notifications, unsubscribe := service.Subscribe(1, nil)
+	defer unsubscribe()
+
+	emailWatch, err := service.CreateWatch(ctx, 1, false, &models.WatchRequest{UserID: plainPublicID(1), Fields: []string{"email"}})
+	require.NoError(t, err)
+
+	// A change to a field the watch does not follow is ignored
+	_, err = users.Update(ctx, 1, &models.UpdateUserRequest{Name: "Ada Lovelace"})
+	require.NoError(t, err)
+	require.NoError(t, service.Evaluate(ctx, userEvent(t, users, models.WebhookUserUpdated, 1)))
+	assert.Empty(t, notifications)
+
+	_, err = users.Update(ctx, 1, &models.UpdateUserRequest{Email: "ada@lovelace.dev"})
+	require.NoError(t, err)
+	event := userEvent(t, users, models.WebhookUserUpdated, 1)
+	require.NoError(t, service.Evaluate(ctx, event))
+	require.Len(t, notifications, 1)
+	notification := <-notifications
+	assert.Equal(t, emailWatch.ID, notification.WatchID)
+	assert.Equal(t, event.ID, notification.EventID)
+	assert.Equal(t, map[string]models.WatchChange{
+		"email": {Old: "ada@example.com", New: "ada@lovelace.dev"},
+	}, notification.Changes)
+
+	// The snapshot moved on, so the same event does not notify again
+	require.NoError(t, service.Evaluate(ctx, event))
+	assert.Empty(t, notifications)
+
+	require.NoError(t, service.Evaluate(ctx, userEvent(t, users, models.WebhookUserDeleted, 1)))
+	require.Len(t, notifications, 1)
+	notification = <-notifications
+	assert.Equal(t, models.WebhookUserDeleted, notification.Event)
+	assert.Empty(t, notification.Changes)
+}
+
+func TestEventTee_PublishesAcceptedEventsToBus(t *testing.T) {
+	bus := events.NewBus()
+	var seen []string
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		seen = append(seen, event.ID)
+	})
+
+	backend := &recordingPublisher{}
+	publisher := events.Tee(backend, bus)
+	accepted := events.NewEvent(models.WebhookUserUpdated, "1", nil)
+	require.NoError(t, publisher.Publish(context.Background(), accepted))
+
+	backend.err = errors.New("broker down")
+	rejected := events.NewEvent(models.WebhookUserUpdated, "1", nil)
+	assert.Error(t, publisher.Publish(context.Background(), rejected))
+	assert.Equal(t, []string{accepted.ID}, seen)
+}
+
+// recordingPublisher is an event backend failing with err
+type recordingPublisher struct {
+	err error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) error { return p.err }
+func (p *recordingPublisher) Close() error                                          { return nil }
+
+func TestWatchHandler_StreamsNotifications(t *testing.T) {
+	service, users := newWatchFixture(t)
+	_, err := service.CreateWatch(context.Background(), 1, false, &models.WatchRequest{UserID: plainPublicID(1)})
+	require.NoError(t, err)
+
+	handler := handlers.NewWatchHandler(service, idcodec.Plain{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.Stream(w, r.WithContext(actor.NewContext(r.Context(), actor.Actor{UserID: "1"})))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": watching\n", line)
+
+	_, err = users.Update(context.Background(), 1, &models.UpdateUserRequest{Age: 31})
+	require.NoError(t, err)
+	require.NoError(t, service.Evaluate(context.Background(), userEvent(t, users, models.WebhookUserUpdated, 1)))
+
+	var frame []string
+	for len(frame) < 3 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			frame = append(frame, line)
+		}
+	}
+	assert.True(t, strings.HasPrefix(frame[0], "id: "))
+	assert.Equal(t, "event: watch.triggered", frame[1])
+	assert.Contains(t, frame[2], `"age":{"old":30,"new":31}`)
+}
+
+func TestWatchService_ShutdownEndsStreams(t *testing.T) {
+	service, _ := newWatchFixture(t)
+	handler := handlers.NewWatchHandler(service, idcodec.Plain{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.Stream(w, r.WithContext(actor.NewContext(r.Context(), actor.Actor{UserID: "1"})))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": watching\n", line)
+
+	// A stream nobody reads holds on until it is cut
+	_, stuck := service.Subscribe(2, nil)
+	defer stuck()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	notified, forced := service.Shutdown(ctx)
+	assert.Equal(t, 2, notified)
+	assert.Equal(t, 1, forced)
+
+	// The client is told to reconnect and the response ends
+	rest, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "event: reconnect\ndata: server shutting down, reconnect", strings.TrimSpace(string(rest)))
+
+	// Streams opened during shutdown end at once
+	notifications, unsubscribe := service.Subscribe(1, nil)
+	defer unsubscribe()
+	_, open := <-notifications
+	assert.False(t, open)
+}
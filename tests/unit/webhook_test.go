@@ -0,0 +1,371 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pratham15541/go-crud/internal/apperrors"
+	"github.com/pratham15541/go-crud/internal/config"
+	"github.com/pratham15541/go-crud/internal/handlers"
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/router"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWebhookRepository implements WebhookRepository interface for testing
+type MockWebhookRepository struct {
+	mu       sync.Mutex
+	webhooks []*models.Webhook
+	failures []*models.WebhookFailure
+}
+
+func (m *MockWebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	webhook.ID = int64(len(m.webhooks) + 1)
+	webhook.CreatedAt = time.Now()
+	webhook.UpdatedAt = webhook.CreatedAt
+	stored := *webhook
+	m.webhooks = append(m.webhooks, &stored)
+	return nil
+}
+
+func (m *MockWebhookRepository) GetByID(ctx context.Context, id int64) (*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, webhook := range m.webhooks {
+		if webhook.ID == id {
+			found := *webhook
+			return &found, nil
+		}
+	}
+	return nil, apperrors.NotFound("webhook not found")
+}
+
+func (m *MockWebhookRepository) List(ctx context.Context) ([]*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.Webhook(nil), m.webhooks...), nil
+}
+
+func (m *MockWebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, stored := range m.webhooks {
+		if stored.ID == webhook.ID {
+			webhook.CreatedAt = stored.CreatedAt
+			webhook.UpdatedAt = time.Now()
+			updated := *webhook
+			m.webhooks[i] = &updated
+			return nil
+		}
+	}
+	return apperrors.NotFound("webhook not found")
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, webhook := range m.webhooks {
+		if webhook.ID == id {
+			m.webhooks = append(m.webhooks[:i], m.webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return apperrors.NotFound("webhook not found")
+}
+
+func (m *MockWebhookRepository) ListForEvent(ctx context.Context, event string) ([]*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var subscribed []*models.Webhook
+	for _, webhook := range m.webhooks {
+		for _, e := range webhook.Events {
+			if e == event {
+				subscribed = append(subscribed, webhook)
+				break
+			}
+		}
+	}
+	return subscribed, nil
+}
+
+func (m *MockWebhookRepository) RecordFailure(ctx context.Context, failure *models.WebhookFailure) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	failure.ID = 1
+	if n := len(m.failures); n > 0 {
+		failure.ID = m.failures[n-1].ID + 1
+	}
+	failure.FailedAt = time.Now()
+	stored := *failure
+	m.failures = append(m.failures, &stored)
+	return nil
+}
+
+func (m *MockWebhookRepository) ListFailures(ctx context.Context, afterID int64, limit int) ([]*models.WebhookFailure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var failures []*models.WebhookFailure
+	for _, failure := range m.failures {
+		if failure.ID > afterID && len(failures) < limit {
+			found := *failure
+			failures = append(failures, &found)
+		}
+	}
+	return failures, nil
+}
+
+func (m *MockWebhookRepository) DeleteFailure(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, failure := range m.failures {
+		if failure.ID == id {
+			m.failures = append(m.failures[:i], m.failures[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// recordedFailures returns the failed deliveries stored so far
+func (m *MockWebhookRepository) recordedFailures() []*models.WebhookFailure {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.WebhookFailure(nil), m.failures...)
+}
+
+// testWebhookConfig retries quickly so tests do not wait on backoff
+func testWebhookConfig() config.WebhookConfig {
+	return config.WebhookConfig{
+		Enabled:      true,
+		Workers:      2,
+		QueueSize:    10,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+	}
+}
+
+// webhookReceiver records the deliveries it gets, failing the first
+// failures of them
+type webhookReceiver struct {
+	mu         sync.Mutex
+	failures   int
+	requests   []*http.Request
+	bodies     [][]byte
+	deliveries chan struct{}
+}
+
+func newWebhookReceiver(t *testing.T, failures int) (*webhookReceiver, *httptest.Server) {
+	receiver := &webhookReceiver{failures: failures, deliveries: make(chan struct{}, 10)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		receiver.requests = append(receiver.requests, r)
+		receiver.bodies = append(receiver.bodies, body)
+		fail := len(receiver.requests) <= receiver.failures
+		receiver.mu.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		receiver.deliveries <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return receiver, server
+}
+
+// wait waits for n more deliveries
+func (r *webhookReceiver) wait(t *testing.T, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.deliveries:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for webhook delivery %d of %d", i+1, n)
+		}
+	}
+}
+
+// runWebhooks starts delivery until the test ends
+func runWebhooks(t *testing.T, webhookService *services.WebhookService) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		webhookService.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestWebhookService_DeliversSignedEvents(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, 0)
+	webhookService := services.NewWebhookService(&MockWebhookRepository{}, testWebhookConfig())
+	_, err := webhookService.CreateWebhook(context.Background(), &models.WebhookRequest{
+		URL:    server.URL,
+		Secret: "0123456789abcdef",
+		Events: []string{models.WebhookUserCreated, models.WebhookUserDeleted},
+	})
+	require.NoError(t, err)
+	runWebhooks(t, webhookService)
+
+	userService := services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{})
+	userService.SetWebhooks(webhookService)
+	user, err := userService.CreateUser(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	receiver.wait(t, 1)
+
+	receiver.mu.Lock()
+	req, body := receiver.requests[0], receiver.bodies[0]
+	receiver.mu.Unlock()
+	assert.Equal(t, models.WebhookUserCreated, req.Header.Get("X-Webhook-Event"))
+	timestamp, err := strconv.ParseInt(req.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, services.SignWebhook("0123456789abcdef", timestamp, body), req.Header.Get("X-Webhook-Signature"))
+
+	var payload struct {
+		ID   string              `json:"id"`
+		Type string              `json:"type"`
+		Data models.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, req.Header.Get("X-Webhook-ID"), payload.ID)
+	assert.Equal(t, models.WebhookUserCreated, payload.Type)
+	assert.Equal(t, "john@example.com", payload.Data.Email)
+
+	// Not subscribed to updates
+	_, err = userService.UpdateUser(context.Background(), user.ID, &models.UpdateUserRequest{Name: "Jane Doe"})
+	require.NoError(t, err)
+	require.NoError(t, userService.DeleteUser(context.Background(), user.ID))
+	receiver.wait(t, 1)
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	require.Len(t, receiver.requests, 2)
+	assert.Equal(t, models.WebhookUserDeleted, receiver.requests[1].Header.Get("X-Webhook-Event"))
+	assert.Contains(t, string(receiver.bodies[1]), `"data":{"id":`)
+}
+
+func TestWebhookService_RetriesFailedDeliveries(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, 2)
+	webhookService := services.NewWebhookService(&MockWebhookRepository{}, testWebhookConfig())
+	_, err := webhookService.CreateWebhook(context.Background(), &models.WebhookRequest{
+		URL:    server.URL,
+		Secret: "0123456789abcdef",
+		Events: []string{models.WebhookUserCreated},
+	})
+	require.NoError(t, err)
+	runWebhooks(t, webhookService)
+
+	webhookService.Publish(context.Background(), models.WebhookUserCreated, map[string]string{"id": "1"})
+	receiver.wait(t, 3)
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	// Retries carry the same event ID, so consumers can deduplicate
+	assert.Equal(t, receiver.requests[0].Header.Get("X-Webhook-ID"), receiver.requests[2].Header.Get("X-Webhook-ID"))
+}
+
+func TestWebhookService_GivesUpAfterMaxAttempts(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, 10)
+	webhookService := services.NewWebhookService(&MockWebhookRepository{}, testWebhookConfig())
+	_, err := webhookService.CreateWebhook(context.Background(), &models.WebhookRequest{
+		URL:    server.URL,
+		Secret: "0123456789abcdef",
+		Events: []string{models.WebhookUserCreated},
+	})
+	require.NoError(t, err)
+	runWebhooks(t, webhookService)
+
+	webhookService.Publish(context.Background(), models.WebhookUserCreated, map[string]string{"id": "1"})
+	receiver.wait(t, 3)
+
+	select {
+	case <-receiver.deliveries:
+		t.Fatal("delivery retried after the last attempt")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookService_ValidatesRequests(t *testing.T) {
+	webhookService := services.NewWebhookService(&MockWebhookRepository{}, testWebhookConfig())
+
+	tests := []struct {
+		name string
+		req  models.WebhookRequest
+	}{
+		{"short secret", models.WebhookRequest{URL: "https://example.com/hook", Secret: "short", Events: []string{models.WebhookUserCreated}}},
+		{"no events", models.WebhookRequest{URL: "https://example.com/hook", Secret: "0123456789abcdef"}},
+		{"unknown event", models.WebhookRequest{URL: "https://example.com/hook", Secret: "0123456789abcdef", Events: []string{"user.renamed"}}},
+		{"not http", models.WebhookRequest{URL: "ftp://example.com/hook", Secret: "0123456789abcdef", Events: []string{models.WebhookUserCreated}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := webhookService.CreateWebhook(context.Background(), &tt.req)
+			assert.ErrorIs(t, err, apperrors.ErrValidation)
+		})
+	}
+}
+
+func TestWebhookHandler_CRUDForAdmins(t *testing.T) {
+	cfg := config.Load()
+	webhookService := services.NewWebhookService(&MockWebhookRepository{}, testWebhookConfig())
+	handler := router.New(router.Dependencies{
+		Config:        cfg,
+		UserHandler:   handlers.NewUserHandler(services.NewUserService(NewMockUserRepository(), services.DefaultPagination(), idcodec.Plain{}), idcodec.Plain{}),
+		HealthHandler: handlers.NewHealthHandler(nil, nil),
+		Webhooks:      handlers.NewWebhookHandler(webhookService),
+	}).Handler()
+	admin := bearer(t, cfg, jwt.MapClaims{"sub": "7", "role": "admin"})
+
+	send := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	body := `{"url":"https://example.com/hook","secret":"0123456789abcdef","events":["user.created"]}`
+	rr := send("POST", "/api/v1/webhooks", body, bearer(t, cfg, jwt.MapClaims{"sub": "7"}))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = send("POST", "/api/v1/webhooks", body, admin)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "0123456789abcdef")
+
+	rr = send("PUT", "/api/v1/webhooks/1", `{"url":"https://example.com/other","secret":"0123456789abcdef","events":["user.updated"]}`, admin)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = send("GET", "/api/v1/webhooks/1", "", admin)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"events":["user.updated"]`)
+
+	rr = send("GET", "/api/v1/webhooks", "", admin)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "https://example.com/other")
+
+	rr = send("POST", "/api/v1/webhooks", `{"url":"https://example.com/hook","secret":"short","events":["user.created"]}`, admin)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = send("DELETE", "/api/v1/webhooks/1", "", admin)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = send("GET", "/api/v1/webhooks/1", "", admin)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/pratham15541/go-crud/internal/idcodec"
+	"github.com/pratham15541/go-crud/internal/models"
+	"github.com/pratham15541/go-crud/internal/repository"
+	"github.com/pratham15541/go-crud/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outageUserRepository fails creates with a connection error while down
+type outageUserRepository struct {
+	*MockUserRepository
+	down bool
+}
+
+func (r *outageUserRepository) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	if r.down {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: assert.AnError}
+	}
+	return r.MockUserRepository.Create(ctx, req)
+}
+
+// primaryStatus is a PrimaryHealthChecker with a fixed answer
+type primaryStatus bool
+
+func (p primaryStatus) PrimaryHealthy() bool {
+	return bool(p)
+}
+
+func newTestWriteAheadService(t *testing.T, userRepo repository.UserRepository, healthy bool) *services.WriteAheadService {
+	queue, err := repository.NewFileWriteAheadRepository(t.TempDir())
+	require.NoError(t, err)
+	userService := services.NewUserService(userRepo, services.DefaultPagination(), idcodec.Plain{})
+	return services.NewWriteAheadService(queue, userService, userService, primaryStatus(healthy), 0)
+}
+
+func TestWriteAhead_CreatesDirectlyWhenHealthy(t *testing.T) {
+	svc := newTestWriteAheadService(t, NewMockUserRepository(), true)
+
+	user, queued, err := svc.CreateUser(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	assert.Nil(t, queued)
+	assert.Equal(t, "john@example.com", user.Email)
+}
+
+func TestWriteAhead_QueuesDuringOutageAndReconciles(t *testing.T) {
+	userRepo := &outageUserRepository{MockUserRepository: NewMockUserRepository(), down: true}
+	svc := newTestWriteAheadService(t, userRepo, true)
+
+	user, queued, err := svc.CreateUser(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	assert.Nil(t, user)
+	require.NotNil(t, queued)
+	assert.Equal(t, models.QueuedWritePending, queued.Status)
+
+	// Still down: the write stays pending
+	assert.Error(t, svc.Reconcile(context.Background()))
+	pending, _ := svc.GetQueuedWrite(context.Background(), queued.ID)
+	assert.Equal(t, models.QueuedWritePending, pending.Status)
+	assert.Equal(t, 1, pending.Attempts)
+
+	userRepo.down = false
+	require.NoError(t, svc.Reconcile(context.Background()))
+
+	applied, err := svc.GetQueuedWrite(context.Background(), queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.QueuedWriteApplied, applied.Status)
+	require.NotNil(t, applied.UserID)
+
+	created, err := userRepo.GetByID(context.Background(), *applied.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", created.Email)
+}
+
+func TestWriteAhead_RecordsFailuresAndValidatesUpFront(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userRepo.Create(context.Background(), &models.CreateUserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 30})
+	svc := newTestWriteAheadService(t, userRepo, false)
+
+	_, _, err := svc.CreateUser(context.Background(), &models.CreateUserRequest{Name: "J", Email: "bad", Age: 0})
+	assert.Error(t, err, "invalid requests are rejected instead of queued")
+
+	_, queued, err := svc.CreateUser(context.Background(), &models.CreateUserRequest{Name: "Jane Again", Email: "jane@example.com", Age: 31})
+	require.NoError(t, err)
+	require.NoError(t, svc.Reconcile(context.Background()))
+
+	failed, _ := svc.GetQueuedWrite(context.Background(), queued.ID)
+	assert.Equal(t, models.QueuedWriteFailed, failed.Status)
+	assert.Contains(t, failed.Error, "already exists")
+}
+
+func TestWriteAhead_ReconcilesThroughJobService(t *testing.T) {
+	queue, err := repository.NewFileWriteAheadRepository(t.TempDir())
+	require.NoError(t, err)
+	requestRepo := NewMockUserRepository()
+	jobRepo := NewMockUserRepository()
+	svc := services.NewWriteAheadService(queue, services.NewUserService(requestRepo, services.DefaultPagination(), idcodec.Plain{}),
+		services.NewUserService(jobRepo, services.DefaultPagination(), idcodec.Plain{}), primaryStatus(false), 0)
+
+	_, queued, err := svc.CreateUser(context.Background(), &models.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	require.NoError(t, svc.Reconcile(context.Background()))
+
+	applied, _ := svc.GetQueuedWrite(context.Background(), queued.ID)
+	require.NotNil(t, applied.UserID)
+	_, err = jobRepo.GetByID(context.Background(), *applied.UserID)
+	assert.NoError(t, err)
+	assert.Empty(t, requestRepo.users)
+}
+
+func TestFileWriteAheadRepository_RejectsInvalidIDs(t *testing.T) {
+	queue, err := repository.NewFileWriteAheadRepository(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = queue.Get(context.Background(), "../../etc/passwd")
+	assert.Error(t, err)
+}